@@ -0,0 +1,45 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package backofftest helps test code that backs off and retries, like retry
+// middleware, without depending on the passage of time.
+//
+// A Recorder is a backoff.Strategy that records the attempts it is asked
+// about and the durations it answers with, either scripted or computed by
+// another strategy without waiting for them. Run sends a request through
+// outbound middleware to an outbound that answers with scripted results, so
+// that retry policies can be tested table-driven:
+//
+// 	tests := []struct {
+// 		results      []error
+// 		wantAttempts int
+// 		wantBackoffs []uint
+// 	}{
+// 		{results: []error{unavailable, nil}, wantAttempts: 2, wantBackoffs: []uint{0}},
+// 		{results: []error{invalidArgument}, wantAttempts: 1},
+// 	}
+// 	for _, tt := range tests {
+// 		recorder := backofftest.Durations(time.Millisecond)
+// 		mw := retry.New(retry.Backoff(recorder))
+// 		outcome := backofftest.Run(ctx, mw, req, tt.results...)
+// 		assert.Equal(t, tt.wantAttempts, outcome.Attempts)
+// 		backofftest.AssertAttempts(t, recorder, tt.wantBackoffs...)
+// 	}
+package backofftest
@@ -0,0 +1,144 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backofftest
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/yarpc/api/backoff"
+)
+
+var _ backoff.Strategy = (*Recorder)(nil)
+
+// Recorder is a backoff.Strategy that records the attempts it is asked
+// about and the durations it requests for them. All backoffs of a Recorder
+// share its record.
+type Recorder struct {
+	duration func(attempts uint) time.Duration
+	wait     bool
+
+	mu        sync.Mutex
+	attempts  []uint
+	durations []time.Duration
+}
+
+// Durations returns a Recorder that requests the given durations for the
+// first, second and following attempts in turn, repeating the last one
+// once they run out, and zero if none are given.
+func Durations(durations ...time.Duration) *Recorder {
+	return &Recorder{
+		duration: func(attempts uint) time.Duration {
+			if len(durations) == 0 {
+				return 0
+			}
+			if int(attempts) >= len(durations) {
+				return durations[len(durations)-1]
+			}
+			return durations[attempts]
+		},
+		wait: true,
+	}
+}
+
+// Instant returns a Recorder that records the durations the given strategy
+// requests, but doesn't make callers wait for them: its backoffs always
+// return zero. This tests policies with their real strategies, including
+// randomized ones, without slowing tests down.
+func Instant(strategy backoff.Strategy) *Recorder {
+	b := strategy.Backoff()
+	var mu sync.Mutex
+	return &Recorder{
+		duration: func(attempts uint) time.Duration {
+			// Backoffs need not be safe for concurrent use.
+			mu.Lock()
+			defer mu.Unlock()
+			return b.Duration(attempts)
+		},
+	}
+}
+
+// Backoff implements backoff.Strategy.
+func (r *Recorder) Backoff() backoff.Backoff {
+	return r
+}
+
+// Duration implements backoff.Backoff, recording the attempt and the
+// duration requested for it.
+func (r *Recorder) Duration(attempts uint) time.Duration {
+	d := r.duration(attempts)
+
+	r.mu.Lock()
+	r.attempts = append(r.attempts, attempts)
+	r.durations = append(r.durations, d)
+	r.mu.Unlock()
+
+	if !r.wait {
+		return 0
+	}
+	return d
+}
+
+// Attempts returns the attempts the Recorder was asked about, in order.
+func (r *Recorder) Attempts() []uint {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]uint(nil), r.attempts...)
+}
+
+// Requested returns the durations the Recorder requested, in order.
+func (r *Recorder) Requested() []time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]time.Duration(nil), r.durations...)
+}
+
+// Reset forgets the recorded attempts and durations.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts = nil
+	r.durations = nil
+}
+
+// AssertAttempts fails the test unless the Recorder was asked about exactly
+// the given attempts, in order.
+func AssertAttempts(t testing.TB, r *Recorder, want ...uint) bool {
+	got := r.Attempts()
+	if len(got) == 0 && len(want) == 0 || reflect.DeepEqual(got, want) {
+		return true
+	}
+	t.Errorf("expected backoffs for attempts %v, got %v", want, got)
+	return false
+}
+
+// AssertRequested fails the test unless the Recorder requested exactly the
+// given durations, in order.
+func AssertRequested(t testing.TB, r *Recorder, want ...time.Duration) bool {
+	got := r.Requested()
+	if len(got) == 0 && len(want) == 0 || reflect.DeepEqual(got, want) {
+		return true
+	}
+	t.Errorf("expected backoff durations %v, got %v", want, got)
+	return false
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backofftest
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/backoff"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+func TestDurations(t *testing.T) {
+	r := Durations(time.Millisecond, time.Second)
+	b := r.Backoff()
+	assert.Equal(t, time.Millisecond, b.Duration(0))
+	assert.Equal(t, time.Second, b.Duration(1))
+	assert.Equal(t, time.Second, b.Duration(5), "expected the last duration to repeat")
+
+	AssertAttempts(t, r, 0, 1, 5)
+	AssertRequested(t, r, time.Millisecond, time.Second, time.Second)
+
+	r.Reset()
+	AssertAttempts(t, r)
+	assert.Equal(t, time.Duration(0), Durations().Backoff().Duration(3))
+}
+
+type linear time.Duration
+
+func (l linear) Backoff() backoff.Backoff { return l }
+func (l linear) Duration(attempts uint) time.Duration {
+	return time.Duration(attempts+1) * time.Duration(l)
+}
+
+func TestInstant(t *testing.T) {
+	r := Instant(linear(time.Hour))
+	b := r.Backoff()
+	assert.Zero(t, b.Duration(0), "expected no wait")
+	assert.Zero(t, b.Duration(1), "expected no wait")
+	AssertRequested(t, r, time.Hour, 2*time.Hour)
+}
+
+func TestAssertionsFail(t *testing.T) {
+	r := Durations(time.Second)
+	r.Backoff().Duration(0)
+
+	ft := &fakeT{}
+	assert.False(t, AssertAttempts(ft, r, 1))
+	assert.False(t, AssertRequested(ft, r, time.Minute))
+	assert.Len(t, ft.errors, 2)
+}
+
+type fakeT struct {
+	testing.TB
+
+	errors []string
+}
+
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, format)
+}
+
+// retryOnce is outbound middleware that retries failed calls once after
+// the backoff of its strategy.
+type retryOnce struct {
+	backoff backoff.Strategy
+}
+
+func (r retryOnce) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	res, err := out.Call(ctx, req)
+	if err == nil {
+		return res, nil
+	}
+	time.Sleep(r.backoff.Backoff().Duration(0))
+	req.Body = bytes.NewReader([]byte("retried"))
+	return out.Call(ctx, req)
+}
+
+var _ middleware.UnaryOutbound = retryOnce{}
+
+func TestRun(t *testing.T) {
+	unavailable := yarpcerrors.UnavailableErrorf("unavailable")
+	req := &transport.Request{Procedure: "proc", Body: bytes.NewReader([]byte("body"))}
+
+	r := Durations(0)
+	outcome := Run(context.Background(), retryOnce{r}, req, unavailable, nil)
+	require.NoError(t, outcome.Err)
+	assert.Equal(t, 2, outcome.Attempts)
+	body, err := ioutil.ReadAll(outcome.Requests[1].Body)
+	require.NoError(t, err)
+	assert.Equal(t, "retried", string(body))
+	AssertAttempts(t, r, 0)
+
+	outcome = Run(context.Background(), retryOnce{Durations(0)}, req, unavailable)
+	assert.Equal(t, 2, outcome.Attempts)
+	assert.True(t, yarpcerrors.IsUnimplemented(outcome.Err), "expected unscripted attempt to fail")
+}
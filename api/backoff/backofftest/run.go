@@ -0,0 +1,66 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backofftest
+
+import (
+	"context"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+)
+
+// Outcome is the outcome of a request sent with Run.
+type Outcome struct {
+	// Response and Err are what the middleware returned.
+	Response *transport.Response
+	Err      error
+
+	// Attempts is the number of times the request reached the outbound.
+	Attempts int
+
+	// Requests holds the requests that reached the outbound, in order.
+	// Their bodies may be read.
+	Requests []*transport.Request
+}
+
+// Run sends the request through the middleware to an outbound that answers
+// the request's procedure with the given results in turn: each attempt fails
+// with the next error, or succeeds with an empty response if it's nil.
+// Attempts beyond the given results fail with Unimplemented errors.
+func Run(ctx context.Context, mw middleware.UnaryOutbound, req *transport.Request, results ...error) Outcome {
+	out := transporttest.NewFakeOutbound()
+	for _, err := range results {
+		call := out.ExpectCall(req.Procedure)
+		if err != nil {
+			call.Return(nil, err)
+		}
+	}
+
+	res, err := mw.Call(ctx, req, out)
+	calls := out.Calls()
+	return Outcome{
+		Response: res,
+		Err:      err,
+		Attempts: len(calls),
+		Requests: calls,
+	}
+}
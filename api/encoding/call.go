@@ -22,7 +22,9 @@ package encoding
 
 import (
 	"context"
+	"crypto/tls"
 	"sort"
+	"time"
 
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/yarpcerrors"
@@ -136,6 +138,71 @@ func (c *Call) RoutingKey() string {
 	return c.ic.req.RoutingKey
 }
 
+// Transport returns the name of the transport that received this request.
+func (c *Call) Transport() string {
+	if c == nil {
+		return ""
+	}
+	return c.ic.req.Transport
+}
+
+// TransportMetadata returns metadata about how the transport received this
+// request, or nil if the inbound did not record it, as for streaming calls.
+// The peer that sent the request is reported by Info.
+func (c *Call) TransportMetadata() *transport.Metadata {
+	if c == nil {
+		return nil
+	}
+	return c.ic.req.Metadata
+}
+
+// CallInfo describes the current request inside handlers, independent of
+// the encoding and transport of the request.
+type CallInfo struct {
+	Caller          string
+	Service         string
+	Procedure       string
+	Encoding        transport.Encoding
+	Transport       string
+	ShardKey        string
+	RoutingKey      string
+	RoutingDelegate string
+
+	// RemoteAddress is the network address of the peer that sent the
+	// request, if the inbound recorded it.
+	RemoteAddress string
+
+	// TLS is the state of the TLS connection with the peer that sent the
+	// request, including the certificates it presented, or nil if the
+	// request was not received over TLS.
+	TLS *tls.ConnectionState
+
+	// Deadline is the time by which the request must be handled, or the zero
+	// time if the request has no deadline.
+	Deadline time.Time
+}
+
+// Info returns the information about this request in a single struct.
+func (c *Call) Info() CallInfo {
+	if c == nil {
+		return CallInfo{}
+	}
+	req := c.ic.req
+	return CallInfo{
+		Caller:          req.Caller,
+		Service:         req.Service,
+		Procedure:       req.Procedure,
+		Encoding:        req.Encoding,
+		Transport:       req.Transport,
+		ShardKey:        req.ShardKey,
+		RoutingKey:      req.RoutingKey,
+		RoutingDelegate: req.RoutingDelegate,
+		RemoteAddress:   c.ic.remotePeer.Address,
+		TLS:             c.ic.remotePeer.TLS,
+		Deadline:        c.ic.deadline,
+	}
+}
+
 // RoutingDelegate returns the routing delegate for this request.
 func (c *Call) RoutingDelegate() string {
 	if c == nil {
@@ -20,6 +20,8 @@
 
 package encoding
 
+import "go.uber.org/yarpc/api/transport"
+
 // CallOption defines options that may be passed in at call sites to other
 // services.
 //
@@ -55,3 +57,15 @@ func WithRoutingKey(rk string) CallOption {
 func WithRoutingDelegate(rd string) CallOption {
 	return CallOption{func(o *OutboundCall) { o.routingDelegate = &rd }}
 }
+
+// WithPeer sends the request to the peer with the given identifier instead of
+// letting the outbound's peer chooser select one.
+func WithPeer(id string) CallOption {
+	return CallOption{func(o *OutboundCall) { o.peer = &id }}
+}
+
+// OutboundInfo specifies that outbounds should record how the request was
+// sent in the given OutboundCallInfo.
+func OutboundInfo(info *transport.OutboundCallInfo) CallOption {
+	return CallOption{func(o *OutboundCall) { o.outboundInfo = info }}
+}
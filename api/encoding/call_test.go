@@ -22,7 +22,9 @@ package encoding
 
 import (
 	"context"
+	"crypto/tls"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -40,6 +42,8 @@ func TestNilCall(t *testing.T) {
 	assert.Equal(t, "", call.ShardKey())
 	assert.Equal(t, "", call.RoutingKey())
 	assert.Equal(t, "", call.RoutingDelegate())
+	assert.Equal(t, "", call.Transport())
+	assert.Equal(t, CallInfo{}, call.Info())
 	assert.Equal(t, "", call.Header("foo"))
 	assert.Empty(t, call.HeaderNames())
 
@@ -76,6 +80,60 @@ func TestReadFromRequest(t *testing.T) {
 	assert.Equal(t, icall.resHeaders[0].v, "bar2")
 }
 
+func TestCallInfo(t *testing.T) {
+	deadline := time.Now().Add(time.Second)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	state := &tls.ConnectionState{ServerName: "service"}
+	ctx = transport.WithRemotePeer(ctx, transport.RemotePeer{Address: "1.2.3.4:5678", TLS: state})
+
+	ctx, icall := NewInboundCall(ctx)
+	icall.ReadFromRequest(&transport.Request{
+		Service:         "service",
+		Caller:          "caller",
+		Encoding:        transport.Encoding("raw"),
+		Transport:       "http",
+		Procedure:       "proc",
+		ShardKey:        "sk",
+		RoutingKey:      "rk",
+		RoutingDelegate: "rd",
+	})
+	call := CallFromContext(ctx)
+	require.NotNil(t, call)
+
+	assert.Equal(t, "http", call.Transport())
+	assert.Equal(t, CallInfo{
+		Caller:          "caller",
+		Service:         "service",
+		Procedure:       "proc",
+		Encoding:        transport.Encoding("raw"),
+		Transport:       "http",
+		ShardKey:        "sk",
+		RoutingKey:      "rk",
+		RoutingDelegate: "rd",
+		RemoteAddress:   "1.2.3.4:5678",
+		TLS:             state,
+		Deadline:        deadline,
+	}, call.Info())
+}
+
+func TestCallTransportMetadata(t *testing.T) {
+	var nilCall *Call
+	assert.Nil(t, nilCall.TransportMetadata())
+
+	meta := &transport.Metadata{
+		GRPC: &transport.GRPCMetadata{Method: "/service/proc"},
+	}
+	ctx, icall := NewInboundCall(context.Background())
+	icall.ReadFromRequest(&transport.Request{Procedure: "proc", Metadata: meta})
+	assert.Equal(t, meta, CallFromContext(ctx).TransportMetadata())
+
+	// Metadata describes inbound requests only and does not survive
+	// conversion to RequestMeta.
+	icall.ReadFromRequestMeta((&transport.Request{Metadata: meta}).ToRequestMeta())
+	assert.Nil(t, CallFromContext(ctx).TransportMetadata())
+}
+
 func TestReadFromRequestMeta(t *testing.T) {
 	ctx, icall := NewInboundCall(context.Background())
 	icall.ReadFromRequestMeta(&transport.RequestMeta{
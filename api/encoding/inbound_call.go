@@ -22,6 +22,7 @@ package encoding
 
 import (
 	"context"
+	"time"
 
 	"go.uber.org/yarpc/api/transport"
 )
@@ -35,6 +36,10 @@ type InboundCall struct {
 	resHeaders             []keyValuePair
 	req                    *transport.Request
 	disableResponseHeaders bool
+
+	// Recorded from the request context when the call is built.
+	remotePeer transport.RemotePeer
+	deadline   time.Time
 }
 
 type inboundCallKey struct{} // context key for *InboundCall
@@ -71,6 +76,8 @@ func NewInboundCallWithOptions(ctx context.Context, opts ...InboundCallOption) (
 	for _, opt := range opts {
 		opt.apply(call)
 	}
+	call.remotePeer, _ = transport.RemotePeerFromContext(ctx)
+	call.deadline, _ = ctx.Deadline()
 	return context.WithValue(ctx, inboundCallKey{}, call), call
 }
 
@@ -23,8 +23,8 @@ package encoding
 import (
 	"context"
 
+	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
-	"go.uber.org/yarpc/yarpcerrors"
 )
 
 // OutboundCall is an outgoing call. It holds per-call options for a request.
@@ -38,6 +38,8 @@ type OutboundCall struct {
 	shardKey        *string
 	routingKey      *string
 	routingDelegate *string
+	peer            *string
+	outboundInfo    *transport.OutboundCallInfo
 
 	// If non-nil, response headers should be written here.
 	responseHeaders *map[string]string
@@ -54,12 +56,10 @@ func NewOutboundCall(options ...CallOption) *OutboundCall {
 
 // NewStreamOutboundCall constructs a new OutboundCall with the given
 // options and enforces the OutboundCall is valid for streams.
+//
+// Response headers requested on a stream are filled by ReadFromStream.
 func NewStreamOutboundCall(options ...CallOption) (*OutboundCall, error) {
-	call := NewOutboundCall(options...)
-	if call.responseHeaders != nil {
-		return nil, yarpcerrors.InvalidArgumentErrorf("response headers are not supported for streams")
-	}
-	return call, nil
+	return NewOutboundCall(options...), nil
 }
 
 // WriteToRequest fills the given request with request-specific options from
@@ -80,6 +80,12 @@ func (c *OutboundCall) WriteToRequest(ctx context.Context, req *transport.Reques
 	if c.routingDelegate != nil {
 		req.RoutingDelegate = *c.routingDelegate
 	}
+	if c.peer != nil {
+		ctx = peer.WithSelectedPeer(ctx, *c.peer)
+	}
+	if c.outboundInfo != nil {
+		ctx = transport.WithOutboundCallInfo(ctx, c.outboundInfo)
+	}
 
 	// NB(abg): context and error are unused for now but we want to leave room
 	// for CallOptions which can fail or modify the context.
@@ -104,6 +110,12 @@ func (c *OutboundCall) WriteToRequestMeta(ctx context.Context, reqMeta *transpor
 	if c.routingDelegate != nil {
 		reqMeta.RoutingDelegate = *c.routingDelegate
 	}
+	if c.peer != nil {
+		ctx = peer.WithSelectedPeer(ctx, *c.peer)
+	}
+	if c.outboundInfo != nil {
+		ctx = transport.WithOutboundCallInfo(ctx, c.outboundInfo)
+	}
 
 	// NB(abg): context and error are unused for now but we want to leave room
 	// for CallOptions which can fail or modify the context.
@@ -115,17 +127,41 @@ func (c *OutboundCall) WriteToRequestMeta(ctx context.Context, reqMeta *transpor
 // This should be called only if the request is unary.
 func (c *OutboundCall) ReadFromResponse(ctx context.Context, res *transport.Response) (context.Context, error) {
 	// We're not using ctx right now but we may in the future.
-	if c.responseHeaders != nil && res.Headers.Len() > 0 {
-		// We make a copy of the response headers because Headers.Items() must
-		// never be mutated.
-		headers := make(map[string]string, res.Headers.Len())
-		for k, v := range res.Headers.Items() {
-			headers[k] = v
-		}
-		*c.responseHeaders = headers
-	}
+	c.readResponseHeaders(res.Headers)
 
 	// NB(abg): context and error are unused for now but we want to leave room
 	// for CallOptions which can fail or modify the context.
 	return ctx, nil
 }
+
+// ReadFromStream reads information from the given client stream for this
+// call.
+//
+// This should be called only if the request is streaming. Because reading
+// response headers may block until the server has sent them, encodings
+// should call this after the first message has been received from the
+// stream.
+func (c *OutboundCall) ReadFromStream(ctx context.Context, stream *transport.ClientStream) (context.Context, error) {
+	if c.responseHeaders == nil {
+		return ctx, nil
+	}
+	headers, err := stream.Headers()
+	if err != nil {
+		return ctx, err
+	}
+	c.readResponseHeaders(headers)
+	return ctx, nil
+}
+
+func (c *OutboundCall) readResponseHeaders(headers transport.Headers) {
+	if c.responseHeaders == nil || headers.Len() == 0 {
+		return
+	}
+	// We make a copy of the response headers because Headers.Items() must
+	// never be mutated.
+	items := make(map[string]string, headers.Len())
+	for k, v := range headers.Items() {
+		items[k] = v
+	}
+	*c.responseHeaders = items
+}
@@ -26,6 +26,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
 )
 
@@ -159,6 +160,36 @@ func TestOutboundCallWriteToRequestAndRequestMeta(t *testing.T) {
 	}
 }
 
+func TestOutboundCallWithPeer(t *testing.T) {
+	call := NewOutboundCall(WithPeer("127.0.0.1:8080"))
+
+	ctx, err := call.WriteToRequest(context.Background(), &transport.Request{})
+	require.NoError(t, err)
+	id, ok := peer.SelectedPeerFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "127.0.0.1:8080", id)
+
+	ctx, err = call.WriteToRequestMeta(context.Background(), &transport.RequestMeta{})
+	require.NoError(t, err)
+	id, ok = peer.SelectedPeerFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "127.0.0.1:8080", id)
+}
+
+func TestOutboundCallWithOutboundInfo(t *testing.T) {
+	var info transport.OutboundCallInfo
+	call := NewOutboundCall(OutboundInfo(&info))
+
+	ctx, err := call.WriteToRequest(context.Background(), &transport.Request{})
+	require.NoError(t, err)
+	assert.True(t, transport.WantsOutboundCallInfo(ctx))
+
+	ctx, err = call.WriteToRequestMeta(context.Background(), &transport.RequestMeta{})
+	require.NoError(t, err)
+	transport.RecordOutboundAttempt(ctx, "127.0.0.1:8080", false)
+	assert.Equal(t, transport.OutboundCallInfo{Peer: "127.0.0.1:8080", Attempts: 1}, info)
+}
+
 func TestOutboundCallReadFromResponse(t *testing.T) {
 	var headers map[string]string
 	call := NewOutboundCall(ResponseHeaders(&headers))
@@ -178,11 +209,47 @@ func TestOutboundCallReadFromResponse(t *testing.T) {
 	}, headers)
 }
 
-func TestStreamOutboundCallCannotReadFromResponse(t *testing.T) {
+type headerStream struct {
+	transport.StreamCloser
+
+	headers transport.Headers
+}
+
+func (s headerStream) Headers() (transport.Headers, error) {
+	return s.headers, nil
+}
+
+func TestStreamOutboundCallReadFromStream(t *testing.T) {
 	var headers map[string]string
 	call, err := NewStreamOutboundCall(ResponseHeaders(&headers))
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "code:invalid-argument")
-	assert.Contains(t, err.Error(), "response headers are not supported for streams")
-	assert.Nil(t, call)
+	require.NoError(t, err)
+
+	stream, err := transport.NewClientStream(headerStream{
+		headers: transport.HeadersFromMap(map[string]string{
+			"hello": "World",
+			"Foo":   "bar",
+		}),
+	})
+	require.NoError(t, err)
+
+	_, err = call.ReadFromStream(context.Background(), stream)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"hello": "World",
+		"foo":   "bar",
+	}, headers)
+}
+
+func TestStreamOutboundCallReadFromStreamWithoutHeaders(t *testing.T) {
+	var headers map[string]string
+	call, err := NewStreamOutboundCall(ResponseHeaders(&headers))
+	require.NoError(t, err)
+
+	// Streams that do not support headers leave the map untouched.
+	stream, err := transport.NewClientStream(struct{ transport.StreamCloser }{})
+	require.NoError(t, err)
+
+	_, err = call.ReadFromStream(context.Background(), stream)
+	require.NoError(t, err)
+	assert.Nil(t, headers)
 }
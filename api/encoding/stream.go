@@ -20,8 +20,43 @@
 
 package encoding
 
+import "context"
+
 // StreamOption is an option that may be passed in at streaming function call
 // sites.
-type StreamOption interface {
-	unimplemented()
+//
+// Encoding authors should accept yarpc.StreamOptions and convert them to
+// encoding.StreamOptions to use with NewStreamCall.
+type StreamOption struct{ apply func(*StreamCall) }
+
+// WithStreamContext bounds an individual Send or Receive on a stream by the
+// given context. If the context is canceled or its deadline passes before
+// the operation completes, the operation fails with a Cancelled or
+// DeadlineExceeded error.
+func WithStreamContext(ctx context.Context) StreamOption {
+	return StreamOption{func(c *StreamCall) { c.ctx = ctx }}
+}
+
+// StreamCall holds the options for an individual message sent or received on
+// a stream.
+type StreamCall struct {
+	ctx context.Context
+}
+
+// NewStreamCall constructs a new StreamCall with the given options.
+func NewStreamCall(options ...StreamOption) *StreamCall {
+	var call StreamCall
+	for _, opt := range options {
+		opt.apply(&call)
+	}
+	return &call
+}
+
+// Context returns the context that bounds this operation, or the given
+// default if none was specified.
+func (c *StreamCall) Context(def context.Context) context.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return def
 }
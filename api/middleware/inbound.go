@@ -22,8 +22,10 @@ package middleware
 
 import (
 	"context"
+	"reflect"
 
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/introspection"
 )
 
 // UnaryInbound defines a transport-level middleware for
@@ -71,6 +73,20 @@ func (h unaryHandlerWithMiddleware) Handle(ctx context.Context, req *transport.R
 	return h.i.Handle(ctx, req, resw, h.h)
 }
 
+func (h unaryHandlerWithMiddleware) RequestType() reflect.Type {
+	if th, ok := h.h.(introspection.TypedHandler); ok {
+		return th.RequestType()
+	}
+	return nil
+}
+
+func (h unaryHandlerWithMiddleware) ResponseType() reflect.Type {
+	if th, ok := h.h.(introspection.TypedHandler); ok {
+		return th.ResponseType()
+	}
+	return nil
+}
+
 type nopUnaryInbound struct{}
 
 func (nopUnaryInbound) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, handler transport.UnaryHandler) error {
@@ -122,6 +138,17 @@ func (h onewayHandlerWithMiddleware) HandleOneway(ctx context.Context, req *tran
 	return h.i.HandleOneway(ctx, req, h.h)
 }
 
+func (h onewayHandlerWithMiddleware) RequestType() reflect.Type {
+	if th, ok := h.h.(introspection.TypedHandler); ok {
+		return th.RequestType()
+	}
+	return nil
+}
+
+func (h onewayHandlerWithMiddleware) ResponseType() reflect.Type {
+	return nil
+}
+
 type nopOnewayInbound struct{}
 
 func (nopOnewayInbound) HandleOneway(ctx context.Context, req *transport.Request, handler transport.OnewayHandler) error {
@@ -24,12 +24,15 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"reflect"
 	"testing"
 
 	"go.uber.org/yarpc/api/middleware"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/encoding/json"
 	"go.uber.org/yarpc/encoding/raw"
+	"go.uber.org/yarpc/internal/introspection"
 	"go.uber.org/yarpc/internal/testtime"
 
 	"github.com/golang/mock/gomock"
@@ -110,6 +113,47 @@ func TestNilInboundMiddleware(t *testing.T) {
 	})
 }
 
+func TestInboundMiddlewareTypedHandler(t *testing.T) {
+	type request struct{ Key string }
+	type response struct{ Value string }
+
+	t.Run("unary", func(t *testing.T) {
+		procedures := json.Procedure("get", func(context.Context, *request) (*response, error) {
+			return nil, nil
+		})
+		mw := middleware.ApplyUnaryInbound(procedures[0].HandlerSpec.Unary(), middleware.NopUnaryInbound)
+
+		th, ok := mw.(introspection.TypedHandler)
+		require.True(t, ok, "expected wrapped handler to be a TypedHandler")
+		assert.Equal(t, reflect.TypeOf(&request{}), th.RequestType())
+		assert.Equal(t, reflect.TypeOf(&response{}), th.ResponseType())
+	})
+
+	t.Run("oneway", func(t *testing.T) {
+		procedures := json.OnewayProcedure("fire", func(context.Context, *request) error {
+			return nil
+		})
+		mw := middleware.ApplyOnewayInbound(procedures[0].HandlerSpec.Oneway(), middleware.NopOnewayInbound)
+
+		th, ok := mw.(introspection.TypedHandler)
+		require.True(t, ok, "expected wrapped handler to be a TypedHandler")
+		assert.Equal(t, reflect.TypeOf(&request{}), th.RequestType())
+		assert.Nil(t, th.ResponseType())
+	})
+
+	t.Run("untyped", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mw := middleware.ApplyUnaryInbound(transporttest.NewMockUnaryHandler(ctrl), middleware.NopUnaryInbound)
+
+		th, ok := mw.(introspection.TypedHandler)
+		require.True(t, ok, "expected wrapped handler to be a TypedHandler")
+		assert.Nil(t, th.RequestType())
+		assert.Nil(t, th.ResponseType())
+	})
+}
+
 func TestStreamNopInboundMiddleware(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -0,0 +1,107 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package middleware
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Named is implemented by middleware that report a name, used to describe
+// middleware chains and to express the ordering constraints of middleware.
+type Named interface {
+	MiddlewareName() string
+}
+
+// Name returns the name of the given middleware: its MiddlewareName if it is
+// Named, or the name of its Go type otherwise.
+func Name(mw interface{}) string {
+	if n, ok := mw.(Named); ok {
+		return n.MiddlewareName()
+	}
+	return fmt.Sprintf("%T", mw)
+}
+
+// Ordering lists the middleware, by name, that a middleware must be applied
+// outside or inside of when they are part of the same chain.
+type Ordering struct {
+	// Outside lists the middleware that this middleware must wrap, that is
+	// the middleware that must come after it in the chain.
+	Outside []string
+
+	// Inside lists the middleware that must wrap this middleware, that is
+	// the middleware that must come before it in the chain.
+	Inside []string
+
+	// Reason explains the constraints in validation errors.
+	Reason string
+}
+
+// Ordered is implemented by middleware with ordering constraints.
+type Ordered interface {
+	Named
+
+	MiddlewareOrdering() Ordering
+}
+
+// ValidateOrder returns an error if the given chain of middleware, outermost
+// first, violates the ordering constraints of any of its middleware.
+func ValidateOrder(chain []interface{}) error {
+	positions := make(map[string]int, len(chain))
+	for i, mw := range chain {
+		name := Name(mw)
+		if _, ok := positions[name]; !ok {
+			positions[name] = i
+		}
+	}
+
+	var violations []string
+	for i, mw := range chain {
+		o, ok := mw.(Ordered)
+		if !ok {
+			continue
+		}
+		name := o.MiddlewareName()
+		ordering := o.MiddlewareOrdering()
+		for _, other := range ordering.Outside {
+			if j, ok := positions[other]; ok && j < i {
+				violations = append(violations, orderViolation(name, "outside", other, ordering.Reason))
+			}
+		}
+		for _, other := range ordering.Inside {
+			if j, ok := positions[other]; ok && j > i {
+				violations = append(violations, orderViolation(name, "inside", other, ordering.Reason))
+			}
+		}
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("invalid middleware order: %s", strings.Join(violations, "; "))
+	}
+	return nil
+}
+
+func orderViolation(name, where, other, reason string) string {
+	msg := fmt.Sprintf("%q must be applied %s of %q", name, where, other)
+	if reason != "" {
+		msg += ": " + reason
+	}
+	return msg
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package middleware
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type namedMiddleware string
+
+func (n namedMiddleware) MiddlewareName() string { return string(n) }
+
+type orderedMiddleware struct {
+	name     string
+	ordering Ordering
+}
+
+func (o orderedMiddleware) MiddlewareName() string       { return o.name }
+func (o orderedMiddleware) MiddlewareOrdering() Ordering { return o.ordering }
+
+func TestName(t *testing.T) {
+	assert.Equal(t, "auth", Name(namedMiddleware("auth")))
+	assert.Equal(t, "middleware.nopUnaryInbound", Name(NopUnaryInbound))
+}
+
+func TestValidateOrder(t *testing.T) {
+	retry := orderedMiddleware{
+		name:     "retry",
+		ordering: Ordering{Outside: []string{"pushback"}, Reason: "attempts must respect pushback"},
+	}
+	requestID := orderedMiddleware{
+		name:     "requestid",
+		ordering: Ordering{Inside: []string{"tracing"}},
+	}
+
+	tests := []struct {
+		desc    string
+		chain   []interface{}
+		wantErr string
+	}{
+		{desc: "empty"},
+		{
+			desc:  "valid",
+			chain: []interface{}{namedMiddleware("tracing"), requestID, retry, namedMiddleware("pushback")},
+		},
+		{
+			desc:  "constrained middleware missing",
+			chain: []interface{}{retry, requestID},
+		},
+		{
+			desc:    "outside violated",
+			chain:   []interface{}{namedMiddleware("pushback"), retry},
+			wantErr: `invalid middleware order: "retry" must be applied outside of "pushback": attempts must respect pushback`,
+		},
+		{
+			desc:  "both violated",
+			chain: []interface{}{requestID, namedMiddleware("pushback"), retry, namedMiddleware("tracing")},
+			wantErr: `invalid middleware order: "requestid" must be applied inside of "tracing"; ` +
+				`"retry" must be applied outside of "pushback": attempts must respect pushback`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := ValidateOrder(tt.chain)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
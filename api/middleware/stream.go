@@ -0,0 +1,135 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package middleware
+
+import (
+	"context"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+// StreamWrapper defines middleware for the individual messages sent and
+// received on a stream, complementing StreamInbound and StreamOutbound which
+// wrap stream establishment.
+//
+// StreamWrapper middleware MAY do zero or more of the following: change the
+// context, change the message, handle the returned error, call the given
+// stream zero or more times.
+//
+// StreamWrapper middleware MUST be thread-safe.
+//
+// StreamWrapper middleware is re-used across streams and MAY be called
+// multiple times for the same stream.
+type StreamWrapper interface {
+	// SendMessage is called for every message sent on the stream.
+	SendMessage(ctx context.Context, msg *transport.StreamMessage, next transport.Stream) error
+
+	// ReceiveMessage is called for every message received from the stream.
+	ReceiveMessage(ctx context.Context, next transport.Stream) (*transport.StreamMessage, error)
+}
+
+// NopStreamWrapper is a StreamWrapper that does not do anything special. It
+// simply calls the underlying stream.
+var NopStreamWrapper StreamWrapper = nopStreamWrapper{}
+
+// WrapServerStream applies the given StreamWrapper to every message sent and
+// received on the given ServerStream.
+func WrapServerStream(s *transport.ServerStream, w StreamWrapper) *transport.ServerStream {
+	if w == nil {
+		return s
+	}
+	// NewServerStream only fails for nil streams.
+	wrapped, _ := transport.NewServerStream(wrappedServerStream{ServerStream: s, w: w})
+	return wrapped
+}
+
+// WrapClientStream applies the given StreamWrapper to every message sent and
+// received on the given ClientStream.
+func WrapClientStream(s *transport.ClientStream, w StreamWrapper) *transport.ClientStream {
+	if w == nil {
+		return s
+	}
+	// NewClientStream only fails for nil streams.
+	wrapped, _ := transport.NewClientStream(wrappedClientStream{ClientStream: s, w: w})
+	return wrapped
+}
+
+// StreamInboundFromWrapper builds a StreamInbound middleware which applies
+// the given StreamWrapper to all inbound streams.
+func StreamInboundFromWrapper(w StreamWrapper) StreamInbound {
+	return StreamInboundFunc(func(s *transport.ServerStream, h transport.StreamHandler) error {
+		return h.HandleStream(WrapServerStream(s, w))
+	})
+}
+
+// StreamOutboundFromWrapper builds a StreamOutbound middleware which applies
+// the given StreamWrapper to all outbound streams.
+func StreamOutboundFromWrapper(w StreamWrapper) StreamOutbound {
+	return StreamOutboundFunc(func(ctx context.Context, req *transport.StreamRequest, out transport.StreamOutbound) (*transport.ClientStream, error) {
+		s, err := out.CallStream(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return WrapClientStream(s, w), nil
+	})
+}
+
+// wrappedServerStream embeds the original ServerStream so that optional
+// capabilities like SendHeaders remain available.
+type wrappedServerStream struct {
+	*transport.ServerStream
+
+	w StreamWrapper
+}
+
+func (s wrappedServerStream) SendMessage(ctx context.Context, msg *transport.StreamMessage) error {
+	return s.w.SendMessage(ctx, msg, s.ServerStream)
+}
+
+func (s wrappedServerStream) ReceiveMessage(ctx context.Context) (*transport.StreamMessage, error) {
+	return s.w.ReceiveMessage(ctx, s.ServerStream)
+}
+
+// wrappedClientStream embeds the original ClientStream so that Close and
+// optional capabilities like Headers remain available.
+type wrappedClientStream struct {
+	*transport.ClientStream
+
+	w StreamWrapper
+}
+
+func (s wrappedClientStream) SendMessage(ctx context.Context, msg *transport.StreamMessage) error {
+	return s.w.SendMessage(ctx, msg, s.ClientStream)
+}
+
+func (s wrappedClientStream) ReceiveMessage(ctx context.Context) (*transport.StreamMessage, error) {
+	return s.w.ReceiveMessage(ctx, s.ClientStream)
+}
+
+type nopStreamWrapper struct{}
+
+func (nopStreamWrapper) SendMessage(ctx context.Context, msg *transport.StreamMessage, next transport.Stream) error {
+	return next.SendMessage(ctx, msg)
+}
+
+func (nopStreamWrapper) ReceiveMessage(ctx context.Context, next transport.Stream) (*transport.StreamMessage, error) {
+	return next.ReceiveMessage(ctx)
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package middleware_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+)
+
+type countingStreamWrapper struct {
+	sent     atomic.Int32
+	received atomic.Int32
+}
+
+func (w *countingStreamWrapper) SendMessage(ctx context.Context, msg *transport.StreamMessage, next transport.Stream) error {
+	w.sent.Inc()
+	return next.SendMessage(ctx, msg)
+}
+
+func (w *countingStreamWrapper) ReceiveMessage(ctx context.Context, next transport.Stream) (*transport.StreamMessage, error) {
+	w.received.Inc()
+	return next.ReceiveMessage(ctx)
+}
+
+func newStreamMessage(body string) *transport.StreamMessage {
+	return &transport.StreamMessage{Body: ioutil.NopCloser(bytes.NewBufferString(body))}
+}
+
+func TestStreamInboundFromWrapper(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx := context.Background()
+	msg := newStreamMessage("hello")
+
+	stream := transporttest.NewMockStream(mockCtrl)
+	stream.EXPECT().SendMessage(ctx, msg).Return(nil)
+	stream.EXPECT().ReceiveMessage(ctx).Return(msg, nil)
+	s, err := transport.NewServerStream(stream)
+	require.NoError(t, err)
+
+	w := &countingStreamWrapper{}
+	h := middleware.ApplyStreamInbound(
+		streamHandlerFunc(func(s *transport.ServerStream) error {
+			if err := s.SendMessage(ctx, msg); err != nil {
+				return err
+			}
+			_, err := s.ReceiveMessage(ctx)
+			return err
+		}),
+		middleware.StreamInboundFromWrapper(w),
+	)
+
+	require.NoError(t, h.HandleStream(s))
+	assert.Equal(t, int32(1), w.sent.Load())
+	assert.Equal(t, int32(1), w.received.Load())
+}
+
+func TestStreamOutboundFromWrapper(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx := context.Background()
+	msg := newStreamMessage("hello")
+	req := &transport.StreamRequest{Meta: &transport.RequestMeta{Procedure: "proc"}}
+
+	stream := transporttest.NewMockStreamCloser(mockCtrl)
+	stream.EXPECT().SendMessage(ctx, msg).Return(nil).Times(2)
+	stream.EXPECT().Close(ctx).Return(nil)
+	clientStream, err := transport.NewClientStream(stream)
+	require.NoError(t, err)
+
+	out := transporttest.NewMockStreamOutbound(mockCtrl)
+	out.EXPECT().CallStream(ctx, req).Return(clientStream, nil)
+
+	w := &countingStreamWrapper{}
+	wrappedOut := middleware.ApplyStreamOutbound(out, middleware.StreamOutboundFromWrapper(w))
+
+	s, err := wrappedOut.CallStream(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, s.SendMessage(ctx, msg))
+	require.NoError(t, s.SendMessage(ctx, msg))
+	require.NoError(t, s.Close(ctx))
+	assert.Equal(t, int32(2), w.sent.Load())
+	assert.Equal(t, int32(0), w.received.Load())
+}
+
+func TestWrapStreamNilWrapper(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	s, err := transport.NewServerStream(transporttest.NewMockStream(mockCtrl))
+	require.NoError(t, err)
+	assert.Equal(t, s, middleware.WrapServerStream(s, nil))
+
+	c, err := transport.NewClientStream(transporttest.NewMockStreamCloser(mockCtrl))
+	require.NoError(t, err)
+	assert.Equal(t, c, middleware.WrapClientStream(c, nil))
+}
+
+func TestNopStreamWrapper(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx := context.Background()
+	msg := newStreamMessage("hello")
+
+	stream := transporttest.NewMockStream(mockCtrl)
+	stream.EXPECT().SendMessage(ctx, msg).Return(nil)
+	stream.EXPECT().ReceiveMessage(ctx).Return(msg, nil)
+	s, err := transport.NewServerStream(stream)
+	require.NoError(t, err)
+
+	wrapped := middleware.WrapServerStream(s, middleware.NopStreamWrapper)
+	require.NoError(t, wrapped.SendMessage(ctx, msg))
+	got, err := wrapped.ReceiveMessage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, msg, got)
+}
+
+type streamHandlerFunc func(*transport.ServerStream) error
+
+func (f streamHandlerFunc) HandleStream(s *transport.ServerStream) error {
+	return f(s)
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peer
+
+import "context"
+
+type selectedPeerKey struct{}
+
+// WithSelectedPeer returns a context that instructs peer choosers to send the
+// request to the peer with the given identifier instead of choosing one
+// themselves.
+//
+// Choosers that honor the selection fail the request with an Unavailable
+// error if the selected peer is not known to them or is not available.
+func WithSelectedPeer(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, selectedPeerKey{}, id)
+}
+
+// SelectedPeerFromContext returns the identifier of the peer selected for the
+// request with WithSelectedPeer, if any.
+func SelectedPeerFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(selectedPeerKey{}).(string)
+	return id, ok
+}
+
+type avoidPeersKey struct{}
+
+// WithAvoidPeers returns a context that asks peer choosers to prefer peers
+// other than the ones with the given identifiers, typically because earlier
+// attempts of the request failed on them. Identifiers already recorded on the
+// context are kept.
+//
+// Unlike WithSelectedPeer, this is only a hint: choosers that honor it still
+// choose an avoided peer if no other peer is available.
+func WithAvoidPeers(ctx context.Context, ids ...string) context.Context {
+	avoid := AvoidPeersFromContext(ctx)
+	return context.WithValue(ctx, avoidPeersKey{}, append(avoid[:len(avoid):len(avoid)], ids...))
+}
+
+// AvoidPeersFromContext returns the identifiers of the peers that choosers
+// should avoid for the request, as recorded with WithAvoidPeers.
+func AvoidPeersFromContext(ctx context.Context) []string {
+	ids, _ := ctx.Value(avoidPeersKey{}).([]string)
+	return ids
+}
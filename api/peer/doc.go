@@ -58,4 +58,10 @@
 // an internal representation of each of their retained peers for book-keeping
 // and sorting which benefits from receiving notifications for state changes
 // directly.
+//
+// Peer list updaters may add peers as `peer.LabeledIdentifier`s, which carry
+// labels like the zone, weight, version, or canary status of a peer.
+// Peer lists and choosers read them with `peer.LabelsOf` and the other label
+// accessors, for example to weight peers or to honor the peers a request
+// prefers with `peer.WithPreferredPeers`.
 package peer
@@ -0,0 +1,173 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peer
+
+import (
+	"context"
+	"strconv"
+)
+
+// Well-known peer labels.
+const (
+	// LabelZone is the label of the zone or availability zone of a peer.
+	LabelZone = "zone"
+
+	// LabelWeight is the label of the relative weight of a peer, a positive
+	// number. Peers without a valid weight have DefaultWeight.
+	LabelWeight = "weight"
+
+	// LabelVersion is the label of the version of the software a peer runs.
+	LabelVersion = "version"
+
+	// LabelCanary is the label of peers that run a canary release, set to
+	// "true".
+	LabelCanary = "canary"
+)
+
+// DefaultWeight is the weight of peers without a valid LabelWeight label.
+const DefaultWeight = 1.0
+
+// Labels are key-value pairs that describe a peer, like its zone or version.
+// Labels must not be modified once they are attached to an identifier.
+type Labels map[string]string
+
+// Matches returns whether the labels have all the labels of the selector,
+// with the same values.
+func (l Labels) Matches(selector Labels) bool {
+	for k, v := range selector {
+		if lv, ok := l[k]; !ok || lv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Labeled is implemented by identifiers that carry labels, and by the peers
+// peer lists build from them.
+type Labeled interface {
+	Labels() Labels
+}
+
+// LabeledIdentifier is an Identifier that carries labels, for peer list
+// updaters that know more about peers than their address. Transports retain
+// peers by identifier alone, so peers with the same identifier but different
+// labels are the same peer; updaters change the labels of a peer by removing
+// and adding it again.
+type LabeledIdentifier struct {
+	id     string
+	labels Labels
+}
+
+var (
+	_ Identifier = LabeledIdentifier{}
+	_ Labeled    = LabeledIdentifier{}
+)
+
+// NewLabeledIdentifier returns an identifier for the peer with the given
+// identifier, typically a host:port, that carries a copy of the given labels.
+func NewLabeledIdentifier(id string, labels Labels) LabeledIdentifier {
+	copied := make(Labels, len(labels))
+	for k, v := range labels {
+		copied[k] = v
+	}
+	return LabeledIdentifier{id: id, labels: copied}
+}
+
+// Identifier returns the identifier of the peer.
+func (i LabeledIdentifier) Identifier() string {
+	return i.id
+}
+
+// Labels returns the labels of the peer, which must not be modified.
+func (i LabeledIdentifier) Labels() Labels {
+	return i.labels
+}
+
+// LabelsOf returns the labels of the given peer or identifier, or nil if it
+// carries none.
+func LabelsOf(id Identifier) Labels {
+	if l, ok := id.(Labeled); ok {
+		return l.Labels()
+	}
+	return nil
+}
+
+// Label returns the value of the label of the given peer or identifier with
+// the given key, and whether it has that label.
+func Label(id Identifier, key string) (string, bool) {
+	v, ok := LabelsOf(id)[key]
+	return v, ok
+}
+
+// Weight returns the weight of the given peer or identifier, from its
+// LabelWeight label, or DefaultWeight if it has no valid weight.
+func Weight(id Identifier) float64 {
+	v, ok := Label(id, LabelWeight)
+	if !ok {
+		return DefaultWeight
+	}
+	w, err := strconv.ParseFloat(v, 64)
+	if err != nil || !(w > 0) {
+		return DefaultWeight
+	}
+	return w
+}
+
+// IsCanary returns whether the given peer or identifier is labeled as running
+// a canary release.
+func IsCanary(id Identifier) bool {
+	v, _ := Label(id, LabelCanary)
+	canary, _ := strconv.ParseBool(v)
+	return canary
+}
+
+type preferPeersKey struct{}
+
+// WithPreferredPeers returns a context that asks peer choosers to prefer the
+// peers for which the given function returns true, for example peers with
+// some labels. Preferences already recorded on the context must also be met.
+//
+// Like WithAvoidPeers, this is only a hint: choosers that honor it still
+// choose another peer if no preferred peer is available. Choosers that honor
+// both prefer peers that are not avoided to peers that are preferred.
+func WithPreferredPeers(ctx context.Context, prefer func(Identifier) bool) context.Context {
+	if previous := PreferredPeersFromContext(ctx); previous != nil {
+		next := prefer
+		prefer = func(id Identifier) bool { return previous(id) && next(id) }
+	}
+	return context.WithValue(ctx, preferPeersKey{}, prefer)
+}
+
+// PreferredPeersFromContext returns the function that reports which peers
+// choosers should prefer for the request, as recorded with
+// WithPreferredPeers, or nil if there is none.
+func PreferredPeersFromContext(ctx context.Context) func(Identifier) bool {
+	prefer, _ := ctx.Value(preferPeersKey{}).(func(Identifier) bool)
+	return prefer
+}
+
+// MatchLabels returns a function for WithPreferredPeers that prefers peers
+// whose labels match the given selector.
+func MatchLabels(selector Labels) func(Identifier) bool {
+	return func(id Identifier) bool {
+		return LabelsOf(id).Matches(selector)
+	}
+}
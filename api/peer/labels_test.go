@@ -0,0 +1,90 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type plainIdentifier string
+
+func (i plainIdentifier) Identifier() string { return string(i) }
+
+func TestLabeledIdentifier(t *testing.T) {
+	labels := Labels{LabelZone: "east", LabelVersion: "v2"}
+	id := NewLabeledIdentifier("127.0.0.1:8080", labels)
+	labels[LabelZone] = "west"
+
+	assert.Equal(t, "127.0.0.1:8080", id.Identifier())
+	assert.Equal(t, Labels{LabelZone: "east", LabelVersion: "v2"}, LabelsOf(id), "labels must be copied")
+	assert.Nil(t, LabelsOf(plainIdentifier("127.0.0.1:8080")))
+
+	zone, ok := Label(id, LabelZone)
+	assert.True(t, ok)
+	assert.Equal(t, "east", zone)
+	_, ok = Label(plainIdentifier("127.0.0.1:8080"), LabelZone)
+	assert.False(t, ok)
+}
+
+func TestWeight(t *testing.T) {
+	tests := []struct {
+		weight string
+		want   float64
+	}{
+		{"2.5", 2.5},
+		{"0", DefaultWeight},
+		{"-1", DefaultWeight},
+		{"NaN", DefaultWeight},
+		{"heavy", DefaultWeight},
+	}
+	for _, tt := range tests {
+		t.Run(tt.weight, func(t *testing.T) {
+			assert.Equal(t, tt.want, Weight(NewLabeledIdentifier("peer", Labels{LabelWeight: tt.weight})))
+		})
+	}
+	assert.Equal(t, DefaultWeight, Weight(plainIdentifier("peer")))
+}
+
+func TestIsCanary(t *testing.T) {
+	assert.True(t, IsCanary(NewLabeledIdentifier("peer", Labels{LabelCanary: "true"})))
+	assert.False(t, IsCanary(NewLabeledIdentifier("peer", Labels{LabelCanary: "false"})))
+	assert.False(t, IsCanary(NewLabeledIdentifier("peer", nil)))
+	assert.False(t, IsCanary(plainIdentifier("peer")))
+}
+
+func TestPreferredPeers(t *testing.T) {
+	assert.Nil(t, PreferredPeersFromContext(context.Background()))
+
+	east := NewLabeledIdentifier("a", Labels{LabelZone: "east", LabelVersion: "v1"})
+	eastV2 := NewLabeledIdentifier("b", Labels{LabelZone: "east", LabelVersion: "v2"})
+	west := NewLabeledIdentifier("c", Labels{LabelZone: "west", LabelVersion: "v2"})
+
+	ctx := WithPreferredPeers(context.Background(), MatchLabels(Labels{LabelZone: "east"}))
+	ctx = WithPreferredPeers(ctx, MatchLabels(Labels{LabelVersion: "v2"}))
+	prefer := PreferredPeersFromContext(ctx)
+	assert.False(t, prefer(east))
+	assert.True(t, prefer(eastV2))
+	assert.False(t, prefer(west))
+	assert.False(t, prefer(plainIdentifier("d")))
+}
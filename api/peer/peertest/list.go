@@ -224,3 +224,96 @@ func (m *MockChooserList) Update(arg0 peer.ListUpdates) error {
 func (mr *MockChooserListMockRecorder) Update(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockChooserList)(nil).Update), arg0)
 }
+
+// MockListImplementation is a mock of ListImplementation interface
+type MockListImplementation struct {
+	ctrl     *gomock.Controller
+	recorder *MockListImplementationMockRecorder
+}
+
+// MockListImplementationMockRecorder is the mock recorder for MockListImplementation
+type MockListImplementationMockRecorder struct {
+	mock *MockListImplementation
+}
+
+// NewMockListImplementation creates a new mock instance
+func NewMockListImplementation(ctrl *gomock.Controller) *MockListImplementation {
+	mock := &MockListImplementation{ctrl: ctrl}
+	mock.recorder = &MockListImplementationMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockListImplementation) EXPECT() *MockListImplementationMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method
+func (m *MockListImplementation) Add(arg0 peer.StatusPeer) peer.Subscriber {
+	ret := m.ctrl.Call(m, "Add", arg0)
+	ret0, _ := ret[0].(peer.Subscriber)
+	return ret0
+}
+
+// Add indicates an expected call of Add
+func (mr *MockListImplementationMockRecorder) Add(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockListImplementation)(nil).Add), arg0)
+}
+
+// Choose mocks base method
+func (m *MockListImplementation) Choose(arg0 context.Context, arg1 *transport.Request) peer.StatusPeer {
+	ret := m.ctrl.Call(m, "Choose", arg0, arg1)
+	ret0, _ := ret[0].(peer.StatusPeer)
+	return ret0
+}
+
+// Choose indicates an expected call of Choose
+func (mr *MockListImplementationMockRecorder) Choose(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Choose", reflect.TypeOf((*MockListImplementation)(nil).Choose), arg0, arg1)
+}
+
+// IsRunning mocks base method
+func (m *MockListImplementation) IsRunning() bool {
+	ret := m.ctrl.Call(m, "IsRunning")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsRunning indicates an expected call of IsRunning
+func (mr *MockListImplementationMockRecorder) IsRunning() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRunning", reflect.TypeOf((*MockListImplementation)(nil).IsRunning))
+}
+
+// Remove mocks base method
+func (m *MockListImplementation) Remove(arg0 peer.StatusPeer, arg1 peer.Subscriber) {
+	m.ctrl.Call(m, "Remove", arg0, arg1)
+}
+
+// Remove indicates an expected call of Remove
+func (mr *MockListImplementationMockRecorder) Remove(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockListImplementation)(nil).Remove), arg0, arg1)
+}
+
+// Start mocks base method
+func (m *MockListImplementation) Start() error {
+	ret := m.ctrl.Call(m, "Start")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Start indicates an expected call of Start
+func (mr *MockListImplementationMockRecorder) Start() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockListImplementation)(nil).Start))
+}
+
+// Stop mocks base method
+func (m *MockListImplementation) Stop() error {
+	ret := m.ctrl.Call(m, "Stop")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Stop indicates an expected call of Stop
+func (mr *MockListImplementationMockRecorder) Stop() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockListImplementation)(nil).Stop))
+}
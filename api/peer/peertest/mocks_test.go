@@ -0,0 +1,37 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peertest
+
+import "go.uber.org/yarpc/api/peer"
+
+// The mocks must keep up with the interfaces they mock; regenerate them with
+// etc/bin/generate.sh when these fail to compile.
+var (
+	_ peer.Chooser            = (*MockChooser)(nil)
+	_ peer.ChooserList        = (*MockChooserList)(nil)
+	_ peer.Identifier         = (*MockIdentifier)(nil)
+	_ peer.List               = (*MockList)(nil)
+	_ peer.ListImplementation = (*MockListImplementation)(nil)
+	_ peer.Peer               = (*MockPeer)(nil)
+	_ peer.StatusPeer         = (*MockStatusPeer)(nil)
+	_ peer.Subscriber         = (*MockSubscriber)(nil)
+	_ peer.Transport          = (*MockTransport)(nil)
+)
@@ -131,3 +131,50 @@ func (m *MockPeer) Status() peer.Status {
 func (mr *MockPeerMockRecorder) Status() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Status", reflect.TypeOf((*MockPeer)(nil).Status))
 }
+
+// MockStatusPeer is a mock of StatusPeer interface
+type MockStatusPeer struct {
+	ctrl     *gomock.Controller
+	recorder *MockStatusPeerMockRecorder
+}
+
+// MockStatusPeerMockRecorder is the mock recorder for MockStatusPeer
+type MockStatusPeerMockRecorder struct {
+	mock *MockStatusPeer
+}
+
+// NewMockStatusPeer creates a new mock instance
+func NewMockStatusPeer(ctrl *gomock.Controller) *MockStatusPeer {
+	mock := &MockStatusPeer{ctrl: ctrl}
+	mock.recorder = &MockStatusPeerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockStatusPeer) EXPECT() *MockStatusPeerMockRecorder {
+	return m.recorder
+}
+
+// Identifier mocks base method
+func (m *MockStatusPeer) Identifier() string {
+	ret := m.ctrl.Call(m, "Identifier")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Identifier indicates an expected call of Identifier
+func (mr *MockStatusPeerMockRecorder) Identifier() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Identifier", reflect.TypeOf((*MockStatusPeer)(nil).Identifier))
+}
+
+// Status mocks base method
+func (m *MockStatusPeer) Status() peer.Status {
+	ret := m.ctrl.Call(m, "Status")
+	ret0, _ := ret[0].(peer.Status)
+	return ret0
+}
+
+// Status indicates an expected call of Status
+func (mr *MockStatusPeerMockRecorder) Status() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Status", reflect.TypeOf((*MockStatusPeer)(nil).Status))
+}
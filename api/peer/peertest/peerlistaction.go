@@ -125,6 +125,48 @@ func (a ChooseAction) Apply(t *testing.T, pl peer.Chooser, deps ListActionDeps)
 	}
 }
 
+// ChooseDistributionAction will run Choose Requests times on the PeerList and
+// assert how the requests were spread across peers.
+type ChooseDistributionAction struct {
+	Requests     int
+	InputRequest *transport.Request
+
+	// ExpectedDistribution maps peer identifiers to the share of requests,
+	// between 0 and 1, that they are expected to receive. Peers missing from
+	// the map are expected to receive no requests.
+	ExpectedDistribution map[string]float64
+
+	// Tolerance is how far the share of requests received by each peer may
+	// be from the expected share.
+	Tolerance float64
+}
+
+// Apply runs "Choose" on the peerList Requests times and validates the share
+// of requests chosen for each peer
+func (a ChooseDistributionAction) Apply(t *testing.T, pl peer.Chooser, deps ListActionDeps) {
+	counts := make(map[string]int)
+	for i := 0; i < a.Requests; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*testtime.Millisecond)
+		p, finish, err := pl.Choose(ctx, a.InputRequest)
+		cancel()
+		if !assert.NoError(t, err, "choose #%d failed", i) {
+			return
+		}
+		finish(nil)
+		counts[p.Identifier()]++
+	}
+
+	for id, count := range counts {
+		if _, ok := a.ExpectedDistribution[id]; !ok {
+			assert.Fail(t, "unexpected peer chosen", "peer %q was chosen %d times", id, count)
+		}
+	}
+	for id, expected := range a.ExpectedDistribution {
+		actual := float64(counts[id]) / float64(a.Requests)
+		assert.InDelta(t, expected, actual, a.Tolerance, "unexpected share of requests for peer %q", id)
+	}
+}
+
 // UpdateAction is an action for adding/removing multiple peers on the PeerList
 type UpdateAction struct {
 	AddedPeerIDs   []string
@@ -26,3 +26,94 @@ import "fmt"
 type Ack interface {
 	fmt.Stringer
 }
+
+// AckStatus is how far a oneway request got by the time it was
+// acknowledged.
+type AckStatus int
+
+const (
+	// AckStatusUnknown indicates that the outbound does not know how far
+	// the request got, only that it was accepted.
+	AckStatusUnknown AckStatus = iota
+
+	// AckStatusQueued indicates that the request was accepted into a queue
+	// in memory and that its handler may not have run yet.
+	AckStatusQueued
+
+	// AckStatusPersisted indicates that the request was stored durably to
+	// be delivered later.
+	AckStatusPersisted
+
+	// AckStatusDelivered indicates that the handler of the request ran
+	// successfully.
+	AckStatusDelivered
+)
+
+var (
+	_ackStatusToString = map[AckStatus]string{
+		AckStatusUnknown:   "unknown",
+		AckStatusQueued:    "queued",
+		AckStatusPersisted: "persisted",
+		AckStatusDelivered: "delivered",
+	}
+	_stringToAckStatus = map[string]AckStatus{
+		"unknown":   AckStatusUnknown,
+		"queued":    AckStatusQueued,
+		"persisted": AckStatusPersisted,
+		"delivered": AckStatusDelivered,
+	}
+)
+
+// String returns the name of the status, as carried by transports.
+func (s AckStatus) String() string {
+	if str, ok := _ackStatusToString[s]; ok {
+		return str
+	}
+	return fmt.Sprintf("AckStatus(%d)", int(s))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s AckStatus) MarshalText() ([]byte, error) {
+	if str, ok := _ackStatusToString[s]; ok {
+		return []byte(str), nil
+	}
+	return nil, fmt.Errorf("unknown ack status: %d", int(s))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *AckStatus) UnmarshalText(text []byte) error {
+	status, ok := _stringToAckStatus[string(text)]
+	if !ok {
+		return fmt.Errorf("unknown ack status: %q", text)
+	}
+	*s = status
+	return nil
+}
+
+// StatusAck is an Ack that reports how far its request got. Oneway
+// outbounds return StatusAcks if they or their transport know it.
+type StatusAck interface {
+	Ack
+
+	// Status returns how far the request got when it was acknowledged.
+	Status() AckStatus
+}
+
+// AckStatusOf returns the status of the given Ack, or AckStatusUnknown if it
+// is not a StatusAck.
+func AckStatusOf(ack Ack) AckStatus {
+	if sa, ok := ack.(StatusAck); ok {
+		return sa.Status()
+	}
+	return AckStatusUnknown
+}
+
+// NewStatusAck builds a StatusAck that reports the given status.
+func NewStatusAck(status AckStatus) StatusAck {
+	return statusAck(status)
+}
+
+type statusAck AckStatus
+
+func (a statusAck) String() string    { return AckStatus(a).String() }
+func (a statusAck) Status() AckStatus { return AckStatus(a) }
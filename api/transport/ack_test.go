@@ -0,0 +1,57 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package transport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAckStatusText(t *testing.T) {
+	for _, status := range []AckStatus{AckStatusUnknown, AckStatusQueued, AckStatusPersisted, AckStatusDelivered} {
+		text, err := status.MarshalText()
+		require.NoError(t, err)
+		assert.Equal(t, status.String(), string(text))
+
+		var got AckStatus
+		require.NoError(t, got.UnmarshalText(text))
+		assert.Equal(t, status, got)
+	}
+
+	_, err := AckStatus(42).MarshalText()
+	assert.Error(t, err)
+	assert.Equal(t, "AckStatus(42)", AckStatus(42).String())
+
+	var status AckStatus
+	assert.Error(t, status.UnmarshalText([]byte("lost")))
+}
+
+func TestAckStatusOf(t *testing.T) {
+	assert.Equal(t, AckStatusUnknown, AckStatusOf(time.Now()))
+	assert.Equal(t, AckStatusUnknown, AckStatusOf(nil))
+
+	ack := NewStatusAck(AckStatusDelivered)
+	assert.Equal(t, AckStatusDelivered, AckStatusOf(ack))
+	assert.Equal(t, "delivered", ack.String())
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package transport
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// ConnectionEventType is the kind of a ConnectionEvent.
+type ConnectionEventType int
+
+const (
+	// ConnectionEstablished indicates that a connection was established and,
+	// for TLS connections, that its handshake completed.
+	ConnectionEstablished ConnectionEventType = iota + 1
+
+	// ConnectionClosed indicates that an established connection was closed.
+	ConnectionClosed
+
+	// ConnectionFailed indicates that a connection could not be
+	// established.
+	ConnectionFailed
+)
+
+var _connectionEventTypeToString = map[ConnectionEventType]string{
+	ConnectionEstablished: "established",
+	ConnectionClosed:      "closed",
+	ConnectionFailed:      "failed",
+}
+
+// String returns the name of the event type.
+func (t ConnectionEventType) String() string {
+	if str, ok := _connectionEventTypeToString[t]; ok {
+		return str
+	}
+	return fmt.Sprintf("ConnectionEventType(%d)", int(t))
+}
+
+// ConnectionEvent describes a change in the state of a connection of a
+// transport.
+type ConnectionEvent struct {
+	Type ConnectionEventType
+
+	// Transport is the name of the transport that owns the connection, for
+	// example "http", "grpc", or "tchannel".
+	Transport string
+
+	// Inbound is true for connections accepted by the transport, and false
+	// for connections it dialed.
+	Inbound bool
+
+	// Peer identifies the other end of the connection. For outbound
+	// connections, it is the identifier of the peer that was dialed. For
+	// inbound connections, it is the remote address of the connection.
+	Peer string
+
+	// LocalAddr is the local address of the connection. It is empty for
+	// connections that failed to be established.
+	LocalAddr string
+
+	// TLS is the state of the TLS connection, or nil if the connection does
+	// not use TLS or the transport cannot report it.
+	TLS *tls.ConnectionState
+
+	// Err is the reason a connection failed to be established.
+	Err error
+}
+
+// ConnectionEventHook is called by transports for every ConnectionEvent.
+// Hooks are called synchronously from the goroutines that manage
+// connections and must not block.
+type ConnectionEventHook func(ConnectionEvent)
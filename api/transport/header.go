@@ -35,6 +35,10 @@ func CanonicalizeHeaderKey(k string) string {
 // 	var headers transport.Headers
 // 	headers = headers.With("foo", "bar")
 // 	headers = headers.With("baz", "qux")
+//
+// With and Del change the headers in place, so copies of a Headers object
+// share the same underlying data store. Use Clone to get a copy that may be
+// changed independently.
 type Headers struct {
 	// This representation allows us to make zero-value valid
 	items map[string]string
@@ -95,6 +99,27 @@ func (h Headers) Len() int {
 	return len(h.items)
 }
 
+// Clone returns a snapshot of the Headers that may be changed without
+// affecting the original, and vice versa. Clone only reads the original, so
+// concurrent clones of the same headers are safe.
+func (h Headers) Clone() Headers {
+	if h.items == nil {
+		return Headers{}
+	}
+	return Headers{
+		items:         copyMap(h.items),
+		originalItems: copyMap(h.originalItems),
+	}
+}
+
+func copyMap(m map[string]string) map[string]string {
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
 // Items returns the underlying map for this Headers object. The returned map
 // MUST NOT be changed. Doing so will result in undefined behavior.
 //
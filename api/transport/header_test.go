@@ -21,6 +21,8 @@
 package transport
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -188,3 +190,47 @@ func TestItemsAndOriginalItems(t *testing.T) {
 		})
 	}
 }
+
+func TestHeadersZeroValue(t *testing.T) {
+	var headers Headers
+	headers.Del("foo")
+	_, ok := headers.Get("foo")
+	assert.False(t, ok)
+	assert.Equal(t, 0, headers.Len())
+	assert.Nil(t, headers.Items())
+	assert.Nil(t, headers.OriginalItems())
+	assert.Equal(t, Headers{}, headers.Clone())
+}
+
+func TestHeadersClone(t *testing.T) {
+	original := NewHeaders().With("foo", "bar").With("baz", "qux")
+	clone := original.Clone()
+	assert.Equal(t, original, clone)
+
+	clone = clone.With("foo", "changed")
+	clone.Del("baz")
+	assert.Equal(t, map[string]string{"foo": "bar", "baz": "qux"}, original.Items())
+	assert.Equal(t, map[string]string{"foo": "changed"}, clone.Items())
+
+	// Changing the original after cloning must not affect the clone either.
+	original = original.With("new", "header")
+	assert.Equal(t, map[string]string{"foo": "changed"}, clone.Items())
+	assert.Equal(t, map[string]string{"foo": "bar", "baz": "qux", "new": "header"}, original.Items())
+}
+
+func TestHeadersConcurrentClone(t *testing.T) {
+	headers := NewHeaders().With("foo", "bar")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			clone := headers.Clone().With("i", fmt.Sprint(i))
+			v, _ := clone.Get("i")
+			assert.Equal(t, fmt.Sprint(i), v)
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, map[string]string{"foo": "bar"}, headers.Items())
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package transport
+
+import "net/url"
+
+// Metadata describes how an inbound unary or oneway request was received.
+// Inbounds populate it on the requests they pass to handlers so that
+// middleware and handlers can inspect the transport-specific details of the
+// call without relying on transport-specific APIs. The peer that sent the
+// request is available from RemotePeerFromContext.
+//
+// At most one of the HTTP, GRPC, and TChannel fields is set, depending on
+// the transport that received the request.
+type Metadata struct {
+	// HTTP describes requests received over HTTP.
+	HTTP *HTTPMetadata
+
+	// GRPC describes requests received over gRPC.
+	GRPC *GRPCMetadata
+
+	// TChannel describes requests received over TChannel.
+	TChannel *TChannelMetadata
+}
+
+// HTTPMetadata describes a request received over HTTP.
+type HTTPMetadata struct {
+	// Method is the HTTP method of the request, for example "POST".
+	Method string
+
+	// URL is the URL of the request as received by the server.
+	URL *url.URL
+}
+
+// GRPCMetadata describes a request received over gRPC.
+type GRPCMetadata struct {
+	// Method is the full gRPC method name, in the form "/service/method".
+	Method string
+}
+
+// TChannelMetadata describes a request received over TChannel.
+type TChannelMetadata struct {
+	// CallerName is the caller name ("cn") sent in the TChannel call frame.
+	CallerName string
+}
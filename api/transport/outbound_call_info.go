@@ -0,0 +1,130 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package transport
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// OutboundCallInfo describes how an outbound call was sent. Outbounds fill it
+// in for calls whose context was built with WithOutboundCallInfo, typically
+// by a CallOption.
+type OutboundCallInfo struct {
+	// Peer is the identifier of the peer that received the last attempt of
+	// the call, typically its "host:port" address.
+	Peer string
+
+	// ConnectionReused is true if the last attempt was sent over a
+	// connection that was already established with the peer, as opposed to
+	// one opened for the attempt.
+	ConnectionReused bool
+
+	// Attempts is the number of times the call was sent to a peer, including
+	// retries.
+	Attempts int
+
+	// Timings breaks down the latency of the last attempt, for outbounds
+	// that measure it, like the HTTP outbound.
+	Timings OutboundCallTimings
+}
+
+// OutboundCallTimings breaks down the latency of an attempt of an outbound
+// call into its phases, to tell latency added by the network apart from
+// latency added by the server. Phases that did not happen, like resolving
+// the address of a peer for an attempt sent over an established
+// connection, are zero.
+type OutboundCallTimings struct {
+	// DNS is the time spent resolving the address of the peer.
+	DNS time.Duration
+
+	// Connect is the time spent establishing a connection to the peer.
+	Connect time.Duration
+
+	// TLSHandshake is the time spent on the TLS handshake with the peer.
+	TLSHandshake time.Duration
+
+	// RequestWrite is the time from getting a connection to the peer until
+	// the request was fully written to it.
+	RequestWrite time.Duration
+
+	// FirstByte is the time from writing the request until the first byte
+	// of the response arrived.
+	FirstByte time.Duration
+}
+
+type outboundCallInfoKey struct{}
+
+type outboundCallRecorder struct {
+	mu   sync.Mutex
+	info *OutboundCallInfo
+
+	// parent is the recorder of the context this one was derived from, if
+	// any, which records the call too.
+	parent *outboundCallRecorder
+}
+
+// WithOutboundCallInfo returns a copy of the context that asks outbounds to
+// record how the call is sent in the given OutboundCallInfo.
+//
+// The call is also recorded in the OutboundCallInfos that the context
+// already asked for, so middleware may inspect calls without hiding them
+// from the callers that asked.
+func WithOutboundCallInfo(ctx context.Context, info *OutboundCallInfo) context.Context {
+	parent, _ := ctx.Value(outboundCallInfoKey{}).(*outboundCallRecorder)
+	return context.WithValue(ctx, outboundCallInfoKey{}, &outboundCallRecorder{info: info, parent: parent})
+}
+
+// WantsOutboundCallInfo returns whether the caller asked for the
+// OutboundCallInfo of the call. Outbounds may use this to skip work that is
+// only needed to fill it in.
+func WantsOutboundCallInfo(ctx context.Context) bool {
+	_, ok := ctx.Value(outboundCallInfoKey{}).(*outboundCallRecorder)
+	return ok
+}
+
+// RecordOutboundAttempt records that an attempt of the call was sent to the
+// given peer. Outbounds call this once per attempt; it does nothing if the
+// caller did not ask for the OutboundCallInfo of the call.
+func RecordOutboundAttempt(ctx context.Context, peer string, connectionReused bool) {
+	r, _ := ctx.Value(outboundCallInfoKey{}).(*outboundCallRecorder)
+	for ; r != nil; r = r.parent {
+		r.mu.Lock()
+		r.info.Peer = peer
+		r.info.ConnectionReused = connectionReused
+		r.info.Attempts++
+		r.mu.Unlock()
+	}
+}
+
+// RecordOutboundTimings records the timings of an attempt of the call.
+// Outbounds that measure them call this once per attempt, before
+// RecordOutboundAttempt; it does nothing if the caller did not ask for the
+// OutboundCallInfo of the call.
+func RecordOutboundTimings(ctx context.Context, timings OutboundCallTimings) {
+	r, _ := ctx.Value(outboundCallInfoKey{}).(*outboundCallRecorder)
+	for ; r != nil; r = r.parent {
+		r.mu.Lock()
+		r.info.Timings = timings
+		r.mu.Unlock()
+	}
+}
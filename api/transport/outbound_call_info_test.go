@@ -0,0 +1,61 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package transport
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboundCallInfo(t *testing.T) {
+	ctx := context.Background()
+	assert.False(t, WantsOutboundCallInfo(ctx))
+	assert.NotPanics(t, func() { RecordOutboundAttempt(ctx, "1.1.1.1:1", false) })
+
+	var info OutboundCallInfo
+	ctx = WithOutboundCallInfo(ctx, &info)
+	assert.True(t, WantsOutboundCallInfo(ctx))
+
+	RecordOutboundAttempt(ctx, "1.1.1.1:1", false)
+	RecordOutboundAttempt(ctx, "2.2.2.2:2", true)
+	assert.Equal(t, OutboundCallInfo{
+		Peer:             "2.2.2.2:2",
+		ConnectionReused: true,
+		Attempts:         2,
+	}, info)
+}
+
+func TestNestedOutboundCallInfo(t *testing.T) {
+	var outer, inner OutboundCallInfo
+	ctx := WithOutboundCallInfo(context.Background(), &outer)
+	ctx = WithOutboundCallInfo(ctx, &inner)
+
+	timings := OutboundCallTimings{DNS: time.Millisecond, FirstByte: time.Second}
+	RecordOutboundTimings(ctx, timings)
+	RecordOutboundAttempt(ctx, "1.1.1.1:1", false)
+
+	want := OutboundCallInfo{Peer: "1.1.1.1:1", Attempts: 1, Timings: timings}
+	assert.Equal(t, want, inner)
+	assert.Equal(t, want, outer)
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+)
+
+// RemotePeer describes the peer that sent an inbound request, as seen by the
+// inbound that received it.
+type RemotePeer struct {
+	// Address is the network address of the peer, typically "host:port".
+	Address string
+
+	// TLS is the state of the TLS connection with the peer, including the
+	// certificates it presented, or nil if the connection was not secured
+	// with TLS.
+	TLS *tls.ConnectionState
+}
+
+type remotePeerKey struct{}
+
+// WithRemotePeer returns a copy of the context that records the peer that
+// sent the inbound request. Inbounds call this before passing the request to
+// the handler.
+func WithRemotePeer(ctx context.Context, p RemotePeer) context.Context {
+	return context.WithValue(ctx, remotePeerKey{}, p)
+}
+
+// RemotePeerFromContext returns the peer that sent the inbound request, if
+// the inbound recorded it.
+func RemotePeerFromContext(ctx context.Context) (RemotePeer, bool) {
+	p, ok := ctx.Value(remotePeerKey{}).(RemotePeer)
+	return p, ok
+}
@@ -64,6 +64,11 @@ type Request struct {
 	// override the routing key and service.
 	RoutingDelegate string
 
+	// Metadata describes how the request was received. Inbounds populate it
+	// on the requests they pass to handlers. It is not copied by ToRequestMeta,
+	// so requests built from an inbound request do not carry it.
+	Metadata *Metadata
+
 	// Request payload.
 	Body io.Reader
 }
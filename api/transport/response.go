@@ -47,3 +47,40 @@ type ResponseWriter interface {
 	// of Write().
 	SetApplicationError()
 }
+
+// StreamingResponseWriter is a ResponseWriter that can send the response
+// body to the caller as it is written instead of holding all of it in
+// memory until the handler returns.
+//
+// Transports that support this implement it on the ResponseWriter they pass
+// to handlers. Use StreamResponse to enable it from handlers.
+type StreamingResponseWriter interface {
+	ResponseWriter
+
+	// StreamResponse switches the ResponseWriter to pass-through mode and
+	// reports whether it succeeded.
+	//
+	// In pass-through mode, headers and the application error flag are sent
+	// with the first call to Write and cannot be changed afterwards. If the
+	// handler fails after it has started writing, the transport aborts the
+	// response so that the caller sees an error rather than a truncated body.
+	StreamResponse() bool
+}
+
+// StreamResponse switches the given ResponseWriter to pass-through mode if
+// it supports it, so that large response bodies are sent to the caller as
+// they are written. It reports whether the ResponseWriter is now streaming;
+// if not, writes continue to be buffered by the transport.
+//
+// 	func (h *handler) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+// 		resw.AddHeaders(headers)
+// 		transport.StreamResponse(resw)
+// 		_, err := io.Copy(resw, largeFile)
+// 		return err
+// 	}
+func StreamResponse(w ResponseWriter) bool {
+	if s, ok := w.(StreamingResponseWriter); ok {
+		return s.StreamResponse()
+	}
+	return false
+}
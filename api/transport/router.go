@@ -47,6 +47,32 @@ type Procedure struct {
 	// Signature of the handler, for introspection. This should be a snippet of
 	// Go code representing the function definition.
 	Signature string
+
+	// Metadata describes the procedure for introspection and documentation.
+	// Code generators populate it from the IDL; it does not affect routing.
+	Metadata ProcedureMetadata
+}
+
+// ProcedureMetadata is structured documentation about a procedure, surfaced
+// by the introspection and debug pages and by the OpenAPI exporter.
+type ProcedureMetadata struct {
+	// Description is a human readable summary of the procedure.
+	Description string
+
+	// RequestSchema and ResponseSchema reference the types of the request and
+	// response bodies in the IDL of the encoding, for example the fully
+	// qualified name of a Protobuf message.
+	RequestSchema  string
+	ResponseSchema string
+
+	// Idempotent is true if the procedure may safely be called more than
+	// once for the same request.
+	Idempotent bool
+
+	// Deprecated is true if callers should stop using the procedure.
+	// DeprecationMessage optionally explains what to use instead.
+	Deprecated         bool
+	DeprecationMessage string
 }
 
 // MarshalLogObject implements zap.ObjectMarshaler.
@@ -75,6 +75,19 @@ func (s *ServerStream) ReceiveMessage(ctx context.Context) (*StreamMessage, erro
 	return s.stream.ReceiveMessage(ctx)
 }
 
+// SendHeaders sends the given application headers to the client ahead of
+// any messages. Headers may be sent at most once and only before the first
+// message.
+//
+// Returns an Unimplemented error if the underlying stream does not support
+// response headers.
+func (s *ServerStream) SendHeaders(headers Headers) error {
+	if hs, ok := s.stream.(StreamHeadersSender); ok {
+		return hs.SendHeaders(headers)
+	}
+	return yarpcerrors.UnimplementedErrorf("stream does not support sending headers")
+}
+
 // ClientStreamOption is an option for configuring a client stream.
 // There are no current ClientStreamOptions implemented.
 type ClientStreamOption interface {
@@ -125,6 +138,19 @@ func (s *ClientStream) Close(ctx context.Context) error {
 	return s.stream.Close(ctx)
 }
 
+// Headers returns the application headers sent by the server for this
+// stream. It blocks until the headers have been received or the stream has
+// failed.
+//
+// If the underlying stream does not support response headers, empty headers
+// are returned.
+func (s *ClientStream) Headers() (Headers, error) {
+	if hr, ok := s.stream.(StreamHeadersReader); ok {
+		return hr.Headers()
+	}
+	return Headers{}, nil
+}
+
 // StreamCloser represents an API of interacting with a Stream that is
 // closable.
 type StreamCloser interface {
@@ -155,6 +181,22 @@ type Stream interface {
 	ReceiveMessage(context.Context) (*StreamMessage, error)
 }
 
+// StreamHeadersReader is implemented by client streams that can surface the
+// application headers sent by the server.
+type StreamHeadersReader interface {
+	// Headers blocks until the headers sent by the server are available and
+	// returns them.
+	Headers() (Headers, error)
+}
+
+// StreamHeadersSender is implemented by server streams that can send
+// application headers to the client.
+type StreamHeadersSender interface {
+	// SendHeaders sends the given headers to the client. It must be called
+	// before any message is sent.
+	SendHeaders(Headers) error
+}
+
 // StreamMessage represents information that can be read off of an individual
 // message in the stream.
 type StreamMessage struct {
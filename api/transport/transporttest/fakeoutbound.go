@@ -0,0 +1,238 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package transporttest
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"sync"
+	"testing"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var _ transport.UnaryOutbound = (*FakeOutbound)(nil)
+
+// FakeOutbound is a unary outbound that records the requests it receives
+// and answers them with programmed responses, so that client code can be
+// unit tested without a dispatcher.
+//
+// 	out := transporttest.NewFakeOutbound()
+// 	out.ExpectCall("KV::Get").Return(&transport.Response{Body: body}, nil)
+//
+// 	client := json.New(&transport.OutboundConfig{
+// 		CallerName: "caller",
+// 		Outbounds:  transport.Outbounds{ServiceName: "kv", Unary: out},
+// 	})
+// 	...
+// 	out.Verify(t)
+//
+// Each request is answered by the first expectation for its procedure that
+// has calls left. Requests without one fail with an Unimplemented error.
+//
+// FakeOutbound does not need to be started.
+type FakeOutbound struct {
+	once *lifecycle.Once
+
+	mu           sync.Mutex
+	calls        []recordedCall
+	expectations []*ExpectedCall
+}
+
+// NewFakeOutbound builds a new FakeOutbound with no expectations.
+func NewFakeOutbound() *FakeOutbound {
+	return &FakeOutbound{once: lifecycle.NewOnce()}
+}
+
+// ExpectCall expects a single call to the given procedure, returning an
+// empty response unless told otherwise.
+func (o *FakeOutbound) ExpectCall(procedure string) *ExpectedCall {
+	e := &ExpectedCall{procedure: procedure, times: 1}
+	o.mu.Lock()
+	o.expectations = append(o.expectations, e)
+	o.mu.Unlock()
+	return e
+}
+
+// Calls returns the requests received by the outbound, in order. Request
+// bodies are buffered and may be read again.
+func (o *FakeOutbound) Calls() []*transport.Request {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	calls := make([]*transport.Request, len(o.calls))
+	for i, call := range o.calls {
+		calls[i] = call.request()
+	}
+	return calls
+}
+
+// Verify fails the test if an expectation has not received all the calls
+// that it expects.
+func (o *FakeOutbound) Verify(t testing.TB) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for _, e := range o.expectations {
+		if e.times >= 0 && e.calls < e.times {
+			t.Errorf("expected %d call(s) to %q, got %d", e.times, e.procedure, e.calls)
+		}
+	}
+}
+
+// Call records the request and answers it with the first matching
+// expectation.
+func (o *FakeOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	call := recordedCall{req: *req}
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		call.body = body
+	}
+
+	o.mu.Lock()
+	o.calls = append(o.calls, call)
+	e := o.match(req.Procedure)
+	o.mu.Unlock()
+
+	if e == nil {
+		return nil, yarpcerrors.UnimplementedErrorf("unexpected call to procedure %q", req.Procedure)
+	}
+	return e.respond(ctx, call.request())
+}
+
+// match returns the first expectation for the procedure that has calls left,
+// and counts the call against it.
+//
+// Must be called with o.mu held.
+func (o *FakeOutbound) match(procedure string) *ExpectedCall {
+	for _, e := range o.expectations {
+		if e.procedure != procedure {
+			continue
+		}
+		if e.times >= 0 && e.calls >= e.times {
+			continue
+		}
+		e.calls++
+		return e
+	}
+	return nil
+}
+
+// Start starts the outbound.
+func (o *FakeOutbound) Start() error {
+	return o.once.Start(nil)
+}
+
+// Stop stops the outbound.
+func (o *FakeOutbound) Stop() error {
+	return o.once.Stop(nil)
+}
+
+// IsRunning returns whether the outbound is running.
+func (o *FakeOutbound) IsRunning() bool {
+	return o.once.IsRunning()
+}
+
+// Transports returns no transports.
+func (o *FakeOutbound) Transports() []transport.Transport {
+	return nil
+}
+
+// ExpectedCall is an expectation of calls to a procedure on a FakeOutbound.
+//
+// ExpectedCall must be configured before the calls it expects are made.
+type ExpectedCall struct {
+	procedure string
+	times     int
+	calls     int
+
+	handle func(context.Context, *transport.Request) (*transport.Response, error)
+}
+
+// Return answers the expected calls with the given response and error. The
+// body of the response is buffered, and every call gets its own copy.
+func (e *ExpectedCall) Return(res *transport.Response, err error) *ExpectedCall {
+	var body []byte
+	if res != nil && res.Body != nil {
+		var readErr error
+		body, readErr = ioutil.ReadAll(res.Body)
+		if readErr != nil {
+			err = readErr
+		}
+		_ = res.Body.Close()
+	}
+
+	e.handle = func(context.Context, *transport.Request) (*transport.Response, error) {
+		if res == nil {
+			return nil, err
+		}
+		copied := *res
+		copied.Body = ioutil.NopCloser(bytes.NewReader(body))
+		return &copied, err
+	}
+	return e
+}
+
+// Do answers the expected calls by calling the given function.
+func (e *ExpectedCall) Do(f func(context.Context, *transport.Request) (*transport.Response, error)) *ExpectedCall {
+	e.handle = f
+	return e
+}
+
+// Times expects n calls instead of one.
+func (e *ExpectedCall) Times(n int) *ExpectedCall {
+	e.times = n
+	return e
+}
+
+// AnyTimes expects any number of calls, including none.
+func (e *ExpectedCall) AnyTimes() *ExpectedCall {
+	e.times = -1
+	return e
+}
+
+func (e *ExpectedCall) respond(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	if e.handle == nil {
+		return &transport.Response{Body: ioutil.NopCloser(&bytes.Buffer{})}, nil
+	}
+	return e.handle(ctx, req)
+}
+
+// recordedCall is a request received by a FakeOutbound, with its body read
+// into memory.
+type recordedCall struct {
+	req  transport.Request
+	body []byte
+}
+
+// request returns a copy of the request whose body can be read
+// independently.
+func (c recordedCall) request() *transport.Request {
+	req := c.req
+	req.Body = bytes.NewReader(c.body)
+	return &req
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package transporttest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// recorder records the failures of a test instead of failing it.
+type recorder struct {
+	testing.TB
+
+	errors []string
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func call(t *testing.T, out *FakeOutbound, procedure, body string) (string, error) {
+	res, err := out.Call(context.Background(), &transport.Request{
+		Service:   "kv",
+		Procedure: procedure,
+		Body:      bytes.NewBufferString(body),
+	})
+	if err != nil {
+		return "", err
+	}
+	b, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	return string(b), nil
+}
+
+func TestFakeOutboundReturn(t *testing.T) {
+	out := NewFakeOutbound()
+	out.ExpectCall("KV::Get").Return(&transport.Response{
+		Body: ioutil.NopCloser(bytes.NewBufferString("bar")),
+	}, nil).Times(2)
+	out.ExpectCall("KV::Set").Return(nil, yarpcerrors.InternalErrorf("great sadness"))
+
+	for i := 0; i < 2; i++ {
+		body, err := call(t, out, "KV::Get", "foo")
+		require.NoError(t, err)
+		assert.Equal(t, "bar", body, "every call must get the full body")
+	}
+
+	_, err := call(t, out, "KV::Set", "foo=baz")
+	assert.Equal(t, yarpcerrors.InternalErrorf("great sadness"), err)
+
+	r := &recorder{TB: t}
+	out.Verify(r)
+	assert.Empty(t, r.errors)
+}
+
+func TestFakeOutboundDo(t *testing.T) {
+	out := NewFakeOutbound()
+	out.ExpectCall("KV::Get").Do(func(_ context.Context, req *transport.Request) (*transport.Response, error) {
+		return &transport.Response{Body: ioutil.NopCloser(req.Body)}, nil
+	}).AnyTimes()
+
+	body, err := call(t, out, "KV::Get", "echo")
+	require.NoError(t, err)
+	assert.Equal(t, "echo", body)
+}
+
+func TestFakeOutboundDefaultResponse(t *testing.T) {
+	out := NewFakeOutbound()
+	out.ExpectCall("KV::Get")
+
+	body, err := call(t, out, "KV::Get", "foo")
+	require.NoError(t, err)
+	assert.Empty(t, body)
+}
+
+func TestFakeOutboundUnexpectedCalls(t *testing.T) {
+	out := NewFakeOutbound()
+	out.ExpectCall("KV::Get")
+
+	_, err := call(t, out, "KV::Set", "foo=bar")
+	assert.Equal(t, yarpcerrors.CodeUnimplemented, yarpcerrors.FromError(err).Code())
+
+	_, err = call(t, out, "KV::Get", "foo")
+	require.NoError(t, err)
+	_, err = call(t, out, "KV::Get", "foo")
+	assert.Error(t, err, "calls beyond the expected number must fail")
+}
+
+func TestFakeOutboundCalls(t *testing.T) {
+	out := NewFakeOutbound()
+	out.ExpectCall("KV::Get").AnyTimes()
+	out.ExpectCall("KV::Set").AnyTimes()
+
+	_, err := call(t, out, "KV::Set", "foo=bar")
+	require.NoError(t, err)
+	_, err = call(t, out, "KV::Get", "foo")
+	require.NoError(t, err)
+
+	calls := out.Calls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, "KV::Set", calls[0].Procedure)
+	assert.Equal(t, "KV::Get", calls[1].Procedure)
+
+	for i := 0; i < 2; i++ {
+		body, err := ioutil.ReadAll(out.Calls()[0].Body)
+		require.NoError(t, err)
+		assert.Equal(t, "foo=bar", string(body), "recorded bodies must be readable again")
+	}
+}
+
+func TestFakeOutboundVerify(t *testing.T) {
+	out := NewFakeOutbound()
+	out.ExpectCall("KV::Get").Times(2)
+	out.ExpectCall("KV::Set").AnyTimes()
+
+	_, err := call(t, out, "KV::Get", "foo")
+	require.NoError(t, err)
+
+	r := &recorder{TB: t}
+	out.Verify(r)
+	assert.Equal(t, []string{`expected 2 call(s) to "KV::Get", got 1`}, r.errors)
+}
+
+func TestFakeOutboundLifecycle(t *testing.T) {
+	out := NewFakeOutbound()
+	assert.Empty(t, out.Transports())
+	require.NoError(t, out.Start())
+	assert.True(t, out.IsRunning())
+	require.NoError(t, out.Stop())
+	assert.False(t, out.IsRunning())
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package transporttest
+
+import "go.uber.org/yarpc/api/transport"
+
+// The mocks must keep up with the interfaces they mock; regenerate them with
+// etc/bin/generate.sh when these fail to compile.
+var (
+	_ transport.Ack                     = (*MockAck)(nil)
+	_ transport.ClientConfig            = (*MockClientConfig)(nil)
+	_ transport.ClientConfigProvider    = (*MockClientConfigProvider)(nil)
+	_ transport.Inbound                 = (*MockInbound)(nil)
+	_ transport.OnewayHandler           = (*MockOnewayHandler)(nil)
+	_ transport.OnewayOutbound          = (*MockOnewayOutbound)(nil)
+	_ transport.Outbound                = (*MockOutbound)(nil)
+	_ transport.ResponseWriter          = (*MockResponseWriter)(nil)
+	_ transport.RouteTable              = (*MockRouteTable)(nil)
+	_ transport.Router                  = (*MockRouter)(nil)
+	_ transport.Stream                  = (*MockStream)(nil)
+	_ transport.StreamCloser            = (*MockStreamCloser)(nil)
+	_ transport.StreamHandler           = (*MockStreamHandler)(nil)
+	_ transport.StreamOutbound          = (*MockStreamOutbound)(nil)
+	_ transport.StreamingResponseWriter = (*MockStreamingResponseWriter)(nil)
+	_ transport.Transport               = (*MockTransport)(nil)
+	_ transport.UnaryHandler            = (*MockUnaryHandler)(nil)
+	_ transport.UnaryOutbound           = (*MockUnaryOutbound)(nil)
+)
@@ -31,6 +31,77 @@ import (
 	reflect "reflect"
 )
 
+// MockOutbound is a mock of Outbound interface
+type MockOutbound struct {
+	ctrl     *gomock.Controller
+	recorder *MockOutboundMockRecorder
+}
+
+// MockOutboundMockRecorder is the mock recorder for MockOutbound
+type MockOutboundMockRecorder struct {
+	mock *MockOutbound
+}
+
+// NewMockOutbound creates a new mock instance
+func NewMockOutbound(ctrl *gomock.Controller) *MockOutbound {
+	mock := &MockOutbound{ctrl: ctrl}
+	mock.recorder = &MockOutboundMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockOutbound) EXPECT() *MockOutboundMockRecorder {
+	return m.recorder
+}
+
+// IsRunning mocks base method
+func (m *MockOutbound) IsRunning() bool {
+	ret := m.ctrl.Call(m, "IsRunning")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsRunning indicates an expected call of IsRunning
+func (mr *MockOutboundMockRecorder) IsRunning() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRunning", reflect.TypeOf((*MockOutbound)(nil).IsRunning))
+}
+
+// Start mocks base method
+func (m *MockOutbound) Start() error {
+	ret := m.ctrl.Call(m, "Start")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Start indicates an expected call of Start
+func (mr *MockOutboundMockRecorder) Start() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockOutbound)(nil).Start))
+}
+
+// Stop mocks base method
+func (m *MockOutbound) Stop() error {
+	ret := m.ctrl.Call(m, "Stop")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Stop indicates an expected call of Stop
+func (mr *MockOutboundMockRecorder) Stop() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockOutbound)(nil).Stop))
+}
+
+// Transports mocks base method
+func (m *MockOutbound) Transports() []transport.Transport {
+	ret := m.ctrl.Call(m, "Transports")
+	ret0, _ := ret[0].([]transport.Transport)
+	return ret0
+}
+
+// Transports indicates an expected call of Transports
+func (mr *MockOutboundMockRecorder) Transports() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transports", reflect.TypeOf((*MockOutbound)(nil).Transports))
+}
+
 // MockUnaryOutbound is a mock of UnaryOutbound interface
 type MockUnaryOutbound struct {
 	ctrl     *gomock.Controller
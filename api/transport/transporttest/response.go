@@ -0,0 +1,152 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package transporttest is a generated GoMock package.
+package transporttest
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	transport "go.uber.org/yarpc/api/transport"
+	reflect "reflect"
+)
+
+// MockResponseWriter is a mock of ResponseWriter interface
+type MockResponseWriter struct {
+	ctrl     *gomock.Controller
+	recorder *MockResponseWriterMockRecorder
+}
+
+// MockResponseWriterMockRecorder is the mock recorder for MockResponseWriter
+type MockResponseWriterMockRecorder struct {
+	mock *MockResponseWriter
+}
+
+// NewMockResponseWriter creates a new mock instance
+func NewMockResponseWriter(ctrl *gomock.Controller) *MockResponseWriter {
+	mock := &MockResponseWriter{ctrl: ctrl}
+	mock.recorder = &MockResponseWriterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockResponseWriter) EXPECT() *MockResponseWriterMockRecorder {
+	return m.recorder
+}
+
+// AddHeaders mocks base method
+func (m *MockResponseWriter) AddHeaders(arg0 transport.Headers) {
+	m.ctrl.Call(m, "AddHeaders", arg0)
+}
+
+// AddHeaders indicates an expected call of AddHeaders
+func (mr *MockResponseWriterMockRecorder) AddHeaders(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddHeaders", reflect.TypeOf((*MockResponseWriter)(nil).AddHeaders), arg0)
+}
+
+// SetApplicationError mocks base method
+func (m *MockResponseWriter) SetApplicationError() {
+	m.ctrl.Call(m, "SetApplicationError")
+}
+
+// SetApplicationError indicates an expected call of SetApplicationError
+func (mr *MockResponseWriterMockRecorder) SetApplicationError() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetApplicationError", reflect.TypeOf((*MockResponseWriter)(nil).SetApplicationError))
+}
+
+// Write mocks base method
+func (m *MockResponseWriter) Write(arg0 []byte) (int, error) {
+	ret := m.ctrl.Call(m, "Write", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Write indicates an expected call of Write
+func (mr *MockResponseWriterMockRecorder) Write(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Write", reflect.TypeOf((*MockResponseWriter)(nil).Write), arg0)
+}
+
+// MockStreamingResponseWriter is a mock of StreamingResponseWriter interface
+type MockStreamingResponseWriter struct {
+	ctrl     *gomock.Controller
+	recorder *MockStreamingResponseWriterMockRecorder
+}
+
+// MockStreamingResponseWriterMockRecorder is the mock recorder for MockStreamingResponseWriter
+type MockStreamingResponseWriterMockRecorder struct {
+	mock *MockStreamingResponseWriter
+}
+
+// NewMockStreamingResponseWriter creates a new mock instance
+func NewMockStreamingResponseWriter(ctrl *gomock.Controller) *MockStreamingResponseWriter {
+	mock := &MockStreamingResponseWriter{ctrl: ctrl}
+	mock.recorder = &MockStreamingResponseWriterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockStreamingResponseWriter) EXPECT() *MockStreamingResponseWriterMockRecorder {
+	return m.recorder
+}
+
+// AddHeaders mocks base method
+func (m *MockStreamingResponseWriter) AddHeaders(arg0 transport.Headers) {
+	m.ctrl.Call(m, "AddHeaders", arg0)
+}
+
+// AddHeaders indicates an expected call of AddHeaders
+func (mr *MockStreamingResponseWriterMockRecorder) AddHeaders(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddHeaders", reflect.TypeOf((*MockStreamingResponseWriter)(nil).AddHeaders), arg0)
+}
+
+// SetApplicationError mocks base method
+func (m *MockStreamingResponseWriter) SetApplicationError() {
+	m.ctrl.Call(m, "SetApplicationError")
+}
+
+// SetApplicationError indicates an expected call of SetApplicationError
+func (mr *MockStreamingResponseWriterMockRecorder) SetApplicationError() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetApplicationError", reflect.TypeOf((*MockStreamingResponseWriter)(nil).SetApplicationError))
+}
+
+// StreamResponse mocks base method
+func (m *MockStreamingResponseWriter) StreamResponse() bool {
+	ret := m.ctrl.Call(m, "StreamResponse")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// StreamResponse indicates an expected call of StreamResponse
+func (mr *MockStreamingResponseWriterMockRecorder) StreamResponse() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamResponse", reflect.TypeOf((*MockStreamingResponseWriter)(nil).StreamResponse))
+}
+
+// Write mocks base method
+func (m *MockStreamingResponseWriter) Write(arg0 []byte) (int, error) {
+	ret := m.ctrl.Call(m, "Write", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Write indicates an expected call of Write
+func (mr *MockStreamingResponseWriterMockRecorder) Write(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Write", reflect.TypeOf((*MockStreamingResponseWriter)(nil).Write), arg0)
+}
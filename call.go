@@ -81,6 +81,36 @@ func WithRoutingDelegate(rd string) CallOption {
 	return CallOption(encoding.WithRoutingDelegate(rd))
 }
 
+// WithPeer sends the request to the peer with the given identifier (for
+// example, a host:port) instead of letting the outbound's peer list choose
+// one. This is useful for steering debug traffic to a specific instance.
+//
+// 	_, err := client.GetValue(ctx, reqBody, yarpc.WithPeer("127.0.0.1:8080"))
+//
+// The request fails with an Unavailable error if the peer list does not
+// contain the peer or the peer is not available, and with a
+// ResourceExhausted error if the peer has reached the list's limit of
+// pending requests.
+func WithPeer(id string) CallOption {
+	return CallOption(encoding.WithPeer(id))
+}
+
+// OutboundInfo specifies that the outbound should record which peer served
+// the request, whether the connection to that peer was reused, and how many
+// attempts were made, in the given struct.
+//
+// 	var info transport.OutboundCallInfo
+// 	_, err := client.GetValue(ctx, reqBody, yarpc.OutboundInfo(&info))
+// 	log.Printf("served by %v after %d attempts", info.Peer, info.Attempts)
+//
+// The struct is filled by the HTTP, gRPC and TChannel outbounds, for unary,
+// oneway, and streaming calls alike. It is left empty if the request never
+// reached a peer. Use it with ResponseHeaders to capture both the response
+// headers and the transport metadata of a call.
+func OutboundInfo(info *transport.OutboundCallInfo) CallOption {
+	return CallOption(encoding.OutboundInfo(info))
+}
+
 // Call provides information about the current request inside handlers. An
 // instance of Call for the current request can be obtained by calling
 // CallFromContext on the request context.
@@ -156,8 +186,43 @@ func (c *Call) RoutingDelegate() string {
 	return (*encoding.Call)(c).RoutingDelegate()
 }
 
+// Transport returns the name of the transport that received this request.
+func (c *Call) Transport() string {
+	return (*encoding.Call)(c).Transport()
+}
+
+// CallInfo describes the current request inside handlers, independent of
+// the encoding and transport of the request, including the address and TLS
+// identity of the peer that sent it and its deadline.
+//
+// 	info := yarpc.CallFromContext(ctx).Info()
+// 	if info.TLS != nil && len(info.TLS.PeerCertificates) > 0 {
+// 		fmt.Println("Received request from", info.TLS.PeerCertificates[0].Subject)
+// 	}
+type CallInfo encoding.CallInfo
+
+// Info returns the information about this request in a single struct. It
+// returns the zero value if the Call is nil.
+func (c *Call) Info() CallInfo {
+	return CallInfo((*encoding.Call)(c).Info())
+}
+
 // StreamOption defines options that may be passed in at streaming function
 // call sites.
 //
 // These may be used to add or alter individual stream calls.
 type StreamOption encoding.StreamOption
+
+// WithStreamContext bounds an individual Send or Receive on a stream by the
+// given context.
+//
+// 	ctx, cancel := context.WithTimeout(stream.Context(), time.Second)
+// 	defer cancel()
+// 	res, err := stream.Recv(yarpc.WithStreamContext(ctx))
+//
+// If the context ends before the message is sent or received, the operation
+// fails with a DeadlineExceeded or Cancelled error. The stream should not be
+// used after such a failure except to close it.
+func WithStreamContext(ctx context.Context) StreamOption {
+	return StreamOption(encoding.WithStreamContext(ctx))
+}
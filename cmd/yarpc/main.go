@@ -0,0 +1,183 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// yarpc is a command line tool that calls a unary YARPC procedure and prints
+// the response body. It is meant for debugging services without writing Go.
+//
+// Usage
+//
+//   yarpc -peer ADDR -service SERVICE -procedure PROCEDURE [FLAGS] [BODY]
+//
+// The request body is read from standard input if BODY is omitted. For
+// example,
+//
+//   yarpc -peer localhost:8080 -service keyvalue -procedure get '{"key": "foo"}'
+//
+// calls the "get" JSON procedure of the keyvalue service over HTTP.
+//
+// Procedures of protobuf services also accept JSON bodies, so they may be
+// called with the default json encoding, as in,
+//
+//   yarpc -peer localhost:8080 -transport grpc -service keyvalue \
+//     -procedure uber.yarpc.KeyValue::GetValue '{"key": "foo"}'
+//
+// Bodies of other encodings, like thrift, must be given already encoded. Use
+// the -hex flag to pass them, and print responses, as hexadecimal strings.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/yarpc/api/transport"
+)
+
+var errUsage = errors.New("usage: yarpc -peer ADDR -service SERVICE -procedure PROCEDURE [FLAGS] [BODY]")
+
+func main() {
+	log.SetFlags(0)
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// headers is a flag.Value that collects repeated "key=value" flags.
+type headers map[string]string
+
+func (h headers) String() string {
+	pairs := make([]string, 0, len(h))
+	for k, v := range h {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (h headers) Set(pair string) error {
+	i := strings.Index(pair, "=")
+	if i < 0 {
+		return fmt.Errorf("header %q must be in the form key=value", pair)
+	}
+	h[pair[:i]] = pair[i+1:]
+	return nil
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) (err error) {
+	flagSet := flag.NewFlagSet("yarpc", flag.ContinueOnError)
+	var (
+		transportName = flagSet.String("transport", "http", "Transport to call the procedure with: http, grpc, or tchannel")
+		peer          = flagSet.String("peer", "", "Address of the peer to call, as host:port or, for http, a URL")
+		service       = flagSet.String("service", "", "Name of the service to call")
+		procedure     = flagSet.String("procedure", "", "Name of the procedure to call")
+		encoding      = flagSet.String("encoding", "json", "Encoding of the request and response bodies")
+		caller        = flagSet.String("caller", "yarpc", "Name of the calling service")
+		shardKey      = flagSet.String("shard-key", "", "Shard key of the request")
+		routingKey    = flagSet.String("routing-key", "", "Routing key of the request")
+		timeout       = flagSet.Duration("timeout", time.Second, "Timeout of the request")
+		useHex        = flagSet.Bool("hex", false, "Decode the request body from and encode the response body as hexadecimal")
+		reqHeaders    = make(headers)
+	)
+	flagSet.Var(reqHeaders, "header", "Request header as key=value; may be repeated")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if *peer == "" || *service == "" || *procedure == "" || flagSet.NArg() > 1 {
+		return errUsage
+	}
+
+	var body []byte
+	if flagSet.NArg() == 1 {
+		body = []byte(flagSet.Arg(0))
+	} else if body, err = ioutil.ReadAll(stdin); err != nil {
+		return fmt.Errorf("failed to read request body: %v", err)
+	}
+	if *useHex {
+		if body, err = hex.DecodeString(strings.TrimSpace(string(body))); err != nil {
+			return fmt.Errorf("failed to decode request body: %v", err)
+		}
+	}
+
+	outbound, lifecycles, err := newOutbound(*transportName, *peer, *caller)
+	if err != nil {
+		return err
+	}
+	for i, lc := range lifecycles {
+		if err := lc.Start(); err != nil {
+			return multierr.Append(err, stop(lifecycles[:i]))
+		}
+	}
+	defer func() { err = multierr.Append(err, stop(lifecycles)) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	res, err := outbound.Call(ctx, &transport.Request{
+		Caller:     *caller,
+		Service:    *service,
+		Procedure:  *procedure,
+		Encoding:   transport.Encoding(*encoding),
+		Headers:    transport.HeadersFromMap(reqHeaders),
+		ShardKey:   *shardKey,
+		RoutingKey: *routingKey,
+		Body:       bytes.NewReader(body),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { err = multierr.Append(err, res.Body.Close()) }()
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %v", err)
+	}
+	if *useHex {
+		resBody = []byte(hex.EncodeToString(resBody))
+	}
+	if len(resBody) > 0 && resBody[len(resBody)-1] != '\n' {
+		resBody = append(resBody, '\n')
+	}
+	if _, err := stdout.Write(resBody); err != nil {
+		return err
+	}
+
+	if res.ApplicationError {
+		return errors.New("procedure returned an application error")
+	}
+	return nil
+}
+
+// stop stops the given lifecycles in reverse order.
+func stop(lifecycles []transport.Lifecycle) (err error) {
+	for i := len(lifecycles) - 1; i >= 0; i-- {
+		err = multierr.Append(err, lifecycles[i].Stop())
+	}
+	return err
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/encoding/json"
+	"go.uber.org/yarpc/encoding/raw"
+	"go.uber.org/yarpc/transport/grpc"
+	"go.uber.org/yarpc/transport/http"
+)
+
+type echoBody struct {
+	Message string `json:"message"`
+	Header  string `json:"header,omitempty"`
+}
+
+func newServer(t *testing.T, inbound transport.Inbound) *yarpc.Dispatcher {
+	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+		Name:     "echo",
+		Inbounds: yarpc.Inbounds{inbound},
+	})
+	dispatcher.Register(json.Procedure("echo", func(ctx context.Context, body *echoBody) (*echoBody, error) {
+		body.Header = yarpc.CallFromContext(ctx).Header("foo")
+		return body, nil
+	}))
+	dispatcher.Register(json.Procedure("fail", func(context.Context, *echoBody) (*echoBody, error) {
+		return &echoBody{Message: "sad"}, errors.New("great sadness")
+	}))
+	dispatcher.Register(raw.Procedure("raw", func(_ context.Context, body []byte) ([]byte, error) {
+		return append(body, 0xff), nil
+	}))
+	require.NoError(t, dispatcher.Start())
+	return dispatcher
+}
+
+func TestRun(t *testing.T) {
+	httpInbound := http.NewTransport().NewInbound("127.0.0.1:0")
+	httpServer := newServer(t, httpInbound)
+	defer httpServer.Stop()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	grpcTransport := grpc.NewTransport()
+	require.NoError(t, grpcTransport.Start())
+	defer grpcTransport.Stop()
+	grpcServer := newServer(t, grpcTransport.NewInbound(listener))
+	defer grpcServer.Stop()
+
+	peers := map[string]string{
+		"http": httpInbound.Addr().String(),
+		"grpc": listener.Addr().String(),
+	}
+	for transportName, peer := range peers {
+		t.Run(transportName, func(t *testing.T) {
+			args := []string{"-transport", transportName, "-peer", peer, "-service", "echo"}
+
+			tests := []struct {
+				desc    string
+				args    []string
+				stdin   string
+				want    string
+				wantErr string
+			}{
+				{
+					desc: "json body",
+					args: []string{"-procedure", "echo", `{"message": "hello"}`},
+					want: `{"message":"hello"}` + "\n",
+				},
+				{
+					desc:  "json body from stdin",
+					args:  []string{"-procedure", "echo"},
+					stdin: `{"message": "hello"}`,
+					want:  `{"message":"hello"}` + "\n",
+				},
+				{
+					desc: "headers",
+					args: []string{"-procedure", "echo", "-header", "foo=bar", `{"message": "hello"}`},
+					want: `{"message":"hello","header":"bar"}` + "\n",
+				},
+				{
+					desc: "hex body",
+					args: []string{"-procedure", "raw", "-encoding", "raw", "-hex", "0102"},
+					want: "0102ff\n",
+				},
+				{
+					desc:    "error",
+					args:    []string{"-procedure", "fail", "{}"},
+					wantErr: "great sadness",
+				},
+				{
+					desc:    "unknown procedure",
+					args:    []string{"-procedure", "unknown", "{}"},
+					wantErr: `unrecognized procedure "unknown" for service "echo"`,
+				},
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.desc, func(t *testing.T) {
+					var stdout bytes.Buffer
+					err := run(append(args, tt.args...), strings.NewReader(tt.stdin), &stdout)
+					if tt.wantErr != "" {
+						require.Error(t, err)
+						assert.Contains(t, err.Error(), tt.wantErr)
+						return
+					}
+					require.NoError(t, err)
+					assert.Equal(t, tt.want, stdout.String())
+				})
+			}
+		})
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		args    []string
+		wantErr string
+	}{
+		{
+			desc:    "missing peer",
+			args:    []string{"-service", "echo", "-procedure", "echo"},
+			wantErr: errUsage.Error(),
+		},
+		{
+			desc:    "too many arguments",
+			args:    []string{"-peer", "localhost:1", "-service", "echo", "-procedure", "echo", "{}", "{}"},
+			wantErr: errUsage.Error(),
+		},
+		{
+			desc:    "invalid header",
+			args:    []string{"-header", "foo"},
+			wantErr: `header "foo" must be in the form key=value`,
+		},
+		{
+			desc:    "invalid hex",
+			args:    []string{"-peer", "localhost:1", "-service", "echo", "-procedure", "echo", "-hex", "xyz"},
+			wantErr: "failed to decode request body",
+		},
+		{
+			desc:    "unknown transport",
+			args:    []string{"-transport", "carrier-pigeon", "-peer", "localhost:1", "-service", "echo", "-procedure", "echo", "{}"},
+			wantErr: `unknown transport "carrier-pigeon"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := run(tt.args, strings.NewReader(""), &bytes.Buffer{})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestHeadersFlag(t *testing.T) {
+	h := make(headers)
+	require.NoError(t, h.Set("b=2"))
+	require.NoError(t, h.Set("a=1=1"))
+	assert.Equal(t, "a=1=1,b=2", h.String())
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/transport/grpc"
+	"go.uber.org/yarpc/transport/http"
+	"go.uber.org/yarpc/transport/tchannel"
+)
+
+// newOutbound builds an outbound of the given transport to the given peer,
+// and the transport and outbound that must be started, in order, before it
+// is used.
+func newOutbound(transportName, peer, caller string) (transport.UnaryOutbound, []transport.Lifecycle, error) {
+	switch transportName {
+	case "http":
+		if !strings.Contains(peer, "://") {
+			peer = "http://" + peer
+		}
+		t := http.NewTransport()
+		o := t.NewSingleOutbound(peer)
+		return o, []transport.Lifecycle{t, o}, nil
+	case "grpc":
+		t := grpc.NewTransport()
+		o := t.NewSingleOutbound(peer)
+		return o, []transport.Lifecycle{t, o}, nil
+	case "tchannel":
+		t, err := tchannel.NewTransport(tchannel.ServiceName(caller))
+		if err != nil {
+			return nil, nil, err
+		}
+		o := t.NewSingleOutbound(peer)
+		return o, []transport.Lifecycle{t, o}, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown transport %q: must be http, grpc, or tchannel", transportName)
+	}
+}
@@ -179,4 +179,16 @@ type Config struct {
 	// observability middleware is being inserted in the Inbound/Outbound
 	// Middleware.
 	DisableAutoObservabilityMiddleware bool
+
+	// ErrorMapper, if set, is used to convert errors returned by handlers
+	// into YARPC errors before they are observed by inbound middleware and
+	// sent to callers.
+	//
+	// This may be nil if handlers only return YARPC errors or if the default
+	// behavior of reporting unknown errors with CodeUnknown is desired.
+	ErrorMapper ErrorMapper
+
+	// DisableDeadlineBudget stops the dispatcher from propagating the
+	// end-to-end deadline of requests with the DeadlineBudgetHeader.
+	DisableDeadlineBudget bool
 }
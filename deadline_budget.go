@@ -0,0 +1,196 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+// DeadlineBudgetHeader is the name of the header with which the dispatcher
+// propagates the remaining end-to-end deadline budget of a request, in
+// milliseconds.
+//
+// Transports carry the time to live of a request from one hop to the next,
+// but a caller may shorten the deadline of an individual call, with a
+// per-call timeout for example. The budget instead carries the deadline of
+// the original request through every hop, across all transports.
+const DeadlineBudgetHeader = "yarpc-deadline-budget-ms"
+
+type deadlineBudgetKey struct{}
+
+// EndToEndDeadline returns the end-to-end deadline of the request being
+// handled with the given context.
+//
+// This is the deadline of the original request, as propagated by the
+// dispatchers of the calling services, or the deadline of the context if no
+// budget was received.
+//
+// 	if deadline, ok := yarpc.EndToEndDeadline(ctx); ok && deadline.Sub(time.Now()) < minBatchTime {
+// 		return nil, errNotEnoughTime
+// 	}
+func EndToEndDeadline(ctx context.Context) (time.Time, bool) {
+	if deadline, ok := ctx.Value(deadlineBudgetKey{}).(time.Time); ok {
+		return deadline, true
+	}
+	return ctx.Deadline()
+}
+
+// deadlineBudgetMiddleware is inbound and outbound middleware that
+// propagates the end-to-end deadline of requests with the
+// DeadlineBudgetHeader.
+//
+// Inbound, it records the end-to-end deadline of the request in the context,
+// shortens the deadline of the context to it, and hides the header from
+// handlers. Outbound, it sends the
+// budget remaining before the end-to-end deadline.
+type deadlineBudgetMiddleware struct{}
+
+func (deadlineBudgetMiddleware) MiddlewareName() string { return "deadlinebudget" }
+
+func (deadlineBudgetMiddleware) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	if headers, ok := removeDeadlineBudget(req.Headers); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = withDeadlineBudget(ctx, req.Headers)
+		defer cancel()
+		r := *req
+		r.Headers = headers
+		req = &r
+	}
+	return h.Handle(ctx, req, resw)
+}
+
+func (deadlineBudgetMiddleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	if headers, ok := removeDeadlineBudget(req.Headers); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = withDeadlineBudget(ctx, req.Headers)
+		defer cancel()
+		r := *req
+		r.Headers = headers
+		req = &r
+	}
+	return h.HandleOneway(ctx, req)
+}
+
+func (deadlineBudgetMiddleware) HandleStream(s *transport.ServerStream, h transport.StreamHandler) error {
+	meta := s.Request().Meta
+	if headers, ok := removeDeadlineBudget(meta.Headers); ok {
+		ctx, cancel := withDeadlineBudget(s.Context(), meta.Headers)
+		defer cancel()
+		m := *meta
+		m.Headers = headers
+		s, _ = transport.NewServerStream(budgetStream{
+			ServerStream: s,
+			ctx:          ctx,
+			req:          &transport.StreamRequest{Meta: &m},
+		})
+	}
+	return h.HandleStream(s)
+}
+
+func (deadlineBudgetMiddleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	if headers, ok := addDeadlineBudget(ctx, req.Headers); ok {
+		r := *req
+		r.Headers = headers
+		req = &r
+	}
+	return out.Call(ctx, req)
+}
+
+func (deadlineBudgetMiddleware) CallOneway(ctx context.Context, req *transport.Request, out transport.OnewayOutbound) (transport.Ack, error) {
+	if headers, ok := addDeadlineBudget(ctx, req.Headers); ok {
+		r := *req
+		r.Headers = headers
+		req = &r
+	}
+	return out.CallOneway(ctx, req)
+}
+
+func (deadlineBudgetMiddleware) CallStream(ctx context.Context, req *transport.StreamRequest, out transport.StreamOutbound) (*transport.ClientStream, error) {
+	if headers, ok := addDeadlineBudget(ctx, req.Meta.Headers); ok {
+		meta := *req.Meta
+		meta.Headers = headers
+		req = &transport.StreamRequest{Meta: &meta}
+	}
+	return out.CallStream(ctx, req)
+}
+
+// removeDeadlineBudget returns a copy of the given headers without the
+// DeadlineBudgetHeader if they have one, so that handlers do not see it.
+func removeDeadlineBudget(headers transport.Headers) (transport.Headers, bool) {
+	if _, ok := headers.Get(DeadlineBudgetHeader); !ok {
+		return headers, false
+	}
+	headers = headers.Clone()
+	headers.Del(DeadlineBudgetHeader)
+	return headers, true
+}
+
+// withDeadlineBudget returns a context that records the end-to-end deadline
+// received in the given headers, if valid, and expires no later than it.
+func withDeadlineBudget(ctx context.Context, headers transport.Headers) (context.Context, context.CancelFunc) {
+	value, _ := headers.Get(DeadlineBudgetHeader)
+	budget, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || budget < 0 {
+		return ctx, func() {}
+	}
+
+	deadline := time.Now().Add(time.Duration(budget) * time.Millisecond)
+	ctx = context.WithValue(ctx, deadlineBudgetKey{}, deadline)
+	if d, ok := ctx.Deadline(); ok && !deadline.Before(d) {
+		return ctx, func() {}
+	}
+	return context.WithDeadline(ctx, deadline)
+}
+
+// addDeadlineBudget returns a copy of the given headers with the budget
+// remaining before the end-to-end deadline of the given context, if it has
+// one.
+func addDeadlineBudget(ctx context.Context, headers transport.Headers) (transport.Headers, bool) {
+	deadline, ok := EndToEndDeadline(ctx)
+	if !ok {
+		return headers, false
+	}
+	budget := deadline.Sub(time.Now()) / time.Millisecond
+	if budget < 0 {
+		budget = 0
+	}
+	return headers.Clone().With(DeadlineBudgetHeader, strconv.FormatInt(int64(budget), 10)), true
+}
+
+// budgetStream replaces the context and request of a stream.
+type budgetStream struct {
+	*transport.ServerStream
+
+	ctx context.Context
+	req *transport.StreamRequest
+}
+
+func (s budgetStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s budgetStream) Request() *transport.StreamRequest {
+	return s.req
+}
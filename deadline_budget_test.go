@@ -0,0 +1,151 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	. "go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+)
+
+type unaryHandlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f unaryHandlerFunc) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	return f(ctx, req, resw)
+}
+
+func TestDeadlineBudgetInbound(t *testing.T) {
+	tests := []struct {
+		desc         string
+		budget       string
+		wantDeadline time.Duration
+		wantEndToEnd time.Duration
+	}{
+		{desc: "no budget", wantDeadline: time.Hour, wantEndToEnd: time.Hour},
+		{desc: "budget", budget: "100", wantDeadline: 100 * time.Millisecond, wantEndToEnd: 100 * time.Millisecond},
+		{desc: "budget beyond deadline", budget: "7200000", wantDeadline: time.Hour, wantEndToEnd: 2 * time.Hour},
+		{desc: "invalid budget", budget: "soon", wantDeadline: time.Hour, wantEndToEnd: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			dispatcher := NewDispatcher(Config{Name: "test"})
+			ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+			defer cancel()
+			req := &transport.Request{
+				Service:   "test",
+				Procedure: "hello",
+				Headers:   transport.NewHeaders().With("foo", "bar"),
+			}
+			if tt.budget != "" {
+				req.Headers = req.Headers.With(DeadlineBudgetHeader, tt.budget)
+			}
+
+			var called bool
+			err := dispatcher.InboundMiddleware().Unary.Handle(ctx, req, nil, unaryHandlerFunc(
+				func(ctx context.Context, req *transport.Request, _ transport.ResponseWriter) error {
+					called = true
+					deadline, ok := ctx.Deadline()
+					require.True(t, ok)
+					assert.WithinDuration(t, time.Now().Add(tt.wantDeadline), deadline, time.Second)
+
+					endToEnd, ok := EndToEndDeadline(ctx)
+					require.True(t, ok)
+					assert.WithinDuration(t, time.Now().Add(tt.wantEndToEnd), endToEnd, time.Second)
+
+					assert.Equal(t, map[string]string{"foo": "bar"}, req.Headers.Items(),
+						"handlers must not see the budget")
+					return nil
+				}))
+			require.NoError(t, err)
+			assert.True(t, called)
+		})
+	}
+}
+
+func TestDeadlineBudgetPropagation(t *testing.T) {
+	out := transporttest.NewFakeOutbound()
+	out.ExpectCall("world")
+	dispatcher := NewDispatcher(Config{
+		Name:      "test",
+		Outbounds: Outbounds{"other": {Unary: out}},
+	})
+	client := dispatcher.ClientConfig("other").GetUnaryOutbound()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	req := &transport.Request{
+		Service:   "test",
+		Procedure: "hello",
+		Headers:   transport.NewHeaders().With(DeadlineBudgetHeader, "60000"),
+	}
+	err := dispatcher.InboundMiddleware().Unary.Handle(ctx, req, nil, unaryHandlerFunc(
+		func(ctx context.Context, _ *transport.Request, _ transport.ResponseWriter) error {
+			// A per-call timeout must not shorten the end-to-end budget.
+			ctx, cancel := context.WithTimeout(ctx, time.Second)
+			defer cancel()
+			_, err := client.Call(ctx, &transport.Request{
+				Caller:    "test",
+				Service:   "other",
+				Procedure: "world",
+				Encoding:  "raw",
+			})
+			return err
+		}))
+	require.NoError(t, err)
+
+	calls := out.Calls()
+	require.Len(t, calls, 1)
+	budget, ok := calls[0].Headers.Get(DeadlineBudgetHeader)
+	require.True(t, ok, "outbound request must carry the budget")
+	assert.Contains(t, []string{"59999", "60000"}, budget)
+}
+
+func TestDeadlineBudgetDisabled(t *testing.T) {
+	out := transporttest.NewFakeOutbound()
+	out.ExpectCall("world")
+	dispatcher := NewDispatcher(Config{
+		Name:                  "test",
+		Outbounds:             Outbounds{"other": {Unary: out}},
+		DisableDeadlineBudget: true,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := dispatcher.ClientConfig("other").GetUnaryOutbound().Call(ctx, &transport.Request{
+		Caller:    "test",
+		Service:   "other",
+		Procedure: "world",
+		Encoding:  "raw",
+	})
+	require.NoError(t, err)
+
+	calls := out.Calls()
+	require.Len(t, calls, 1)
+	_, ok := calls[0].Headers.Get(DeadlineBudgetHeader)
+	assert.False(t, ok)
+}
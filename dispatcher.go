@@ -23,15 +23,18 @@ package yarpc
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	"go.uber.org/multierr"
 	"go.uber.org/net/metrics"
 	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal"
 	"go.uber.org/yarpc/internal/inboundmiddleware"
 	"go.uber.org/yarpc/internal/observability"
 	"go.uber.org/yarpc/internal/outboundmiddleware"
+	"go.uber.org/yarpc/internal/peermetrics"
 	"go.uber.org/yarpc/internal/request"
 	"go.uber.org/yarpc/pkg/lifecycle"
 	"go.uber.org/zap"
@@ -79,22 +82,65 @@ func NewDispatcher(cfg Config) *Dispatcher {
 	extractor := cfg.Logging.extractor()
 
 	meter, stopMeter := cfg.Metrics.scope(cfg.Name, logger)
+	cfg = addErrorMapperMiddleware(cfg)
+	cfg = addDeadlineBudgetMiddleware(cfg)
 	cfg = addObservingMiddleware(cfg, meter, logger, extractor)
+	instrumentPeerLists(cfg.Outbounds, meter)
 
 	return &Dispatcher{
-		name:              cfg.Name,
-		table:             middleware.ApplyRouteTable(NewMapRouter(cfg.Name), cfg.RouterMiddleware),
-		inbounds:          cfg.Inbounds,
-		outbounds:         convertOutbounds(cfg.Outbounds, cfg.OutboundMiddleware),
-		transports:        collectTransports(cfg.Inbounds, cfg.Outbounds),
-		inboundMiddleware: cfg.InboundMiddleware,
-		log:               logger,
-		meter:             meter,
-		stopMeter:         stopMeter,
-		once:              lifecycle.NewOnce(),
+		name:               cfg.Name,
+		table:              middleware.ApplyRouteTable(NewMapRouter(cfg.Name), cfg.RouterMiddleware),
+		inbounds:           cfg.Inbounds,
+		outbounds:          convertOutbounds(cfg.Outbounds, cfg.OutboundMiddleware),
+		transports:         collectTransports(cfg.Inbounds, cfg.Outbounds),
+		inboundMiddleware:  cfg.InboundMiddleware,
+		outboundMiddleware: cfg.OutboundMiddleware,
+		log:                logger,
+		meter:              meter,
+		stopMeter:          stopMeter,
+		once:               lifecycle.NewOnce(),
 	}
 }
 
+// addErrorMapperMiddleware applies the configured ErrorMapper as the
+// innermost inbound middleware so that all other middleware observe the
+// mapped errors.
+func addErrorMapperMiddleware(cfg Config) Config {
+	if cfg.ErrorMapper == nil {
+		return cfg
+	}
+
+	mapper := errorMapperMiddleware{mapper: cfg.ErrorMapper}
+
+	cfg.InboundMiddleware.Unary = inboundmiddleware.UnaryChain(cfg.InboundMiddleware.Unary, mapper)
+	cfg.InboundMiddleware.Oneway = inboundmiddleware.OnewayChain(cfg.InboundMiddleware.Oneway, mapper)
+	cfg.InboundMiddleware.Stream = inboundmiddleware.StreamChain(cfg.InboundMiddleware.Stream, mapper)
+
+	return cfg
+}
+
+// addDeadlineBudgetMiddleware propagates end-to-end deadlines. Inbound, it is
+// applied before all other middleware so that they observe the end-to-end
+// deadline. Outbound, it is applied after all other middleware so that the
+// budget is computed as late as possible.
+func addDeadlineBudgetMiddleware(cfg Config) Config {
+	if cfg.DisableDeadlineBudget {
+		return cfg
+	}
+
+	budget := deadlineBudgetMiddleware{}
+
+	cfg.InboundMiddleware.Unary = inboundmiddleware.UnaryChain(budget, cfg.InboundMiddleware.Unary)
+	cfg.InboundMiddleware.Oneway = inboundmiddleware.OnewayChain(budget, cfg.InboundMiddleware.Oneway)
+	cfg.InboundMiddleware.Stream = inboundmiddleware.StreamChain(budget, cfg.InboundMiddleware.Stream)
+
+	cfg.OutboundMiddleware.Unary = outboundmiddleware.UnaryChain(cfg.OutboundMiddleware.Unary, budget)
+	cfg.OutboundMiddleware.Oneway = outboundmiddleware.OnewayChain(cfg.OutboundMiddleware.Oneway, budget)
+	cfg.OutboundMiddleware.Stream = outboundmiddleware.StreamChain(cfg.OutboundMiddleware.Stream, budget)
+
+	return cfg
+}
+
 func addObservingMiddleware(cfg Config, meter *metrics.Scope, logger *zap.Logger, extractor observability.ContextExtractor) Config {
 	if cfg.DisableAutoObservabilityMiddleware {
 		return cfg
@@ -160,6 +206,42 @@ func convertOutbounds(outbounds Outbounds, mw OutboundMiddleware) Outbounds {
 	return outboundSpecs
 }
 
+// instrumentPeerLists reports metrics for the peer lists of all outbounds
+// that choose peers through an instrumentable peer list. A peer list shared
+// by several outbounds is instrumented once, tagged with the first outbound
+// key that uses it.
+func instrumentPeerLists(outbounds Outbounds, meter *metrics.Scope) {
+	outboundKeys := make([]string, 0, len(outbounds))
+	for outboundKey := range outbounds {
+		outboundKeys = append(outboundKeys, outboundKey)
+	}
+	sort.Strings(outboundKeys)
+
+	type chooserOutbound interface {
+		Chooser() peer.Chooser
+	}
+
+	instrumented := make(map[peermetrics.Instrumented]struct{})
+	for _, outboundKey := range outboundKeys {
+		outs := outbounds[outboundKey]
+		for _, o := range []interface{}{outs.Unary, outs.Oneway, outs.Stream} {
+			co, ok := o.(chooserOutbound)
+			if !ok {
+				continue
+			}
+			list, ok := co.Chooser().(peermetrics.Instrumented)
+			if !ok {
+				continue
+			}
+			if _, ok := instrumented[list]; ok {
+				continue
+			}
+			instrumented[list] = struct{}{}
+			list.Instrument(meter.Tagged(metrics.Tags{"outbound": outboundKey}))
+		}
+	}
+}
+
 // collectTransports iterates over all inbounds and outbounds and collects all
 // of their unique underlying transports. Multiple inbounds and outbounds may
 // share a transport, and we only want the dispatcher to manage their lifecycle
@@ -205,7 +287,8 @@ type Dispatcher struct {
 	outbounds  Outbounds
 	transports []transport.Transport
 
-	inboundMiddleware InboundMiddleware
+	inboundMiddleware  InboundMiddleware
+	outboundMiddleware OutboundMiddleware
 
 	log       *zap.Logger
 	meter     *metrics.Scope
@@ -309,6 +392,14 @@ func (d *Dispatcher) Register(rs []transport.Procedure) {
 				r.HandlerSpec.Type(), r.Service, r.Name))
 		}
 
+		if r.Metadata.Idempotent {
+			service := r.Service
+			if service == "" {
+				service = d.name
+			}
+			RegisterIdempotentProcedures(service, r.Name)
+		}
+
 		procedures = append(procedures, r)
 		d.log.Info("Registration succeeded.", zap.Object("registeredProcedure", r))
 	}
@@ -339,6 +430,9 @@ func (d *Dispatcher) Start() error {
 	}
 	return d.once.Start(func() error {
 		d.log.Info("starting dispatcher")
+		if err := d.validateMiddleware(); err != nil {
+			return err
+		}
 		starter.setRouters()
 		if err := starter.StartTransports(); err != nil {
 			return err
@@ -370,6 +464,9 @@ func (d *Dispatcher) PhasedStart() (*PhasedStarter, error) {
 	}
 	if err := d.once.Start(func() error {
 		starter.log.Info("beginning phased dispatcher start")
+		if err := d.validateMiddleware(); err != nil {
+			return err
+		}
 		starter.setRouters()
 		return nil
 	}); err != nil {
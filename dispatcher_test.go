@@ -112,6 +112,27 @@ func TestDispatcherRegisterPanic(t *testing.T) {
 	}, "expected unknown handler type to panic")
 }
 
+func TestDispatcherRegistersIdempotentProcedures(t *testing.T) {
+	d := basicDispatcher(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	h := transport.NewUnaryHandlerSpec(transporttest.NewMockUnaryHandler(mockCtrl))
+	d.Register([]transport.Procedure{
+		{Name: "get", HandlerSpec: h, Metadata: transport.ProcedureMetadata{Idempotent: true}},
+		{Name: "set", HandlerSpec: h},
+		{Name: "list", Service: "other", HandlerSpec: h, Metadata: transport.ProcedureMetadata{Idempotent: true}},
+	})
+	// Remove the registrations from the global registry afterwards.
+	defer RegisterIdempotentProcedures(d.Name(), "get")()
+	defer RegisterIdempotentProcedures("other", "list")()
+
+	assert.True(t, IsIdempotentProcedure(d.Name(), "get"))
+	assert.False(t, IsIdempotentProcedure(d.Name(), "set"))
+	assert.True(t, IsIdempotentProcedure("other", "list"))
+	assert.False(t, IsIdempotentProcedure(d.Name(), "list"))
+}
+
 func TestInboundsReturnsACopy(t *testing.T) {
 	dispatcher := basicDispatcher(t)
 
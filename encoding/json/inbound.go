@@ -39,6 +39,20 @@ import (
 type jsonHandler struct {
 	reader  requestReader
 	handler reflect.Value
+
+	reqBodyType reflect.Type
+	resBodyType reflect.Type
+}
+
+// RequestType returns the type of request bodies accepted by this handler.
+func (h jsonHandler) RequestType() reflect.Type {
+	return h.reqBodyType
+}
+
+// ResponseType returns the type of response bodies produced by this
+// handler, or nil for oneway handlers.
+func (h jsonHandler) ResponseType() reflect.Type {
+	return h.resBodyType
 }
 
 func (h jsonHandler) Handle(ctx context.Context, treq *transport.Request, rw transport.ResponseWriter) error {
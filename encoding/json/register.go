@@ -83,18 +83,21 @@ func OnewayProcedure(name string, handler interface{}) []transport.Procedure {
 // wrapUnaryHandler takes a valid JSON handler function and converts it into a
 // transport.UnaryHandler.
 func wrapUnaryHandler(name string, handler interface{}) transport.UnaryHandler {
-	reqBodyType := verifyUnarySignature(name, reflect.TypeOf(handler))
-	return newJSONHandler(reqBodyType, handler)
+	handlerType := reflect.TypeOf(handler)
+	reqBodyType := verifyUnarySignature(name, handlerType)
+	return newJSONHandler(reqBodyType, handlerType.Out(0), handler)
 }
 
 // wrapOnewayHandler takes a valid JSON handler function and converts it into a
 // transport.OnewayHandler.
 func wrapOnewayHandler(name string, handler interface{}) transport.OnewayHandler {
 	reqBodyType := verifyOnewaySignature(name, reflect.TypeOf(handler))
-	return newJSONHandler(reqBodyType, handler)
+	return newJSONHandler(reqBodyType, nil, handler)
 }
 
-func newJSONHandler(reqBodyType reflect.Type, handler interface{}) jsonHandler {
+// newJSONHandler builds a jsonHandler for the given handler function. resBodyType
+// is nil for oneway handlers.
+func newJSONHandler(reqBodyType, resBodyType reflect.Type, handler interface{}) jsonHandler {
 	var r requestReader
 	if reqBodyType == _interfaceEmptyType {
 		r = ifaceEmptyReader{}
@@ -106,8 +109,10 @@ func newJSONHandler(reqBodyType reflect.Type, handler interface{}) jsonHandler {
 	}
 
 	return jsonHandler{
-		reader:  r,
-		handler: reflect.ValueOf(handler),
+		reader:      r,
+		handler:     reflect.ValueOf(handler),
+		reqBodyType: reqBodyType,
+		resBodyType: resBodyType,
 	}
 }
 
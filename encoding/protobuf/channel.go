@@ -0,0 +1,107 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package protobuf
+
+import (
+	"context"
+	"io"
+
+	"github.com/gogo/protobuf/proto"
+)
+
+// ReceiveToChannel receives messages from a stream in a background goroutine
+// and delivers them on the returned channel. The receive function is
+// typically the Recv method of a generated stream, wrapped to return a
+// proto.Message:
+//
+// 	messages, errs := protobuf.ReceiveToChannel(ctx, 10, func() (proto.Message, error) {
+// 		return stream.Recv()
+// 	})
+// 	for msg := range messages {
+// 		process(msg.(*examplepb.GetValueResponse))
+// 	}
+// 	if err := <-errs; err != nil {
+// 		return err
+// 	}
+//
+// At most buffer messages are queued on the channel. When the channel is
+// full, no further messages are received from the stream until the consumer
+// catches up, so the transport's flow control applies backpressure to the
+// sender.
+//
+// The messages channel is closed when the stream ends or fails. Exactly one
+// value is then sent on the error channel: nil if the stream ended with
+// io.EOF, and the error otherwise. ctx only stops the goroutine while it
+// waits for room on the channel, dropping the message it holds; it does not
+// interrupt a receive in progress. Bound receives with
+// yarpc.WithStreamContext, or cancel the stream, to stop those.
+func ReceiveToChannel(ctx context.Context, buffer int, receive func() (proto.Message, error)) (<-chan proto.Message, <-chan error) {
+	messages := make(chan proto.Message, buffer)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(messages)
+		for {
+			message, err := receive()
+			if err != nil {
+				if err == io.EOF {
+					err = nil
+				}
+				errs <- err
+				return
+			}
+			select {
+			case messages <- message:
+			case <-ctx.Done():
+				errs <- contextError(ctx.Err())
+				return
+			}
+		}
+	}()
+	return messages, errs
+}
+
+// SendFromChannel sends every message received on the given channel to a
+// stream until the channel is closed, sending fails, or ctx is done. The send
+// function is typically the Send method of a generated stream.
+//
+// 	err := protobuf.SendFromChannel(ctx, requests, func(msg proto.Message) error {
+// 		return stream.Send(msg.(*examplepb.SetValueRequest))
+// 	})
+//
+// Because messages are taken off the channel only after the previous message
+// has been sent, producers block while the stream is applying backpressure.
+// SendFromChannel does not close the stream.
+func SendFromChannel(ctx context.Context, messages <-chan proto.Message, send func(proto.Message) error) error {
+	for {
+		select {
+		case message, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			if err := send(message); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return contextError(ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package protobuf
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+func receiveFrom(messages []proto.Message, finalErr error) func() (proto.Message, error) {
+	return func() (proto.Message, error) {
+		if len(messages) == 0 {
+			return nil, finalErr
+		}
+		msg := messages[0]
+		messages = messages[1:]
+		return msg, nil
+	}
+}
+
+func TestReceiveToChannel(t *testing.T) {
+	want := []proto.Message{
+		&types.StringValue{Value: "a"},
+		&types.StringValue{Value: "b"},
+		&types.StringValue{Value: "c"},
+	}
+
+	tests := []struct {
+		desc     string
+		finalErr error
+		wantErr  error
+	}{
+		{desc: "end of stream", finalErr: io.EOF},
+		{desc: "stream error", finalErr: errors.New("great sadness"), wantErr: errors.New("great sadness")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			messages, errs := ReceiveToChannel(context.Background(), 1, receiveFrom(want, tt.finalErr))
+
+			var got []proto.Message
+			for msg := range messages {
+				got = append(got, msg)
+			}
+			assert.Equal(t, want, got)
+			assert.Equal(t, tt.wantErr, <-errs)
+		})
+	}
+}
+
+func TestReceiveToChannelContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Nobody reads from the unbuffered channel, so the receiver blocks until
+	// the context is canceled.
+	messages, errs := ReceiveToChannel(ctx, 0, func() (proto.Message, error) {
+		return &types.StringValue{Value: "a"}, nil
+	})
+	cancel()
+
+	err := <-errs
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeCancelled, yarpcerrors.FromError(err).Code())
+	_, ok := <-messages
+	assert.False(t, ok, "messages channel must be closed")
+}
+
+func TestSendFromChannel(t *testing.T) {
+	messages := make(chan proto.Message, 2)
+	messages <- &types.StringValue{Value: "a"}
+	messages <- &types.StringValue{Value: "b"}
+	close(messages)
+
+	var sent []proto.Message
+	err := SendFromChannel(context.Background(), messages, func(msg proto.Message) error {
+		sent = append(sent, msg)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []proto.Message{
+		&types.StringValue{Value: "a"},
+		&types.StringValue{Value: "b"},
+	}, sent)
+}
+
+func TestSendFromChannelErrors(t *testing.T) {
+	t.Run("send error", func(t *testing.T) {
+		messages := make(chan proto.Message, 1)
+		messages <- &types.StringValue{Value: "a"}
+
+		err := SendFromChannel(context.Background(), messages, func(proto.Message) error {
+			return errors.New("great sadness")
+		})
+		assert.EqualError(t, err, "great sadness")
+	})
+
+	t.Run("context done", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+
+		err := SendFromChannel(ctx, make(chan proto.Message), func(proto.Message) error {
+			return nil
+		})
+		require.Error(t, err)
+		assert.Equal(t, yarpcerrors.CodeDeadlineExceeded, yarpcerrors.FromError(err).Code())
+	})
+}
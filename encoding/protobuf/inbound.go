@@ -22,6 +22,7 @@ package protobuf
 
 import (
 	"context"
+	"reflect"
 
 	"github.com/gogo/protobuf/proto"
 	apiencoding "go.uber.org/yarpc/api/encoding"
@@ -73,6 +74,17 @@ func (u *unaryHandler) Handle(ctx context.Context, transportRequest *transport.R
 	return appErr
 }
 
+// RequestType returns the type of request messages accepted by this handler.
+func (u *unaryHandler) RequestType() reflect.Type {
+	return reflect.TypeOf(u.newRequest())
+}
+
+// ResponseType returns nil because the type of response messages is not
+// known until the handler is called.
+func (u *unaryHandler) ResponseType() reflect.Type {
+	return nil
+}
+
 type onewayHandler struct {
 	handleOneway func(context.Context, proto.Message) error
 	newRequest   func() proto.Message
@@ -93,6 +105,16 @@ func (o *onewayHandler) HandleOneway(ctx context.Context, transportRequest *tran
 	return o.handleOneway(ctx, request)
 }
 
+// RequestType returns the type of request messages accepted by this handler.
+func (o *onewayHandler) RequestType() reflect.Type {
+	return reflect.TypeOf(o.newRequest())
+}
+
+// ResponseType returns nil because oneway handlers do not respond.
+func (o *onewayHandler) ResponseType() reflect.Type {
+	return nil
+}
+
 type streamHandler struct {
 	handle func(*ServerStream) error
 }
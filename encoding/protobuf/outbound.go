@@ -186,9 +186,13 @@ func (c *client) CallStream(
 	if streamOutbound == nil {
 		return nil, yarpcerrors.InternalErrorf("no stream outbounds for OutboundConfig %s", c.outboundConfig.CallerName)
 	}
+	// Stream operations that time out cancel the stream so that they do not
+	// linger in the background.
+	ctx, cancel := context.WithCancel(ctx)
 	stream, err := streamOutbound.CallStream(ctx, streamRequest)
 	if err != nil {
+		cancel()
 		return nil, err
 	}
-	return &ClientStream{stream: stream}, nil
+	return &ClientStream{stream: stream, call: call, guard: streamGuard{cancel: cancel}}, nil
 }
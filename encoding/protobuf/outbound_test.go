@@ -99,7 +99,7 @@ func TestNoStreamOutbound(t *testing.T) {
 	assert.Contains(t, err.Error(), "no stream outbounds for OutboundConfig")
 }
 
-func TestNoResponseHeaders(t *testing.T) {
+func TestStreamResponseHeadersAllowed(t *testing.T) {
 	client := &client{
 		serviceName: "test",
 		outboundConfig: &transport.OutboundConfig{
@@ -112,6 +112,8 @@ func TestNoResponseHeaders(t *testing.T) {
 
 	_, err := client.CallStream(context.Background(), "somemethod", yarpc.ResponseHeaders(&headers))
 
-	assert.Contains(t, err.Error(), "code:invalid-argument")
-	assert.Contains(t, err.Error(), "response headers are not supported for streams")
+	// Response headers are allowed on streams; the call fails only because
+	// there is no stream outbound.
+	assert.Contains(t, err.Error(), "code:internal")
+	assert.Contains(t, err.Error(), "no stream outbounds for OutboundConfig")
 }
@@ -22,11 +22,14 @@ package protobuf
 
 import (
 	"context"
+	"io"
 	"reflect"
 	"strings"
 
 	"github.com/gogo/protobuf/proto"
+	"go.uber.org/atomic"
 	"go.uber.org/yarpc"
+	apiencoding "go.uber.org/yarpc/api/encoding"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/pkg/procedure"
 	"go.uber.org/yarpc/yarpcerrors"
@@ -59,18 +62,21 @@ type BuildProceduresParams struct {
 type BuildProceduresUnaryHandlerParams struct {
 	MethodName string
 	Handler    transport.UnaryHandler
+	Metadata   transport.ProcedureMetadata
 }
 
 // BuildProceduresOnewayHandlerParams contains the parameters for a OnewayHandler for BuildProcedures.
 type BuildProceduresOnewayHandlerParams struct {
 	MethodName string
 	Handler    transport.OnewayHandler
+	Metadata   transport.ProcedureMetadata
 }
 
 // BuildProceduresStreamHandlerParams contains the parameters for a StreamHandler for BuildProcedures.
 type BuildProceduresStreamHandlerParams struct {
 	MethodName string
 	Handler    transport.StreamHandler
+	Metadata   transport.ProcedureMetadata
 }
 
 // BuildProcedures builds the transport.Procedures.
@@ -83,11 +89,13 @@ func BuildProcedures(params BuildProceduresParams) []transport.Procedure {
 				Name:        procedure.ToName(params.ServiceName, unaryHandlerParams.MethodName),
 				HandlerSpec: transport.NewUnaryHandlerSpec(unaryHandlerParams.Handler),
 				Encoding:    Encoding,
+				Metadata:    unaryHandlerParams.Metadata,
 			},
 			transport.Procedure{
 				Name:        procedure.ToName(params.ServiceName, unaryHandlerParams.MethodName),
 				HandlerSpec: transport.NewUnaryHandlerSpec(unaryHandlerParams.Handler),
 				Encoding:    JSONEncoding,
+				Metadata:    unaryHandlerParams.Metadata,
 			},
 		)
 	}
@@ -98,11 +106,13 @@ func BuildProcedures(params BuildProceduresParams) []transport.Procedure {
 				Name:        procedure.ToName(params.ServiceName, onewayHandlerParams.MethodName),
 				HandlerSpec: transport.NewOnewayHandlerSpec(onewayHandlerParams.Handler),
 				Encoding:    Encoding,
+				Metadata:    onewayHandlerParams.Metadata,
 			},
 			transport.Procedure{
 				Name:        procedure.ToName(params.ServiceName, onewayHandlerParams.MethodName),
 				HandlerSpec: transport.NewOnewayHandlerSpec(onewayHandlerParams.Handler),
 				Encoding:    JSONEncoding,
+				Metadata:    onewayHandlerParams.Metadata,
 			},
 		)
 	}
@@ -113,11 +123,13 @@ func BuildProcedures(params BuildProceduresParams) []transport.Procedure {
 				Name:        procedure.ToName(params.ServiceName, streamHandlerParams.MethodName),
 				HandlerSpec: transport.NewStreamHandlerSpec(streamHandlerParams.Handler),
 				Encoding:    Encoding,
+				Metadata:    streamHandlerParams.Metadata,
 			},
 			transport.Procedure{
 				Name:        procedure.ToName(params.ServiceName, streamHandlerParams.MethodName),
 				HandlerSpec: transport.NewStreamHandlerSpec(streamHandlerParams.Handler),
 				Encoding:    JSONEncoding,
+				Metadata:    streamHandlerParams.Metadata,
 			},
 		)
 	}
@@ -247,6 +259,12 @@ func uniqueLowercaseStrings(s []string) []string {
 // ClientStream is a protobuf-specific client stream.
 type ClientStream struct {
 	stream *transport.ClientStream
+	call   *apiencoding.OutboundCall
+	guard  streamGuard
+
+	// Response headers are read once the first message has been received.
+	headersRead bool
+	sendClosed  atomic.Bool
 }
 
 // Context returns the context of the stream.
@@ -255,24 +273,48 @@ func (c *ClientStream) Context() context.Context {
 }
 
 // Receive will receive a protobuf message from the client stream.
+//
+// The yarpc.WithStreamContext option may be used to bound how long Receive
+// waits for a message. If it times out, the stream is cancelled and fails
+// all further operations.
 func (c *ClientStream) Receive(newMessage func() proto.Message, options ...yarpc.StreamOption) (proto.Message, error) {
-	return readFromStream(context.Background(), c.stream, newMessage)
+	message, err := readFromStream(streamContext(options), c.stream, &c.guard, newMessage)
+	if c.call != nil && !c.headersRead {
+		c.headersRead = true
+		// The headers are available after the first receive, even if the
+		// stream failed; errors reading them do not change the outcome of
+		// the receive.
+		_, _ = c.call.ReadFromStream(c.stream.Context(), c.stream)
+	}
+	return message, err
 }
 
 // Send will send a protobuf message to the client stream.
+//
+// Send returns io.EOF if the sending side of the stream has been closed.
 func (c *ClientStream) Send(message proto.Message, options ...yarpc.StreamOption) error {
-	return writeToStream(context.Background(), c.stream, message)
+	if c.sendClosed.Load() {
+		return io.EOF
+	}
+	return writeToStream(streamContext(options), c.stream, &c.guard, message)
 }
 
-// Close will close the protobuf stream.
+// Close will close the sending side of the protobuf stream.
+//
+// Messages already sent by the server may still be received until Receive
+// returns io.EOF. Calling Close more than once is a no-op.
 func (c *ClientStream) Close(options ...yarpc.StreamOption) error {
-	return c.stream.Close(context.Background())
+	if c.sendClosed.Swap(true) {
+		return nil
+	}
+	return c.stream.Close(streamContext(options))
 }
 
 // ServerStream is a protobuf-specific server stream.
 type ServerStream struct {
 	ctx    context.Context
 	stream *transport.ServerStream
+	guard  streamGuard
 }
 
 // Context returns the context of the stream.
@@ -281,11 +323,22 @@ func (s *ServerStream) Context() context.Context {
 }
 
 // Receive will receive a protobuf message from the server stream.
+//
+// If the yarpc.WithStreamContext option times out, the stream fails all
+// further operations, and the handler should return to end it.
 func (s *ServerStream) Receive(newMessage func() proto.Message, options ...yarpc.StreamOption) (proto.Message, error) {
-	return readFromStream(context.Background(), s.stream, newMessage)
+	return readFromStream(streamContext(options), s.stream, &s.guard, newMessage)
 }
 
 // Send will send a protobuf message to the server stream.
 func (s *ServerStream) Send(message proto.Message, options ...yarpc.StreamOption) error {
-	return writeToStream(context.Background(), s.stream, message)
+	return writeToStream(streamContext(options), s.stream, &s.guard, message)
+}
+
+// SendHeaders sends the given headers to the client. It must be called
+// before the first message is sent on the stream.
+//
+// Clients may read these headers with the yarpc.ResponseHeaders call option.
+func (s *ServerStream) SendHeaders(headers map[string]string) error {
+	return s.stream.SendHeaders(transport.HeadersFromMap(headers))
 }
@@ -0,0 +1,250 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package lib
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"go.uber.org/yarpc/internal/protoplugin"
+)
+
+const gomockTmpl = `{{$packageName := goPackageName .GoPackage}}
+// Code generated by protoc-gen-yarpc-go
+// source: {{.GetName}}
+// DO NOT EDIT!
+
+package {{.GoPackage.Name}}test
+
+import (
+	{{range $i := gomockImports .}}{{if $i.Standard}}{{$i | printf "%s\n"}}{{end}}{{end}}
+
+	{{range $i := gomockImports .}}{{if not $i.Standard}}{{$i | printf "%s\n"}}{{end}}{{end}}
+)
+
+{{range $service := .Services}}
+// Mock{{$service.GetName}}YARPCClient implements a gomock-compatible mock client for service {{$service.GetName}}.
+type Mock{{$service.GetName}}YARPCClient struct {
+	ctrl     *gomock.Controller
+	recorder *_Mock{{$service.GetName}}YARPCClientRecorder
+}
+
+var _ {{$packageName}}.{{$service.GetName}}YARPCClient = (*Mock{{$service.GetName}}YARPCClient)(nil)
+
+type _Mock{{$service.GetName}}YARPCClientRecorder struct {
+	mock *Mock{{$service.GetName}}YARPCClient
+}
+
+// NewMock{{$service.GetName}}YARPCClient builds a new mock client for service {{$service.GetName}}.
+//
+// 	mockCtrl := gomock.NewController(t)
+// 	client := {{$.GoPackage.Name}}test.NewMock{{$service.GetName}}YARPCClient(mockCtrl)
+//
+// Use EXPECT() to set expectations on the mock.
+func NewMock{{$service.GetName}}YARPCClient(ctrl *gomock.Controller) *Mock{{$service.GetName}}YARPCClient {
+	mock := &Mock{{$service.GetName}}YARPCClient{ctrl: ctrl}
+	mock.recorder = &_Mock{{$service.GetName}}YARPCClientRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows you to define an expectation on the
+// {{$service.GetName}} mock client.
+func (m *Mock{{$service.GetName}}YARPCClient) EXPECT() *_Mock{{$service.GetName}}YARPCClientRecorder {
+	return m.recorder
+}
+{{range $method := unaryMethods $service}}
+// {{$method.GetName}} responds to a {{$method.GetName}} call based on the mock expectations.
+func (m *Mock{{$service.GetName}}YARPCClient) {{$method.GetName}}(ctx context.Context, request *{{$method.RequestType.GoType ""}}, options ...yarpc.CallOption) (*{{$method.ResponseType.GoType ""}}, error) {
+	args := []interface{}{ctx, request}
+	for _, o := range options {
+		args = append(args, o)
+	}
+	ret := m.ctrl.Call(m, "{{$method.GetName}}", args...)
+	response, _ := ret[0].(*{{$method.ResponseType.GoType ""}})
+	err, _ := ret[1].(error)
+	return response, err
+}
+
+// {{$method.GetName}} records an expected call to {{$method.GetName}}.
+func (mr *_Mock{{$service.GetName}}YARPCClientRecorder) {{$method.GetName}}(ctx interface{}, request interface{}, options ...interface{}) *gomock.Call {
+	args := append([]interface{}{ctx, request}, options...)
+	return mr.mock.ctrl.RecordCall(mr.mock, "{{$method.GetName}}", args...)
+}
+{{end}}{{range $method := onewayMethods $service}}
+// {{$method.GetName}} responds to a {{$method.GetName}} call based on the mock expectations.
+func (m *Mock{{$service.GetName}}YARPCClient) {{$method.GetName}}(ctx context.Context, request *{{$method.RequestType.GoType ""}}, options ...yarpc.CallOption) (yarpc.Ack, error) {
+	args := []interface{}{ctx, request}
+	for _, o := range options {
+		args = append(args, o)
+	}
+	ret := m.ctrl.Call(m, "{{$method.GetName}}", args...)
+	ack, _ := ret[0].(yarpc.Ack)
+	err, _ := ret[1].(error)
+	return ack, err
+}
+
+// {{$method.GetName}} records an expected call to {{$method.GetName}}.
+func (mr *_Mock{{$service.GetName}}YARPCClientRecorder) {{$method.GetName}}(ctx interface{}, request interface{}, options ...interface{}) *gomock.Call {
+	args := append([]interface{}{ctx, request}, options...)
+	return mr.mock.ctrl.RecordCall(mr.mock, "{{$method.GetName}}", args...)
+}
+{{end}}{{range $method := serverStreamingMethods $service}}
+// {{$method.GetName}} responds to a {{$method.GetName}} call based on the mock expectations.
+func (m *Mock{{$service.GetName}}YARPCClient) {{$method.GetName}}(ctx context.Context, request *{{$method.RequestType.GoType ""}}, options ...yarpc.CallOption) ({{$packageName}}.{{$service.GetName}}Service{{$method.GetName}}YARPCClient, error) {
+	args := []interface{}{ctx, request}
+	for _, o := range options {
+		args = append(args, o)
+	}
+	ret := m.ctrl.Call(m, "{{$method.GetName}}", args...)
+	stream, _ := ret[0].({{$packageName}}.{{$service.GetName}}Service{{$method.GetName}}YARPCClient)
+	err, _ := ret[1].(error)
+	return stream, err
+}
+
+// {{$method.GetName}} records an expected call to {{$method.GetName}}.
+func (mr *_Mock{{$service.GetName}}YARPCClientRecorder) {{$method.GetName}}(ctx interface{}, request interface{}, options ...interface{}) *gomock.Call {
+	args := append([]interface{}{ctx, request}, options...)
+	return mr.mock.ctrl.RecordCall(mr.mock, "{{$method.GetName}}", args...)
+}
+{{end}}{{range $method := clientStreamingMethods $service}}{{template "streamMethod" (methodInfo $packageName $service $method)}}{{end}}{{range $method := clientServerStreamingMethods $service}}{{template "streamMethod" (methodInfo $packageName $service $method)}}{{end}}
+{{end}}
+
+{{define "streamMethod"}}
+// {{.Method.GetName}} responds to a {{.Method.GetName}} call based on the mock expectations.
+func (m *Mock{{.Service.GetName}}YARPCClient) {{.Method.GetName}}(ctx context.Context, options ...yarpc.CallOption) ({{.PackageName}}.{{.Service.GetName}}Service{{.Method.GetName}}YARPCClient, error) {
+	args := []interface{}{ctx}
+	for _, o := range options {
+		args = append(args, o)
+	}
+	ret := m.ctrl.Call(m, "{{.Method.GetName}}", args...)
+	stream, _ := ret[0].({{.PackageName}}.{{.Service.GetName}}Service{{.Method.GetName}}YARPCClient)
+	err, _ := ret[1].(error)
+	return stream, err
+}
+
+// {{.Method.GetName}} records an expected call to {{.Method.GetName}}.
+func (mr *_Mock{{.Service.GetName}}YARPCClientRecorder) {{.Method.GetName}}(ctx interface{}, options ...interface{}) *gomock.Call {
+	args := append([]interface{}{ctx}, options...)
+	return mr.mock.ctrl.RecordCall(mr.mock, "{{.Method.GetName}}", args...)
+}
+{{end}}
+`
+
+// _gomockBaseImports are the imports used by every generated mock.
+var _gomockBaseImports = []string{
+	"context",
+	"github.com/golang/mock/gomock",
+	"go.uber.org/yarpc",
+}
+
+func newGomockRunner() protoplugin.Runner {
+	return protoplugin.NewRunner(
+		template.Must(template.New("tmpl").Funcs(
+			template.FuncMap{
+				"unaryMethods":                 unaryMethods,
+				"onewayMethods":                onewayMethods,
+				"clientStreamingMethods":       clientStreamingMethods,
+				"serverStreamingMethods":       serverStreamingMethods,
+				"clientServerStreamingMethods": clientServerStreamingMethods,
+				"goPackageName":                goPackageName,
+				"gomockImports":                gomockImports,
+				"methodInfo":                   newGomockMethodInfo,
+			}).Parse(gomockTmpl)),
+		checkGomockTemplateInfo,
+		_gomockBaseImports,
+		func(file *protoplugin.File) (string, error) {
+			// foo/kv.proto => foo/kvpbtest/kv.pb.yarpc.go
+			name := file.GetName()
+			base := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+			return filepath.Join(
+				filepath.Dir(name),
+				file.GoPackage.Name+"test",
+				fmt.Sprintf("%s.pb.yarpc.go", base),
+			), nil
+		},
+		func(key string, value string) error {
+			return nil
+		},
+	)
+}
+
+// checkGomockTemplateInfo skips files without services, for which there is
+// nothing to mock.
+func checkGomockTemplateInfo(templateInfo *protoplugin.TemplateInfo) error {
+	if len(templateInfo.Services) == 0 {
+		return protoplugin.ErrNoTargetService
+	}
+	return nil
+}
+
+type gomockMethodInfo struct {
+	PackageName string
+	Service     *protoplugin.Service
+	Method      *protoplugin.Method
+}
+
+func newGomockMethodInfo(packageName string, service *protoplugin.Service, method *protoplugin.Method) *gomockMethodInfo {
+	return &gomockMethodInfo{
+		PackageName: packageName,
+		Service:     service,
+		Method:      method,
+	}
+}
+
+// goPackageName returns the name by which the given package is referred to
+// in generated code.
+func goPackageName(pkg *protoplugin.GoPackage) string {
+	if pkg.Alias != "" {
+		return pkg.Alias
+	}
+	return pkg.Name
+}
+
+// gomockImports returns the imports needed by the mocks for the given file:
+// the base imports, the package for which mocks are generated, and the
+// packages of the messages that appear in the mocked method signatures.
+func gomockImports(info *protoplugin.TemplateInfo) []*protoplugin.GoPackage {
+	used := map[string]bool{info.GoPackage.Path: true}
+	for _, path := range _gomockBaseImports {
+		used[path] = true
+	}
+	for _, service := range info.Services {
+		for _, method := range service.Methods {
+			if !method.GetClientStreaming() {
+				used[method.RequestType.File.GoPackage.Path] = true
+			}
+		}
+		unary, _ := unaryMethods(service)
+		for _, method := range unary {
+			used[method.ResponseType.File.GoPackage.Path] = true
+		}
+	}
+
+	imports := []*protoplugin.GoPackage{info.GoPackage}
+	for _, pkg := range info.Imports {
+		if used[pkg.Path] {
+			imports = append(imports, pkg)
+		}
+	}
+	return imports
+}
@@ -0,0 +1,193 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package lib
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/gogo/protobuf/proto"
+	"go.uber.org/yarpc/internal/protoplugin"
+	"go.uber.org/yarpc/pkg/procedure"
+)
+
+// _httpRuleField is the field number of the google.api.http extension of
+// google.protobuf.MethodOptions.
+const _httpRuleField = 72295728
+
+// Field numbers of google.api.HttpRule and google.api.CustomHttpPattern.
+const (
+	_httpRuleGet                = 2
+	_httpRulePut                = 3
+	_httpRulePost               = 4
+	_httpRuleDelete             = 5
+	_httpRulePatch              = 6
+	_httpRuleBody               = 7
+	_httpRuleCustom             = 8
+	_httpRuleAdditionalBindings = 11
+
+	_customHTTPPatternKind = 1
+	_customHTTPPatternPath = 2
+)
+
+var errInvalidWireFormat = errors.New("invalid protobuf wire format")
+
+// httpRule is a google.api.http annotation of a method.
+type httpRule struct {
+	Procedure  string
+	HTTPMethod string
+	Path       string
+	Body       string
+}
+
+// hasHTTPRules returns whether any method in the given file has
+// google.api.http annotations.
+func hasHTTPRules(file *protoplugin.TemplateInfo) (bool, error) {
+	for _, service := range file.Services {
+		rules, err := httpRules(service)
+		if err != nil {
+			return false, err
+		}
+		if len(rules) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// httpRules returns the google.api.http annotations of the unary and oneway
+// methods of the given service. Annotations on streaming methods are ignored.
+func httpRules(service *protoplugin.Service) ([]*httpRule, error) {
+	var rules []*httpRule
+	for _, method := range service.Methods {
+		if method.GetClientStreaming() || method.GetServerStreaming() || method.GetOptions() == nil {
+			continue
+		}
+		options, err := proto.Marshal(method.GetOptions())
+		if err != nil {
+			return nil, err
+		}
+		name := procedure.ToName(trimPrefixPeriod(service.FQSN()), method.GetName())
+		err = forEachBytesField(options, func(number uint64, value []byte) error {
+			if number != _httpRuleField {
+				return nil
+			}
+			return decodeHTTPRule(name, value, &rules)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read google.api.http annotation of %q: %v", name, err)
+		}
+	}
+	return rules, nil
+}
+
+// decodeHTTPRule decodes a google.api.HttpRule message, including its
+// additional bindings, into rules for the given procedure.
+func decodeHTTPRule(name string, data []byte, rules *[]*httpRule) error {
+	rule := &httpRule{Procedure: name}
+	var additional [][]byte
+	err := forEachBytesField(data, func(number uint64, value []byte) error {
+		switch number {
+		case _httpRuleGet:
+			rule.HTTPMethod, rule.Path = "GET", string(value)
+		case _httpRulePut:
+			rule.HTTPMethod, rule.Path = "PUT", string(value)
+		case _httpRulePost:
+			rule.HTTPMethod, rule.Path = "POST", string(value)
+		case _httpRuleDelete:
+			rule.HTTPMethod, rule.Path = "DELETE", string(value)
+		case _httpRulePatch:
+			rule.HTTPMethod, rule.Path = "PATCH", string(value)
+		case _httpRuleBody:
+			rule.Body = string(value)
+		case _httpRuleCustom:
+			return forEachBytesField(value, func(number uint64, value []byte) error {
+				switch number {
+				case _customHTTPPatternKind:
+					rule.HTTPMethod = string(value)
+				case _customHTTPPatternPath:
+					rule.Path = string(value)
+				}
+				return nil
+			})
+		case _httpRuleAdditionalBindings:
+			additional = append(additional, value)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if rule.HTTPMethod != "" {
+		*rules = append(*rules, rule)
+	}
+	for _, value := range additional {
+		if err := decodeHTTPRule(name, value, rules); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forEachBytesField calls f with the number and value of every
+// length-delimited field of the given encoded protobuf message. Fields of
+// other wire types are skipped.
+func forEachBytesField(data []byte, f func(number uint64, value []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errInvalidWireFormat
+		}
+		data = data[n:]
+
+		switch key & 7 {
+		case proto.WireVarint:
+			if _, n = binary.Uvarint(data); n <= 0 {
+				return errInvalidWireFormat
+			}
+			data = data[n:]
+		case proto.WireFixed64:
+			if len(data) < 8 {
+				return errInvalidWireFormat
+			}
+			data = data[8:]
+		case proto.WireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return errInvalidWireFormat
+			}
+			value := data[n : n+int(length)]
+			data = data[n+int(length):]
+			if err := f(key>>3, value); err != nil {
+				return err
+			}
+		case proto.WireFixed32:
+			if len(data) < 4 {
+				return errInvalidWireFormat
+			}
+			data = data[4:]
+		default:
+			return errInvalidWireFormat
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package lib
+
+import (
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"go.uber.org/yarpc/internal/protoplugin"
+	"go.uber.org/yarpc/pkg/procedure"
+)
+
+// idempotentProcedures returns the names of the procedures for the unary and
+// oneway methods of the given service whose idempotency_level option is
+// NO_SIDE_EFFECTS or IDEMPOTENT. Streaming methods are never retried so
+// their option is ignored.
+func idempotentProcedures(service *protoplugin.Service) []string {
+	var procedures []string
+	for _, method := range service.Methods {
+		if !isIdempotent(method) {
+			continue
+		}
+		procedures = append(procedures, procedure.ToName(trimPrefixPeriod(service.FQSN()), method.GetName()))
+	}
+	return procedures
+}
+
+// isIdempotent returns whether the given unary or oneway method has an
+// idempotency_level option of NO_SIDE_EFFECTS or IDEMPOTENT.
+func isIdempotent(method *protoplugin.Method) bool {
+	if method.GetClientStreaming() || method.GetServerStreaming() {
+		return false
+	}
+	return method.GetOptions().GetIdempotencyLevel() != descriptor.MethodOptions_IDEMPOTENCY_UNKNOWN
+}
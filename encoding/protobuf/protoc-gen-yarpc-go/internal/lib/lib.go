@@ -26,9 +26,12 @@ package lib
 import (
 	"fmt"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"text/template"
 
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/plugin"
 	"go.uber.org/yarpc/internal/protoplugin"
 )
 
@@ -42,7 +45,8 @@ package {{$packageName}}
 import (
 	{{range $i := .Imports}}{{if $i.Standard}}{{$i | printf "%s\n"}}{{end}}{{end}}
 
-	{{range $i := .Imports}}{{if not $i.Standard}}{{$i | printf "%s\n"}}{{end}}{{end}}
+	{{range $i := .Imports}}{{if not $i.Standard}}{{$i | printf "%s\n"}}{{end}}{{end}}{{if hasHTTPRules .}}"go.uber.org/yarpc/x/protohttp"
+	{{end}}
 ){{end}}
 
 {{if ne (len .Services) 0}}var _ = ioutil.NopCloser{{end}}
@@ -88,7 +92,10 @@ type {{$service.GetName}}Service{{$method.GetName}}YARPCClient interface {
 
 // New{{$service.GetName}}YARPCClient builds a new YARPC client for the {{$service.GetName}} service.
 func New{{$service.GetName}}YARPCClient(clientConfig transport.ClientConfig, options ...protobuf.ClientOption) {{$service.GetName}}YARPCClient {
-	return &_{{$service.GetName}}YARPCCaller{protobuf.NewStreamClient(
+	{{with idempotentProcedures $service}}yarpc.RegisterIdempotentProcedures(clientConfig.Service(),{{range .}}
+		{{printf "%q" .}},{{end}}
+	)
+	{{end}}return &_{{$service.GetName}}YARPCCaller{protobuf.NewStreamClient(
 		protobuf.ClientParams{
 			ServiceName: "{{trimPrefixPeriod $service.FQSN}}",
 			ClientConfig: clientConfig,
@@ -147,6 +154,7 @@ func Build{{$service.GetName}}YARPCProcedures(server {{$service.GetName}}YARPCSe
 							NewRequest: new{{$service.GetName}}Service{{$method.GetName}}YARPCRequest,
 						},
 					),
+					Metadata: {{template "procedureMetadata" $method}},
 				},
 			{{end}}
 			},
@@ -159,6 +167,7 @@ func Build{{$service.GetName}}YARPCProcedures(server {{$service.GetName}}YARPCSe
 							NewRequest: new{{$service.GetName}}Service{{$method.GetName}}YARPCRequest,
 						},
 					),
+					Metadata: {{template "procedureMetadata" $method}},
 				},
 			{{end}}
 			},
@@ -170,6 +179,7 @@ func Build{{$service.GetName}}YARPCProcedures(server {{$service.GetName}}YARPCSe
 							Handle: handler.{{$method.GetName}},
 						},
 					),
+					Metadata: {{template "procedureMetadata" $method}},
 				},
 			{{end}}
 			{{range $method := serverStreamingMethods $service}}{
@@ -179,6 +189,7 @@ func Build{{$service.GetName}}YARPCProcedures(server {{$service.GetName}}YARPCSe
 							Handle: handler.{{$method.GetName}},
 						},
 					),
+					Metadata: {{template "procedureMetadata" $method}},
 				},
 			{{end}}
 			{{range $method := clientStreamingMethods $service}}{
@@ -188,14 +199,28 @@ func Build{{$service.GetName}}YARPCProcedures(server {{$service.GetName}}YARPCSe
 							Handle: handler.{{$method.GetName}},
 						},
 					),
+					Metadata: {{template "procedureMetadata" $method}},
 				},
 			{{end}}
 			},
 		},
 	)
 }
-
-// Fx{{$service.GetName}}YARPCClientParams defines the input
+{{with httpRules $service}}
+// Build{{$service.GetName}}YARPCHTTPRules returns the rules declared by the google.api.http annotations of the {{$service.GetName}} service.
+// Use them with protohttp.NewMux to make the service reachable at RESTful paths.
+func Build{{$service.GetName}}YARPCHTTPRules() []protohttp.Rule {
+	return []protohttp.Rule{
+	{{range $rule := .}}{
+			Procedure: {{printf "%q" $rule.Procedure}},
+			HTTPMethod: {{printf "%q" $rule.HTTPMethod}},
+			Path: {{printf "%q" $rule.Path}},
+			{{if $rule.Body}}Body: {{printf "%q" $rule.Body}},
+		{{end}}},
+	{{end}}}
+}
+{{end}}
+{{if fx}}// Fx{{$service.GetName}}YARPCClientParams defines the input
 // for NewFx{{$service.GetName}}YARPCClient. It provides the
 // paramaters to get a {{$service.GetName}}YARPCClient in an
 // Fx application.
@@ -269,7 +294,7 @@ func NewFx{{$service.GetName}}YARPCProcedures() interface{} {
 		}
 	}
 }
-
+{{end}}
 type _{{$service.GetName}}YARPCCaller struct {
 	streamClient protobuf.StreamClient
 }
@@ -555,38 +580,114 @@ var (
 		},
 	){{end}}
 }{{end}}
-`
+{{define "procedureMetadata"}}transport.ProcedureMetadata{
+						RequestSchema: "{{trimPrefixPeriod .RequestType.FQMN}}",{{if not (isOneway .)}}
+						ResponseSchema: "{{trimPrefixPeriod .ResponseType.FQMN}}",{{end}}{{if isIdempotent .}}
+						Idempotent: true,{{end}}{{if .GetOptions.GetDeprecated}}
+						Deprecated: true,{{end}}
+					}{{end}}`
 
 // Runner is the Runner used for protoc-gen-yarpc-go.
-var Runner = protoplugin.NewRunner(
-	template.Must(template.New("tmpl").Funcs(
-		template.FuncMap{
-			"unaryMethods":                 unaryMethods,
-			"onewayMethods":                onewayMethods,
-			"clientStreamingMethods":       clientStreamingMethods,
-			"serverStreamingMethods":       serverStreamingMethods,
-			"clientServerStreamingMethods": clientServerStreamingMethods,
-			"trimPrefixPeriod":             trimPrefixPeriod,
-		}).Parse(tmpl)),
-	checkTemplateInfo,
-	[]string{
+//
+// In addition to the parameters understood by every protoc plugin, it
+// accepts the following:
+//
+// 	fx=false     Do not generate Fx constructors for clients and procedures.
+// 	gomock=true  Generate gomock-compatible mocks for clients. Mocks are
+// 	             placed in a separate package named after the generated
+// 	             package with a "test" suffix.
+var Runner protoplugin.Runner = runner{}
+
+type runner struct{}
+
+func (runner) Run(request *plugin_go.CodeGeneratorRequest) *plugin_go.CodeGeneratorResponse {
+	flags, err := parseFlags(request.GetParameter())
+	if err != nil {
+		return &plugin_go.CodeGeneratorResponse{Error: proto.String(err.Error())}
+	}
+	if !flags.gomock {
+		return newServiceRunner(flags).Run(request)
+	}
+	return protoplugin.NewMultiRunner(newServiceRunner(flags), newGomockRunner()).Run(request)
+}
+
+type flags struct {
+	fx     bool
+	gomock bool
+}
+
+func parseFlags(parameter string) (flags, error) {
+	f := flags{fx: true}
+	if parameter == "" {
+		return f, nil
+	}
+	for _, p := range strings.Split(parameter, ",") {
+		spec := strings.SplitN(p, "=", 2)
+		if len(spec) == 1 {
+			continue
+		}
+		name, value := spec[0], spec[1]
+		var dest *bool
+		switch name {
+		case "fx":
+			dest = &f.fx
+		case "gomock":
+			dest = &f.gomock
+		default:
+			continue
+		}
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return f, fmt.Errorf("invalid value %q for parameter %q: %v", value, name, err)
+		}
+		*dest = v
+	}
+	return f, nil
+}
+
+func newServiceRunner(flags flags) protoplugin.Runner {
+	imports := []string{
 		"context",
 		"io/ioutil",
 		"reflect",
 		"github.com/gogo/protobuf/proto",
-		"go.uber.org/fx",
+	}
+	if flags.fx {
+		imports = append(imports, "go.uber.org/fx")
+	}
+	imports = append(imports,
 		"go.uber.org/yarpc",
 		"go.uber.org/yarpc/api/transport",
 		"go.uber.org/yarpc/encoding/protobuf",
-	},
-	func(file *protoplugin.File) (string, error) {
-		name := file.GetName()
-		return fmt.Sprintf("%s.pb.yarpc.go", strings.TrimSuffix(name, filepath.Ext(name))), nil
-	},
-	func(key string, value string) error {
-		return nil
-	},
-)
+	)
+
+	return protoplugin.NewRunner(
+		template.Must(template.New("tmpl").Funcs(
+			template.FuncMap{
+				"unaryMethods":                 unaryMethods,
+				"onewayMethods":                onewayMethods,
+				"clientStreamingMethods":       clientStreamingMethods,
+				"serverStreamingMethods":       serverStreamingMethods,
+				"clientServerStreamingMethods": clientServerStreamingMethods,
+				"trimPrefixPeriod":             trimPrefixPeriod,
+				"fx":                           func() bool { return flags.fx },
+				"hasHTTPRules":                 hasHTTPRules,
+				"httpRules":                    httpRules,
+				"idempotentProcedures":         idempotentProcedures,
+				"isIdempotent":                 isIdempotent,
+				"isOneway":                     isOneway,
+			}).Parse(tmpl)),
+		checkTemplateInfo,
+		imports,
+		func(file *protoplugin.File) (string, error) {
+			name := file.GetName()
+			return fmt.Sprintf("%s.pb.yarpc.go", strings.TrimSuffix(name, filepath.Ext(name))), nil
+		},
+		func(key string, value string) error {
+			return nil
+		},
+	)
+}
 
 func checkTemplateInfo(templateInfo *protoplugin.TemplateInfo) error {
 	return nil
@@ -595,7 +696,7 @@ func checkTemplateInfo(templateInfo *protoplugin.TemplateInfo) error {
 func unaryMethods(service *protoplugin.Service) ([]*protoplugin.Method, error) {
 	methods := make([]*protoplugin.Method, 0, len(service.Methods))
 	for _, method := range service.Methods {
-		if !method.GetClientStreaming() && !method.GetServerStreaming() && method.ResponseType.FQMN() != ".uber.yarpc.Oneway" {
+		if !method.GetClientStreaming() && !method.GetServerStreaming() && !isOneway(method) {
 			methods = append(methods, method)
 		}
 	}
@@ -605,13 +706,19 @@ func unaryMethods(service *protoplugin.Service) ([]*protoplugin.Method, error) {
 func onewayMethods(service *protoplugin.Service) ([]*protoplugin.Method, error) {
 	methods := make([]*protoplugin.Method, 0, len(service.Methods))
 	for _, method := range service.Methods {
-		if !method.GetClientStreaming() && !method.GetServerStreaming() && method.ResponseType.FQMN() == ".uber.yarpc.Oneway" {
+		if !method.GetClientStreaming() && !method.GetServerStreaming() && isOneway(method) {
 			methods = append(methods, method)
 		}
 	}
 	return methods, nil
 }
 
+// isOneway returns whether the given method is a oneway method, that is
+// whether it returns uber.yarpc.Oneway.
+func isOneway(method *protoplugin.Method) bool {
+	return method.ResponseType.FQMN() == ".uber.yarpc.Oneway"
+}
+
 func clientStreamingMethods(service *protoplugin.Service) ([]*protoplugin.Method, error) {
 	methods := make([]*protoplugin.Method, 0, len(service.Methods))
 	for _, method := range service.Methods {
@@ -0,0 +1,57 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package testing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc"
+	testpb "go.uber.org/yarpc/encoding/protobuf/protoc-gen-yarpc-go/internal/testing"
+	"go.uber.org/yarpc/encoding/protobuf/protoc-gen-yarpc-go/internal/testing/testingtest"
+)
+
+func TestGomockClient(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx := context.Background()
+	var client testpb.AllYARPCClient = testingtest.NewMockAllYARPCClient(mockCtrl)
+	mock := client.(*testingtest.MockAllYARPCClient)
+
+	mock.EXPECT().GetValue(gomock.Any(), &testpb.GetValueRequest{Key: "foo"}).
+		Return(&testpb.GetValueResponse{Value: "bar"}, nil)
+	response, err := client.GetValue(ctx, &testpb.GetValueRequest{Key: "foo"})
+	require.NoError(t, err)
+	assert.Equal(t, "bar", response.Value)
+
+	option := yarpc.WithShardKey("shard")
+	mock.EXPECT().Fire(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+	_, err = client.Fire(ctx, &testpb.FireRequest{Value: "fire"}, option)
+	require.NoError(t, err)
+
+	mock.EXPECT().HelloThree(gomock.Any()).Return(nil, assert.AnError)
+	_, err = client.HelloThree(ctx)
+	assert.Equal(t, assert.AnError, err)
+}
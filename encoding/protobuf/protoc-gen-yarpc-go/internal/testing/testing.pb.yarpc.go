@@ -77,6 +77,10 @@ func BuildKeyValueYARPCProcedures(server KeyValueYARPCServer) []transport.Proced
 							NewRequest: newKeyValueServiceGetValueYARPCRequest,
 						},
 					),
+					Metadata: transport.ProcedureMetadata{
+						RequestSchema:  "uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing.GetValueRequest",
+						ResponseSchema: "uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing.GetValueResponse",
+					},
 				},
 				{
 					MethodName: "SetValue",
@@ -86,6 +90,10 @@ func BuildKeyValueYARPCProcedures(server KeyValueYARPCServer) []transport.Proced
 							NewRequest: newKeyValueServiceSetValueYARPCRequest,
 						},
 					),
+					Metadata: transport.ProcedureMetadata{
+						RequestSchema:  "uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing.SetValueRequest",
+						ResponseSchema: "uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing.SetValueResponse",
+					},
 				},
 			},
 			OnewayHandlerParams: []protobuf.BuildProceduresOnewayHandlerParams{},
@@ -293,6 +301,9 @@ func BuildSinkYARPCProcedures(server SinkYARPCServer) []transport.Procedure {
 							NewRequest: newSinkServiceFireYARPCRequest,
 						},
 					),
+					Metadata: transport.ProcedureMetadata{
+						RequestSchema: "uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing.FireRequest",
+					},
 				},
 			},
 			StreamHandlerParams: []protobuf.BuildProceduresStreamHandlerParams{},
@@ -499,6 +510,10 @@ func BuildAllYARPCProcedures(server AllYARPCServer) []transport.Procedure {
 							NewRequest: newAllServiceGetValueYARPCRequest,
 						},
 					),
+					Metadata: transport.ProcedureMetadata{
+						RequestSchema:  "uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing.GetValueRequest",
+						ResponseSchema: "uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing.GetValueResponse",
+					},
 				},
 				{
 					MethodName: "SetValue",
@@ -508,6 +523,10 @@ func BuildAllYARPCProcedures(server AllYARPCServer) []transport.Procedure {
 							NewRequest: newAllServiceSetValueYARPCRequest,
 						},
 					),
+					Metadata: transport.ProcedureMetadata{
+						RequestSchema:  "uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing.SetValueRequest",
+						ResponseSchema: "uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing.SetValueResponse",
+					},
 				},
 			},
 			OnewayHandlerParams: []protobuf.BuildProceduresOnewayHandlerParams{
@@ -519,6 +538,9 @@ func BuildAllYARPCProcedures(server AllYARPCServer) []transport.Procedure {
 							NewRequest: newAllServiceFireYARPCRequest,
 						},
 					),
+					Metadata: transport.ProcedureMetadata{
+						RequestSchema: "uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing.FireRequest",
+					},
 				},
 			},
 			StreamHandlerParams: []protobuf.BuildProceduresStreamHandlerParams{
@@ -529,6 +551,10 @@ func BuildAllYARPCProcedures(server AllYARPCServer) []transport.Procedure {
 							Handle: handler.HelloThree,
 						},
 					),
+					Metadata: transport.ProcedureMetadata{
+						RequestSchema:  "uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing.HelloRequest",
+						ResponseSchema: "uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing.HelloResponse",
+					},
 				},
 
 				{
@@ -538,6 +564,10 @@ func BuildAllYARPCProcedures(server AllYARPCServer) []transport.Procedure {
 							Handle: handler.HelloTwo,
 						},
 					),
+					Metadata: transport.ProcedureMetadata{
+						RequestSchema:  "uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing.HelloRequest",
+						ResponseSchema: "uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing.HelloResponse",
+					},
 				},
 
 				{
@@ -547,6 +577,10 @@ func BuildAllYARPCProcedures(server AllYARPCServer) []transport.Procedure {
 							Handle: handler.HelloOne,
 						},
 					),
+					Metadata: transport.ProcedureMetadata{
+						RequestSchema:  "uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing.HelloRequest",
+						ResponseSchema: "uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing.HelloResponse",
+					},
 				},
 			},
 		},
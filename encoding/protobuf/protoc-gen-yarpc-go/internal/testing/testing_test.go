@@ -24,11 +24,13 @@ import (
 	"bytes"
 	"compress/gzip"
 	"io/ioutil"
+	"strings"
 	"testing"
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
 	"github.com/gogo/protobuf/protoc-gen-gogo/plugin"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/yarpc/encoding/protobuf/protoc-gen-yarpc-go/internal/lib"
 	"go.uber.org/yarpc/internal/protoplugin"
@@ -53,14 +55,254 @@ func TestGoldenNoService(t *testing.T) {
 	)
 }
 
+func TestGoldenGomock(t *testing.T) {
+	const goPackage = "go.uber.org/yarpc/encoding/protobuf/protoc-gen-yarpc-go/internal/testing"
+	codeGeneratorResponse := generate(
+		t,
+		"encoding/protobuf/protoc-gen-yarpc-go/internal/testing/testing.proto",
+		strings.Join([]string{
+			"gomock=true",
+			"Mencoding/protobuf/protoc-gen-yarpc-go/internal/testing/dep.proto=" + goPackage,
+			"Mencoding/protobuf/protoc-gen-yarpc-go/internal/testing/testing.proto=" + goPackage,
+		}, ","),
+	)
+
+	content, err := ioutil.ReadFile("testing.pb.yarpc.go.golden")
+	require.NoError(t, err)
+	gomockContent, err := ioutil.ReadFile("testingtest.pb.yarpc.go.golden")
+	require.NoError(t, err)
+	expectedCodeGeneratorResponse := &plugin_go.CodeGeneratorResponse{
+		File: []*plugin_go.CodeGeneratorResponse_File{
+			{
+				Name:    proto.String("encoding/protobuf/protoc-gen-yarpc-go/internal/testing/testing.pb.yarpc.go"),
+				Content: proto.String(string(content)),
+			},
+			{
+				Name:    proto.String("encoding/protobuf/protoc-gen-yarpc-go/internal/testing/testingtest/testing.pb.yarpc.go"),
+				Content: proto.String(string(gomockContent)),
+			},
+		},
+	}
+
+	require.Equal(t, expectedCodeGeneratorResponse, codeGeneratorResponse)
+}
+
+func TestNoFx(t *testing.T) {
+	codeGeneratorResponse := generate(
+		t,
+		"encoding/protobuf/protoc-gen-yarpc-go/internal/testing/testing.proto",
+		"fx=false",
+	)
+	require.Empty(t, codeGeneratorResponse.GetError())
+	require.Len(t, codeGeneratorResponse.File, 1)
+
+	content := codeGeneratorResponse.File[0].GetContent()
+	assert.NotContains(t, content, `"go.uber.org/fx"`)
+	assert.NotContains(t, content, "NewFx")
+	assert.Contains(t, content, "NewKeyValueYARPCClient")
+}
+
+func TestInvalidFlag(t *testing.T) {
+	codeGeneratorResponse := generate(
+		t,
+		"encoding/protobuf/protoc-gen-yarpc-go/internal/testing/testing.proto",
+		"gomock=maybe",
+	)
+	assert.Contains(t, codeGeneratorResponse.GetError(), `invalid value "maybe" for parameter "gomock"`)
+	assert.Empty(t, codeGeneratorResponse.File)
+}
+
+func TestHTTPRules(t *testing.T) {
+	const inputFilePath = "encoding/protobuf/protoc-gen-yarpc-go/internal/testing/testing.proto"
+	fileDescriptorProto := getFileDescriptorProto(t, inputFilePath)
+	for _, service := range fileDescriptorProto.Service {
+		if service.GetName() != "KeyValue" {
+			continue
+		}
+		for _, method := range service.Method {
+			method.Options = &descriptor.MethodOptions{}
+			switch method.GetName() {
+			case "GetValue":
+				setHTTPRule(method.Options, httpRule(2, "/v1/values/{key}"))
+			case "SetValue":
+				setHTTPRule(method.Options, httpRule(4, "/v1/values/{key}",
+					bytesField(7, []byte("*")),
+					bytesField(11, httpRule(3, "/v1/values/{key}/{value}")),
+				))
+			}
+		}
+	}
+
+	codeGeneratorResponse := run(t, &plugin_go.CodeGeneratorRequest{
+		Parameter:      proto.String("Myarpcproto/yarpc.proto=go.uber.org/yarpc/yarpcproto"),
+		FileToGenerate: []string{inputFilePath},
+		ProtoFile: []*descriptor.FileDescriptorProto{
+			getFileDescriptorProto(t, "encoding/protobuf/protoc-gen-yarpc-go/internal/testing/dep.proto"),
+			getFileDescriptorProto(t, "yarpcproto/yarpc.proto"),
+			fileDescriptorProto,
+		},
+	})
+	require.Empty(t, codeGeneratorResponse.GetError())
+	require.Len(t, codeGeneratorResponse.File, 1)
+
+	content := codeGeneratorResponse.File[0].GetContent()
+	assert.Contains(t, content, `"go.uber.org/yarpc/x/protohttp"`)
+	assert.Contains(t, content, "func BuildKeyValueYARPCHTTPRules() []protohttp.Rule {")
+	assert.NotContains(t, content, "BuildSinkYARPCHTTPRules")
+	assert.NotContains(t, content, "BuildAllYARPCHTTPRules")
+
+	const procedurePrefix = "uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing.KeyValue::"
+	for _, rule := range [][]string{
+		{procedurePrefix + "GetValue", "GET", "/v1/values/{key}", ""},
+		{procedurePrefix + "SetValue", "POST", "/v1/values/{key}", "*"},
+		{procedurePrefix + "SetValue", "PUT", "/v1/values/{key}/{value}", ""},
+	} {
+		expected := "Procedure:  \"" + rule[0] + "\",\n" +
+			"\t\t\tHTTPMethod: \"" + rule[1] + "\",\n" +
+			"\t\t\tPath:       \"" + rule[2] + "\",\n"
+		if rule[3] != "" {
+			expected += "\t\t\tBody:       \"" + rule[3] + "\",\n"
+		}
+		expected += "\t\t},"
+		assert.Contains(t, content, expected)
+	}
+}
+
+func TestIdempotentProcedures(t *testing.T) {
+	const inputFilePath = "encoding/protobuf/protoc-gen-yarpc-go/internal/testing/testing.proto"
+	fileDescriptorProto := getFileDescriptorProto(t, inputFilePath)
+	for _, service := range fileDescriptorProto.Service {
+		for _, method := range service.Method {
+			switch service.GetName() + "::" + method.GetName() {
+			case "KeyValue::GetValue":
+				method.Options = &descriptor.MethodOptions{
+					IdempotencyLevel: descriptor.MethodOptions_NO_SIDE_EFFECTS.Enum(),
+				}
+			case "KeyValue::SetValue", "All::HelloThree":
+				method.Options = &descriptor.MethodOptions{
+					IdempotencyLevel: descriptor.MethodOptions_IDEMPOTENT.Enum(),
+				}
+			}
+		}
+	}
+
+	codeGeneratorResponse := run(t, &plugin_go.CodeGeneratorRequest{
+		Parameter:      proto.String("Myarpcproto/yarpc.proto=go.uber.org/yarpc/yarpcproto"),
+		FileToGenerate: []string{inputFilePath},
+		ProtoFile: []*descriptor.FileDescriptorProto{
+			getFileDescriptorProto(t, "encoding/protobuf/protoc-gen-yarpc-go/internal/testing/dep.proto"),
+			getFileDescriptorProto(t, "yarpcproto/yarpc.proto"),
+			fileDescriptorProto,
+		},
+	})
+	require.Empty(t, codeGeneratorResponse.GetError())
+	require.Len(t, codeGeneratorResponse.File, 1)
+
+	const procedurePrefix = "uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing."
+	assert.Contains(t, codeGeneratorResponse.File[0].GetContent(), "\tyarpc.RegisterIdempotentProcedures(clientConfig.Service(),\n"+
+		"\t\t\""+procedurePrefix+"KeyValue::GetValue\",\n"+
+		"\t\t\""+procedurePrefix+"KeyValue::SetValue\",\n"+
+		"\t)\n")
+}
+
+func TestProcedureMetadata(t *testing.T) {
+	const inputFilePath = "encoding/protobuf/protoc-gen-yarpc-go/internal/testing/testing.proto"
+	fileDescriptorProto := getFileDescriptorProto(t, inputFilePath)
+	for _, service := range fileDescriptorProto.Service {
+		if service.GetName() != "KeyValue" {
+			continue
+		}
+		for _, method := range service.Method {
+			if method.GetName() == "GetValue" {
+				method.Options = &descriptor.MethodOptions{
+					Deprecated:       proto.Bool(true),
+					IdempotencyLevel: descriptor.MethodOptions_NO_SIDE_EFFECTS.Enum(),
+				}
+			}
+		}
+	}
+
+	codeGeneratorResponse := run(t, &plugin_go.CodeGeneratorRequest{
+		Parameter:      proto.String("Myarpcproto/yarpc.proto=go.uber.org/yarpc/yarpcproto"),
+		FileToGenerate: []string{inputFilePath},
+		ProtoFile: []*descriptor.FileDescriptorProto{
+			getFileDescriptorProto(t, "encoding/protobuf/protoc-gen-yarpc-go/internal/testing/dep.proto"),
+			getFileDescriptorProto(t, "yarpcproto/yarpc.proto"),
+			fileDescriptorProto,
+		},
+	})
+	require.Empty(t, codeGeneratorResponse.GetError())
+	require.Len(t, codeGeneratorResponse.File, 1)
+
+	const typePrefix = "uber.yarpc.encoding.protobuf.protocgenyarpcgo.internal.testing."
+	content := codeGeneratorResponse.File[0].GetContent()
+	assert.Contains(t, content, "\t\t\t\t\tMetadata: transport.ProcedureMetadata{\n"+
+		"\t\t\t\t\t\tRequestSchema:  \""+typePrefix+"GetValueRequest\",\n"+
+		"\t\t\t\t\t\tResponseSchema: \""+typePrefix+"GetValueResponse\",\n"+
+		"\t\t\t\t\t\tIdempotent:     true,\n"+
+		"\t\t\t\t\t\tDeprecated:     true,\n"+
+		"\t\t\t\t\t},\n")
+	assert.Contains(t, content, "\t\t\t\t\tMetadata: transport.ProcedureMetadata{\n"+
+		"\t\t\t\t\t\tRequestSchema: \""+typePrefix+"FireRequest\",\n"+
+		"\t\t\t\t\t},\n", "oneway procedures must not reference a response schema")
+}
+
+// setHTTPRule sets the google.api.http extension of the given options to the
+// given encoded google.api.HttpRule.
+func setHTTPRule(options *descriptor.MethodOptions, rule []byte) {
+	const httpRuleField = 72295728
+	proto.SetRawExtension(options, httpRuleField, bytesField(httpRuleField, rule))
+}
+
+// httpRule encodes a google.api.HttpRule with the given pattern field and
+// additional encoded fields.
+func httpRule(patternField uint64, path string, fields ...[]byte) []byte {
+	rule := bytesField(patternField, []byte(path))
+	for _, field := range fields {
+		rule = append(rule, field...)
+	}
+	return rule
+}
+
+// bytesField encodes a length-delimited protobuf field.
+func bytesField(number uint64, value []byte) []byte {
+	buffer := proto.NewBuffer(nil)
+	_ = buffer.EncodeVarint(number<<3 | proto.WireBytes)
+	_ = buffer.EncodeRawBytes(value)
+	return buffer.Bytes()
+}
+
 func testGolden(
 	t *testing.T,
 	inputFilePath string,
 	outputFilePath string,
 	outputGoldenFilePath string,
 ) {
+	codeGeneratorResponse := generate(t, inputFilePath, "")
+
+	content, err := ioutil.ReadFile(outputGoldenFilePath)
+	require.NoError(t, err)
+	expectedCodeGeneratorResponse := &plugin_go.CodeGeneratorResponse{
+		File: []*plugin_go.CodeGeneratorResponse_File{
+			{
+				Name:    proto.String(outputFilePath),
+				Content: proto.String(string(content)),
+			},
+		},
+	}
+
+	require.Equal(t, expectedCodeGeneratorResponse, codeGeneratorResponse)
+}
+
+// generate runs protoc-gen-yarpc-go on the given file with the given
+// additional parameters.
+func generate(t *testing.T, inputFilePath string, parameters string) *plugin_go.CodeGeneratorResponse {
+	parameter := "Myarpcproto/yarpc.proto=go.uber.org/yarpc/yarpcproto"
+	if parameters != "" {
+		parameter += "," + parameters
+	}
 	codeGeneratorRequest := &plugin_go.CodeGeneratorRequest{
-		Parameter: proto.String("Myarpcproto/yarpc.proto=go.uber.org/yarpc/yarpcproto"),
+		Parameter: proto.String(parameter),
 		FileToGenerate: []string{
 			inputFilePath,
 		},
@@ -70,7 +312,11 @@ func testGolden(
 			getFileDescriptorProto(t, inputFilePath),
 		},
 	}
+	return run(t, codeGeneratorRequest)
+}
 
+// run runs protoc-gen-yarpc-go on the given request.
+func run(t *testing.T, codeGeneratorRequest *plugin_go.CodeGeneratorRequest) *plugin_go.CodeGeneratorResponse {
 	data, err := proto.Marshal(codeGeneratorRequest)
 	require.NoError(t, err)
 	reader := bytes.NewReader(data)
@@ -78,19 +324,7 @@ func testGolden(
 	require.NoError(t, protoplugin.Do(lib.Runner, reader, writer))
 	codeGeneratorResponse := &plugin_go.CodeGeneratorResponse{}
 	require.NoError(t, proto.Unmarshal(writer.Bytes(), codeGeneratorResponse))
-
-	content, err := ioutil.ReadFile(outputGoldenFilePath)
-	require.NoError(t, err)
-	expectedCodeGeneratorResponse := &plugin_go.CodeGeneratorResponse{
-		File: []*plugin_go.CodeGeneratorResponse_File{
-			{
-				Name:    proto.String(outputFilePath),
-				Content: proto.String(string(content)),
-			},
-		},
-	}
-
-	require.Equal(t, expectedCodeGeneratorResponse, codeGeneratorResponse)
+	return codeGeneratorResponse
 }
 
 func getFileDescriptorProto(t *testing.T, name string) *descriptor.FileDescriptorProto {
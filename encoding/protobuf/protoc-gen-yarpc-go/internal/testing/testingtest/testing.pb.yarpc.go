@@ -0,0 +1,285 @@
+// Code generated by protoc-gen-yarpc-go
+// source: encoding/protobuf/protoc-gen-yarpc-go/internal/testing/testing.proto
+// DO NOT EDIT!
+
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package testingtest
+
+import (
+	"context"
+
+	"github.com/golang/mock/gomock"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/encoding/protobuf/protoc-gen-yarpc-go/internal/testing"
+)
+
+// MockKeyValueYARPCClient implements a gomock-compatible mock client for service KeyValue.
+type MockKeyValueYARPCClient struct {
+	ctrl     *gomock.Controller
+	recorder *_MockKeyValueYARPCClientRecorder
+}
+
+var _ testing.KeyValueYARPCClient = (*MockKeyValueYARPCClient)(nil)
+
+type _MockKeyValueYARPCClientRecorder struct {
+	mock *MockKeyValueYARPCClient
+}
+
+// NewMockKeyValueYARPCClient builds a new mock client for service KeyValue.
+//
+// 	mockCtrl := gomock.NewController(t)
+// 	client := testingtest.NewMockKeyValueYARPCClient(mockCtrl)
+//
+// Use EXPECT() to set expectations on the mock.
+func NewMockKeyValueYARPCClient(ctrl *gomock.Controller) *MockKeyValueYARPCClient {
+	mock := &MockKeyValueYARPCClient{ctrl: ctrl}
+	mock.recorder = &_MockKeyValueYARPCClientRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows you to define an expectation on the
+// KeyValue mock client.
+func (m *MockKeyValueYARPCClient) EXPECT() *_MockKeyValueYARPCClientRecorder {
+	return m.recorder
+}
+
+// GetValue responds to a GetValue call based on the mock expectations.
+func (m *MockKeyValueYARPCClient) GetValue(ctx context.Context, request *testing.GetValueRequest, options ...yarpc.CallOption) (*testing.GetValueResponse, error) {
+	args := []interface{}{ctx, request}
+	for _, o := range options {
+		args = append(args, o)
+	}
+	ret := m.ctrl.Call(m, "GetValue", args...)
+	response, _ := ret[0].(*testing.GetValueResponse)
+	err, _ := ret[1].(error)
+	return response, err
+}
+
+// GetValue records an expected call to GetValue.
+func (mr *_MockKeyValueYARPCClientRecorder) GetValue(ctx interface{}, request interface{}, options ...interface{}) *gomock.Call {
+	args := append([]interface{}{ctx, request}, options...)
+	return mr.mock.ctrl.RecordCall(mr.mock, "GetValue", args...)
+}
+
+// SetValue responds to a SetValue call based on the mock expectations.
+func (m *MockKeyValueYARPCClient) SetValue(ctx context.Context, request *testing.SetValueRequest, options ...yarpc.CallOption) (*testing.SetValueResponse, error) {
+	args := []interface{}{ctx, request}
+	for _, o := range options {
+		args = append(args, o)
+	}
+	ret := m.ctrl.Call(m, "SetValue", args...)
+	response, _ := ret[0].(*testing.SetValueResponse)
+	err, _ := ret[1].(error)
+	return response, err
+}
+
+// SetValue records an expected call to SetValue.
+func (mr *_MockKeyValueYARPCClientRecorder) SetValue(ctx interface{}, request interface{}, options ...interface{}) *gomock.Call {
+	args := append([]interface{}{ctx, request}, options...)
+	return mr.mock.ctrl.RecordCall(mr.mock, "SetValue", args...)
+}
+
+// MockSinkYARPCClient implements a gomock-compatible mock client for service Sink.
+type MockSinkYARPCClient struct {
+	ctrl     *gomock.Controller
+	recorder *_MockSinkYARPCClientRecorder
+}
+
+var _ testing.SinkYARPCClient = (*MockSinkYARPCClient)(nil)
+
+type _MockSinkYARPCClientRecorder struct {
+	mock *MockSinkYARPCClient
+}
+
+// NewMockSinkYARPCClient builds a new mock client for service Sink.
+//
+// 	mockCtrl := gomock.NewController(t)
+// 	client := testingtest.NewMockSinkYARPCClient(mockCtrl)
+//
+// Use EXPECT() to set expectations on the mock.
+func NewMockSinkYARPCClient(ctrl *gomock.Controller) *MockSinkYARPCClient {
+	mock := &MockSinkYARPCClient{ctrl: ctrl}
+	mock.recorder = &_MockSinkYARPCClientRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows you to define an expectation on the
+// Sink mock client.
+func (m *MockSinkYARPCClient) EXPECT() *_MockSinkYARPCClientRecorder {
+	return m.recorder
+}
+
+// Fire responds to a Fire call based on the mock expectations.
+func (m *MockSinkYARPCClient) Fire(ctx context.Context, request *testing.FireRequest, options ...yarpc.CallOption) (yarpc.Ack, error) {
+	args := []interface{}{ctx, request}
+	for _, o := range options {
+		args = append(args, o)
+	}
+	ret := m.ctrl.Call(m, "Fire", args...)
+	ack, _ := ret[0].(yarpc.Ack)
+	err, _ := ret[1].(error)
+	return ack, err
+}
+
+// Fire records an expected call to Fire.
+func (mr *_MockSinkYARPCClientRecorder) Fire(ctx interface{}, request interface{}, options ...interface{}) *gomock.Call {
+	args := append([]interface{}{ctx, request}, options...)
+	return mr.mock.ctrl.RecordCall(mr.mock, "Fire", args...)
+}
+
+// MockAllYARPCClient implements a gomock-compatible mock client for service All.
+type MockAllYARPCClient struct {
+	ctrl     *gomock.Controller
+	recorder *_MockAllYARPCClientRecorder
+}
+
+var _ testing.AllYARPCClient = (*MockAllYARPCClient)(nil)
+
+type _MockAllYARPCClientRecorder struct {
+	mock *MockAllYARPCClient
+}
+
+// NewMockAllYARPCClient builds a new mock client for service All.
+//
+// 	mockCtrl := gomock.NewController(t)
+// 	client := testingtest.NewMockAllYARPCClient(mockCtrl)
+//
+// Use EXPECT() to set expectations on the mock.
+func NewMockAllYARPCClient(ctrl *gomock.Controller) *MockAllYARPCClient {
+	mock := &MockAllYARPCClient{ctrl: ctrl}
+	mock.recorder = &_MockAllYARPCClientRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows you to define an expectation on the
+// All mock client.
+func (m *MockAllYARPCClient) EXPECT() *_MockAllYARPCClientRecorder {
+	return m.recorder
+}
+
+// GetValue responds to a GetValue call based on the mock expectations.
+func (m *MockAllYARPCClient) GetValue(ctx context.Context, request *testing.GetValueRequest, options ...yarpc.CallOption) (*testing.GetValueResponse, error) {
+	args := []interface{}{ctx, request}
+	for _, o := range options {
+		args = append(args, o)
+	}
+	ret := m.ctrl.Call(m, "GetValue", args...)
+	response, _ := ret[0].(*testing.GetValueResponse)
+	err, _ := ret[1].(error)
+	return response, err
+}
+
+// GetValue records an expected call to GetValue.
+func (mr *_MockAllYARPCClientRecorder) GetValue(ctx interface{}, request interface{}, options ...interface{}) *gomock.Call {
+	args := append([]interface{}{ctx, request}, options...)
+	return mr.mock.ctrl.RecordCall(mr.mock, "GetValue", args...)
+}
+
+// SetValue responds to a SetValue call based on the mock expectations.
+func (m *MockAllYARPCClient) SetValue(ctx context.Context, request *testing.SetValueRequest, options ...yarpc.CallOption) (*testing.SetValueResponse, error) {
+	args := []interface{}{ctx, request}
+	for _, o := range options {
+		args = append(args, o)
+	}
+	ret := m.ctrl.Call(m, "SetValue", args...)
+	response, _ := ret[0].(*testing.SetValueResponse)
+	err, _ := ret[1].(error)
+	return response, err
+}
+
+// SetValue records an expected call to SetValue.
+func (mr *_MockAllYARPCClientRecorder) SetValue(ctx interface{}, request interface{}, options ...interface{}) *gomock.Call {
+	args := append([]interface{}{ctx, request}, options...)
+	return mr.mock.ctrl.RecordCall(mr.mock, "SetValue", args...)
+}
+
+// Fire responds to a Fire call based on the mock expectations.
+func (m *MockAllYARPCClient) Fire(ctx context.Context, request *testing.FireRequest, options ...yarpc.CallOption) (yarpc.Ack, error) {
+	args := []interface{}{ctx, request}
+	for _, o := range options {
+		args = append(args, o)
+	}
+	ret := m.ctrl.Call(m, "Fire", args...)
+	ack, _ := ret[0].(yarpc.Ack)
+	err, _ := ret[1].(error)
+	return ack, err
+}
+
+// Fire records an expected call to Fire.
+func (mr *_MockAllYARPCClientRecorder) Fire(ctx interface{}, request interface{}, options ...interface{}) *gomock.Call {
+	args := append([]interface{}{ctx, request}, options...)
+	return mr.mock.ctrl.RecordCall(mr.mock, "Fire", args...)
+}
+
+// HelloTwo responds to a HelloTwo call based on the mock expectations.
+func (m *MockAllYARPCClient) HelloTwo(ctx context.Context, request *testing.HelloRequest, options ...yarpc.CallOption) (testing.AllServiceHelloTwoYARPCClient, error) {
+	args := []interface{}{ctx, request}
+	for _, o := range options {
+		args = append(args, o)
+	}
+	ret := m.ctrl.Call(m, "HelloTwo", args...)
+	stream, _ := ret[0].(testing.AllServiceHelloTwoYARPCClient)
+	err, _ := ret[1].(error)
+	return stream, err
+}
+
+// HelloTwo records an expected call to HelloTwo.
+func (mr *_MockAllYARPCClientRecorder) HelloTwo(ctx interface{}, request interface{}, options ...interface{}) *gomock.Call {
+	args := append([]interface{}{ctx, request}, options...)
+	return mr.mock.ctrl.RecordCall(mr.mock, "HelloTwo", args...)
+}
+
+// HelloOne responds to a HelloOne call based on the mock expectations.
+func (m *MockAllYARPCClient) HelloOne(ctx context.Context, options ...yarpc.CallOption) (testing.AllServiceHelloOneYARPCClient, error) {
+	args := []interface{}{ctx}
+	for _, o := range options {
+		args = append(args, o)
+	}
+	ret := m.ctrl.Call(m, "HelloOne", args...)
+	stream, _ := ret[0].(testing.AllServiceHelloOneYARPCClient)
+	err, _ := ret[1].(error)
+	return stream, err
+}
+
+// HelloOne records an expected call to HelloOne.
+func (mr *_MockAllYARPCClientRecorder) HelloOne(ctx interface{}, options ...interface{}) *gomock.Call {
+	args := append([]interface{}{ctx}, options...)
+	return mr.mock.ctrl.RecordCall(mr.mock, "HelloOne", args...)
+}
+
+// HelloThree responds to a HelloThree call based on the mock expectations.
+func (m *MockAllYARPCClient) HelloThree(ctx context.Context, options ...yarpc.CallOption) (testing.AllServiceHelloThreeYARPCClient, error) {
+	args := []interface{}{ctx}
+	for _, o := range options {
+		args = append(args, o)
+	}
+	ret := m.ctrl.Call(m, "HelloThree", args...)
+	stream, _ := ret[0].(testing.AllServiceHelloThreeYARPCClient)
+	err, _ := ret[1].(error)
+	return stream, err
+}
+
+// HelloThree records an expected call to HelloThree.
+func (mr *_MockAllYARPCClientRecorder) HelloThree(ctx interface{}, options ...interface{}) *gomock.Call {
+	args := append([]interface{}{ctx}, options...)
+	return mr.mock.ctrl.RecordCall(mr.mock, "HelloThree", args...)
+}
@@ -26,6 +26,34 @@ To use:
 	go get go.uber.org/yarpc/encoding/protobuf/protoc-gen-yarpc-go
 	protoc --gogoslick_out=. foo.proto
 	protoc --yarpc-go_out=. foo.proto
+
+The following parameters may be passed to the plugin:
+
+	fx=false     Do not generate Fx constructors for clients and procedures.
+	gomock=true  Generate gomock-compatible client mocks in a separate
+	             package named after the generated package with a "test"
+	             suffix. For example,
+
+	                 protoc --yarpc-go_out=gomock=true:. kv/kv.proto
+
+	             generates mocks in kv/kvtest. The mocks import the generated
+	             package, so its full import path must be known, either
+	             from a go_package option or from an M parameter.
+
+Unary and oneway methods annotated with google.api.http options get a
+Build<Service>YARPCHTTPRules function that returns their HTTP bindings. Use it
+with go.uber.org/yarpc/x/protohttp to serve the methods at RESTful paths on an
+HTTP inbound. Annotations on streaming methods are ignored.
+
+Unary and oneway methods with an idempotency_level option of NO_SIDE_EFFECTS
+or IDEMPOTENT are registered with yarpc.RegisterIdempotentProcedures, for
+the service a client sends requests to when it is built, so that retry
+middleware knows they may be retried safely.
+
+Every procedure carries transport.ProcedureMetadata naming its request and
+response messages and recording its idempotency_level and deprecated options.
+The debug pages and the OpenAPI exporter in go.uber.org/yarpc/x/openapi
+display it.
 */
 package main
 
@@ -24,18 +24,28 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"sync"
 
 	"github.com/gogo/protobuf/proto"
+	"go.uber.org/yarpc"
+	apiencoding "go.uber.org/yarpc/api/encoding"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/pkg/encoding"
+	"go.uber.org/yarpc/yarpcerrors"
 )
 
 // readFromStream reads a proto.Message from a stream.
 func readFromStream(
 	ctx context.Context,
 	stream transport.Stream,
+	guard *streamGuard,
 	newMessage func() proto.Message,
 ) (proto.Message, error) {
-	streamMsg, err := stream.ReceiveMessage(ctx)
+	var streamMsg *transport.StreamMessage
+	err := guard.run(ctx, func() (err error) {
+		streamMsg, err = stream.ReceiveMessage(ctx)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -51,17 +61,87 @@ func readFromStream(
 }
 
 // writeToStream writes a proto.Message to a stream.
-func writeToStream(ctx context.Context, stream transport.Stream, message proto.Message) error {
+func writeToStream(ctx context.Context, stream transport.Stream, guard *streamGuard, message proto.Message) error {
 	messageData, cleanup, err := marshal(stream.Request().Meta.Encoding, message)
 	if err != nil {
 		return err
 	}
-	return stream.SendMessage(
-		ctx,
-		&transport.StreamMessage{
-			Body: readCloser{Reader: bytes.NewReader(messageData), closer: cleanup},
-		},
-	)
+	return guard.run(ctx, func() error {
+		return stream.SendMessage(
+			ctx,
+			&transport.StreamMessage{
+				Body: readCloser{Reader: bytes.NewReader(messageData), closer: cleanup},
+			},
+		)
+	})
+}
+
+// streamContext returns the context that bounds a single stream operation.
+func streamContext(options []yarpc.StreamOption) context.Context {
+	return apiencoding.NewStreamCall(encoding.FromStreamOptions(options)...).Context(context.Background())
+}
+
+// streamGuard bounds the operations on a stream by their contexts.
+//
+// Transports do not necessarily honor the context given to stream
+// operations, so it is enforced here. An operation that is abandoned keeps
+// running in the background until the stream unblocks it, so the stream
+// becomes unusable: later operations would run concurrently with it, and
+// the message it receives would be lost.
+type streamGuard struct {
+	// cancel cancels the stream to unblock abandoned operations. Server
+	// streams have none; they end when their handler returns.
+	cancel context.CancelFunc
+
+	mu  sync.Mutex
+	err error
+}
+
+// run runs f, returning early if ctx is done before f returns.
+func (g *streamGuard) run(ctx context.Context, f func() error) error {
+	g.mu.Lock()
+	err := g.err
+	g.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if ctx.Done() == nil {
+		return f()
+	}
+	if err := ctx.Err(); err != nil {
+		return contextError(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- f() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		err := contextError(ctx.Err())
+		g.abandon(err)
+		return err
+	}
+}
+
+// abandon makes the stream unusable after an operation failed with the given
+// error, and cancels it.
+func (g *streamGuard) abandon(err error) {
+	g.mu.Lock()
+	if g.err == nil {
+		g.err = yarpcerrors.FailedPreconditionErrorf("stream is unusable after an abandoned operation: %v", err)
+	}
+	g.mu.Unlock()
+	if g.cancel != nil {
+		g.cancel()
+	}
+}
+
+func contextError(err error) error {
+	if err == context.DeadlineExceeded {
+		return yarpcerrors.DeadlineExceededErrorf("stream operation timed out: %v", err)
+	}
+	return yarpcerrors.CancelledErrorf("stream operation canceled: %v", err)
 }
 
 type readCloser struct {
@@ -23,8 +23,10 @@ package protobuf
 import (
 	"context"
 	"errors"
+	"io"
 	"io/ioutil"
 	"testing"
+	"time"
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/golang/mock/gomock"
@@ -57,7 +59,7 @@ func TestReadFromStreamDecodeError(t *testing.T) {
 	clientStream, err := transport.NewClientStream(stream)
 	require.NoError(t, err)
 
-	_, err = readFromStream(ctx, clientStream, func() proto.Message { return nil })
+	_, err = readFromStream(ctx, clientStream, &streamGuard{}, func() proto.Message { return nil })
 
 	assert.Equal(t, wantErr, err)
 }
@@ -88,7 +90,54 @@ func TestWriteToStreamInvalidEncoding(t *testing.T) {
 	clientStream, err := transport.NewClientStream(stream)
 	require.NoError(t, err)
 
-	err = writeToStream(ctx, clientStream, nil)
+	err = writeToStream(ctx, clientStream, &streamGuard{}, nil)
 
 	assert.Equal(t, yarpcerrors.Newf(yarpcerrors.CodeInternal, "encoding.Expect should have handled encoding \"raw\" but did not"), err)
 }
+
+func TestReadFromStreamContextTimeout(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	unblock := make(chan struct{})
+	received := make(chan struct{})
+	stream := transporttest.NewMockStreamCloser(mockCtrl)
+	stream.EXPECT().ReceiveMessage(ctx).DoAndReturn(func(context.Context) (*transport.StreamMessage, error) {
+		defer close(received)
+		<-unblock
+		return nil, io.EOF
+	})
+
+	clientStream, err := transport.NewClientStream(stream)
+	require.NoError(t, err)
+
+	// Cancelling the stream unblocks the abandoned receive.
+	guard := &streamGuard{cancel: func() { close(unblock) }}
+	_, err = readFromStream(ctx, clientStream, guard, func() proto.Message { return nil })
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeDeadlineExceeded, yarpcerrors.FromError(err).Code())
+	<-received
+
+	_, err = readFromStream(context.Background(), clientStream, guard, func() proto.Message { return nil })
+	assert.Equal(t, yarpcerrors.CodeFailedPrecondition, yarpcerrors.FromError(err).Code(),
+		"streams must be unusable after an abandoned operation")
+}
+
+func TestClientStreamSendAfterClose(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	stream := transporttest.NewMockStreamCloser(mockCtrl)
+	stream.EXPECT().Close(gomock.Any()).Return(nil).Times(1)
+
+	transportStream, err := transport.NewClientStream(stream)
+	require.NoError(t, err)
+	clientStream := &ClientStream{stream: transportStream}
+
+	require.NoError(t, clientStream.Close())
+	require.NoError(t, clientStream.Close(), "close must be idempotent")
+	assert.Equal(t, io.EOF, clientStream.Send(nil))
+}
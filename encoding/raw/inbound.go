@@ -22,10 +22,10 @@ package raw
 
 import (
 	"context"
-	"io/ioutil"
 
 	encodingapi "go.uber.org/yarpc/api/encoding"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/iopool"
 	"go.uber.org/yarpc/pkg/errors"
 )
 
@@ -45,7 +45,7 @@ func (r rawUnaryHandler) Handle(ctx context.Context, treq *transport.Request, rw
 		return err
 	}
 
-	reqBody, err := ioutil.ReadAll(treq.Body)
+	reqBody, err := iopool.ReadAll(treq.Body)
 	if err != nil {
 		return err
 	}
@@ -78,7 +78,7 @@ func (r rawOnewayHandler) HandleOneway(ctx context.Context, treq *transport.Requ
 		return err
 	}
 
-	reqBody, err := ioutil.ReadAll(treq.Body)
+	reqBody, err := iopool.ReadAll(treq.Body)
 	if err != nil {
 		return err
 	}
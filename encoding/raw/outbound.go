@@ -23,11 +23,11 @@ package raw
 import (
 	"bytes"
 	"context"
-	"io/ioutil"
 
 	"go.uber.org/yarpc"
 	encodingapi "go.uber.org/yarpc/api/encoding"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/iopool"
 	"go.uber.org/yarpc/pkg/encoding"
 )
 
@@ -85,7 +85,7 @@ func (c rawClient) Call(ctx context.Context, procedure string, body []byte, opts
 	var resBody []byte
 	var readErr error
 	if tres.Body != nil {
-		resBody, readErr = ioutil.ReadAll(tres.Body)
+		resBody, readErr = iopool.ReadAll(tres.Body)
 	}
 	if appErr != nil {
 		return resBody, appErr
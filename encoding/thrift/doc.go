@@ -120,6 +120,18 @@
 // 	var h handler
 // 	yarpc.InjectClients(dispatcher, &h)
 //
+// Idempotent Procedures
+//
+// Functions annotated with `idempotent = "true"` are registered with
+// yarpc.RegisterIdempotentProcedures, for the service a generated client
+// sends requests to when it is built, so that retry middleware like
+// go.uber.org/yarpc/x/retry retries them by default. Their procedures also
+// carry Idempotent metadata, which the Dispatcher registers for its service.
+//
+// 	service KeyValue {
+// 		string getValue(1: string key) (idempotent = "true")
+// 	}
+//
 // Automatically Sanitizing TChannel Contexts
 //
 // Contexts created with `tchannel.ContextWithHeaders` are incompatible with YARPC clients generated from Thrift.
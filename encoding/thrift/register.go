@@ -69,6 +69,9 @@ type Method struct {
 	// ThriftModule, if non-nil, refers to the Thrift module from where this
 	// method is coming from.
 	ThriftModule *thriftreflect.ThriftModule
+
+	// Metadata describes the method for introspection and documentation.
+	Metadata transport.ProcedureMetadata
 }
 
 // Service is a generic Thrift service implementation.
@@ -118,6 +121,7 @@ func BuildProcedures(s Service, opts ...RegisterOption) []transport.Procedure {
 			HandlerSpec: spec,
 			Encoding:    Encoding,
 			Signature:   method.Signature,
+			Metadata:    method.Metadata,
 		})
 	}
 	return rs
@@ -64,7 +64,8 @@ type Interface interface {
 // New builds a new client for the <.Name> service.
 //
 // 	client := <$pkgname>.New(dispatcher.ClientConfig("<lower .Name>"))
-func New(c <$transport>.ClientConfig, opts ...<$thrift>.ClientOption) Interface {
+func New(c <$transport>.ClientConfig, opts ...<$thrift>.ClientOption) Interface {<$service := .><range .Functions><if eq (index .Annotations "idempotent") "true">
+	<$yarpc>.RegisterIdempotentProcedures(c.Service(), "<$service.Name>::<.ThriftName>")<end><end>
 	return client{
 		c: <$thrift>.New(<$thrift>.Config{
 			Service: "<.Name>",
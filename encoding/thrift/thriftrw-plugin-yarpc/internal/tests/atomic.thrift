@@ -17,6 +17,7 @@ struct CompareAndSwap {
 
 service ReadOnlyStore extends common.BaseService {
     i64 integer(1: string key) throws (1: KeyDoesNotExist doesNotExist)
+        (idempotent = "true")
 }
 
 service Store extends ReadOnlyStore {
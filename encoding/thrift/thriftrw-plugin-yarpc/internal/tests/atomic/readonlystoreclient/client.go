@@ -29,6 +29,7 @@ type Interface interface {
 //
 // 	client := readonlystoreclient.New(dispatcher.ClientConfig("readonlystore"))
 func New(c transport.ClientConfig, opts ...thrift.ClientOption) Interface {
+	yarpc.RegisterIdempotentProcedures(c.Service(), "ReadOnlyStore::integer")
 	return client{
 		c: thrift.New(thrift.Config{
 			Service:      "ReadOnlyStore",
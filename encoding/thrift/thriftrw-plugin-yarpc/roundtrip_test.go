@@ -410,3 +410,11 @@ func (extendEmptyHandler) Hello(ctx context.Context) error {
 func (extendEmptyHandler) Healthy(ctx context.Context) (bool, error) {
 	return true, nil
 }
+
+func TestIdempotentAnnotation(t *testing.T) {
+	// atomic.thrift annotates ReadOnlyStore::integer with idempotent = "true",
+	// so the generated readonlystoreclient package registers it.
+	assert.True(t, yarpc.IsIdempotentProcedure("ReadOnlyStore::integer"))
+	assert.False(t, yarpc.IsIdempotentProcedure("Store::increment"))
+	assert.False(t, yarpc.IsIdempotentProcedure("Store::compareAndSwap"))
+}
@@ -88,7 +88,8 @@ func New(impl Interface, opts ...<$thrift>.RegisterOption) []<$transport>.Proced
 				<end>
 				},
 				Signature: "<.Name>(<range $i, $v := .Arguments><if ne $i 0>, <end><.Name> <formatType .Type><end>)<if not .OneWay | and .ReturnType> (<formatType .ReturnType>)<end>",
-				ThriftModule: <import $module.ImportPath>.ThriftModule,
+				ThriftModule: <import $module.ImportPath>.ThriftModule,<if eq (index .Annotations "idempotent") "true">
+				Metadata: <$transport>.ProcedureMetadata{Idempotent: true},<end>
 				},
 		<end>},
 	}
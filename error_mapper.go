@@ -0,0 +1,85 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import (
+	"context"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// ErrorMapper customizes how errors returned by handlers are converted into
+// YARPC errors.
+//
+// By default, handler errors that are not YARPC errors are reported to
+// callers with CodeUnknown. An ErrorMapper registered on the Dispatcher with
+// Config.ErrorMapper may instead translate application-specific error types
+// into YARPC errors with meaningful codes. Transports then derive their
+// status codes from the code of the returned Status, so mapping a domain
+// "not found" error to CodeNotFound results in a 404 over HTTP and NOT_FOUND
+// over gRPC.
+type ErrorMapper interface {
+	// MapError returns the Status that should be sent to the caller for the
+	// given handler error, or nil to leave the error unchanged.
+	//
+	// MapError is never called with a nil error or an error that is already
+	// a YARPC error.
+	MapError(err error) *yarpcerrors.Status
+}
+
+// ErrorMapperFunc is a function that implements ErrorMapper.
+type ErrorMapperFunc func(err error) *yarpcerrors.Status
+
+// MapError calls f(err).
+func (f ErrorMapperFunc) MapError(err error) *yarpcerrors.Status {
+	return f(err)
+}
+
+// errorMapperMiddleware is inbound middleware that applies an ErrorMapper to
+// the errors returned by handlers.
+type errorMapperMiddleware struct {
+	mapper ErrorMapper
+}
+
+func (errorMapperMiddleware) MiddlewareName() string { return "errormapper" }
+
+func (m errorMapperMiddleware) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	return m.mapError(h.Handle(ctx, req, resw))
+}
+
+func (m errorMapperMiddleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	return m.mapError(h.HandleOneway(ctx, req))
+}
+
+func (m errorMapperMiddleware) HandleStream(s *transport.ServerStream, h transport.StreamHandler) error {
+	return m.mapError(h.HandleStream(s))
+}
+
+func (m errorMapperMiddleware) mapError(err error) error {
+	if err == nil || yarpcerrors.IsStatus(err) {
+		return err
+	}
+	if status := m.mapper.MapError(err); status != nil {
+		return status
+	}
+	return err
+}
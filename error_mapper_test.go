@@ -0,0 +1,99 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	. "go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var errNotFound = errors.New("not found")
+
+func notFoundMapper(err error) *yarpcerrors.Status {
+	if err == errNotFound {
+		return yarpcerrors.Newf(yarpcerrors.CodeNotFound, "could not find it")
+	}
+	return nil
+}
+
+func TestErrorMapper(t *testing.T) {
+	tests := []struct {
+		desc    string
+		give    error
+		wantErr error
+	}{
+		{
+			desc: "success",
+		},
+		{
+			desc:    "mapped error",
+			give:    errNotFound,
+			wantErr: yarpcerrors.Newf(yarpcerrors.CodeNotFound, "could not find it"),
+		},
+		{
+			desc:    "unmapped error",
+			give:    errors.New("great sadness"),
+			wantErr: errors.New("great sadness"),
+		},
+		{
+			desc:    "yarpc error",
+			give:    yarpcerrors.Newf(yarpcerrors.CodeInternal, "great sadness"),
+			wantErr: yarpcerrors.Newf(yarpcerrors.CodeInternal, "great sadness"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			dispatcher := NewDispatcher(Config{
+				Name:                               "test",
+				ErrorMapper:                        ErrorMapperFunc(notFoundMapper),
+				DisableAutoObservabilityMiddleware: true,
+				DisableDeadlineBudget:              true,
+			})
+			mw := dispatcher.InboundMiddleware()
+			ctx := context.Background()
+			req := &transport.Request{Service: "test", Procedure: "hello"}
+
+			unary := transporttest.NewMockUnaryHandler(mockCtrl)
+			unary.EXPECT().Handle(ctx, req, nil).Return(tt.give)
+			assert.Equal(t, tt.wantErr, mw.Unary.Handle(ctx, req, nil, unary), "unary")
+
+			oneway := transporttest.NewMockOnewayHandler(mockCtrl)
+			oneway.EXPECT().HandleOneway(ctx, req).Return(tt.give)
+			assert.Equal(t, tt.wantErr, mw.Oneway.HandleOneway(ctx, req, oneway), "oneway")
+
+			stream := transporttest.NewMockStreamHandler(mockCtrl)
+			stream.EXPECT().HandleStream(nil).Return(tt.give)
+			assert.Equal(t, tt.wantErr, mw.Stream.HandleStream(nil, stream), "stream")
+		})
+	}
+}
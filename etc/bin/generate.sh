@@ -67,13 +67,15 @@ strip_thrift_warnings() {
 }
 
 mockgen -destination=api/middleware/middlewaretest/router.go -package=middlewaretest go.uber.org/yarpc/api/middleware Router,UnaryInbound,UnaryOutbound,OnewayInbound,OnewayOutbound,StreamInbound,StreamOutbound
-mockgen -destination=api/peer/peertest/list.go -package=peertest go.uber.org/yarpc/api/peer Chooser,List,ChooserList
-mockgen -destination=api/peer/peertest/peer.go -package=peertest go.uber.org/yarpc/api/peer Identifier,Peer
+mockgen -destination=api/peer/peertest/list.go -package=peertest go.uber.org/yarpc/api/peer Chooser,List,ChooserList,ListImplementation
+mockgen -destination=api/peer/peertest/peer.go -package=peertest go.uber.org/yarpc/api/peer Identifier,Peer,StatusPeer
 mockgen -destination=api/peer/peertest/transport.go -package=peertest go.uber.org/yarpc/api/peer Transport,Subscriber
+mockgen -destination=api/transport/transporttest/ack.go -package=transporttest go.uber.org/yarpc/api/transport Ack
 mockgen -destination=api/transport/transporttest/clientconfig.go -package=transporttest go.uber.org/yarpc/api/transport ClientConfig,ClientConfigProvider
 mockgen -destination=api/transport/transporttest/handler.go -package=transporttest go.uber.org/yarpc/api/transport UnaryHandler,OnewayHandler,StreamHandler
 mockgen -destination=api/transport/transporttest/inbound.go -package=transporttest go.uber.org/yarpc/api/transport Inbound
-mockgen -destination=api/transport/transporttest/outbound.go -package=transporttest go.uber.org/yarpc/api/transport UnaryOutbound,OnewayOutbound,StreamOutbound
+mockgen -destination=api/transport/transporttest/outbound.go -package=transporttest go.uber.org/yarpc/api/transport Outbound,UnaryOutbound,OnewayOutbound,StreamOutbound
+mockgen -destination=api/transport/transporttest/response.go -package=transporttest go.uber.org/yarpc/api/transport ResponseWriter,StreamingResponseWriter
 mockgen -destination=api/transport/transporttest/router.go -package=transporttest go.uber.org/yarpc/api/transport Router,RouteTable
 mockgen -destination=api/transport/transporttest/stream.go -package=transporttest go.uber.org/yarpc/api/transport Stream,StreamCloser
 mockgen -destination=api/transport/transporttest/transport.go -package=transporttest go.uber.org/yarpc/api/transport Transport
@@ -107,6 +109,8 @@ protoc_all \
   encoding/protobuf/protoc-gen-yarpc-go/internal/testing/dep.proto \
   encoding/protobuf/protoc-gen-yarpc-go/internal/testing/testing.proto \
   encoding/protobuf/protoc-gen-yarpc-go/internal/testing/testing_no_service.proto
+protoc_with_imports "yarpc-go" "gomock=true,Mencoding/protobuf/protoc-gen-yarpc-go/internal/testing/dep.proto=go.uber.org/yarpc/encoding/protobuf/protoc-gen-yarpc-go/internal/testing,Mencoding/protobuf/protoc-gen-yarpc-go/internal/testing/testing.proto=go.uber.org/yarpc/encoding/protobuf/protoc-gen-yarpc-go/internal/testing," \
+  encoding/protobuf/protoc-gen-yarpc-go/internal/testing/testing.proto
 protoc_all internal/examples/streaming/stream.proto
 
 ragel -Z -G2 -o internal/interpolate/parse.go internal/interpolate/parse.rl
@@ -0,0 +1,83 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import "sync"
+
+var (
+	_idempotentMu sync.RWMutex
+
+	// _idempotentProcedures is the set of procedures that were declared
+	// safe to retry.
+	_idempotentProcedures = make(map[serviceProcedure]struct{})
+)
+
+// RegisterIdempotentProcedures records that the given procedures of a
+// service are idempotent: calling them more than once has the same effect as
+// calling them once, so failed calls to them may be retried safely.
+//
+// Procedures are registered per service because different services may
+// expose procedures of the same name that are not all idempotent.
+//
+// Clients generated by protoc-gen-yarpc-go and thriftrw-plugin-yarpc call
+// this for the service they send requests to when they are built, and the
+// Dispatcher calls it for procedures registered with Idempotent metadata.
+// Methods are declared idempotent with the idempotency_level option in
+// Protobuf,
+//
+// 	rpc GetValue(GetValueRequest) returns (GetValueResponse) {
+// 		option idempotency_level = IDEMPOTENT;
+// 	}
+//
+// or with the idempotent annotation in Thrift.
+//
+// 	string getValue(1: string key) (idempotent = "true")
+//
+// Retry middleware such as go.uber.org/yarpc/x/retry consults these
+// declarations with IsIdempotentProcedure.
+//
+// A function to unregister the procedures is returned.
+func RegisterIdempotentProcedures(service string, procedures ...string) (forget func()) {
+	_idempotentMu.Lock()
+	defer _idempotentMu.Unlock()
+
+	for _, p := range procedures {
+		_idempotentProcedures[serviceProcedure{service: service, procedure: p}] = struct{}{}
+	}
+	return func() {
+		_idempotentMu.Lock()
+		defer _idempotentMu.Unlock()
+
+		for _, p := range procedures {
+			delete(_idempotentProcedures, serviceProcedure{service: service, procedure: p})
+		}
+	}
+}
+
+// IsIdempotentProcedure returns whether the given procedure of a service
+// was registered as idempotent with RegisterIdempotentProcedures.
+func IsIdempotentProcedure(service, procedure string) bool {
+	_idempotentMu.RLock()
+	defer _idempotentMu.RUnlock()
+
+	_, ok := _idempotentProcedures[serviceProcedure{service: service, procedure: procedure}]
+	return ok
+}
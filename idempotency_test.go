@@ -0,0 +1,42 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterIdempotentProcedures(t *testing.T) {
+	assert.False(t, IsIdempotentProcedure("kv", "KeyValue::getValue"))
+
+	forget := RegisterIdempotentProcedures("kv", "KeyValue::getValue", "KeyValue::listValues")
+	assert.True(t, IsIdempotentProcedure("kv", "KeyValue::getValue"))
+	assert.True(t, IsIdempotentProcedure("kv", "KeyValue::listValues"))
+	assert.False(t, IsIdempotentProcedure("kv", "KeyValue::setValue"))
+	assert.False(t, IsIdempotentProcedure("other", "KeyValue::getValue"),
+		"procedures are idempotent only for the service they were registered for")
+
+	forget()
+	assert.False(t, IsIdempotentProcedure("kv", "KeyValue::getValue"))
+	assert.False(t, IsIdempotentProcedure("kv", "KeyValue::listValues"))
+}
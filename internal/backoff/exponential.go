@@ -23,6 +23,7 @@ package backoff
 import (
 	"errors"
 	"math/rand"
+	"sync"
 	"time"
 
 	"go.uber.org/multierr"
@@ -91,6 +92,24 @@ func MaxBackoff(t time.Duration) ExponentialOption {
 	}
 }
 
+// Seed makes the durations of the strategy reproducible. The random number
+// generator of each backoff returned by the strategy is seeded from a
+// sequence that starts with the given seed, so that the Nth backoff always
+// returns the same durations.
+//
+// Defaults to seeding each backoff from the current time.
+func Seed(seed int64) ExponentialOption {
+	return func(options *exponentialOptions) {
+		var lock sync.Mutex
+		seeds := rand.New(rand.NewSource(seed))
+		options.newRand = func() *rand.Rand {
+			lock.Lock()
+			defer lock.Unlock()
+			return rand.New(rand.NewSource(seeds.Int63()))
+		}
+	}
+}
+
 // randGenerator is an internal option for overriding the random number
 // generator.
 func randGenerator(newRand func() *rand.Rand) ExponentialOption {
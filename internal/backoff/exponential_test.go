@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestInvalidFirst(t *testing.T) {
@@ -194,3 +195,26 @@ func (r *mutableRandSrc) Int63() int64 {
 }
 
 func (*mutableRandSrc) Seed(int64) {}
+
+func TestExponentialSeed(t *testing.T) {
+	durations := func(s *ExponentialStrategy) []time.Duration {
+		var ds []time.Duration
+		for i := 0; i < 3; i++ {
+			b := s.Backoff()
+			for attempt := uint(0); attempt < 5; attempt++ {
+				ds = append(ds, b.Duration(attempt))
+			}
+		}
+		return ds
+	}
+
+	s1, err := NewExponential(Seed(42))
+	require.NoError(t, err)
+	s2, err := NewExponential(Seed(42))
+	require.NoError(t, err)
+	s3, err := NewExponential(Seed(43))
+	require.NoError(t, err)
+
+	assert.Equal(t, durations(s1), durations(s2))
+	assert.NotEqual(t, durations(s1), durations(s3))
+}
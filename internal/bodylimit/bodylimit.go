@@ -0,0 +1,107 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package bodylimit enforces maximum request body sizes.
+package bodylimit
+
+import (
+	"io"
+
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// Check fails with a ResourceExhausted error if a body of the given size is
+// larger than limit. Sizes and limits of zero or less are ignored.
+func Check(size, limit int64) error {
+	if limit <= 0 || size <= limit {
+		return nil
+	}
+	return yarpcerrors.ResourceExhaustedErrorf(
+		"request body of %d bytes exceeds the limit of %d bytes", size, limit)
+}
+
+// Limit wraps a body so that reading more than limit bytes from it fails
+// with a ResourceExhausted error.
+//
+// Bodies that report their size with a Len method are rejected right away
+// without reading them. The returned reader reports the size of the body
+// and closes it if the body supports that. A limit of zero or less returns
+// the body as-is.
+func Limit(body io.Reader, limit int64) (io.Reader, error) {
+	if limit <= 0 || body == nil {
+		return body, nil
+	}
+	r := &reader{r: body, n: limit, limit: limit}
+	if l, ok := body.(lener); ok {
+		if err := Check(int64(l.Len()), limit); err != nil {
+			return nil, err
+		}
+		return lenReader{r}, nil
+	}
+	return r, nil
+}
+
+type lener interface {
+	Len() int
+}
+
+type reader struct {
+	r     io.Reader
+	n     int64 // bytes left before the limit is reached
+	limit int64
+	err   error
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	// Read one byte past the limit so that bodies of exactly limit bytes
+	// succeed while larger ones are detected.
+	if int64(len(p)) > r.n+1 {
+		p = p[:r.n+1]
+	}
+	n, err := r.r.Read(p)
+	if int64(n) <= r.n {
+		r.n -= int64(n)
+		return n, err
+	}
+
+	n = int(r.n)
+	r.n = 0
+	r.err = yarpcerrors.ResourceExhaustedErrorf(
+		"request body exceeds the limit of %d bytes", r.limit)
+	return n, r.err
+}
+
+func (r *reader) Close() error {
+	if c, ok := r.r.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// lenReader is a reader whose body knows its size.
+type lenReader struct{ *reader }
+
+func (r lenReader) Len() int {
+	return r.r.(lener).Len()
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bodylimit
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+func TestCheck(t *testing.T) {
+	assert.NoError(t, Check(10, 0))
+	assert.NoError(t, Check(10, 10))
+	assert.NoError(t, Check(-1, 10))
+
+	err := Check(11, 10)
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeResourceExhausted, yarpcerrors.FromError(err).Code())
+}
+
+func TestLimit(t *testing.T) {
+	tests := []struct {
+		desc    string
+		body    string
+		limit   int64
+		wantErr bool
+	}{
+		{desc: "no limit", body: "hello", limit: 0},
+		{desc: "under limit", body: "hello", limit: 10},
+		{desc: "at limit", body: "hello", limit: 5},
+		{desc: "over limit", body: "hello world", limit: 5, wantErr: true},
+		{desc: "empty", body: "", limit: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			// Hide the Len method of strings.Reader so that the body must
+			// be read to find its size.
+			body, err := Limit(ioutil.NopCloser(strings.NewReader(tt.body)), tt.limit)
+			require.NoError(t, err)
+
+			got, err := ioutil.ReadAll(body)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Equal(t, yarpcerrors.CodeResourceExhausted, yarpcerrors.FromError(err).Code())
+				assert.Len(t, got, int(tt.limit), "reads up to the limit must succeed")
+
+				_, err = body.Read(make([]byte, 1))
+				assert.Error(t, err, "reads after the limit must keep failing")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.body, string(got))
+		})
+	}
+}
+
+func TestLimitSizedBody(t *testing.T) {
+	_, err := Limit(bytes.NewReader([]byte("hello world")), 5)
+	require.Error(t, err, "bodies of known size must be rejected without reading")
+	assert.Equal(t, yarpcerrors.CodeResourceExhausted, yarpcerrors.FromError(err).Code())
+
+	body, err := Limit(bytes.NewReader([]byte("hello")), 5)
+	require.NoError(t, err)
+	l, ok := body.(interface {
+		Len() int
+	})
+	require.True(t, ok, "size of the body must be preserved")
+	assert.Equal(t, 5, l.Len())
+
+	body, err = Limit(ioutil.NopCloser(strings.NewReader("hello")), 5)
+	require.NoError(t, err)
+	_, ok = body.(interface {
+		Len() int
+	})
+	assert.False(t, ok, "bodies of unknown size must not report a size")
+}
@@ -178,6 +178,9 @@ func (t *FakeTimer) tick() {
 // Reset adjusts the timer's scheduled time forward from now, unless it has
 // already fired.
 func (t *FakeTimer) Reset(d time.Duration) bool {
+	t.clock.Lock()
+	defer t.clock.Unlock()
+
 	t.time = t.clock.now.Add(d)
 
 	// Empty the channel if already filled.
@@ -196,6 +199,9 @@ func (t *FakeTimer) Reset(d time.Duration) bool {
 
 // Stop removes a timer from the scheduled timers.
 func (t *FakeTimer) Stop() bool {
+	t.clock.Lock()
+	defer t.clock.Unlock()
+
 	if t.index < 0 {
 		return false
 	}
@@ -32,6 +32,7 @@ type Clock interface {
 	After(d time.Duration) <-chan time.Time
 	Now() time.Time
 	Sleep(d time.Duration)
+	Timer(d time.Duration) Timer
 }
 
 // Timer represents an individual timer in a clock, either real or fake.
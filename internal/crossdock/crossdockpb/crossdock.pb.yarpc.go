@@ -75,6 +75,10 @@ func BuildEchoYARPCProcedures(server EchoYARPCServer) []transport.Procedure {
 							NewRequest: newEchoServiceEchoYARPCRequest,
 						},
 					),
+					Metadata: transport.ProcedureMetadata{
+						RequestSchema:  "uber.yarpc.internal.crossdock.Ping",
+						ResponseSchema: "uber.yarpc.internal.crossdock.Pong",
+					},
 				},
 			},
 			OnewayHandlerParams: []protobuf.BuildProceduresOnewayHandlerParams{},
@@ -244,6 +248,9 @@ func BuildOnewayYARPCProcedures(server OnewayYARPCServer) []transport.Procedure
 							NewRequest: newOnewayServiceEchoYARPCRequest,
 						},
 					),
+					Metadata: transport.ProcedureMetadata{
+						RequestSchema: "uber.yarpc.internal.crossdock.Token",
+					},
 				},
 			},
 			StreamHandlerParams: []protobuf.BuildProceduresStreamHandlerParams{},
@@ -0,0 +1,141 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package dnscache provides a client-side cache of DNS resolutions for
+// transports that dial host names.
+package dnscache
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/internal/clock"
+	intnet "go.uber.org/yarpc/internal/net"
+)
+
+// Cache caches the addresses that host names resolve to.
+//
+// Resolutions are cached for the TTL of the cache. Once a resolution
+// expires, the next dial resolves the host name again. If that resolution
+// fails, the expired addresses are served for up to the max staleness of the
+// cache, so that DNS failures don't prevent new connections to hosts that
+// are still up.
+//
+// A resolution expires early if no connection can be established to any of
+// its addresses.
+type Cache struct {
+	ttl      time.Duration
+	maxStale time.Duration
+	lookup   func(ctx context.Context, host string) ([]string, error)
+	clock    clock.Clock
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+type entry struct {
+	addrs      []string
+	resolvedAt time.Time
+	expired    bool
+}
+
+// New builds a Cache that caches resolutions for the given TTL and serves
+// them for up to maxStale past their expiry if resolving fails.
+func New(ttl, maxStale time.Duration) *Cache {
+	return &Cache{
+		ttl:      ttl,
+		maxStale: maxStale,
+		lookup:   net.DefaultResolver.LookupHost,
+		clock:    clock.NewReal(),
+		entries:  make(map[string]*entry),
+	}
+}
+
+// Resolve returns the addresses of the given host.
+func (c *Cache) Resolve(ctx context.Context, host string) ([]string, error) {
+	now := c.clock.Now()
+	c.mu.Lock()
+	e := c.entries[host]
+	c.mu.Unlock()
+	if e != nil && !e.expired && now.Before(e.resolvedAt.Add(c.ttl)) {
+		return e.addrs, nil
+	}
+
+	addrs, err := c.lookup(ctx, host)
+	if err != nil {
+		if e != nil && now.Before(e.resolvedAt.Add(c.ttl+c.maxStale)) {
+			return e.addrs, nil
+		}
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[host] = &entry{addrs: addrs, resolvedAt: now}
+	c.mu.Unlock()
+	return addrs, nil
+}
+
+// Expire expires the resolution of the given host, if any, so that the next
+// dial resolves it again. The expired addresses remain available as stale
+// addresses.
+func (c *Cache) Expire(host string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[host]; ok {
+		expired := *e
+		expired.expired = true
+		c.entries[host] = &expired
+	}
+}
+
+// Dialer wraps the given dial function to dial addresses resolved through
+// the cache. Each address of a host is dialed in turn until a connection is
+// established. If none can be, the resolution of the host is expired.
+//
+// Addresses with IP hosts are dialed directly.
+func (c *Cache) Dialer(dial intnet.DialFunc) intnet.DialFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil || net.ParseIP(host) != nil {
+			return dial(ctx, network, address)
+		}
+
+		addrs, err := c.Resolve(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			var conn net.Conn
+			conn, err = dial(ctx, network, net.JoinHostPort(addr, port))
+			if err == nil {
+				return conn, nil
+			}
+			if ctx.Err() != nil {
+				// Don't blame the addresses for our own deadline.
+				return nil, err
+			}
+		}
+		c.Expire(host)
+		return nil, err
+	}
+}
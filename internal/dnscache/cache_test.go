@@ -0,0 +1,139 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dnscache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/internal/clock"
+)
+
+type fakeResolver struct {
+	addrs   []string
+	err     error
+	lookups int
+}
+
+func (r *fakeResolver) lookup(_ context.Context, host string) ([]string, error) {
+	r.lookups++
+	return r.addrs, r.err
+}
+
+func newTestCache(ttl, maxStale time.Duration) (*Cache, *fakeResolver, *clock.FakeClock) {
+	resolver := &fakeResolver{addrs: []string{"10.0.0.1"}}
+	clk := clock.NewFake()
+	c := New(ttl, maxStale)
+	c.lookup = resolver.lookup
+	c.clock = clk
+	return c, resolver, clk
+}
+
+func TestResolveCaches(t *testing.T) {
+	c, resolver, clk := newTestCache(time.Minute, 0)
+	ctx := context.Background()
+
+	addrs, err := c.Resolve(ctx, "example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1"}, addrs)
+
+	resolver.addrs = []string{"10.0.0.2"}
+	addrs, err = c.Resolve(ctx, "example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1"}, addrs, "resolution should be cached")
+	assert.Equal(t, 1, resolver.lookups)
+
+	clk.Add(time.Minute)
+	addrs, err = c.Resolve(ctx, "example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.2"}, addrs, "expired resolution should be refreshed")
+	assert.Equal(t, 2, resolver.lookups)
+}
+
+func TestResolveServesStale(t *testing.T) {
+	c, resolver, clk := newTestCache(time.Minute, time.Hour)
+	ctx := context.Background()
+
+	_, err := c.Resolve(ctx, "example.com")
+	require.NoError(t, err)
+
+	resolver.err = errors.New("great sadness")
+	clk.Add(time.Minute)
+	addrs, err := c.Resolve(ctx, "example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1"}, addrs, "stale addresses should be served")
+
+	clk.Add(time.Hour)
+	_, err = c.Resolve(ctx, "example.com")
+	assert.EqualError(t, err, "great sadness", "addresses past the max staleness should not be served")
+
+	_, err = c.Resolve(ctx, "other.com")
+	assert.EqualError(t, err, "great sadness")
+}
+
+func TestDialerReresolvesOnFailure(t *testing.T) {
+	c, resolver, _ := newTestCache(time.Minute, time.Minute)
+	resolver.addrs = []string{"10.0.0.1", "10.0.0.2"}
+
+	var dialed []string
+	up := map[string]bool{"10.0.0.2:80": true}
+	dial := c.Dialer(func(_ context.Context, _, addr string) (net.Conn, error) {
+		dialed = append(dialed, addr)
+		if up[addr] {
+			client, server := net.Pipe()
+			server.Close()
+			return client, nil
+		}
+		return nil, errors.New("connection refused")
+	})
+	ctx := context.Background()
+
+	conn, err := dial(ctx, "tcp", "example.com:80")
+	require.NoError(t, err)
+	conn.Close()
+	assert.Equal(t, []string{"10.0.0.1:80", "10.0.0.2:80"}, dialed, "addresses should be dialed in turn")
+
+	up["10.0.0.2:80"] = false
+	_, err = dial(ctx, "tcp", "example.com:80")
+	assert.EqualError(t, err, "connection refused")
+	assert.Equal(t, 1, resolver.lookups)
+
+	resolver.addrs = []string{"10.0.0.3"}
+	up["10.0.0.3:80"] = true
+	dialed = nil
+	conn, err = dial(ctx, "tcp", "example.com:80")
+	require.NoError(t, err)
+	conn.Close()
+	assert.Equal(t, []string{"10.0.0.3:80"}, dialed, "failed resolution should be refreshed")
+	assert.Equal(t, 2, resolver.lookups)
+
+	dialed = nil
+	conn, err = dial(ctx, "tcp", "10.0.0.3:80")
+	require.NoError(t, err)
+	conn.Close()
+	assert.Equal(t, []string{"10.0.0.3:80"}, dialed)
+	assert.Equal(t, 2, resolver.lookups, "IP addresses should not be resolved")
+}
@@ -77,6 +77,10 @@ func BuildKeyValueYARPCProcedures(server KeyValueYARPCServer) []transport.Proced
 							NewRequest: newKeyValueServiceGetValueYARPCRequest,
 						},
 					),
+					Metadata: transport.ProcedureMetadata{
+						RequestSchema:  "uber.yarpc.internal.examples.protobuf.example.GetValueRequest",
+						ResponseSchema: "uber.yarpc.internal.examples.protobuf.example.GetValueResponse",
+					},
 				},
 				{
 					MethodName: "SetValue",
@@ -86,6 +90,10 @@ func BuildKeyValueYARPCProcedures(server KeyValueYARPCServer) []transport.Proced
 							NewRequest: newKeyValueServiceSetValueYARPCRequest,
 						},
 					),
+					Metadata: transport.ProcedureMetadata{
+						RequestSchema:  "uber.yarpc.internal.examples.protobuf.example.SetValueRequest",
+						ResponseSchema: "uber.yarpc.internal.examples.protobuf.example.SetValueResponse",
+					},
 				},
 			},
 			OnewayHandlerParams: []protobuf.BuildProceduresOnewayHandlerParams{},
@@ -293,6 +301,9 @@ func BuildSinkYARPCProcedures(server SinkYARPCServer) []transport.Procedure {
 							NewRequest: newSinkServiceFireYARPCRequest,
 						},
 					),
+					Metadata: transport.ProcedureMetadata{
+						RequestSchema: "uber.yarpc.internal.examples.protobuf.example.FireRequest",
+					},
 				},
 			},
 			StreamHandlerParams: []protobuf.BuildProceduresStreamHandlerParams{},
@@ -494,6 +505,10 @@ func BuildFooYARPCProcedures(server FooYARPCServer) []transport.Procedure {
 							Handle: handler.EchoBoth,
 						},
 					),
+					Metadata: transport.ProcedureMetadata{
+						RequestSchema:  "uber.yarpc.internal.examples.protobuf.example.EchoBothRequest",
+						ResponseSchema: "uber.yarpc.internal.examples.protobuf.example.EchoBothResponse",
+					},
 				},
 
 				{
@@ -503,6 +518,10 @@ func BuildFooYARPCProcedures(server FooYARPCServer) []transport.Procedure {
 							Handle: handler.EchoIn,
 						},
 					),
+					Metadata: transport.ProcedureMetadata{
+						RequestSchema:  "uber.yarpc.internal.examples.protobuf.example.EchoInRequest",
+						ResponseSchema: "uber.yarpc.internal.examples.protobuf.example.EchoInResponse",
+					},
 				},
 
 				{
@@ -512,6 +531,10 @@ func BuildFooYARPCProcedures(server FooYARPCServer) []transport.Procedure {
 							Handle: handler.EchoOut,
 						},
 					),
+					Metadata: transport.ProcedureMetadata{
+						RequestSchema:  "uber.yarpc.internal.examples.protobuf.example.EchoOutRequest",
+						ResponseSchema: "uber.yarpc.internal.examples.protobuf.example.EchoOutResponse",
+					},
 				},
 			},
 		},
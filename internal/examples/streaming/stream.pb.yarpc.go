@@ -121,6 +121,10 @@ func BuildHelloYARPCProcedures(server HelloYARPCServer) []transport.Procedure {
 							NewRequest: newHelloServiceHelloUnaryYARPCRequest,
 						},
 					),
+					Metadata: transport.ProcedureMetadata{
+						RequestSchema:  "uber.yarpc.internal.examples.streaming.HelloRequest",
+						ResponseSchema: "uber.yarpc.internal.examples.streaming.HelloResponse",
+					},
 				},
 			},
 			OnewayHandlerParams: []protobuf.BuildProceduresOnewayHandlerParams{},
@@ -132,6 +136,10 @@ func BuildHelloYARPCProcedures(server HelloYARPCServer) []transport.Procedure {
 							Handle: handler.HelloThere,
 						},
 					),
+					Metadata: transport.ProcedureMetadata{
+						RequestSchema:  "uber.yarpc.internal.examples.streaming.HelloRequest",
+						ResponseSchema: "uber.yarpc.internal.examples.streaming.HelloResponse",
+					},
 				},
 
 				{
@@ -141,6 +149,10 @@ func BuildHelloYARPCProcedures(server HelloYARPCServer) []transport.Procedure {
 							Handle: handler.HelloInStream,
 						},
 					),
+					Metadata: transport.ProcedureMetadata{
+						RequestSchema:  "uber.yarpc.internal.examples.streaming.HelloRequest",
+						ResponseSchema: "uber.yarpc.internal.examples.streaming.HelloResponse",
+					},
 				},
 
 				{
@@ -150,6 +162,10 @@ func BuildHelloYARPCProcedures(server HelloYARPCServer) []transport.Procedure {
 							Handle: handler.HelloOutStream,
 						},
 					),
+					Metadata: transport.ProcedureMetadata{
+						RequestSchema:  "uber.yarpc.internal.examples.streaming.HelloRequest",
+						ResponseSchema: "uber.yarpc.internal.examples.streaming.HelloResponse",
+					},
 				},
 			},
 		},
@@ -0,0 +1,72 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package headerlimit enforces limits on the headers of inbound requests.
+package headerlimit
+
+import "go.uber.org/yarpc/yarpcerrors"
+
+// Limits bounds the headers of inbound requests, including the headers
+// that carry tracing context and other metadata besides application
+// headers. Limits of zero or less are ignored.
+type Limits struct {
+	// MaxBytes is the largest total size of the names and values of the
+	// headers of a request.
+	MaxBytes int
+
+	// MaxCount is the largest number of headers of a request.
+	MaxCount int
+}
+
+// Enabled reports whether any limit is set, so that transports only measure
+// headers if they need to.
+func (l Limits) Enabled() bool {
+	return l.MaxBytes > 0 || l.MaxCount > 0
+}
+
+// Check fails with an InvalidArgument error if count headers of the given
+// total size in bytes exceed the limits.
+func (l Limits) Check(count, bytes int) error {
+	if l.MaxCount > 0 && count > l.MaxCount {
+		return yarpcerrors.InvalidArgumentErrorf(
+			"request has %d headers, exceeding the limit of %d headers", count, l.MaxCount)
+	}
+	if l.MaxBytes > 0 && bytes > l.MaxBytes {
+		return yarpcerrors.InvalidArgumentErrorf(
+			"request headers of %d bytes exceed the limit of %d bytes", bytes, l.MaxBytes)
+	}
+	return nil
+}
+
+// CheckMap checks headers given as a map from names to all their values, as
+// in HTTP headers and gRPC metadata. Each value counts as a header.
+func (l Limits) CheckMap(headers map[string][]string) error {
+	if !l.Enabled() {
+		return nil
+	}
+	var count, bytes int
+	for k, vs := range headers {
+		for _, v := range vs {
+			count++
+			bytes += len(k) + len(v)
+		}
+	}
+	return l.Check(count, bytes)
+}
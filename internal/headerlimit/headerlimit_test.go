@@ -0,0 +1,64 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package headerlimit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+func TestCheckMap(t *testing.T) {
+	headers := map[string][]string{
+		"foo": {"bar", "baz"}, // 12 bytes
+		"qux": {"quux"},       // 7 bytes
+	}
+
+	tests := []struct {
+		desc    string
+		limits  Limits
+		wantErr string
+	}{
+		{desc: "unlimited"},
+		{desc: "within limits", limits: Limits{MaxBytes: 19, MaxCount: 3}},
+		{
+			desc:    "too many headers",
+			limits:  Limits{MaxCount: 2},
+			wantErr: "request has 3 headers, exceeding the limit of 2 headers",
+		},
+		{
+			desc:    "too many bytes",
+			limits:  Limits{MaxBytes: 18},
+			wantErr: "request headers of 19 bytes exceed the limit of 18 bytes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := tt.limits.CheckMap(headers)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, yarpcerrors.InvalidArgumentErrorf(tt.wantErr), err)
+		})
+	}
+}
@@ -173,3 +173,48 @@ func (x streamChainExec) HandleStream(s *transport.ServerStream) error {
 	x.Chain = x.Chain[1:]
 	return next.HandleStream(s, x)
 }
+
+// UnchainUnary returns the middleware combined by UnaryChain, outermost first.
+// It returns nil for nil and no-op middleware.
+func UnchainUnary(mw middleware.UnaryInbound) []middleware.UnaryInbound {
+	switch m := mw.(type) {
+	case nil:
+		return nil
+	case unaryChain:
+		return append([]middleware.UnaryInbound(nil), m...)
+	}
+	if mw == middleware.NopUnaryInbound {
+		return nil
+	}
+	return []middleware.UnaryInbound{mw}
+}
+
+// UnchainOneway returns the middleware combined by OnewayChain, outermost first.
+// It returns nil for nil and no-op middleware.
+func UnchainOneway(mw middleware.OnewayInbound) []middleware.OnewayInbound {
+	switch m := mw.(type) {
+	case nil:
+		return nil
+	case onewayChain:
+		return append([]middleware.OnewayInbound(nil), m...)
+	}
+	if mw == middleware.NopOnewayInbound {
+		return nil
+	}
+	return []middleware.OnewayInbound{mw}
+}
+
+// UnchainStream returns the middleware combined by StreamChain, outermost first.
+// It returns nil for nil and no-op middleware.
+func UnchainStream(mw middleware.StreamInbound) []middleware.StreamInbound {
+	switch m := mw.(type) {
+	case nil:
+		return nil
+	case streamChain:
+		return append([]middleware.StreamInbound(nil), m...)
+	}
+	if mw == middleware.NopStreamInbound {
+		return nil
+	}
+	return []middleware.StreamInbound{mw}
+}
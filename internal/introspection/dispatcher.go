@@ -23,10 +23,11 @@ package introspection
 // DispatcherStatus represent detailed introspection information about a
 // dispatcher.
 type DispatcherStatus struct {
-	Name            string           `json:"name"`
-	ID              string           `json:"id"`
-	Procedures      []Procedure      `json:"procedures"`
-	Inbounds        []InboundStatus  `json:"inbounds"`
-	Outbounds       []OutboundStatus `json:"outbounds"`
-	PackageVersions []PackageVersion `json:"packageVersions"`
+	Name            string            `json:"name"`
+	ID              string            `json:"id"`
+	Procedures      []Procedure       `json:"procedures"`
+	Inbounds        []InboundStatus   `json:"inbounds"`
+	Outbounds       []OutboundStatus  `json:"outbounds"`
+	Middleware      []MiddlewareChain `json:"middleware"`
+	PackageVersions []PackageVersion  `json:"packageVersions"`
 }
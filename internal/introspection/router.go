@@ -30,6 +30,13 @@ type Procedure struct {
 	Encoding  string `json:"encoding"`
 	Signature string `json:"signature"`
 	RPCType   string `json:"rpcType"`
+
+	Description        string `json:"description,omitempty"`
+	RequestSchema      string `json:"requestSchema,omitempty"`
+	ResponseSchema     string `json:"responseSchema,omitempty"`
+	Idempotent         bool   `json:"idempotent,omitempty"`
+	Deprecated         bool   `json:"deprecated,omitempty"`
+	DeprecationMessage string `json:"deprecationMessage,omitempty"`
 }
 
 // IntrospectProcedures is a convenience function that translate a slice of
@@ -43,6 +50,13 @@ func IntrospectProcedures(routerProcs []transport.Procedure) []Procedure {
 			Encoding:  string(p.Encoding),
 			Signature: p.Signature,
 			RPCType:   p.HandlerSpec.Type().String(),
+
+			Description:        p.Metadata.Description,
+			RequestSchema:      p.Metadata.RequestSchema,
+			ResponseSchema:     p.Metadata.ResponseSchema,
+			Idempotent:         p.Metadata.Idempotent,
+			Deprecated:         p.Metadata.Deprecated,
+			DeprecationMessage: p.Metadata.DeprecationMessage,
 		})
 	}
 	return procedures
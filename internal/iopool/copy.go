@@ -38,7 +38,17 @@ var _pool = sync.Pool{
 }
 
 // Copy copies bytes from the Reader to the Writer until the Reader is exhausted.
+//
+// As with io.Copy, the Reader's WriteTo or the Writer's ReadFrom method is
+// used if either implements it, and no buffer is taken from the pool.
 func Copy(dst io.Writer, src io.Reader) (int64, error) {
+	if wt, ok := src.(io.WriterTo); ok {
+		return wt.WriteTo(dst)
+	}
+	if rf, ok := dst.(io.ReaderFrom); ok {
+		return rf.ReadFrom(src)
+	}
+
 	// To avoid unnecessary memory allocations we maintain our own pool of
 	// buffers.
 	buf := _pool.Get().(*buffer)
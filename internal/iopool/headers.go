@@ -0,0 +1,46 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package iopool
+
+import "sync"
+
+var _headerPool = sync.Pool{
+	New: func() interface{} {
+		return make(map[string][]string)
+	},
+}
+
+// GetHeaderMap returns an empty header map, in the shape of http.Header and
+// gRPC metadata, from a pool.
+//
+// The map must only be used for headers that are no longer referenced once
+// a request has been sent, and must be returned with PutHeaderMap.
+func GetHeaderMap() map[string][]string {
+	return _headerPool.Get().(map[string][]string)
+}
+
+// PutHeaderMap clears the map and returns it to the pool.
+func PutHeaderMap(m map[string][]string) {
+	for k := range m {
+		delete(m, k)
+	}
+	_headerPool.Put(m)
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package iopool
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+)
+
+// maxPrealloc caps how much ReadAll allocates up front so that a peer
+// advertising a very large body cannot make us allocate before sending it.
+const maxPrealloc = 4 << 20
+
+// ReadAll reads from the Reader until EOF and returns the bytes read.
+//
+// Unlike ioutil.ReadAll, if the Reader reports the number of bytes left to
+// read with a Len method, like *bytes.Reader, *bytes.Buffer, and LenReader
+// do, the result is allocated once with the right size instead of being
+// grown as the Reader is consumed. Readers that implement io.WriterTo write
+// their contents into the result directly.
+func ReadAll(r io.Reader) ([]byte, error) {
+	l, ok := r.(interface {
+		Len() int
+	})
+	if !ok {
+		return ioutil.ReadAll(r)
+	}
+
+	n := l.Len()
+	if n > maxPrealloc {
+		n = maxPrealloc
+	}
+	size := n + bytes.MinRead
+
+	// bytes.Buffer.ReadFrom only grows the buffer if fewer than MinRead
+	// bytes are free, so reading Len bytes and then EOF does not grow it.
+	buf := bytes.NewBuffer(make([]byte, 0, size))
+	var err error
+	if wt, ok := r.(io.WriterTo); ok {
+		_, err = wt.WriteTo(buf)
+	} else {
+		_, err = buf.ReadFrom(r)
+	}
+	return buf.Bytes(), err
+}
+
+// LenReader is a Reader that reports how many bytes are left to read from
+// an underlying Reader whose size is known ahead of time, like HTTP bodies
+// with a Content-Length.
+type LenReader struct {
+	r io.Reader
+	n int64
+}
+
+// NewLenReader wraps a Reader that holds n bytes.
+func NewLenReader(r io.Reader, n int64) *LenReader {
+	return &LenReader{r: r, n: n}
+}
+
+// Read reads from the underlying Reader.
+func (l *LenReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+// WriteTo writes the rest of the underlying Reader to w, letting the
+// Reader or w copy the bytes without an intermediate buffer if they
+// implement io.WriterTo or io.ReaderFrom.
+func (l *LenReader) WriteTo(w io.Writer) (int64, error) {
+	var (
+		n   int64
+		err error
+	)
+	if wt, ok := l.r.(io.WriterTo); ok {
+		n, err = wt.WriteTo(w)
+	} else {
+		n, err = Copy(w, l.r)
+	}
+	l.n -= n
+	return n, err
+}
+
+// Len returns the number of bytes that are expected to be left to read.
+func (l *LenReader) Len() int {
+	if l.n < 0 {
+		return 0
+	}
+	return int(l.n)
+}
+
+// Close closes the underlying Reader if it is an io.Closer.
+func (l *LenReader) Close() error {
+	if c, ok := l.r.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package iopool
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestReadAll(t *testing.T) {
+	tests := []struct {
+		desc string
+		give io.Reader
+		want string
+	}{
+		{desc: "empty", give: strings.NewReader(""), want: ""},
+		{desc: "bytes reader", give: bytes.NewReader([]byte("hello")), want: "hello"},
+		{
+			desc: "reader without len",
+			give: ioutil.NopCloser(strings.NewReader(strings.Repeat("a", 5000))),
+			want: strings.Repeat("a", 5000),
+		},
+		{
+			desc: "len reader",
+			give: NewLenReader(ioutil.NopCloser(strings.NewReader("hello world")), 11),
+			want: "hello world",
+		},
+		{
+			desc: "len reader with wrong length",
+			give: NewLenReader(strings.NewReader("hello world"), 3),
+			want: "hello world",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := ReadAll(tt.give)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestReadAllError(t *testing.T) {
+	r := io.MultiReader(strings.NewReader("foo"), errReader{errors.New("great sadness")})
+	got, err := ReadAll(r)
+	assert.EqualError(t, err, "great sadness")
+	assert.Equal(t, "foo", string(got))
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
+func TestReadAllSizedAllocatesOnce(t *testing.T) {
+	body := bytes.Repeat([]byte("x"), 64*1024)
+	var got []byte
+	allocs := testing.AllocsPerRun(100, func() {
+		got, _ = ReadAll(NewLenReader(bytes.NewReader(body), int64(len(body))))
+	})
+	assert.Equal(t, body, got)
+	// The LenReader, bytes.Reader, bytes.Buffer and the backing slice.
+	assert.True(t, allocs <= 4, "expected at most 4 allocations, got %v", allocs)
+	assert.True(t, cap(got) < 2*len(body), "buffer should not have grown")
+}
+
+func TestLenReader(t *testing.T) {
+	c := &closeRecorder{Reader: strings.NewReader("hello")}
+	r := NewLenReader(c, 5)
+	assert.Equal(t, 5, r.Len())
+
+	buf := make([]byte, 2)
+	n, err := r.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 3, r.Len())
+
+	rest, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "llo", string(rest))
+	assert.Equal(t, 0, r.Len())
+
+	require.NoError(t, r.Close())
+	assert.True(t, c.closed, "underlying reader must be closed")
+
+	// Readers that are not Closers are left alone.
+	assert.NoError(t, NewLenReader(strings.NewReader(""), 0).Close())
+
+	// Reading more than advertised never reports a negative length.
+	over := NewLenReader(strings.NewReader("hello"), 1)
+	_, err = ioutil.ReadAll(over)
+	require.NoError(t, err)
+	assert.Equal(t, 0, over.Len())
+}
+
+// writerToRecorder is a Reader that records whether it was copied with
+// WriteTo.
+type writerToRecorder struct {
+	*strings.Reader
+	wroteTo bool
+}
+
+func (r *writerToRecorder) WriteTo(w io.Writer) (int64, error) {
+	r.wroteTo = true
+	return r.Reader.WriteTo(w)
+}
+
+func TestLenReaderWriteTo(t *testing.T) {
+	t.Run("writer to", func(t *testing.T) {
+		src := &writerToRecorder{Reader: strings.NewReader("hello")}
+		r := NewLenReader(src, 5)
+
+		var buf bytes.Buffer
+		n, err := io.Copy(&buf, r)
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), n)
+		assert.Equal(t, "hello", buf.String())
+		assert.Equal(t, 0, r.Len())
+		assert.True(t, src.wroteTo, "underlying WriteTo must be used")
+	})
+
+	t.Run("plain reader", func(t *testing.T) {
+		r := NewLenReader(ioutil.NopCloser(strings.NewReader("hello")), 5)
+
+		var buf bytes.Buffer
+		n, err := r.WriteTo(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), n)
+		assert.Equal(t, "hello", buf.String())
+		assert.Equal(t, 0, r.Len())
+	})
+}
+
+func TestReadAllUsesWriterTo(t *testing.T) {
+	src := &writerToRecorder{Reader: strings.NewReader("hello")}
+	got, err := ReadAll(NewLenReader(src, 5))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(got))
+	assert.True(t, src.wroteTo, "underlying WriteTo must be used")
+}
+
+func TestHeaderMapPool(t *testing.T) {
+	m := GetHeaderMap()
+	assert.Empty(t, m)
+	m["foo"] = []string{"bar"}
+	PutHeaderMap(m)
+	assert.Empty(t, m, "returned maps must be cleared")
+	assert.Empty(t, GetHeaderMap())
+}
@@ -0,0 +1,213 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package net
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"sync"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+// DialFunc dials a connection to the given address.
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// ConnReporter reports the connection events of a transport to a
+// ConnectionEventHook. A nil ConnReporter reports nothing.
+type ConnReporter struct {
+	transport string
+	hook      transport.ConnectionEventHook
+}
+
+// NewConnReporter builds a ConnReporter for the named transport. It returns
+// nil if the hook is nil.
+func NewConnReporter(transportName string, hook transport.ConnectionEventHook) *ConnReporter {
+	if hook == nil {
+		return nil
+	}
+	return &ConnReporter{transport: transportName, hook: hook}
+}
+
+// Established reports that a connection was established.
+func (r *ConnReporter) Established(c net.Conn, peer string, inbound bool, state *tls.ConnectionState) {
+	if r == nil {
+		return
+	}
+	r.hook(transport.ConnectionEvent{
+		Type:      transport.ConnectionEstablished,
+		Transport: r.transport,
+		Inbound:   inbound,
+		Peer:      peer,
+		LocalAddr: c.LocalAddr().String(),
+		TLS:       state,
+	})
+}
+
+// Closed reports that an established connection was closed.
+func (r *ConnReporter) Closed(c net.Conn, peer string, inbound bool) {
+	if r == nil {
+		return
+	}
+	r.hook(transport.ConnectionEvent{
+		Type:      transport.ConnectionClosed,
+		Transport: r.transport,
+		Inbound:   inbound,
+		Peer:      peer,
+		LocalAddr: c.LocalAddr().String(),
+	})
+}
+
+// Failed reports that a connection could not be established.
+func (r *ConnReporter) Failed(peer string, inbound bool, err error) {
+	if r == nil {
+		return
+	}
+	r.hook(transport.ConnectionEvent{
+		Type:      transport.ConnectionFailed,
+		Transport: r.transport,
+		Inbound:   inbound,
+		Peer:      peer,
+		Err:       err,
+	})
+}
+
+// Dialer wraps the given DialFunc to report the connections it dials.
+//
+// If handshake is true, the connections are reported as established only
+// once the caller reports the completion of their handshake with
+// HandshakeComplete.
+func (r *ConnReporter) Dialer(dial DialFunc, handshake bool) DialFunc {
+	if r == nil {
+		return dial
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		c, err := dial(ctx, network, addr)
+		if err != nil {
+			r.Failed(addr, false, err)
+			return nil, err
+		}
+		return r.track(c, addr, false, handshake), nil
+	}
+}
+
+// Listener wraps the given Listener to report the connections it accepts.
+//
+// If handshake is true, the connections are reported as established only
+// once the caller reports the completion of their handshake with
+// HandshakeComplete.
+func (r *ConnReporter) Listener(l net.Listener, handshake bool) net.Listener {
+	if r == nil {
+		return l
+	}
+	return &listener{Listener: l, r: r, handshake: handshake}
+}
+
+type listener struct {
+	net.Listener
+
+	r         *ConnReporter
+	handshake bool
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return l.r.track(c, c.RemoteAddr().String(), true, l.handshake), nil
+}
+
+func (r *ConnReporter) track(c net.Conn, peer string, inbound, handshake bool) net.Conn {
+	tc := &trackedConn{Conn: c, r: r, peer: peer, inbound: inbound}
+	if !handshake {
+		tc.establish(nil)
+	}
+	return tc
+}
+
+// HandshakeComplete reports that the handshake of a connection returned by a
+// Dialer or Listener completed, with the given TLS state, if any.
+func HandshakeComplete(c net.Conn, state *tls.ConnectionState) {
+	if tc, ok := c.(*trackedConn); ok {
+		tc.establish(state)
+	}
+}
+
+// HandshakeFailed reports that the handshake of a connection returned by a
+// Dialer or Listener failed.
+func HandshakeFailed(c net.Conn, err error) {
+	if tc, ok := c.(*trackedConn); ok {
+		tc.fail(err)
+	}
+}
+
+// trackedConn reports when it is established and closed.
+type trackedConn struct {
+	net.Conn
+
+	r       *ConnReporter
+	peer    string
+	inbound bool
+
+	mu          sync.Mutex
+	established bool
+	done        bool
+}
+
+func (c *trackedConn) establish(state *tls.ConnectionState) {
+	c.mu.Lock()
+	if c.established || c.done {
+		c.mu.Unlock()
+		return
+	}
+	c.established = true
+	c.mu.Unlock()
+
+	c.r.Established(c.Conn, c.peer, c.inbound, state)
+}
+
+func (c *trackedConn) fail(err error) {
+	c.mu.Lock()
+	if c.established || c.done {
+		c.mu.Unlock()
+		return
+	}
+	c.done = true
+	c.mu.Unlock()
+
+	c.r.Failed(c.peer, c.inbound, err)
+}
+
+func (c *trackedConn) Close() error {
+	err := c.Conn.Close()
+
+	c.mu.Lock()
+	report := c.established && !c.done
+	c.done = true
+	c.mu.Unlock()
+
+	if report {
+		c.r.Closed(c.Conn, c.peer, c.inbound)
+	}
+	return err
+}
@@ -0,0 +1,192 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package net
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+)
+
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []transport.ConnectionEvent
+}
+
+func (r *eventRecorder) record(e transport.ConnectionEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+func (r *eventRecorder) types() []transport.ConnectionEventType {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var types []transport.ConnectionEventType
+	for _, e := range r.events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+func TestNilConnReporter(t *testing.T) {
+	r := NewConnReporter("test", nil)
+	assert.Nil(t, r)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	assert.Equal(t, l, r.Listener(l, false))
+
+	r.Failed("peer", false, errors.New("great sadness"))
+}
+
+func TestConnReporter(t *testing.T) {
+	var events eventRecorder
+	r := NewConnReporter("test", events.record)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	l = r.Listener(l, false)
+	defer l.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		c, err := l.Accept()
+		if assert.NoError(t, err) {
+			accepted <- c
+		}
+	}()
+
+	dial := r.Dialer((&net.Dialer{}).DialContext, false)
+	addr := l.Addr().String()
+	c, err := dial(context.Background(), "tcp", addr)
+	require.NoError(t, err)
+	server := <-accepted
+
+	require.NoError(t, c.Close())
+	_ = c.Close() // closing again must not be reported again
+	require.NoError(t, server.Close())
+
+	events.mu.Lock()
+	defer events.mu.Unlock()
+	require.Len(t, events.events, 4)
+
+	var outbound, inbound []transport.ConnectionEvent
+	for _, e := range events.events {
+		assert.Equal(t, "test", e.Transport)
+		assert.Nil(t, e.TLS)
+		assert.NoError(t, e.Err)
+		if e.Inbound {
+			inbound = append(inbound, e)
+		} else {
+			outbound = append(outbound, e)
+		}
+	}
+	require.Len(t, outbound, 2)
+	assert.Equal(t, transport.ConnectionEstablished, outbound[0].Type)
+	assert.Equal(t, transport.ConnectionClosed, outbound[1].Type)
+	assert.Equal(t, addr, outbound[0].Peer)
+	assert.Equal(t, c.LocalAddr().String(), outbound[0].LocalAddr)
+
+	require.Len(t, inbound, 2)
+	assert.Equal(t, transport.ConnectionEstablished, inbound[0].Type)
+	assert.Equal(t, transport.ConnectionClosed, inbound[1].Type)
+	assert.Equal(t, c.LocalAddr().String(), inbound[0].Peer)
+	assert.Equal(t, addr, inbound[0].LocalAddr)
+}
+
+func TestConnReporterDialFailure(t *testing.T) {
+	var events eventRecorder
+	r := NewConnReporter("test", events.record)
+
+	dialErr := errors.New("great sadness")
+	dial := r.Dialer(func(context.Context, string, string) (net.Conn, error) {
+		return nil, dialErr
+	}, false)
+	_, err := dial(context.Background(), "tcp", "127.0.0.1:1234")
+	assert.Equal(t, dialErr, err)
+
+	require.Len(t, events.events, 1)
+	assert.Equal(t, transport.ConnectionEvent{
+		Type:      transport.ConnectionFailed,
+		Transport: "test",
+		Peer:      "127.0.0.1:1234",
+		Err:       dialErr,
+	}, events.events[0])
+}
+
+func TestConnReporterHandshake(t *testing.T) {
+	tests := []struct {
+		desc         string
+		handshakeErr error
+		want         []transport.ConnectionEventType
+	}{
+		{
+			desc: "success",
+			want: []transport.ConnectionEventType{transport.ConnectionEstablished, transport.ConnectionClosed},
+		},
+		{
+			desc:         "failure",
+			handshakeErr: errors.New("bad certificate"),
+			want:         []transport.ConnectionEventType{transport.ConnectionFailed},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var events eventRecorder
+			r := NewConnReporter("test", events.record)
+
+			client, server := net.Pipe()
+			defer server.Close()
+			dial := r.Dialer(func(context.Context, string, string) (net.Conn, error) {
+				return client, nil
+			}, true)
+
+			c, err := dial(context.Background(), "tcp", "peer")
+			require.NoError(t, err)
+			assert.Empty(t, events.types(), "connection must not be established before its handshake")
+
+			state := &tls.ConnectionState{HandshakeComplete: true}
+			if tt.handshakeErr != nil {
+				HandshakeFailed(c, tt.handshakeErr)
+			} else {
+				HandshakeComplete(c, state)
+			}
+			require.NoError(t, c.Close())
+
+			assert.Equal(t, tt.want, events.types())
+			if tt.handshakeErr == nil {
+				assert.Equal(t, state, events.events[0].TLS)
+			} else {
+				assert.Equal(t, tt.handshakeErr, events.events[0].Err)
+			}
+		})
+	}
+}
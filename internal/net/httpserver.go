@@ -21,6 +21,7 @@
 package net
 
 import (
+	"crypto/tls"
 	"errors"
 	"net"
 	"net/http"
@@ -64,7 +65,8 @@ func (h *HTTPServer) Listener() net.Listener {
 
 // ListenAndServe starts the given HTTP server up in the background and
 // returns immediately. The server listens on the configured Addr or ":http"
-// if unconfigured.
+// if unconfigured, and accepts only TLS connections if the server has a
+// TLSConfig.
 //
 // An error is returned if the server failed to start up, if the server was
 // already listening, or if the server was stopped with Stop().
@@ -91,7 +93,11 @@ func (h *HTTPServer) ListenAndServe() error {
 		return err
 	}
 
-	go h.serve(h.listener)
+	listener := h.listener
+	if h.Server.TLSConfig != nil {
+		listener = tls.NewListener(listener, h.Server.TLSConfig)
+	}
+	go h.serve(listener)
 	return nil
 }
 
@@ -32,6 +32,7 @@ import (
 
 const (
 	_error              = "error"
+	_phase              = "phase"
 	_successfulInbound  = "Handled inbound request."
 	_successfulOutbound = "Made outbound call."
 	_errorInbound       = "Error handling inbound request."
@@ -159,3 +160,32 @@ func (c call) endStats(elapsed time.Duration, err error, isApplicationError bool
 		counter.Inc()
 	}
 }
+
+// WantsPhases returns whether EndPhases records the phases of the call.
+func (c call) WantsPhases() bool {
+	return c.edge.phaseLatencies != nil
+}
+
+// EndPhases records the phases of an outbound call, as recorded by the
+// outbound in the given timings.
+func (c call) EndPhases(timings transport.OutboundCallTimings) {
+	if !c.WantsPhases() {
+		return
+	}
+	c.observePhase("dns", timings.DNS)
+	c.observePhase("connect", timings.Connect)
+	c.observePhase("tls_handshake", timings.TLSHandshake)
+	c.observePhase("request_write", timings.RequestWrite)
+	c.observePhase("first_byte", timings.FirstByte)
+}
+
+func (c call) observePhase(phase string, d time.Duration) {
+	// Phases that did not happen, like connecting over a reused
+	// connection, aren't recorded.
+	if d <= 0 {
+		return
+	}
+	if histogram, err := c.edge.phaseLatencies.Get(_phase, phase); err == nil {
+		histogram.Observe(d)
+	}
+}
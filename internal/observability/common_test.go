@@ -24,6 +24,7 @@ import (
 	"context"
 	"time"
 
+	"go.uber.org/net/metrics"
 	"go.uber.org/yarpc/api/transport"
 )
 
@@ -109,7 +110,40 @@ func (s *fakeStream) Close(context.Context) error {
 }
 
 func stubTime() func() {
-	prev := _timeNow
+	prev, prevNumGoroutine := _timeNow, _numGoroutine
 	_timeNow = func() time.Time { return time.Time{} }
-	return func() { _timeNow = prev }
+	_numGoroutine = func() int { return 10 }
+	return func() {
+		_timeNow = prev
+		_numGoroutine = prevNumGoroutine
+	}
+}
+
+// resourceGauges returns the values of the resource gauges in the snapshot,
+// keyed by name and tags.
+func resourceGauges(snap *metrics.RootSnapshot) map[string]int64 {
+	gauges := make(map[string]int64)
+	for _, g := range snap.Gauges {
+		key := g.Name
+		if d, ok := g.Tags["direction"]; ok {
+			key += " " + d
+		}
+		if t, ok := g.Tags["rpc_type"]; ok {
+			key += " " + t
+		}
+		gauges[key] = g.Value
+	}
+	return gauges
+}
+
+// idleResourceGauges are the resource gauges of a dispatcher without
+// requests in flight, with stubbed time.
+var idleResourceGauges = map[string]int64{
+	"active_streams inbound":             0,
+	"active_streams outbound":            0,
+	"goroutines":                         10,
+	"in_flight_requests inbound Unary":   0,
+	"in_flight_requests outbound Unary":  0,
+	"in_flight_requests inbound Oneway":  0,
+	"in_flight_requests outbound Oneway": 0,
 }
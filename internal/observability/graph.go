@@ -54,16 +54,19 @@ type graph struct {
 	logger  *zap.Logger
 	extract ContextExtractor
 
+	resources resources
+
 	edgesMu sync.RWMutex
 	edges   map[string]*edge
 }
 
 func newGraph(meter *metrics.Scope, logger *zap.Logger, extract ContextExtractor) graph {
 	return graph{
-		edges:   make(map[string]*edge, _defaultGraphSize),
-		meter:   meter,
-		logger:  logger,
-		extract: extract,
+		edges:     make(map[string]*edge, _defaultGraphSize),
+		meter:     meter,
+		logger:    logger,
+		extract:   extract,
+		resources: newResources(meter),
 	}
 }
 
@@ -136,6 +139,11 @@ type edge struct {
 	latencies          *metrics.Histogram
 	callerErrLatencies *metrics.Histogram
 	serverErrLatencies *metrics.Histogram
+
+	// phaseLatencies breaks down the latency of outbound calls into the
+	// phases recorded in transport.OutboundCallTimings. It's nil for
+	// inbound edges.
+	phaseLatencies *metrics.HistogramVector
 }
 
 // newEdge constructs a new edge. Since Registries enforce metric uniqueness,
@@ -221,6 +229,22 @@ func newEdge(logger *zap.Logger, meter *metrics.Scope, req *transport.Request, d
 	if err != nil {
 		logger.Error("Failed to create server failure latency distribution.", zap.Error(err))
 	}
+	var phaseLatencies *metrics.HistogramVector
+	if direction == string(_directionOutbound) {
+		phaseLatencies, err = meter.HistogramVector(metrics.HistogramSpec{
+			Spec: metrics.Spec{
+				Name:      "outbound_phase_latency_ms",
+				Help:      "Latency distribution of the phases of outbound RPCs, like connecting and waiting for the first byte of the response.",
+				ConstTags: tags,
+				VarTags:   []string{_phase},
+			},
+			Unit:    time.Millisecond,
+			Buckets: _bucketsMs,
+		})
+		if err != nil {
+			logger.Error("Failed to create outbound phase latency distribution.", zap.Error(err))
+		}
+	}
 	logger = logger.With(
 		zap.String("source", req.Caller),
 		zap.String("dest", req.Service),
@@ -240,6 +264,7 @@ func newEdge(logger *zap.Logger, meter *metrics.Scope, req *transport.Request, d
 		latencies:          latencies,
 		callerErrLatencies: callerErrLatencies,
 		serverErrLatencies: serverErrLatencies,
+		phaseLatencies:     phaseLatencies,
 	}
 }
 
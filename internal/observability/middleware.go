@@ -26,6 +26,7 @@ import (
 
 	"go.uber.org/net/metrics"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/responsewriter"
 	"go.uber.org/zap"
 )
 
@@ -36,7 +37,7 @@ var _writerPool = sync.Pool{New: func() interface{} {
 // writer wraps a transport.ResponseWriter so the observing middleware can
 // detect application errors.
 type writer struct {
-	transport.ResponseWriter
+	responsewriter.Wrapper
 
 	isApplicationError bool
 }
@@ -67,9 +68,13 @@ func NewMiddleware(logger *zap.Logger, scope *metrics.Scope, extract ContextExtr
 	return &Middleware{newGraph(scope, logger, extract)}
 }
 
+// MiddlewareName implements middleware.Named.
+func (m *Middleware) MiddlewareName() string { return "observability" }
+
 // Handle implements middleware.UnaryInbound.
 func (m *Middleware) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter, h transport.UnaryHandler) error {
 	call := m.graph.begin(ctx, transport.Unary, _directionInbound, req)
+	defer m.graph.resources.start(transport.Unary, _directionInbound)()
 	wrappedWriter := newWriter(w)
 	err := h.Handle(ctx, req, wrappedWriter)
 	call.EndWithAppError(err, wrappedWriter.isApplicationError)
@@ -80,6 +85,15 @@ func (m *Middleware) Handle(ctx context.Context, req *transport.Request, w trans
 // Call implements middleware.UnaryOutbound.
 func (m *Middleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
 	call := m.graph.begin(ctx, transport.Unary, _directionOutbound, req)
+	defer m.graph.resources.start(transport.Unary, _directionOutbound)()
+
+	// Outbounds that measure the phases of the call, like the HTTP outbound,
+	// record them in the OutboundCallInfo. Measuring them has a cost, so
+	// it's only asked for if the phases are recorded.
+	var info transport.OutboundCallInfo
+	if call.WantsPhases() {
+		ctx = transport.WithOutboundCallInfo(ctx, &info)
+	}
 	res, err := out.Call(ctx, req)
 
 	isApplicationError := false
@@ -87,12 +101,14 @@ func (m *Middleware) Call(ctx context.Context, req *transport.Request, out trans
 		isApplicationError = res.ApplicationError
 	}
 	call.EndWithAppError(err, isApplicationError)
+	call.EndPhases(info.Timings)
 	return res, err
 }
 
 // HandleOneway implements middleware.OnewayInbound.
 func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
 	call := m.graph.begin(ctx, transport.Oneway, _directionInbound, req)
+	defer m.graph.resources.start(transport.Oneway, _directionInbound)()
 	err := h.HandleOneway(ctx, req)
 	call.End(err)
 	return err
@@ -101,6 +117,7 @@ func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h
 // CallOneway implements middleware.OnewayOutbound.
 func (m *Middleware) CallOneway(ctx context.Context, req *transport.Request, out transport.OnewayOutbound) (transport.Ack, error) {
 	call := m.graph.begin(ctx, transport.Oneway, _directionOutbound, req)
+	defer m.graph.resources.start(transport.Oneway, _directionOutbound)()
 	ack, err := out.CallOneway(ctx, req)
 	call.End(err)
 	return ack, err
@@ -109,6 +126,7 @@ func (m *Middleware) CallOneway(ctx context.Context, req *transport.Request, out
 // HandleStream implements middleware.StreamInbound.
 func (m *Middleware) HandleStream(serverStream *transport.ServerStream, h transport.StreamHandler) error {
 	call := m.graph.begin(serverStream.Context(), transport.Streaming, _directionInbound, serverStream.Request().Meta.ToRequest())
+	defer m.graph.resources.start(transport.Streaming, _directionInbound)()
 	err := h.HandleStream(serverStream)
 	// TODO(pedge): wrap the *transport.ServerStream?
 	call.End(err)
@@ -118,8 +136,13 @@ func (m *Middleware) HandleStream(serverStream *transport.ServerStream, h transp
 // CallStream implements middleware.StreamOutbound.
 func (m *Middleware) CallStream(ctx context.Context, request *transport.StreamRequest, out transport.StreamOutbound) (*transport.ClientStream, error) {
 	call := m.graph.begin(ctx, transport.Streaming, _directionOutbound, request.Meta.ToRequest())
+	end := m.graph.resources.start(transport.Streaming, _directionOutbound)
 	clientStream, err := out.CallStream(ctx, request)
 	// TODO(pedge): wrap the *transport.ClientStream?
 	call.End(err)
-	return clientStream, err
+	if err != nil {
+		end()
+		return clientStream, err
+	}
+	return transport.NewClientStream(&trackedClientStream{ClientStream: clientStream, end: end})
 }
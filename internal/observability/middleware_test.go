@@ -386,6 +386,43 @@ func TestMiddlewareMetrics(t *testing.T) {
 	}
 }
 
+// callInfoOutbound records whether it was asked for the OutboundCallInfo of
+// calls.
+type callInfoOutbound struct {
+	fakeOutbound
+
+	wantsInfo bool
+}
+
+func (o *callInfoOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	o.wantsInfo = transport.WantsOutboundCallInfo(ctx)
+	return o.fakeOutbound.Call(ctx, req)
+}
+
+func TestMiddlewareAsksForCallInfoOnlyToRecordPhases(t *testing.T) {
+	req := &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  "raw",
+		Procedure: "procedure",
+		Body:      strings.NewReader("body"),
+	}
+	mw := NewMiddleware(zap.NewNop(), metrics.New().Scope(), NewNopContextExtractor())
+
+	out := &callInfoOutbound{}
+	_, err := mw.Call(context.Background(), req, out)
+	require.NoError(t, err)
+	assert.True(t, out.wantsInfo, "outbound must be asked for call info when phases are recorded")
+
+	key, free := getKey(req, string(_directionOutbound))
+	mw.graph.getEdge(key).phaseLatencies = nil
+	free()
+
+	_, err = mw.Call(context.Background(), req, out)
+	require.NoError(t, err)
+	assert.False(t, out.wantsInfo, "outbound must not be asked for call info when phases aren't recorded")
+}
+
 // getKey gets the "key" that we will use to get an edge in the graph.  We use
 // a separate function to recreate the logic because extracting it out in the
 // main code could have performance implications.
@@ -515,6 +552,8 @@ func TestMiddlewareSuccessSnapshot(t *testing.T) {
 			},
 		},
 	}
+	assert.Equal(t, idleResourceGauges, resourceGauges(snap), "Unexpected resource gauges.")
+	snap.Gauges = nil
 	assert.Equal(t, want, snap, "Unexpected snapshot of metrics.")
 }
 
@@ -589,5 +628,7 @@ func TestMiddlewareFailureSnapshot(t *testing.T) {
 			},
 		},
 	}
+	assert.Equal(t, idleResourceGauges, resourceGauges(snap), "Unexpected resource gauges.")
+	snap.Gauges = nil
 	assert.Equal(t, want, snap, "Unexpected snapshot of metrics.")
 }
@@ -0,0 +1,139 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package observability
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/transport"
+)
+
+var _numGoroutine = runtime.NumGoroutine // for tests
+
+// resources tracks the resources held by a dispatcher so that leaks show up
+// in metrics before they exhaust memory.
+type resources struct {
+	// In-flight unary and oneway requests, by direction. For inbound oneway
+	// requests, this is the depth of the queue of requests that were
+	// acknowledged but not handled yet.
+	inboundUnary   *metrics.Gauge
+	outboundUnary  *metrics.Gauge
+	inboundOneway  *metrics.Gauge
+	outboundOneway *metrics.Gauge
+
+	// Open streams, by direction.
+	inboundStreams  *metrics.Gauge
+	outboundStreams *metrics.Gauge
+
+	// Goroutines in the process, sampled whenever a request starts.
+	goroutines *metrics.Gauge
+}
+
+func newResources(meter *metrics.Scope) resources {
+	inFlight, _ := meter.GaugeVector(metrics.Spec{
+		Name:    "in_flight_requests",
+		Help:    "Number of unary and oneway requests in flight.",
+		VarTags: []string{"direction", "rpc_type"},
+	})
+	streams, _ := meter.GaugeVector(metrics.Spec{
+		Name:    "active_streams",
+		Help:    "Number of open streams.",
+		VarTags: []string{"direction"},
+	})
+	goroutines, _ := meter.Gauge(metrics.Spec{
+		Name: "goroutines",
+		Help: "Number of goroutines in the process, sampled when requests start.",
+	})
+
+	get := func(gv *metrics.GaugeVector, pairs ...string) *metrics.Gauge {
+		g, _ := gv.Get(pairs...)
+		return g
+	}
+	inbound, outbound := string(_directionInbound), string(_directionOutbound)
+	unary, oneway := transport.Unary.String(), transport.Oneway.String()
+	return resources{
+		inboundUnary:    get(inFlight, "direction", inbound, "rpc_type", unary),
+		outboundUnary:   get(inFlight, "direction", outbound, "rpc_type", unary),
+		inboundOneway:   get(inFlight, "direction", inbound, "rpc_type", oneway),
+		outboundOneway:  get(inFlight, "direction", outbound, "rpc_type", oneway),
+		inboundStreams:  get(streams, "direction", inbound),
+		outboundStreams: get(streams, "direction", outbound),
+		goroutines:      goroutines,
+	}
+}
+
+// inFlight returns the gauge of in-flight requests or open streams of the
+// given type and direction.
+func (r *resources) inFlight(rpcType transport.Type, direction directionName) *metrics.Gauge {
+	inbound := direction == _directionInbound
+	switch rpcType {
+	case transport.Unary:
+		if inbound {
+			return r.inboundUnary
+		}
+		return r.outboundUnary
+	case transport.Oneway:
+		if inbound {
+			return r.inboundOneway
+		}
+		return r.outboundOneway
+	case transport.Streaming:
+		if inbound {
+			return r.inboundStreams
+		}
+		return r.outboundStreams
+	}
+	return nil
+}
+
+// start records the start of a request and returns a function that records
+// its end.
+func (r *resources) start(rpcType transport.Type, direction directionName) func() {
+	r.goroutines.Store(int64(_numGoroutine()))
+	g := r.inFlight(rpcType, direction)
+	g.Inc()
+	return func() { g.Dec() }
+}
+
+// trackedClientStream counts an outbound stream as open until it is closed
+// or fails to receive a message.
+type trackedClientStream struct {
+	*transport.ClientStream
+
+	once sync.Once
+	end  func()
+}
+
+func (s *trackedClientStream) ReceiveMessage(ctx context.Context) (*transport.StreamMessage, error) {
+	msg, err := s.ClientStream.ReceiveMessage(ctx)
+	if err != nil {
+		s.once.Do(s.end)
+	}
+	return msg, err
+}
+
+func (s *trackedClientStream) Close(ctx context.Context) error {
+	s.once.Do(s.end)
+	return s.ClientStream.Close(ctx)
+}
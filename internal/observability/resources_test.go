@@ -0,0 +1,64 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package observability
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/zap"
+)
+
+type handlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f handlerFunc) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	return f(ctx, req, resw)
+}
+
+func TestResourceGauges(t *testing.T) {
+	defer stubTime()()
+	root := metrics.New()
+	mw := NewMiddleware(zap.NewNop(), root.Scope(), NewNopContextExtractor())
+	req := &transport.Request{Caller: "caller", Service: "service", Procedure: "procedure"}
+
+	err := mw.Handle(context.Background(), req, nil, handlerFunc(
+		func(context.Context, *transport.Request, transport.ResponseWriter) error {
+			gauges := resourceGauges(root.Snapshot())
+			assert.Equal(t, int64(1), gauges["in_flight_requests inbound Unary"], "request must be in flight")
+			assert.Equal(t, int64(10), gauges["goroutines"])
+			return nil
+		}))
+	require.NoError(t, err)
+	assert.Equal(t, idleResourceGauges, resourceGauges(root.Snapshot()))
+
+	sreq := &transport.StreamRequest{Meta: req.ToRequestMeta()}
+	stream, err := mw.CallStream(context.Background(), sreq, fakeOutbound{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), resourceGauges(root.Snapshot())["active_streams outbound"], "stream must be open")
+
+	require.NoError(t, stream.Close(context.Background()))
+	require.NoError(t, stream.Close(context.Background()))
+	assert.Equal(t, idleResourceGauges, resourceGauges(root.Snapshot()), "stream must be counted once")
+}
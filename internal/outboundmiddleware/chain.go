@@ -236,3 +236,48 @@ func (x streamChainExec) CallStream(ctx context.Context, request *transport.Stre
 	x.Chain = x.Chain[1:]
 	return next.CallStream(ctx, request, x)
 }
+
+// UnchainUnary returns the middleware combined by UnaryChain, outermost first.
+// It returns nil for nil and no-op middleware.
+func UnchainUnary(mw middleware.UnaryOutbound) []middleware.UnaryOutbound {
+	switch m := mw.(type) {
+	case nil:
+		return nil
+	case unaryChain:
+		return append([]middleware.UnaryOutbound(nil), m...)
+	}
+	if mw == middleware.NopUnaryOutbound {
+		return nil
+	}
+	return []middleware.UnaryOutbound{mw}
+}
+
+// UnchainOneway returns the middleware combined by OnewayChain, outermost first.
+// It returns nil for nil and no-op middleware.
+func UnchainOneway(mw middleware.OnewayOutbound) []middleware.OnewayOutbound {
+	switch m := mw.(type) {
+	case nil:
+		return nil
+	case onewayChain:
+		return append([]middleware.OnewayOutbound(nil), m...)
+	}
+	if mw == middleware.NopOnewayOutbound {
+		return nil
+	}
+	return []middleware.OnewayOutbound{mw}
+}
+
+// UnchainStream returns the middleware combined by StreamChain, outermost first.
+// It returns nil for nil and no-op middleware.
+func UnchainStream(mw middleware.StreamOutbound) []middleware.StreamOutbound {
+	switch m := mw.(type) {
+	case nil:
+		return nil
+	case streamChain:
+		return append([]middleware.StreamOutbound(nil), m...)
+	}
+	if mw == middleware.NopStreamOutbound {
+		return nil
+	}
+	return []middleware.StreamOutbound{mw}
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package peerhint ranks peers by how well they meet the hints requests give
+// peer choosers with peer.WithAvoidPeers and peer.WithPreferredPeers.
+package peerhint
+
+import (
+	"context"
+
+	"go.uber.org/yarpc/api/peer"
+)
+
+// Hints are the peer hints of a request.
+type Hints struct {
+	avoid  []string
+	prefer func(peer.Identifier) bool
+}
+
+// FromContext returns the peer hints recorded on the context.
+func FromContext(ctx context.Context) Hints {
+	return Hints{
+		avoid:  peer.AvoidPeersFromContext(ctx),
+		prefer: peer.PreferredPeersFromContext(ctx),
+	}
+}
+
+// Rank returns how well the peer meets the hints, lower being better: peers
+// that are not avoided come first, then peers that are preferred. Peers that
+// meet all hints have rank zero.
+func (h Hints) Rank(id peer.Identifier) int {
+	r := 0
+	if h.avoids(id.Identifier()) {
+		r += 2
+	}
+	if h.prefer != nil && !h.prefer(id) {
+		r++
+	}
+	return r
+}
+
+func (h Hints) avoids(id string) bool {
+	for _, i := range h.avoid {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peerhint
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/peer/hostport"
+)
+
+func TestRank(t *testing.T) {
+	ctx := peer.WithAvoidPeers(context.Background(), "avoided")
+	ctx = peer.WithPreferredPeers(ctx, func(id peer.Identifier) bool {
+		return id.Identifier() != "other"
+	})
+	hints := FromContext(ctx)
+
+	assert.Equal(t, 0, hints.Rank(hostport.PeerIdentifier("preferred")))
+	assert.Equal(t, 1, hints.Rank(hostport.PeerIdentifier("other")))
+	assert.Equal(t, 2, hints.Rank(hostport.PeerIdentifier("avoided")))
+
+	assert.Equal(t, 0, FromContext(context.Background()).Rank(hostport.PeerIdentifier("avoided")))
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package peermetrics provides the metrics reported by peer lists.
+package peermetrics
+
+import (
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/net/metrics"
+)
+
+// Instrumented is implemented by peer lists that report metrics. The
+// dispatcher instruments the peer lists of its outbounds with its own
+// metrics scope.
+type Instrumented interface {
+	// Instrument starts reporting metrics for the peer list to the given
+	// scope. It must be called before the peer list is started.
+	Instrument(meter *metrics.Scope)
+}
+
+var _bucketsMs = []int64{
+	1, 2, 5,
+	10, 20, 50,
+	100, 200, 500,
+	1000, 2000, 5000,
+}
+
+// Metrics are the metrics of a single peer list.
+//
+// All methods of Metrics are no-ops on a nil Metrics so that peer lists may
+// record metrics before, or without, being instrumented.
+type Metrics struct {
+	available       *metrics.Gauge
+	unavailable     *metrics.Gauge
+	pending         *metrics.Gauge
+	adds            *metrics.Counter
+	removes         *metrics.Counter
+	statusChanges   *metrics.Counter
+	noPeerAvailable *metrics.Counter
+	chooseLatency   *metrics.Histogram
+}
+
+// New builds the metrics for the peer list with the given name.
+//
+// New returns nil, disabling metrics for the list, if the metrics cannot be
+// registered, for example because another list with the same name already
+// reports to the same scope.
+func New(meter *metrics.Scope, list string) *Metrics {
+	tags := metrics.Tags{"peer_list": list}
+	gauge := func(name, help string) (*metrics.Gauge, error) {
+		return meter.Gauge(metrics.Spec{Name: name, Help: help, ConstTags: tags})
+	}
+	counter := func(name, help string) (*metrics.Counter, error) {
+		return meter.Counter(metrics.Spec{Name: name, Help: help, ConstTags: tags})
+	}
+
+	var (
+		m    Metrics
+		errs = make([]error, 8)
+	)
+	m.available, errs[0] = gauge("peers_available",
+		"Number of peers that are available to receive requests.")
+	m.unavailable, errs[1] = gauge("peers_unavailable",
+		"Number of peers in the list that are not available.")
+	m.pending, errs[2] = gauge("peer_pending_requests",
+		"Number of requests in flight to peers of the list.")
+	m.adds, errs[3] = counter("peer_adds",
+		"Number of peers added to the list.")
+	m.removes, errs[4] = counter("peer_removes",
+		"Number of peers removed from the list.")
+	m.statusChanges, errs[5] = counter("peer_availability_changes",
+		"Number of times peers became available or unavailable.")
+	m.noPeerAvailable, errs[6] = counter("peer_choose_no_peer_available",
+		"Number of times no peer was available to choose.")
+	m.chooseLatency, errs[7] = meter.Histogram(metrics.HistogramSpec{
+		Spec: metrics.Spec{
+			Name:      "peer_choose_latency_ms",
+			Help:      "Latency distribution of choosing a peer, including waiting for one to become available.",
+			ConstTags: tags,
+		},
+		Unit:    time.Millisecond,
+		Buckets: _bucketsMs,
+	})
+	if multierr.Combine(errs...) != nil {
+		return nil
+	}
+	return &m
+}
+
+// SetPeers records the current number of available and unavailable peers.
+func (m *Metrics) SetPeers(available, unavailable int) {
+	if m == nil {
+		return
+	}
+	m.available.Store(int64(available))
+	m.unavailable.Store(int64(unavailable))
+}
+
+// Added records that a peer was added to the list.
+func (m *Metrics) Added() {
+	if m != nil {
+		m.adds.Inc()
+	}
+}
+
+// Removed records that a peer was removed from the list.
+func (m *Metrics) Removed() {
+	if m != nil {
+		m.removes.Inc()
+	}
+}
+
+// StatusChanged records that a peer became available or unavailable.
+func (m *Metrics) StatusChanged() {
+	if m != nil {
+		m.statusChanges.Inc()
+	}
+}
+
+// Chose records an attempt to choose a peer that started at the given time.
+func (m *Metrics) Chose(start time.Time, err error) {
+	if m == nil {
+		return
+	}
+	m.chooseLatency.Observe(time.Since(start))
+	if err != nil {
+		m.noPeerAvailable.Inc()
+	}
+}
+
+// RequestStarted records that a request was sent to a peer of the list.
+func (m *Metrics) RequestStarted() {
+	if m != nil {
+		m.pending.Inc()
+	}
+}
+
+// RequestFinished records that a request to a peer of the list finished.
+func (m *Metrics) RequestFinished() {
+	if m != nil {
+		m.pending.Dec()
+	}
+}
+
+// Snapshot is a point-in-time view of the metrics of a peer list.
+type Snapshot struct {
+	Available       int64
+	Unavailable     int64
+	Pending         int64
+	Adds            int64
+	Removes         int64
+	StatusChanges   int64
+	NoPeerAvailable int64
+}
+
+// Snapshot returns the current values of the metrics. It returns an empty
+// snapshot for nil Metrics.
+func (m *Metrics) Snapshot() Snapshot {
+	if m == nil {
+		return Snapshot{}
+	}
+	return Snapshot{
+		Available:       m.available.Load(),
+		Unavailable:     m.unavailable.Load(),
+		Pending:         m.pending.Load(),
+		Adds:            m.adds.Load(),
+		Removes:         m.removes.Load(),
+		StatusChanges:   m.statusChanges.Load(),
+		NoPeerAvailable: m.noPeerAvailable.Load(),
+	}
+}
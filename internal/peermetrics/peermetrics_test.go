@@ -0,0 +1,68 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peermetrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/net/metrics"
+)
+
+func TestNilMetrics(t *testing.T) {
+	var m *Metrics
+	assert.NotPanics(t, func() {
+		m.SetPeers(1, 2)
+		m.Added()
+		m.Removed()
+		m.StatusChanged()
+		m.Chose(time.Now(), errors.New("great sadness"))
+		m.RequestStarted()
+		m.RequestFinished()
+	})
+	assert.Equal(t, Snapshot{}, m.Snapshot())
+}
+
+func TestMetrics(t *testing.T) {
+	m := New(metrics.New().Scope(), "test")
+	m.SetPeers(3, 1)
+	m.Added()
+	m.Added()
+	m.Removed()
+	m.StatusChanged()
+	m.Chose(time.Now(), nil)
+	m.Chose(time.Now(), errors.New("no peer"))
+	m.RequestStarted()
+	m.RequestStarted()
+	m.RequestFinished()
+
+	assert.Equal(t, Snapshot{
+		Available:       3,
+		Unavailable:     1,
+		Pending:         1,
+		Adds:            2,
+		Removes:         1,
+		StatusChanges:   1,
+		NoPeerAvailable: 1,
+	}, m.Snapshot())
+}
@@ -22,7 +22,6 @@ package protoplugin
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
 	"go/format"
 	"path"
@@ -32,10 +31,6 @@ import (
 	"github.com/gogo/protobuf/protoc-gen-gogo/plugin"
 )
 
-var (
-	errNoTargetService = errors.New("no target service defined in the file")
-)
-
 type generator struct {
 	registry             *registry
 	tmpl                 *template.Template
@@ -82,7 +77,7 @@ func (g *generator) Generate(targets []*File) ([]*plugin_go.CodeGeneratorRespons
 	var files []*plugin_go.CodeGeneratorResponse_File
 	for _, file := range targets {
 		code, err := g.generate(file)
-		if err == errNoTargetService {
+		if err == ErrNoTargetService {
 			continue
 		}
 		if err != nil {
@@ -35,6 +35,7 @@ Note that "FQMN", "FQSN", etc stand for "Fully Qualified Message Name",
 package protoplugin
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -46,6 +47,10 @@ import (
 	"github.com/gogo/protobuf/protoc-gen-gogo/plugin"
 )
 
+// ErrNoTargetService may be returned by the templateInfoChecker given to
+// NewRunner to skip generating code for a file.
+var ErrNoTargetService = errors.New("no target service defined in the file")
+
 // Do is a helper function for protobuf plugins.
 //
 //   func main() {
@@ -0,0 +1,38 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package responsewriter helps middleware wrap transport.ResponseWriters.
+package responsewriter
+
+import "go.uber.org/yarpc/api/transport"
+
+// Wrapper wraps a transport.ResponseWriter and forwards the optional
+// interfaces that transports implement on their ResponseWriters, like
+// transport.StreamingResponseWriter. Middleware that wraps ResponseWriters
+// should embed a Wrapper instead of the ResponseWriter itself so that
+// wrapping it does not disable these features.
+type Wrapper struct {
+	transport.ResponseWriter
+}
+
+// StreamResponse implements transport.StreamingResponseWriter.
+func (w Wrapper) StreamResponse() bool {
+	return transport.StreamResponse(w.ResponseWriter)
+}
@@ -0,0 +1,203 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpcerrors
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+const (
+	_detailTypeString = "string"
+	_detailTypeInt    = "int"
+	_detailTypeFloat  = "float"
+	_detailTypeBool   = "bool"
+)
+
+// wireStatus is the JSON representation of the details and cause of a
+// Status. The code, name and message of the top-level Status are already
+// carried by dedicated transport headers, so they are only set for causes.
+type wireStatus struct {
+	Code    string       `json:"code,omitempty"`
+	Name    string       `json:"name,omitempty"`
+	Message string       `json:"message,omitempty"`
+	Details []wireDetail `json:"details,omitempty"`
+	Cause   *wireStatus  `json:"cause,omitempty"`
+}
+
+type wireDetail struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// MarshalDetails serializes the details and cause of the given Status into a
+// string that is safe to use as a header value on all transports.
+//
+// An empty string is returned if the Status has neither details nor a cause.
+func MarshalDetails(status *yarpcerrors.Status) (string, error) {
+	if len(status.Details()) == 0 && status.Cause() == nil {
+		return "", nil
+	}
+	w, err := toWireStatus(status)
+	if err != nil {
+		return "", err
+	}
+	// The code, name and message are carried separately.
+	w.Code, w.Name, w.Message = "", "", ""
+	b, err := json.Marshal(w)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// WithMarshaledDetails returns a copy of the given Status with the details
+// and cause serialized by MarshalDetails attached to it.
+//
+// The Status is returned unchanged if the given string is empty.
+func WithMarshaledDetails(status *yarpcerrors.Status, details string) (*yarpcerrors.Status, error) {
+	if status == nil || details == "" {
+		return status, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(details)
+	if err != nil {
+		return status, err
+	}
+	var w wireStatus
+	if err := json.Unmarshal(b, &w); err != nil {
+		return status, err
+	}
+	return applyWireStatus(status, &w)
+}
+
+// DetailsHeader returns the details and cause of the given Status serialized
+// by MarshalDetails for transports to send in a header.
+//
+// Details that cannot be serialized are logged and left out, so the caller
+// still receives the error itself.
+func DetailsHeader(logger *zap.Logger, status *yarpcerrors.Status) string {
+	details, err := MarshalDetails(status)
+	if err != nil {
+		logger.Warn("failed to serialize error details",
+			zap.Stringer("code", status.Code()), zap.Error(err))
+		return ""
+	}
+	return details
+}
+
+// WithDetailsHeader returns a copy of the given Status with the details and
+// cause read from a header sent by DetailsHeader attached to it.
+//
+// Headers that cannot be parsed are logged and the Status is returned
+// unchanged, so the caller still receives the error itself.
+func WithDetailsHeader(logger *zap.Logger, status *yarpcerrors.Status, header string) *yarpcerrors.Status {
+	withDetails, err := WithMarshaledDetails(status, header)
+	if err != nil {
+		logger.Warn("failed to parse error details",
+			zap.Stringer("code", status.Code()), zap.Error(err))
+		return status
+	}
+	return withDetails
+}
+
+func toWireStatus(status *yarpcerrors.Status) (*wireStatus, error) {
+	code, err := status.Code().MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	w := &wireStatus{
+		Code:    string(code),
+		Name:    status.Name(),
+		Message: status.Message(),
+	}
+	for _, d := range status.Details() {
+		wd := wireDetail{Key: d.Key()}
+		switch v := d.Value().(type) {
+		case string:
+			wd.Type, wd.Value = _detailTypeString, v
+		case int64:
+			wd.Type, wd.Value = _detailTypeInt, strconv.FormatInt(v, 10)
+		case float64:
+			wd.Type, wd.Value = _detailTypeFloat, strconv.FormatFloat(v, 'g', -1, 64)
+		case bool:
+			wd.Type, wd.Value = _detailTypeBool, strconv.FormatBool(v)
+		default:
+			return nil, fmt.Errorf("unsupported type %T for error detail %q", v, d.Key())
+		}
+		w.Details = append(w.Details, wd)
+	}
+	if cause := status.Cause(); cause != nil {
+		wc, err := toWireStatus(cause)
+		if err != nil {
+			return nil, err
+		}
+		w.Cause = wc
+	}
+	return w, nil
+}
+
+func applyWireStatus(status *yarpcerrors.Status, w *wireStatus) (*yarpcerrors.Status, error) {
+	details := make([]yarpcerrors.Detail, 0, len(w.Details))
+	for _, wd := range w.Details {
+		d, err := fromWireDetail(wd)
+		if err != nil {
+			return status, err
+		}
+		details = append(details, d)
+	}
+	status = status.WithDetails(details...)
+	if w.Cause != nil {
+		var code yarpcerrors.Code
+		if err := code.UnmarshalText([]byte(w.Cause.Code)); err != nil {
+			return status, err
+		}
+		cause, err := applyWireStatus(NewWithNamef(code, w.Cause.Name, w.Cause.Message), w.Cause)
+		if err != nil {
+			return status, err
+		}
+		status = status.WithCause(cause)
+	}
+	return status, nil
+}
+
+func fromWireDetail(wd wireDetail) (yarpcerrors.Detail, error) {
+	switch wd.Type {
+	case _detailTypeString:
+		return yarpcerrors.StringDetail(wd.Key, wd.Value), nil
+	case _detailTypeInt:
+		v, err := strconv.ParseInt(wd.Value, 10, 64)
+		return yarpcerrors.IntDetail(wd.Key, v), err
+	case _detailTypeFloat:
+		v, err := strconv.ParseFloat(wd.Value, 64)
+		return yarpcerrors.FloatDetail(wd.Key, v), err
+	case _detailTypeBool:
+		v, err := strconv.ParseBool(wd.Value)
+		return yarpcerrors.BoolDetail(wd.Key, v), err
+	default:
+		return yarpcerrors.Detail{}, fmt.Errorf("unknown type %q for error detail %q", wd.Type, wd.Key)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpcerrors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestMarshalDetails(t *testing.T) {
+	tests := []struct {
+		name string
+		give *yarpcerrors.Status
+	}{
+		{
+			name: "no details",
+			give: yarpcerrors.Newf(yarpcerrors.CodeNotFound, "foo"),
+		},
+		{
+			name: "details",
+			give: yarpcerrors.Newf(yarpcerrors.CodeNotFound, "foo").WithDetails(
+				yarpcerrors.StringDetail("string", "bar\nbaz"),
+				yarpcerrors.IntDetail("int", -12),
+				yarpcerrors.FloatDetail("float", 0.25),
+				yarpcerrors.BoolDetail("bool", false),
+			),
+		},
+		{
+			name: "nested causes",
+			give: NewWithNamef(yarpcerrors.CodeInternal, "foo", "bar").
+				WithDetails(yarpcerrors.StringDetail("a", "b")).
+				WithCause(NewWithNamef(yarpcerrors.CodeUnavailable, "baz", "qux").
+					WithDetails(yarpcerrors.IntDetail("c", 1)).
+					WithCause(yarpcerrors.Newf(yarpcerrors.CodeDataLoss, "great sadness"))),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details, err := MarshalDetails(tt.give)
+			require.NoError(t, err)
+
+			got, err := WithMarshaledDetails(NewWithNamef(tt.give.Code(), tt.give.Name(), tt.give.Message()), details)
+			require.NoError(t, err)
+			assert.Equal(t, tt.give, got)
+		})
+	}
+}
+
+func TestWithMarshaledDetailsInvalid(t *testing.T) {
+	status := yarpcerrors.Newf(yarpcerrors.CodeNotFound, "foo")
+	for _, give := range []string{"not base64!", "bm90IGpzb24=", "eyJkZXRhaWxzIjpbeyJrZXkiOiJhIiwidHlwZSI6ImZvbyJ9XX0="} {
+		got, err := WithMarshaledDetails(status, give)
+		assert.Error(t, err)
+		assert.Equal(t, status, got)
+	}
+}
+
+func TestWithDetailsHeaderInvalid(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	status := yarpcerrors.Newf(yarpcerrors.CodeNotFound, "foo")
+
+	assert.Equal(t, status, WithDetailsHeader(zap.New(core), status, "not base64!"))
+	assert.Equal(t, 1, logs.FilterMessage("failed to parse error details").Len())
+
+	assert.Equal(t, status, WithDetailsHeader(zap.New(core), status, ""))
+	assert.Equal(t, 1, logs.Len())
+}
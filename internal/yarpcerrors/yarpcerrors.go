@@ -37,7 +37,11 @@ func NewWithNamef(code yarpcerrors.Code, name string, format string, args ...int
 }
 
 // AnnotateWithInfo will take an error and add info to it's error message while
-// keeping the same status code.
+// keeping the same status code, details and cause.
 func AnnotateWithInfo(status *yarpcerrors.Status, format string, args ...interface{}) *yarpcerrors.Status {
-	return yarpcerrors.Newf(status.Code(), "%s: %s", fmt.Sprintf(format, args...), status.Message())
+	annotated := yarpcerrors.Newf(status.Code(), "%s: %s", fmt.Sprintf(format, args...), status.Message()).WithDetails(status.Details()...)
+	if cause := status.Cause(); cause != nil {
+		annotated = annotated.WithCause(cause)
+	}
+	return annotated
 }
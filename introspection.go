@@ -23,6 +23,7 @@ package yarpc
 import (
 	"fmt"
 	"runtime"
+	"strings"
 
 	tchannel "github.com/uber/tchannel-go"
 	thriftrw "go.uber.org/thriftrw/version"
@@ -73,6 +74,14 @@ func (d *Dispatcher) Introspect() introspection.DispatcherStatus {
 			outbounds = append(outbounds, status)
 		}
 	}
+	var chains []introspection.MiddlewareChain
+	for _, c := range d.MiddlewareChains() {
+		chains = append(chains, introspection.MiddlewareChain{
+			Direction:  c.Direction,
+			RPCType:    strings.ToLower(c.Type.String()),
+			Middleware: c.Middleware,
+		})
+	}
 	procedures := introspection.IntrospectProcedures(d.table.Procedures())
 	return introspection.DispatcherStatus{
 		Name:            d.name,
@@ -80,6 +89,7 @@ func (d *Dispatcher) Introspect() introspection.DispatcherStatus {
 		Procedures:      procedures,
 		Inbounds:        inbounds,
 		Outbounds:       outbounds,
+		Middleware:      chains,
 		PackageVersions: PackageVersions,
 	}
 }
@@ -0,0 +1,120 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import (
+	"fmt"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/inboundmiddleware"
+	"go.uber.org/yarpc/internal/outboundmiddleware"
+)
+
+// Directions of middleware chains.
+const (
+	InboundDirection  = "inbound"
+	OutboundDirection = "outbound"
+)
+
+// MiddlewareChain describes the middleware a Dispatcher applies to requests
+// of one RPC type in one direction.
+type MiddlewareChain struct {
+	// Direction is either InboundDirection or OutboundDirection.
+	Direction string
+
+	// Type is the RPC type of the requests the middleware applies to.
+	Type transport.Type
+
+	// Middleware holds the names of the middleware in the chain, outermost
+	// first. Middleware that implement middleware.Named report their own
+	// name; other middleware are named after their Go type.
+	Middleware []string
+}
+
+// MiddlewareChains returns the effective middleware chains of the
+// Dispatcher, including the middleware added by the Dispatcher itself.
+func (d *Dispatcher) MiddlewareChains() []MiddlewareChain {
+	chains := d.middlewareChains()
+	result := make([]MiddlewareChain, len(chains))
+	for i, c := range chains {
+		names := make([]string, len(c.middleware))
+		for j, mw := range c.middleware {
+			names[j] = middleware.Name(mw)
+		}
+		result[i] = MiddlewareChain{
+			Direction:  c.direction,
+			Type:       c.rpcType,
+			Middleware: names,
+		}
+	}
+	return result
+}
+
+// validateMiddleware verifies that none of the middleware chains of the
+// Dispatcher violate the ordering constraints of their middleware.
+func (d *Dispatcher) validateMiddleware() error {
+	for _, c := range d.middlewareChains() {
+		if err := middleware.ValidateOrder(c.middleware); err != nil {
+			return fmt.Errorf("%v %v middleware: %v", c.direction, c.rpcType, err)
+		}
+	}
+	return nil
+}
+
+type middlewareChain struct {
+	direction  string
+	rpcType    transport.Type
+	middleware []interface{}
+}
+
+func (d *Dispatcher) middlewareChains() []middlewareChain {
+	var (
+		inUnary, inOneway, inStream    []interface{}
+		outUnary, outOneway, outStream []interface{}
+	)
+	for _, mw := range inboundmiddleware.UnchainUnary(d.inboundMiddleware.Unary) {
+		inUnary = append(inUnary, mw)
+	}
+	for _, mw := range inboundmiddleware.UnchainOneway(d.inboundMiddleware.Oneway) {
+		inOneway = append(inOneway, mw)
+	}
+	for _, mw := range inboundmiddleware.UnchainStream(d.inboundMiddleware.Stream) {
+		inStream = append(inStream, mw)
+	}
+	for _, mw := range outboundmiddleware.UnchainUnary(d.outboundMiddleware.Unary) {
+		outUnary = append(outUnary, mw)
+	}
+	for _, mw := range outboundmiddleware.UnchainOneway(d.outboundMiddleware.Oneway) {
+		outOneway = append(outOneway, mw)
+	}
+	for _, mw := range outboundmiddleware.UnchainStream(d.outboundMiddleware.Stream) {
+		outStream = append(outStream, mw)
+	}
+	return []middlewareChain{
+		{InboundDirection, transport.Unary, inUnary},
+		{InboundDirection, transport.Oneway, inOneway},
+		{InboundDirection, transport.Streaming, inStream},
+		{OutboundDirection, transport.Unary, outUnary},
+		{OutboundDirection, transport.Oneway, outOneway},
+		{OutboundDirection, transport.Streaming, outStream},
+	}
+}
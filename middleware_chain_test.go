@@ -0,0 +1,111 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	. "go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/outboundmiddleware"
+	"go.uber.org/yarpc/x/pushback"
+	"go.uber.org/yarpc/x/retry"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+func TestMiddlewareChains(t *testing.T) {
+	d := NewDispatcher(Config{
+		Name: "test",
+		OutboundMiddleware: OutboundMiddleware{
+			Unary:  outboundmiddleware.UnaryChain(retry.New(), pushback.New()),
+			Oneway: pushback.New(),
+		},
+		ErrorMapper: ErrorMapperFunc(func(error) *yarpcerrors.Status { return nil }),
+	})
+
+	assert.Equal(t, []MiddlewareChain{
+		{
+			Direction:  InboundDirection,
+			Type:       transport.Unary,
+			Middleware: []string{"observability", "deadlinebudget", "errormapper"},
+		},
+		{
+			Direction:  InboundDirection,
+			Type:       transport.Oneway,
+			Middleware: []string{"observability", "deadlinebudget", "errormapper"},
+		},
+		{
+			Direction:  InboundDirection,
+			Type:       transport.Streaming,
+			Middleware: []string{"observability", "deadlinebudget", "errormapper"},
+		},
+		{
+			Direction:  OutboundDirection,
+			Type:       transport.Unary,
+			Middleware: []string{"retry", "pushback", "deadlinebudget", "observability"},
+		},
+		{
+			Direction:  OutboundDirection,
+			Type:       transport.Oneway,
+			Middleware: []string{"pushback", "deadlinebudget", "observability"},
+		},
+		{
+			Direction:  OutboundDirection,
+			Type:       transport.Streaming,
+			Middleware: []string{"deadlinebudget", "observability"},
+		},
+	}, d.MiddlewareChains())
+
+	require.NoError(t, d.Start())
+	require.NoError(t, d.Stop())
+}
+
+func TestMiddlewareChainsInvalidOrder(t *testing.T) {
+	newDispatcher := func() *Dispatcher {
+		return NewDispatcher(Config{
+			Name: "test",
+			OutboundMiddleware: OutboundMiddleware{
+				Unary: outboundmiddleware.UnaryChain(pushback.New(), retry.New()),
+			},
+			DisableAutoObservabilityMiddleware: true,
+			DisableDeadlineBudget:              true,
+		})
+	}
+
+	d := newDispatcher()
+	assert.Equal(t, []MiddlewareChain{
+		{Direction: InboundDirection, Type: transport.Unary, Middleware: []string{}},
+		{Direction: InboundDirection, Type: transport.Oneway, Middleware: []string{}},
+		{Direction: InboundDirection, Type: transport.Streaming, Middleware: []string{}},
+		{Direction: OutboundDirection, Type: transport.Unary, Middleware: []string{"pushback", "retry"}},
+		{Direction: OutboundDirection, Type: transport.Oneway, Middleware: []string{}},
+		{Direction: OutboundDirection, Type: transport.Streaming, Middleware: []string{}},
+	}, d.MiddlewareChains())
+
+	wantErr := `outbound Unary middleware: invalid middleware order: ` +
+		`"retry" must be applied outside of "pushback": every attempt must respect pushback`
+	assert.EqualError(t, d.Start(), wantErr)
+
+	_, err := newDispatcher().PhasedStart()
+	assert.EqualError(t, err, wantErr)
+}
@@ -24,9 +24,11 @@ import (
 	"context"
 
 	"go.uber.org/multierr"
+	"go.uber.org/net/metrics"
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/internal/peermetrics"
 	"go.uber.org/yarpc/pkg/lifecycle"
 )
 
@@ -61,6 +63,14 @@ func (c *BoundChooser) ChooserList() peer.ChooserList {
 	return c.chooserList
 }
 
+// Instrument reports metrics for the bound peer list to the given scope if
+// the peer list supports metrics.
+func (c *BoundChooser) Instrument(meter *metrics.Scope) {
+	if list, ok := c.chooserList.(peermetrics.Instrumented); ok {
+		list.Instrument(meter)
+	}
+}
+
 // Choose returns a peer from the bound peer list.
 func (c *BoundChooser) Choose(ctx context.Context, treq *transport.Request) (peer peer.Peer, onFinish func(error), err error) {
 	return c.chooserList.Choose(ctx, treq)
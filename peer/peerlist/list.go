@@ -29,9 +29,12 @@ import (
 
 	"go.uber.org/atomic"
 	"go.uber.org/multierr"
+	"go.uber.org/net/metrics"
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/internal/peerhint"
+	"go.uber.org/yarpc/internal/peermetrics"
 	intyarpcerrors "go.uber.org/yarpc/internal/yarpcerrors"
 	"go.uber.org/yarpc/pkg/lifecycle"
 	"go.uber.org/yarpc/yarpcerrors"
@@ -42,9 +45,11 @@ var (
 )
 
 type listOptions struct {
-	capacity  int
-	noShuffle bool
-	seed      int64
+	capacity           int
+	noShuffle          bool
+	seed               int64
+	maxPendingRequests int
+	spillOver          bool
 }
 
 var defaultListOptions = listOptions{
@@ -87,6 +92,32 @@ func Seed(seed int64) ListOption {
 	})
 }
 
+// MaxPendingRequests limits the number of requests pending on each peer.
+// Requests count against the limit of a peer regardless of which list chose
+// the peer, so the limit holds across all outbounds that share a transport.
+// The limit is checked before a request starts, so concurrent requests may
+// briefly exceed it.
+//
+// When the peer the list would choose has reached the limit, Choose fails
+// with a ResourceExhausted error instead of queueing another request on a
+// slow peer, unless SpillOver is also specified.
+//
+// Defaults to no limit.
+func MaxPendingRequests(n int) ListOption {
+	return listOptionFunc(func(options *listOptions) {
+		options.maxPendingRequests = n
+	})
+}
+
+// SpillOver specifies that, when the peer the list would choose has reached
+// its MaxPendingRequests, the list tries its other available peers before
+// failing with a ResourceExhausted error.
+func SpillOver() ListOption {
+	return listOptionFunc(func(options *listOptions) {
+		options.spillOver = true
+	})
+}
+
 // New creates a new peer list with an identifier chooser for available peers.
 func New(name string, transport peer.Transport, availableChooser peer.ListImplementation, opts ...ListOption) *List {
 	options := defaultListOptions
@@ -105,6 +136,8 @@ func New(name string, transport peer.Transport, availableChooser peer.ListImplem
 		noShuffle:          options.noShuffle,
 		randSrc:            rand.NewSource(options.seed),
 		peerAvailableEvent: make(chan struct{}, 1),
+		maxPendingRequests: options.maxPendingRequests,
+		spillOver:          options.spillOver,
 	}
 }
 
@@ -134,9 +167,35 @@ type List struct {
 	noShuffle bool
 	randSrc   rand.Source
 
+	maxPendingRequests int
+	spillOver          bool
+
+	// metrics is nil until the list is instrumented.
+	metrics *peermetrics.Metrics
+
 	once *lifecycle.Once
 }
 
+var _ peermetrics.Instrumented = (*List)(nil)
+
+// Instrument reports metrics about the peers of the list, peer churn, and
+// peer selection to the given scope. Dispatchers instrument the peer lists
+// of their outbounds automatically.
+//
+// Instrument must be called before the list is started.
+func (pl *List) Instrument(meter *metrics.Scope) {
+	pl.lock.Lock()
+	defer pl.lock.Unlock()
+
+	pl.metrics = peermetrics.New(meter, pl.name)
+	pl.updatePeerMetrics()
+}
+
+// Must be run in a mutex.Lock()
+func (pl *List) updatePeerMetrics() {
+	pl.metrics.SetPeers(len(pl.availablePeers), len(pl.unavailablePeers))
+}
+
 // Update applies the additions and removals of peer Identifiers to the list
 // it returns a multi-error result of every failure that happened without
 // circuit breaking due to failures.
@@ -149,6 +208,7 @@ func (pl *List) Update(updates peer.ListUpdates) error {
 	defer pl.lock.Unlock()
 
 	if pl.shouldRetainPeers.Load() {
+		defer pl.updatePeerMetrics()
 		return pl.updateInitialized(updates)
 	}
 	return pl.updateUninitialized(updates)
@@ -210,6 +270,7 @@ func (pl *List) addPeerIdentifier(pid peer.Identifier) error {
 		return err
 	}
 	t.peer = p
+	pl.metrics.Added()
 	return pl.addPeer(t)
 }
 
@@ -265,6 +326,7 @@ func (pl *List) start() error {
 	}
 
 	pl.shouldRetainPeers.Store(true)
+	pl.updatePeerMetrics()
 
 	return errs
 }
@@ -294,6 +356,7 @@ func (pl *List) stop() error {
 	pl.addToUninitialized(unavailablePeers)
 
 	pl.shouldRetainPeers.Store(false)
+	pl.updatePeerMetrics()
 
 	return errs
 }
@@ -350,6 +413,7 @@ func (pl *List) removePeerIdentifier(pid peer.Identifier) error {
 		return err
 	}
 
+	pl.metrics.Removed()
 	return pl.transport.ReleasePeer(pid, t)
 }
 
@@ -387,16 +451,28 @@ func (pl *List) removeFromUnavailablePeers(t *peerThunk) {
 }
 
 // Choose selects the next available peer in the peer list
-func (pl *List) Choose(ctx context.Context, req *transport.Request) (peer.Peer, func(error), error) {
+func (pl *List) Choose(ctx context.Context, req *transport.Request) (_ peer.Peer, _ func(error), err error) {
+	if pl.metrics != nil {
+		start := time.Now()
+		defer func() { pl.metrics.Chose(start, err) }()
+	}
+
 	if err := pl.once.WaitUntilRunning(ctx); err != nil {
 		return nil, nil, intyarpcerrors.AnnotateWithInfo(yarpcerrors.FromError(err), "%s peer list is not running", pl.name)
 	}
 
+	if id, ok := peer.SelectedPeerFromContext(ctx); ok {
+		return pl.chooseSelected(id)
+	}
+
 	for {
 		pl.lock.RLock()
-		p := pl.availableChooser.Choose(ctx, req)
+		p, err := pl.chooseAvailable(ctx, req)
 		pl.lock.RUnlock()
 
+		if err != nil {
+			return nil, nil, err
+		}
 		if p != nil {
 			t := p.(*peerThunk)
 			pl.notifyPeerAvailable()
@@ -409,6 +485,79 @@ func (pl *List) Choose(ctx context.Context, req *transport.Request) (peer.Peer,
 	}
 }
 
+// chooseAvailable returns the peer the available chooser selects, or nil if
+// there are no available peers. If the peer has reached the limit of pending
+// requests, chooseAvailable spills over to the other available peers or
+// fails.
+//
+// Must be run in a mutex.RLock()
+func (pl *List) chooseAvailable(ctx context.Context, req *transport.Request) (peer.StatusPeer, error) {
+	p := pl.availableChooser.Choose(ctx, req)
+	if hints := peerhint.FromContext(ctx); p != nil && hints.Rank(p) > 0 {
+		p = pl.chooseBest(ctx, req, p, hints)
+	}
+	if p == nil || !pl.atPendingLimit(p) {
+		return p, nil
+	}
+	first := p
+	if pl.spillOver {
+		// Every call advances the chooser, so trying as many times as there
+		// are available peers gives each of them a chance.
+		for i := 1; i < len(pl.availablePeers); i++ {
+			p = pl.availableChooser.Choose(ctx, req)
+			if p != nil && !pl.atPendingLimit(p) {
+				return p, nil
+			}
+		}
+	}
+	return nil, yarpcerrors.Newf(yarpcerrors.CodeResourceExhausted,
+		"%s peer list: peer %q has reached the limit of %d pending requests",
+		pl.name, first.Identifier(), pl.maxPendingRequests)
+}
+
+// chooseBest returns the first peer the available chooser selects that meets
+// all the peer hints of the request, or the best ranked of the peers it
+// selects if there is no such peer.
+//
+// Must be run in a mutex.RLock()
+func (pl *List) chooseBest(ctx context.Context, req *transport.Request, first peer.StatusPeer, hints peerhint.Hints) peer.StatusPeer {
+	best, bestRank := first, hints.Rank(first)
+	for i := 1; i < len(pl.availablePeers) && bestRank > 0; i++ {
+		if p := pl.availableChooser.Choose(ctx, req); p != nil {
+			if r := hints.Rank(p); r < bestRank {
+				best, bestRank = p, r
+			}
+		}
+	}
+	return best
+}
+
+func (pl *List) atPendingLimit(p peer.StatusPeer) bool {
+	return pl.maxPendingRequests > 0 && p.Status().PendingRequestCount >= pl.maxPendingRequests
+}
+
+// chooseSelected returns the peer with the given identifier if it is
+// available and has not reached the limit of pending requests. Selected
+// peers are never spilled over to other peers.
+func (pl *List) chooseSelected(id string) (peer.Peer, func(error), error) {
+	pl.lock.RLock()
+	t := pl.availablePeers[id]
+	available := t != nil && t.Status().ConnectionStatus == peer.Available
+	atLimit := available && pl.atPendingLimit(t)
+	pl.lock.RUnlock()
+
+	if !available {
+		return nil, nil, yarpcerrors.Newf(yarpcerrors.CodeUnavailable, "%s peer list has no available peer %q", pl.name, id)
+	}
+	if atLimit {
+		return nil, nil, yarpcerrors.Newf(yarpcerrors.CodeResourceExhausted,
+			"%s peer list: peer %q has reached the limit of %d pending requests",
+			pl.name, id, pl.maxPendingRequests)
+	}
+	t.onStart()
+	return t.peer, t.boundOnFinish, nil
+}
+
 // IsRunning returns whether the peer list is running.
 func (pl *List) IsRunning() bool {
 	return pl.once.IsRunning()
@@ -473,6 +622,8 @@ func (pl *List) notifyStatusChanged(pid peer.Identifier) {
 	pl.lock.Lock()
 	defer pl.lock.Unlock()
 
+	defer pl.updatePeerMetrics()
+
 	if t := pl.availablePeers[pid.Identifier()]; t != nil {
 		// TODO: log error
 		_ = pl.handleAvailablePeerStatusChange(t)
@@ -495,6 +646,7 @@ func (pl *List) handleAvailablePeerStatusChange(t *peerThunk) error {
 		return nil
 	}
 
+	pl.metrics.StatusChanged()
 	pl.availableChooser.Remove(t, t.Subscriber())
 	t.SetSubscriber(nil)
 	delete(pl.availablePeers, t.peer.Identifier())
@@ -512,6 +664,7 @@ func (pl *List) handleUnavailablePeerStatusChange(t *peerThunk) error {
 		return nil
 	}
 
+	pl.metrics.StatusChanged()
 	pl.removeFromUnavailablePeers(t)
 	return pl.addToAvailablePeers(t)
 }
@@ -21,12 +21,19 @@
 package peerlist
 
 import (
+	"context"
 	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/net/metrics"
 	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/yarpc/yarpctest"
 )
 
 const (
@@ -77,3 +84,229 @@ func TestShuffle(t *testing.T) {
 		})
 	}
 }
+
+// firstPeer is a ListImplementation that always chooses the first
+// available peer.
+type firstPeer struct {
+	peers []peer.StatusPeer
+}
+
+func (*firstPeer) Start() error    { return nil }
+func (*firstPeer) Stop() error     { return nil }
+func (*firstPeer) IsRunning() bool { return true }
+
+func (f *firstPeer) Add(p peer.StatusPeer) peer.Subscriber {
+	f.peers = append(f.peers, p)
+	return nil
+}
+
+func (f *firstPeer) Remove(p peer.StatusPeer, _ peer.Subscriber) {
+	for i, q := range f.peers {
+		if q == p {
+			f.peers = append(f.peers[:i], f.peers[i+1:]...)
+			return
+		}
+	}
+}
+
+func (f *firstPeer) Choose(context.Context, *transport.Request) peer.StatusPeer {
+	if len(f.peers) == 0 {
+		return nil
+	}
+	return f.peers[0]
+}
+
+func TestInstrument(t *testing.T) {
+	pl := New("first", yarpctest.NewFakeTransport(), &firstPeer{})
+	pl.Instrument(metrics.New().Scope())
+	m := pl.metrics
+	require.NotNil(t, m)
+
+	require.NoError(t, pl.Start())
+	require.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: []peer.Identifier{id1, id2, id3},
+	}))
+	require.NoError(t, pl.Update(peer.ListUpdates{
+		Removals: []peer.Identifier{id3},
+	}))
+	assert.Equal(t, int64(2), m.Snapshot().Available, "available peers")
+	assert.Equal(t, int64(3), m.Snapshot().Adds, "peer adds")
+	assert.Equal(t, int64(1), m.Snapshot().Removes, "peer removes")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, onFinish, err := pl.Choose(ctx, &transport.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), m.Snapshot().Pending, "pending requests")
+	onFinish(nil)
+	assert.Equal(t, int64(0), m.Snapshot().Pending, "pending requests")
+
+	_, _, err = pl.Choose(peer.WithSelectedPeer(ctx, "unknown"), &transport.Request{})
+	require.Error(t, err)
+	assert.Equal(t, int64(1), m.Snapshot().NoPeerAvailable, "no peer available")
+
+	require.NoError(t, pl.Stop())
+	assert.Equal(t, int64(0), m.Snapshot().Available, "available peers after stop")
+}
+
+// rotatingPeer is a ListImplementation that chooses available peers in turn.
+type rotatingPeer struct {
+	firstPeer
+
+	next int
+}
+
+func (r *rotatingPeer) Choose(context.Context, *transport.Request) peer.StatusPeer {
+	if len(r.peers) == 0 {
+		return nil
+	}
+	r.next++
+	return r.peers[r.next%len(r.peers)]
+}
+
+// pendingTransport retains available hostport peers, which count their
+// pending requests, shared between all lists.
+type pendingTransport struct {
+	*yarpctest.FakeTransport
+
+	peers map[string]*hostport.Peer
+}
+
+func newPendingTransport() *pendingTransport {
+	return &pendingTransport{
+		FakeTransport: yarpctest.NewFakeTransport(),
+		peers:         make(map[string]*hostport.Peer),
+	}
+}
+
+func (t *pendingTransport) RetainPeer(id peer.Identifier, _ peer.Subscriber) (peer.Peer, error) {
+	p, ok := t.peers[id.Identifier()]
+	if !ok {
+		p = hostport.NewPeer(id.(hostport.PeerIdentifier), t)
+		p.SetStatus(peer.Available)
+		t.peers[id.Identifier()] = p
+	}
+	return p, nil
+}
+
+func TestMaxPendingRequests(t *testing.T) {
+	trans := newPendingTransport()
+	pl := New("first", trans, &firstPeer{}, MaxPendingRequests(2), NoShuffle())
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+	require.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: []peer.Identifier{id1, id2},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, onFinish, err := pl.Choose(ctx, &transport.Request{})
+	require.NoError(t, err)
+
+	// Requests chosen by another list count against the limit.
+	trans.peers[id1.Identifier()].StartRequest()
+
+	_, _, err = pl.Choose(ctx, &transport.Request{})
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeResourceExhausted, yarpcerrors.FromError(err).Code())
+
+	// Selected peers are held to the same limit.
+	_, _, err = pl.Choose(peer.WithSelectedPeer(ctx, id1.Identifier()), &transport.Request{})
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeResourceExhausted, yarpcerrors.FromError(err).Code())
+
+	onFinish(nil)
+	p, _, err := pl.Choose(ctx, &transport.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, id1.Identifier(), p.Identifier())
+}
+
+func TestMaxPendingRequestsSpillOver(t *testing.T) {
+	trans := newPendingTransport()
+	pl := New("rotating", trans, &rotatingPeer{}, MaxPendingRequests(1), SpillOver(), NoShuffle())
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+	require.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: []peer.Identifier{id1, id2, id3},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	trans.peers[id1.Identifier()].StartRequest()
+	trans.peers[id2.Identifier()].StartRequest()
+
+	for i := 0; i < 3; i++ {
+		p, onFinish, err := pl.Choose(ctx, &transport.Request{})
+		require.NoError(t, err)
+		assert.Equal(t, id3.Identifier(), p.Identifier(), "must spill over to the only peer below the limit")
+		onFinish(nil)
+	}
+
+	trans.peers[id3.Identifier()].StartRequest()
+	_, _, err := pl.Choose(ctx, &transport.Request{})
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeResourceExhausted, yarpcerrors.FromError(err).Code())
+}
+
+func TestAvoidPeers(t *testing.T) {
+	pl := New("rotating", yarpctest.NewFakeTransport(), &rotatingPeer{}, NoShuffle())
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+	require.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: []peer.Identifier{id1, id2},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	for i := 0; i < 4; i++ {
+		p, onFinish, err := pl.Choose(peer.WithAvoidPeers(ctx, id1.Identifier()), &transport.Request{})
+		require.NoError(t, err)
+		onFinish(nil)
+		assert.Equal(t, id2.Identifier(), p.Identifier())
+	}
+
+	// Avoided peers are still chosen if no other peer is available.
+	p, onFinish, err := pl.Choose(peer.WithAvoidPeers(ctx, id1.Identifier(), id2.Identifier()), &transport.Request{})
+	require.NoError(t, err)
+	onFinish(nil)
+	assert.Contains(t, []string{id1.Identifier(), id2.Identifier()}, p.Identifier())
+}
+
+func TestPreferredPeers(t *testing.T) {
+	pl := New("rotating", yarpctest.NewFakeTransport(), &rotatingPeer{}, NoShuffle())
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+
+	east := peer.NewLabeledIdentifier(id1.Identifier(), peer.Labels{peer.LabelZone: "east"})
+	west := peer.NewLabeledIdentifier(id2.Identifier(), peer.Labels{peer.LabelZone: "west"})
+	require.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: []peer.Identifier{east, west},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	preferWest := peer.WithPreferredPeers(ctx, peer.MatchLabels(peer.Labels{peer.LabelZone: "west"}))
+
+	for i := 0; i < 4; i++ {
+		p, onFinish, err := pl.Choose(preferWest, &transport.Request{})
+		require.NoError(t, err)
+		onFinish(nil)
+		assert.Equal(t, west.Identifier(), p.Identifier())
+	}
+
+	// Peers that are not avoided come before preferred ones.
+	p, onFinish, err := pl.Choose(peer.WithAvoidPeers(preferWest, west.Identifier()), &transport.Request{})
+	require.NoError(t, err)
+	onFinish(nil)
+	assert.Equal(t, east.Identifier(), p.Identifier())
+
+	// Other peers are chosen if no preferred peer is available.
+	preferNorth := peer.WithPreferredPeers(ctx, peer.MatchLabels(peer.Labels{peer.LabelZone: "north"}))
+	p, onFinish, err = pl.Choose(preferNorth, &transport.Request{})
+	require.NoError(t, err)
+	onFinish(nil)
+	assert.Contains(t, []string{east.Identifier(), west.Identifier()}, p.Identifier())
+}
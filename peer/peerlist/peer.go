@@ -38,10 +38,12 @@ type peerThunk struct {
 }
 
 func (t *peerThunk) onStart() {
+	t.list.metrics.RequestStarted()
 	t.peer.StartRequest()
 }
 
 func (t *peerThunk) onFinish(error) {
+	t.list.metrics.RequestFinished()
 	t.peer.EndRequest()
 }
 
@@ -49,6 +51,12 @@ func (t *peerThunk) Identifier() string {
 	return t.peer.Identifier()
 }
 
+// Labels returns the labels of the identifier the peer was added with, if
+// any.
+func (t *peerThunk) Labels() peer.Labels {
+	return peer.LabelsOf(t.id)
+}
+
 func (t *peerThunk) Status() peer.Status {
 	return t.peer.Status()
 }
@@ -32,6 +32,7 @@ import (
 
 func TestPendingHeapConfig(t *testing.T) {
 	minus1, zero, twenty := -1, 0, 20
+	seed := int64(42)
 	tests := []struct {
 		name    string
 		cfg     Configuration
@@ -60,6 +61,26 @@ func TestPendingHeapConfig(t *testing.T) {
 				Capacity: &twenty,
 			},
 		},
+		{
+			name: "negative max pending requests",
+			cfg: Configuration{
+				MaxPendingRequests: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "max pending requests with spill over",
+			cfg: Configuration{
+				MaxPendingRequests: 100,
+				SpillOver:          true,
+			},
+		},
+		{
+			name: "seed",
+			cfg: Configuration{
+				Seed: &seed,
+			},
+		},
 	}
 
 	s := Spec()
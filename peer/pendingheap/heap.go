@@ -27,6 +27,7 @@ import (
 
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/peerhint"
 )
 
 type pendingHeap struct {
@@ -41,19 +42,44 @@ type pendingHeap struct {
 }
 
 func (ph *pendingHeap) Choose(ctx context.Context, req *transport.Request) peer.StatusPeer {
+	hints := peerhint.FromContext(ctx)
+
 	ph.Lock()
-	ps, ok := ph.popPeer()
-	if !ok {
-		ph.Unlock()
+	defer ph.Unlock()
+
+	// Peers are popped in order of score until one meets all the peer hints
+	// of the request, so that hints take precedence over scores.
+	var (
+		popped   []*peerScore
+		best     *peerScore
+		bestRank int
+	)
+	for {
+		ps, ok := ph.popPeer()
+		if !ok {
+			break
+		}
+		popped = append(popped, ps)
+		if r := hints.Rank(ps.peer); best == nil || r < bestRank {
+			best, bestRank = ps, r
+		}
+		if bestRank == 0 {
+			break
+		}
+	}
+	if best == nil {
 		return nil
 	}
 
-	// Note: We push the peer back to reset the "next" counter.
-	// This gives us round-robin behavior.
-	ph.pushPeer(ps)
-
-	ph.Unlock()
-	return ps.peer
+	// Note: We push the peers back to reset the "next" counter, the chosen
+	// peer last. This gives us round-robin behavior.
+	for _, ps := range popped {
+		if ps != best {
+			ph.pushPeer(ps)
+		}
+	}
+	ph.pushPeer(best)
+	return best.peer
 }
 
 func (ph *pendingHeap) Add(p peer.StatusPeer) peer.Subscriber {
@@ -21,11 +21,13 @@
 package pendingheap
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
 )
 
 func TestPeerHeapRunning(t *testing.T) {
@@ -65,6 +67,43 @@ func TestPeerHeapOrdering(t *testing.T) {
 	}
 }
 
+type labeledPeer struct {
+	peer.LabeledIdentifier
+	pending int
+}
+
+func (p labeledPeer) Status() peer.Status {
+	return peer.Status{PendingRequestCount: p.pending, ConnectionStatus: peer.Available}
+}
+
+func TestScorePeerWeight(t *testing.T) {
+	light := labeledPeer{LabeledIdentifier: peer.NewLabeledIdentifier("light", nil), pending: 2}
+	heavy := labeledPeer{LabeledIdentifier: peer.NewLabeledIdentifier("heavy", peer.Labels{peer.LabelWeight: "4"}), pending: 4}
+	assert.True(t, scorePeer(heavy) < scorePeer(light), "heavier peers should take more pending requests")
+
+	heavy.pending = 8
+	assert.Equal(t, scorePeer(light), scorePeer(heavy))
+}
+
+func TestPeerHeapPreferredPeers(t *testing.T) {
+	var h pendingHeap
+	idle := labeledPeer{LabeledIdentifier: peer.NewLabeledIdentifier("idle", nil)}
+	busy := labeledPeer{LabeledIdentifier: peer.NewLabeledIdentifier("busy", peer.Labels{peer.LabelCanary: "true"}), pending: 10}
+	h.Add(idle)
+	h.Add(busy)
+
+	ctx := context.Background()
+	assert.Equal(t, idle, h.Choose(ctx, nil))
+
+	// Preferred peers are chosen whatever their score.
+	preferCanary := peer.WithPreferredPeers(ctx, peer.IsCanary)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, busy, h.Choose(preferCanary, nil))
+	}
+	assert.Equal(t, 2, h.Len(), "peers must be pushed back")
+	assert.Equal(t, idle, h.Choose(ctx, nil))
+}
+
 func TestPeerHeapUpdate(t *testing.T) {
 	var h pendingHeap
 	p1 := &peerScore{score: 1}
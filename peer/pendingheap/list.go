@@ -21,18 +21,24 @@
 package pendingheap
 
 import (
+	"time"
+
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/peer/peerlist"
 )
 
 type listConfig struct {
-	capacity int
-	shuffle  bool
+	capacity           int
+	shuffle            bool
+	seed               int64
+	maxPendingRequests int
+	spillOver          bool
 }
 
 var defaultListConfig = listConfig{
 	capacity: 10,
 	shuffle:  true,
+	seed:     time.Now().UnixNano(),
 }
 
 // ListOption customizes the behavior of a pending requests peer heap.
@@ -48,6 +54,36 @@ func Capacity(capacity int) ListOption {
 	}
 }
 
+// MaxPendingRequests limits the number of requests pending on each peer,
+// counting requests from all peer lists that share the peer. When the chosen
+// peer has reached the limit, requests fail fast with a ResourceExhausted
+// error, unless SpillOver is also specified.
+//
+// Defaults to no limit.
+func MaxPendingRequests(n int) ListOption {
+	return func(c *listConfig) {
+		c.maxPendingRequests = n
+	}
+}
+
+// SpillOver specifies that requests go to another available peer, rather than
+// failing, when the chosen peer has reached its MaxPendingRequests.
+func SpillOver() ListOption {
+	return func(c *listConfig) {
+		c.spillOver = true
+	}
+}
+
+// Seed specifies the random seed to use for shuffling peers, making the
+// order in which peers are chosen reproducible.
+//
+// Defaults to approximately the process start time in nanoseconds.
+func Seed(seed int64) ListOption {
+	return func(c *listConfig) {
+		c.seed = seed
+	}
+}
+
 // New creates a new pending heap.
 func New(transport peer.Transport, opts ...ListOption) *List {
 	cfg := defaultListConfig
@@ -57,10 +93,17 @@ func New(transport peer.Transport, opts ...ListOption) *List {
 
 	plOpts := []peerlist.ListOption{
 		peerlist.Capacity(cfg.capacity),
+		peerlist.Seed(cfg.seed),
 	}
 	if !cfg.shuffle {
 		plOpts = append(plOpts, peerlist.NoShuffle())
 	}
+	if cfg.maxPendingRequests > 0 {
+		plOpts = append(plOpts, peerlist.MaxPendingRequests(cfg.maxPendingRequests))
+	}
+	if cfg.spillOver {
+		plOpts = append(plOpts, peerlist.SpillOver())
+	}
 
 	return &List{
 		List: peerlist.New(
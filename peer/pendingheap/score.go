@@ -40,8 +40,13 @@ func (ps *peerScore) NotifyStatusChanged(_ peer.Identifier) {
 	ps.heap.notifyStatusChanged(ps)
 }
 
+// _weightScale keeps the precision of weighted scores, which are integers.
+const _weightScale = 1 << 16
+
+// scorePeer returns the number of pending requests of the peer divided by its
+// weight, so that heavier peers receive proportionally more concurrent
+// requests.
 func scorePeer(p peer.StatusPeer) int64 {
 	status := p.Status()
-	score := int64(status.PendingRequestCount)
-	return score
+	return int64(float64(status.PendingRequestCount) * _weightScale / peer.Weight(p))
 }
@@ -31,6 +31,14 @@ import (
 // Configuration descripes how to build a round-robin peer list.
 type Configuration struct {
 	Capacity *int `config:"capacity"`
+
+	// Limits the requests pending on each peer. See MaxPendingRequests and
+	// SpillOver.
+	MaxPendingRequests int  `config:"maxPendingRequests"`
+	SpillOver          bool `config:"spillOver"`
+
+	// Seed makes the order in which peers are chosen reproducible.
+	Seed *int64 `config:"seed"`
 }
 
 // Spec returns a configuration specification for the round-robin peer list
@@ -55,16 +63,29 @@ func Spec() yarpcconfig.PeerListSpec {
 	return yarpcconfig.PeerListSpec{
 		Name: "round-robin",
 		BuildPeerList: func(cfg Configuration, t peer.Transport, k *yarpcconfig.Kit) (peer.ChooserList, error) {
-			if cfg.Capacity == nil {
-				return New(t), nil
+			var opts []ListOption
+			if cfg.Capacity != nil {
+				if *cfg.Capacity <= 0 {
+					return nil, yarpcerrors.Newf(yarpcerrors.CodeInvalidArgument,
+						fmt.Sprintf("Capacity must be greater than 0. Got: %d.", *cfg.Capacity))
+				}
+				opts = append(opts, Capacity(*cfg.Capacity))
 			}
-
-			if *cfg.Capacity <= 0 {
+			if cfg.MaxPendingRequests < 0 {
 				return nil, yarpcerrors.Newf(yarpcerrors.CodeInvalidArgument,
-					fmt.Sprintf("Capacity must be greater than 0. Got: %d.", *cfg.Capacity))
+					fmt.Sprintf("MaxPendingRequests must not be negative. Got: %d.", cfg.MaxPendingRequests))
+			}
+			if cfg.MaxPendingRequests > 0 {
+				opts = append(opts, MaxPendingRequests(cfg.MaxPendingRequests))
+			}
+			if cfg.SpillOver {
+				opts = append(opts, SpillOver())
+			}
+			if cfg.Seed != nil {
+				opts = append(opts, Seed(*cfg.Seed))
 			}
 
-			return New(t, Capacity(*cfg.Capacity)), nil
+			return New(t, opts...), nil
 		},
 	}
 }
@@ -28,9 +28,11 @@ import (
 )
 
 type listConfig struct {
-	capacity int
-	shuffle  bool
-	seed     int64
+	capacity           int
+	shuffle            bool
+	seed               int64
+	maxPendingRequests int
+	spillOver          bool
 }
 
 var defaultListConfig = listConfig{
@@ -52,6 +54,36 @@ func Capacity(capacity int) ListOption {
 	}
 }
 
+// MaxPendingRequests limits the number of requests pending on each peer,
+// counting requests from all peer lists that share the peer. When the chosen
+// peer has reached the limit, requests fail fast with a ResourceExhausted
+// error, unless SpillOver is also specified.
+//
+// Defaults to no limit.
+func MaxPendingRequests(n int) ListOption {
+	return func(c *listConfig) {
+		c.maxPendingRequests = n
+	}
+}
+
+// SpillOver specifies that requests go to another available peer, rather than
+// failing, when the chosen peer has reached its MaxPendingRequests.
+func SpillOver() ListOption {
+	return func(c *listConfig) {
+		c.spillOver = true
+	}
+}
+
+// Seed specifies the random seed to use for shuffling peers, making the
+// order in which peers are chosen reproducible.
+//
+// Defaults to approximately the process start time in nanoseconds.
+func Seed(seed int64) ListOption {
+	return func(c *listConfig) {
+		c.seed = seed
+	}
+}
+
 // New creates a new round robin peer list.
 func New(transport peer.Transport, opts ...ListOption) *List {
 	cfg := defaultListConfig
@@ -66,6 +98,12 @@ func New(transport peer.Transport, opts ...ListOption) *List {
 	if !cfg.shuffle {
 		plOpts = append(plOpts, peerlist.NoShuffle())
 	}
+	if cfg.maxPendingRequests > 0 {
+		plOpts = append(plOpts, peerlist.MaxPendingRequests(cfg.maxPendingRequests))
+	}
+	if cfg.spillOver {
+		plOpts = append(plOpts, peerlist.SpillOver())
+	}
 
 	return &List{
 		List: peerlist.New(
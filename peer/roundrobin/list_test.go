@@ -28,12 +28,15 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/multierr"
 	"go.uber.org/yarpc/api/peer"
 	. "go.uber.org/yarpc/api/peer/peertest"
+	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/introspection"
 	"go.uber.org/yarpc/peer/hostport"
 	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/yarpc/yarpctest"
 )
 
 var (
@@ -854,6 +857,28 @@ func TestRoundRobinList(t *testing.T) {
 			},
 			expectedRunning: true,
 		},
+		{
+			msg: "distribution across available peers",
+			retainedAvailablePeerIDs:   []string{"1", "2", "3"},
+			retainedUnavailablePeerIDs: []string{"4"},
+			expectedAvailablePeers:     []string{"1", "3"},
+			expectedUnavailablePeers:   []string{"2", "4"},
+			peerListActions: []PeerListAction{
+				StartAction{},
+				UpdateAction{AddedPeerIDs: []string{"1", "2", "3", "4"}},
+				ChooseDistributionAction{
+					Requests:             30,
+					ExpectedDistribution: map[string]float64{"1": 1.0 / 3, "2": 1.0 / 3, "3": 1.0 / 3},
+				},
+				NotifyStatusChangeAction{PeerID: "2", NewConnectionStatus: peer.Unavailable},
+				ChooseDistributionAction{
+					Requests:             30,
+					ExpectedDistribution: map[string]float64{"1": 0.5, "3": 0.5},
+					Tolerance:            0.1,
+				},
+			},
+			expectedRunning: true,
+		},
 		{
 			msg: "stop with available and unavailable",
 			retainedAvailablePeerIDs:   []string{"1", "2"},
@@ -888,7 +913,7 @@ func TestRoundRobinList(t *testing.T) {
 			ExpectPeerRetainsWithError(transport, tt.errRetainedPeerIDs, tt.retainErr)
 			ExpectPeerReleases(transport, tt.errReleasedPeerIDs, tt.releaseErr)
 
-			opts := []ListOption{seed(0)}
+			opts := []ListOption{Seed(0)}
 			if !tt.shuffle {
 				opts = append(opts, noShuffle)
 			}
@@ -1004,8 +1029,26 @@ var noShuffle ListOption = func(c *listConfig) {
 	c.shuffle = false
 }
 
-func seed(seed int64) ListOption {
-	return func(c *listConfig) {
-		c.seed = seed
+func TestChooseSelectedPeer(t *testing.T) {
+	pl := New(yarpctest.NewFakeTransport())
+	require.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: []peer.Identifier{hostport.PeerIdentifier("1"), hostport.PeerIdentifier("2")},
+	}))
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	for i := 0; i < 3; i++ {
+		p, onFinish, err := pl.Choose(peer.WithSelectedPeer(ctx, "2"), &transport.Request{})
+		require.NoError(t, err)
+		assert.Equal(t, "2", p.Identifier())
+		onFinish(nil)
 	}
+
+	_, _, err := pl.Choose(peer.WithSelectedPeer(ctx, "3"), &transport.Request{})
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeUnavailable, yarpcerrors.FromError(err).Code())
+	assert.Contains(t, err.Error(), `roundrobin peer list has no available peer "3"`)
 }
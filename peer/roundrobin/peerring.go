@@ -23,6 +23,7 @@ package roundrobin
 import (
 	"container/ring"
 	"context"
+	"sync"
 
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
@@ -47,7 +48,11 @@ func (s *subscriber) NotifyStatusChanged(pid peer.Identifier) {
 // peerRing provides a safe way to interact (Add/Remove/Get) with a potentially
 // changing list of peer objects
 // peerRing is NOT Thread-safe, make sure to only call peerRing functions with a lock
+//
+// Choose is called under a read lock, so concurrent calls to Choose
+// synchronize on chooseMu to advance nextNode.
 type peerRing struct {
+	chooseMu sync.Mutex
 	nextNode *ring.Ring
 }
 
@@ -96,6 +101,9 @@ func (pr *peerRing) isNextNode(node *ring.Ring) bool {
 // Choose returns the next peer in the ring, or nil if there is no peer in the ring
 // after it has the next peer, it increments the nextPeer marker in the ring
 func (pr *peerRing) Choose(_ context.Context, _ *transport.Request) peer.StatusPeer {
+	pr.chooseMu.Lock()
+	defer pr.chooseMu.Unlock()
+
 	if pr.nextNode == nil {
 		return nil
 	}
@@ -28,6 +28,7 @@ import (
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/introspection"
 	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/yarpc/yarpcerrors"
 )
 
 // Single implements the Chooser interface for a single peer
@@ -56,6 +57,9 @@ func (s *Single) Choose(ctx context.Context, _ *transport.Request) (peer.Peer, f
 	if err := s.once.WaitUntilRunning(ctx); err != nil {
 		return nil, nil, err
 	}
+	if id, ok := peer.SelectedPeerFromContext(ctx); ok && id != s.pid.Identifier() {
+		return nil, nil, yarpcerrors.Newf(yarpcerrors.CodeUnavailable, "single peer chooser has no peer %q", id)
+	}
 	s.p.StartRequest()
 	return s.p, s.boundOnFinish, s.err
 }
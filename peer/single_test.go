@@ -21,9 +21,12 @@
 package peer_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apipeer "go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/internal/introspection"
 	"go.uber.org/yarpc/peer"
 	"go.uber.org/yarpc/peer/hostport"
@@ -37,3 +40,17 @@ func TestSingleIntrospect(t *testing.T) {
 		Peers: []introspection.PeerStatus{{Identifier: "x", State: "uninitialized"}},
 	}, single.Introspect())
 }
+
+func TestSingleChooseSelectedPeer(t *testing.T) {
+	single := peer.NewSingle(hostport.PeerIdentifier("x"), yarpctest.NewFakeTransport())
+	require.NoError(t, single.Start())
+	defer single.Stop()
+
+	p, onFinish, err := single.Choose(apipeer.WithSelectedPeer(context.Background(), "x"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "x", p.Identifier())
+	onFinish(nil)
+
+	_, _, err = single.Choose(apipeer.WithSelectedPeer(context.Background(), "y"), nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package canary
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/internal/peermetrics"
+)
+
+var _ peer.Chooser = (*Chooser)(nil)
+
+// Chooser is a peer chooser that routes a share of requests to canary peers.
+type Chooser struct {
+	chooser peer.Chooser
+	opts    options
+
+	mu         sync.Mutex
+	percentage float64
+	// Accumulated share of requests owed to canaries. A request goes to
+	// canaries each time it reaches one.
+	credit float64
+}
+
+// New builds a Chooser that routes requests to canary or stable peers of the
+// given chooser.
+func New(chooser peer.Chooser, opts ...Option) *Chooser {
+	options := applyOptions(opts...)
+	return &Chooser{
+		chooser:    chooser,
+		opts:       options,
+		percentage: clampPercentage(options.percentage),
+	}
+}
+
+// SetPercentage changes the percentage of requests, between 0 and 100, sent
+// to canary peers.
+func (c *Chooser) SetPercentage(percentage float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.percentage = clampPercentage(percentage)
+	c.credit = 0
+}
+
+// Percentage returns the percentage of requests sent to canary peers.
+func (c *Chooser) Percentage() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.percentage
+}
+
+// Start starts the wrapped chooser.
+func (c *Chooser) Start() error {
+	return c.chooser.Start()
+}
+
+// Stop stops the wrapped chooser.
+func (c *Chooser) Stop() error {
+	return c.chooser.Stop()
+}
+
+// IsRunning returns whether the wrapped chooser is running.
+func (c *Chooser) IsRunning() bool {
+	return c.chooser.IsRunning()
+}
+
+// Introspect introspects the wrapped chooser.
+func (c *Chooser) Introspect() introspection.ChooserStatus {
+	if ic, ok := c.chooser.(introspection.IntrospectableChooser); ok {
+		return ic.Introspect()
+	}
+	return introspection.ChooserStatus{}
+}
+
+// Instrument reports metrics for the wrapped chooser if it supports metrics.
+func (c *Chooser) Instrument(meter *metrics.Scope) {
+	if i, ok := c.chooser.(peermetrics.Instrumented); ok {
+		i.Instrument(meter)
+	}
+}
+
+// Choose asks the wrapped chooser for a canary peer if the request should go
+// to canaries, or for a stable peer otherwise.
+func (c *Chooser) Choose(ctx context.Context, req *transport.Request) (peer.Peer, func(error), error) {
+	if _, ok := peer.SelectedPeerFromContext(ctx); ok {
+		return c.chooser.Choose(ctx, req)
+	}
+	if c.toCanary(req) {
+		return c.chooser.Choose(peer.WithPreferredPeers(ctx, peer.IsCanary), req)
+	}
+	return c.chooser.Choose(peer.WithPreferredPeers(ctx, isStable), req)
+}
+
+// toCanary reports whether the request should go to canary peers.
+func (c *Chooser) toCanary(req *transport.Request) bool {
+	if c.opts.header != "" && req != nil {
+		if v, ok := req.Headers.Get(c.opts.header); ok && v != "" && (c.opts.headerValue == "" || v == c.opts.headerValue) {
+			return true
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.credit += c.percentage / 100
+	if c.credit < 1 {
+		return false
+	}
+	c.credit--
+	return true
+}
+
+func isStable(id peer.Identifier) bool {
+	return !peer.IsCanary(id)
+}
+
+func clampPercentage(percentage float64) float64 {
+	switch {
+	case !(percentage > 0):
+		return 0
+	case percentage > 100:
+		return 100
+	default:
+		return percentage
+	}
+}
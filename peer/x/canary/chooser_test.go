@@ -0,0 +1,207 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package canary
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/peer/pendingheap"
+	"go.uber.org/yarpc/peer/roundrobin"
+	"go.uber.org/yarpc/peer/x/peerheap"
+)
+
+const _header = "x-canary"
+
+var (
+	_stable1 = peer.NewLabeledIdentifier("stable1:80", nil)
+	_stable2 = peer.NewLabeledIdentifier("stable2:80", peer.Labels{peer.LabelCanary: "false"})
+	_canary  = peer.NewLabeledIdentifier("canary:80", peer.Labels{peer.LabelCanary: "true"})
+)
+
+var _lists = []struct {
+	name string
+	new  func(peer.Transport) peer.ChooserList
+}{
+	{"roundrobin", func(t peer.Transport) peer.ChooserList { return roundrobin.New(t) }},
+	{"pendingheap", func(t peer.Transport) peer.ChooserList { return pendingheap.New(t) }},
+	{"peerheap", func(t peer.Transport) peer.ChooserList { return peerheap.New(t) }},
+}
+
+// testTransport retains available peers that track their pending requests.
+type testTransport struct {
+	mu    sync.Mutex
+	peers map[string]*hostport.Peer
+}
+
+func newTestTransport() *testTransport {
+	return &testTransport{peers: make(map[string]*hostport.Peer)}
+}
+
+func (t *testTransport) RetainPeer(id peer.Identifier, sub peer.Subscriber) (peer.Peer, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p, ok := t.peers[id.Identifier()]
+	if !ok {
+		p = hostport.NewPeer(hostport.PeerIdentifier(id.Identifier()), t)
+		p.SetStatus(peer.Available)
+		t.peers[id.Identifier()] = p
+	}
+	p.Subscribe(sub)
+	return p, nil
+}
+
+func (t *testTransport) ReleasePeer(id peer.Identifier, sub peer.Subscriber) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if p, ok := t.peers[id.Identifier()]; ok {
+		return p.Unsubscribe(sub)
+	}
+	return nil
+}
+
+// forEachList runs the test with each peer list of this repository, started
+// with the given peers.
+func forEachList(t *testing.T, ids []peer.Identifier, f func(*testing.T, peer.ChooserList)) {
+	for _, l := range _lists {
+		t.Run(l.name, func(t *testing.T) {
+			pl := l.new(newTestTransport())
+			require.NoError(t, pl.Start())
+			defer pl.Stop()
+			require.NoError(t, pl.Update(peer.ListUpdates{Additions: ids}))
+			f(t, pl)
+		})
+	}
+}
+
+func choose(t *testing.T, c *Chooser, header string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	req := &transport.Request{Headers: transport.NewHeaders()}
+	if header != "" {
+		req.Headers = req.Headers.With(_header, header)
+	}
+	p, onFinish, err := c.Choose(ctx, req)
+	require.NoError(t, err)
+	onFinish(nil)
+	return p.Identifier()
+}
+
+func TestPercentage(t *testing.T) {
+	forEachList(t, []peer.Identifier{_stable1, _stable2, _canary}, func(t *testing.T, pl peer.ChooserList) {
+		c := New(pl, Percentage(25))
+
+		counts := make(map[string]int)
+		for i := 0; i < 100; i++ {
+			counts[choose(t, c, "")]++
+		}
+		assert.Equal(t, 25, counts[_canary.Identifier()])
+		assert.Equal(t, 75, counts[_stable1.Identifier()]+counts[_stable2.Identifier()])
+
+		c.SetPercentage(0)
+		assert.Equal(t, 0.0, c.Percentage())
+		for i := 0; i < 10; i++ {
+			assert.NotEqual(t, _canary.Identifier(), choose(t, c, ""))
+		}
+
+		c.SetPercentage(200)
+		assert.Equal(t, 100.0, c.Percentage())
+		for i := 0; i < 10; i++ {
+			assert.Equal(t, _canary.Identifier(), choose(t, c, ""))
+		}
+	})
+}
+
+func TestHeader(t *testing.T) {
+	forEachList(t, []peer.Identifier{_stable1, _canary}, func(t *testing.T, pl peer.ChooserList) {
+		c := New(pl, Header(_header, "true"))
+		for i := 0; i < 4; i++ {
+			assert.Equal(t, _canary.Identifier(), choose(t, c, "true"))
+			assert.Equal(t, _stable1.Identifier(), choose(t, c, "false"))
+			assert.Equal(t, _stable1.Identifier(), choose(t, c, ""))
+		}
+
+		c = New(pl, Header(_header, ""))
+		assert.Equal(t, _canary.Identifier(), choose(t, c, "anything"))
+	})
+}
+
+func TestUnderLoad(t *testing.T) {
+	forEachList(t, []peer.Identifier{_stable1, _canary}, func(t *testing.T, pl peer.ChooserList) {
+		c := New(pl, Header(_header, "true"))
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		req := &transport.Request{Headers: transport.NewHeaders().With(_header, "true")}
+
+		// Requests pending on the canary must not send canary traffic to the
+		// less loaded stable peer.
+		for i := 0; i < 5; i++ {
+			p, onFinish, err := c.Choose(ctx, req)
+			require.NoError(t, err)
+			defer onFinish(nil)
+			assert.Equal(t, _canary.Identifier(), p.Identifier())
+		}
+		assert.Equal(t, _stable1.Identifier(), choose(t, c, ""))
+	})
+}
+
+func TestFallback(t *testing.T) {
+	forEachList(t, []peer.Identifier{_stable1, _canary}, func(t *testing.T, pl peer.ChooserList) {
+		c := New(pl, Percentage(100))
+		assert.Equal(t, _canary.Identifier(), choose(t, c, ""))
+
+		// Canary requests fall back to stable peers without canaries.
+		require.NoError(t, pl.Update(peer.ListUpdates{Removals: []peer.Identifier{_canary}}))
+		assert.Equal(t, _stable1.Identifier(), choose(t, c, ""))
+
+		// Stable requests fall back to canaries without stable peers.
+		require.NoError(t, pl.Update(peer.ListUpdates{
+			Additions: []peer.Identifier{_canary},
+			Removals:  []peer.Identifier{_stable1},
+		}))
+		c.SetPercentage(0)
+		assert.Equal(t, _canary.Identifier(), choose(t, c, ""))
+	})
+}
+
+func TestSelectedPeer(t *testing.T) {
+	forEachList(t, []peer.Identifier{_stable1, _canary}, func(t *testing.T, pl peer.ChooserList) {
+		c := New(pl, Percentage(100))
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		p, onFinish, err := c.Choose(peer.WithSelectedPeer(ctx, _stable1.Identifier()), &transport.Request{})
+		require.NoError(t, err)
+		onFinish(nil)
+		assert.Equal(t, _stable1.Identifier(), p.Identifier())
+	})
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package canary provides a peer chooser that routes a share of requests to
+// peers labeled as running a canary release, and the other requests to the
+// other peers.
+//
+// Peers are canaries if they were added to the peer list as
+// peer.LabeledIdentifiers with the peer.LabelCanary label set to "true".
+// Requests go to canaries if they carry the canary header, or as part of the
+// canary percentage of the other requests.
+//
+// 	chooser := canary.New(roundrobin.New(transport),
+// 		canary.Percentage(5),
+// 		canary.Header("x-canary", "true"),
+// 	)
+//
+// Routing relies on peer.WithPreferredPeers, which the roundrobin,
+// pendingheap, and peerheap peer lists honor ahead of their load balancing.
+// Other choosers may ignore it. Since preferences are only hints, requests
+// fall back to stable peers when no canary is available, and to canaries when
+// no stable peer is available. Requests that already select a peer are passed
+// to the wrapped chooser unchanged.
+//
+// The canary percentage may be changed at runtime with SetPercentage to ramp
+// canaries up or down.
+package canary
@@ -0,0 +1,63 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package canary
+
+// Option customizes the behavior of a canary Chooser.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	percentage  float64
+	header      string
+	headerValue string
+}
+
+// Percentage specifies the percentage of requests, between 0 and 100, sent
+// to canary peers. Defaults to 0, in which case only requests with the
+// canary header go to canaries.
+func Percentage(percentage float64) Option {
+	return optionFunc(func(opts *options) {
+		opts.percentage = percentage
+	})
+}
+
+// Header specifies a request header that sends requests to canary peers
+// regardless of the canary percentage, when it has the given value. An empty
+// value matches any non-empty header value.
+func Header(key, value string) Option {
+	return optionFunc(func(opts *options) {
+		opts.header = key
+		opts.headerValue = value
+	})
+}
+
+func applyOptions(opts ...Option) options {
+	var options options
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return options
+}
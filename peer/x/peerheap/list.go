@@ -27,8 +27,11 @@ import (
 	"time"
 
 	"go.uber.org/multierr"
+	"go.uber.org/net/metrics"
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/peerhint"
+	"go.uber.org/yarpc/internal/peermetrics"
 	intyarpcerrors "go.uber.org/yarpc/internal/yarpcerrors"
 	"go.uber.org/yarpc/pkg/lifecycle"
 	"go.uber.org/yarpc/yarpcerrors"
@@ -72,6 +75,10 @@ type List struct {
 
 	byScore      peerHeap
 	byIdentifier map[string]*peerScore
+	available    int
+
+	// metrics is nil until the list is instrumented.
+	metrics *peermetrics.Metrics
 
 	peerAvailableEvent chan struct{}
 
@@ -93,6 +100,26 @@ func (pl *List) Stop() error {
 	return pl.once.Stop(pl.clearPeers) // TODO clear peers
 }
 
+var _ peermetrics.Instrumented = (*List)(nil)
+
+// Instrument reports metrics about the peers of the heap, peer churn, and
+// peer selection to the given scope. Dispatchers instrument the peer lists
+// of their outbounds automatically.
+//
+// Instrument must be called before the list is started.
+func (pl *List) Instrument(meter *metrics.Scope) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+
+	pl.metrics = peermetrics.New(meter, "peer-heap")
+	pl.updatePeerMetrics()
+}
+
+// Must be called with the mutex locked.
+func (pl *List) updatePeerMetrics() {
+	pl.metrics.SetPeers(pl.available, len(pl.byIdentifier)-pl.available)
+}
+
 // New returns a new peer heap-chooser-list for the given transport.
 func New(transport peer.Transport, opts ...HeapOption) *List {
 	cfg := defaultHeapConfig
@@ -140,18 +167,24 @@ func (pl *List) retainPeer(pid peer.Identifier) error {
 		return peer.ErrPeerAddAlreadyInList(pid.Identifier())
 	}
 
-	ps := &peerScore{id: pid, list: pl}
+	ps := &peerScore{id: pid, list: pl, metrics: pl.metrics}
 	p, err := pl.transport.RetainPeer(pid, ps)
 	if err != nil {
 		return err
 	}
 
 	ps.peer = p
+	ps.status = p.Status()
 	ps.score = scorePeer(p)
 	ps.boundFinish = ps.finish
 	pl.byIdentifier[pid.Identifier()] = ps
+	if ps.status.ConnectionStatus == peer.Available {
+		pl.available++
+	}
 	pl.byScore.pushPeer(ps)
 	pl.internalNotifyStatusChanged(ps)
+	pl.metrics.Added()
+	pl.updatePeerMetrics()
 	return nil
 }
 
@@ -168,8 +201,13 @@ func (pl *List) releasePeer(pid peer.Identifier) error {
 
 	err := pl.transport.ReleasePeer(pid, ps)
 	delete(pl.byIdentifier, pid.Identifier())
+	if ps.status.ConnectionStatus == peer.Available {
+		pl.available--
+	}
 	pl.byScore.delete(ps.idx)
 	ps.list = nil
+	pl.metrics.Removed()
+	pl.updatePeerMetrics()
 	return err
 }
 
@@ -198,14 +236,24 @@ func (pl *List) clearPeers() error {
 // deadline.
 // The peer heap does not use the given *transport.Request and can safely
 // receive nil.
-func (pl *List) Choose(ctx context.Context, _ *transport.Request) (peer.Peer, func(error), error) {
+func (pl *List) Choose(ctx context.Context, _ *transport.Request) (_ peer.Peer, _ func(error), err error) {
+	if pl.metrics != nil {
+		start := time.Now()
+		defer func() { pl.metrics.Chose(start, err) }()
+	}
+
 	if err := pl.once.WaitUntilRunning(ctx); err != nil {
 		return nil, nil, intyarpcerrors.AnnotateWithInfo(yarpcerrors.FromError(err), "%s peer list is not running", "peer heap")
 	}
 
+	if id, ok := peer.SelectedPeerFromContext(ctx); ok {
+		return pl.chooseSelected(id)
+	}
+
 	for {
-		if ps, ok := pl.get(); ok {
+		if ps, ok := pl.get(peerhint.FromContext(ctx)); ok {
 			pl.notifyPeerAvailable()
+			pl.metrics.RequestStarted()
 			ps.peer.StartRequest()
 			return ps.peer, ps.boundFinish, nil
 		}
@@ -216,7 +264,25 @@ func (pl *List) Choose(ctx context.Context, _ *transport.Request) (peer.Peer, fu
 	}
 }
 
-func (pl *List) get() (*peerScore, bool) {
+// chooseSelected returns the peer with the given identifier if it is
+// available.
+func (pl *List) chooseSelected(id string) (peer.Peer, func(error), error) {
+	pl.mu.Lock()
+	ps, ok := pl.byIdentifier[id]
+	available := ok && ps.status.ConnectionStatus == peer.Available
+	pl.mu.Unlock()
+
+	if !available {
+		return nil, nil, yarpcerrors.Newf(yarpcerrors.CodeUnavailable, "peer heap has no available peer %q", id)
+	}
+	pl.metrics.RequestStarted()
+	ps.peer.StartRequest()
+	return ps.peer, ps.boundFinish, nil
+}
+
+// get returns the peer with the best score, preferring available peers that
+// meet the peer hints of the request.
+func (pl *List) get(hints peerhint.Hints) (*peerScore, bool) {
 	pl.mu.Lock()
 	defer pl.mu.Unlock()
 
@@ -229,7 +295,46 @@ func (pl *List) get() (*peerScore, bool) {
 	// This gives us round-robin behavior.
 	pl.byScore.pushPeer(ps)
 
-	return ps, ps.status.ConnectionStatus == peer.Available
+	available := ps.status.ConnectionStatus == peer.Available
+	if available && hints.Rank(ps.id) > 0 {
+		if other, ok := pl.getBest(hints); ok {
+			return other, true
+		}
+	}
+	return ps, available
+}
+
+// getBest returns the available peer with the best score among the peers
+// that best meet the peer hints of the request, if any.
+// Must be run in a mutex.Lock()
+func (pl *List) getBest(hints peerhint.Hints) (*peerScore, bool) {
+	var (
+		popped   []*peerScore
+		best     *peerScore
+		bestRank int
+	)
+	defer func() {
+		for _, ps := range popped {
+			pl.byScore.pushPeer(ps)
+		}
+	}()
+	for {
+		ps, ok := pl.byScore.popPeer()
+		if !ok || ps.status.ConnectionStatus != peer.Available {
+			// Available peers have better scores than unavailable ones.
+			if ok {
+				popped = append(popped, ps)
+			}
+			return best, best != nil
+		}
+		popped = append(popped, ps)
+		if r := hints.Rank(ps.id); best == nil || r < bestRank {
+			best, bestRank = ps, r
+		}
+		if bestRank == 0 {
+			return best, true
+		}
+	}
 }
 
 // waitForPeerAvailableEvent waits until a peer is added to the peer list or the
@@ -290,11 +395,23 @@ func (pl *List) internalNotifyStatusChanged(ps *peerScore) {
 	}
 }
 
+// rescorePeer must be called with the mutex locked.
 func (pl *List) rescorePeer(ps *peerScore) {
 	p := ps.peer
+	wasAvailable := ps.status.ConnectionStatus == peer.Available
 	ps.status = p.Status()
 	ps.score = scorePeer(p)
 	pl.byScore.update(ps.idx)
+
+	if isAvailable := ps.status.ConnectionStatus == peer.Available; isAvailable != wasAvailable {
+		if isAvailable {
+			pl.available++
+		} else {
+			pl.available--
+		}
+		pl.metrics.StatusChanged()
+		pl.updatePeerMetrics()
+	}
 }
 
 func scorePeer(p peer.Peer) int64 {
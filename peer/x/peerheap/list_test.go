@@ -28,11 +28,14 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/multierr"
 	"go.uber.org/yarpc/api/peer"
 	. "go.uber.org/yarpc/api/peer/peertest"
 	intyarpcerrors "go.uber.org/yarpc/internal/yarpcerrors"
+	"go.uber.org/yarpc/peer/hostport"
 	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/yarpc/yarpctest"
 )
 
 func newNotRunningError(err error) error {
@@ -763,3 +766,88 @@ func TestPeerHeapList(t *testing.T) {
 		})
 	}
 }
+
+func TestPeerHeapAvoidPeers(t *testing.T) {
+	pl := New(yarpctest.NewFakeTransport())
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+	require.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: []peer.Identifier{hostport.PeerIdentifier("1"), hostport.PeerIdentifier("2")},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	for i := 0; i < 4; i++ {
+		p, onFinish, err := pl.Choose(peer.WithAvoidPeers(ctx, "1"), nil)
+		require.NoError(t, err)
+		onFinish(nil)
+		assert.Equal(t, "2", p.Identifier())
+	}
+
+	// Avoided peers are still chosen if no other peer is available.
+	p, onFinish, err := pl.Choose(peer.WithAvoidPeers(ctx, "1", "2"), nil)
+	require.NoError(t, err)
+	onFinish(nil)
+	assert.Contains(t, []string{"1", "2"}, p.Identifier())
+}
+
+func TestPeerHeapChooseSelectedPeer(t *testing.T) {
+	pl := New(yarpctest.NewFakeTransport())
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+	require.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: []peer.Identifier{hostport.PeerIdentifier("1"), hostport.PeerIdentifier("2")},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	for i := 0; i < 3; i++ {
+		p, onFinish, err := pl.Choose(peer.WithSelectedPeer(ctx, "2"), nil)
+		require.NoError(t, err)
+		assert.Equal(t, "2", p.Identifier())
+		onFinish(nil)
+	}
+
+	_, _, err := pl.Choose(peer.WithSelectedPeer(ctx, "3"), nil)
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeUnavailable, yarpcerrors.FromError(err).Code())
+
+	// Peers that are no longer available cannot be selected.
+	pl.mu.Lock()
+	pl.byIdentifier["1"].status.ConnectionStatus = peer.Unavailable
+	pl.mu.Unlock()
+	_, _, err = pl.Choose(peer.WithSelectedPeer(ctx, "1"), nil)
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeUnavailable, yarpcerrors.FromError(err).Code())
+}
+
+func TestPeerHeapPreferredPeers(t *testing.T) {
+	pl := New(yarpctest.NewFakeTransport())
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+	require.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: []peer.Identifier{
+			peer.NewLabeledIdentifier("1", peer.Labels{peer.LabelZone: "east"}),
+			peer.NewLabeledIdentifier("2", peer.Labels{peer.LabelZone: "west"}),
+		},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	preferWest := peer.WithPreferredPeers(ctx, peer.MatchLabels(peer.Labels{peer.LabelZone: "west"}))
+
+	for i := 0; i < 4; i++ {
+		p, onFinish, err := pl.Choose(preferWest, nil)
+		require.NoError(t, err)
+		onFinish(nil)
+		assert.Equal(t, "2", p.Identifier())
+	}
+
+	// Peers that are not avoided come before preferred ones.
+	p, onFinish, err := pl.Choose(peer.WithAvoidPeers(preferWest, "2"), nil)
+	require.NoError(t, err)
+	onFinish(nil)
+	assert.Equal(t, "1", p.Identifier())
+}
@@ -20,7 +20,10 @@
 
 package peerheap
 
-import "go.uber.org/yarpc/api/peer"
+import (
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/internal/peermetrics"
+)
 
 // peerScore is a book-keeping object for each retained peer and
 // gets
@@ -29,6 +32,7 @@ type peerScore struct {
 	peer        peer.Peer
 	id          peer.Identifier
 	list        *List
+	metrics     *peermetrics.Metrics
 	boundFinish func(error)
 
 	status peer.Status
@@ -43,14 +47,15 @@ func (ps *peerScore) NotifyStatusChanged(_ peer.Identifier) {
 		// obtained a nil subscriber (happens in tests).
 		return
 	}
-	status := ps.peer.Status()
-	if ps.status == status {
+	if ps.status == ps.peer.Status() {
 		return
 	}
-	ps.status = status
+	// The list records the new status while rescoring the peer so that it
+	// can observe changes in availability.
 	ps.list.peerScoreChanged(ps)
 }
 
 func (ps *peerScore) finish(error) {
+	ps.metrics.RequestFinished()
 	ps.peer.EndRequest()
 }
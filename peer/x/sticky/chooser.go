@@ -0,0 +1,202 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package sticky provides a peer chooser that sends all requests of a
+// session to the same peer, for servers that keep per-session state in
+// memory.
+//
+// Sessions are identified by the value of a request header. The first
+// request of a session is sent to the peer picked by the wrapped chooser,
+// and later requests with the same header value are sent to the same peer
+// until the session expires. If that peer is no longer available, the
+// session moves to a peer picked by the wrapped chooser.
+//
+// 	chooser := sticky.New(roundrobin.New(transport), "x-session-id")
+//
+// Pinning relies on peer.WithSelectedPeer, which the peer lists of this
+// repository honor. Requests without the session header, and requests that
+// already select a peer, are passed to the wrapped chooser unchanged.
+package sticky
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/internal/peermetrics"
+)
+
+var _ peer.Chooser = (*Chooser)(nil)
+
+// Chooser is a peer chooser that pins sessions to peers.
+type Chooser struct {
+	chooser peer.Chooser
+	header  string
+	opts    options
+
+	mu       sync.Mutex
+	sessions map[string]*list.Element
+	lru      *list.List // of *session, most recently used first
+}
+
+type session struct {
+	key     string
+	peerID  string
+	expires time.Time
+}
+
+// New builds a Chooser that pins requests carrying the same value for the
+// given header to the same peer chosen by the given chooser.
+func New(chooser peer.Chooser, header string, opts ...Option) *Chooser {
+	return &Chooser{
+		chooser:  chooser,
+		header:   header,
+		opts:     applyOptions(opts...),
+		sessions: make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// Start starts the wrapped chooser.
+func (c *Chooser) Start() error {
+	return c.chooser.Start()
+}
+
+// Stop stops the wrapped chooser.
+func (c *Chooser) Stop() error {
+	return c.chooser.Stop()
+}
+
+// IsRunning returns whether the wrapped chooser is running.
+func (c *Chooser) IsRunning() bool {
+	return c.chooser.IsRunning()
+}
+
+// Introspect introspects the wrapped chooser.
+func (c *Chooser) Introspect() introspection.ChooserStatus {
+	if ic, ok := c.chooser.(introspection.IntrospectableChooser); ok {
+		return ic.Introspect()
+	}
+	return introspection.ChooserStatus{}
+}
+
+// Instrument reports metrics for the wrapped chooser if it supports metrics.
+func (c *Chooser) Instrument(meter *metrics.Scope) {
+	if i, ok := c.chooser.(peermetrics.Instrumented); ok {
+		i.Instrument(meter)
+	}
+}
+
+// Choose returns the peer the session of the request is pinned to, or pins
+// the session to the peer picked by the wrapped chooser.
+func (c *Chooser) Choose(ctx context.Context, req *transport.Request) (peer.Peer, func(error), error) {
+	if _, ok := peer.SelectedPeerFromContext(ctx); ok || req == nil {
+		return c.chooser.Choose(ctx, req)
+	}
+	key, ok := req.Headers.Get(c.header)
+	if !ok || key == "" {
+		return c.chooser.Choose(ctx, req)
+	}
+
+	if id, ok := c.lookup(key); ok && !avoided(ctx, id) {
+		p, onFinish, err := c.chooser.Choose(peer.WithSelectedPeer(ctx, id), req)
+		if err == nil {
+			c.pin(key, p.Identifier())
+			return p, onFinish, nil
+		}
+		// The peer is gone or unavailable; move the session to another peer.
+		c.forget(key, id)
+	}
+
+	p, onFinish, err := c.chooser.Choose(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.pin(key, p.Identifier())
+	return p, onFinish, nil
+}
+
+// avoided reports whether the request asks choosers to avoid the peer, in
+// which case the session moves to another peer.
+func avoided(ctx context.Context, id string) bool {
+	for _, avoid := range peer.AvoidPeersFromContext(ctx) {
+		if avoid == id {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the peer the session is pinned to, if it has not expired.
+func (c *Chooser) lookup(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.sessions[key]
+	if !ok {
+		return "", false
+	}
+	s := e.Value.(*session)
+	if c.opts.clock.Now().After(s.expires) {
+		c.remove(e)
+		return "", false
+	}
+	return s.peerID, true
+}
+
+// pin pins the session to the peer and extends its expiration.
+func (c *Chooser) pin(key, peerID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := c.opts.clock.Now().Add(c.opts.ttl)
+	if e, ok := c.sessions[key]; ok {
+		s := e.Value.(*session)
+		s.peerID, s.expires = peerID, expires
+		c.lru.MoveToFront(e)
+		return
+	}
+
+	c.sessions[key] = c.lru.PushFront(&session{key: key, peerID: peerID, expires: expires})
+	for c.opts.maxSessions > 0 && c.lru.Len() > c.opts.maxSessions {
+		c.remove(c.lru.Back())
+	}
+}
+
+// forget unpins the session if it is still pinned to the given peer.
+func (c *Chooser) forget(key, peerID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.sessions[key]; ok && e.Value.(*session).peerID == peerID {
+		c.remove(e)
+	}
+}
+
+// remove must be called with the mutex locked.
+func (c *Chooser) remove(e *list.Element) {
+	c.lru.Remove(e)
+	delete(c.sessions, e.Value.(*session).key)
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sticky
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/peer/roundrobin"
+	"go.uber.org/yarpc/yarpctest"
+)
+
+const _header = "x-session"
+
+func newList(t *testing.T, ids ...string) *roundrobin.List {
+	pl := roundrobin.New(yarpctest.NewFakeTransport())
+	var pids []peer.Identifier
+	for _, id := range ids {
+		pids = append(pids, hostport.PeerIdentifier(id))
+	}
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: pids}))
+	require.NoError(t, pl.Start())
+	return pl
+}
+
+func choose(t *testing.T, c *Chooser, session string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	req := &transport.Request{Headers: transport.NewHeaders()}
+	if session != "" {
+		req.Headers = req.Headers.With(_header, session)
+	}
+	p, onFinish, err := c.Choose(ctx, req)
+	require.NoError(t, err)
+	onFinish(nil)
+	return p.Identifier()
+}
+
+func TestSessionsArePinned(t *testing.T) {
+	pl := newList(t, "1", "2", "3")
+	defer pl.Stop()
+	c := New(pl, _header)
+
+	first := choose(t, c, "a")
+	other := choose(t, c, "b")
+	assert.NotEqual(t, first, other, "new sessions must be spread by the wrapped chooser")
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, first, choose(t, c, "a"))
+		assert.Equal(t, other, choose(t, c, "b"))
+	}
+}
+
+func TestRequestsWithoutSession(t *testing.T) {
+	pl := newList(t, "1", "2")
+	defer pl.Stop()
+	c := New(pl, _header)
+
+	seen := make(map[string]struct{})
+	for i := 0; i < 4; i++ {
+		seen[choose(t, c, "")] = struct{}{}
+	}
+	assert.Len(t, seen, 2, "requests without a session must not be pinned")
+	assert.Equal(t, 0, c.lru.Len())
+}
+
+func TestSessionMovesWhenPeerIsLost(t *testing.T) {
+	pl := newList(t, "1", "2")
+	defer pl.Stop()
+	c := New(pl, _header)
+
+	first := choose(t, c, "a")
+	require.NoError(t, pl.Update(peer.ListUpdates{
+		Removals: []peer.Identifier{hostport.PeerIdentifier(first)},
+	}))
+
+	moved := choose(t, c, "a")
+	assert.NotEqual(t, first, moved)
+	assert.Equal(t, moved, choose(t, c, "a"), "session must be pinned to its new peer")
+}
+
+func TestSessionsExpire(t *testing.T) {
+	pl := newList(t, "1", "2")
+	defer pl.Stop()
+	fake := clock.NewFake()
+	c := New(pl, _header, TTL(time.Minute), withClock(fake))
+
+	id := choose(t, c, "a")
+	fake.Add(59 * time.Second)
+	got, ok := c.lookup("a")
+	require.True(t, ok)
+	assert.Equal(t, id, got)
+
+	// Using the session extends it.
+	assert.Equal(t, id, choose(t, c, "a"))
+	fake.Add(59 * time.Second)
+	_, ok = c.lookup("a")
+	assert.True(t, ok)
+
+	fake.Add(2 * time.Second)
+	_, ok = c.lookup("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.lru.Len())
+}
+
+func TestMaxSessions(t *testing.T) {
+	pl := newList(t, "1", "2")
+	defer pl.Stop()
+	c := New(pl, _header, MaxSessions(2))
+
+	choose(t, c, "a")
+	choose(t, c, "b")
+	choose(t, c, "a")
+	choose(t, c, "c") // evicts b, the least recently used session
+
+	assert.Equal(t, 2, c.lru.Len())
+	_, ok := c.lookup("b")
+	assert.False(t, ok)
+	_, ok = c.lookup("a")
+	assert.True(t, ok)
+	_, ok = c.lookup("c")
+	assert.True(t, ok)
+}
+
+func TestSelectedPeerTakesPrecedence(t *testing.T) {
+	pl := newList(t, "1", "2")
+	defer pl.Stop()
+	c := New(pl, _header)
+
+	first := choose(t, c, "a")
+	other := "1"
+	if first == "1" {
+		other = "2"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	req := &transport.Request{Headers: transport.NewHeaders().With(_header, "a")}
+	p, onFinish, err := c.Choose(peer.WithSelectedPeer(ctx, other), req)
+	require.NoError(t, err)
+	onFinish(nil)
+	assert.Equal(t, other, p.Identifier())
+	assert.Equal(t, first, choose(t, c, "a"), "session must stay pinned")
+}
+
+func TestAvoidedPeerMovesSession(t *testing.T) {
+	pl := newList(t, "1", "2")
+	defer pl.Stop()
+	c := New(pl, _header)
+
+	first := choose(t, c, "a")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	req := &transport.Request{Headers: transport.NewHeaders().With(_header, "a")}
+	p, onFinish, err := c.Choose(peer.WithAvoidPeers(ctx, first), req)
+	require.NoError(t, err)
+	onFinish(nil)
+	assert.NotEqual(t, first, p.Identifier())
+	assert.Equal(t, p.Identifier(), choose(t, c, "a"), "session must move to the new peer")
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sticky
+
+import (
+	"time"
+
+	"go.uber.org/yarpc/internal/clock"
+)
+
+const (
+	_defaultTTL         = 10 * time.Minute
+	_defaultMaxSessions = 10000
+)
+
+// Option customizes the behavior of a sticky Chooser.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	ttl         time.Duration
+	maxSessions int
+	clock       clock.Clock
+}
+
+// TTL specifies how long a session stays pinned to its peer after its last
+// request. Defaults to ten minutes.
+func TTL(ttl time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.ttl = ttl
+	})
+}
+
+// MaxSessions specifies the maximum number of sessions the chooser
+// remembers. When the limit is reached, the least recently used session is
+// forgotten. Defaults to 10000.
+func MaxSessions(n int) Option {
+	return optionFunc(func(opts *options) {
+		opts.maxSessions = n
+	})
+}
+
+func withClock(clock clock.Clock) Option {
+	return optionFunc(func(opts *options) {
+		opts.clock = clock
+	})
+}
+
+func applyOptions(opts ...Option) options {
+	options := options{
+		ttl:         _defaultTTL,
+		maxSessions: _defaultMaxSessions,
+		clock:       clock.NewReal(),
+	}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return options
+}
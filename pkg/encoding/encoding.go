@@ -37,3 +37,13 @@ func FromOptions(opts []yarpc.CallOption) []encoding.CallOption {
 	}
 	return newOpts
 }
+
+// FromStreamOptions converts a collection of yarpc.StreamOptions to
+// encoding.StreamOptions.
+func FromStreamOptions(opts []yarpc.StreamOption) []encoding.StreamOption {
+	newOpts := make([]encoding.StreamOption, len(opts))
+	for i, o := range opts {
+		newOpts[i] = encoding.StreamOption(o)
+	}
+	return newOpts
+}
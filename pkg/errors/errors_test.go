@@ -103,3 +103,8 @@ func assertError(t *testing.T, err error, expectedCode yarpcerrors.Code, expecte
 		assert.Contains(t, err.Error(), expectedWord)
 	}
 }
+
+func TestRequestBodyDecodeErrorKeepsResourceExhausted(t *testing.T) {
+	err := yarpcerrors.ResourceExhaustedErrorf("too large")
+	assert.Equal(t, err, RequestBodyDecodeError(&transport.Request{}, err))
+}
@@ -31,7 +31,13 @@ import (
 // RequestBodyDecodeError builds a YARPC error with code
 // yarpcerrors.CodeInvalidArgument that represents a failure to decode
 // the request body.
+//
+// Errors with code yarpcerrors.CodeResourceExhausted, returned when the
+// request body exceeds a size limit, are returned as-is.
 func RequestBodyDecodeError(req *transport.Request, err error) error {
+	if yarpcerrors.IsStatus(err) && yarpcerrors.FromError(err).Code() == yarpcerrors.CodeResourceExhausted {
+		return err
+	}
 	return newServerEncodingError(req, nil, false /*isResponse*/, false /*isHeader*/, err)
 }
 
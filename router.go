@@ -76,6 +76,11 @@ func NewMapRouter(defaultService string) MapRouter {
 // same name and service name can exist if they handle different encodings.
 // If a procedure does not specify an encoding, it can only support one handler.
 // The router will select that handler regardless of the encoding.
+//
+// For example, the same procedure may be registered with a JSON handler and a
+// Protobuf handler, and the router selects the handler that matches the
+// encoding of each request. Registering two handlers for the same encoding of
+// a procedure panics.
 func (m MapRouter) Register(rs []transport.Procedure) {
 	for _, r := range rs {
 		if r.Service == "" {
@@ -118,6 +123,10 @@ func (m MapRouter) Register(rs []transport.Procedure) {
 		if _, ok := m.serviceProcedures[sp]; ok {
 			panic(fmt.Sprintf("Cannot register a handler for both (service, procedure) on any * encoding and (service, procedure, encoding), specifically (%q, %q, %q)", r.Service, r.Name, r.Encoding))
 		}
+		// Protect against overriding handlers for the same encoding.
+		if _, ok := m.serviceProcedureEncodings[spe]; ok {
+			panic(fmt.Sprintf("Cannot register multiple handlers for service %q, procedure %q and encoding %q", r.Service, r.Name, r.Encoding))
+		}
 		// Route to individual handlers for unique combinations of service,
 		// procedure, and encoding. This shall henceforth be the
 		// recommended way for models to register procedures.
@@ -202,6 +211,15 @@ func (m MapRouter) Choose(ctx context.Context, req *transport.Request) (transpor
 		return m.serviceProcedureEncodings[spe].HandlerSpec, nil
 	}
 
+	// Supported procedure, but none of its handlers supports the encoding.
+	if wantEncodings := m.supportedEncodings[sp]; len(wantEncodings) > 1 {
+		encodings := append([]string(nil), wantEncodings...)
+		sort.Strings(encodings)
+		return transport.HandlerSpec{}, yarpcerrors.Newf(yarpcerrors.CodeUnimplemented,
+			"unrecognized encoding %q for procedure %q of service %q, supported encodings: %s",
+			req.Encoding, req.Procedure, req.Service, humanize.QuotedJoin(encodings, "and", "none"))
+	}
+
 	return transport.HandlerSpec{}, yarpcerrors.Newf(yarpcerrors.CodeUnimplemented, "unrecognized procedure %q for service %q", req.Procedure, req.Service)
 }
 
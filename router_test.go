@@ -28,10 +28,12 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/yarpc/api/middleware"
 	"go.uber.org/yarpc/api/middleware/middlewaretest"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/yarpcerrors"
 )
 
 func TestMapRouter(t *testing.T) {
@@ -187,6 +189,45 @@ func TestAmbiguousProcedureRegistration(t *testing.T) {
 		"expected router panic")
 }
 
+func TestAmbiguousEncodingProcedureRegistration(t *testing.T) {
+	m := NewMapRouter("test-service-name")
+
+	procedures := []transport.Procedure{
+		{
+			Name:     "foo",
+			Service:  "test",
+			Encoding: "json",
+		},
+		{
+			Name:     "foo",
+			Service:  "test",
+			Encoding: "json",
+		},
+	}
+
+	assert.Panics(t,
+		func() { m.Register(procedures) },
+		"expected router panic")
+}
+
+func TestUnrecognizedEncoding(t *testing.T) {
+	m := NewMapRouter("test-service-name")
+	m.Register([]transport.Procedure{
+		{Name: "foo", Encoding: "proto"},
+		{Name: "foo", Encoding: "json"},
+	})
+
+	_, err := m.Choose(context.Background(), &transport.Request{
+		Service:   "test-service-name",
+		Procedure: "foo",
+		Encoding:  "thrift",
+	})
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeUnimplemented, yarpcerrors.FromError(err).Code())
+	assert.Equal(t, `unrecognized encoding "thrift" for procedure "foo" of service "test-service-name", supported encodings: "json" and "proto"`,
+		yarpcerrors.FromError(err).Message())
+}
+
 func TestEncodingBeforeWildcardProcedureRegistration(t *testing.T) {
 	m := NewMapRouter("test-service-name")
 
@@ -0,0 +1,153 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package grpc
+
+import (
+	"context"
+	"io"
+	"strconv"
+
+	"go.uber.org/yarpc/yarpcerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// ChunkSizeHeader is the header key with which outbounds that send large
+// unary requests in chunks announce the size of the chunks. Inbounds that
+// accept chunked requests reassemble the request from all the messages the
+// caller sends, and send the response back in chunks of at most this size.
+// This header is optional.
+const ChunkSizeHeader = "rpc-chunk-size"
+
+// ChunkSize specifies that the outbound splits unary request bodies larger
+// than the given number of bytes into chunks, and that it accepts the
+// responses to such requests in chunks of the same size. The chunks are sent
+// as separate messages of the gRPC call, so that payloads larger than the
+// maximum message size of the client or the server go through without
+// raising the limit for every call. Smaller requests, and their responses,
+// are sent whole.
+//
+// Chunk sizes must be below the maximum message sizes on both sides. The
+// server must be a YARPC gRPC inbound that accepts chunked requests with
+// MaxChunkedRequestSize, so this option has no effect with NativeInterop.
+// Payloads are still reassembled in memory, and streaming procedures are not
+// affected. Handlers may send the responses to chunked requests as they are
+// written with transport.StreamResponse.
+//
+// Chunking is disabled by default.
+func ChunkSize(size int) OutboundOption {
+	return func(outboundOptions *outboundOptions) {
+		outboundOptions.chunkSize = size
+	}
+}
+
+var _chunkedStreamDesc = &grpc.StreamDesc{ClientStreams: true, ServerStreams: true}
+
+// chunk splits a payload into chunks of at most the given size. Empty
+// payloads are sent as a single empty chunk.
+func chunk(payload []byte, size int) [][]byte {
+	chunks := make([][]byte, 0, len(payload)/size+1)
+	for len(payload) > size {
+		chunks = append(chunks, payload[:size])
+		payload = payload[size:]
+	}
+	return append(chunks, payload)
+}
+
+// invokeChunked makes a unary call sending the request and receiving the
+// response in chunks.
+func invokeChunked(
+	ctx context.Context,
+	clientConn *grpc.ClientConn,
+	fullMethod string,
+	requestBody []byte,
+	responseBody *[]byte,
+	chunkSize int,
+	callOptions ...grpc.CallOption,
+) error {
+	stream, err := clientConn.NewStream(ctx, _chunkedStreamDesc, fullMethod, callOptions...)
+	if err != nil {
+		return err
+	}
+	for _, c := range chunk(requestBody, chunkSize) {
+		if err := stream.SendMsg(c); err != nil {
+			if err == io.EOF {
+				// The server ended the call early; its status is returned
+				// by RecvMsg.
+				break
+			}
+			return err
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+	body, err := receiveChunks(stream.RecvMsg, 0)
+	if err != nil {
+		return err
+	}
+	*responseBody = body
+	return nil
+}
+
+// receiveChunks receives messages until the end of the stream and returns
+// them concatenated. It fails with a ResourceExhausted error once more than
+// maxSize bytes have been received, unless maxSize is zero.
+func receiveChunks(recvMsg func(interface{}) error, maxSize int) ([]byte, error) {
+	var body []byte
+	for {
+		var c []byte
+		if err := recvMsg(&c); err != nil {
+			if err == io.EOF {
+				return body, nil
+			}
+			return nil, err
+		}
+		if maxSize > 0 && len(body)+len(c) > maxSize {
+			return nil, yarpcerrors.ResourceExhaustedErrorf(
+				"chunked request is larger than the limit of %d bytes", maxSize)
+		}
+		body = append(body, c...)
+	}
+}
+
+// chunkSizeFromMetadata returns the chunk size the caller announced, if any.
+func chunkSizeFromMetadata(md metadata.MD) (int, error) {
+	values := md[ChunkSizeHeader]
+	if len(values) == 0 {
+		return 0, nil
+	}
+	size, err := strconv.Atoi(values[0])
+	if err != nil || size <= 0 {
+		return 0, yarpcerrors.InvalidArgumentErrorf("invalid %s header: %q", ChunkSizeHeader, values[0])
+	}
+	return size, nil
+}
+
+// sendChunks sends a response in chunks of at most the given size.
+func sendChunks(serverStream grpc.ServerStream, payload []byte, chunkSize int) error {
+	for _, c := range chunk(payload, chunkSize) {
+		if err := serverStream.SendMsg(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package grpc
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestChunk(t *testing.T) {
+	tests := []struct {
+		payload string
+		size    int
+		want    []string
+	}{
+		{payload: "", size: 3, want: []string{""}},
+		{payload: "ab", size: 3, want: []string{"ab"}},
+		{payload: "abc", size: 3, want: []string{"abc"}},
+		{payload: "abcdefg", size: 3, want: []string{"abc", "def", "g"}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, c := range chunk([]byte(tt.payload), tt.size) {
+			got = append(got, string(c))
+		}
+		assert.Equal(t, tt.want, got, "chunks of %q", tt.payload)
+	}
+}
+
+func TestChunkSizeFromMetadata(t *testing.T) {
+	size, err := chunkSizeFromMetadata(metadata.Pairs())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, size)
+
+	size, err = chunkSizeFromMetadata(metadata.Pairs(ChunkSizeHeader, "1024"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1024, size)
+
+	for _, invalid := range []string{"foo", "0", "-1"} {
+		_, err = chunkSizeFromMetadata(metadata.Pairs(ChunkSizeHeader, invalid))
+		assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.FromError(err).Code(), "chunk size %q", invalid)
+	}
+}
+
+func TestReceiveChunks(t *testing.T) {
+	recv := func(chunks ...string) func(interface{}) error {
+		return func(m interface{}) error {
+			if len(chunks) == 0 {
+				return io.EOF
+			}
+			*m.(*[]byte) = []byte(chunks[0])
+			chunks = chunks[1:]
+			return nil
+		}
+	}
+
+	body, err := receiveChunks(recv("foo", "bar"), 0)
+	require.NoError(t, err)
+	assert.Equal(t, "foobar", string(body))
+
+	body, err = receiveChunks(recv("foo", "bar"), 6)
+	require.NoError(t, err)
+	assert.Equal(t, "foobar", string(body))
+
+	_, err = receiveChunks(recv("foo", "bar", "baz"), 6)
+	assert.Equal(t, yarpcerrors.CodeResourceExhausted, yarpcerrors.FromError(err).Code())
+}
+
+func TestResponseWriterStreamsChunks(t *testing.T) {
+	var sent []string
+	w := newResponseWriter()
+	w.sendMsg = func(m interface{}) error {
+		sent = append(sent, string(m.([]byte)))
+		return nil
+	}
+	w.chunkSize = 3
+
+	require.True(t, transport.StreamResponse(w))
+	_, err := w.Write([]byte("ab"))
+	require.NoError(t, err)
+	assert.Empty(t, sent, "partial chunks must not be sent")
+	_, err = w.Write([]byte("cdefgh"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"abc", "def"}, sent)
+	assert.Equal(t, "gh", string(w.Bytes()), "the rest must be sent when the handler returns")
+}
+
+func TestResponseWriterStreamsOnlyChunkedCalls(t *testing.T) {
+	assert.False(t, transport.StreamResponse(newResponseWriter()))
+
+	w := newResponseWriter()
+	w.sendMsg = func(interface{}) error { return nil }
+	w.chunkSize = 3
+	_, err := w.Write([]byte("a"))
+	require.NoError(t, err)
+	assert.False(t, transport.StreamResponse(w), "responses that were already written to must stay buffered")
+}
@@ -23,6 +23,7 @@ package grpc
 import (
 	"fmt"
 	"net"
+	"time"
 
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/peer/hostport"
@@ -61,6 +62,16 @@ func TransportSpec(opts ...Option) yarpcconfig.TransportSpec {
 //        exponential:
 //          first: 10ms
 //          max: 30s
+//      clientKeepaliveTime: 1m
+//      streamIdleTimeout: 10m
+//      warmConnections: 4
+//      clientMaxConcurrentStreams: 100
+//      clientMaxConnectionsPerPeer: 8
+//      clientMaxConnectionIdle: 1m
+//      serverMaxRecvMsgSize: 16777216
+//      clientMaxRecvMsgSize: 16777216
+//      serverInitialWindowSize: 1048576
+//      clientInitialWindowSize: 1048576
 //
 // All parameters of TransportConfig are optional. This section
 // may be omitted in the transports section.
@@ -71,6 +82,37 @@ type TransportConfig struct {
 	ClientMaxSendMsgSize int                 `config:"clientMaxSendMsgSize"`
 	ClientTLS            bool                `config:"clientTLS"`
 	Backoff              yarpcconfig.Backoff `config:"backoff"`
+
+	// Limits on the metadata of inbound requests. See
+	// ServerMaxRequestHeaderBytes and ServerMaxRequestHeaders.
+	ServerMaxRequestHeaderBytes int `config:"serverMaxRequestHeaderBytes"`
+	ServerMaxRequestHeaders     int `config:"serverMaxRequestHeaders"`
+
+	// Flow-control window sizes in bytes. See the options of the same names
+	// for details.
+	ServerInitialWindowSize     int32 `config:"serverInitialWindowSize"`
+	ServerInitialConnWindowSize int32 `config:"serverInitialConnWindowSize"`
+	ClientInitialWindowSize     int32 `config:"clientInitialWindowSize"`
+	ClientInitialConnWindowSize int32 `config:"clientInitialConnWindowSize"`
+
+	// Keepalive and idle settings. See the options of the same names for
+	// details.
+	ClientKeepaliveTime     time.Duration `config:"clientKeepaliveTime"`
+	ClientKeepaliveTimeout  time.Duration `config:"clientKeepaliveTimeout"`
+	ServerKeepaliveTime     time.Duration `config:"serverKeepaliveTime"`
+	ServerKeepaliveTimeout  time.Duration `config:"serverKeepaliveTimeout"`
+	ServerKeepaliveMinTime  time.Duration `config:"serverKeepaliveMinTime"`
+	ServerMaxConnectionIdle time.Duration `config:"serverMaxConnectionIdle"`
+	StreamIdleTimeout       time.Duration `config:"streamIdleTimeout"`
+
+	// Number of connections to establish to each peer. See WarmConnections.
+	WarmConnections int `config:"warmConnections"`
+
+	// Connection pool settings. See the options of the same names for
+	// details.
+	ClientMaxConcurrentStreams  int           `config:"clientMaxConcurrentStreams"`
+	ClientMaxConnectionsPerPeer int           `config:"clientMaxConnectionsPerPeer"`
+	ClientMaxConnectionIdle     time.Duration `config:"clientMaxConnectionIdle"`
 }
 
 // InboundConfig configures a gRPC Inbound.
@@ -81,6 +123,9 @@ type TransportConfig struct {
 type InboundConfig struct {
 	// Address to listen on. This field is required.
 	Address string `config:"address,interpolate"`
+	// MaxChunkedRequestSize accepts unary requests sent in chunks, up to
+	// this number of bytes. See the MaxChunkedRequestSize option.
+	MaxChunkedRequestSize int `config:"maxChunkedRequestSize"`
 }
 
 // OutboundConfig configures a gRPC Outbound.
@@ -99,11 +144,32 @@ type InboundConfig struct {
 //          peers:
 //            - 127.0.0.1:8080
 //            - 127.0.0.1:8081
+//
+// Alternatively, a gRPC outbound can delegate load balancing to gRPC by
+// specifying a target with the scheme of a resolver registered with gRPC.
+// See NewTargetOutbound.
+//
+//  outbounds:
+//    myservice:
+//      grpc:
+//        target: dns:///myservice:8080
 type OutboundConfig struct {
 	yarpcconfig.PeerChooser
 
 	// Address to connect to if no peer options set.
 	Address string `config:"address,interpolate"`
+	// Target to dial with gRPC resolvers and load balancers instead of
+	// choosing peers. Mutually exclusive with address and peer options.
+	Target string `config:"target,interpolate"`
+	// NativeInterop makes the outbound speak plain gRPC without YARPC
+	// headers. See the NativeInterop option.
+	NativeInterop bool `config:"nativeInterop"`
+	// ChunkSize splits unary requests larger than this number of bytes into
+	// chunks. See the ChunkSize option.
+	ChunkSize int `config:"chunkSize"`
+	// Compressor is the name of the registered gRPC compressor with which
+	// requests are compressed. See the Compressor option.
+	Compressor string `config:"compressor"`
 }
 
 type transportSpec struct {
@@ -143,9 +209,60 @@ func (t *transportSpec) buildTransport(transportConfig *TransportConfig, _ *yarp
 	if transportConfig.ClientMaxSendMsgSize > 0 {
 		options = append(options, ClientMaxSendMsgSize(transportConfig.ClientMaxSendMsgSize))
 	}
+	if transportConfig.ServerMaxRequestHeaderBytes > 0 {
+		options = append(options, ServerMaxRequestHeaderBytes(transportConfig.ServerMaxRequestHeaderBytes))
+	}
+	if transportConfig.ServerMaxRequestHeaders > 0 {
+		options = append(options, ServerMaxRequestHeaders(transportConfig.ServerMaxRequestHeaders))
+	}
 	if transportConfig.ClientTLS {
 		options = append(options, ClientTLS())
 	}
+	if transportConfig.ServerInitialWindowSize > 0 {
+		options = append(options, ServerInitialWindowSize(transportConfig.ServerInitialWindowSize))
+	}
+	if transportConfig.ServerInitialConnWindowSize > 0 {
+		options = append(options, ServerInitialConnWindowSize(transportConfig.ServerInitialConnWindowSize))
+	}
+	if transportConfig.ClientInitialWindowSize > 0 {
+		options = append(options, ClientInitialWindowSize(transportConfig.ClientInitialWindowSize))
+	}
+	if transportConfig.ClientInitialConnWindowSize > 0 {
+		options = append(options, ClientInitialConnWindowSize(transportConfig.ClientInitialConnWindowSize))
+	}
+	if transportConfig.ClientKeepaliveTime > 0 {
+		options = append(options, ClientKeepaliveTime(transportConfig.ClientKeepaliveTime))
+	}
+	if transportConfig.ClientKeepaliveTimeout > 0 {
+		options = append(options, ClientKeepaliveTimeout(transportConfig.ClientKeepaliveTimeout))
+	}
+	if transportConfig.ServerKeepaliveTime > 0 {
+		options = append(options, ServerKeepaliveTime(transportConfig.ServerKeepaliveTime))
+	}
+	if transportConfig.ServerKeepaliveTimeout > 0 {
+		options = append(options, ServerKeepaliveTimeout(transportConfig.ServerKeepaliveTimeout))
+	}
+	if transportConfig.ServerKeepaliveMinTime > 0 {
+		options = append(options, ServerKeepaliveMinTime(transportConfig.ServerKeepaliveMinTime))
+	}
+	if transportConfig.ServerMaxConnectionIdle > 0 {
+		options = append(options, ServerMaxConnectionIdle(transportConfig.ServerMaxConnectionIdle))
+	}
+	if transportConfig.StreamIdleTimeout > 0 {
+		options = append(options, StreamIdleTimeout(transportConfig.StreamIdleTimeout))
+	}
+	if transportConfig.WarmConnections > 0 {
+		options = append(options, WarmConnections(transportConfig.WarmConnections))
+	}
+	if transportConfig.ClientMaxConcurrentStreams > 0 {
+		options = append(options, ClientMaxConcurrentStreams(transportConfig.ClientMaxConcurrentStreams))
+	}
+	if transportConfig.ClientMaxConnectionsPerPeer > 0 {
+		options = append(options, ClientMaxConnectionsPerPeer(transportConfig.ClientMaxConnectionsPerPeer))
+	}
+	if transportConfig.ClientMaxConnectionIdle > 0 {
+		options = append(options, ClientMaxConnectionIdle(transportConfig.ClientMaxConnectionIdle))
+	}
 	backoffStrategy, err := transportConfig.Backoff.Strategy()
 	if err != nil {
 		return nil, err
@@ -166,7 +283,11 @@ func (t *transportSpec) buildInbound(inboundConfig *InboundConfig, tr transport.
 	if err != nil {
 		return nil, err
 	}
-	return trans.NewInbound(listener, t.InboundOptions...), nil
+	options := append([]InboundOption(nil), t.InboundOptions...)
+	if inboundConfig.MaxChunkedRequestSize > 0 {
+		options = append(options, MaxChunkedRequestSize(inboundConfig.MaxChunkedRequestSize))
+	}
+	return trans.NewInbound(listener, options...), nil
 }
 
 func (t *transportSpec) buildUnaryOutbound(outboundConfig *OutboundConfig, tr transport.Transport, kit *yarpcconfig.Kit) (transport.UnaryOutbound, error) {
@@ -182,17 +303,33 @@ func (t *transportSpec) buildOutbound(outboundConfig *OutboundConfig, tr transpo
 	if !ok {
 		return nil, newTransportCastError(tr)
 	}
+	options := append([]OutboundOption(nil), t.OutboundOptions...)
+	if outboundConfig.NativeInterop {
+		options = append(options, NativeInterop())
+	}
+	if outboundConfig.ChunkSize > 0 {
+		options = append(options, ChunkSize(outboundConfig.ChunkSize))
+	}
+	if outboundConfig.Compressor != "" {
+		options = append(options, Compressor(outboundConfig.Compressor))
+	}
+	if outboundConfig.Target != "" {
+		if outboundConfig.Address != "" || !outboundConfig.Empty() {
+			return nil, fmt.Errorf("target cannot be specified with address or peer options")
+		}
+		return trans.NewTargetOutbound(outboundConfig.Target, options...), nil
+	}
 	if outboundConfig.Empty() {
 		if outboundConfig.Address == "" {
 			return nil, newRequiredFieldMissingError("address")
 		}
-		return trans.NewSingleOutbound(outboundConfig.Address, t.OutboundOptions...), nil
+		return trans.NewSingleOutbound(outboundConfig.Address, options...), nil
 	}
 	chooser, err := outboundConfig.BuildPeerChooser(trans, hostport.Identify, kit)
 	if err != nil {
 		return nil, err
 	}
-	return trans.NewOutbound(chooser, t.OutboundOptions...), nil
+	return trans.NewOutbound(chooser, options...), nil
 }
 
 func newTransportCastError(tr transport.Transport) error {
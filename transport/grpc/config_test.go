@@ -28,6 +28,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/headerlimit"
 	"go.uber.org/yarpc/peer"
 	"go.uber.org/yarpc/yarpcconfig"
 )
@@ -92,10 +93,35 @@ func TestTransportSpec(t *testing.T) {
 		ClientMaxRecvMsgSize int
 		ClientMaxSendMsgSize int
 		ClientTLS            bool
+		HeaderLimits         headerlimit.Limits
+
+		ServerInitialWindowSize     int32
+		ServerInitialConnWindowSize int32
+		ClientInitialWindowSize     int32
+		ClientInitialConnWindowSize int32
+
+		ClientKeepaliveTime     time.Duration
+		ClientKeepaliveTimeout  time.Duration
+		ServerKeepaliveTime     time.Duration
+		ServerKeepaliveTimeout  time.Duration
+		ServerKeepaliveMinTime  time.Duration
+		ServerMaxConnectionIdle time.Duration
+		StreamIdleTimeout       time.Duration
+		WarmConnections         int
+
+		ClientMaxConcurrentStreams  int
+		ClientMaxConnectionsPerPeer int
+		ClientMaxConnectionIdle     time.Duration
+
+		MaxChunkedRequestSize int
 	}
 
 	type wantOutbound struct {
-		Address string
+		Address       string
+		Target        string
+		NativeInterop bool
+		ChunkSize     int
+		Compressor    string
 	}
 
 	type test struct {
@@ -141,6 +167,70 @@ func TestTransportSpec(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "outbound with native interop",
+			outboundCfg: attrs{
+				"myservice": attrs{
+					transportName: attrs{"address": "localhost:54569", "nativeInterop": true},
+				},
+			},
+			wantOutbounds: map[string]wantOutbound{
+				"myservice": {
+					Address:       "localhost:54569",
+					NativeInterop: true,
+				},
+			},
+		},
+		{
+			desc: "outbound with chunking",
+			outboundCfg: attrs{
+				"myservice": attrs{
+					transportName: attrs{"address": "localhost:54569", "chunkSize": 1048576},
+				},
+			},
+			wantOutbounds: map[string]wantOutbound{
+				"myservice": {
+					Address:   "localhost:54569",
+					ChunkSize: 1048576,
+				},
+			},
+		},
+		{
+			desc: "outbound with compressor",
+			outboundCfg: attrs{
+				"myservice": attrs{
+					transportName: attrs{"address": "localhost:54569", "compressor": "gzip"},
+				},
+			},
+			wantOutbounds: map[string]wantOutbound{
+				"myservice": {
+					Address:    "localhost:54569",
+					Compressor: "gzip",
+				},
+			},
+		},
+		{
+			desc: "outbound with target",
+			outboundCfg: attrs{
+				"myservice": attrs{
+					transportName: attrs{"target": "dns:///localhost:54569"},
+				},
+			},
+			wantOutbounds: map[string]wantOutbound{
+				"myservice": {
+					Target: "dns:///localhost:54569",
+				},
+			},
+		},
+		{
+			desc: "outbound with target and address",
+			outboundCfg: attrs{
+				"myservice": attrs{
+					transportName: attrs{"target": "dns:///localhost:54569", "address": "localhost:54569"},
+				},
+			},
+			wantErrors: []string{"target cannot be specified with address or peer options"},
+		},
 		{
 			desc: "outbound interpolation",
 			outboundCfg: attrs{
@@ -210,6 +300,26 @@ func TestTransportSpec(t *testing.T) {
 				ClientMaxSendMsgSize: 8192,
 			},
 		},
+		{
+			desc:       "inbound accepting chunked requests",
+			inboundCfg: attrs{"address": ":54583", "maxChunkedRequestSize": 1 << 26},
+			wantInbound: &wantInbound{
+				Address:               ":54583",
+				MaxChunkedRequestSize: 1 << 26,
+			},
+		},
+		{
+			desc: "inbound and transport with header limits",
+			transportCfg: attrs{
+				"serverMaxRequestHeaderBytes": 4096,
+				"serverMaxRequestHeaders":     32,
+			},
+			inboundCfg: attrs{"address": ":54582"},
+			wantInbound: &wantInbound{
+				Address:      ":54582",
+				HeaderLimits: headerlimit.Limits{MaxBytes: 4096, MaxCount: 32},
+			},
+		},
 		{
 			desc: "inbound and transport with client TLS",
 			transportCfg: attrs{
@@ -221,6 +331,63 @@ func TestTransportSpec(t *testing.T) {
 				ClientTLS: true,
 			},
 		},
+		{
+			desc: "inbound and transport with window size options",
+			transportCfg: attrs{
+				"serverInitialWindowSize":     "131072",
+				"serverInitialConnWindowSize": "262144",
+				"clientInitialWindowSize":     "524288",
+				"clientInitialConnWindowSize": "1048576",
+			},
+			inboundCfg: attrs{"address": ":54574"},
+			wantInbound: &wantInbound{
+				Address:                     ":54574",
+				ServerInitialWindowSize:     131072,
+				ServerInitialConnWindowSize: 262144,
+				ClientInitialWindowSize:     524288,
+				ClientInitialConnWindowSize: 1048576,
+			},
+		},
+		{
+			desc: "inbound and transport with keepalive options",
+			transportCfg: attrs{
+				"clientKeepaliveTime":     "1m",
+				"clientKeepaliveTimeout":  "10s",
+				"serverKeepaliveTime":     "2m",
+				"serverKeepaliveTimeout":  "20s",
+				"serverKeepaliveMinTime":  "30s",
+				"serverMaxConnectionIdle": "1h",
+				"streamIdleTimeout":       "5m",
+				"warmConnections":         3,
+			},
+			inboundCfg: attrs{"address": ":54573"},
+			wantInbound: &wantInbound{
+				Address:                 ":54573",
+				ClientKeepaliveTime:     time.Minute,
+				ClientKeepaliveTimeout:  10 * time.Second,
+				ServerKeepaliveTime:     2 * time.Minute,
+				ServerKeepaliveTimeout:  20 * time.Second,
+				ServerKeepaliveMinTime:  30 * time.Second,
+				ServerMaxConnectionIdle: time.Hour,
+				StreamIdleTimeout:       5 * time.Minute,
+				WarmConnections:         3,
+			},
+		},
+		{
+			desc: "inbound and transport with connection pool options",
+			transportCfg: attrs{
+				"clientMaxConcurrentStreams":  100,
+				"clientMaxConnectionsPerPeer": 4,
+				"clientMaxConnectionIdle":     "30s",
+			},
+			inboundCfg: attrs{"address": ":54574"},
+			wantInbound: &wantInbound{
+				Address:                     ":54574",
+				ClientMaxConcurrentStreams:  100,
+				ClientMaxConnectionsPerPeer: 4,
+				ClientMaxConnectionIdle:     30 * time.Second,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -281,6 +448,31 @@ func TestTransportSpec(t *testing.T) {
 					assert.Equal(t, defaultClientMaxSendMsgSize, inbound.t.options.clientMaxSendMsgSize)
 				}
 				assert.Equal(t, tt.wantInbound.ClientTLS, inbound.t.options.clientTLS)
+				assert.Equal(t, tt.wantInbound.HeaderLimits, inbound.t.options.serverHeaderLimits)
+				assert.Equal(t, tt.wantInbound.MaxChunkedRequestSize, inbound.options.maxChunkedRequestSize)
+				assert.Equal(t, tt.wantInbound.ServerInitialWindowSize, inbound.t.options.serverInitialWindowSize)
+				assert.Equal(t, tt.wantInbound.ServerInitialConnWindowSize, inbound.t.options.serverInitialConnWindowSize)
+				assert.Equal(t, tt.wantInbound.ClientInitialWindowSize, inbound.t.options.clientInitialWindowSize)
+				assert.Equal(t, tt.wantInbound.ClientInitialConnWindowSize, inbound.t.options.clientInitialConnWindowSize)
+				assert.Equal(t, tt.wantInbound.ClientKeepaliveTime, inbound.t.options.clientKeepaliveTime)
+				assert.Equal(t, tt.wantInbound.ClientKeepaliveTimeout, inbound.t.options.clientKeepaliveTimeout)
+				assert.Equal(t, tt.wantInbound.ServerKeepaliveTime, inbound.t.options.serverKeepaliveTime)
+				assert.Equal(t, tt.wantInbound.ServerKeepaliveTimeout, inbound.t.options.serverKeepaliveTimeout)
+				assert.Equal(t, tt.wantInbound.ServerKeepaliveMinTime, inbound.t.options.serverKeepaliveMinTime)
+				assert.Equal(t, tt.wantInbound.ServerMaxConnectionIdle, inbound.t.options.serverMaxConnectionIdle)
+				assert.Equal(t, tt.wantInbound.StreamIdleTimeout, inbound.t.options.streamIdleTimeout)
+				assert.Equal(t, tt.wantInbound.WarmConnections, inbound.t.options.warmConnections)
+				assert.Equal(t, tt.wantInbound.ClientMaxConcurrentStreams, inbound.t.options.clientMaxConcurrentStreams)
+				if tt.wantInbound.ClientMaxConnectionsPerPeer > 0 {
+					assert.Equal(t, tt.wantInbound.ClientMaxConnectionsPerPeer, inbound.t.options.clientMaxConnectionsPerPeer)
+				} else {
+					assert.Equal(t, defaultClientMaxConnectionsPerPeer, inbound.t.options.clientMaxConnectionsPerPeer)
+				}
+				if tt.wantInbound.ClientMaxConnectionIdle > 0 {
+					assert.Equal(t, tt.wantInbound.ClientMaxConnectionIdle, inbound.t.options.clientMaxConnectionIdle)
+				} else {
+					assert.Equal(t, defaultClientMaxConnectionIdle, inbound.t.options.clientMaxConnectionIdle)
+				}
 			} else {
 				assert.Len(t, cfg.Inbounds, 0)
 			}
@@ -289,6 +481,10 @@ func TestTransportSpec(t *testing.T) {
 				require.True(t, ok, "no outbounds for %s", svc)
 				outbound, ok := ob.Unary.(*Outbound)
 				require.True(t, ok, "expected *Outbound, got %T", ob)
+				assert.Equal(t, wantOutbound.NativeInterop, outbound.options.nativeInterop)
+				assert.Equal(t, wantOutbound.ChunkSize, outbound.options.chunkSize)
+				assert.Equal(t, wantOutbound.Compressor, outbound.options.compressor)
+				assert.Equal(t, wantOutbound.Target, outbound.target)
 				if wantOutbound.Address != "" {
 					single, ok := outbound.peerChooser.(*peer.Single)
 					if !ok {
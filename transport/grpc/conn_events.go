@@ -0,0 +1,78 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"time"
+
+	intnet "go.uber.org/yarpc/internal/net"
+	"google.golang.org/grpc/credentials"
+)
+
+// reportingDialer returns a gRPC dialer that dials connections with the given
+// dial function and reports them, if reporter is non-nil. If handshake is
+// true, connections are established once the handshake of their
+// reportingCredentials completes.
+func reportingDialer(reporter *intnet.ConnReporter, dial intnet.DialFunc, handshake bool) func(string, time.Duration) (net.Conn, error) {
+	dial = reporter.Dialer(dial, handshake)
+	return func(addr string, timeout time.Duration) (net.Conn, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return dial(ctx, "tcp", addr)
+	}
+}
+
+// reportingCredentials reports the outcome of the handshakes of connections
+// dialed by a reportingDialer or accepted by a reporting listener.
+type reportingCredentials struct {
+	credentials.TransportCredentials
+}
+
+func (c reportingCredentials) ClientHandshake(ctx context.Context, authority string, rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	conn, info, err := c.TransportCredentials.ClientHandshake(ctx, authority, rawConn)
+	reportHandshake(rawConn, info, err)
+	return conn, info, err
+}
+
+func (c reportingCredentials) ServerHandshake(rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	conn, info, err := c.TransportCredentials.ServerHandshake(rawConn)
+	reportHandshake(rawConn, info, err)
+	return conn, info, err
+}
+
+func (c reportingCredentials) Clone() credentials.TransportCredentials {
+	return reportingCredentials{c.TransportCredentials.Clone()}
+}
+
+func reportHandshake(rawConn net.Conn, info credentials.AuthInfo, err error) {
+	if err != nil {
+		intnet.HandshakeFailed(rawConn, err)
+		return
+	}
+	var state *tls.ConnectionState
+	if tlsInfo, ok := info.(credentials.TLSInfo); ok {
+		state = &tlsInfo.State
+	}
+	intnet.HandshakeComplete(rawConn, state)
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	intnet "go.uber.org/yarpc/internal/net"
+	"google.golang.org/grpc/credentials"
+)
+
+type connEvents struct {
+	mu     sync.Mutex
+	events []transport.ConnectionEvent
+}
+
+func (c *connEvents) record(e transport.ConnectionEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, e)
+}
+
+// find waits for an event of the given type and direction.
+func (c *connEvents) find(typ transport.ConnectionEventType, inbound bool) (transport.ConnectionEvent, bool) {
+	for i := 0; i < 100; i++ {
+		c.mu.Lock()
+		for _, e := range c.events {
+			if e.Type == typ && e.Inbound == inbound {
+				c.mu.Unlock()
+				return e, true
+			}
+		}
+		c.mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	return transport.ConnectionEvent{}, false
+}
+
+func TestConnectionEvents(t *testing.T) {
+	var events connEvents
+	trans := NewTransport(ConnectionEvents(events.record))
+	require.NoError(t, trans.Start())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	inbound := trans.NewInbound(listener)
+	inbound.SetRouter(newTestRouter(nil))
+	require.NoError(t, inbound.Start())
+	defer inbound.Stop()
+	address := listener.Addr().String()
+
+	_, err = trans.RetainPeer(testIdentifier{address}, testPeerSubscriber{})
+	require.NoError(t, err)
+
+	established, ok := events.find(transport.ConnectionEstablished, false)
+	require.True(t, ok, "outbound connection must be established")
+	assert.Equal(t, "grpc", established.Transport)
+	assert.Equal(t, address, established.Peer)
+	assert.Nil(t, established.TLS)
+
+	accepted, ok := events.find(transport.ConnectionEstablished, true)
+	require.True(t, ok, "inbound connection must be established")
+	assert.Equal(t, established.LocalAddr, accepted.Peer)
+
+	require.NoError(t, trans.ReleasePeer(testIdentifier{address}, testPeerSubscriber{}))
+	require.NoError(t, trans.Stop())
+	_, ok = events.find(transport.ConnectionClosed, false)
+	assert.True(t, ok, "outbound connection must be closed")
+}
+
+func TestReportHandshake(t *testing.T) {
+	state := tls.ConnectionState{HandshakeComplete: true, ServerName: "example.com"}
+	tests := []struct {
+		desc      string
+		info      credentials.AuthInfo
+		err       error
+		wantType  transport.ConnectionEventType
+		wantState *tls.ConnectionState
+	}{
+		{
+			desc:      "tls",
+			info:      credentials.TLSInfo{State: state},
+			wantType:  transport.ConnectionEstablished,
+			wantState: &state,
+		},
+		{
+			desc:     "failure",
+			err:      errors.New("bad certificate"),
+			wantType: transport.ConnectionFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var events connEvents
+			reporter := intnet.NewConnReporter(transportName, events.record)
+
+			client, server := net.Pipe()
+			defer client.Close()
+			defer server.Close()
+			dial := reporter.Dialer(func(context.Context, string, string) (net.Conn, error) {
+				return client, nil
+			}, true)
+			rawConn, err := dial(context.Background(), "tcp", "peer")
+			require.NoError(t, err)
+
+			reportHandshake(rawConn, tt.info, tt.err)
+			require.Len(t, events.events, 1)
+			assert.Equal(t, tt.wantType, events.events[0].Type)
+			assert.Equal(t, tt.wantState, events.events[0].TLS)
+			assert.Equal(t, tt.err, events.events[0].Err)
+		})
+	}
+}
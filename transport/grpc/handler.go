@@ -21,18 +21,22 @@
 package grpc
 
 import (
+	"bytes"
 	"strings"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
 	"go.uber.org/yarpc"
 	"go.uber.org/yarpc/api/transport"
-	"go.uber.org/yarpc/internal/bufferpool"
+	intyarpcerrors "go.uber.org/yarpc/internal/yarpcerrors"
 	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 )
 
@@ -52,7 +56,7 @@ func newHandler(i *Inbound) *handler {
 
 func (h *handler) handle(srv interface{}, serverStream grpc.ServerStream) error {
 	start := time.Now()
-	ctx := serverStream.Context()
+	ctx := withRemotePeer(serverStream.Context())
 	streamMethod, ok := grpc.MethodFromServerStream(serverStream)
 	if !ok {
 		return errInvalidGRPCStream
@@ -76,6 +80,24 @@ func (h *handler) handle(srv interface{}, serverStream grpc.ServerStream) error
 	return yarpcerrors.Newf(yarpcerrors.CodeUnimplemented, "transport grpc does not handle %s handlers", handlerSpec.Type().String())
 }
 
+// withRemotePeer records the gRPC peer of the stream as the remote peer of the
+// request.
+func withRemotePeer(ctx context.Context) context.Context {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	var remote transport.RemotePeer
+	if p.Addr != nil {
+		remote.Address = p.Addr.String()
+	}
+	if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+		state := info.State
+		remote.TLS = &state
+	}
+	return transport.WithRemotePeer(ctx, remote)
+}
+
 // getBasicTransportRequest converts the grpc request metadata into a
 // transport.Request without a body field.
 func (h *handler) getBasicTransportRequest(ctx context.Context, streamMethod string) (*transport.Request, error) {
@@ -83,7 +105,10 @@ func (h *handler) getBasicTransportRequest(ctx context.Context, streamMethod str
 	if md == nil || !ok {
 		return nil, yarpcerrors.Newf(yarpcerrors.CodeInternal, "cannot get metadata from ctx: %v", ctx)
 	}
-	transportRequest, err := metadataToTransportRequest(md)
+	if err := h.i.t.options.serverHeaderLimits.CheckMap(md); err != nil {
+		return nil, err
+	}
+	transportRequest, err := metadataToTransportRequest(md, h.i.t.options.subtypeEncodings)
 	if err != nil {
 		return nil, err
 	}
@@ -95,6 +120,9 @@ func (h *handler) getBasicTransportRequest(ctx context.Context, streamMethod str
 	}
 
 	transportRequest.Procedure = procedure
+	transportRequest.Metadata = &transport.Metadata{
+		GRPC: &transport.GRPCMetadata{Method: streamMethod},
+	}
 	if err := transport.ValidateRequest(transportRequest); err != nil {
 		return nil, err
 	}
@@ -161,30 +189,52 @@ func (h *handler) handleUnary(
 	start time.Time,
 	handler transport.UnaryHandler,
 ) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	chunkSize, err := chunkSizeFromMetadata(md)
+	if err != nil {
+		return err
+	}
 	var requestData []byte
-	if err := serverStream.RecvMsg(&requestData); err != nil {
+	if chunkSize > 0 {
+		maxSize := h.i.options.maxChunkedRequestSize
+		if maxSize <= 0 {
+			return toGRPCStreamError(yarpcerrors.UnimplementedErrorf("gRPC inbound does not accept chunked requests"))
+		}
+		if requestData, err = receiveChunks(serverStream.RecvMsg, maxSize); err != nil {
+			return toGRPCStreamError(err)
+		}
+	} else if err := serverStream.RecvMsg(&requestData); err != nil {
 		return err
 	}
-	requestBuffer := bufferpool.Get()
-	defer bufferpool.Put(requestBuffer)
-
-	// Buffers are documented to always return a nil error.
-	_, _ = requestBuffer.Write(requestData)
-	transportRequest.Body = requestBuffer
+	// requestData is not retained by gRPC after RecvMsg returns, so it can be
+	// read directly instead of being copied.
+	transportRequest.Body = bytes.NewReader(requestData)
 
 	responseWriter := newResponseWriter()
 	defer responseWriter.Close()
+	if chunkSize > 0 {
+		responseWriter.sendMsg = serverStream.SendMsg
+		responseWriter.chunkSize = chunkSize
+	}
 
 	// Echo accepted rpc-service in response header
 	responseWriter.AddSystemHeader(ServiceHeader, transportRequest.Service)
 
-	err := h.handleUnaryBeforeErrorConversion(ctx, transportRequest, responseWriter, start, handler)
-	err = handlerErrorToGRPCError(err, responseWriter)
+	err = h.handleUnaryBeforeErrorConversion(ctx, transportRequest, responseWriter, start, handler)
+	err = handlerErrorToGRPCError(err, responseWriter, h.i.t.options.logger)
 
 	// Send the response attributes back and end the stream.
-	if sendErr := serverStream.SendMsg(responseWriter.Bytes()); sendErr != nil {
-		// We couldn't send the response.
-		return sendErr
+	sendMsg := serverStream.SendMsg
+	if chunkSize > 0 {
+		sendMsg = func(m interface{}) error {
+			return sendChunks(serverStream, m.([]byte), chunkSize)
+		}
+	}
+	if body := responseWriter.Bytes(); len(body) > 0 || !responseWriter.streaming {
+		if sendErr := sendMsg(body); sendErr != nil {
+			// We couldn't send the response.
+			return sendErr
+		}
 	}
 	if responseWriter.md != nil {
 		serverStream.SetTrailer(responseWriter.md)
@@ -226,7 +276,7 @@ func (h *handler) callUnary(ctx context.Context, transportRequest *transport.Req
 	return transport.DispatchUnaryHandler(ctx, unaryHandler, time.Now(), transportRequest, responseWriter)
 }
 
-func handlerErrorToGRPCError(err error, responseWriter *responseWriter) error {
+func handlerErrorToGRPCError(err error, responseWriter *responseWriter, logger *zap.Logger) error {
 	if err == nil {
 		return nil
 	}
@@ -255,6 +305,9 @@ func handlerErrorToGRPCError(err error, responseWriter *responseWriter) error {
 			message = name + ": " + message
 		}
 	}
+	if details := intyarpcerrors.DetailsHeader(logger, yarpcStatus); details != "" {
+		responseWriter.AddSystemHeader(ErrorDetailsHeader, details)
+	}
 	grpcCode, ok := _codeToGRPCCode[yarpcStatus.Code()]
 	// should only happen if _codeToGRPCCode does not cover all codes
 	if !ok {
@@ -26,7 +26,9 @@ import (
 	"net"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
 	"google.golang.org/grpc/metadata"
 )
 
@@ -115,3 +117,76 @@ func TestInvalidStreamMultipleHeaders(t *testing.T) {
 	require.Contains(t, err.Error(), "code:invalid-argument")
 	require.Contains(t, err.Error(), "header has more than one value: rpc-caller")
 }
+
+func TestStreamRequestMetadata(t *testing.T) {
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:0"))
+	require.NoError(t, err)
+
+	tran := NewTransport()
+	i := tran.NewInbound(listener)
+
+	h := handler{i: i}
+	md := metadata.MD{
+		CallerHeader:   []string{"caller"},
+		ServiceHeader:  []string{"service"},
+		EncodingHeader: []string{"raw"},
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+
+	req, err := h.getBasicTransportRequest(ctx, "/service/proc")
+	require.NoError(t, err)
+	assert.Equal(t, &transport.Metadata{
+		GRPC: &transport.GRPCMetadata{Method: "/service/proc"},
+	}, req.Metadata)
+}
+
+func TestStreamRequestHeaderLimits(t *testing.T) {
+	md := metadata.MD{
+		CallerHeader:   []string{"caller"},
+		ServiceHeader:  []string{"service"},
+		EncodingHeader: []string{"raw"},
+		"rpc-header-a": []string{"0123456789012345678901234567890123456789"},
+	}
+
+	tests := []struct {
+		desc    string
+		opts    []TransportOption
+		wantErr string
+	}{
+		{desc: "no limits"},
+		{
+			desc: "within limits",
+			opts: []TransportOption{ServerMaxRequestHeaderBytes(1024), ServerMaxRequestHeaders(4)},
+		},
+		{
+			desc:    "too many bytes",
+			opts:    []TransportOption{ServerMaxRequestHeaderBytes(64)},
+			wantErr: "exceed the limit of 64 bytes",
+		},
+		{
+			desc:    "too many headers",
+			opts:    []TransportOption{ServerMaxRequestHeaders(3)},
+			wantErr: "exceeding the limit of 3 headers",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			defer listener.Close()
+
+			h := handler{i: NewTransport(tt.opts...).NewInbound(listener)}
+			ctx := metadata.NewIncomingContext(context.Background(), md)
+
+			_, err = h.getBasicTransportRequest(ctx, "/service/proc")
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "code:invalid-argument")
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
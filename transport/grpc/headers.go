@@ -62,6 +62,14 @@ const (
 	EncodingHeader = "rpc-encoding"
 	// ErrorNameHeader is the header key for the error name.
 	ErrorNameHeader = "rpc-error-name"
+	// ErrorDetailsHeader is the header key for the serialized details and
+	// cause of an error.
+	//
+	// The details are not sent as google.rpc.Status details because they are
+	// not protobuf messages, and a header in the same format as the HTTP and
+	// TChannel transports lets proxies forward errors between transports
+	// unchanged.
+	ErrorDetailsHeader = "rpc-error-details"
 	// ApplicationErrorHeader is the header key that will contain a non-empty value
 	// if there was an application error.
 	ApplicationErrorHeader = "rpc-application-error"
@@ -89,6 +97,12 @@ func isReserved(header string) bool {
 // from the Request into a new MD.
 func transportRequestToMetadata(request *transport.Request) (metadata.MD, error) {
 	md := metadata.New(nil)
+	return md, addRequestMetadata(md, request)
+}
+
+// addRequestMetadata adds all reserved and application headers from the
+// Request to the MD.
+func addRequestMetadata(md metadata.MD, request *transport.Request) error {
 	if err := multierr.Combine(
 		addToMetadata(md, CallerHeader, request.Caller),
 		addToMetadata(md, ServiceHeader, request.Service),
@@ -97,17 +111,21 @@ func transportRequestToMetadata(request *transport.Request) (metadata.MD, error)
 		addToMetadata(md, RoutingDelegateHeader, request.RoutingDelegate),
 		addToMetadata(md, EncodingHeader, string(request.Encoding)),
 	); err != nil {
-		return md, err
+		return err
 	}
-	return md, addApplicationHeaders(md, request.Headers)
+	return addApplicationHeaders(md, request.Headers)
 }
 
 // metadataToTransportRequest will populate the Request with all reserved and application
 // headers into a new Request, only not setting the Body field.
-func metadataToTransportRequest(md metadata.MD) (*transport.Request, error) {
+//
+// subtypeEncodings maps content-subtypes to the encodings they stand for
+// when the rpc-encoding header is absent.
+func metadataToTransportRequest(md metadata.MD, subtypeEncodings map[string]transport.Encoding) (*transport.Request, error) {
 	request := &transport.Request{
 		Headers: transport.NewHeadersWithCapacity(md.Len()),
 	}
+	var contentSubtype string
 	for header, values := range md {
 		var value string
 		switch len(values) {
@@ -133,15 +151,21 @@ func metadataToTransportRequest(md metadata.MD) (*transport.Request, error) {
 		case EncodingHeader:
 			request.Encoding = transport.Encoding(value)
 		case contentTypeHeader:
-			// if request.Encoding was set, do not parse content-type
-			// this results in EncodingHeader overriding content-type
-			if request.Encoding == "" {
-				request.Encoding = transport.Encoding(getContentSubtype(value))
-			}
+			contentSubtype = getContentSubtype(value)
+		case ChunkSizeHeader:
+			// Read by the handler.
 		default:
 			request.Headers = request.Headers.With(header, value)
 		}
 	}
+	// EncodingHeader overrides content-type
+	if request.Encoding == "" && contentSubtype != "" {
+		if encoding, ok := subtypeEncodings[contentSubtype]; ok {
+			request.Encoding = encoding
+		} else {
+			request.Encoding = transport.Encoding(contentSubtype)
+		}
+	}
 	return request, nil
 }
 
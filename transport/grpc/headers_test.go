@@ -35,6 +35,7 @@ func TestMetadataToTransportRequest(t *testing.T) {
 	tests := []struct {
 		Name             string
 		MD               metadata.MD
+		SubtypeEncodings map[string]transport.Encoding
 		TransportRequest *transport.Request
 		Error            error
 	}{
@@ -114,10 +115,26 @@ func TestMetadataToTransportRequest(t *testing.T) {
 				}),
 			},
 		},
+		{
+			Name: "Content-subtype mapped to encoding",
+			MD: metadata.Pairs(
+				CallerHeader, "example-caller",
+				ServiceHeader, "example-service",
+				contentTypeHeader, "application/grpc+x-protobuf",
+				"foo", "bar",
+			),
+			SubtypeEncodings: map[string]transport.Encoding{"x-protobuf": "proto"},
+			TransportRequest: &transport.Request{
+				Caller:   "example-caller",
+				Service:  "example-service",
+				Encoding: "proto",
+				Headers:  transport.NewHeaders().With("foo", "bar"),
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.Name, func(t *testing.T) {
-			transportRequest, err := metadataToTransportRequest(tt.MD)
+			transportRequest, err := metadataToTransportRequest(tt.MD, tt.SubtypeEncodings)
 			require.Equal(t, tt.Error, err)
 			require.Equal(t, tt.TransportRequest, transportRequest)
 		})
@@ -21,6 +21,7 @@
 package grpc
 
 import (
+	"crypto/tls"
 	"net"
 	"sync"
 
@@ -29,6 +30,8 @@ import (
 	"go.uber.org/yarpc/yarpcerrors"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
 )
 
 var (
@@ -109,12 +112,37 @@ func (i *Inbound) start() error {
 
 	handler := newHandler(i)
 
-	server := grpc.NewServer(
+	serverOptions := []grpc.ServerOption{
 		grpc.CustomCodec(customCodec{}),
 		grpc.UnknownServiceHandler(handler.handle),
 		grpc.MaxRecvMsgSize(i.t.options.serverMaxRecvMsgSize),
 		grpc.MaxSendMsgSize(i.t.options.serverMaxSendMsgSize),
-	)
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionIdle: i.t.options.serverMaxConnectionIdle,
+			Time:              i.t.options.serverKeepaliveTime,
+			Timeout:           i.t.options.serverKeepaliveTimeout,
+		}),
+	}
+	if i.t.options.serverInitialWindowSize > 0 {
+		serverOptions = append(serverOptions, grpc.InitialWindowSize(i.t.options.serverInitialWindowSize))
+	}
+	if i.t.options.serverInitialConnWindowSize > 0 {
+		serverOptions = append(serverOptions, grpc.InitialConnWindowSize(i.t.options.serverInitialConnWindowSize))
+	}
+	if i.t.options.serverKeepaliveMinTime > 0 {
+		serverOptions = append(serverOptions, grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime: i.t.options.serverKeepaliveMinTime,
+		}))
+	}
+	if i.t.options.serverTLSConfig != nil {
+		var creds credentials.TransportCredentials = credentials.NewTLS(withH2(i.t.options.serverTLSConfig))
+		if i.t.connReporter != nil {
+			creds = reportingCredentials{creds}
+		}
+		serverOptions = append(serverOptions, grpc.Creds(creds))
+	}
+	listener := i.t.connReporter.Listener(i.listener, i.t.options.serverTLSConfig != nil)
+	server := grpc.NewServer(serverOptions...)
 
 	go func() {
 		i.t.options.logger.Info("started GRPC inbound", zap.Stringer("address", i.listener.Addr()))
@@ -131,7 +159,7 @@ func (i *Inbound) start() error {
 		//
 		// TODO Server always returns a non-nil error but should
 		// we do something with some or all errors?
-		_ = server.Serve(i.listener)
+		_ = server.Serve(listener)
 	}()
 	i.server = server
 	return nil
@@ -148,3 +176,29 @@ func (i *Inbound) stop() error {
 }
 
 type noopGrpcStruct struct{}
+
+// withH2 returns a copy of the given configuration whose GetConfigForClient,
+// if any, returns configurations that negotiate HTTP/2 with ALPN, as
+// credentials.NewTLS only does so for the configuration it is given.
+func withH2(config *tls.Config) *tls.Config {
+	if config.GetConfigForClient == nil {
+		return config
+	}
+	getConfigForClient := config.GetConfigForClient
+	config = config.Clone()
+	config.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		c, err := getConfigForClient(hello)
+		if c == nil || err != nil {
+			return c, err
+		}
+		for _, p := range c.NextProtos {
+			if p == "h2" {
+				return c, nil
+			}
+		}
+		c = c.Clone()
+		c.NextProtos = append(append([]string(nil), c.NextProtos...), "h2")
+		return c, nil
+	}
+	return config
+}
@@ -21,6 +21,7 @@
 package grpc
 
 import (
+	"crypto/tls"
 	"net"
 	"testing"
 
@@ -45,3 +46,19 @@ func TestInboundMechanics(t *testing.T) {
 	assert.NoError(t, inbound.Stop())
 	assert.Nil(t, inbound.Addr())
 }
+
+func TestWithH2(t *testing.T) {
+	plain := &tls.Config{}
+	assert.True(t, plain == withH2(plain), "configurations without GetConfigForClient should be used as-is")
+
+	perClient := &tls.Config{NextProtos: []string{"http/1.1"}}
+	config := withH2(&tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return perClient, nil
+		},
+	})
+	c, err := config.GetConfigForClient(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http/1.1", "h2"}, c.NextProtos)
+	assert.Equal(t, []string{"http/1.1"}, perClient.NextProtos, "configuration must not change")
+}
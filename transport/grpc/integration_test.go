@@ -24,15 +24,18 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io/ioutil"
 	"math"
 	"net"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/multierr"
+	apiencoding "go.uber.org/yarpc/api/encoding"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/encoding/protobuf"
 	"go.uber.org/yarpc/internal/clientconfig"
@@ -45,6 +48,7 @@ import (
 	"go.uber.org/yarpc/yarpcerrors"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	_ "google.golang.org/grpc/encoding/gzip"
 	"google.golang.org/grpc/status"
 )
 
@@ -99,6 +103,140 @@ func TestYARPCNamedErrorNoMessage(t *testing.T) {
 	})
 }
 
+func TestYARPCErrorWithDetails(t *testing.T) {
+	t.Parallel()
+	doWithTestEnv(t, nil, nil, nil, func(t *testing.T, e *testEnv) {
+		giveErr := yarpcerrors.Newf(yarpcerrors.CodeNotFound, "baz 1").
+			WithDetails(yarpcerrors.StringDetail("key", "foo"), yarpcerrors.IntDetail("attempts", 3)).
+			WithCause(yarpcerrors.Newf(yarpcerrors.CodeUnavailable, "storage down"))
+		e.KeyValueYARPCServer.SetNextError(giveErr)
+		err := e.SetValueYARPC(context.Background(), "foo", "bar")
+		assert.Equal(t, giveErr, err)
+	})
+}
+
+func TestStreamResponseHeadersAndOutboundInfo(t *testing.T) {
+	tr := NewTransport()
+	require.NoError(t, tr.Start())
+	defer tr.Stop()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	inbound := tr.NewInbound(listener)
+	inbound.SetRouter(newTestRouter([]transport.Procedure{
+		{
+			Name:    "stream",
+			Service: "test",
+			HandlerSpec: transport.NewStreamHandlerSpec(streamHandlerFunc(func(s *transport.ServerStream) error {
+				if err := s.SendHeaders(transport.NewHeaders().With("foo", "bar")); err != nil {
+					return err
+				}
+				return s.SendMessage(context.Background(), &transport.StreamMessage{
+					Body: ioutil.NopCloser(bytes.NewReader([]byte("hello"))),
+				})
+			})),
+		},
+	}))
+	require.NoError(t, inbound.Start())
+	defer inbound.Stop()
+
+	outbound := tr.NewSingleOutbound(listener.Addr().String())
+	require.NoError(t, outbound.Start())
+	defer outbound.Stop()
+
+	var (
+		resHeaders map[string]string
+		info       transport.OutboundCallInfo
+	)
+	call, err := apiencoding.NewStreamOutboundCall(
+		apiencoding.ResponseHeaders(&resHeaders),
+		apiencoding.OutboundInfo(&info),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+	defer cancel()
+	reqMeta := &transport.RequestMeta{
+		Caller:    "caller",
+		Service:   "test",
+		Procedure: "stream",
+		Encoding:  "raw",
+	}
+	ctx, err = call.WriteToRequestMeta(ctx, reqMeta)
+	require.NoError(t, err)
+	stream, err := outbound.CallStream(ctx, &transport.StreamRequest{Meta: reqMeta})
+	require.NoError(t, err)
+
+	msg, err := stream.ReceiveMessage(ctx)
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(msg.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(body))
+
+	headers, err := stream.Headers()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"foo": "bar"}, headers.Items())
+
+	_, err = call.ReadFromStream(ctx, stream)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"foo": "bar"}, resHeaders)
+	assert.Equal(t, listener.Addr().String(), info.Peer)
+	assert.Equal(t, 1, info.Attempts)
+	assert.NoError(t, stream.Close(ctx))
+}
+
+func TestStreamIdleTimeout(t *testing.T) {
+	tr := NewTransport(StreamIdleTimeout(50 * time.Millisecond))
+	require.NoError(t, tr.Start())
+	defer tr.Stop()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	inbound := tr.NewInbound(listener)
+	inbound.SetRouter(newTestRouter([]transport.Procedure{
+		{
+			Name:    "stream",
+			Service: "test",
+			HandlerSpec: transport.NewStreamHandlerSpec(streamHandlerFunc(func(s *transport.ServerStream) error {
+				// Never send anything; wait for the client to go away.
+				<-s.Context().Done()
+				return nil
+			})),
+		},
+	}))
+	require.NoError(t, inbound.Start())
+	defer inbound.Stop()
+
+	outbound := tr.NewSingleOutbound(listener.Addr().String())
+	require.NoError(t, outbound.Start())
+	defer outbound.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+	defer cancel()
+	stream, err := outbound.CallStream(ctx, &transport.StreamRequest{
+		Meta: &transport.RequestMeta{
+			Caller:    "caller",
+			Service:   "test",
+			Procedure: "stream",
+			Encoding:  "raw",
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = stream.ReceiveMessage(ctx)
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeDeadlineExceeded, yarpcerrors.FromError(err).Code())
+	assert.Contains(t, err.Error(), "stream was idle")
+}
+
+type streamHandlerFunc func(*transport.ServerStream) error
+
+func (f streamHandlerFunc) HandleStream(s *transport.ServerStream) error {
+	return f(s)
+}
+
 func TestGRPCWellKnownError(t *testing.T) {
 	t.Parallel()
 	doWithTestEnv(t, nil, nil, nil, func(t *testing.T, e *testEnv) {
@@ -170,6 +308,57 @@ func TestYARPCMaxMsgSize(t *testing.T) {
 	})
 }
 
+func TestYARPCChunking(t *testing.T) {
+	t.Parallel()
+	value := strings.Repeat("a", defaultServerMaxRecvMsgSize*2)
+	chunkSize := ChunkSize(defaultServerMaxRecvMsgSize / 2)
+	// Responses to requests that are small enough to be sent whole are not
+	// chunked either, so the client must accept large messages to read the
+	// value back.
+	largeResponses := []TransportOption{
+		ClientMaxRecvMsgSize(math.MaxInt32),
+		ServerMaxSendMsgSize(math.MaxInt32),
+	}
+
+	doWithTestEnv(t, largeResponses, []InboundOption{MaxChunkedRequestSize(len(value) * 2)}, []OutboundOption{chunkSize}, func(t *testing.T, e *testEnv) {
+		assert.NoError(t, e.SetValueYARPC(context.Background(), "foo", value))
+		getValue, err := e.GetValueYARPC(context.Background(), "foo")
+		assert.NoError(t, err)
+		assert.Equal(t, value, getValue)
+
+		_, err = e.GetValueYARPC(context.Background(), "bar")
+		assert.Error(t, err, "errors must be propagated")
+	})
+
+	t.Run("not accepted by default", func(t *testing.T) {
+		doWithTestEnv(t, nil, nil, []OutboundOption{chunkSize}, func(t *testing.T, e *testEnv) {
+			err := e.SetValueYARPC(context.Background(), "foo", value)
+			assert.Equal(t, yarpcerrors.CodeUnimplemented, yarpcerrors.FromError(err).Code())
+
+			// Requests below the chunk size are sent whole.
+			assert.NoError(t, e.SetValueYARPC(context.Background(), "foo", "bar"))
+		})
+	})
+
+	t.Run("larger than the limit", func(t *testing.T) {
+		doWithTestEnv(t, nil, []InboundOption{MaxChunkedRequestSize(len(value) / 2)}, []OutboundOption{chunkSize}, func(t *testing.T, e *testEnv) {
+			err := e.SetValueYARPC(context.Background(), "foo", value)
+			assert.Equal(t, yarpcerrors.CodeResourceExhausted, yarpcerrors.FromError(err).Code())
+		})
+	})
+}
+
+func TestYARPCCompression(t *testing.T) {
+	t.Parallel()
+	value := strings.Repeat("a", 32768)
+	doWithTestEnv(t, nil, nil, []OutboundOption{Compressor("gzip")}, func(t *testing.T, e *testEnv) {
+		assert.NoError(t, e.SetValueYARPC(context.Background(), "foo", value))
+		getValue, err := e.GetValueYARPC(context.Background(), "foo")
+		assert.NoError(t, err)
+		assert.Equal(t, value, getValue)
+	})
+}
+
 func TestLargeEcho(t *testing.T) {
 	t.Parallel()
 	value := strings.Repeat("a", 32768)
@@ -181,6 +370,25 @@ func TestLargeEcho(t *testing.T) {
 	})
 }
 
+func TestLargeEchoWithFlowControlOptions(t *testing.T) {
+	t.Parallel()
+	value := strings.Repeat("a", 8*1024*1024)
+	transportOptions := []TransportOption{
+		ServerMaxRecvMsgSize(16 * 1024 * 1024),
+		ClientMaxRecvMsgSize(16 * 1024 * 1024),
+		ServerInitialWindowSize(1024 * 1024),
+		ServerInitialConnWindowSize(4 * 1024 * 1024),
+		ClientInitialWindowSize(1024 * 1024),
+		ClientInitialConnWindowSize(4 * 1024 * 1024),
+	}
+	doWithTestEnv(t, transportOptions, nil, nil, func(t *testing.T, e *testEnv) {
+		assert.NoError(t, e.SetValueYARPC(context.Background(), "foo", value))
+		getValue, err := e.GetValueYARPC(context.Background(), "foo")
+		assert.NoError(t, err)
+		assert.Equal(t, value, getValue)
+	})
+}
+
 func TestApplicationErrorPropagation(t *testing.T) {
 	t.Parallel()
 	doWithTestEnv(t, nil, nil, nil, func(t *testing.T, e *testEnv) {
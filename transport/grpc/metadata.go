@@ -0,0 +1,67 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package grpc
+
+import (
+	"context"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+	"google.golang.org/grpc/metadata"
+)
+
+type outgoingMetadataKey struct{}
+
+// WithMetadata returns a copy of the context that carries raw gRPC metadata
+// for outbound calls made with it. The metadata is sent alongside the YARPC
+// request headers, without the restrictions on application headers: keys
+// may have multiple values, and binary keys ending in "-bin" are allowed.
+// This is useful to call non-YARPC gRPC servers that require specific
+// metadata.
+//
+// Keys reserved by YARPC, which start with "rpc-", may not be used. Metadata
+// attached to a context that already has some is merged with it.
+//
+// Handlers behind gRPC inbounds can read the raw metadata of the requests
+// they receive with metadata.FromIncomingContext.
+func WithMetadata(ctx context.Context, md metadata.MD) context.Context {
+	if existing, ok := ctx.Value(outgoingMetadataKey{}).(metadata.MD); ok {
+		md = metadata.Join(existing, md)
+	}
+	return context.WithValue(ctx, outgoingMetadataKey{}, md)
+}
+
+// addOutgoingMetadata adds the metadata attached to ctx with WithMetadata to
+// md.
+func addOutgoingMetadata(ctx context.Context, md metadata.MD) error {
+	extra, ok := ctx.Value(outgoingMetadataKey{}).(metadata.MD)
+	if !ok {
+		return nil
+	}
+	for key, values := range extra {
+		key = transport.CanonicalizeHeaderKey(key)
+		if isReserved(key) {
+			return yarpcerrors.InvalidArgumentErrorf("cannot use reserved header in gRPC metadata: %s", key)
+		}
+		md[key] = append(md[key], values...)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// newMetadataServer starts a gRPC server that is not YARPC-aware, which
+// sends the metadata of every request it receives to the returned channel.
+func newMetadataServer(t *testing.T) (addr string, requests <-chan metadata.MD, stop func()) {
+	mds := make(chan metadata.MD, 1)
+	server := grpc.NewServer(
+		grpc.CustomCodec(customCodec{}),
+		grpc.UnknownServiceHandler(func(srv interface{}, stream grpc.ServerStream) error {
+			md, _ := metadata.FromIncomingContext(stream.Context())
+			mds <- md
+			var body []byte
+			if err := stream.RecvMsg(&body); err != nil {
+				return err
+			}
+			return stream.SendMsg(body)
+		}),
+	)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(listener)
+	return listener.Addr().String(), mds, server.Stop
+}
+
+func TestOutboundMetadataAndContentSubtype(t *testing.T) {
+	addr, requests, stop := newMetadataServer(t)
+	defer stop()
+
+	grpcTransport := NewTransport(ContentSubtype("json", "json"))
+	out := grpcTransport.NewSingleOutbound(addr)
+	require.NoError(t, grpcTransport.Start())
+	defer grpcTransport.Stop()
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ctx = WithMetadata(ctx, metadata.Pairs("x-api-key", "secret", "x-multi", "a"))
+	ctx = WithMetadata(ctx, metadata.Pairs("x-multi", "b", "x-trace-bin", "\x00\x01"))
+
+	_, err := out.Call(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Procedure: "Foo::Bar",
+		Encoding:  "json",
+		Headers:   transport.NewHeaders().With("foo", "bar"),
+		Body:      bytes.NewBufferString("{}"),
+	})
+	require.NoError(t, err)
+
+	md := <-requests
+	assert.Equal(t, []string{"application/grpc+json"}, md[contentTypeHeader])
+	assert.Equal(t, []string{"secret"}, md["x-api-key"])
+	assert.Equal(t, []string{"a", "b"}, md["x-multi"])
+	assert.Equal(t, []string{"\x00\x01"}, md["x-trace-bin"])
+	assert.Equal(t, []string{"bar"}, md["foo"], "application headers must still be sent")
+}
+
+func TestOutboundDefaultContentType(t *testing.T) {
+	addr, requests, stop := newMetadataServer(t)
+	defer stop()
+
+	grpcTransport := NewTransport(ContentSubtype("json", "json"))
+	out := grpcTransport.NewSingleOutbound(addr)
+	require.NoError(t, grpcTransport.Start())
+	defer grpcTransport.Stop()
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := out.Call(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Procedure: "Foo::Bar",
+		Encoding:  "raw",
+		Body:      bytes.NewBufferString("hello"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{baseContentType}, (<-requests)[contentTypeHeader])
+}
+
+func TestOutboundNativeInterop(t *testing.T) {
+	addr, requests, stop := newMetadataServer(t)
+	defer stop()
+
+	grpcTransport := NewTransport()
+	out := grpcTransport.NewSingleOutbound(addr, NativeInterop())
+	require.NoError(t, grpcTransport.Start())
+	defer grpcTransport.Stop()
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ctx = WithMetadata(ctx, metadata.Pairs("x-api-key", "secret"))
+
+	res, err := out.Call(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Procedure: "foo.Bar::Baz",
+		Encoding:  "proto",
+		ShardKey:  "shard",
+		Headers:   transport.NewHeaders().With("foo", "bar"),
+		Body:      bytes.NewBufferString("hello"),
+	})
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(body))
+
+	md := <-requests
+	for key := range md {
+		assert.False(t, isReserved(key), "unexpected YARPC header %q", key)
+	}
+	assert.Equal(t, []string{baseContentType}, md[contentTypeHeader])
+	assert.Equal(t, []string{"secret"}, md["x-api-key"])
+	assert.Equal(t, []string{"bar"}, md["foo"])
+}
+
+func TestOutboundReservedMetadata(t *testing.T) {
+	grpcTransport := NewTransport()
+	out := grpcTransport.NewSingleOutbound("127.0.0.1:0")
+	require.NoError(t, grpcTransport.Start())
+	defer grpcTransport.Stop()
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ctx = WithMetadata(ctx, metadata.Pairs(CallerHeader, "impostor"))
+
+	_, err := out.Call(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Procedure: "Foo::Bar",
+		Body:      bytes.NewBufferString("hello"),
+	})
+	assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.FromError(err).Code())
+}
+
+func TestContentSubtypeOption(t *testing.T) {
+	options := newTransportOptions([]TransportOption{
+		ContentSubtype("proto", "x-protobuf"),
+		ContentSubtype("json", "json"),
+	})
+	assert.Equal(t, map[transport.Encoding]string{
+		"proto": "x-protobuf",
+		"json":  "json",
+	}, options.contentSubtypes)
+	assert.Equal(t, map[string]transport.Encoding{
+		"x-protobuf": "proto",
+		"json":       "json",
+	}, options.subtypeEncodings)
+}
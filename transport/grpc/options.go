@@ -21,12 +21,17 @@
 package grpc
 
 import (
+	"crypto/tls"
 	"math"
+	"time"
 
 	"github.com/opentracing/opentracing-go"
 	"go.uber.org/yarpc/api/backoff"
+	"go.uber.org/yarpc/api/transport"
 	intbackoff "go.uber.org/yarpc/internal/backoff"
+	"go.uber.org/yarpc/internal/headerlimit"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 )
 
 const (
@@ -37,6 +42,9 @@ const (
 	defaultServerMaxSendMsgSize = math.MaxInt32
 	defaultClientMaxRecvMsgSize = 1024 * 1024 * 4
 	defaultClientMaxSendMsgSize = math.MaxInt32
+
+	defaultClientMaxConnectionsPerPeer = 8
+	defaultClientMaxConnectionIdle     = time.Minute
 )
 
 // Option is an interface shared by TransportOption, InboundOption, and OutboundOption
@@ -92,6 +100,30 @@ func ServerMaxRecvMsgSize(serverMaxRecvMsgSize int) TransportOption {
 	}
 }
 
+// ServerMaxRequestHeaderBytes limits the total size of the names and values
+// of the metadata of requests accepted by inbounds, including the metadata
+// that carries tracing context. Requests with larger metadata are rejected
+// with an InvalidArgument error before they are routed.
+//
+// The default is unlimited, other than by the HTTP/2 limits of gRPC.
+func ServerMaxRequestHeaderBytes(bytes int) TransportOption {
+	return func(transportOptions *transportOptions) {
+		transportOptions.serverHeaderLimits.MaxBytes = bytes
+	}
+}
+
+// ServerMaxRequestHeaders limits the number of metadata values of requests
+// accepted by inbounds, including the metadata that carries tracing
+// context. Requests with more values are rejected with an InvalidArgument
+// error before they are routed.
+//
+// The default is unlimited.
+func ServerMaxRequestHeaders(count int) TransportOption {
+	return func(transportOptions *transportOptions) {
+		transportOptions.serverHeaderLimits.MaxCount = count
+	}
+}
+
 // ServerMaxSendMsgSize is the maximum message size the server can send.
 //
 // The default is unlimited.
@@ -119,6 +151,48 @@ func ClientMaxSendMsgSize(clientMaxSendMsgSize int) TransportOption {
 	}
 }
 
+// ServerInitialWindowSize is the initial flow-control window size, in bytes,
+// for each stream accepted by the server.
+//
+// Values smaller than 64KB are ignored and the gRPC default of 64KB is used.
+func ServerInitialWindowSize(serverInitialWindowSize int32) TransportOption {
+	return func(transportOptions *transportOptions) {
+		transportOptions.serverInitialWindowSize = serverInitialWindowSize
+	}
+}
+
+// ServerInitialConnWindowSize is the initial flow-control window size, in
+// bytes, for each connection accepted by the server. The connection window
+// is shared by all streams on the connection.
+//
+// Values smaller than 64KB are ignored and the gRPC default of 64KB is used.
+func ServerInitialConnWindowSize(serverInitialConnWindowSize int32) TransportOption {
+	return func(transportOptions *transportOptions) {
+		transportOptions.serverInitialConnWindowSize = serverInitialConnWindowSize
+	}
+}
+
+// ClientInitialWindowSize is the initial flow-control window size, in bytes,
+// for each stream opened by the client.
+//
+// Values smaller than 64KB are ignored and the gRPC default of 64KB is used.
+func ClientInitialWindowSize(clientInitialWindowSize int32) TransportOption {
+	return func(transportOptions *transportOptions) {
+		transportOptions.clientInitialWindowSize = clientInitialWindowSize
+	}
+}
+
+// ClientInitialConnWindowSize is the initial flow-control window size, in
+// bytes, for each connection opened by the client. The connection window is
+// shared by all streams on the connection.
+//
+// Values smaller than 64KB are ignored and the gRPC default of 64KB is used.
+func ClientInitialConnWindowSize(clientInitialConnWindowSize int32) TransportOption {
+	return func(transportOptions *transportOptions) {
+		transportOptions.clientInitialConnWindowSize = clientInitialConnWindowSize
+	}
+}
+
 // ClientTLS says to use TLS with the system certificate pool on the client side.
 //
 // The default is to not use TLS.
@@ -128,16 +202,287 @@ func ClientTLS() TransportOption {
 	}
 }
 
+// ClientTLSConfig says to use TLS with the given configuration on the client
+// side. It takes precedence over ClientTLS.
+func ClientTLSConfig(config *tls.Config) TransportOption {
+	return func(transportOptions *transportOptions) {
+		transportOptions.clientTLSConfig = config
+	}
+}
+
+// ServerTLSConfig says to accept only TLS connections, using the given
+// configuration, on the server side.
+//
+// The default is to not use TLS.
+func ServerTLSConfig(config *tls.Config) TransportOption {
+	return func(transportOptions *transportOptions) {
+		transportOptions.serverTLSConfig = config
+	}
+}
+
+// ClientKeepaliveTime is the duration of inactivity after which the client
+// pings the server to check that the connection is still alive. Connections
+// to servers that fail to respond within ClientKeepaliveTimeout are closed
+// and their streams fail.
+//
+// Servers reject pings that are more frequent than their enforcement policy
+// allows (5 minutes by default for gRPC servers, see ServerKeepaliveMinTime).
+//
+// The default is to not send keepalive pings.
+func ClientKeepaliveTime(clientKeepaliveTime time.Duration) TransportOption {
+	return func(transportOptions *transportOptions) {
+		transportOptions.clientKeepaliveTime = clientKeepaliveTime
+	}
+}
+
+// ClientKeepaliveTimeout is how long the client waits for a response to a
+// keepalive ping before closing the connection.
+//
+// The default is 20s.
+func ClientKeepaliveTimeout(clientKeepaliveTimeout time.Duration) TransportOption {
+	return func(transportOptions *transportOptions) {
+		transportOptions.clientKeepaliveTimeout = clientKeepaliveTimeout
+	}
+}
+
+// ServerKeepaliveTime is the duration of inactivity after which the server
+// pings the client to check that the connection is still alive. Connections
+// to clients that fail to respond within ServerKeepaliveTimeout are closed
+// and their streams fail.
+//
+// The default is 2h.
+func ServerKeepaliveTime(serverKeepaliveTime time.Duration) TransportOption {
+	return func(transportOptions *transportOptions) {
+		transportOptions.serverKeepaliveTime = serverKeepaliveTime
+	}
+}
+
+// ServerKeepaliveTimeout is how long the server waits for a response to a
+// keepalive ping before closing the connection.
+//
+// The default is 20s.
+func ServerKeepaliveTimeout(serverKeepaliveTimeout time.Duration) TransportOption {
+	return func(transportOptions *transportOptions) {
+		transportOptions.serverKeepaliveTimeout = serverKeepaliveTimeout
+	}
+}
+
+// ServerKeepaliveMinTime is the minimum interval between keepalive pings the
+// server accepts from clients. Clients that ping more often have their
+// connections closed.
+//
+// The default is 5m.
+func ServerKeepaliveMinTime(serverKeepaliveMinTime time.Duration) TransportOption {
+	return func(transportOptions *transportOptions) {
+		transportOptions.serverKeepaliveMinTime = serverKeepaliveMinTime
+	}
+}
+
+// ServerMaxConnectionIdle is the duration after which the server closes
+// connections that have had no active streams.
+//
+// The default is infinity.
+func ServerMaxConnectionIdle(serverMaxConnectionIdle time.Duration) TransportOption {
+	return func(transportOptions *transportOptions) {
+		transportOptions.serverMaxConnectionIdle = serverMaxConnectionIdle
+	}
+}
+
+// StreamIdleTimeout is the duration after which client streams that have not
+// sent or received a message are canceled. Subsequent operations on such
+// streams fail with a DeadlineExceeded error.
+//
+// The default is to never time out idle streams.
+func StreamIdleTimeout(streamIdleTimeout time.Duration) TransportOption {
+	return func(transportOptions *transportOptions) {
+		transportOptions.streamIdleTimeout = streamIdleTimeout
+	}
+}
+
+// WarmConnections specifies the number of connections the transport
+// establishes to each retained peer. Connections are dialed as soon as the
+// peer is retained rather than on the first request, and requests to the
+// peer are spread across them round-robin. Connections that break are
+// re-established.
+//
+// The default is a single connection per peer.
+func WarmConnections(warmConnections int) TransportOption {
+	return func(transportOptions *transportOptions) {
+		transportOptions.warmConnections = warmConnections
+	}
+}
+
+// ClientMaxConcurrentStreams specifies the number of pending requests and
+// open streams per connection beyond which the transport opens another
+// connection to the peer. A single HTTP/2 connection limits the throughput
+// to a peer, so services that send many concurrent requests to few peers
+// may need several.
+//
+// Requests are sent on the connection with the fewest pending requests.
+// Additional connections are closed once they have been idle for
+// ClientMaxConnectionIdle; the others are kept open until the peer is
+// released. Streams count as pending until they end, not when the client
+// closes its side.
+//
+// The default is to use a single connection per peer, or the number of
+// WarmConnections.
+func ClientMaxConcurrentStreams(clientMaxConcurrentStreams int) TransportOption {
+	return func(transportOptions *transportOptions) {
+		transportOptions.clientMaxConcurrentStreams = clientMaxConcurrentStreams
+	}
+}
+
+// ClientMaxConnectionsPerPeer specifies the maximum number of connections
+// the transport opens to a peer when ClientMaxConcurrentStreams is exceeded.
+//
+// The default is 8.
+func ClientMaxConnectionsPerPeer(clientMaxConnectionsPerPeer int) TransportOption {
+	return func(transportOptions *transportOptions) {
+		transportOptions.clientMaxConnectionsPerPeer = clientMaxConnectionsPerPeer
+	}
+}
+
+// ClientMaxConnectionIdle specifies how long a connection opened beyond
+// WarmConnections because of ClientMaxConcurrentStreams may have no pending
+// requests before the transport closes it. Idle connections are closed when
+// the next request is sent to the peer. A duration of zero or less keeps
+// connections open until the peer is released.
+//
+// The default is 1 minute.
+func ClientMaxConnectionIdle(clientMaxConnectionIdle time.Duration) TransportOption {
+	return func(transportOptions *transportOptions) {
+		transportOptions.clientMaxConnectionIdle = clientMaxConnectionIdle
+	}
+}
+
+// ContentSubtype specifies the gRPC content-subtype of requests with the
+// given encoding, for interoperability with gRPC servers and clients that
+// are not YARPC-aware.
+//
+// Outbound requests with the encoding are sent with the content-type
+// "application/grpc+<subtype>", and inbound requests with that content-type
+// and no rpc-encoding header are given the encoding. YARPC encodings still
+// produce the request bodies; the content-subtype only labels them.
+//
+// For example, to call gRPC servers that only accept JSON,
+//
+// 	grpc.NewTransport(grpc.ContentSubtype(json.Encoding, "json"))
+//
+// By default, outbound requests are sent with the content-type
+// "application/grpc", and inbound content-subtypes are used as the encoding
+// as is.
+func ContentSubtype(encoding transport.Encoding, subtype string) TransportOption {
+	return func(transportOptions *transportOptions) {
+		if transportOptions.contentSubtypes == nil {
+			transportOptions.contentSubtypes = make(map[transport.Encoding]string)
+			transportOptions.subtypeEncodings = make(map[string]transport.Encoding)
+		}
+		transportOptions.contentSubtypes[encoding] = subtype
+		transportOptions.subtypeEncodings[subtype] = encoding
+	}
+}
+
+// ConnectionEvents specifies a hook that is called when the connections of
+// the transport, and of its inbounds, are established, closed, or fail to be
+// established. Connections that use TLS are established once their
+// handshake completes, and their events carry the TLS connection state.
+func ConnectionEvents(hook transport.ConnectionEventHook) TransportOption {
+	return func(transportOptions *transportOptions) {
+		transportOptions.connectionEventHook = hook
+	}
+}
+
+// DNSCache enables a client-side cache of the DNS resolutions of the host
+// names that the transport dials, such as the host of the address of a
+// single outbound. Resolutions are cached for the given TTL.
+//
+// If resolving a host name fails once its resolution expired, the expired
+// addresses are used for up to maxStale longer, so that DNS failures don't
+// take down outbound traffic. Host names are resolved again early when no
+// connection can be established to any of their addresses.
+//
+// This has no effect on outbounds built with NewTargetOutbound, whose
+// targets are resolved by gRPC.
+//
+// By default, host names are resolved on every connection attempt.
+func DNSCache(ttl, maxStale time.Duration) TransportOption {
+	return func(transportOptions *transportOptions) {
+		transportOptions.dnsCacheTTL = ttl
+		transportOptions.dnsCacheMaxStale = maxStale
+	}
+}
+
 // InboundOption is an option for an inbound.
 type InboundOption func(*inboundOptions)
 
 func (InboundOption) grpcOption() {}
 
+// MaxChunkedRequestSize specifies that the inbound accepts unary requests
+// that outbounds with the ChunkSize option send in chunks, and reassembles
+// at most the given number of bytes of each of them. Larger requests fail
+// with ResourceExhausted errors. Responses to chunked requests are sent in
+// chunks as well.
+//
+// Chunked requests are rejected with Unimplemented errors by default.
+func MaxChunkedRequestSize(bytes int) InboundOption {
+	return func(inboundOptions *inboundOptions) {
+		inboundOptions.maxChunkedRequestSize = bytes
+	}
+}
+
 // OutboundOption is an option for an outbound.
 type OutboundOption func(*outboundOptions)
 
 func (OutboundOption) grpcOption() {}
 
+// NativeInterop makes the outbound speak plain gRPC, to call gRPC servers
+// that are not YARPC-aware and reject unknown metadata.
+//
+// The outbound does not send the rpc-caller, rpc-service, rpc-encoding, and
+// other YARPC headers; only application headers, metadata attached with
+// WithMetadata, and tracing headers are sent. Procedure names map to gRPC
+// methods as usual: the procedure "foo.Bar::Baz" calls the method
+// "/foo.Bar/Baz". Requests are sent with the content-type
+// "application/grpc", which gRPC servers treat as Protobuf, unless the
+// ContentSubtype option specifies otherwise.
+//
+// The default is to send YARPC headers.
+func NativeInterop() OutboundOption {
+	return func(outboundOptions *outboundOptions) {
+		outboundOptions.nativeInterop = true
+	}
+}
+
+// TargetDialOptions specifies additional options to dial the target of an
+// outbound created with NewTargetOutbound, such as the load balancing policy
+// gRPC uses across the addresses the target resolves to.
+//
+//   grpcTransport.NewTargetOutbound("dns:///myservice:8080",
+//     grpc.TargetDialOptions(ggrpc.WithBalancerName(roundrobin.Name)))
+//
+// Other outbounds ignore these options.
+func TargetDialOptions(opts ...grpc.DialOption) OutboundOption {
+	return func(outboundOptions *outboundOptions) {
+		outboundOptions.targetDialOptions = append(outboundOptions.targetDialOptions, opts...)
+	}
+}
+
+// Compressor specifies the name of the compressor with which the outbound
+// compresses requests, like "gzip". The compressor must be registered with
+// google.golang.org/grpc/encoding.RegisterCompressor on both ends; the
+// compressors of x/compress register with compress.GRPC.
+//
+//   encoding.RegisterCompressor(compress.GRPC(zstdCompressor))
+//   grpcTransport.NewSingleOutbound(address, grpc.Compressor("zstd"))
+//
+// Servers compress responses with the compressor of the request. By
+// default, requests are not compressed.
+func Compressor(name string) OutboundOption {
+	return func(outboundOptions *outboundOptions) {
+		outboundOptions.compressor = name
+	}
+}
+
 type transportOptions struct {
 	backoffStrategy      backoff.Strategy
 	tracer               opentracing.Tracer
@@ -146,7 +491,36 @@ type transportOptions struct {
 	serverMaxSendMsgSize int
 	clientMaxRecvMsgSize int
 	clientMaxSendMsgSize int
+	serverHeaderLimits   headerlimit.Limits
 	clientTLS            bool
+	clientTLSConfig      *tls.Config
+	serverTLSConfig      *tls.Config
+
+	serverInitialWindowSize     int32
+	serverInitialConnWindowSize int32
+	clientInitialWindowSize     int32
+	clientInitialConnWindowSize int32
+
+	clientKeepaliveTime     time.Duration
+	clientKeepaliveTimeout  time.Duration
+	serverKeepaliveTime     time.Duration
+	serverKeepaliveTimeout  time.Duration
+	serverKeepaliveMinTime  time.Duration
+	serverMaxConnectionIdle time.Duration
+	streamIdleTimeout       time.Duration
+	warmConnections         int
+
+	clientMaxConcurrentStreams  int
+	clientMaxConnectionsPerPeer int
+	clientMaxConnectionIdle     time.Duration
+
+	contentSubtypes  map[transport.Encoding]string
+	subtypeEncodings map[string]transport.Encoding
+
+	connectionEventHook transport.ConnectionEventHook
+
+	dnsCacheTTL      time.Duration
+	dnsCacheMaxStale time.Duration
 }
 
 func newTransportOptions(options []TransportOption) *transportOptions {
@@ -156,6 +530,9 @@ func newTransportOptions(options []TransportOption) *transportOptions {
 		serverMaxSendMsgSize: defaultServerMaxSendMsgSize,
 		clientMaxRecvMsgSize: defaultClientMaxRecvMsgSize,
 		clientMaxSendMsgSize: defaultClientMaxSendMsgSize,
+
+		clientMaxConnectionsPerPeer: defaultClientMaxConnectionsPerPeer,
+		clientMaxConnectionIdle:     defaultClientMaxConnectionIdle,
 	}
 	for _, option := range options {
 		option(transportOptions)
@@ -172,7 +549,9 @@ func newTransportOptions(options []TransportOption) *transportOptions {
 	return transportOptions
 }
 
-type inboundOptions struct{}
+type inboundOptions struct {
+	maxChunkedRequestSize int
+}
 
 func newInboundOptions(options []InboundOption) *inboundOptions {
 	inboundOptions := &inboundOptions{}
@@ -182,7 +561,12 @@ func newInboundOptions(options []InboundOption) *inboundOptions {
 	return inboundOptions
 }
 
-type outboundOptions struct{}
+type outboundOptions struct {
+	nativeInterop     bool
+	targetDialOptions []grpc.DialOption
+	chunkSize         int
+	compressor        string
+}
 
 func newOutboundOptions(options []OutboundOption) *outboundOptions {
 	outboundOptions := &outboundOptions{}
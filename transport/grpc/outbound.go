@@ -23,7 +23,7 @@ package grpc
 import (
 	"bytes"
 	"context"
-	"io/ioutil"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -32,11 +32,13 @@ import (
 	"go.uber.org/yarpc"
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/iopool"
 	intyarpcerrors "go.uber.org/yarpc/internal/yarpcerrors"
 	peerchooser "go.uber.org/yarpc/peer"
 	"go.uber.org/yarpc/peer/hostport"
 	"go.uber.org/yarpc/pkg/lifecycle"
 	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
@@ -55,6 +57,11 @@ type Outbound struct {
 	t           *Transport
 	peerChooser peer.Chooser
 	options     *outboundOptions
+
+	// target, if set, is dialed with the resolvers and balancers registered
+	// with gRPC instead of choosing peers with the peer chooser.
+	target     string
+	targetConn *poolConn
 }
 
 func newSingleOutbound(t *Transport, address string, options ...OutboundOption) *Outbound {
@@ -70,14 +77,46 @@ func newOutbound(t *Transport, peerChooser peer.Chooser, options ...OutboundOpti
 	}
 }
 
+func newTargetOutbound(t *Transport, target string, options ...OutboundOption) *Outbound {
+	return &Outbound{
+		once:    lifecycle.NewOnce(),
+		t:       t,
+		options: newOutboundOptions(options),
+		target:  target,
+	}
+}
+
 // Start implements transport.Lifecycle#Start.
 func (o *Outbound) Start() error {
-	return o.once.Start(o.peerChooser.Start)
+	return o.once.Start(o.start)
+}
+
+func (o *Outbound) start() error {
+	if o.target == "" {
+		return o.peerChooser.Start()
+	}
+	dialOptions := append(o.t.clientDialOptions(), o.options.targetDialOptions...)
+	clientConn, err := grpc.Dial(o.target, dialOptions...)
+	if err != nil {
+		return err
+	}
+	o.targetConn = &poolConn{clientConn: clientConn}
+	return nil
 }
 
 // Stop implements transport.Lifecycle#Stop.
 func (o *Outbound) Stop() error {
-	return o.once.Stop(o.peerChooser.Stop)
+	return o.once.Stop(o.stop)
+}
+
+func (o *Outbound) stop() error {
+	if o.target == "" {
+		return o.peerChooser.Stop()
+	}
+	if o.targetConn == nil {
+		return nil
+	}
+	return o.targetConn.clientConn.Close()
 }
 
 // IsRunning implements transport.Lifecycle#IsRunning.
@@ -90,11 +129,42 @@ func (o *Outbound) Transports() []transport.Transport {
 	return []transport.Transport{o.t}
 }
 
-// Chooser returns the peer.Chooser associated with this Outbound.
+// Chooser returns the peer.Chooser associated with this Outbound, or nil if
+// the Outbound was created with NewTargetOutbound.
 func (o *Outbound) Chooser() peer.Chooser {
 	return o.peerChooser
 }
 
+// choose returns the connection to send a request over, and a function to
+// call with the result of the request. The connection must be released once
+// the request has finished.
+func (o *Outbound) choose(ctx context.Context, req *transport.Request) (*poolConn, func(error), error) {
+	if o.target != "" {
+		// gRPC picks the peer of each request on the connection to the
+		// target.
+		o.targetConn.pending.Inc()
+		return o.targetConn, func(error) {}, nil
+	}
+
+	apiPeer, onFinish, err := o.peerChooser.Choose(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	grpcPeer, ok := apiPeer.(*grpcPeer)
+	if !ok {
+		err := peer.ErrInvalidPeerConversion{
+			Peer:         apiPeer,
+			ExpectedType: "*grpcPeer",
+		}
+		onFinish(err)
+		return nil, nil, err
+	}
+	// gRPC multiplexes calls over the connection it maintains with each peer,
+	// so the connection is reused once the peer is available.
+	transport.RecordOutboundAttempt(ctx, grpcPeer.Identifier(), grpcPeer.Status().ConnectionStatus == peer.Available)
+	return grpcPeer.pool.acquire(), onFinish, nil
+}
+
 // Call implements transport.UnaryOutbound#Call.
 func (o *Outbound) Call(ctx context.Context, request *transport.Request) (*transport.Response, error) {
 	if request == nil {
@@ -114,7 +184,7 @@ func (o *Outbound) Call(ctx context.Context, request *transport.Request) (*trans
 		return nil, err
 	}
 	return &transport.Response{
-		Body:             ioutil.NopCloser(bytes.NewBuffer(responseBody)),
+		Body:             iopool.NewLenReader(bytes.NewReader(responseBody), int64(len(responseBody))),
 		Headers:          responseHeaders,
 		ApplicationError: metadataToIsApplicationError(responseMD),
 	}, invokeErr
@@ -127,12 +197,18 @@ func (o *Outbound) invoke(
 	responseMD *metadata.MD,
 	start time.Time,
 ) (retErr error) {
-	md, err := transportRequestToMetadata(request)
-	if err != nil {
+	// gRPC encodes the metadata before Invoke returns, so the map can be
+	// reused by later calls.
+	md := metadata.MD(iopool.GetHeaderMap())
+	defer iopool.PutHeaderMap(md)
+	if err := o.requestMetadata(md, request); err != nil {
+		return err
+	}
+	if err := addOutgoingMetadata(ctx, md); err != nil {
 		return err
 	}
 
-	bytes, err := ioutil.ReadAll(request.Body)
+	bytes, err := iopool.ReadAll(request.Body)
 	if err != nil {
 		return err
 	}
@@ -142,20 +218,22 @@ func (o *Outbound) invoke(
 	}
 	var callOptions []grpc.CallOption
 	if responseMD != nil {
-		callOptions = []grpc.CallOption{grpc.Trailer(responseMD)}
+		callOptions = append(callOptions, grpc.Trailer(responseMD))
+	}
+	if subtype, ok := o.t.options.contentSubtypes[request.Encoding]; ok {
+		callOptions = append(callOptions, grpc.CallContentSubtype(subtype))
 	}
-	apiPeer, onFinish, err := o.peerChooser.Choose(ctx, request)
+	if o.options.compressor != "" {
+		callOptions = append(callOptions, grpc.UseCompressor(o.options.compressor))
+	}
+	conn, onFinish, err := o.choose(ctx, request)
 	if err != nil {
 		return err
 	}
-	defer func() { onFinish(retErr) }()
-	grpcPeer, ok := apiPeer.(*grpcPeer)
-	if !ok {
-		return peer.ErrInvalidPeerConversion{
-			Peer:         apiPeer,
-			ExpectedType: "*grpcPeer",
-		}
-	}
+	defer func() {
+		conn.release()
+		onFinish(retErr)
+	}()
 
 	tracer := o.t.options.tracer
 	createOpenTracingSpan := &transport.CreateOpenTracingSpan{
@@ -171,18 +249,29 @@ func (o *Outbound) invoke(
 		return err
 	}
 
-	err = transport.UpdateSpanWithErr(
-		span,
-		grpcPeer.clientConn.Invoke(
+	if chunkSize := o.chunkSize(); chunkSize > 0 && len(bytes) > chunkSize {
+		md[ChunkSizeHeader] = []string{strconv.Itoa(chunkSize)}
+		err = invokeChunked(
 			metadata.NewOutgoingContext(ctx, md),
+			conn.clientConn,
 			fullMethod,
 			bytes,
 			responseBody,
+			chunkSize,
 			callOptions...,
-		),
-	)
+		)
+	} else {
+		err = conn.clientConn.Invoke(
+			metadata.NewOutgoingContext(ctx, md),
+			fullMethod,
+			bytes,
+			responseBody,
+			callOptions...,
+		)
+	}
+	err = transport.UpdateSpanWithErr(span, err)
 	if err != nil {
-		return invokeErrorToYARPCError(err, *responseMD)
+		return invokeErrorToYARPCError(err, *responseMD, o.t.options.logger)
 	}
 	// Service name match validation, return yarpcerrors.CodeInternal error if not match
 	if match, resSvcName := checkServiceMatch(request.Service, *responseMD); !match {
@@ -193,6 +282,24 @@ func (o *Outbound) invoke(
 	return nil
 }
 
+// chunkSize returns the size of the chunks in which requests are sent, or
+// zero if they are sent whole.
+func (o *Outbound) chunkSize() int {
+	if o.options.nativeInterop {
+		return 0
+	}
+	return o.options.chunkSize
+}
+
+// requestMetadata adds the metadata to send for the request to md, leaving
+// out the YARPC headers in native interop mode.
+func (o *Outbound) requestMetadata(md metadata.MD, request *transport.Request) error {
+	if !o.options.nativeInterop {
+		return addRequestMetadata(md, request)
+	}
+	return addApplicationHeaders(md, request.Headers)
+}
+
 func metadataToIsApplicationError(responseMD metadata.MD) bool {
 	if responseMD == nil {
 		return false
@@ -201,7 +308,7 @@ func metadataToIsApplicationError(responseMD metadata.MD) bool {
 	return ok && len(value) > 0 && len(value[0]) > 0
 }
 
-func invokeErrorToYARPCError(err error, responseMD metadata.MD) error {
+func invokeErrorToYARPCError(err error, responseMD metadata.MD, logger *zap.Logger) error {
 	if err == nil {
 		return nil
 	}
@@ -217,13 +324,17 @@ func invokeErrorToYARPCError(err error, responseMD metadata.MD) error {
 	if !ok {
 		code = yarpcerrors.CodeUnknown
 	}
-	var name string
+	var name, details string
 	if responseMD != nil {
 		value, ok := responseMD[ErrorNameHeader]
 		// TODO: what to do if the length is > 1?
 		if ok && len(value) == 1 {
 			name = value[0]
 		}
+		value, ok = responseMD[ErrorDetailsHeader]
+		if ok && len(value) == 1 {
+			details = value[0]
+		}
 	}
 	message := status.Message()
 	// we put the name as a prefix for grpc compatibility
@@ -233,7 +344,7 @@ func invokeErrorToYARPCError(err error, responseMD metadata.MD) error {
 	} else if name != "" && message == name {
 		message = ""
 	}
-	return intyarpcerrors.NewWithNamef(code, name, message)
+	return intyarpcerrors.WithDetailsHeader(logger, intyarpcerrors.NewWithNamef(code, name, message), details)
 }
 
 // CallStream implements transport.StreamOutbound#CallStream.
@@ -253,8 +364,11 @@ func (o *Outbound) stream(
 		return nil, yarpcerrors.InvalidArgumentErrorf("stream request requires a request metadata")
 	}
 	treq := req.Meta.ToRequest()
-	md, err := transportRequestToMetadata(treq)
-	if err != nil {
+	md := metadata.New(nil)
+	if err := o.requestMetadata(md, treq); err != nil {
+		return nil, err
+	}
+	if err := addOutgoingMetadata(ctx, md); err != nil {
 		return nil, err
 	}
 
@@ -263,20 +377,12 @@ func (o *Outbound) stream(
 		return nil, err
 	}
 
-	apiPeer, onFinish, err := o.peerChooser.Choose(ctx, treq)
+	conn, onFinish, err := o.choose(ctx, treq)
 	if err != nil {
 		return nil, err
 	}
 	defer func() { onFinish(err) }()
 
-	grpcPeer, ok := apiPeer.(*grpcPeer)
-	if !ok {
-		return nil, peer.ErrInvalidPeerConversion{
-			Peer:         apiPeer,
-			ExpectedType: "*grpcPeer",
-		}
-	}
-
 	tracer := o.t.options.tracer
 	createOpenTracingSpan := &transport.CreateOpenTracingSpan{
 		Tracer:        tracer,
@@ -287,26 +393,44 @@ func (o *Outbound) stream(
 	_, span := createOpenTracingSpan.Do(ctx, treq)
 
 	if err := tracer.Inject(span.Context(), opentracing.HTTPHeaders, mdReadWriter(md)); err != nil {
+		conn.release()
 		span.Finish()
 		return nil, err
 	}
 
 	streamCtx := metadata.NewOutgoingContext(ctx, md)
-	clientStream, err := grpcPeer.clientConn.NewStream(
+	var idle *idleTimer
+	if timeout := o.t.options.streamIdleTimeout; timeout > 0 {
+		var cancel context.CancelFunc
+		streamCtx, cancel = context.WithCancel(streamCtx)
+		idle = newIdleTimer(timeout, cancel)
+	}
+	var callOptions []grpc.CallOption
+	if subtype, ok := o.t.options.contentSubtypes[treq.Encoding]; ok {
+		callOptions = append(callOptions, grpc.CallContentSubtype(subtype))
+	}
+	if o.options.compressor != "" {
+		callOptions = append(callOptions, grpc.UseCompressor(o.options.compressor))
+	}
+	clientStream, err := conn.clientConn.NewStream(
 		streamCtx,
 		&grpc.StreamDesc{
 			ClientStreams: true,
 			ServerStreams: true,
 		},
 		fullMethod,
+		callOptions...,
 	)
 	if err != nil {
+		conn.release()
+		idle.stop()
 		span.Finish()
 		return nil, err
 	}
-	stream := newClientStream(streamCtx, req, clientStream, span)
+	stream := newClientStream(streamCtx, req, clientStream, span, idle, conn.release)
 	tClientStream, err := transport.NewClientStream(stream)
 	if err != nil {
+		stream.end()
 		span.Finish()
 		return nil, err
 	}
@@ -280,3 +280,80 @@ func TestCallServiceMatch(t *testing.T) {
 		})
 	}
 }
+
+func TestTargetOutbound(t *testing.T) {
+	methods := make(chan string, 1)
+	server := grpc.NewServer(
+		grpc.UnknownServiceHandler(func(srv interface{}, stream grpc.ServerStream) error {
+			method, _ := grpc.MethodFromServerStream(stream)
+			methods <- method
+			return stream.SendMsg(&empty.Empty{})
+		}),
+	)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	grpcTransport := NewTransport()
+	out := grpcTransport.NewTargetOutbound("passthrough:///" + listener.Addr().String())
+	assert.Nil(t, out.Chooser())
+	require.NoError(t, grpcTransport.Start())
+	defer grpcTransport.Stop()
+	require.NoError(t, out.Start())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = out.Call(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  "raw",
+		Procedure: "foo.Bar::Baz",
+		Body:      bytes.NewReader([]byte("hello")),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "/foo.Bar/Baz", <-methods)
+	assert.Equal(t, int32(0), out.targetConn.pending.Load(), "connection must be released")
+
+	require.NoError(t, out.Stop())
+	_, err = out.Call(ctx, &transport.Request{Service: "service", Procedure: "foo.Bar::Baz"})
+	assert.Error(t, err, "stopped outbound must not send requests")
+}
+
+func TestDNSCache(t *testing.T) {
+	server := grpc.NewServer(
+		grpc.UnknownServiceHandler(func(srv interface{}, stream grpc.ServerStream) error {
+			return stream.SendMsg(&empty.Empty{})
+		}),
+	)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+
+	grpcTransport := NewTransport(DNSCache(time.Minute, time.Hour))
+	require.NotNil(t, grpcTransport.dnsCache)
+	out := grpcTransport.NewSingleOutbound(net.JoinHostPort("localhost", port))
+	require.NoError(t, grpcTransport.Start())
+	defer grpcTransport.Stop()
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = out.Call(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  "raw",
+		Procedure: "foo.Bar::Baz",
+		Body:      bytes.NewReader([]byte("hello")),
+	})
+	require.NoError(t, err)
+
+	addrs, err := grpcTransport.dnsCache.Resolve(ctx, "localhost")
+	require.NoError(t, err)
+	assert.NotEmpty(t, addrs)
+}
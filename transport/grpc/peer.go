@@ -22,20 +22,26 @@ package grpc
 
 import (
 	"context"
+	"net"
 	"sync"
 
 	"go.uber.org/yarpc/api/peer"
+	intnet "go.uber.org/yarpc/internal/net"
 	"go.uber.org/yarpc/peer/hostport"
 	"go.uber.org/yarpc/yarpcerrors"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
 )
 
 type grpcPeer struct {
 	*hostport.Peer
-	t          *Transport
+	t *Transport
+	// clientConn is the connection whose state determines the status of the
+	// peer. It is the first connection of the pool.
 	clientConn *grpc.ClientConn
+	pool       *connPool
 	stoppingC  chan struct{}
 	stoppedC   chan error
 	lock       sync.Mutex
@@ -45,27 +51,23 @@ type grpcPeer struct {
 }
 
 func newPeer(address string, t *Transport) (*grpcPeer, error) {
-	dialOptions := []grpc.DialOption{
-		grpc.WithUserAgent(UserAgent),
-		grpc.WithDefaultCallOptions(
-			grpc.CallCustomCodec(customCodec{}),
-			grpc.MaxCallRecvMsgSize(t.options.clientMaxRecvMsgSize),
-			grpc.MaxCallSendMsgSize(t.options.clientMaxSendMsgSize),
-		),
+	size := t.options.warmConnections
+	if size < 1 {
+		size = 1
 	}
-	if t.options.clientTLS {
-		dialOptions = append(dialOptions, grpc.WithTransportCredentials(credentials.NewClientTLSFromCert(nil, "")))
-	} else {
-		dialOptions = append(dialOptions, grpc.WithInsecure())
+	maxConns := t.options.clientMaxConnectionsPerPeer
+	if maxConns < size {
+		maxConns = size
 	}
-	clientConn, err := grpc.Dial(address, dialOptions...)
+	pool, err := newConnPool(address, t.clientDialOptions(), size, t.options.clientMaxConcurrentStreams, maxConns, t.options.clientMaxConnectionIdle)
 	if err != nil {
 		return nil, err
 	}
 	grpcPeer := &grpcPeer{
 		Peer:       hostport.NewPeer(hostport.PeerIdentifier(address), t),
 		t:          t,
-		clientConn: clientConn,
+		clientConn: pool.primary(),
+		pool:       pool,
 		stoppingC:  make(chan struct{}, 1),
 		stoppedC:   make(chan error, 1),
 	}
@@ -73,6 +75,52 @@ func newPeer(address string, t *Transport) (*grpcPeer, error) {
 	return grpcPeer, nil
 }
 
+// clientDialOptions returns the options to dial connections with.
+func (t *Transport) clientDialOptions() []grpc.DialOption {
+	dialOptions := []grpc.DialOption{
+		grpc.WithUserAgent(UserAgent),
+		grpc.WithDefaultCallOptions(
+			grpc.CallCustomCodec(customCodec{}),
+			grpc.MaxCallRecvMsgSize(t.options.clientMaxRecvMsgSize),
+			grpc.MaxCallSendMsgSize(t.options.clientMaxSendMsgSize),
+		),
+	}
+	if t.options.clientInitialWindowSize > 0 {
+		dialOptions = append(dialOptions, grpc.WithInitialWindowSize(t.options.clientInitialWindowSize))
+	}
+	if t.options.clientInitialConnWindowSize > 0 {
+		dialOptions = append(dialOptions, grpc.WithInitialConnWindowSize(t.options.clientInitialConnWindowSize))
+	}
+	if t.options.clientKeepaliveTime > 0 {
+		dialOptions = append(dialOptions, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    t.options.clientKeepaliveTime,
+			Timeout: t.options.clientKeepaliveTimeout,
+		}))
+	}
+	var creds credentials.TransportCredentials
+	if t.options.clientTLSConfig != nil {
+		creds = credentials.NewTLS(t.options.clientTLSConfig)
+	} else if t.options.clientTLS {
+		creds = credentials.NewClientTLSFromCert(nil, "")
+	}
+	if reporter := t.connReporter; reporter != nil || t.dnsCache != nil {
+		dial := intnet.DialFunc((&net.Dialer{}).DialContext)
+		if t.dnsCache != nil {
+			dial = t.dnsCache.Dialer(dial)
+		}
+		dialOptions = append(dialOptions, grpc.WithDialer(reportingDialer(reporter, dial, creds != nil)))
+		if reporter != nil && creds != nil {
+			creds = reportingCredentials{creds}
+		}
+	}
+	if creds != nil {
+		dialOptions = append(dialOptions, grpc.WithTransportCredentials(creds))
+	} else {
+		dialOptions = append(dialOptions, grpc.WithInsecure())
+	}
+	return dialOptions
+}
+
 func (p *grpcPeer) monitor() {
 	if !p.monitorStart() {
 		p.monitorStop(nil)
@@ -133,8 +181,7 @@ func (p *grpcPeer) monitorStart() bool {
 // this should only be called by monitor()
 func (p *grpcPeer) monitorStop(err error) {
 	p.Peer.SetStatus(peer.Unavailable)
-	// Close always returns an error
-	_ = p.clientConn.Close()
+	p.pool.close()
 	p.stoppedC <- err
 	close(p.stoppedC)
 }
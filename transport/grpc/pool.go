@@ -0,0 +1,192 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package grpc
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// connPool is the set of connections to a peer.
+//
+// Requests are sent on the usable connection with the fewest pending
+// requests. If every connection has maxStreams or more pending requests, the
+// pool opens another connection, up to maxConns connections. Connections
+// beyond the initial ones are closed once they have had no pending requests
+// for maxIdle, when the next request is sent.
+type connPool struct {
+	address     string
+	dialOptions []grpc.DialOption
+	minConns    int
+	maxStreams  int
+	maxConns    int
+	maxIdle     time.Duration
+	now         func() time.Time
+
+	lock   sync.Mutex
+	conns  []*poolConn
+	next   int
+	closed bool
+}
+
+// poolConn is a connection in a connPool.
+type poolConn struct {
+	pool       *connPool // nil for connections outside of a pool
+	clientConn *grpc.ClientConn
+	pending    atomic.Int32
+	// lastUsed is the time in Unix nanoseconds at which a request was last
+	// sent or finished on the connection.
+	lastUsed atomic.Int64
+}
+
+// newConnPool dials size connections to the address.
+func newConnPool(address string, dialOptions []grpc.DialOption, size, maxStreams, maxConns int, maxIdle time.Duration) (*connPool, error) {
+	p := &connPool{
+		address:     address,
+		dialOptions: dialOptions,
+		minConns:    size,
+		maxStreams:  maxStreams,
+		maxConns:    maxConns,
+		maxIdle:     maxIdle,
+		now:         time.Now,
+	}
+	for i := 0; i < size; i++ {
+		if _, err := p.dial(); err != nil {
+			p.close()
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// dial opens a new connection and adds it to the pool. It must be called
+// with the lock held, or before the pool is shared.
+func (p *connPool) dial() (*poolConn, error) {
+	clientConn, err := grpc.Dial(p.address, p.dialOptions...)
+	if err != nil {
+		return nil, err
+	}
+	c := &poolConn{pool: p, clientConn: clientConn}
+	c.lastUsed.Store(p.now().UnixNano())
+	p.conns = append(p.conns, c)
+	return c, nil
+}
+
+// primary returns the first connection of the pool, whose state determines
+// the status of the peer.
+func (p *connPool) primary() *grpc.ClientConn {
+	return p.conns[0].clientConn
+}
+
+// acquire returns the connection to send the next request or stream on.
+// Callers must release the connection once the request finishes.
+func (p *connPool) acquire() *poolConn {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.closeIdle()
+
+	// Start at a different connection each time so that requests rotate
+	// through connections with the same number of pending requests.
+	p.next++
+	var best *poolConn
+	for i := range p.conns {
+		c := p.conns[(p.next+i)%len(p.conns)]
+		if !usable(c.clientConn.GetState()) {
+			continue
+		}
+		if best == nil || c.pending.Load() < best.pending.Load() {
+			best = c
+		}
+	}
+	if best == nil {
+		best = p.conns[0]
+	}
+
+	if p.maxStreams > 0 && int(best.pending.Load()) >= p.maxStreams &&
+		len(p.conns) < p.maxConns && !p.closed {
+		// If the new connection can't be dialed, the request goes to the
+		// least busy connection instead.
+		if c, err := p.dial(); err == nil {
+			best = c
+		}
+	}
+
+	best.pending.Inc()
+	best.lastUsed.Store(p.now().UnixNano())
+	return best
+}
+
+// closeIdle closes the connections beyond the initial ones that have had no
+// pending requests for maxIdle. It must be called with the lock held.
+func (p *connPool) closeIdle() {
+	if p.maxIdle <= 0 || len(p.conns) <= p.minConns {
+		return
+	}
+	deadline := p.now().Add(-p.maxIdle).UnixNano()
+	conns := p.conns[:p.minConns]
+	for _, c := range p.conns[p.minConns:] {
+		// Requests are only sent on connections with the lock held, so idle
+		// connections can't get new requests while they are closed.
+		if c.pending.Load() == 0 && c.lastUsed.Load() < deadline {
+			// Close always returns an error
+			_ = c.clientConn.Close()
+			continue
+		}
+		conns = append(conns, c)
+	}
+	for i := len(conns); i < len(p.conns); i++ {
+		p.conns[i] = nil
+	}
+	p.conns = conns
+}
+
+// release records that a request sent on the connection has finished.
+func (c *poolConn) release() {
+	// Connections of outbounds with a fixed target are not in a pool.
+	if c.pool != nil {
+		c.lastUsed.Store(c.pool.now().UnixNano())
+	}
+	c.pending.Dec()
+}
+
+// close closes all connections of the pool.
+func (p *connPool) close() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.closed = true
+	for _, c := range p.conns {
+		// Close always returns an error
+		_ = c.clientConn.Close()
+	}
+}
+
+// usable reports whether requests may be sent on a connection in the given
+// state. Requests on connections that are still connecting wait for them to
+// become ready.
+func usable(state connectivity.State) bool {
+	return state != connectivity.TransientFailure && state != connectivity.Shutdown
+}
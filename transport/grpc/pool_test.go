@@ -0,0 +1,211 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package grpc
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+	"google.golang.org/grpc"
+)
+
+func TestConnPoolGrows(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	grpcServer := grpc.NewServer()
+	go grpcServer.Serve(listener)
+	defer grpcServer.Stop()
+
+	pool, err := newConnPool(listener.Addr().String(), []grpc.DialOption{grpc.WithInsecure()}, 1, 2, 2, 0)
+	require.NoError(t, err)
+	defer pool.close()
+
+	first := pool.acquire()
+	second := pool.acquire()
+	assert.True(t, first == second, "connection must be shared below the stream limit")
+	require.Len(t, pool.conns, 1)
+
+	third := pool.acquire()
+	assert.True(t, first != third, "pool must open a connection beyond the stream limit")
+	require.Len(t, pool.conns, 2)
+
+	fourth := pool.acquire()
+	assert.True(t, third == fourth, "requests must go to the least busy connection")
+
+	fifth := pool.acquire()
+	assert.Len(t, pool.conns, 2, "pool must not exceed the connection limit")
+
+	for _, c := range []*poolConn{first, second, third, fourth, fifth} {
+		c.release()
+	}
+	for _, c := range pool.conns {
+		assert.Equal(t, int32(0), c.pending.Load())
+	}
+}
+
+func TestConnPoolWithoutStreamLimit(t *testing.T) {
+	pool, err := newConnPool("127.0.0.1:0", []grpc.DialOption{grpc.WithInsecure()}, 1, 0, 8, 0)
+	require.NoError(t, err)
+	defer pool.close()
+
+	for i := 0; i < 10; i++ {
+		pool.acquire()
+	}
+	assert.Len(t, pool.conns, 1)
+}
+
+func TestConnPoolClosed(t *testing.T) {
+	pool, err := newConnPool("127.0.0.1:0", []grpc.DialOption{grpc.WithInsecure()}, 1, 1, 8, 0)
+	require.NoError(t, err)
+	pool.close()
+
+	pool.acquire()
+	pool.acquire()
+	assert.Len(t, pool.conns, 1, "closed pool must not open connections")
+}
+
+func TestConnPoolClosesIdleConnections(t *testing.T) {
+	// Dial a live server so that the connections stay usable and the choice
+	// of connection depends only on pending requests.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	grpcServer := grpc.NewServer()
+	go grpcServer.Serve(listener)
+	defer grpcServer.Stop()
+
+	now := time.Unix(1000, 0)
+	pool, err := newConnPool(listener.Addr().String(), []grpc.DialOption{grpc.WithInsecure()}, 1, 1, 4, time.Minute)
+	require.NoError(t, err)
+	defer pool.close()
+	pool.now = func() time.Time { return now }
+
+	first := pool.acquire()
+	second := pool.acquire()
+	third := pool.acquire()
+	require.Len(t, pool.conns, 3)
+
+	second.release()
+	now = now.Add(30 * time.Second)
+	third.release()
+	now = now.Add(45 * time.Second)
+
+	// Only the connection idle for longer than a minute is closed. The other
+	// connections are busy or recently used, so the request goes to the
+	// least busy one.
+	fourth := pool.acquire()
+	assert.Len(t, pool.conns, 2)
+	assert.False(t, inPool(pool, second), "idle connection must be closed")
+	assert.True(t, fourth == third)
+
+	// The initial connections are kept open even when idle.
+	first.release()
+	fourth.release()
+	now = now.Add(time.Hour)
+	pool.acquire()
+	assert.Len(t, pool.conns, 1)
+	assert.True(t, inPool(pool, first))
+}
+
+func TestConnPoolNeverShrinksWithoutMaxIdle(t *testing.T) {
+	now := time.Unix(1000, 0)
+	pool, err := newConnPool("127.0.0.1:0", []grpc.DialOption{grpc.WithInsecure()}, 1, 1, 4, 0)
+	require.NoError(t, err)
+	defer pool.close()
+	pool.now = func() time.Time { return now }
+
+	pool.acquire().release()
+	pool.acquire()
+	pool.acquire().release()
+	require.Len(t, pool.conns, 2)
+
+	now = now.Add(24 * time.Hour)
+	pool.acquire()
+	assert.Len(t, pool.conns, 2)
+}
+
+func inPool(pool *connPool, c *poolConn) bool {
+	for _, pc := range pool.conns {
+		if pc == c {
+			return true
+		}
+	}
+	return false
+}
+
+// halfClosedStream is a gRPC client stream whose server keeps streaming
+// until it is told to stop.
+type halfClosedStream struct {
+	grpc.ClientStream
+
+	end chan struct{}
+}
+
+func (s *halfClosedStream) CloseSend() error { return nil }
+
+func (s *halfClosedStream) RecvMsg(interface{}) error {
+	<-s.end
+	return io.EOF
+}
+
+func TestClientStreamReleasesConnectionWhenStreamEnds(t *testing.T) {
+	newStream := func(ctx context.Context) (*clientStream, *halfClosedStream, *atomic.Int32) {
+		var released atomic.Int32
+		grpcStream := &halfClosedStream{end: make(chan struct{})}
+		span := opentracing.NoopTracer{}.StartSpan("test")
+		cs := newClientStream(ctx, nil, grpcStream, span, nil, func() { released.Inc() })
+		return cs, grpcStream, &released
+	}
+
+	t.Run("receive", func(t *testing.T) {
+		cs, grpcStream, released := newStream(context.Background())
+		require.NoError(t, cs.Close(context.Background()))
+		assert.Equal(t, int32(0), released.Load(), "half-closed stream must keep its connection")
+
+		close(grpcStream.end)
+		_, err := cs.ReceiveMessage(context.Background())
+		assert.Equal(t, io.EOF, err)
+		assert.Equal(t, int32(1), released.Load())
+
+		_, err = cs.ReceiveMessage(context.Background())
+		assert.Equal(t, io.EOF, err)
+		assert.Equal(t, int32(1), released.Load(), "connection must be released once")
+	})
+
+	t.Run("context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cs, _, released := newStream(ctx)
+		require.NoError(t, cs.Close(context.Background()))
+		assert.Equal(t, int32(0), released.Load())
+
+		cancel()
+		<-cs.done
+		assert.Equal(t, int32(1), released.Load())
+	})
+}
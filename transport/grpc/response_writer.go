@@ -28,10 +28,18 @@ import (
 	"google.golang.org/grpc/metadata"
 )
 
+var _ transport.StreamingResponseWriter = (*responseWriter)(nil)
+
 type responseWriter struct {
 	buffer    *bytes.Buffer
 	md        metadata.MD
 	headerErr error
+
+	// sendMsg and chunkSize are set for calls whose caller accepts the
+	// response in chunks, which lets handlers stream the response.
+	sendMsg   func(interface{}) error
+	chunkSize int
+	streaming bool
 }
 
 func newResponseWriter() *responseWriter {
@@ -42,7 +50,33 @@ func (r *responseWriter) Write(p []byte) (int, error) {
 	if r.buffer == nil {
 		r.buffer = bytes.NewBuffer(make([]byte, 0, len(p)))
 	}
-	return r.buffer.Write(p)
+	n, err := r.buffer.Write(p)
+	if err != nil || !r.streaming {
+		return n, err
+	}
+	// Only full chunks are sent so that the caller receives the same
+	// messages as for a buffered response. The rest is sent once the handler
+	// returns.
+	for r.buffer.Len() >= r.chunkSize {
+		if err := r.sendMsg(r.buffer.Next(r.chunkSize)); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// StreamResponse implements transport.StreamingResponseWriter. Responses
+// can only be streamed to callers that accept them in chunks, since the
+// response of a plain unary call is a single message.
+//
+// Headers are sent as trailers, so they can still be added after the
+// response has started.
+func (r *responseWriter) StreamResponse() bool {
+	if r.sendMsg == nil || r.Bytes() != nil {
+		return false
+	}
+	r.streaming = true
+	return true
 }
 
 func (r *responseWriter) AddHeaders(headers transport.Headers) {
@@ -25,6 +25,8 @@ import (
 	"context"
 	"io"
 	"io/ioutil"
+	"sync"
+	"time"
 
 	"github.com/opentracing/opentracing-go"
 	"go.uber.org/atomic"
@@ -32,6 +34,7 @@ import (
 	"go.uber.org/yarpc/yarpcerrors"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
@@ -75,23 +78,61 @@ func (ss *serverStream) ReceiveMessage(_ context.Context) (*transport.StreamMess
 	return &transport.StreamMessage{Body: ioutil.NopCloser(bytes.NewReader(msg))}, nil
 }
 
+func (ss *serverStream) SendHeaders(headers transport.Headers) error {
+	md := metadata.New(nil)
+	if err := addApplicationHeaders(md, headers); err != nil {
+		return err
+	}
+	return toYARPCStreamError(ss.stream.SendHeader(md))
+}
+
 type clientStream struct {
 	ctx    context.Context
 	req    *transport.StreamRequest
 	stream grpc.ClientStream
 	span   opentracing.Span
 	closed atomic.Bool
+	idle   *idleTimer
+	// release is called once the stream ends, which may be long after the
+	// client closes its side of the stream.
+	release func()
+	ended   sync.Once
+	done    chan struct{}
 }
 
-func newClientStream(ctx context.Context, req *transport.StreamRequest, stream grpc.ClientStream, span opentracing.Span) *clientStream {
-	return &clientStream{
-		ctx:    ctx,
-		req:    req,
-		stream: stream,
-		span:   span,
+func newClientStream(ctx context.Context, req *transport.StreamRequest, stream grpc.ClientStream, span opentracing.Span, idle *idleTimer, release func()) *clientStream {
+	cs := &clientStream{
+		ctx:     ctx,
+		req:     req,
+		stream:  stream,
+		span:    span,
+		idle:    idle,
+		release: release,
+		done:    make(chan struct{}),
+	}
+	go cs.endOnContextDone()
+	return cs
+}
+
+// endOnContextDone ends the stream when its context is done, for streams
+// whose responses are not read until they end.
+func (cs *clientStream) endOnContextDone() {
+	select {
+	case <-cs.ctx.Done():
+		cs.end()
+	case <-cs.done:
 	}
 }
 
+// end records that the stream has ended, either because all its responses
+// were received, because it failed, or because its context is done.
+func (cs *clientStream) end() {
+	cs.ended.Do(func() {
+		close(cs.done)
+		cs.release()
+	})
+}
+
 func (cs *clientStream) Context() context.Context {
 	return cs.ctx
 }
@@ -112,8 +153,11 @@ func (cs *clientStream) SendMessage(_ context.Context, m *transport.StreamMessag
 		return toYARPCStreamError(err)
 	}
 	if err := cs.stream.SendMsg(msg); err != nil {
-		return toYARPCStreamError(cs.closeWithErr(err))
+		// SendMsg only fails once the stream is aborted.
+		cs.end()
+		return cs.idle.wrapError(toYARPCStreamError(cs.closeWithErr(err)))
 	}
+	cs.idle.reset()
 	return nil
 }
 
@@ -121,11 +165,26 @@ func (cs *clientStream) ReceiveMessage(context.Context) (*transport.StreamMessag
 	// TODO use buffers for performance reasons.
 	var msg []byte
 	if err := cs.stream.RecvMsg(&msg); err != nil {
-		return nil, toYARPCStreamError(cs.closeWithErr(err))
+		// The stream is over; release the idle timer and the connection.
+		cs.idle.stop()
+		cs.end()
+		return nil, cs.idle.wrapError(toYARPCStreamError(cs.closeWithErr(err)))
 	}
+	cs.idle.reset()
 	return &transport.StreamMessage{Body: ioutil.NopCloser(bytes.NewReader(msg))}, nil
 }
 
+func (cs *clientStream) Headers() (transport.Headers, error) {
+	md, err := cs.stream.Header()
+	if err != nil {
+		return transport.Headers{}, toYARPCStreamError(err)
+	}
+	// content-type is added by gRPC itself and is not an application header.
+	md = md.Copy()
+	delete(md, contentTypeHeader)
+	return getApplicationHeaders(md)
+}
+
 func (cs *clientStream) Close(context.Context) error {
 	_ = cs.closeWithErr(nil)
 	return cs.stream.CloseSend()
@@ -139,6 +198,48 @@ func (cs *clientStream) closeWithErr(err error) error {
 	return err
 }
 
+// idleTimer cancels a stream if it has been idle for longer than a timeout.
+// A nil idleTimer is valid and does nothing.
+type idleTimer struct {
+	timeout time.Duration
+	timer   *time.Timer
+	cancel  context.CancelFunc
+	expired atomic.Bool
+}
+
+func newIdleTimer(timeout time.Duration, cancel context.CancelFunc) *idleTimer {
+	t := &idleTimer{timeout: timeout, cancel: cancel}
+	t.timer = time.AfterFunc(timeout, t.expire)
+	return t
+}
+
+func (t *idleTimer) expire() {
+	t.expired.Store(true)
+	t.cancel()
+}
+
+func (t *idleTimer) reset() {
+	if t != nil {
+		t.timer.Reset(t.timeout)
+	}
+}
+
+func (t *idleTimer) stop() {
+	if t != nil {
+		t.timer.Stop()
+		t.cancel()
+	}
+}
+
+// wrapError replaces the cancellation error of a stream that was canceled for
+// being idle with a more descriptive error.
+func (t *idleTimer) wrapError(err error) error {
+	if t == nil || err == nil || err == io.EOF || !t.expired.Load() {
+		return err
+	}
+	return yarpcerrors.DeadlineExceededErrorf("stream was idle for longer than %v", t.timeout)
+}
+
 func toYARPCStreamError(err error) error {
 	if err == nil {
 		return nil
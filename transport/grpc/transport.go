@@ -26,6 +26,8 @@ import (
 
 	"go.uber.org/multierr"
 	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/internal/dnscache"
+	intnet "go.uber.org/yarpc/internal/net"
 	"go.uber.org/yarpc/pkg/lifecycle"
 )
 
@@ -38,6 +40,8 @@ type Transport struct {
 	once          *lifecycle.Once
 	options       *transportOptions
 	addressToPeer map[string]*grpcPeer
+	connReporter  *intnet.ConnReporter
+	dnsCache      *dnscache.Cache
 }
 
 // NewTransport returns a new Transport.
@@ -46,11 +50,16 @@ func NewTransport(options ...TransportOption) *Transport {
 }
 
 func newTransport(transportOptions *transportOptions) *Transport {
-	return &Transport{
+	t := &Transport{
 		once:          lifecycle.NewOnce(),
 		options:       transportOptions,
 		addressToPeer: make(map[string]*grpcPeer),
+		connReporter:  intnet.NewConnReporter(transportName, transportOptions.connectionEventHook),
 	}
+	if transportOptions.dnsCacheTTL > 0 {
+		t.dnsCache = dnscache.New(transportOptions.dnsCacheTTL, transportOptions.dnsCacheMaxStale)
+	}
+	return t
 }
 
 // Start implements transport.Lifecycle#Start.
@@ -94,6 +103,17 @@ func (t *Transport) NewOutbound(peerChooser peer.Chooser, options ...OutboundOpt
 	return newOutbound(t, peerChooser, options...)
 }
 
+// NewTargetOutbound returns a new Outbound that sends requests to the given
+// gRPC target, such as "dns:///myservice:8080" or "xds:///myservice", using
+// the resolvers and load balancers registered with gRPC rather than a YARPC
+// peer chooser. This delegates load balancing to gRPC, for example to
+// services that are load balanced with xDS.
+//
+// The scheme of the target must name a resolver registered with gRPC.
+func (t *Transport) NewTargetOutbound(target string, options ...OutboundOption) *Outbound {
+	return newTargetOutbound(t, target, options...)
+}
+
 // RetainPeer retains the peer.
 func (t *Transport) RetainPeer(peerIdentifier peer.Identifier, peerSubscriber peer.Subscriber) (peer.Peer, error) {
 	t.lock.Lock()
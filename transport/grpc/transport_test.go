@@ -21,13 +21,16 @@
 package grpc
 
 import (
+	"context"
 	"net"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/internal/testtime"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 )
 
 func TestTransportLifecycle(t *testing.T) {
@@ -59,6 +62,45 @@ func TestRetainReleasePeerSuccess(t *testing.T) {
 	assert.NoError(t, transport.ReleasePeer(testIdentifier{address}, peerSubscriber))
 }
 
+func TestRetainPeerWarmConnections(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	grpcServer := grpc.NewServer()
+	go grpcServer.Serve(listener)
+	defer grpcServer.Stop()
+
+	transport := NewTransport(WarmConnections(3))
+	assert.NoError(t, transport.Start())
+	defer func() { assert.NoError(t, transport.Stop()) }()
+
+	address := listener.Addr().String()
+	peerSubscriber := testPeerSubscriber{}
+
+	p, err := transport.RetainPeer(testIdentifier{address}, peerSubscriber)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, transport.ReleasePeer(testIdentifier{address}, peerSubscriber)) }()
+
+	grpcPeer := p.(*grpcPeer)
+	require.Len(t, grpcPeer.pool.conns, 3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+	defer cancel()
+	for _, c := range grpcPeer.pool.conns {
+		for state := c.clientConn.GetState(); state != connectivity.Ready; state = c.clientConn.GetState() {
+			require.True(t, c.clientConn.WaitForStateChange(ctx, state), "connection did not become ready")
+		}
+	}
+
+	used := make(map[*poolConn]struct{})
+	for i := 0; i < 3; i++ {
+		c := grpcPeer.pool.acquire()
+		defer c.release()
+		used[c] = struct{}{}
+	}
+	assert.Len(t, used, 3, "requests must be spread across the connections")
+}
+
 func TestRetainReleasePeerErrorPeerIdentifier(t *testing.T) {
 	transport := NewTransport()
 	assert.NoError(t, transport.Start())
@@ -87,6 +87,7 @@ func (ts *transportSpec) Spec() yarpcconfig.TransportSpec {
 //      disableCompression: false
 //      responseHeaderTimeout: 0s
 //      connTimeout: 500ms
+//      warmConnections: 2
 //      connBackoff:
 //        exponential:
 //          first: 10ms
@@ -106,6 +107,9 @@ type TransportConfig struct {
 	ResponseHeaderTimeout time.Duration       `config:"responseHeaderTimeout"`
 	ConnTimeout           time.Duration       `config:"connTimeout"`
 	ConnBackoff           yarpcconfig.Backoff `config:"connBackoff"`
+	// Number of idle connections to establish to each peer. See
+	// WarmConnections.
+	WarmConnections int `config:"warmConnections"`
 }
 
 func (ts *transportSpec) buildTransport(tc *TransportConfig, k *yarpcconfig.Kit) (transport.Transport, error) {
@@ -136,6 +140,9 @@ func (ts *transportSpec) buildTransport(tc *TransportConfig, k *yarpcconfig.Kit)
 	if tc.ConnTimeout > 0 {
 		options.connTimeout = tc.ConnTimeout
 	}
+	if tc.WarmConnections > 0 {
+		options.warmConnections = tc.WarmConnections
+	}
 
 	strategy, err := tc.ConnBackoff.Strategy()
 	if err != nil {
@@ -160,6 +167,25 @@ type InboundConfig struct {
 	// The additional headers, starting with x, that should be
 	// propagated to handlers. This field is optional.
 	GrabHeaders []string `config:"grabHeaders"`
+	// The maximum size of request bodies in bytes. Larger requests are
+	// rejected. This field is optional; bodies are unlimited by default.
+	MaxRequestBodySize int64 `config:"maxRequestBodySize"`
+	// The maximum total size in bytes, and the maximum number, of the
+	// headers of requests. Requests with larger headers are rejected. These
+	// fields are optional; headers are only limited by the HTTP server by
+	// default.
+	MaxRequestHeaderBytes int `config:"maxRequestHeaderBytes"`
+	MaxRequestHeaders     int `config:"maxRequestHeaders"`
+	// The number of workers that run oneway handlers in order per shard
+	// key. This field is optional; oneway handlers run concurrently by
+	// default.
+	OrderedOnewayWorkers int `config:"orderedOnewayWorkers"`
+	// Procedures whose responses carry an ETag and support conditional
+	// requests. This field is optional.
+	ETags []string `config:"etags"`
+	// Whether the inbound accepts grpc-web requests. See GRPCWeb. This field
+	// is optional; grpc-web requests are not accepted by default.
+	GRPCWeb bool `config:"grpcWeb"`
 }
 
 func (ts *transportSpec) buildInbound(ic *InboundConfig, t transport.Transport, k *yarpcconfig.Kit) (transport.Inbound, error) {
@@ -170,6 +196,24 @@ func (ts *transportSpec) buildInbound(ic *InboundConfig, t transport.Transport,
 	if len(ic.GrabHeaders) > 0 {
 		inboundOptions = append(inboundOptions, GrabHeaders(ic.GrabHeaders...))
 	}
+	if ic.MaxRequestBodySize > 0 {
+		inboundOptions = append(inboundOptions, MaxRequestBodySize(ic.MaxRequestBodySize))
+	}
+	if ic.MaxRequestHeaderBytes > 0 {
+		inboundOptions = append(inboundOptions, MaxRequestHeaderBytes(ic.MaxRequestHeaderBytes))
+	}
+	if ic.MaxRequestHeaders > 0 {
+		inboundOptions = append(inboundOptions, MaxRequestHeaders(ic.MaxRequestHeaders))
+	}
+	if ic.OrderedOnewayWorkers > 0 {
+		inboundOptions = append(inboundOptions, OrderedOneway(ic.OrderedOnewayWorkers))
+	}
+	if len(ic.ETags) > 0 {
+		inboundOptions = append(inboundOptions, ETags(ic.ETags...))
+	}
+	if ic.GRPCWeb {
+		inboundOptions = append(inboundOptions, GRPCWeb())
+	}
 	return t.(*Transport).NewInbound(ic.Address, inboundOptions...), nil
 }
 
@@ -220,6 +264,22 @@ type OutboundConfig struct {
 	//      X-Caller: myserice
 	//      X-Token: foo
 	AddHeaders map[string]string `config:"addHeaders"`
+
+	// Procedures to send as plain REST requests, with their routes. See
+	// RESTRoute for details.
+	//
+	//  http:
+	//    url: "http://users.example.com"
+	//    rest:
+	//      Users::Get:
+	//        method: GET
+	//        path: /users/{id}
+	REST map[string]RESTRoute `config:"rest"`
+
+	// The number of responses with an ETag that the outbound keeps and
+	// revalidates with conditional requests. This field is optional;
+	// conditional requests are disabled by default.
+	ConditionalRequests int `config:"conditionalRequests"`
 }
 
 func (ts *transportSpec) buildOutbound(oc *OutboundConfig, t transport.Transport, k *yarpcconfig.Kit) (*Outbound, error) {
@@ -231,6 +291,12 @@ func (ts *transportSpec) buildOutbound(oc *OutboundConfig, t transport.Transport
 			opts = append(opts, AddHeader(k, v))
 		}
 	}
+	for procedure, route := range oc.REST {
+		opts = append(opts, RESTProcedure(procedure, route))
+	}
+	if oc.ConditionalRequests > 0 {
+		opts = append(opts, ConditionalRequests(oc.ConditionalRequests))
+	}
 
 	// Special case where the URL implies the single peer.
 	if oc.Empty() {
@@ -28,6 +28,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/internal/headerlimit"
 	"go.uber.org/yarpc/yarpcconfig"
 )
 
@@ -60,6 +61,12 @@ func TestTransportSpec(t *testing.T) {
 		Mux         *http.ServeMux
 		MuxPattern  string
 		GrabHeaders map[string]struct{}
+
+		MaxRequestBodySize   int64
+		HeaderLimits         headerlimit.Limits
+		OrderedOnewayWorkers int
+		ETagProcedures       map[string]struct{}
+		GRPCWeb              bool
 	}
 
 	type inboundTest struct {
@@ -77,6 +84,9 @@ func TestTransportSpec(t *testing.T) {
 	type wantOutbound struct {
 		URLTemplate string
 		Headers     http.Header
+		RESTRoutes  map[string]RESTRoute
+
+		ConditionalRequests int
 	}
 
 	type outboundTest struct {
@@ -123,6 +133,7 @@ func TestTransportSpec(t *testing.T) {
 				"disableKeepAlives":     true,
 				"disableCompression":    true,
 				"responseHeaderTimeout": "1s",
+				"warmConnections":       3,
 			},
 			wantClient: &wantHTTPClient{
 				KeepAlive:             5 * time.Second,
@@ -133,6 +144,7 @@ func TestTransportSpec(t *testing.T) {
 				DisableKeepAlives:     true,
 				DisableCompression:    true,
 				ResponseHeaderTimeout: 1 * time.Second,
+				WarmConnections:       3,
 			},
 		},
 	}
@@ -156,6 +168,31 @@ func TestTransportSpec(t *testing.T) {
 			cfg:         attrs{"address": ":8080", "grabHeaders": []string{"x-foo", "x-bar"}},
 			wantInbound: &wantInbound{Address: ":8080", GrabHeaders: map[string]struct{}{"x-foo": {}, "x-bar": {}}},
 		},
+		{
+			desc:        "simple inbound with max request body size",
+			cfg:         attrs{"address": ":8080", "maxRequestBodySize": 1024},
+			wantInbound: &wantInbound{Address: ":8080", MaxRequestBodySize: 1024},
+		},
+		{
+			desc:        "simple inbound with header limits",
+			cfg:         attrs{"address": ":8080", "maxRequestHeaderBytes": 4096, "maxRequestHeaders": 32},
+			wantInbound: &wantInbound{Address: ":8080", HeaderLimits: headerlimit.Limits{MaxBytes: 4096, MaxCount: 32}},
+		},
+		{
+			desc:        "simple inbound with ordered oneway workers",
+			cfg:         attrs{"address": ":8080", "orderedOnewayWorkers": 8},
+			wantInbound: &wantInbound{Address: ":8080", OrderedOnewayWorkers: 8},
+		},
+		{
+			desc:        "simple inbound with etags",
+			cfg:         attrs{"address": ":8080", "etags": []string{"KeyValue::getValue"}},
+			wantInbound: &wantInbound{Address: ":8080", ETagProcedures: map[string]struct{}{"KeyValue::getValue": {}}},
+		},
+		{
+			desc:        "simple inbound with grpc-web",
+			cfg:         attrs{"address": ":8080", "grpcWeb": true},
+			wantInbound: &wantInbound{Address: ":8080", GRPCWeb: true},
+		},
 		{
 			desc:        "inbound interpolation",
 			cfg:         attrs{"address": "${HOST:}:${PORT}"},
@@ -288,6 +325,46 @@ func TestTransportSpec(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "outbound REST config",
+			cfg: attrs{
+				"myservice": attrs{
+					"http": attrs{
+						"url": "http://localhost/",
+						"rest": attrs{
+							"Users::Get":    attrs{"method": "GET", "path": "/users/{id}"},
+							"Users::Create": attrs{"path": "/users"},
+						},
+					},
+				},
+			},
+			wantOutbounds: map[string]wantOutbound{
+				"myservice": {
+					URLTemplate: "http://localhost/",
+					RESTRoutes: map[string]RESTRoute{
+						"Users::Get":    {Method: "GET", Path: "/users/{id}"},
+						"Users::Create": {Path: "/users"},
+					},
+				},
+			},
+		},
+		{
+			desc: "outbound with conditional requests",
+			cfg: attrs{
+				"myservice": attrs{
+					"http": attrs{
+						"url":                 "http://localhost/yarpc",
+						"conditionalRequests": 100,
+					},
+				},
+			},
+			wantOutbounds: map[string]wantOutbound{
+				"myservice": {
+					URLTemplate:         "http://localhost/yarpc",
+					ConditionalRequests: 100,
+				},
+			},
+		},
 		{
 			desc: "outbound header config with peer",
 			cfg: attrs{
@@ -406,6 +483,15 @@ func TestTransportSpec(t *testing.T) {
 				} else {
 					assert.Empty(t, ib.grabHeaders)
 				}
+				assert.Equal(t, want.MaxRequestBodySize, ib.maxRequestBodySize, "inbound max request body size should match")
+				assert.Equal(t, want.HeaderLimits, ib.headerLimits, "inbound header limits should match")
+				assert.Equal(t, want.OrderedOnewayWorkers, ib.onewayWorkers, "inbound ordered oneway workers should match")
+				assert.Equal(t, want.GRPCWeb, ib.grpcWeb, "inbound grpc-web should match")
+				if len(want.ETagProcedures) > 0 {
+					assert.Equal(t, want.ETagProcedures, ib.etagProcedures, "inbound etag procedures should match")
+				} else {
+					assert.Empty(t, ib.etagProcedures)
+				}
 			}
 		}
 
@@ -418,6 +504,14 @@ func TestTransportSpec(t *testing.T) {
 
 				assert.Equal(t, want.URLTemplate, ob.urlTemplate.String(), "outbound URLTemplate should match")
 				assert.Equal(t, want.Headers, ob.headers, "outbound headers should match")
+				assert.Equal(t, want.RESTRoutes, ob.restRoutes, "outbound REST routes should match")
+				if want.ConditionalRequests > 0 {
+					if assert.NotNil(t, ob.etags, "outbound should make conditional requests") {
+						assert.Equal(t, want.ConditionalRequests, ob.etags.size, "outbound conditional requests should match")
+					}
+				} else {
+					assert.Nil(t, ob.etags, "outbound should not make conditional requests")
+				}
 			}
 
 		}
@@ -459,6 +553,7 @@ type wantHTTPClient struct {
 	DisableCompression    bool
 	ResponseHeaderTimeout time.Duration
 	ConnTimeout           time.Duration
+	WarmConnections       int
 }
 
 // useFakeBuildClient verifies the configuration we use to build an HTTP
@@ -474,6 +569,7 @@ func useFakeBuildClient(t *testing.T, want *wantHTTPClient) TransportOption {
 		assert.Equal(t, want.DisableCompression, options.disableCompression, "http.Client: DisableCompression should match")
 		assert.Equal(t, want.ResponseHeaderTimeout, options.responseHeaderTimeout, "http.Client: ResponseHeaderTimeout should match")
 		assert.Equal(t, want.ConnTimeout, options.connTimeout, "http.Client: ConnTimeout should match")
+		assert.Equal(t, want.WarmConnections, options.warmConnections, "http.Client: WarmConnections should match")
 		return buildHTTPClient(options)
 	})
 }
@@ -0,0 +1,102 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package http
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+
+	intnet "go.uber.org/yarpc/internal/net"
+)
+
+var errTLSHandshake = errors.New("connection closed before its TLS handshake completed")
+
+// connStates reports the connection events of an HTTP server from the state
+// changes of its connections.
+type connStates struct {
+	reporter *intnet.ConnReporter
+
+	mu          sync.Mutex
+	established map[net.Conn]struct{}
+}
+
+func newConnStates(reporter *intnet.ConnReporter) *connStates {
+	return &connStates{
+		reporter:    reporter,
+		established: make(map[net.Conn]struct{}),
+	}
+}
+
+// onStateChange is an http.Server ConnState hook. TLS connections are only
+// established once their handshake completes, before they first become
+// active.
+func (s *connStates) onStateChange(c net.Conn, state http.ConnState) {
+	switch state {
+	case http.StateNew:
+		if _, ok := c.(*tls.Conn); !ok {
+			s.establish(c, nil)
+		}
+	case http.StateActive:
+		if tc, ok := c.(*tls.Conn); ok {
+			cs := tc.ConnectionState()
+			s.establish(c, &cs)
+		}
+	case http.StateClosed:
+		s.close(c)
+	}
+}
+
+func (s *connStates) establish(c net.Conn, state *tls.ConnectionState) {
+	s.mu.Lock()
+	_, ok := s.established[c]
+	s.established[c] = struct{}{}
+	s.mu.Unlock()
+
+	if !ok {
+		s.reporter.Established(c, c.RemoteAddr().String(), true, state)
+	}
+}
+
+func (s *connStates) close(c net.Conn) {
+	s.mu.Lock()
+	_, ok := s.established[c]
+	delete(s.established, c)
+	s.mu.Unlock()
+
+	peer := c.RemoteAddr().String()
+	if !ok {
+		tc, isTLS := c.(*tls.Conn)
+		if !isTLS {
+			return
+		}
+		cs := tc.ConnectionState()
+		if !cs.HandshakeComplete {
+			s.reporter.Failed(peer, true, errTLSHandshake)
+			return
+		}
+		// The connection was closed without ever becoming active.
+		s.reporter.Established(c, peer, true, &cs)
+	}
+	s.reporter.Closed(c, peer, true)
+}
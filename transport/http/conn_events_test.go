@@ -0,0 +1,131 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/encoding/raw"
+	intnet "go.uber.org/yarpc/internal/net"
+)
+
+type connEvents struct {
+	mu     sync.Mutex
+	events []transport.ConnectionEvent
+}
+
+func (c *connEvents) record(e transport.ConnectionEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, e)
+}
+
+// find waits for an event of the given type and direction.
+func (c *connEvents) find(typ transport.ConnectionEventType, inbound bool) (transport.ConnectionEvent, bool) {
+	return c.findPeer(typ, inbound, "")
+}
+
+// findPeer waits for an event of the given type and direction for the
+// given peer, or any peer if empty.
+func (c *connEvents) findPeer(typ transport.ConnectionEventType, inbound bool, peer string) (transport.ConnectionEvent, bool) {
+	for i := 0; i < 100; i++ {
+		c.mu.Lock()
+		for _, e := range c.events {
+			if e.Type == typ && e.Inbound == inbound && (peer == "" || e.Peer == peer) {
+				c.mu.Unlock()
+				return e, true
+			}
+		}
+		c.mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	return transport.ConnectionEvent{}, false
+}
+
+func TestConnectionEvents(t *testing.T) {
+	var events connEvents
+	x := NewTransport(ConnectionEvents(events.record))
+	require.NoError(t, x.Start())
+	defer x.Stop()
+
+	i := x.NewInbound("127.0.0.1:0")
+	i.SetRouter(newTestRouter(nil))
+	require.NoError(t, i.Start())
+	defer i.Stop()
+	addr := i.Addr().String()
+
+	out := x.NewSingleOutbound("http://" + addr)
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	// The procedure does not exist; the call only opens a connection.
+	_, _ = out.Call(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  raw.Encoding,
+		Procedure: "hello",
+	})
+
+	established, ok := events.find(transport.ConnectionEstablished, false)
+	require.True(t, ok, "outbound connection must be established")
+	assert.Equal(t, "http", established.Transport)
+	assert.Equal(t, addr, established.Peer)
+
+	// Peers probe their address too, so look for the connection of the call.
+	accepted, ok := events.findPeer(transport.ConnectionEstablished, true, established.LocalAddr)
+	require.True(t, ok, "inbound connection must be established")
+	assert.Equal(t, established.LocalAddr, accepted.Peer)
+	assert.Equal(t, addr, accepted.LocalAddr)
+
+	x.client.Transport.(*http.Transport).CloseIdleConnections()
+	_, ok = events.find(transport.ConnectionClosed, false)
+	assert.True(t, ok, "outbound connection must be closed")
+	_, ok = events.find(transport.ConnectionClosed, true)
+	assert.True(t, ok, "inbound connection must be closed")
+}
+
+func TestConnStatesTLSHandshakeFailure(t *testing.T) {
+	var events connEvents
+	states := newConnStates(intnet.NewConnReporter(transportName, events.record))
+
+	client, server := net.Pipe()
+	defer client.Close()
+	c := tls.Server(server, &tls.Config{})
+
+	states.onStateChange(c, http.StateNew)
+	states.onStateChange(c, http.StateClosed)
+
+	require.Len(t, events.events, 1)
+	assert.Equal(t, transport.ConnectionFailed, events.events[0].Type)
+	assert.True(t, events.events[0].Inbound)
+	assert.Equal(t, errTLSHandshake, events.events[0].Err)
+}
@@ -70,6 +70,15 @@ const (
 	// ErrorNameHeader contains the name of a user-defined error.
 	ErrorNameHeader = "Rpc-Error-Name"
 
+	// ErrorDetailsHeader contains the serialized details and cause of an
+	// error, if any.
+	ErrorDetailsHeader = "Rpc-Error-Details"
+
+	// RetryAfterHeader is the standard HTTP header through which servers ask
+	// clients to wait before sending more requests. It carries the duration
+	// set with yarpcerrors.Status.WithRetryAfter, in seconds.
+	RetryAfterHeader = "Retry-After"
+
 	// ErrorMessageHeader contains the message of an error, if the
 	// BothResponseError feature is enabled.
 	ErrorMessageHeader = "Rpc-Error-Message"
@@ -83,6 +92,11 @@ const (
 	// feature is supported on the server. If any non-empty value is set,
 	// this indicates true.
 	BothResponseErrorHeader = "Rpc-Both-Response-Error"
+
+	// AckStatusHeader is the response header with which inbounds report how
+	// far a oneway request got when it was acknowledged, as the text form of
+	// a transport.AckStatus.
+	AckStatusHeader = "Rpc-Ack-Status"
 )
 
 // Valid values for the Rpc-Status header.
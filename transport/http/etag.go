@@ -0,0 +1,279 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package http
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/bufferpool"
+)
+
+const (
+	etagHeader        = "ETag"
+	ifNoneMatchHeader = "If-None-Match"
+)
+
+// ETags specifies procedures whose successful responses carry an ETag, a
+// digest of the response body. Requests for these procedures whose
+// If-None-Match header matches the ETag of the response receive a
+// 304 Not Modified response without a body, so HTTP caches in front of the
+// inbound and outbounds with ConditionalRequests can reuse the body they
+// already have.
+//
+// 	httpTransport.NewInbound(addr, http.ETags("KeyValue::getValue"))
+//
+// The handler still runs for conditional requests; only the transfer of the
+// body is saved. Streamed responses and application errors are never
+// tagged.
+func ETags(procedures ...string) InboundOption {
+	return func(i *Inbound) {
+		for _, procedure := range procedures {
+			i.etagProcedures[procedure] = struct{}{}
+		}
+	}
+}
+
+// ConditionalRequests specifies that the outbound keeps the bodies of up to
+// the given number of responses that carry an ETag, and revalidates them
+// with conditional requests. When the same request is sent again, the
+// outbound includes the ETag in an If-None-Match header, and answers a
+// 304 Not Modified response with the body it kept.
+//
+// 	httpTransport.NewOutbound(chooser, http.ConditionalRequests(1000))
+//
+// Requests are the same if they have the same service, procedure, encoding,
+// routing and shard keys, application headers, and body. Once full, the
+// outbound forgets the least recently used responses first.
+func ConditionalRequests(entries int) OutboundOption {
+	return func(o *Outbound) {
+		if entries > 0 {
+			o.etags = newETagCache(entries)
+		} else {
+			o.etags = nil
+		}
+	}
+}
+
+// computeETag returns a strong ETag for the given response body.
+func computeETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether the value of an If-None-Match header matches
+// the given ETag. As required for If-None-Match, weak and strong ETags are
+// compared alike.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// etagEntry is a response body kept by an etagCache.
+type etagEntry struct {
+	key  string
+	etag string
+	body []byte
+}
+
+func (e *etagEntry) reader() io.ReadCloser {
+	return ioutil.NopCloser(bytes.NewReader(e.body))
+}
+
+// etagCache keeps the most recently used response bodies that carried an
+// ETag.
+type etagCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element // of *etagEntry
+	order   *list.List               // most recently used first
+}
+
+func newETagCache(size int) *etagCache {
+	return &etagCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// key identifies the given request, reading its body. The body of the
+// request is replaced so that it may still be sent.
+func (c *etagCache) key(treq *transport.Request) (string, error) {
+	var body []byte
+	if treq.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(treq.Body); err != nil {
+			return "", err
+		}
+		treq.Body = bytes.NewReader(body)
+	}
+
+	h := sha256.New()
+	for _, field := range []string{
+		treq.Service,
+		treq.Procedure,
+		string(treq.Encoding),
+		treq.ShardKey,
+		treq.RoutingKey,
+		treq.RoutingDelegate,
+	} {
+		writeKeyField(h, field)
+	}
+	headers := treq.Headers.Items()
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeKeyField(h, name)
+		writeKeyField(h, headers[name])
+	}
+	_, _ = h.Write(body)
+	return string(h.Sum(nil)), nil
+}
+
+// writeKeyField writes a length-prefixed field so that the fields of
+// different requests cannot run into each other.
+func writeKeyField(w io.Writer, field string) {
+	var size [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(size[:], uint64(len(field)))
+	_, _ = w.Write(size[:n])
+	_, _ = io.WriteString(w, field)
+}
+
+func (c *etagCache) get(key string) *etagEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*etagEntry)
+}
+
+func (c *etagCache) put(entry *etagEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[entry.key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[entry.key] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*etagEntry).key)
+	}
+}
+
+func (c *etagCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+	}
+}
+
+// setETag tags the buffered response with its ETag and reports whether the
+// caller already has this response, in which case the body is dropped.
+func (rw *responseWriter) setETag(ifNoneMatch string) (notModified bool) {
+	if rw.streaming || rw.w.Header().Get(ApplicationStatusHeader) == ApplicationErrorStatus {
+		return false
+	}
+	var body []byte
+	if rw.buffer != nil {
+		body = rw.buffer.Bytes()
+	}
+	etag := computeETag(body)
+	rw.w.Header().Set(etagHeader, etag)
+	if !etagMatches(ifNoneMatch, etag) {
+		return false
+	}
+	if rw.buffer != nil {
+		bufferpool.Put(rw.buffer)
+		rw.buffer = nil
+	}
+	return true
+}
+
+// callConditional sends a request through an outbound with
+// ConditionalRequests, revalidating the response kept for it, if any.
+func (o *Outbound) callConditional(ctx context.Context, treq *transport.Request) (*transport.Response, error) {
+	// The body is read to identify the request, so send a copy rather than
+	// changing the caller's request.
+	req := *treq
+	key, err := o.etags.key(&req)
+	if err != nil {
+		return nil, err
+	}
+	cached := o.etags.get(key)
+
+	res, header, err := o.call(ctx, &req, cached)
+	if err != nil || res.ApplicationError {
+		return res, err
+	}
+	etag := header.Get(etagHeader)
+	if etag == "" {
+		o.etags.remove(key)
+		return res, nil
+	}
+	if cached != nil && etag == cached.etag {
+		// Either the response was not modified and its body is the one we
+		// kept, or the server ignored the condition.
+		return res, nil
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	entry := &etagEntry{key: key, etag: etag, body: body}
+	o.etags.put(entry)
+	res.Body = entry.reader()
+	return res, nil
+}
@@ -0,0 +1,229 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/encoding/raw"
+	"go.uber.org/yarpc/internal/testtime"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+func TestETagMatches(t *testing.T) {
+	etag := computeETag([]byte("hello"))
+
+	tests := []struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		{ifNoneMatch: "", want: false},
+		{ifNoneMatch: etag, want: true},
+		{ifNoneMatch: "W/" + etag, want: true},
+		{ifNoneMatch: `"foo", ` + etag, want: true},
+		{ifNoneMatch: "*", want: true},
+		{ifNoneMatch: `"foo"`, want: false},
+		{ifNoneMatch: computeETag([]byte("world")), want: false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, etagMatches(tt.ifNoneMatch, etag), "If-None-Match: %q", tt.ifNoneMatch)
+	}
+}
+
+func TestETagCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newETagCache(2)
+	cache.put(&etagEntry{key: "a", etag: `"a"`})
+	cache.put(&etagEntry{key: "b", etag: `"b"`})
+	require.NotNil(t, cache.get("a"))
+
+	cache.put(&etagEntry{key: "c", etag: `"c"`})
+	assert.NotNil(t, cache.get("a"))
+	assert.Nil(t, cache.get("b"), "least recently used entry must be evicted")
+	assert.NotNil(t, cache.get("c"))
+
+	cache.remove("a")
+	assert.Nil(t, cache.get("a"))
+}
+
+func TestETagCacheKey(t *testing.T) {
+	cache := newETagCache(1)
+	newRequest := func(body string, headers transport.Headers) *transport.Request {
+		return &transport.Request{
+			Service:   "service",
+			Procedure: "hello",
+			Encoding:  raw.Encoding,
+			Headers:   headers,
+			Body:      bytes.NewReader([]byte(body)),
+		}
+	}
+	key := func(req *transport.Request) string {
+		k, err := cache.key(req)
+		require.NoError(t, err)
+		return k
+	}
+
+	req := newRequest("world", transport.NewHeaders().With("foo", "bar"))
+	k := key(req)
+	body, err := ioutil.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "world", string(body), "body must still be readable")
+
+	assert.Equal(t, k, key(newRequest("world", transport.NewHeaders().With("foo", "bar"))))
+	assert.NotEqual(t, k, key(newRequest("world!", transport.NewHeaders().With("foo", "bar"))))
+	assert.NotEqual(t, k, key(newRequest("world", transport.NewHeaders().With("foo", "baz"))))
+	assert.NotEqual(t, k, key(newRequest("world", transport.NewHeaders())))
+}
+
+// statusRecorder records the status codes of the responses of an HTTP
+// handler.
+type statusRecorder struct {
+	http.Handler
+
+	statuses []int
+}
+
+func (r *statusRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	rec := httptest.NewRecorder()
+	r.Handler.ServeHTTP(rec, req)
+	r.statuses = append(r.statuses, rec.Code)
+	for k, vs := range rec.Header() {
+		w.Header()[k] = vs
+	}
+	w.WriteHeader(rec.Code)
+	_, _ = rec.Body.WriteTo(w)
+}
+
+func TestConditionalRequests(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	value := "v1"
+	appError := false
+	rpcHandler := transporttest.NewMockUnaryHandler(mockCtrl)
+	router := transporttest.NewMockRouter(mockCtrl)
+	router.EXPECT().Choose(gomock.Any(), gomock.Any()).
+		Return(transport.NewUnaryHandlerSpec(rpcHandler), nil).AnyTimes()
+	rpcHandler.EXPECT().Handle(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ *transport.Request, resw transport.ResponseWriter) error {
+			resw.AddHeaders(transport.NewHeaders().With("version", value))
+			if appError {
+				resw.SetApplicationError()
+			}
+			_, err := resw.Write([]byte(value))
+			return err
+		}).AnyTimes()
+
+	recorder := &statusRecorder{Handler: handler{
+		router:         router,
+		tracer:         &opentracing.NoopTracer{},
+		etagProcedures: map[string]struct{}{"hello": {}},
+	}}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	out := NewTransport().NewSingleOutbound(server.URL, ConditionalRequests(10))
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	call := func() (string, string) {
+		ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+		defer cancel()
+		res, err := out.Call(ctx, &transport.Request{
+			Caller:    "caller",
+			Service:   "service",
+			Encoding:  raw.Encoding,
+			Procedure: "hello",
+			Body:      bytes.NewReader([]byte("world")),
+		})
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		version, _ := res.Headers.Get("version")
+		return string(body), version
+	}
+
+	body, version := call()
+	assert.Equal(t, "v1", body)
+	assert.Equal(t, "v1", version)
+
+	body, version = call()
+	assert.Equal(t, "v1", body, "unmodified response must be answered from the kept body")
+	assert.Equal(t, "v1", version)
+
+	value = "v2"
+	body, version = call()
+	assert.Equal(t, "v2", body)
+	assert.Equal(t, "v2", version)
+
+	appError = true
+	body, _ = call()
+	assert.Equal(t, "v2", body)
+
+	assert.Equal(t, []int{
+		http.StatusOK,
+		http.StatusNotModified,
+		http.StatusOK,
+		// Application errors are never tagged.
+		http.StatusOK,
+	}, recorder.statuses)
+}
+
+func TestETagsSkipErrors(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	rpcHandler := transporttest.NewMockUnaryHandler(mockCtrl)
+	router := transporttest.NewMockRouter(mockCtrl)
+	router.EXPECT().Choose(gomock.Any(), gomock.Any()).
+		Return(transport.NewUnaryHandlerSpec(rpcHandler), nil)
+	rpcHandler.EXPECT().Handle(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(yarpcerrors.InternalErrorf("great sadness"))
+
+	req := httptest.NewRequest("POST", "/", bytes.NewReader(nil))
+	req.Header.Set(CallerHeader, "caller")
+	req.Header.Set(ServiceHeader, "service")
+	req.Header.Set(ProcedureHeader, "hello")
+	req.Header.Set(EncodingHeader, "raw")
+	req.Header.Set(TTLMSHeader, "1000")
+	req.Header.Set(ifNoneMatchHeader, "*")
+	rec := httptest.NewRecorder()
+	handler{
+		router:         router,
+		tracer:         &opentracing.NoopTracer{},
+		etagProcedures: map[string]struct{}{"hello": {}},
+	}.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Empty(t, rec.Header().Get(etagHeader))
+}
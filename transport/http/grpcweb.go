@@ -0,0 +1,349 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/bodylimit"
+	"go.uber.org/yarpc/pkg/procedure"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	_grpcWebContentType     = "application/grpc-web"
+	_grpcWebTextContentType = "application/grpc-web-text"
+
+	// Flags of grpc-web frames.
+	_grpcWebCompressed = 0x01
+	_grpcWebTrailers   = 0x80
+
+	// Service of procedures whose names have no service part, as used by
+	// the gRPC transport.
+	_grpcDefaultService = "__default__"
+)
+
+// _grpcWebReservedHeaders are headers of grpc-web requests that are not
+// passed to handlers as application headers, in addition to headers
+// starting with "rpc-", "grpc-", "sec-", or "access-control-".
+var _grpcWebReservedHeaders = map[string]struct{}{
+	"accept":          {},
+	"accept-encoding": {},
+	"accept-language": {},
+	"connection":      {},
+	"content-length":  {},
+	"content-type":    {},
+	"host":            {},
+	"origin":          {},
+	"referer":         {},
+	"te":              {},
+	"user-agent":      {},
+	"x-grpc-web":      {},
+	"x-user-agent":    {},
+}
+
+// GRPCWeb makes the inbound accept unary requests from grpc-web clients,
+// including those using the grpc-web-text encoding, so that web frontends
+// can call Protobuf procedures without a translating proxy. Requests are
+// recognized by their application/grpc-web content types; other requests
+// are handled as usual.
+//
+// As with the gRPC transport, requests carry the caller and service names in
+// the rpc-caller and rpc-service metadata, and other metadata are passed to
+// handlers as application headers. Deadlines are read from the grpc-timeout
+// metadata. Browsers may need the HTTPMiddleware option to add CORS support.
+//
+// Compressed messages and streaming procedures are not supported.
+func GRPCWeb() InboundOption {
+	return func(i *Inbound) {
+		i.grpcWeb = true
+	}
+}
+
+// grpcWebHandler serves grpc-web requests, passing other requests to the
+// next handler.
+type grpcWebHandler struct {
+	h    handler
+	next http.Handler
+}
+
+func (g grpcWebHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	contentType := req.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, _grpcWebContentType) {
+		g.next.ServeHTTP(w, req)
+		return
+	}
+	if req.Method != http.MethodPost {
+		http.Error(w, fmt.Sprintf("request method was %s but only %s is allowed", req.Method, http.MethodPost), http.StatusMethodNotAllowed)
+		return
+	}
+	text := strings.HasPrefix(contentType, _grpcWebTextContentType)
+
+	resw := newGRPCWebResponseWriter()
+	err := g.handle(req, resw, text)
+
+	var out bytes.Buffer
+	if err == nil {
+		writeGRPCWebFrame(&out, 0, resw.body.Bytes())
+	}
+	var trailers bytes.Buffer
+	writeGRPCWebStatus(&trailers, err, resw.applicationError)
+	writeGRPCWebFrame(&out, _grpcWebTrailers, trailers.Bytes())
+
+	for k, v := range resw.headers.OriginalItems() {
+		w.Header().Add(k, v)
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	if text {
+		enc := base64.NewEncoder(base64.StdEncoding, w)
+		enc.Write(out.Bytes())
+		enc.Close()
+		return
+	}
+	w.Write(out.Bytes())
+}
+
+func (g grpcWebHandler) handle(req *http.Request, resw *grpcWebResponseWriter, text bool) error {
+	start := time.Now()
+	defer req.Body.Close()
+
+	if err := g.h.headerLimits.CheckMap(req.Header); err != nil {
+		return err
+	}
+	procedure, err := procedureFromGRPCPath(req.URL.Path)
+	if err != nil {
+		return err
+	}
+
+	var body io.Reader = req.Body
+	if text {
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+	if body, err = bodylimit.Limit(body, g.h.maxRequestBodySize); err != nil {
+		return err
+	}
+	message, err := readGRPCWebMessage(body)
+	if err != nil {
+		return err
+	}
+
+	treq := &transport.Request{
+		Caller:          req.Header.Get(CallerHeader),
+		Service:         req.Header.Get(ServiceHeader),
+		Procedure:       procedure,
+		Encoding:        grpcWebEncoding(req.Header),
+		Transport:       transportName,
+		ShardKey:        req.Header.Get(ShardKeyHeader),
+		RoutingKey:      req.Header.Get(RoutingKeyHeader),
+		RoutingDelegate: req.Header.Get(RoutingDelegateHeader),
+		Headers:         grpcWebApplicationHeaders(req.Header),
+		Body:            bytes.NewReader(message),
+	}
+	if err := transport.ValidateRequest(treq); err != nil {
+		return err
+	}
+
+	treq.Metadata = &transport.Metadata{
+		HTTP: &transport.HTTPMetadata{Method: req.Method, URL: req.URL},
+	}
+	ctx := transport.WithRemotePeer(req.Context(), transport.RemotePeer{
+		Address: req.RemoteAddr,
+		TLS:     req.TLS,
+	})
+	ctx, cancel, err := parseGRPCTimeout(ctx, req.Header.Get("grpc-timeout"))
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	ctx, span := g.h.createSpan(ctx, req, treq, start)
+	defer span.Finish()
+
+	spec, err := g.h.router.Choose(ctx, treq)
+	if err == nil {
+		err = transport.ValidateRequestContext(ctx)
+	}
+	if err == nil {
+		if spec.Type() != transport.Unary {
+			err = yarpcerrors.UnimplementedErrorf("grpc-web does not handle %s handlers", spec.Type().String())
+		} else {
+			err = transport.DispatchUnaryHandler(ctx, spec.Unary(), start, treq, resw)
+		}
+	}
+	updateSpanWithErr(span, err)
+	return err
+}
+
+// procedureFromGRPCPath converts the path of a gRPC method,
+// "/package.Service/Method", into a YARPC procedure name.
+func procedureFromGRPCPath(path string) (string, error) {
+	path = strings.TrimPrefix(path, "/")
+	pos := strings.LastIndex(path, "/")
+	if pos <= 0 || pos == len(path)-1 {
+		return "", yarpcerrors.InvalidArgumentErrorf("invalid gRPC method %q", path)
+	}
+	service, err := url.QueryUnescape(path[:pos])
+	if err != nil {
+		return "", yarpcerrors.InvalidArgumentErrorf("invalid gRPC method %q: %v", path, err)
+	}
+	method, err := url.QueryUnescape(path[pos+1:])
+	if err != nil {
+		return "", yarpcerrors.InvalidArgumentErrorf("invalid gRPC method %q: %v", path, err)
+	}
+	if service == _grpcDefaultService {
+		return method, nil
+	}
+	return procedure.ToName(service, method), nil
+}
+
+// grpcWebEncoding returns the encoding of a grpc-web request, from the
+// rpc-encoding metadata or the subtype of its content type.
+func grpcWebEncoding(header http.Header) transport.Encoding {
+	if encoding := header.Get(EncodingHeader); encoding != "" {
+		return transport.Encoding(encoding)
+	}
+	contentType := header.Get("Content-Type")
+	if i := strings.IndexByte(contentType, '+'); i >= 0 {
+		return transport.Encoding(contentType[i+1:])
+	}
+	return transport.Encoding("proto")
+}
+
+func grpcWebApplicationHeaders(header http.Header) transport.Headers {
+	headers := transport.NewHeadersWithCapacity(len(header))
+	for k, vs := range header {
+		k = strings.ToLower(k)
+		if _, ok := _grpcWebReservedHeaders[k]; ok {
+			continue
+		}
+		if strings.HasPrefix(k, "rpc-") || strings.HasPrefix(k, "grpc-") ||
+			strings.HasPrefix(k, "sec-") || strings.HasPrefix(k, "access-control-") {
+			continue
+		}
+		if len(vs) > 0 {
+			headers = headers.With(k, vs[0])
+		}
+	}
+	return headers
+}
+
+// readGRPCWebMessage reads the single message of a unary grpc-web request.
+func readGRPCWebMessage(r io.Reader) ([]byte, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return nil, yarpcerrors.InvalidArgumentErrorf("failed to read grpc-web message: %v", err)
+	}
+	if prefix[0]&_grpcWebCompressed != 0 {
+		return nil, yarpcerrors.UnimplementedErrorf("compressed grpc-web messages are not supported")
+	}
+	size := binary.BigEndian.Uint32(prefix[1:])
+	message, err := ioutil.ReadAll(io.LimitReader(r, int64(size)))
+	if err != nil {
+		return nil, err
+	}
+	if uint32(len(message)) != size {
+		return nil, yarpcerrors.InvalidArgumentErrorf(
+			"grpc-web message was %d bytes but its frame declared %d bytes", len(message), size)
+	}
+	return message, nil
+}
+
+func writeGRPCWebFrame(w *bytes.Buffer, flags byte, data []byte) {
+	var prefix [5]byte
+	prefix[0] = flags
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(data)))
+	w.Write(prefix[:])
+	w.Write(data)
+}
+
+// writeGRPCWebStatus writes the trailers of a grpc-web response for the
+// given error. YARPC codes have the same values as gRPC status codes.
+func writeGRPCWebStatus(w *bytes.Buffer, err error, applicationError bool) {
+	code, message := yarpcerrors.CodeOK, ""
+	if err != nil {
+		status := yarpcerrors.FromError(err)
+		code, message = status.Code(), status.Message()
+	}
+	fmt.Fprintf(w, "grpc-status: %d\r\n", int(code))
+	if message != "" {
+		fmt.Fprintf(w, "grpc-message: %s\r\n", url.PathEscape(message))
+	}
+	if applicationError {
+		io.WriteString(w, "rpc-application-error: error\r\n")
+	}
+}
+
+// parseGRPCTimeout applies a grpc-timeout, like "100m" for 100
+// milliseconds, to the context.
+func parseGRPCTimeout(ctx context.Context, timeout string) (context.Context, context.CancelFunc, error) {
+	if timeout == "" {
+		return ctx, func() {}, nil
+	}
+	units := map[byte]time.Duration{
+		'H': time.Hour,
+		'M': time.Minute,
+		'S': time.Second,
+		'm': time.Millisecond,
+		'u': time.Microsecond,
+		'n': time.Nanosecond,
+	}
+	unit, ok := units[timeout[len(timeout)-1]]
+	value, err := strconv.ParseInt(timeout[:len(timeout)-1], 10, 64)
+	if !ok || err != nil || value < 0 {
+		return ctx, func() {}, yarpcerrors.InvalidArgumentErrorf("invalid grpc-timeout %q", timeout)
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(value)*unit)
+	return ctx, cancel, nil
+}
+
+// grpcWebResponseWriter buffers the response of a grpc-web request so that
+// it can be framed.
+type grpcWebResponseWriter struct {
+	body             bytes.Buffer
+	headers          transport.Headers
+	applicationError bool
+}
+
+func newGRPCWebResponseWriter() *grpcWebResponseWriter {
+	return &grpcWebResponseWriter{headers: transport.NewHeaders()}
+}
+
+func (w *grpcWebResponseWriter) Write(p []byte) (int, error) { return w.body.Write(p) }
+
+func (w *grpcWebResponseWriter) AddHeaders(h transport.Headers) {
+	for k, v := range h.OriginalItems() {
+		w.headers = w.headers.With(k, v)
+	}
+}
+
+func (w *grpcWebResponseWriter) SetApplicationError() { w.applicationError = true }
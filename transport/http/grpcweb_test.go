@@ -0,0 +1,156 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type grpcWebEcho struct{}
+
+func (grpcWebEcho) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	if _, ok := ctx.Deadline(); !ok {
+		return fmt.Errorf("no deadline")
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	if string(body) == "fail" {
+		return yarpcerrors.NotFoundErrorf("no such key")
+	}
+	value, _ := req.Headers.Get("x-key")
+	resw.AddHeaders(transport.NewHeaders().With("x-echo", value))
+	_, err = resw.Write(body)
+	return err
+}
+
+func grpcWebFrame(flags byte, data string) []byte {
+	var buf bytes.Buffer
+	writeGRPCWebFrame(&buf, flags, []byte(data))
+	return buf.Bytes()
+}
+
+func TestGRPCWeb(t *testing.T) {
+	inbound := NewTransport().NewInbound("127.0.0.1:0", GRPCWeb())
+	inbound.SetRouter(newTestRouter([]transport.Procedure{{
+		Name:        "kv.KeyValue::Echo",
+		HandlerSpec: transport.NewUnaryHandlerSpec(grpcWebEcho{}),
+	}}))
+	require.NoError(t, inbound.Start())
+	defer inbound.Stop()
+
+	call := func(t *testing.T, contentType, path string, body []byte) (*http.Response, []byte) {
+		req, err := http.NewRequest("POST", fmt.Sprintf("http://%v%v", inbound.Addr(), path), bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("rpc-caller", "web")
+		req.Header.Set("rpc-service", "kv")
+		req.Header.Set("x-key", "foo")
+		req.Header.Set("grpc-timeout", "1S")
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		out, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res, out
+	}
+
+	t.Run("binary", func(t *testing.T) {
+		res, body := call(t, "application/grpc-web+proto", "/kv.KeyValue/Echo", grpcWebFrame(0, "hello"))
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "application/grpc-web+proto", res.Header.Get("Content-Type"))
+		assert.Equal(t, "foo", res.Header.Get("x-echo"))
+		want := append(grpcWebFrame(0, "hello"), grpcWebFrame(_grpcWebTrailers, "grpc-status: 0\r\n")...)
+		assert.Equal(t, want, body)
+	})
+
+	t.Run("text", func(t *testing.T) {
+		req := base64.StdEncoding.EncodeToString(grpcWebFrame(0, "hello"))
+		_, body := call(t, "application/grpc-web-text", "/kv.KeyValue/Echo", []byte(req))
+		decoded, err := base64.StdEncoding.DecodeString(string(body))
+		require.NoError(t, err)
+		assert.Equal(t, grpcWebFrame(0, "hello"), decoded[:10])
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, body := call(t, "application/grpc-web+proto", "/kv.KeyValue/Echo", grpcWebFrame(0, "fail"))
+		assert.Equal(t, grpcWebFrame(_grpcWebTrailers, "grpc-status: 5\r\ngrpc-message: no%20such%20key\r\n"), body)
+	})
+
+	t.Run("compressed", func(t *testing.T) {
+		_, body := call(t, "application/grpc-web+proto", "/kv.KeyValue/Echo", grpcWebFrame(_grpcWebCompressed, "hello"))
+		assert.Contains(t, string(body), "grpc-status: 12\r\n")
+	})
+
+	t.Run("not grpc-web", func(t *testing.T) {
+		res, _ := call(t, "application/octet-stream", "/kv.KeyValue/Echo", []byte("hello"))
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode, "must be handled as a plain YARPC request")
+	})
+}
+
+func TestProcedureFromGRPCPath(t *testing.T) {
+	tests := []struct {
+		give    string
+		want    string
+		wantErr bool
+	}{
+		{give: "/kv.KeyValue/Get", want: "kv.KeyValue::Get"},
+		{give: "/__default__/echo", want: "echo"},
+		{give: "/noslash", wantErr: true},
+		{give: "/kv.KeyValue/", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := procedureFromGRPCPath(tt.give)
+		if tt.wantErr {
+			assert.Error(t, err, tt.give)
+			continue
+		}
+		require.NoError(t, err, tt.give)
+		assert.Equal(t, tt.want, got)
+	}
+}
+
+func TestParseGRPCTimeout(t *testing.T) {
+	ctx, cancel, err := parseGRPCTimeout(context.Background(), "100m")
+	require.NoError(t, err)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	assert.True(t, time.Until(deadline) <= 100*time.Millisecond)
+
+	for _, give := range []string{"100", "-1S", "10x", "S"} {
+		_, _, err := parseGRPCTimeout(context.Background(), give)
+		assert.Error(t, err, give)
+	}
+}
@@ -24,7 +24,9 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
@@ -32,12 +34,25 @@ import (
 	opentracinglog "github.com/opentracing/opentracing-go/log"
 	"go.uber.org/yarpc"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/bodylimit"
 	"go.uber.org/yarpc/internal/bufferpool"
+	"go.uber.org/yarpc/internal/headerlimit"
 	"go.uber.org/yarpc/internal/iopool"
+	intyarpcerrors "go.uber.org/yarpc/internal/yarpcerrors"
 	"go.uber.org/yarpc/pkg/errors"
 	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
 )
 
+// sizedBody reports the length of bodies with a known Content-Length so
+// that encodings can read them without growing their buffers.
+func sizedBody(body io.ReadCloser, contentLength int64) io.ReadCloser {
+	if contentLength <= 0 {
+		return body
+	}
+	return iopool.NewLenReader(body, contentLength)
+}
+
 func popHeader(h http.Header, n string) string {
 	v := h.Get(n)
 	h.Del(n)
@@ -46,10 +61,16 @@ func popHeader(h http.Header, n string) string {
 
 // handler adapts a transport.Handler into a handler for net/http.
 type handler struct {
-	router            transport.Router
-	tracer            opentracing.Tracer
-	grabHeaders       map[string]struct{}
-	bothResponseError bool
+	router             transport.Router
+	tracer             opentracing.Tracer
+	grabHeaders        map[string]struct{}
+	errorStatusCodes   map[yarpcerrors.Code]int
+	maxRequestBodySize int64
+	headerLimits       headerlimit.Limits
+	bothResponseError  bool
+	onewayQueues       *onewayQueues
+	etagProcedures     map[string]struct{}
+	logger             *zap.Logger
 }
 
 func (h handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
@@ -61,9 +82,21 @@ func (h handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	responseWriter.AddSystemHeader(ServiceHeader, service)
 	status := yarpcerrors.FromError(errors.WrapHandlerError(h.callHandler(responseWriter, req, service, procedure), service, procedure))
 	if status == nil {
-		responseWriter.Close(http.StatusOK)
+		httpStatusCode := http.StatusOK
+		if _, ok := h.etagProcedures[procedure]; ok && responseWriter.setETag(req.Header.Get(ifNoneMatchHeader)) {
+			httpStatusCode = http.StatusNotModified
+		}
+		responseWriter.Close(httpStatusCode)
 		return
 	}
+	if responseWriter.wroteHeader {
+		// The handler failed after it started streaming the response, so the
+		// success status has already been sent. Abort the response so that
+		// the caller does not mistake the partial body for a success.
+		panic(http.ErrAbortHandler)
+	}
+	// Errors are always buffered.
+	responseWriter.streaming = false
 	if statusCodeText, marshalErr := status.Code().MarshalText(); marshalErr != nil {
 		status = yarpcerrors.Newf(yarpcerrors.CodeInternal, "error %s had code %v which is unknown", status.Error(), status.Code())
 		responseWriter.AddSystemHeader(ErrorCodeHeader, "internal")
@@ -73,6 +106,15 @@ func (h handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	if status.Name() != "" {
 		responseWriter.AddSystemHeader(ErrorNameHeader, status.Name())
 	}
+	if details := intyarpcerrors.DetailsHeader(h.logger, status); details != "" {
+		responseWriter.AddSystemHeader(ErrorDetailsHeader, details)
+	}
+	if retryAfter, ok := status.RetryAfter(); ok {
+		// Retry-After only supports whole seconds; round up so that clients
+		// never retry early.
+		seconds := int64((retryAfter + time.Second - 1) / time.Second)
+		responseWriter.AddSystemHeader(RetryAfterHeader, strconv.FormatInt(seconds, 10))
+	}
 	if bothResponseError && h.bothResponseError {
 		responseWriter.AddSystemHeader(BothResponseErrorHeader, AcceptTrue)
 		responseWriter.AddSystemHeader(ErrorMessageHeader, status.Message())
@@ -81,7 +123,10 @@ func (h handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		_, _ = fmt.Fprintln(responseWriter, status.Message())
 		responseWriter.AddSystemHeader("Content-Type", "text/plain; charset=utf8")
 	}
-	httpStatusCode, ok := _codeToStatusCode[status.Code()]
+	httpStatusCode, ok := h.errorStatusCodes[status.Code()]
+	if !ok {
+		httpStatusCode, ok = _codeToStatusCode[status.Code()]
+	}
 	if !ok {
 		httpStatusCode = http.StatusInternalServerError
 	}
@@ -94,6 +139,9 @@ func (h handler) callHandler(responseWriter *responseWriter, req *http.Request,
 	if req.Method != http.MethodPost {
 		return yarpcerrors.Newf(yarpcerrors.CodeNotFound, "request method was %s but only %s is allowed", req.Method, http.MethodPost)
 	}
+	if err := h.headerLimits.CheckMap(req.Header); err != nil {
+		return err
+	}
 	treq := &transport.Request{
 		Caller:          popHeader(req.Header, CallerHeader),
 		Service:         service,
@@ -104,7 +152,7 @@ func (h handler) callHandler(responseWriter *responseWriter, req *http.Request,
 		RoutingKey:      popHeader(req.Header, RoutingKeyHeader),
 		RoutingDelegate: popHeader(req.Header, RoutingDelegateHeader),
 		Headers:         applicationHeaders.FromHTTPHeaders(req.Header, transport.Headers{}),
-		Body:            req.Body,
+		Body:            sizedBody(req.Body, req.ContentLength),
 	}
 	for header := range h.grabHeaders {
 		if value := req.Header.Get(header); value != "" {
@@ -114,6 +162,12 @@ func (h handler) callHandler(responseWriter *responseWriter, req *http.Request,
 	if err := transport.ValidateRequest(treq); err != nil {
 		return err
 	}
+	body, err := bodylimit.Limit(treq.Body, h.maxRequestBodySize)
+	if err != nil {
+		return err
+	}
+	treq.Body = body
+	responseWriter.contentType = getContentType(treq.Encoding)
 	defer func() {
 		if retErr == nil {
 			if contentType := getContentType(treq.Encoding); contentType != "" {
@@ -122,7 +176,13 @@ func (h handler) callHandler(responseWriter *responseWriter, req *http.Request,
 		}
 	}()
 
-	ctx := req.Context()
+	treq.Metadata = &transport.Metadata{
+		HTTP: &transport.HTTPMetadata{Method: req.Method, URL: req.URL},
+	}
+	ctx := transport.WithRemotePeer(req.Context(), transport.RemotePeer{
+		Address: req.RemoteAddr,
+		TLS:     req.TLS,
+	})
 	ctx, cancel, parseTTLErr := parseTTL(ctx, treq, popHeader(req.Header, TTLMSHeader))
 	// parseTTLErr != nil is a problem only if the request is unary.
 	defer cancel()
@@ -147,7 +207,11 @@ func (h handler) callHandler(responseWriter *responseWriter, req *http.Request,
 		err = transport.DispatchUnaryHandler(ctx, spec.Unary(), start, treq, responseWriter)
 
 	case transport.Oneway:
-		err = handleOnewayRequest(span, treq, spec.Oneway())
+		err = h.handleOnewayRequest(span, treq, spec.Oneway())
+		if err == nil {
+			// The handler runs in the background, after the response.
+			responseWriter.AddSystemHeader(AckStatusHeader, transport.AckStatusQueued.String())
+		}
 
 	default:
 		err = yarpcerrors.Newf(yarpcerrors.CodeUnimplemented, "transport http does not handle %s handlers", spec.Type().String())
@@ -157,30 +221,35 @@ func (h handler) callHandler(responseWriter *responseWriter, req *http.Request,
 	return err
 }
 
-func handleOnewayRequest(
+func (h handler) handleOnewayRequest(
 	span opentracing.Span,
 	treq *transport.Request,
 	onewayHandler transport.OnewayHandler,
 ) error {
 	// we will lose access to the body unless we read all the bytes before
 	// returning from the request
-	var buff bytes.Buffer
-	if _, err := iopool.Copy(&buff, treq.Body); err != nil {
+	body, err := iopool.ReadAll(treq.Body)
+	if err != nil {
 		return err
 	}
-	treq.Body = &buff
+	treq.Body = bytes.NewReader(body)
 
 	// create a new context for oneway requests since the HTTP handler cancels
 	// http.Request's context when ServeHTTP returns
 	ctx := opentracing.ContextWithSpan(context.Background(), span)
 
-	go func() {
+	handle := func() {
 		// ensure the span lasts for length of the handler in case of errors
 		defer span.Finish()
 
 		err := transport.DispatchOnewayHandler(ctx, onewayHandler, treq)
 		updateSpanWithErr(span, err)
-	}()
+	}
+	if h.onewayQueues != nil && treq.ShardKey != "" {
+		h.onewayQueues.run(treq.ShardKey, handle)
+	} else {
+		go handle()
+	}
 	return nil
 }
 
@@ -220,9 +289,19 @@ func (h handler) createSpan(ctx context.Context, req *http.Request, treq *transp
 }
 
 // responseWriter adapts a http.ResponseWriter into a transport.ResponseWriter.
+//
+// Responses are buffered so that errors returned by handlers can replace
+// them, unless the handler switches to pass-through mode with
+// StreamResponse.
 type responseWriter struct {
 	w      http.ResponseWriter
 	buffer *bufferpool.Buffer
+
+	// contentType is sent with streamed responses, which are sent before
+	// the handler returns.
+	contentType string
+	streaming   bool
+	wroteHeader bool
 }
 
 func newResponseWriter(w http.ResponseWriter) *responseWriter {
@@ -231,12 +310,38 @@ func newResponseWriter(w http.ResponseWriter) *responseWriter {
 }
 
 func (rw *responseWriter) Write(s []byte) (int, error) {
+	if rw.streaming {
+		if !rw.wroteHeader {
+			rw.writeStreamHeader()
+		}
+		return rw.w.Write(s)
+	}
 	if rw.buffer == nil {
 		rw.buffer = bufferpool.Get()
 	}
 	return rw.buffer.Write(s)
 }
 
+// StreamResponse implements transport.StreamingResponseWriter.
+func (rw *responseWriter) StreamResponse() bool {
+	rw.streaming = true
+	return true
+}
+
+func (rw *responseWriter) writeStreamHeader() {
+	if rw.contentType != "" {
+		rw.w.Header().Set("Content-Type", rw.contentType)
+	}
+	rw.w.WriteHeader(http.StatusOK)
+	rw.wroteHeader = true
+	if rw.buffer != nil {
+		// Flush anything written before the handler started streaming.
+		_, _ = rw.buffer.WriteTo(rw.w)
+		bufferpool.Put(rw.buffer)
+		rw.buffer = nil
+	}
+}
+
 func (rw *responseWriter) AddHeaders(h transport.Headers) {
 	applicationHeaders.ToHTTPHeaders(h, rw.w.Header())
 }
@@ -256,6 +361,15 @@ func (rw *responseWriter) ResetBuffer() {
 }
 
 func (rw *responseWriter) Close(httpStatusCode int) {
+	if rw.wroteHeader {
+		// The response was streamed.
+		return
+	}
+	if rw.buffer != nil {
+		// The whole body is buffered so we know its length. Advertising it
+		// avoids chunked encoding and lets callers size their buffers.
+		rw.w.Header().Set("Content-Length", strconv.Itoa(rw.buffer.Len()))
+	}
 	rw.w.WriteHeader(httpStatusCode)
 	if rw.buffer != nil {
 		// TODO: what to do with error?
@@ -27,6 +27,8 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -38,7 +40,9 @@ import (
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/api/transport/transporttest"
 	"go.uber.org/yarpc/encoding/raw"
+	"go.uber.org/yarpc/internal/headerlimit"
 	"go.uber.org/yarpc/internal/routertest"
+	"go.uber.org/yarpc/internal/testtime"
 	"go.uber.org/yarpc/yarpcerrors"
 )
 
@@ -97,6 +101,179 @@ func TestHandlerSuccess(t *testing.T) {
 	assert.Equal(t, rw.Body.String(), "")
 }
 
+func TestHandlerOnewayAckStatus(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	headers := make(http.Header)
+	headers.Set(CallerHeader, "moe")
+	headers.Set(EncodingHeader, "raw")
+	headers.Set(TTLMSHeader, "1000")
+	headers.Set(ProcedureHeader, "nyuck")
+	headers.Set(ServiceHeader, "curly")
+
+	router := transporttest.NewMockRouter(mockCtrl)
+	rpcHandler := transporttest.NewMockOnewayHandler(mockCtrl)
+	router.EXPECT().Choose(gomock.Any(), gomock.Any()).Return(transport.NewOnewayHandlerSpec(rpcHandler), nil)
+
+	handled := make(chan struct{})
+	rpcHandler.EXPECT().HandleOneway(gomock.Any(), gomock.Any()).
+		Do(func(context.Context, *transport.Request) { close(handled) }).
+		Return(nil)
+
+	httpHandler := handler{router: router, tracer: &opentracing.NoopTracer{}}
+	req := &http.Request{
+		Method: "POST",
+		Header: headers,
+		Body:   ioutil.NopCloser(bytes.NewReader([]byte("Nyuck Nyuck"))),
+	}
+	rw := httptest.NewRecorder()
+	httpHandler.ServeHTTP(rw, req)
+	assert.Equal(t, 200, rw.Code)
+	assert.Equal(t, "queued", rw.Header().Get(AckStatusHeader))
+	<-handled
+}
+
+func TestHandlerOrderedOneway(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	router := transporttest.NewMockRouter(mockCtrl)
+	rpcHandler := transporttest.NewMockOnewayHandler(mockCtrl)
+	router.EXPECT().Choose(gomock.Any(), gomock.Any()).Return(transport.NewOnewayHandlerSpec(rpcHandler), nil).Times(10)
+
+	var got []string
+	rpcHandler.EXPECT().HandleOneway(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, req *transport.Request) {
+			body, err := ioutil.ReadAll(req.Body)
+			assert.NoError(t, err)
+			got = append(got, string(body))
+		}).
+		Return(nil).
+		Times(10)
+
+	queues := newOnewayQueues(4)
+	httpHandler := handler{router: router, tracer: &opentracing.NoopTracer{}, onewayQueues: queues}
+	var want []string
+	for i := 0; i < 10; i++ {
+		headers := make(http.Header)
+		headers.Set(CallerHeader, "moe")
+		headers.Set(EncodingHeader, "raw")
+		headers.Set(TTLMSHeader, "1000")
+		headers.Set(ProcedureHeader, "nyuck")
+		headers.Set(ServiceHeader, "curly")
+		headers.Set(ShardKeyHeader, "larry")
+		body := fmt.Sprintf("nyuck %d", i)
+		want = append(want, body)
+
+		rw := httptest.NewRecorder()
+		httpHandler.ServeHTTP(rw, &http.Request{
+			Method: "POST",
+			Header: headers,
+			Body:   ioutil.NopCloser(bytes.NewReader([]byte(body))),
+		})
+		assert.Equal(t, 200, rw.Code)
+	}
+	queues.stop()
+	assert.Equal(t, want, got, "handlers for a shard key must run in order")
+}
+
+func TestHandlerMaxRequestBodySize(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	router := transporttest.NewMockRouter(mockCtrl)
+	rpcHandler := transporttest.NewMockUnaryHandler(mockCtrl)
+	router.EXPECT().Choose(gomock.Any(), gomock.Any()).
+		Return(transport.NewUnaryHandlerSpec(rpcHandler), nil).AnyTimes()
+	rpcHandler.EXPECT().Handle(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, req *transport.Request, _ transport.ResponseWriter) error {
+			_, err := ioutil.ReadAll(req.Body)
+			return err
+		})
+
+	httpHandler := handler{
+		router:             router,
+		tracer:             &opentracing.NoopTracer{},
+		maxRequestBodySize: 5,
+		bothResponseError:  true,
+	}
+
+	tests := []struct {
+		desc          string
+		contentLength int64
+	}{
+		// Rejected before the handler is called.
+		{desc: "content length", contentLength: 11},
+		// Rejected when the handler reads the body.
+		{desc: "unknown length", contentLength: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			headers := make(http.Header)
+			headers.Set(CallerHeader, "moe")
+			headers.Set(EncodingHeader, "raw")
+			headers.Set(TTLMSHeader, "1000")
+			headers.Set(ProcedureHeader, "nyuck")
+			headers.Set(ServiceHeader, "curly")
+
+			req := &http.Request{
+				Method:        "POST",
+				Header:        headers,
+				Body:          ioutil.NopCloser(bytes.NewReader([]byte("Nyuck Nyuck"))),
+				ContentLength: tt.contentLength,
+			}
+			rw := httptest.NewRecorder()
+			httpHandler.ServeHTTP(rw, req)
+			assert.Equal(t, http.StatusTooManyRequests, rw.Code)
+			assert.Equal(t, "resource-exhausted", rw.Header().Get(ErrorCodeHeader))
+		})
+	}
+}
+
+func TestHandlerHeaderLimits(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	// The router must not be called for rejected requests.
+	router := transporttest.NewMockRouter(mockCtrl)
+
+	tests := []struct {
+		desc   string
+		limits headerlimit.Limits
+	}{
+		{desc: "bytes", limits: headerlimit.Limits{MaxBytes: 64}},
+		{desc: "count", limits: headerlimit.Limits{MaxCount: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			httpHandler := handler{
+				router:       router,
+				tracer:       &opentracing.NoopTracer{},
+				headerLimits: tt.limits,
+			}
+			headers := make(http.Header)
+			headers.Set(CallerHeader, "moe")
+			headers.Set(EncodingHeader, "raw")
+			headers.Set(TTLMSHeader, "1000")
+			headers.Set(ProcedureHeader, "nyuck")
+			headers.Set(ServiceHeader, "curly")
+			headers.Set("Rpc-Header-Bomb", strings.Repeat("x", 64))
+
+			rw := httptest.NewRecorder()
+			httpHandler.ServeHTTP(rw, &http.Request{
+				Method: "POST",
+				Header: headers,
+				Body:   ioutil.NopCloser(bytes.NewReader([]byte("Nyuck Nyuck"))),
+			})
+			assert.Equal(t, http.StatusBadRequest, rw.Code)
+			assert.Equal(t, "invalid-argument", rw.Header().Get(ErrorCodeHeader))
+		})
+	}
+}
+
 func TestHandlerHeaders(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -361,6 +538,121 @@ func (th panickedHandler) Handle(context.Context, *transport.Request, transport.
 	panic("oops I panicked!")
 }
 
+func TestHandlerErrorStatusCodes(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	headers := make(http.Header)
+	headers.Set(CallerHeader, "somecaller")
+	headers.Set(EncodingHeader, "raw")
+	headers.Set(TTLMSHeader, "1000")
+	headers.Set(ProcedureHeader, "hello")
+	headers.Set(ServiceHeader, "fake")
+
+	request := http.Request{
+		Method: "POST",
+		Header: headers,
+		Body:   ioutil.NopCloser(bytes.NewReader([]byte{})),
+	}
+
+	rpcHandler := transporttest.NewMockUnaryHandler(mockCtrl)
+	rpcHandler.EXPECT().Handle(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(yarpcerrors.Newf(yarpcerrors.CodeResourceExhausted, "slow down"))
+
+	router := transporttest.NewMockRouter(mockCtrl)
+	router.EXPECT().Choose(gomock.Any(), gomock.Any()).
+		Return(transport.NewUnaryHandlerSpec(rpcHandler), nil)
+
+	httpHandler := handler{
+		router:            router,
+		tracer:            &opentracing.NoopTracer{},
+		bothResponseError: true,
+		errorStatusCodes: map[yarpcerrors.Code]int{
+			yarpcerrors.CodeResourceExhausted: http.StatusServiceUnavailable,
+		},
+	}
+	httpResponse := httptest.NewRecorder()
+	httpHandler.ServeHTTP(httpResponse, &request)
+
+	assert.Equal(t, http.StatusServiceUnavailable, httpResponse.Code)
+	assert.Equal(t, "resource-exhausted", httpResponse.Header().Get(ErrorCodeHeader))
+}
+
+func TestHandlerRetryAfter(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	headers := make(http.Header)
+	headers.Set(CallerHeader, "somecaller")
+	headers.Set(EncodingHeader, "raw")
+	headers.Set(TTLMSHeader, "1000")
+	headers.Set(ProcedureHeader, "hello")
+	headers.Set(ServiceHeader, "fake")
+
+	request := http.Request{
+		Method: "POST",
+		Header: headers,
+		Body:   ioutil.NopCloser(bytes.NewReader([]byte{})),
+	}
+
+	rpcHandler := transporttest.NewMockUnaryHandler(mockCtrl)
+	rpcHandler.EXPECT().Handle(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(yarpcerrors.Newf(yarpcerrors.CodeResourceExhausted, "slow down").WithRetryAfter(1500 * time.Millisecond))
+
+	router := transporttest.NewMockRouter(mockCtrl)
+	router.EXPECT().Choose(gomock.Any(), gomock.Any()).
+		Return(transport.NewUnaryHandlerSpec(rpcHandler), nil)
+
+	httpHandler := handler{router: router, tracer: &opentracing.NoopTracer{}}
+	httpResponse := httptest.NewRecorder()
+	httpHandler.ServeHTTP(httpResponse, &request)
+
+	assert.Equal(t, "2", httpResponse.Header().Get(RetryAfterHeader), "Retry-After must be rounded up")
+}
+
+func TestHandlerRemotePeer(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	headers := make(http.Header)
+	headers.Set(CallerHeader, "somecaller")
+	headers.Set(EncodingHeader, "raw")
+	headers.Set(TTLMSHeader, "1000")
+	headers.Set(ProcedureHeader, "hello")
+	headers.Set(ServiceHeader, "fake")
+
+	request := http.Request{
+		Method:     "POST",
+		URL:        &url.URL{Path: "/"},
+		Header:     headers,
+		Body:       ioutil.NopCloser(bytes.NewReader([]byte{})),
+		RemoteAddr: "1.2.3.4:5678",
+	}
+
+	var (
+		remote transport.RemotePeer
+		meta   *transport.Metadata
+	)
+	rpcHandler := transporttest.NewMockUnaryHandler(mockCtrl)
+	rpcHandler.EXPECT().Handle(gomock.Any(), gomock.Any(), gomock.Any()).
+		Do(func(ctx context.Context, req *transport.Request, _ transport.ResponseWriter) {
+			remote, _ = transport.RemotePeerFromContext(ctx)
+			meta = req.Metadata
+		}).Return(nil)
+
+	router := transporttest.NewMockRouter(mockCtrl)
+	router.EXPECT().Choose(gomock.Any(), gomock.Any()).
+		Return(transport.NewUnaryHandlerSpec(rpcHandler), nil)
+
+	httpHandler := handler{router: router, tracer: &opentracing.NoopTracer{}}
+	httpHandler.ServeHTTP(httptest.NewRecorder(), &request)
+
+	assert.Equal(t, transport.RemotePeer{Address: "1.2.3.4:5678"}, remote)
+	assert.Equal(t, &transport.Metadata{
+		HTTP: &transport.HTTPMetadata{Method: "POST", URL: request.URL},
+	}, meta)
+}
+
 func TestHandlerPanic(t *testing.T) {
 	httpTransport := NewTransport()
 	inbound := httpTransport.NewInbound("localhost:0")
@@ -428,5 +720,166 @@ func TestResponseWriter(t *testing.T) {
 
 	assert.Equal(t, "bar", recorder.Header().Get("rpc-header-foo"))
 	assert.Equal(t, "123", recorder.Header().Get("rpc-header-shard-key"))
+	assert.Equal(t, "5", recorder.Header().Get("Content-Length"))
 	assert.Equal(t, "hello", recorder.Body.String())
 }
+
+func TestHandlerStreamResponse(t *testing.T) {
+	tests := []struct {
+		desc   string
+		handle func(transport.ResponseWriter) error
+
+		wantAbort   bool
+		wantCode    int
+		wantBody    string
+		wantHeaders map[string]string
+	}{
+		{
+			desc: "success",
+			handle: func(resw transport.ResponseWriter) error {
+				resw.AddHeaders(transport.NewHeaders().With("foo", "bar"))
+				_, _ = resw.Write([]byte("before "))
+				require.True(t, transport.StreamResponse(resw))
+				_, _ = resw.Write([]byte("hello "))
+				_, _ = resw.Write([]byte("world"))
+				return nil
+			},
+			wantCode: http.StatusOK,
+			wantBody: "before hello world",
+			wantHeaders: map[string]string{
+				"Rpc-Header-Foo": "bar",
+				"Content-Type":   "application/octet-stream",
+				"Content-Length": "",
+			},
+		},
+		{
+			desc: "failure before writing",
+			handle: func(resw transport.ResponseWriter) error {
+				transport.StreamResponse(resw)
+				return yarpcerrors.InternalErrorf("great sadness")
+			},
+			wantCode: http.StatusInternalServerError,
+			wantBody: "great sadness\n",
+		},
+		{
+			desc: "failure after writing",
+			handle: func(resw transport.ResponseWriter) error {
+				transport.StreamResponse(resw)
+				_, _ = resw.Write([]byte("partial"))
+				return yarpcerrors.InternalErrorf("great sadness")
+			},
+			wantAbort: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			router := transporttest.NewMockRouter(mockCtrl)
+			rpcHandler := transporttest.NewMockUnaryHandler(mockCtrl)
+			router.EXPECT().Choose(gomock.Any(), gomock.Any()).
+				Return(transport.NewUnaryHandlerSpec(rpcHandler), nil)
+			rpcHandler.EXPECT().Handle(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, _ *transport.Request, resw transport.ResponseWriter) error {
+					return tt.handle(resw)
+				})
+
+			headers := make(http.Header)
+			headers.Set(CallerHeader, "moe")
+			headers.Set(EncodingHeader, "raw")
+			headers.Set(TTLMSHeader, "1000")
+			headers.Set(ProcedureHeader, "nyuck")
+			headers.Set(ServiceHeader, "curly")
+			req := &http.Request{
+				Method: "POST",
+				Header: headers,
+				Body:   ioutil.NopCloser(bytes.NewReader(nil)),
+			}
+
+			httpHandler := handler{router: router, tracer: &opentracing.NoopTracer{}}
+			rw := httptest.NewRecorder()
+			if tt.wantAbort {
+				assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+					httpHandler.ServeHTTP(rw, req)
+				})
+				assert.Equal(t, http.StatusOK, rw.Code, "status must have been sent already")
+				return
+			}
+
+			httpHandler.ServeHTTP(rw, req)
+			assert.Equal(t, tt.wantCode, rw.Code)
+			assert.Equal(t, tt.wantBody, rw.Body.String())
+			for k, v := range tt.wantHeaders {
+				assert.Equal(t, v, rw.Header().Get(k), "header %q", k)
+			}
+		})
+	}
+}
+
+func TestStreamResponseEndToEnd(t *testing.T) {
+	chunk := bytes.Repeat([]byte("a"), 64*1024)
+	const chunks = 16
+
+	tests := []struct {
+		desc    string
+		fail    bool
+		wantErr bool
+	}{
+		{desc: "success"},
+		{desc: "failure after writing", fail: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			router := transporttest.NewMockRouter(mockCtrl)
+			rpcHandler := transporttest.NewMockUnaryHandler(mockCtrl)
+			router.EXPECT().Choose(gomock.Any(), gomock.Any()).
+				Return(transport.NewUnaryHandlerSpec(rpcHandler), nil)
+			rpcHandler.EXPECT().Handle(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, _ *transport.Request, resw transport.ResponseWriter) error {
+					require.True(t, transport.StreamResponse(resw))
+					for i := 0; i < chunks; i++ {
+						if _, err := resw.Write(chunk); err != nil {
+							return err
+						}
+					}
+					if tt.fail {
+						return yarpcerrors.InternalErrorf("great sadness")
+					}
+					return nil
+				})
+
+			server := httptest.NewServer(handler{router: router, tracer: &opentracing.NoopTracer{}})
+			defer server.Close()
+
+			out := NewTransport().NewSingleOutbound(server.URL)
+			require.NoError(t, out.Start())
+			defer out.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+			defer cancel()
+			res, err := out.Call(ctx, &transport.Request{
+				Caller:    "caller",
+				Service:   "service",
+				Encoding:  raw.Encoding,
+				Procedure: "hello",
+				Body:      bytes.NewReader(nil),
+			})
+			require.NoError(t, err, "the status is sent before the handler fails")
+			defer res.Body.Close()
+
+			body, err := ioutil.ReadAll(res.Body)
+			if tt.wantErr {
+				assert.Error(t, err, "reading an aborted response must fail")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, chunks*len(chunk), len(body))
+		})
+	}
+}
@@ -21,12 +21,14 @@
 package http
 
 import (
+	"crypto/tls"
 	"net"
 	"net/http"
 	"strings"
 
 	"github.com/opentracing/opentracing-go"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/headerlimit"
 	"go.uber.org/yarpc/internal/introspection"
 	intnet "go.uber.org/yarpc/internal/net"
 	"go.uber.org/yarpc/pkg/lifecycle"
@@ -63,6 +65,24 @@ func Interceptor(interceptor func(yarpcHandler http.Handler) http.Handler) Inbou
 	}
 }
 
+// HTTPMiddleware wraps the YARPC handler with standard net/http middleware,
+// such as CORS, compression, or authentication middleware, so that they
+// run before requests reach YARPC. The first middleware is the outermost.
+// Middleware given by repeated uses of this option are appended to the
+// chain.
+//
+// Middleware run outside of the Interceptor, if any, and only for requests
+// routed to YARPC when a Mux is used.
+//
+// 	inbound := transport.NewInbound(":8080",
+// 		http.HTTPMiddleware(cors.Default().Handler, gziphandler.GzipHandler),
+// 	)
+func HTTPMiddleware(middleware ...func(http.Handler) http.Handler) InboundOption {
+	return func(i *Inbound) {
+		i.httpMiddleware = append(i.httpMiddleware, middleware...)
+	}
+}
+
 // GrabHeaders specifies additional headers that are not prefixed with
 // ApplicationHeaderPrefix that should be propagated to the caller.
 //
@@ -79,6 +99,84 @@ func GrabHeaders(headers ...string) InboundOption {
 	}
 }
 
+// ErrorStatusCodes overrides the HTTP status codes with which errors are
+// reported to callers. Errors whose codes are not present in the given map
+// are reported with the default status code for their code.
+//
+// 	httpTransport.NewInbound(addr, http.ErrorStatusCodes(map[yarpcerrors.Code]int{
+// 		yarpcerrors.CodeResourceExhausted: http.StatusServiceUnavailable,
+// 	}))
+//
+// YARPC clients determine error codes from the Rpc-Error-Code response header
+// so this only affects how other HTTP clients and proxies see the response.
+func ErrorStatusCodes(statusCodes map[yarpcerrors.Code]int) InboundOption {
+	return func(i *Inbound) {
+		for code, statusCode := range statusCodes {
+			i.errorStatusCodes[code] = statusCode
+		}
+	}
+}
+
+// MaxRequestBodySize limits the size of request bodies accepted by the
+// inbound. Requests whose Content-Length exceeds the limit are rejected with
+// a ResourceExhausted error before their body is read, and reading past the
+// limit from bodies of unknown length fails with the same error.
+//
+// Request bodies are unlimited by default.
+func MaxRequestBodySize(bytes int64) InboundOption {
+	return func(i *Inbound) {
+		i.maxRequestBodySize = bytes
+	}
+}
+
+// MaxRequestHeaderBytes limits the total size of the names and values of
+// the HTTP headers of requests accepted by the inbound, including the
+// headers that carry tracing context. Requests with larger headers are
+// rejected with an InvalidArgument error before they are routed.
+//
+// Request headers are only limited by the HTTP server by default.
+func MaxRequestHeaderBytes(bytes int) InboundOption {
+	return func(i *Inbound) {
+		i.headerLimits.MaxBytes = bytes
+	}
+}
+
+// MaxRequestHeaders limits the number of HTTP headers of requests accepted
+// by the inbound, including the headers that carry tracing context.
+// Requests with more headers are rejected with an InvalidArgument error
+// before they are routed.
+//
+// The number of request headers is unlimited by default.
+func MaxRequestHeaders(count int) InboundOption {
+	return func(i *Inbound) {
+		i.headerLimits.MaxCount = count
+	}
+}
+
+// ServerTLSConfig specifies that the inbound accepts only TLS connections,
+// using the given configuration.
+func ServerTLSConfig(config *tls.Config) InboundOption {
+	return func(i *Inbound) {
+		i.tlsConfig = config
+	}
+}
+
+// OrderedOneway specifies that the oneway handlers of requests with the same
+// shard key run one at a time, in the order in which the requests arrived,
+// so that events for the same entity are processed in order. Requests are
+// spread over the given number of workers by shard key, and requests for
+// different keys run concurrently on different workers.
+//
+// Requests without a shard key are handled concurrently, as they are by
+// default. Handlers that take long delay the requests queued behind them,
+// and once the queue of a worker is full, the inbound waits before
+// acknowledging new requests for its keys.
+func OrderedOneway(workers int) InboundOption {
+	return func(i *Inbound) {
+		i.onewayWorkers = workers
+	}
+}
+
 // NewInbound builds a new HTTP inbound that listens on the given address and
 // sharing this transport.
 func (t *Transport) NewInbound(addr string, opts ...InboundOption) *Inbound {
@@ -89,6 +187,8 @@ func (t *Transport) NewInbound(addr string, opts ...InboundOption) *Inbound {
 		logger:            t.logger,
 		transport:         t,
 		grabHeaders:       make(map[string]struct{}),
+		errorStatusCodes:  make(map[yarpcerrors.Code]int),
+		etagProcedures:    make(map[string]struct{}),
 		bothResponseError: true,
 	}
 	for _, opt := range opts {
@@ -111,6 +211,17 @@ type Inbound struct {
 	grabHeaders map[string]struct{}
 	interceptor func(http.Handler) http.Handler
 
+	httpMiddleware []func(http.Handler) http.Handler
+	grpcWeb        bool
+
+	errorStatusCodes   map[yarpcerrors.Code]int
+	maxRequestBodySize int64
+	headerLimits       headerlimit.Limits
+	tlsConfig          *tls.Config
+	onewayWorkers      int
+	onewayQueues       *onewayQueues
+	etagProcedures     map[string]struct{}
+
 	once *lifecycle.Once
 
 	// should only be false in testing
@@ -151,24 +262,46 @@ func (i *Inbound) start() error {
 		}
 	}
 
-	var httpHandler http.Handler = handler{
-		router:            i.router,
-		tracer:            i.tracer,
-		grabHeaders:       i.grabHeaders,
-		bothResponseError: i.bothResponseError,
+	if i.onewayWorkers > 0 {
+		i.onewayQueues = newOnewayQueues(i.onewayWorkers)
+	}
+
+	yarpcHandler := handler{
+		router:             i.router,
+		tracer:             i.tracer,
+		grabHeaders:        i.grabHeaders,
+		errorStatusCodes:   i.errorStatusCodes,
+		maxRequestBodySize: i.maxRequestBodySize,
+		headerLimits:       i.headerLimits,
+		bothResponseError:  i.bothResponseError,
+		onewayQueues:       i.onewayQueues,
+		etagProcedures:     i.etagProcedures,
+		logger:             i.logger,
+	}
+	var httpHandler http.Handler = yarpcHandler
+	if i.grpcWeb {
+		httpHandler = grpcWebHandler{h: yarpcHandler, next: yarpcHandler}
 	}
 	if i.interceptor != nil {
 		httpHandler = i.interceptor(httpHandler)
 	}
+	for j := len(i.httpMiddleware) - 1; j >= 0; j-- {
+		httpHandler = i.httpMiddleware[j](httpHandler)
+	}
 	if i.mux != nil {
 		i.mux.Handle(i.muxPattern, httpHandler)
 		httpHandler = i.mux
 	}
 
-	i.server = intnet.NewHTTPServer(&http.Server{
-		Addr:    i.addr,
-		Handler: httpHandler,
-	})
+	server := &http.Server{
+		Addr:      i.addr,
+		Handler:   httpHandler,
+		TLSConfig: i.tlsConfig,
+	}
+	if reporter := i.transport.connReporter; reporter != nil {
+		server.ConnState = newConnStates(reporter).onStateChange
+	}
+	i.server = intnet.NewHTTPServer(server)
 	if err := i.server.ListenAndServe(); err != nil {
 		return err
 	}
@@ -190,7 +323,11 @@ func (i *Inbound) stop() error {
 	if i.server == nil {
 		return nil
 	}
-	return i.server.Stop()
+	err := i.server.Stop()
+	if i.onewayQueues != nil {
+		i.onewayQueues.stop()
+	}
+	return err
 }
 
 // IsRunning returns whether the inbound is currently running
@@ -246,3 +246,57 @@ func TestMuxWithInterceptor(t *testing.T) {
 		})
 	}
 }
+
+func TestHTTPMiddleware(t *testing.T) {
+	var order []string
+	record := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	deny := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Token") == "" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	intercept := func(yarpcHandler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			order = append(order, "interceptor")
+			w.WriteHeader(http.StatusTeapot)
+		})
+	}
+
+	inbound := NewTransport().NewInbound("127.0.0.1:0",
+		HTTPMiddleware(record("first"), deny),
+		HTTPMiddleware(record("second")),
+		Interceptor(intercept),
+	)
+	inbound.SetRouter(newTestRouter(nil))
+	require.NoError(t, inbound.Start(), "Failed to start inbound")
+	defer inbound.Stop()
+
+	url := fmt.Sprintf("http://%v/", inbound.Addr())
+
+	res, err := http.Get(url)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	assert.Equal(t, []string{"first"}, order)
+
+	order = nil
+	req, err := http.NewRequest("GET", url, nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Token", "token")
+	res, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusTeapot, res.StatusCode)
+	assert.Equal(t, []string{"first", "second", "interceptor"}, order)
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package http
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+// _onewayQueueSize is the number of oneway requests each worker of an
+// onewayQueues holds before inbound requests for its shard keys block.
+const _onewayQueueSize = 128
+
+// onewayQueues runs oneway handlers in the order in which their requests
+// arrived for each shard key, on a fixed number of workers. Shard keys are
+// hashed to workers so that requests for different keys run concurrently.
+type onewayQueues struct {
+	// mu guards against queueing handlers on closed queues. Requests may
+	// still be in flight when the inbound stops.
+	mu      sync.RWMutex
+	stopped bool
+	queues  []chan func()
+	wg      sync.WaitGroup
+}
+
+func newOnewayQueues(workers int) *onewayQueues {
+	q := &onewayQueues{queues: make([]chan func(), workers)}
+	for i := range q.queues {
+		queue := make(chan func(), _onewayQueueSize)
+		q.queues[i] = queue
+		q.wg.Add(1)
+		go q.work(queue)
+	}
+	return q
+}
+
+func (q *onewayQueues) work(queue <-chan func()) {
+	defer q.wg.Done()
+	for f := range queue {
+		f()
+	}
+}
+
+// run queues f on the worker for the given shard key, blocking while the
+// queue of the worker is full. f runs right away, concurrently, once the
+// queues are stopped.
+func (q *onewayQueues) run(shardKey string, f func()) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.stopped {
+		go f()
+		return
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(shardKey))
+	q.queues[h.Sum32()%uint32(len(q.queues))] <- f
+}
+
+// stop waits for the queued handlers to run and stops the workers.
+func (q *onewayQueues) stop() {
+	q.mu.Lock()
+	q.stopped = true
+	for _, queue := range q.queues {
+		close(queue)
+	}
+	q.mu.Unlock()
+	q.wg.Wait()
+}
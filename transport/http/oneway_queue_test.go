@@ -0,0 +1,83 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package http
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnewayQueuesOrder(t *testing.T) {
+	q := newOnewayQueues(4)
+
+	var (
+		mu  sync.Mutex
+		got = make(map[string][]int)
+	)
+	for i := 0; i < 100; i++ {
+		for _, key := range []string{"a", "b", "c"} {
+			i, key := i, key
+			q.run(key, func() {
+				mu.Lock()
+				got[key] = append(got[key], i)
+				mu.Unlock()
+			})
+		}
+	}
+	q.stop()
+
+	for _, key := range []string{"a", "b", "c"} {
+		assert.Len(t, got[key], 100)
+		for i, v := range got[key] {
+			assert.Equal(t, i, v, "handlers for %q ran out of order", key)
+		}
+	}
+}
+
+func TestOnewayQueuesSerializeKey(t *testing.T) {
+	q := newOnewayQueues(2)
+	defer q.stop()
+
+	release := make(chan struct{})
+	second := make(chan struct{})
+	q.run("a", func() { <-release })
+	q.run("a", func() { close(second) })
+
+	select {
+	case <-second:
+		t.Fatal("second handler for a key ran before the first returned")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(release)
+	<-second
+}
+
+func TestOnewayQueuesAfterStop(t *testing.T) {
+	q := newOnewayQueues(1)
+	q.stop()
+
+	done := make(chan struct{})
+	q.run("a", func() { close(done) })
+	<-done
+}
@@ -22,11 +22,14 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -42,6 +45,7 @@ import (
 	"go.uber.org/yarpc/peer/hostport"
 	"go.uber.org/yarpc/pkg/lifecycle"
 	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
 )
 
 // this ensures the HTTP outbound implements both transport.Outbound interfaces
@@ -90,6 +94,19 @@ func AddHeader(key, value string) OutboundOption {
 	}
 }
 
+// ClientTLSConfig specifies that the outbound sends requests over TLS with the
+// given configuration. The outbound keeps a connection pool of its own, so
+// outbounds of the same transport may present different client certificates
+// and verify servers differently. Its idle connections are closed when the
+// outbound stops.
+//
+// URL templates with the "http" scheme are upgraded to "https".
+func ClientTLSConfig(config *tls.Config) OutboundOption {
+	return func(o *Outbound) {
+		o.tlsConfig = config
+	}
+}
+
 // NewOutbound builds an HTTP outbound that sends requests to peers supplied
 // by the given peer.Chooser. The URL template for used for the different
 // peers may be customized using the URLTemplate option.
@@ -111,6 +128,17 @@ func (t *Transport) NewOutbound(chooser peer.Chooser, opts ...OutboundOption) *O
 	for _, opt := range opts {
 		opt(o)
 	}
+	o.client = t.client
+	if o.tlsConfig != nil {
+		options := t.clientOptions
+		options.tlsClientConfig = o.tlsConfig
+		o.client = options.buildClient(&options)
+		if o.urlTemplate.Scheme == "http" {
+			httpsURL := *o.urlTemplate
+			httpsURL.Scheme = "https"
+			o.urlTemplate = &httpsURL
+		}
+	}
 	return o
 }
 
@@ -138,12 +166,7 @@ func (t *Transport) NewSingleOutbound(uri string, opts ...OutboundOption) *Outbo
 	}
 
 	chooser := peerchooser.NewSingle(hostport.PeerIdentifier(parsedURL.Host), t)
-	o := t.NewOutbound(chooser)
-	for _, opt := range opts {
-		opt(o)
-	}
-	o.setURLTemplate(uri)
-	return o
+	return t.NewOutbound(chooser, append(opts, URLTemplate(uri))...)
 }
 
 // Outbound sends YARPC requests over HTTP. It may be constructed using the
@@ -157,9 +180,20 @@ type Outbound struct {
 	tracer      opentracing.Tracer
 	transport   *Transport
 
+	// HTTP client that sends requests, and the TLS configuration it uses,
+	// if any.
+	client    *http.Client
+	tlsConfig *tls.Config
+
 	// Headers to add to all outgoing requests.
 	headers http.Header
 
+	// Routes of procedures sent as plain REST requests.
+	restRoutes map[string]RESTRoute
+
+	// Responses kept for conditional requests, if enabled.
+	etags *etagCache
+
 	once *lifecycle.Once
 
 	// should only be false in testing
@@ -194,7 +228,21 @@ func (o *Outbound) Start() error {
 
 // Stop the HTTP outbound
 func (o *Outbound) Stop() error {
-	return o.once.Stop(o.chooser.Stop)
+	return o.once.Stop(o.stop)
+}
+
+func (o *Outbound) stop() error {
+	err := o.chooser.Stop()
+	// Outbounds with their own TLS configuration have a client of their
+	// own, whose connections nothing else would close.
+	if o.client != o.transport.client {
+		if t, ok := o.client.Transport.(interface {
+			CloseIdleConnections()
+		}); ok {
+			t.CloseIdleConnections()
+		}
+	}
+	return err
 }
 
 // IsRunning returns whether the Outbound is running.
@@ -207,8 +255,12 @@ func (o *Outbound) Call(ctx context.Context, treq *transport.Request) (*transpor
 	if treq == nil {
 		return nil, yarpcerrors.InvalidArgumentErrorf("request for http unary outbound was nil")
 	}
+	if o.etags != nil {
+		return o.callConditional(ctx, treq)
+	}
 
-	return o.call(ctx, treq)
+	res, _, err := o.call(ctx, treq, nil)
+	return res, err
 }
 
 // CallOneway makes a oneway request
@@ -217,69 +269,108 @@ func (o *Outbound) CallOneway(ctx context.Context, treq *transport.Request) (tra
 		return nil, yarpcerrors.InvalidArgumentErrorf("request for http oneway outbound was nil")
 	}
 
-	_, err := o.call(ctx, treq)
+	_, header, err := o.call(ctx, treq, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return time.Now(), nil
+	var status transport.AckStatus
+	if err := status.UnmarshalText([]byte(header.Get(AckStatusHeader))); err != nil {
+		// Inbounds that predate ack statuses do not report them.
+		return time.Now(), nil
+	}
+	return onewayAck{time: time.Now(), status: status}, nil
+}
+
+// onewayAck is the Ack of a oneway request to an inbound that reported an
+// ack status.
+type onewayAck struct {
+	time   time.Time
+	status transport.AckStatus
+}
+
+func (a onewayAck) String() string {
+	return a.status.String() + " at " + a.time.String()
 }
 
-func (o *Outbound) call(ctx context.Context, treq *transport.Request) (*transport.Response, error) {
+func (a onewayAck) Status() transport.AckStatus {
+	return a.status
+}
+
+// call sends the request and returns its response along with the headers of
+// the HTTP response. If a response kept for the request is given, the request
+// is conditional on it.
+func (o *Outbound) call(ctx context.Context, treq *transport.Request, cached *etagEntry) (*transport.Response, http.Header, error) {
 	start := time.Now()
 	deadline, ok := ctx.Deadline()
 	if !ok {
-		return nil, yarpcerrors.Newf(yarpcerrors.CodeInvalidArgument, "missing context deadline")
+		return nil, nil, yarpcerrors.Newf(yarpcerrors.CodeInvalidArgument, "missing context deadline")
 	}
 	ttl := deadline.Sub(start)
 
-	hreq, err := o.createRequest(treq)
+	headers := treq.Headers
+	var hreq *http.Request
+	var err error
+	if route, ok := o.restRoute(ctx, treq); ok {
+		hreq, headers, err = o.createRESTRequest(route, treq)
+	} else {
+		hreq, err = o.createRequest(treq)
+	}
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	hreq.Header = applicationHeaders.ToHTTPHeaders(treq.Headers, nil)
+	hreq.Header = applicationHeaders.ToHTTPHeaders(headers, nil)
 	ctx, hreq, span, err := o.withOpentracingSpan(ctx, hreq, treq, start)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer span.Finish()
 
 	hreq = o.withCoreHeaders(hreq, treq, ttl)
+	if cached != nil {
+		hreq.Header.Set(ifNoneMatchHeader, cached.etag)
+	}
 	hreq = hreq.WithContext(ctx)
 
 	response, err := o.roundTrip(hreq, treq, start)
 	if err != nil {
 		span.SetTag("error", true)
 		span.LogFields(opentracinglog.String("event", err.Error()))
-		return nil, err
+		return nil, nil, err
 	}
 
 	span.SetTag("http.status_code", response.StatusCode)
 
 	// Service name match validation, return yarpcerrors.CodeInternal error if not match
 	if match, resSvcName := checkServiceMatch(treq.Service, response.Header); !match {
-		return nil, transport.UpdateSpanWithErr(span,
+		return nil, nil, transport.UpdateSpanWithErr(span,
 			yarpcerrors.InternalErrorf("service name sent from the request "+
 				"does not match the service name received in the response, sent %q, got: %q", treq.Service, resSvcName))
 	}
 
 	tres := &transport.Response{
 		Headers:          applicationHeaders.FromHTTPHeaders(response.Header, transport.NewHeaders()),
-		Body:             response.Body,
+		Body:             sizedBody(response.Body, response.ContentLength),
 		ApplicationError: response.Header.Get(ApplicationStatusHeader) == ApplicationErrorStatus,
 	}
+	if cached != nil && response.StatusCode == http.StatusNotModified {
+		// The response did not change; answer with the body kept for it.
+		_ = response.Body.Close()
+		tres.Body = cached.reader()
+		return tres, response.Header, nil
+	}
 
 	bothResponseError := response.Header.Get(BothResponseErrorHeader) == AcceptTrue
 	if bothResponseError && o.bothResponseError {
 		if response.StatusCode >= 300 {
-			return tres, getYARPCErrorFromResponse(response, true)
+			return tres, response.Header, getYARPCErrorFromResponse(response, true, o.transport.logger)
 		}
-		return tres, nil
+		return tres, response.Header, nil
 	}
 	if response.StatusCode >= 200 && response.StatusCode < 300 {
-		return tres, nil
+		return tres, response.Header, nil
 	}
-	return nil, getYARPCErrorFromResponse(response, false)
+	return nil, nil, getYARPCErrorFromResponse(response, false, o.transport.logger)
 }
 
 func (o *Outbound) getPeerForRequest(ctx context.Context, treq *transport.Request) (*httpPeer, func(error), error) {
@@ -376,7 +467,7 @@ func (o *Outbound) withCoreHeaders(req *http.Request, treq *transport.Request, t
 	return req
 }
 
-func getYARPCErrorFromResponse(response *http.Response, bothResponseError bool) error {
+func getYARPCErrorFromResponse(response *http.Response, bothResponseError bool, logger *zap.Logger) error {
 	var contents string
 	if bothResponseError {
 		contents = response.Header.Get(ErrorMessageHeader)
@@ -399,11 +490,42 @@ func getYARPCErrorFromResponse(response *http.Response, bothResponseError bool)
 			code = errorCode
 		}
 	}
-	return intyarpcerrors.NewWithNamef(
+	status := intyarpcerrors.NewWithNamef(
 		code,
 		response.Header.Get(ErrorNameHeader),
 		strings.TrimSuffix(contents, "\n"),
 	)
+	status = intyarpcerrors.WithDetailsHeader(logger, status, response.Header.Get(ErrorDetailsHeader))
+	if _, ok := status.RetryAfter(); !ok {
+		// Servers and proxies that are not YARPC only set the standard
+		// header.
+		if retryAfter, ok := parseRetryAfter(response.Header.Get(RetryAfterHeader), time.Now()); ok {
+			status = status.WithRetryAfter(retryAfter)
+		}
+	}
+	return status
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either a
+// number of seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	t, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if d := t.Sub(now); d > 0 {
+		return d, true
+	}
+	return 0, true
 }
 
 // Only does verification if there is a response header
@@ -495,7 +617,13 @@ func (o *Outbound) doWithPeer(
 ) (*http.Response, error) {
 	hreq.URL.Host = p.HostPort()
 
-	response, err := o.transport.client.Do(hreq.WithContext(ctx))
+	reqCtx := ctx
+	var trace callTrace
+	if transport.WantsOutboundCallInfo(ctx) {
+		reqCtx = httptrace.WithClientTrace(ctx, trace.clientTrace())
+	}
+	response, err := o.client.Do(hreq.WithContext(reqCtx))
+	trace.record(ctx, p.Identifier())
 
 	if err != nil {
 		// Workaround borrowed from ctxhttp until
@@ -23,11 +23,15 @@ package http
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -37,6 +41,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.uber.org/yarpc/api/peer/peertest"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
 	"go.uber.org/yarpc/encoding/raw"
 	"go.uber.org/yarpc/internal/testtime"
 	"go.uber.org/yarpc/yarpcerrors"
@@ -311,6 +316,105 @@ func TestCallFailures(t *testing.T) {
 	}
 }
 
+func TestCallRetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(RetryAfterHeader, "3")
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+		}))
+	defer server.Close()
+
+	out := NewTransport().NewSingleOutbound(server.URL)
+	require.NoError(t, out.Start(), "failed to start outbound")
+	defer out.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+	defer cancel()
+	_, err := out.Call(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  raw.Encoding,
+		Procedure: "wat",
+		Body:      bytes.NewReader([]byte("huh")),
+	})
+	require.Error(t, err)
+	retryAfter, ok := yarpcerrors.FromError(err).RetryAfter()
+	require.True(t, ok, "expected retry-after on error")
+	assert.Equal(t, 3*time.Second, retryAfter)
+}
+
+func TestCallOutboundInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(ApplicationStatusHeader, ApplicationSuccessStatus)
+			_, _ = w.Write([]byte("ok"))
+		}))
+	defer server.Close()
+
+	out := NewTransport().NewSingleOutbound(server.URL)
+	require.NoError(t, out.Start(), "failed to start outbound")
+	defer out.Stop()
+
+	call := func() transport.OutboundCallInfo {
+		var info transport.OutboundCallInfo
+		ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+		defer cancel()
+		res, err := out.Call(transport.WithOutboundCallInfo(ctx, &info), &transport.Request{
+			Caller:    "caller",
+			Service:   "service",
+			Encoding:  raw.Encoding,
+			Procedure: "hello",
+			Body:      bytes.NewReader([]byte("world")),
+		})
+		require.NoError(t, err)
+		_, err = ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		return info
+	}
+
+	peer := strings.TrimPrefix(server.URL, "http://")
+
+	first := call()
+	assert.Equal(t, peer, first.Peer)
+	assert.False(t, first.ConnectionReused)
+	assert.Equal(t, 1, first.Attempts)
+	assert.True(t, first.Timings.Connect > 0, "expected connect timing on a new connection")
+	assert.True(t, first.Timings.FirstByte > 0, "expected time to first byte")
+
+	second := call()
+	assert.Equal(t, peer, second.Peer)
+	assert.True(t, second.ConnectionReused)
+	assert.Equal(t, 1, second.Attempts)
+	assert.Zero(t, second.Timings.Connect, "expected no connect timing on a reused connection")
+	assert.True(t, second.Timings.FirstByte > 0, "expected time to first byte")
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{value: ""},
+		{value: "nope"},
+		{value: "-1"},
+		{value: "0", wantOK: true},
+		{value: "120", want: 2 * time.Minute, wantOK: true},
+		{value: "Thu, 01 Mar 2018 12:00:30 GMT", want: 30 * time.Second, wantOK: true},
+		{value: "Thu, 01 Mar 2018 11:00:00 GMT", wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestStartMultiple(t *testing.T) {
 	httpTransport := NewTransport()
 	out := httpTransport.NewSingleOutbound("http://localhost:9999")
@@ -447,10 +551,54 @@ func TestNoRequest(t *testing.T) {
 	assert.Equal(t, yarpcerrors.InvalidArgumentErrorf("request for http oneway outbound was nil"), err)
 }
 
+func TestCallOnewayAckStatus(t *testing.T) {
+	tests := []struct {
+		desc       string
+		header     string
+		wantStatus transport.AckStatus
+	}{
+		{desc: "queued", header: "queued", wantStatus: transport.AckStatusQueued},
+		{desc: "delivered", header: "delivered", wantStatus: transport.AckStatusDelivered},
+		{desc: "no status", wantStatus: transport.AckStatusUnknown},
+		{desc: "unknown status", header: "lost", wantStatus: transport.AckStatusUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, req *http.Request) {
+					defer req.Body.Close()
+					w.Header().Set(ServiceHeader, req.Header.Get(ServiceHeader))
+					if tt.header != "" {
+						w.Header().Set(AckStatusHeader, tt.header)
+					}
+				},
+			))
+			defer server.Close()
+
+			out := NewTransport().NewSingleOutbound(server.URL)
+			require.NoError(t, out.Start())
+			defer out.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			ack, err := out.CallOneway(ctx, &transport.Request{
+				Caller:    "caller",
+				Service:   "service",
+				Encoding:  raw.Encoding,
+				Procedure: "hello",
+				Body:      bytes.NewReader([]byte("world")),
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, transport.AckStatusOf(ack))
+		})
+	}
+}
+
 func TestOutboundNoDeadline(t *testing.T) {
 	out := NewTransport().NewSingleOutbound("http://foo-host:8080")
 
-	_, err := out.call(context.Background(), &transport.Request{})
+	_, _, err := out.call(context.Background(), &transport.Request{}, nil)
 	assert.Equal(t, yarpcerrors.Newf(yarpcerrors.CodeInvalidArgument, "missing context deadline"), err)
 }
 
@@ -525,3 +673,132 @@ func TestServiceMatchNoHeader(t *testing.T) {
 	})
 	require.NoError(t, err)
 }
+
+func TestClientTLSConfig(t *testing.T) {
+	// Borrow the certificate of a TLS test server, which is valid for
+	// 127.0.0.1.
+	certServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer certServer.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(certServer.Certificate())
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	handler := transporttest.NewMockUnaryHandler(mockCtrl)
+	handler.EXPECT().Handle(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	router := transporttest.NewMockRouter(mockCtrl)
+	router.EXPECT().Procedures().AnyTimes()
+	router.EXPECT().Choose(gomock.Any(), gomock.Any()).Return(transport.NewUnaryHandlerSpec(handler), nil)
+
+	httpTransport := NewTransport()
+	inbound := httpTransport.NewInbound("127.0.0.1:0", ServerTLSConfig(&tls.Config{
+		Certificates: certServer.TLS.Certificates,
+	}))
+	inbound.SetRouter(router)
+	require.NoError(t, inbound.Start())
+	defer inbound.Stop()
+
+	call := func(out *Outbound) error {
+		require.NoError(t, out.Start())
+		defer out.Stop()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+		defer cancel()
+		res, err := out.Call(ctx, &transport.Request{
+			Caller:    "caller",
+			Service:   "service",
+			Encoding:  raw.Encoding,
+			Procedure: "hello",
+			Body:      bytes.NewReader([]byte("world")),
+		})
+		if err == nil {
+			res.Body.Close()
+		}
+		return err
+	}
+
+	url := "http://" + inbound.Addr().String()
+	out := httpTransport.NewSingleOutbound(url, ClientTLSConfig(&tls.Config{RootCAs: roots}))
+	assert.Equal(t, "https", out.urlTemplate.Scheme)
+	assert.NoError(t, call(out), "TLS call should succeed")
+
+	assert.Error(t, call(httpTransport.NewSingleOutbound(url)), "plaintext call should fail")
+	assert.Error(t, call(httpTransport.NewSingleOutbound(url, ClientTLSConfig(&tls.Config{}))),
+		"call to untrusted server should fail")
+}
+
+func TestClientTLSConfigClosesConnectionsOnStop(t *testing.T) {
+	closed := make(chan struct{})
+	var closeOnce sync.Once
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set(ServiceHeader, req.Header.Get(ServiceHeader))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			closeOnce.Do(func() { close(closed) })
+		}
+	}
+	server.StartTLS()
+	defer server.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	out := NewTransport().NewSingleOutbound(server.URL, ClientTLSConfig(&tls.Config{RootCAs: roots}))
+	require.NoError(t, out.Start())
+
+	ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+	defer cancel()
+	res, err := out.Call(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  raw.Encoding,
+		Procedure: "hello",
+		Body:      bytes.NewReader([]byte("world")),
+	})
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	require.NoError(t, out.Stop())
+	select {
+	case <-closed:
+	case <-time.After(testtime.Second):
+		t.Fatal("idle connection was not closed when the outbound stopped")
+	}
+}
+
+func TestDNSCache(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			_, err := w.Write([]byte("great success"))
+			assert.NoError(t, err)
+		},
+	))
+	defer server.Close()
+
+	httpTransport := NewTransport(DNSCache(time.Minute, time.Hour))
+	require.NotNil(t, httpTransport.dnsCache)
+
+	out := httpTransport.NewSingleOutbound(strings.Replace(server.URL, "127.0.0.1", "localhost", 1))
+	require.NoError(t, out.Start(), "failed to start outbound")
+	defer out.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+	defer cancel()
+	res, err := out.Call(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  raw.Encoding,
+		Procedure: "hello",
+		Body:      bytes.NewReader([]byte("world")),
+	})
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("great success"), body)
+
+	addrs, err := httpTransport.dnsCache.Resolve(ctx, "localhost")
+	require.NoError(t, err)
+	assert.NotEmpty(t, addrs)
+}
@@ -21,13 +21,24 @@
 package http
 
 import (
+	"context"
+	"io"
+	"io/ioutil"
 	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
 	"time"
 
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/peer/hostport"
 )
 
+// warmInterval is how often peers with warm connections re-send the requests
+// that keep them established, replacing connections the server has closed.
+const warmInterval = 30 * time.Second
+
 type httpPeer struct {
 	*hostport.Peer
 
@@ -35,23 +46,15 @@ type httpPeer struct {
 	addr      string
 	changed   chan struct{}
 	released  chan struct{}
-	timer     *time.Timer
 }
 
 func newPeer(addr string, t *Transport) *httpPeer {
-	// Create a defused timer for later use.
-	timer := time.NewTimer(0)
-	if !timer.Stop() {
-		<-timer.C
-	}
-
 	return &httpPeer{
 		Peer:      hostport.NewPeer(hostport.PeerIdentifier(addr), t),
 		transport: t,
 		addr:      addr,
 		changed:   make(chan struct{}, 1),
 		released:  make(chan struct{}, 0),
-		timer:     timer,
 	}
 }
 
@@ -62,8 +65,11 @@ func newPeer(addr string, t *Transport) *httpPeer {
 // connection attempt.
 func (p *httpPeer) isAvailable() bool {
 	// If there's no open connection, we probe by connecting.
-	dialer := &net.Dialer{Timeout: p.transport.connTimeout}
-	conn, err := dialer.Dial("tcp", p.addr)
+	dial := (&net.Dialer{Timeout: p.transport.connTimeout}).DialContext
+	if cache := p.transport.dnsCache; cache != nil {
+		dial = cache.Dialer(dial)
+	}
+	conn, err := dial(context.Background(), "tcp", p.addr)
 	if conn != nil {
 		conn.Close()
 	}
@@ -73,6 +79,74 @@ func (p *httpPeer) isAvailable() bool {
 	return false
 }
 
+// warm establishes the transport's configured number of idle connections to
+// the peer by sending as many concurrent "OPTIONS *" requests. Servers answer
+// these requests themselves, and the HTTP client keeps the connections for
+// later requests. Connections that are already idle are reused.
+//
+// The body of each request is held open until every request has a
+// connection, so that no two requests share one.
+func (p *httpPeer) warm() {
+	n := p.transport.warmConnections
+	if n <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.transport.connTimeout)
+	defer cancel()
+
+	var connected, done sync.WaitGroup
+	connected.Add(n)
+	done.Add(n)
+	ready := make(chan struct{})
+	for i := 0; i < n; i++ {
+		go func() {
+			defer done.Done()
+			p.sendOptions(ctx, connected.Done, ready)
+		}()
+	}
+	connected.Wait()
+	close(ready)
+	done.Wait()
+}
+
+// sendOptions sends an "OPTIONS *" request to the peer, calling gotConn once
+// the request has a connection, or has failed to get one, and finishing the
+// request once ready is closed.
+func (p *httpPeer) sendOptions(ctx context.Context, gotConn func(), ready <-chan struct{}) {
+	var once sync.Once
+	defer once.Do(gotConn)
+
+	body, bodyWriter := io.Pipe()
+	go func() {
+		select {
+		case <-ready:
+		case <-ctx.Done():
+		}
+		_ = bodyWriter.Close()
+	}()
+
+	trace := &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) { once.Do(gotConn) },
+	}
+	req := &http.Request{
+		Method: "OPTIONS",
+		URL:    &url.URL{Scheme: "http", Host: p.addr, Opaque: "*"},
+		Host:   p.addr,
+		Header: make(http.Header),
+		Body:   body,
+	}
+	req = req.WithContext(httptrace.WithClientTrace(ctx, trace))
+	res, err := p.transport.client.Do(req)
+	if err != nil {
+		// The connection is re-attempted when the peer is next checked.
+		return
+	}
+	// Drain the body so that the connection can be reused.
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+	_ = res.Body.Close()
+}
+
 func (p *httpPeer) OnDisconnected() {
 	p.Peer.SetStatus(peer.Unavailable)
 
@@ -103,6 +177,7 @@ func (p *httpPeer) MaintainConn() {
 			p.Peer.SetStatus(peer.Available)
 			// Reset on success
 			attempts = 0
+			p.warm()
 			if !p.waitForChange() {
 				break
 			}
@@ -124,11 +199,22 @@ func (p *httpPeer) MaintainConn() {
 // change notification, but exits early if the transport releases the peer or
 // stops.  waitForChange returns whether it is resuming due to a connection
 // status change event.
+//
+// Peers with warm connections also resume periodically to re-warm them.
 func (p *httpPeer) waitForChange() (changed bool) {
+	var refresh <-chan time.Time
+	if p.transport.warmConnections > 0 {
+		timer := p.transport.clock.Timer(warmInterval)
+		defer timer.Stop()
+		refresh = timer.C()
+	}
+
 	// Wait for a connection status change
 	select {
 	case <-p.changed:
 		return true
+	case <-refresh:
+		return true
 	case <-p.released:
 		return false
 	case <-p.transport.once.Stopping():
@@ -140,17 +226,14 @@ func (p *httpPeer) waitForChange() (changed bool) {
 // peer or stops.  sleep returns whether it successfully waited the entire
 // duration.
 func (p *httpPeer) sleep(delay time.Duration) (completed bool) {
-	p.timer.Reset(delay)
+	timer := p.transport.clock.Timer(delay)
+	defer timer.Stop()
 
 	select {
-	case <-p.timer.C:
+	case <-timer.C():
 		return true
 	case <-p.released:
 	case <-p.transport.once.Stopping():
 	}
-
-	if !p.timer.Stop() {
-		<-p.timer.C
-	}
 	return false
 }
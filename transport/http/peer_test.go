@@ -22,14 +22,21 @@ package http_test
 
 import (
 	"context"
+	"net"
+	nethttp "net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/yarpc/api/backoff"
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/integrationtest"
+	"go.uber.org/yarpc/internal/testtime"
 	"go.uber.org/yarpc/internal/yarpctest"
 	"go.uber.org/yarpc/peer/hostport"
 	"go.uber.org/yarpc/transport/http"
@@ -106,3 +113,50 @@ func TestIntegration(t *testing.T) {
 	t.Skip("Skipping due to test flakiness")
 	spec.Test(t)
 }
+
+type nopSubscriber struct{}
+
+func (nopSubscriber) NotifyStatusChanged(peer.Identifier) {}
+
+func TestWarmConnections(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		conns = make(map[net.Conn]struct{})
+	)
+	server := httptest.NewUnstartedServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		t.Errorf("unexpected request to handler: %v %v", r.Method, r.URL)
+	}))
+	server.Config.ConnState = func(conn net.Conn, state nethttp.ConnState) {
+		// Connections that served a request and are now idle.
+		if state == nethttp.StateIdle {
+			mu.Lock()
+			conns[conn] = struct{}{}
+			mu.Unlock()
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	trans := http.NewTransport(
+		http.WarmConnections(3),
+		http.ConnBackoff(backoff.None),
+	)
+	require.NoError(t, trans.Start())
+	defer trans.Stop()
+
+	pid := hostport.PeerIdentifier(server.Listener.Addr().String())
+	_, err := trans.RetainPeer(pid, nopSubscriber{})
+	require.NoError(t, err)
+	defer trans.ReleasePeer(pid, nopSubscriber{})
+
+	numConns := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(conns)
+	}
+	deadline := time.Now().Add(testtime.Second)
+	for numConns() < 3 && time.Now().Before(deadline) {
+		time.Sleep(testtime.Millisecond)
+	}
+	assert.Equal(t, 3, numConns(), "expected warm connections")
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// RESTRoute describes the plain HTTP request that an outbound sends for a
+// procedure in REST mode, so that YARPC clients, along with their middleware
+// and peer lists, can call REST backends.
+//
+// The path may contain placeholders in braces, which are replaced by the
+// values of the application headers of the same names. Headers used this
+// way are not sent as headers. For example, with the route
+//
+// 	http.RESTRoute{Method: "GET", Path: "/users/{id}/posts?limit={limit}"}
+//
+// a call with the headers id=42 and limit=10 is sent as
+// "GET /users/42/posts?limit=10". Callers can set the headers with the
+// yarpc.WithHeader call option.
+type RESTRoute struct {
+	// HTTP method of requests. Defaults to POST.
+	Method string `config:"method"`
+
+	// Path and query of requests, which replace the path and query of the
+	// outbound's URL template.
+	Path string `config:"path"`
+}
+
+// RESTProcedure configures the outbound to send calls to the given procedure
+// as plain HTTP requests described by the route. This may be specified
+// multiple times.
+//
+// 	httpTransport.NewSingleOutbound("http://users.example.com",
+// 		http.RESTProcedure("Users::Get", http.RESTRoute{Method: "GET", Path: "/users/{id}"}),
+// 		http.RESTProcedure("Users::Create", http.RESTRoute{Method: "POST", Path: "/users"}),
+// 	)
+//
+// WithRESTRoute overrides the route of individual calls.
+func RESTProcedure(procedure string, route RESTRoute) OutboundOption {
+	return func(o *Outbound) {
+		if o.restRoutes == nil {
+			o.restRoutes = make(map[string]RESTRoute)
+		}
+		o.restRoutes[procedure] = route
+	}
+}
+
+type restRouteKey struct{}
+
+// WithRESTRoute returns a copy of the context that makes HTTP outbounds send
+// calls made with it as the given REST route, regardless of their
+// procedures.
+func WithRESTRoute(ctx context.Context, route RESTRoute) context.Context {
+	return context.WithValue(ctx, restRouteKey{}, route)
+}
+
+// restRoute returns the REST route for the call, if any.
+func (o *Outbound) restRoute(ctx context.Context, treq *transport.Request) (RESTRoute, bool) {
+	if route, ok := ctx.Value(restRouteKey{}).(RESTRoute); ok {
+		return route, true
+	}
+	route, ok := o.restRoutes[treq.Procedure]
+	return route, ok
+}
+
+// createRESTRequest builds the HTTP request for a REST route, returning it
+// along with the application headers that were not used to fill the path.
+func (o *Outbound) createRESTRequest(route RESTRoute, treq *transport.Request) (*http.Request, transport.Headers, error) {
+	headers := treq.Headers.Clone()
+	path, query := route.Path, ""
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path, query = path[:i], path[i+1:]
+	}
+
+	path, err := expandRESTTemplate(path, headers, url.PathEscape)
+	if err != nil {
+		return nil, headers, err
+	}
+	query, err = expandRESTTemplate(query, headers, url.QueryEscape)
+	if err != nil {
+		return nil, headers, err
+	}
+
+	base := *o.urlTemplate
+	base.Path, base.RawPath, base.RawQuery = "", "", ""
+	target := base.String() + path
+	if query != "" {
+		target += "?" + query
+	}
+
+	method := route.Method
+	if method == "" {
+		method = "POST"
+	}
+	body := treq.Body
+	if body == nil {
+		body = &bytes.Buffer{}
+	}
+	hreq, err := http.NewRequest(method, target, body)
+	if err != nil {
+		return nil, headers, yarpcerrors.InvalidArgumentErrorf("invalid REST request for procedure %q: %v", treq.Procedure, err)
+	}
+	return hreq, headers, nil
+}
+
+// expandRESTTemplate replaces the {name} placeholders in the template with
+// the escaped values of the headers of the same names, removing those
+// headers.
+func expandRESTTemplate(template string, headers transport.Headers, escape func(string) string) (string, error) {
+	var out bytes.Buffer
+	for {
+		start := strings.IndexByte(template, '{')
+		if start < 0 {
+			out.WriteString(template)
+			return out.String(), nil
+		}
+		end := strings.IndexByte(template[start:], '}')
+		if end < 0 {
+			return "", yarpcerrors.InvalidArgumentErrorf("unterminated placeholder in REST route %q", template)
+		}
+		end += start
+
+		name := template[start+1 : end]
+		value, ok := headers.Get(name)
+		if !ok {
+			return "", yarpcerrors.InvalidArgumentErrorf("missing header %q for REST route placeholder", name)
+		}
+		headers.Del(name)
+
+		out.WriteString(template[:start])
+		out.WriteString(escape(value))
+		template = template[end+1:]
+	}
+}
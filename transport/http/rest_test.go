@@ -0,0 +1,202 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type restRequest struct {
+	Method string
+	URI    string
+	Header http.Header
+	Body   string
+}
+
+// newRESTServer starts a plain HTTP server that sends every request it
+// receives to the returned channel.
+func newRESTServer(t *testing.T) (*httptest.Server, <-chan restRequest) {
+	requests := make(chan restRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		requests <- restRequest{
+			Method: r.Method,
+			URI:    r.RequestURI,
+			Header: r.Header,
+			Body:   string(body),
+		}
+		w.Write([]byte(`{"name":"alice"}`))
+	}))
+	return server, requests
+}
+
+func TestRESTOutbound(t *testing.T) {
+	server, requests := newRESTServer(t)
+	defer server.Close()
+
+	tests := []struct {
+		desc    string
+		ctx     func(context.Context) context.Context
+		req     *transport.Request
+		want    restRequest
+		wantErr string
+	}{
+		{
+			desc: "procedure route",
+			req: &transport.Request{
+				Procedure: "Users::Get",
+				Headers:   transport.NewHeaders().With("id", "42/a b").With("x-extra", "yes"),
+			},
+			want: restRequest{Method: "GET", URI: "/users/42%2Fa%20b"},
+		},
+		{
+			desc: "query placeholders",
+			req: &transport.Request{
+				Procedure: "Users::List",
+				Headers:   transport.NewHeaders().With("limit", "10").With("q", "a&b"),
+			},
+			want: restRequest{Method: "GET", URI: "/users?limit=10&q=a%26b"},
+		},
+		{
+			desc: "default method with body",
+			req: &transport.Request{
+				Procedure: "Users::Create",
+				Body:      bytes.NewBufferString(`{"name":"alice"}`),
+			},
+			want: restRequest{Method: "POST", URI: "/users", Body: `{"name":"alice"}`},
+		},
+		{
+			desc: "context route",
+			ctx: func(ctx context.Context) context.Context {
+				return WithRESTRoute(ctx, RESTRoute{Method: "DELETE", Path: "/users/{id}"})
+			},
+			req: &transport.Request{
+				Procedure: "Users::Get",
+				Headers:   transport.NewHeaders().With("id", "42"),
+			},
+			want: restRequest{Method: "DELETE", URI: "/users/42"},
+		},
+		{
+			desc: "missing placeholder header",
+			req: &transport.Request{
+				Procedure: "Users::Get",
+			},
+			wantErr: `missing header "id" for REST route placeholder`,
+		},
+	}
+
+	httpTransport := NewTransport()
+	out := httpTransport.NewSingleOutbound(server.URL+"/ignored?x=y",
+		RESTProcedure("Users::Get", RESTRoute{Method: "GET", Path: "/users/{id}"}),
+		RESTProcedure("Users::List", RESTRoute{Method: "GET", Path: "/users?limit={limit}&q={q}"}),
+		RESTProcedure("Users::Create", RESTRoute{Path: "/users"}),
+	)
+	require.NoError(t, httpTransport.Start())
+	defer httpTransport.Stop()
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if tt.ctx != nil {
+				ctx = tt.ctx(ctx)
+			}
+			tt.req.Caller = "caller"
+			tt.req.Service = "users"
+			tt.req.Encoding = "json"
+
+			res, err := out.Call(ctx, tt.req)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.FromError(err).Code())
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			body, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			assert.Equal(t, `{"name":"alice"}`, string(body))
+
+			got := <-requests
+			assert.Equal(t, tt.want.Method, got.Method)
+			assert.Equal(t, tt.want.URI, got.URI)
+			assert.Equal(t, tt.want.Body, got.Body)
+			for _, name := range []string{"id", "limit", "q"} {
+				assert.Empty(t, got.Header.Get(ApplicationHeaderPrefix+name), "placeholder header %q must not be sent", name)
+			}
+		})
+	}
+
+	// Headers not used by placeholders are still sent.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := out.Call(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "users",
+		Procedure: "Users::Get",
+		Headers:   transport.NewHeaders().With("id", "42").With("x-extra", "yes"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "yes", (<-requests).Header.Get(ApplicationHeaderPrefix+"x-extra"))
+}
+
+func TestRESTOutboundNonRESTProcedure(t *testing.T) {
+	server, requests := newRESTServer(t)
+	defer server.Close()
+
+	httpTransport := NewTransport()
+	out := httpTransport.NewSingleOutbound(server.URL+"/yarpc",
+		RESTProcedure("Users::Get", RESTRoute{Method: "GET", Path: "/users/{id}"}),
+	)
+	require.NoError(t, httpTransport.Start())
+	defer httpTransport.Stop()
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := out.Call(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "users",
+		Procedure: "Users::Delete",
+		Body:      bytes.NewBufferString("{}"),
+	})
+	require.NoError(t, err)
+
+	got := <-requests
+	assert.Equal(t, "POST", got.Method)
+	assert.Equal(t, "/yarpc", got.URI)
+	assert.Equal(t, "Users::Delete", got.Header.Get(ProcedureHeader))
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+// callTrace records how an attempt of an outbound call went using the hooks
+// of net/http/httptrace. The hooks may be called from other goroutines than
+// the one sending the request, for instance when dialing several addresses
+// of a host at once.
+type callTrace struct {
+	mu sync.Mutex
+
+	connReused bool
+	timings    transport.OutboundCallTimings
+
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	gotConn      time.Time
+	wroteRequest time.Time
+}
+
+func (t *callTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			t.dnsStart = time.Now()
+			t.mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			t.timings.DNS = since(t.dnsStart)
+			t.mu.Unlock()
+		},
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+			t.mu.Unlock()
+		},
+		ConnectDone: func(_, _ string, err error) {
+			t.mu.Lock()
+			if err == nil {
+				t.timings.Connect = since(t.connectStart)
+			}
+			t.mu.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			t.tlsStart = time.Now()
+			t.mu.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			t.timings.TLSHandshake = since(t.tlsStart)
+			t.mu.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.connReused = info.Reused
+			t.gotConn = time.Now()
+			t.mu.Unlock()
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.mu.Lock()
+			t.wroteRequest = time.Now()
+			t.timings.RequestWrite = since(t.gotConn)
+			t.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			t.timings.FirstByte = since(t.wroteRequest)
+			t.mu.Unlock()
+		},
+	}
+}
+
+// record records the attempt in the OutboundCallInfo of the context.
+func (t *callTrace) record(ctx context.Context, peer string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	transport.RecordOutboundTimings(ctx, t.timings)
+	transport.RecordOutboundAttempt(ctx, peer, t.connReused)
+}
+
+// since is time.Since for phases whose start may not have been seen.
+func since(start time.Time) time.Duration {
+	if start.IsZero() {
+		return 0
+	}
+	return time.Since(start)
+}
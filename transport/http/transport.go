@@ -21,6 +21,7 @@
 package http
 
 import (
+	"crypto/tls"
 	"net"
 	"net/http"
 	"sync"
@@ -31,6 +32,9 @@ import (
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/backoff"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/internal/dnscache"
+	intnet "go.uber.org/yarpc/internal/net"
 	"go.uber.org/yarpc/pkg/lifecycle"
 	"go.uber.org/zap"
 )
@@ -43,11 +47,21 @@ type transportOptions struct {
 	disableKeepAlives     bool
 	disableCompression    bool
 	responseHeaderTimeout time.Duration
+	tlsClientConfig       *tls.Config
 	connTimeout           time.Duration
 	connBackoffStrategy   backoffapi.Strategy
+	warmConnections       int
 	tracer                opentracing.Tracer
 	buildClient           func(*transportOptions) *http.Client
 	logger                *zap.Logger
+	clock                 clock.Clock
+	connectionEventHook   transport.ConnectionEventHook
+	dnsCacheTTL           time.Duration
+	dnsCacheMaxStale      time.Duration
+
+	// dnsCache is built by newTransport from dnsCacheTTL and
+	// dnsCacheMaxStale, and shared by all clients of the transport.
+	dnsCache *dnscache.Cache
 }
 
 var defaultTransportOptions = transportOptions{
@@ -56,6 +70,7 @@ var defaultTransportOptions = transportOptions{
 	connTimeout:         defaultConnTimeout,
 	connBackoffStrategy: backoff.DefaultExponential,
 	buildClient:         buildHTTPClient,
+	clock:               clock.NewReal(),
 }
 
 func newTransportOptions() transportOptions {
@@ -99,6 +114,22 @@ func MaxIdleConnsPerHost(i int) TransportOption {
 	}
 }
 
+// WarmConnections specifies the number of idle connections the transport
+// establishes to each retained peer as soon as the transport is started or
+// the peer is retained, rather than on the first request, and maintains
+// while the peer is retained.
+//
+// Connections are warmed by sending "OPTIONS *" requests over plain HTTP,
+// which HTTP servers answer without invoking any handler. The maximum number
+// of idle connections per host is raised to this number if necessary.
+//
+// Defaults to no warm connections.
+func WarmConnections(n int) TransportOption {
+	return func(options *transportOptions) {
+		options.warmConnections = n
+	}
+}
+
 // IdleConnTimeout is the maximum amount of time an idle (keep-alive)
 // connection will remain idle before closing itself.
 // Zero means no limit.
@@ -160,6 +191,23 @@ func ConnBackoff(s backoffapi.Strategy) TransportOption {
 	}
 }
 
+// DNSCache enables a client-side cache of the DNS resolutions of the host
+// names that the transport dials, such as the host of the URL of a single
+// outbound. Resolutions are cached for the given TTL.
+//
+// If resolving a host name fails once its resolution expired, the expired
+// addresses are used for up to maxStale longer, so that DNS failures don't
+// take down outbound traffic. Host names are resolved again early when no
+// connection can be established to any of their addresses.
+//
+// By default, host names are resolved on every connection attempt.
+func DNSCache(ttl, maxStale time.Duration) TransportOption {
+	return func(options *transportOptions) {
+		options.dnsCacheTTL = ttl
+		options.dnsCacheMaxStale = maxStale
+	}
+}
+
 // Tracer configures a tracer for the transport and all its inbounds and
 // outbounds.
 func Tracer(tracer opentracing.Tracer) TransportOption {
@@ -177,6 +225,17 @@ func Logger(logger *zap.Logger) TransportOption {
 	}
 }
 
+// ConnectionEvents specifies a hook that is called when the connections of
+// the transport, and of its inbounds, are established, closed, or fail to be
+// established.
+//
+// TLS information is reported for inbound connections only.
+func ConnectionEvents(hook transport.ConnectionEventHook) TransportOption {
+	return func(options *transportOptions) {
+		options.connectionEventHook = hook
+	}
+}
+
 // Hidden option to override the buildHTTPClient function. This is used only
 // for testing.
 func buildClient(f func(*transportOptions) *http.Client) TransportOption {
@@ -185,6 +244,14 @@ func buildClient(f func(*transportOptions) *http.Client) TransportOption {
 	}
 }
 
+// Hidden option to override the clock used to wait between connection
+// attempts. This is used only for testing.
+func withClock(clock clock.Clock) TransportOption {
+	return func(options *transportOptions) {
+		options.clock = clock
+	}
+}
+
 // NewTransport creates a new HTTP transport for managing peers and sending requests
 func NewTransport(opts ...TransportOption) *Transport {
 	options := newTransportOptions()
@@ -199,34 +266,53 @@ func (o *transportOptions) newTransport() *Transport {
 	if logger == nil {
 		logger = zap.NewNop()
 	}
+	if o.dnsCacheTTL > 0 {
+		o.dnsCache = dnscache.New(o.dnsCacheTTL, o.dnsCacheMaxStale)
+	}
 	return &Transport{
 		once:                lifecycle.NewOnce(),
 		client:              o.buildClient(o),
+		clientOptions:       *o,
 		connTimeout:         o.connTimeout,
 		connBackoffStrategy: o.connBackoffStrategy,
+		dnsCache:            o.dnsCache,
+		warmConnections:     o.warmConnections,
 		peers:               make(map[string]*httpPeer),
 		tracer:              o.tracer,
 		logger:              logger,
+		clock:               o.clock,
+		connReporter:        intnet.NewConnReporter(transportName, o.connectionEventHook),
 	}
 }
 
 func buildHTTPClient(options *transportOptions) *http.Client {
+	maxIdleConnsPerHost := options.maxIdleConnsPerHost
+	if maxIdleConnsPerHost < options.warmConnections {
+		maxIdleConnsPerHost = options.warmConnections
+	}
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: options.keepAlive,
+	}
+	dial := dialer.DialContext
+	if options.dnsCache != nil {
+		dial = options.dnsCache.Dialer(dial)
+	}
+	reporter := intnet.NewConnReporter(transportName, options.connectionEventHook)
 	return &http.Client{
 		Transport: &http.Transport{
 			// options lifted from https://golang.org/src/net/http/transport.go
-			Proxy: http.ProxyFromEnvironment,
-			Dial: (&net.Dialer{
-				Timeout:   30 * time.Second,
-				KeepAlive: options.keepAlive,
-			}).Dial,
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           reporter.Dialer(dial, false),
 			TLSHandshakeTimeout:   10 * time.Second,
 			ExpectContinueTimeout: 1 * time.Second,
 			MaxIdleConns:          options.maxIdleConns,
-			MaxIdleConnsPerHost:   options.maxIdleConnsPerHost,
+			MaxIdleConnsPerHost:   maxIdleConnsPerHost,
 			IdleConnTimeout:       options.idleConnTimeout,
 			DisableKeepAlives:     options.disableKeepAlives,
 			DisableCompression:    options.disableCompression,
 			ResponseHeaderTimeout: options.responseHeaderTimeout,
+			TLSClientConfig:       options.tlsClientConfig,
 		},
 	}
 }
@@ -241,12 +327,19 @@ type Transport struct {
 	client *http.Client
 	peers  map[string]*httpPeer
 
+	// clientOptions builds HTTP clients for outbounds that need their own.
+	clientOptions transportOptions
+
 	connTimeout         time.Duration
 	connBackoffStrategy backoffapi.Strategy
+	dnsCache            *dnscache.Cache
 	connectorsGroup     sync.WaitGroup
+	warmConnections     int
 
-	tracer opentracing.Tracer
-	logger *zap.Logger
+	tracer       opentracing.Tracer
+	logger       *zap.Logger
+	clock        clock.Clock
+	connReporter *intnet.ConnReporter
 }
 
 var _ transport.Transport = (*Transport)(nil)
@@ -21,14 +21,19 @@
 package http
 
 import (
+	"net"
 	"testing"
 	"time"
 
 	"github.com/crossdock/crossdock-go/assert"
 	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	backoffapi "go.uber.org/yarpc/api/backoff"
 	"go.uber.org/yarpc/api/peer"
 	. "go.uber.org/yarpc/api/peer/peertest"
+	"go.uber.org/yarpc/internal/clock"
 	"go.uber.org/yarpc/internal/testtime"
+	"go.uber.org/yarpc/peer/hostport"
 )
 
 type peerExpectation struct {
@@ -287,3 +292,50 @@ type testIdentifier struct {
 func (i testIdentifier) Identifier() string {
 	return i.id
 }
+
+type constantBackoff time.Duration
+
+func (b constantBackoff) Backoff() backoffapi.Backoff { return b }
+
+func (b constantBackoff) Duration(uint) time.Duration { return time.Duration(b) }
+
+type nopSubscriber struct{}
+
+func (nopSubscriber) NotifyStatusChanged(peer.Identifier) {}
+
+func TestPeerConnBackoffUsesClock(t *testing.T) {
+	// Reserve an address that nothing listens on.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	clock := clock.NewFake()
+	trans := NewTransport(
+		ConnTimeout(testtime.Second),
+		ConnBackoff(constantBackoff(time.Hour)),
+		withClock(clock),
+	)
+	require.NoError(t, trans.Start())
+	defer trans.Stop()
+
+	p, err := trans.RetainPeer(hostport.PeerIdentifier(addr), nopSubscriber{})
+	require.NoError(t, err)
+	waitForStatus := func(want peer.ConnectionStatus, advance time.Duration) {
+		deadline := time.Now().Add(testtime.Second)
+		for p.Status().ConnectionStatus != want {
+			require.True(t, time.Now().Before(deadline), "peer did not become %v", want)
+			clock.Add(advance)
+			time.Sleep(time.Millisecond)
+		}
+	}
+	waitForStatus(peer.Unavailable, 0)
+
+	ln, err = net.Listen("tcp", addr)
+	require.NoError(t, err)
+	defer ln.Close()
+
+	// The peer does not reconnect until the backoff has elapsed on the clock.
+	require.Equal(t, peer.Unavailable, p.Status().ConnectionStatus)
+	waitForStatus(peer.Available, time.Hour)
+}
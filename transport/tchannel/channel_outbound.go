@@ -32,6 +32,7 @@ import (
 	"go.uber.org/yarpc/pkg/errors"
 	"go.uber.org/yarpc/pkg/lifecycle"
 	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
 )
 
 var (
@@ -201,7 +202,7 @@ func (o *ChannelOutbound) Call(ctx context.Context, req *transport.Request) (*tr
 		Headers:          headers,
 		Body:             resBody,
 		ApplicationError: res.ApplicationError(),
-	}, getResponseErrorAndDeleteHeaderKeys(headers)
+	}, getResponseErrorAndDeleteHeaderKeys(headers, o.transport.logger)
 }
 
 // Introspect returns basic status about this outbound.
@@ -238,11 +239,12 @@ func fromSystemError(err tchannel.SystemError) error {
 	return yarpcerrors.Newf(code, err.Message())
 }
 
-func getResponseErrorAndDeleteHeaderKeys(headers transport.Headers) error {
+func getResponseErrorAndDeleteHeaderKeys(headers transport.Headers, logger *zap.Logger) error {
 	defer func() {
 		headers.Del(ErrorCodeHeaderKey)
 		headers.Del(ErrorNameHeaderKey)
 		headers.Del(ErrorMessageHeaderKey)
+		headers.Del(ErrorDetailsHeaderKey)
 	}()
 	errorCodeString, ok := headers.Get(ErrorCodeHeaderKey)
 	if !ok {
@@ -257,7 +259,8 @@ func getResponseErrorAndDeleteHeaderKeys(headers transport.Headers) error {
 	}
 	errorName, _ := headers.Get(ErrorNameHeaderKey)
 	errorMessage, _ := headers.Get(ErrorMessageHeaderKey)
-	return intyarpcerrors.NewWithNamef(errorCode, errorName, errorMessage)
+	errorDetails, _ := headers.Get(ErrorDetailsHeaderKey)
+	return intyarpcerrors.WithDetailsHeader(logger, intyarpcerrors.NewWithNamef(errorCode, errorName, errorMessage), errorDetails)
 }
 
 // ServiceHeaderKey is internal key used by YARPC, we need to remove it before give response to client
@@ -26,6 +26,7 @@ import (
 	"github.com/opentracing/opentracing-go"
 	"github.com/uber/tchannel-go"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/headerlimit"
 	"go.uber.org/yarpc/pkg/lifecycle"
 	"go.uber.org/zap"
 )
@@ -88,6 +89,9 @@ func (options transportOptions) newChannelTransport() *ChannelTransport {
 		tracer:          options.tracer,
 		logger:          logger,
 		originalHeaders: options.originalHeaders,
+
+		maxRequestBodySize: options.maxRequestBodySize,
+		headerLimits:       options.headerLimits,
 	}
 }
 
@@ -104,6 +108,9 @@ type ChannelTransport struct {
 	router          transport.Router
 	originalHeaders bool
 
+	maxRequestBodySize int64
+	headerLimits       headerlimit.Limits
+
 	once *lifecycle.Once
 }
 
@@ -139,7 +146,14 @@ func (t *ChannelTransport) start() error {
 		for s := range services {
 			sc := t.ch.GetSubChannel(s)
 			existing := sc.GetHandlers()
-			sc.SetHandler(handler{existing: existing, router: t.router, tracer: t.tracer})
+			sc.SetHandler(handler{
+				existing:           existing,
+				router:             t.router,
+				tracer:             t.tracer,
+				maxRequestBodySize: t.maxRequestBodySize,
+				headerLimits:       t.headerLimits,
+				logger:             t.logger,
+			})
 		}
 	}
 
@@ -35,6 +35,7 @@ import (
 //  transports:
 //    tchannel:
 //      connTimeout: 500ms
+//      warmConnections: 2
 //      connBackoff:
 //        exponential:
 //          first: 10ms
@@ -42,6 +43,19 @@ import (
 type TransportConfig struct {
 	ConnTimeout time.Duration       `config:"connTimeout"`
 	ConnBackoff yarpcconfig.Backoff `config:"connBackoff"`
+
+	// The maximum size of request bodies in bytes accepted by the inbound.
+	// Bodies are unlimited by default.
+	MaxRequestBodySize int64 `config:"maxRequestBodySize"`
+
+	// Limits on the headers of requests accepted by the inbound. See
+	// MaxRequestHeaderBytes and MaxRequestHeaders.
+	MaxRequestHeaderBytes int `config:"maxRequestHeaderBytes"`
+	MaxRequestHeaders     int `config:"maxRequestHeaders"`
+
+	// Number of outbound connections to establish to each peer. See
+	// WarmConnections.
+	WarmConnections int `config:"warmConnections"`
 }
 
 // InboundConfig configures a TChannel inbound.
@@ -91,10 +105,11 @@ type transportSpec struct {
 
 func (ts *transportSpec) Spec() yarpcconfig.TransportSpec {
 	return yarpcconfig.TransportSpec{
-		Name:               transportName,
-		BuildTransport:     ts.buildTransport,
-		BuildInbound:       ts.buildInbound,
-		BuildUnaryOutbound: ts.buildUnaryOutbound,
+		Name:                transportName,
+		BuildTransport:      ts.buildTransport,
+		BuildInbound:        ts.buildInbound,
+		BuildUnaryOutbound:  ts.buildUnaryOutbound,
+		BuildStreamOutbound: ts.buildStreamOutbound,
 	}
 }
 
@@ -109,6 +124,22 @@ func (ts *transportSpec) buildTransport(tc *TransportConfig, k *yarpcconfig.Kit)
 		options.connTimeout = tc.ConnTimeout
 	}
 
+	if tc.MaxRequestBodySize > 0 {
+		options.maxRequestBodySize = tc.MaxRequestBodySize
+	}
+
+	if tc.MaxRequestHeaderBytes > 0 {
+		options.headerLimits.MaxBytes = tc.MaxRequestHeaderBytes
+	}
+
+	if tc.MaxRequestHeaders > 0 {
+		options.headerLimits.MaxCount = tc.MaxRequestHeaders
+	}
+
+	if tc.WarmConnections > 0 {
+		options.warmConnections = tc.WarmConnections
+	}
+
 	strategy, err := tc.ConnBackoff.Strategy()
 	if err != nil {
 		return nil, err
@@ -156,3 +187,12 @@ func (ts *transportSpec) buildUnaryOutbound(oc *OutboundConfig, t transport.Tran
 	}
 	return x.NewOutbound(chooser), nil
 }
+
+func (ts *transportSpec) buildStreamOutbound(oc *OutboundConfig, t transport.Transport, k *yarpcconfig.Kit) (transport.StreamOutbound, error) {
+	x := t.(*Transport)
+	chooser, err := oc.BuildPeerChooser(x, hostport.Identify, k)
+	if err != nil {
+		return nil, err
+	}
+	return x.NewOutbound(chooser), nil
+}
@@ -19,7 +19,10 @@
 // THE SOFTWARE.
 
 // Package tchannel implements a YARPC transport based on the TChannel
-// protocol. The TChannel transport provides support for Unary RPCs only.
+// protocol. The TChannel transport provides support for Unary RPCs, and
+// for Streaming RPCs with outbounds built by NewTransport. Stream messages
+// are framed inside the body of a single TChannel call, so the deadline of
+// the context used to start a stream bounds the lifetime of the stream.
 //
 // Usage
 //
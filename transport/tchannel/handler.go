@@ -29,9 +29,13 @@ import (
 	"github.com/uber/tchannel-go"
 	"go.uber.org/multierr"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/bodylimit"
 	"go.uber.org/yarpc/internal/bufferpool"
+	"go.uber.org/yarpc/internal/headerlimit"
+	intyarpcerrors "go.uber.org/yarpc/internal/yarpcerrors"
 	"go.uber.org/yarpc/pkg/errors"
 	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
 	ncontext "golang.org/x/net/context"
 )
 
@@ -81,10 +85,13 @@ func (c tchannelCall) Response() inboundCallResponse {
 
 // handler wraps a transport.UnaryHandler into a TChannel Handler.
 type handler struct {
-	existing   map[string]tchannel.Handler
-	router     transport.Router
-	tracer     opentracing.Tracer
-	headerCase headerCase
+	existing           map[string]tchannel.Handler
+	router             transport.Router
+	tracer             opentracing.Tracer
+	headerCase         headerCase
+	maxRequestBodySize int64
+	headerLimits       headerlimit.Limits
+	logger             *zap.Logger
 }
 
 func (h handler) Handle(ctx ncontext.Context, call *tchannel.InboundCall) {
@@ -100,6 +107,16 @@ func (h handler) handle(ctx context.Context, call inboundCall) {
 
 	err := h.callHandler(ctx, call, responseWriter)
 
+	if responseWriter.streamed {
+		// The stream has started its response and ends it itself, so the
+		// call can only fail with a system error now.
+		if err != nil {
+			// TODO: log error
+			_ = call.Response().SendSystemError(getSystemError(err))
+		}
+		return
+	}
+
 	// black-hole requests on resource exhausted errors
 	if yarpcerrors.FromError(err).Code() == yarpcerrors.CodeResourceExhausted {
 		// all TChannel clients will time out instead of receiving an error
@@ -115,15 +132,8 @@ func (h handler) handle(ctx context.Context, call inboundCall) {
 		// we have an error, so we're going to propagate it as a yarpc error,
 		// regardless of whether or not it is a system error.
 		status := yarpcerrors.FromError(errors.WrapHandlerError(err, call.ServiceName(), call.MethodString()))
-		// TODO: what to do with error? we could have a whole complicated scheme to
-		// return a SystemError here, might want to do that
-		text, _ := status.Code().MarshalText()
-		responseWriter.addHeader(ErrorCodeHeaderKey, string(text))
-		if status.Name() != "" {
-			responseWriter.addHeader(ErrorNameHeaderKey, status.Name())
-		}
-		if status.Message() != "" {
-			responseWriter.addHeader(ErrorMessageHeaderKey, status.Message())
+		for k, v := range errorHeaders(h.logger, status) {
+			responseWriter.addHeader(k, v)
 		}
 	}
 	if err := responseWriter.Close(); err != nil {
@@ -132,6 +142,27 @@ func (h handler) handle(ctx context.Context, call inboundCall) {
 	}
 }
 
+// errorHeaders returns the headers that carry the given error to the
+// caller, in the response of a unary call or the error frame of a stream.
+func errorHeaders(logger *zap.Logger, status *yarpcerrors.Status) map[string]string {
+	text, err := status.Code().MarshalText()
+	if err != nil {
+		status = yarpcerrors.Newf(yarpcerrors.CodeInternal, "error %s had code %v which is unknown", status.Error(), status.Code())
+		text = []byte("internal")
+	}
+	headers := map[string]string{ErrorCodeHeaderKey: string(text)}
+	if status.Name() != "" {
+		headers[ErrorNameHeaderKey] = status.Name()
+	}
+	if status.Message() != "" {
+		headers[ErrorMessageHeaderKey] = status.Message()
+	}
+	if details := intyarpcerrors.DetailsHeader(logger, status); details != "" {
+		headers[ErrorDetailsHeaderKey] = details
+	}
+	return headers
+}
+
 func (h handler) callHandler(ctx context.Context, call inboundCall, responseWriter *responseWriter) error {
 	start := time.Now()
 	_, ok := ctx.Deadline()
@@ -154,11 +185,18 @@ func (h handler) callHandler(ctx context.Context, call inboundCall, responseWrit
 	if err != nil {
 		return errors.RequestHeadersDecodeError(treq, err)
 	}
+	if err := checkHeaderLimits(h.headerLimits, headers); err != nil {
+		return err
+	}
 	treq.Headers = headers
 
 	if tcall, ok := call.(tchannelCall); ok {
 		tracer := h.tracer
 		ctx = tchannel.ExtractInboundSpan(ctx, tcall.InboundCall, headers.Items(), tracer)
+		ctx = transport.WithRemotePeer(ctx, transport.RemotePeer{Address: tcall.RemotePeer().HostPort})
+		treq.Metadata = &transport.Metadata{
+			TChannel: &transport.TChannelMetadata{CallerName: call.CallerName()},
+		}
 	}
 
 	body, err := call.Arg3Reader()
@@ -172,6 +210,11 @@ func (h handler) callHandler(ctx context.Context, call inboundCall, responseWrit
 		return err
 	}
 
+	// Streams limit the size of each message instead.
+	if treq.Body, err = bodylimit.Limit(treq.Body, h.maxRequestBodySize); err != nil {
+		return err
+	}
+
 	spec, err := h.router.Choose(ctx, treq)
 	if err != nil {
 		if yarpcerrors.FromError(err).Code() != yarpcerrors.CodeUnimplemented {
@@ -193,6 +236,9 @@ func (h handler) callHandler(ctx context.Context, call inboundCall, responseWrit
 	case transport.Unary:
 		return transport.DispatchUnaryHandler(ctx, spec.Unary(), start, treq, responseWriter)
 
+	case transport.Streaming:
+		return h.handleStream(ctx, treq, body, spec.Stream(), responseWriter)
+
 	default:
 		return yarpcerrors.Newf(yarpcerrors.CodeUnimplemented, "transport tchannel does not handle %s handlers", spec.Type().String())
 	}
@@ -206,6 +252,9 @@ type responseWriter struct {
 	response           inboundCallResponse
 	isApplicationError bool
 	headerCase         headerCase
+
+	// streamed is set once a stream has started writing the response.
+	streamed bool
 }
 
 func newResponseWriter(response inboundCallResponse, format tchannel.Format, headerCase headerCase) *responseWriter {
@@ -25,6 +25,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -117,6 +118,36 @@ func TestHandlerErrors(t *testing.T) {
 	}
 }
 
+func TestHandlerMaxRequestBodySize(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	rpcHandler := transporttest.NewMockUnaryHandler(mockCtrl)
+	router := transporttest.NewMockRouter(mockCtrl)
+	router.EXPECT().Choose(gomock.Any(), gomock.Any()).
+		Return(transport.NewUnaryHandlerSpec(rpcHandler), nil)
+	rpcHandler.EXPECT().Handle(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, req *transport.Request, _ transport.ResponseWriter) error {
+			_, err := ioutil.ReadAll(req.Body)
+			return err
+		})
+
+	respRecorder := newResponseRecorder()
+	ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+	defer cancel()
+	handler{router: router, maxRequestBodySize: 3}.handle(ctx, &fakeInboundCall{
+		service: "service",
+		caller:  "caller",
+		format:  tchannel.Raw,
+		method:  "hello",
+		arg2:    []byte{0x00, 0x00},
+		arg3:    []byte("world"),
+		resp:    respRecorder,
+	})
+
+	assert.True(t, respRecorder.blackholed, "requests over the size limit must be dropped")
+}
+
 func TestHandlerFailures(t *testing.T) {
 	tests := []struct {
 		desc string
@@ -28,6 +28,7 @@ import (
 
 	"github.com/uber/tchannel-go"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/headerlimit"
 	"go.uber.org/yarpc/transport/tchannel/internal"
 )
 
@@ -38,6 +39,9 @@ const (
 	ErrorNameHeaderKey = "$rpc$-error-name"
 	// ErrorMessageHeaderKey is the response header key for the error message.
 	ErrorMessageHeaderKey = "$rpc$-error-message"
+	// ErrorDetailsHeaderKey is the response header key for the serialized
+	// details and cause of the error.
+	ErrorDetailsHeaderKey = "$rpc$-error-details"
 	// ServiceHeaderKey is the response header key for the respond service
 	ServiceHeaderKey = "$rpc$-service"
 )
@@ -46,6 +50,7 @@ var _reservedHeaderKeys = map[string]struct{}{
 	ErrorCodeHeaderKey:    {},
 	ErrorNameHeaderKey:    {},
 	ErrorMessageHeaderKey: {},
+	ErrorDetailsHeaderKey: {},
 	ServiceHeaderKey:      {},
 }
 
@@ -67,6 +72,18 @@ func readRequestHeaders(
 	return ctx, headers, nil
 }
 
+// checkHeaderLimits fails if the given request headers exceed the limits.
+func checkHeaderLimits(limits headerlimit.Limits, headers transport.Headers) error {
+	if !limits.Enabled() {
+		return nil
+	}
+	var bytes int
+	for k, v := range headers.OriginalItems() {
+		bytes += len(k) + len(v)
+	}
+	return limits.Check(headers.Len(), bytes)
+}
+
 // readHeaders reads headers using the given function to get the arg reader.
 //
 // This may be used with the Arg2Reader functions on InboundCall and
@@ -30,6 +30,8 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/uber/tchannel-go"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/headerlimit"
+	"go.uber.org/yarpc/yarpcerrors"
 )
 
 func TestEncodeAndDecodeHeaders(t *testing.T) {
@@ -242,3 +244,18 @@ func TestWriteHeaders(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckHeaderLimits(t *testing.T) {
+	headers := transport.NewHeaders().
+		With("Foo", "bar").
+		With("$tracing$uber-trace-id", "0123456789")
+
+	assert.NoError(t, checkHeaderLimits(headerlimit.Limits{}, headers))
+	assert.NoError(t, checkHeaderLimits(headerlimit.Limits{MaxBytes: 38, MaxCount: 2}, headers))
+
+	err := checkHeaderLimits(headerlimit.Limits{MaxBytes: 37}, headers)
+	assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.FromError(err).Code())
+
+	err = checkHeaderLimits(headerlimit.Limits{MaxCount: 1}, headers)
+	assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.FromError(err).Code())
+}
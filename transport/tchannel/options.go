@@ -26,7 +26,10 @@ import (
 
 	"github.com/opentracing/opentracing-go"
 	backoffapi "go.uber.org/yarpc/api/backoff"
+	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/backoff"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/internal/headerlimit"
 	"go.uber.org/zap"
 )
 
@@ -57,6 +60,11 @@ type transportOptions struct {
 	connTimeout         time.Duration
 	connBackoffStrategy backoffapi.Strategy
 	originalHeaders     bool
+	maxRequestBodySize  int64
+	headerLimits        headerlimit.Limits
+	warmConnections     int
+	clock               clock.Clock
+	connectionEventHook transport.ConnectionEventHook
 }
 
 // newTransportOptions constructs the default transport options struct
@@ -65,6 +73,7 @@ func newTransportOptions() transportOptions {
 		tracer:              opentracing.GlobalTracer(),
 		connTimeout:         defaultConnTimeout,
 		connBackoffStrategy: backoff.DefaultExponential,
+		clock:               clock.NewReal(),
 	}
 }
 
@@ -177,9 +186,76 @@ func ConnBackoff(s backoffapi.Strategy) TransportOption {
 	}
 }
 
+// WarmConnections specifies the number of outbound connections the transport
+// establishes to each retained peer as soon as the transport is started or
+// the peer is retained, and re-establishes if they close.
+//
+// By default, the transport maintains a single connection to each peer,
+// counting connections the peer opened to this transport.
+func WarmConnections(n int) TransportOption {
+	return func(options *transportOptions) {
+		options.warmConnections = n
+	}
+}
+
 // OriginalHeaders specifies whether to forward headers without canonicalizing them
 func OriginalHeaders() TransportOption {
 	return func(options *transportOptions) {
 		options.originalHeaders = true
 	}
 }
+
+// MaxRequestBodySize limits the size of request bodies accepted by inbounds
+// of this transport. Reading past the limit fails with a ResourceExhausted
+// error, so handlers stop buffering requests that are too large. As with
+// other ResourceExhausted errors, TChannel inbounds drop these requests
+// without responding.
+//
+// Request bodies are unlimited by default.
+func MaxRequestBodySize(bytes int64) TransportOption {
+	return func(options *transportOptions) {
+		options.maxRequestBodySize = bytes
+	}
+}
+
+// MaxRequestHeaderBytes limits the total size of the names and values of the
+// headers of requests accepted by inbounds of this transport, including the
+// headers that carry tracing context. Requests with larger headers are
+// rejected with an InvalidArgument error before they are routed.
+//
+// Request headers are unlimited by default.
+func MaxRequestHeaderBytes(bytes int) TransportOption {
+	return func(options *transportOptions) {
+		options.headerLimits.MaxBytes = bytes
+	}
+}
+
+// MaxRequestHeaders limits the number of headers of requests accepted by
+// inbounds of this transport, including the headers that carry tracing
+// context. Requests with more headers are rejected with an InvalidArgument
+// error before they are routed.
+//
+// Request headers are unlimited by default.
+func MaxRequestHeaders(count int) TransportOption {
+	return func(options *transportOptions) {
+		options.headerLimits.MaxCount = count
+	}
+}
+
+// ConnectionEvents specifies a hook that is called when the connections of
+// the transport are established, closed, or fail to be established.
+//
+// This option has no effect on transports built with NewChannelTransport.
+func ConnectionEvents(hook transport.ConnectionEventHook) TransportOption {
+	return func(options *transportOptions) {
+		options.connectionEventHook = hook
+	}
+}
+
+// Hidden option to override the clock used to wait between connection
+// attempts. This is used only for testing.
+func withClock(clock clock.Clock) TransportOption {
+	return func(options *transportOptions) {
+		options.clock = clock
+	}
+}
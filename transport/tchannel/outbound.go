@@ -175,7 +175,7 @@ func callWithPeer(ctx context.Context, req *transport.Request, peer *tchannel.Pe
 		Headers:          headers,
 		Body:             resBody,
 		ApplicationError: res.ApplicationError(),
-	}, getResponseErrorAndDeleteHeaderKeys(headers)
+	}, getResponseErrorAndDeleteHeaderKeys(headers, o.transport.logger)
 }
 
 func (o *Outbound) getPeerForRequest(ctx context.Context, treq *transport.Request) (*tchannelPeer, func(error), error) {
@@ -191,6 +191,7 @@ func (o *Outbound) getPeerForRequest(ctx context.Context, treq *transport.Reques
 			ExpectedType: "*tchannelPeer",
 		}
 	}
+	transport.RecordOutboundAttempt(ctx, tp.Identifier(), tp.Status().ConnectionStatus == peer.Available)
 
 	return tp, onFinish, nil
 }
@@ -34,23 +34,15 @@ type tchannelPeer struct {
 	addr      string
 	changed   chan struct{}
 	released  chan struct{}
-	timer     *time.Timer
 }
 
 func newPeer(addr string, t *Transport) *tchannelPeer {
-	// Create a defused timer for later use.
-	timer := time.NewTimer(0)
-	if !timer.Stop() {
-		<-timer.C
-	}
-
 	return &tchannelPeer{
 		addr:      addr,
 		Peer:      hostport.NewPeer(hostport.PeerIdentifier(addr), t),
 		transport: t,
 		changed:   make(chan struct{}, 1),
 		released:  make(chan struct{}, 0),
-		timer:     timer,
 	}
 }
 
@@ -73,7 +65,8 @@ func (p *tchannelPeer) MaintainConn() {
 		tp := pl.GetOrAdd(p.addr)
 
 		inbound, outbound := tp.NumConnections()
-		if inbound+outbound > 0 {
+		connected := inbound+outbound > 0
+		if connected && outbound >= p.transport.warmConnections {
 			p.Peer.SetStatus(peer.Available)
 			// Reset on success
 			attempts = 0
@@ -82,7 +75,11 @@ func (p *tchannelPeer) MaintainConn() {
 			}
 
 		} else {
-			p.Peer.SetStatus(peer.Connecting)
+			// A peer with a connection remains available while the transport
+			// establishes the rest of its warm connections.
+			if !connected {
+				p.Peer.SetStatus(peer.Connecting)
+			}
 
 			// Attempt to connect
 			ctx := context.Background()
@@ -92,7 +89,9 @@ func (p *tchannelPeer) MaintainConn() {
 			if err == nil {
 				p.Peer.SetStatus(peer.Available)
 			} else {
-				p.Peer.SetStatus(peer.Unavailable)
+				if !connected {
+					p.Peer.SetStatus(peer.Unavailable)
+				}
 				// Back-off on fail
 				if !p.sleep(backoff.Duration(attempts)) {
 					break
@@ -137,17 +136,14 @@ func (p *tchannelPeer) waitForChange() (changed bool) {
 // peer or stops.  sleep returns whether it successfully waited the entire
 // duration.
 func (p *tchannelPeer) sleep(delay time.Duration) (completed bool) {
-	p.timer.Reset(delay)
+	timer := p.transport.clock.Timer(delay)
+	defer timer.Stop()
 
 	select {
-	case <-p.timer.C:
+	case <-timer.C():
 		return true
 	case <-p.released:
 	case <-p.transport.once.Stopping():
 	}
-
-	if !p.timer.Stop() {
-		<-p.timer.C
-	}
 	return false
 }
@@ -24,8 +24,11 @@ import (
 	"context"
 	"net"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
 	"go.uber.org/yarpc/api/backoff"
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
@@ -136,6 +139,54 @@ func TestCancelMaintainConn(t *testing.T) {
 	require.NoError(t, err)
 }
 
+type countingListener struct {
+	net.Listener
+
+	accepted atomic.Int32
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		l.accepted.Inc()
+	}
+	return conn, err
+}
+
+func TestWarmConnections(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	listener := &countingListener{Listener: l}
+
+	server, err := tchannel.NewTransport(
+		tchannel.ServiceName("server"),
+		tchannel.Listener(listener),
+	)
+	require.NoError(t, err)
+	require.NoError(t, server.Start())
+	defer server.Stop()
+
+	client, err := tchannel.NewTransport(
+		tchannel.ServiceName("client"),
+		tchannel.WarmConnections(3),
+		tchannel.ConnBackoff(backoff.None),
+	)
+	require.NoError(t, err)
+	require.NoError(t, client.Start())
+	defer client.Stop()
+
+	pid := identify(l.Addr().String())
+	_, err = client.RetainPeer(pid, noSub{})
+	require.NoError(t, err)
+	defer client.ReleasePeer(pid, noSub{})
+
+	deadline := time.Now().Add(testtime.Second)
+	for listener.accepted.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(testtime.Millisecond)
+	}
+	assert.Equal(t, int32(3), listener.accepted.Load(), "expected warm connections")
+}
+
 func identify(id string) peer.Identifier {
 	return &testIdentifier{id}
 }
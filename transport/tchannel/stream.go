@@ -0,0 +1,444 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannel
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/uber/tchannel-go"
+	"go.uber.org/atomic"
+	"go.uber.org/yarpc/api/transport"
+	intyarpcerrors "go.uber.org/yarpc/internal/yarpcerrors"
+	"go.uber.org/yarpc/pkg/errors"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+// Streams are carried by a single TChannel call. The request and response
+// headers travel in arg2 as usual, and the messages in both directions are
+// framed inside arg3, which TChannel fragments across as many frames as it
+// needs. Each frame has the format:
+//
+// 	type:1 length:uvarint payload~length
+//
+// The end of arg3 ends the stream in its direction. The server may end the
+// response with an error frame, whose payload holds the error headers
+// encoded like arg2.
+const (
+	frameMessage byte = iota
+	frameError
+)
+
+var _ transport.StreamOutbound = (*Outbound)(nil)
+
+func writeFrame(w io.Writer, typ byte, payload []byte) error {
+	var header [1 + binary.MaxVarintLen64]byte
+	header[0] = typ
+	n := binary.PutUvarint(header[1:], uint64(len(payload)))
+	if _, err := w.Write(header[:1+n]); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// readFrame reads the next frame from r. It returns io.EOF if the stream
+// ended cleanly. Payloads larger than limit are rejected; a limit of zero or
+// less is ignored.
+func readFrame(r *bufio.Reader, limit int64) (byte, []byte, error) {
+	typ, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, nil, unexpectedEOF(err)
+	}
+	if limit > 0 && size > uint64(limit) {
+		return 0, nil, yarpcerrors.ResourceExhaustedErrorf(
+			"stream message of %d bytes exceeds the limit of %d bytes", size, limit)
+	}
+	// The payload is copied rather than read into a buffer of the advertised
+	// size so that a corrupt length cannot allocate more than was sent.
+	var payload bytes.Buffer
+	if _, err := io.CopyN(&payload, r, int64(size)); err != nil {
+		return 0, nil, unexpectedEOF(err)
+	}
+	return typ, payload.Bytes(), nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func readMessage(m *transport.StreamMessage) ([]byte, error) {
+	defer m.Body.Close()
+	return ioutil.ReadAll(m.Body)
+}
+
+func newStreamMessage(payload []byte) *transport.StreamMessage {
+	return &transport.StreamMessage{Body: ioutil.NopCloser(bytes.NewReader(payload))}
+}
+
+func (h handler) handleStream(ctx context.Context, treq *transport.Request, body io.Reader, streamHandler transport.StreamHandler, responseWriter *responseWriter) error {
+	stream := newServerStream(ctx, &transport.StreamRequest{Meta: treq.ToRequestMeta()}, body, responseWriter, h.maxRequestBodySize, h.logger)
+	ss, err := transport.NewServerStream(stream)
+	if err != nil {
+		return err
+	}
+	return stream.close(transport.DispatchStreamHandler(streamHandler, ss))
+}
+
+// serverStream is the server side of a stream. It starts the response when
+// it sends the first message, so handlers that fail before that respond the
+// same way unary handlers do.
+type serverStream struct {
+	ctx            context.Context
+	req            *transport.StreamRequest
+	reader         *bufio.Reader
+	responseWriter *responseWriter
+	maxMessageSize int64
+	logger         *zap.Logger
+
+	writer  tchannel.ArgWriter
+	openErr error
+}
+
+func newServerStream(ctx context.Context, req *transport.StreamRequest, body io.Reader, responseWriter *responseWriter, maxMessageSize int64, logger *zap.Logger) *serverStream {
+	return &serverStream{
+		ctx:            ctx,
+		req:            req,
+		reader:         bufio.NewReader(body),
+		responseWriter: responseWriter,
+		maxMessageSize: maxMessageSize,
+		logger:         logger,
+	}
+}
+
+func (ss *serverStream) Context() context.Context {
+	return ss.ctx
+}
+
+func (ss *serverStream) Request() *transport.StreamRequest {
+	return ss.req
+}
+
+func (ss *serverStream) SendHeaders(headers transport.Headers) error {
+	if ss.responseWriter.streamed {
+		return yarpcerrors.FailedPreconditionErrorf("stream headers must be sent before any message")
+	}
+	ss.responseWriter.AddHeaders(headers)
+	return ss.responseWriter.failedWith
+}
+
+func (ss *serverStream) SendMessage(_ context.Context, m *transport.StreamMessage) error {
+	payload, err := readMessage(m)
+	if err != nil {
+		return err
+	}
+	if err := ss.open(); err != nil {
+		return err
+	}
+	if err := writeFrame(ss.writer, frameMessage, payload); err != nil {
+		return err
+	}
+	return ss.writer.Flush()
+}
+
+func (ss *serverStream) ReceiveMessage(context.Context) (*transport.StreamMessage, error) {
+	typ, payload, err := readFrame(ss.reader, ss.maxMessageSize)
+	if err != nil {
+		return nil, err
+	}
+	if typ != frameMessage {
+		return nil, yarpcerrors.InvalidArgumentErrorf("unexpected stream frame type %d from client", typ)
+	}
+	return newStreamMessage(payload), nil
+}
+
+// open writes the response headers and opens the response body that the
+// messages are written to.
+func (ss *serverStream) open() error {
+	if ss.writer != nil || ss.openErr != nil {
+		return ss.openErr
+	}
+	rw := ss.responseWriter
+	if rw.failedWith != nil {
+		return rw.failedWith
+	}
+	// Once arg2 is written, the handler may no longer respond with the
+	// responseWriter.
+	rw.streamed = true
+	if ss.openErr = writeHeaders(rw.format, headerMap(rw.headers, rw.headerCase), nil, rw.response.Arg2Writer); ss.openErr != nil {
+		return ss.openErr
+	}
+	ss.writer, ss.openErr = rw.response.Arg3Writer()
+	return ss.openErr
+}
+
+// close ends the response after the handler returned with the given error.
+func (ss *serverStream) close(err error) error {
+	if !ss.responseWriter.streamed {
+		if err != nil {
+			return err
+		}
+		if err := ss.open(); err != nil {
+			return err
+		}
+	}
+	if ss.openErr != nil {
+		// Opening the response failed half way; only a system error can
+		// fail the call now.
+		return ss.openErr
+	}
+	if err != nil {
+		meta := ss.req.Meta
+		status := yarpcerrors.FromError(errors.WrapHandlerError(err, meta.Service, meta.Procedure))
+		if err := writeFrame(ss.writer, frameError, encodeHeaders(errorHeaders(ss.logger, status))); err != nil {
+			return err
+		}
+	}
+	return ss.writer.Close()
+}
+
+// CallStream starts a stream over this TChannel outbound.
+//
+// TChannel requires a TTL for every call, so unlike other transports the
+// deadline of the context bounds the lifetime of the whole stream, not just
+// its establishment.
+func (o *Outbound) CallStream(ctx context.Context, req *transport.StreamRequest) (*transport.ClientStream, error) {
+	if req == nil || req.Meta == nil {
+		return nil, yarpcerrors.InvalidArgumentErrorf("stream request for tchannel outbound was nil")
+	}
+	treq := req.Meta.ToRequest()
+	if err := o.once.WaitUntilRunning(ctx); err != nil {
+		return nil, intyarpcerrors.AnnotateWithInfo(yarpcerrors.FromError(err), "error waiting for tchannel outbound to start for service: %s", treq.Service)
+	}
+	if _, ok := ctx.(tchannel.ContextWithHeaders); ok {
+		return nil, errDoNotUseContextWithHeaders
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		return nil, yarpcerrors.InvalidArgumentErrorf("tchannel streams require a context deadline")
+	}
+	p, onFinish, err := o.getPeerForRequest(ctx, treq)
+	if err != nil {
+		return nil, toYARPCError(treq, err)
+	}
+	tp := p.transport.ch.RootPeers().GetOrAdd(p.HostPort())
+	stream, err := newClientStream(ctx, req, tp, p.transport.headerCase, p.transport.logger, onFinish)
+	if err != nil {
+		onFinish(err)
+		return nil, toYARPCError(treq, err)
+	}
+	return transport.NewClientStream(stream)
+}
+
+// clientStream is the client side of a stream.
+type clientStream struct {
+	ctx    context.Context
+	req    *transport.StreamRequest
+	format tchannel.Format
+	call   *tchannel.OutboundCall
+	writer tchannel.ArgWriter
+	logger *zap.Logger
+	closed atomic.Bool
+
+	responseOnce sync.Once
+	headers      transport.Headers
+	reader       *bufio.Reader
+	responseErr  error
+
+	finishOnce sync.Once
+	finished   chan struct{}
+	onFinish   func(error)
+}
+
+func newClientStream(ctx context.Context, req *transport.StreamRequest, peer *tchannel.Peer, headerCase headerCase, logger *zap.Logger, onFinish func(error)) (*clientStream, error) {
+	treq := req.Meta.ToRequest()
+	format := tchannel.Format(treq.Encoding)
+	call, err := peer.BeginCall(ctx, treq.Service, treq.Procedure, &tchannel.CallOptions{
+		Format:          format,
+		ShardKey:        treq.ShardKey,
+		RoutingKey:      treq.RoutingKey,
+		RoutingDelegate: treq.RoutingDelegate,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tracingBaggage := tchannel.InjectOutboundSpan(call.Response(), nil)
+	if err := writeHeaders(format, headerMap(treq.Headers, headerCase), tracingBaggage, call.Arg2Writer); err != nil {
+		return nil, errors.RequestHeadersEncodeError(treq, err)
+	}
+	writer, err := call.Arg3Writer()
+	if err != nil {
+		return nil, err
+	}
+	// Flush so that the server starts handling the stream before the first
+	// message is sent.
+	if err := writer.Flush(); err != nil {
+		return nil, err
+	}
+	cs := &clientStream{
+		ctx:      ctx,
+		req:      req,
+		format:   format,
+		call:     call,
+		writer:   writer,
+		logger:   logger,
+		finished: make(chan struct{}),
+		onFinish: onFinish,
+	}
+	go cs.finishOnDeadline()
+	return cs, nil
+}
+
+func (cs *clientStream) Context() context.Context {
+	return cs.ctx
+}
+
+func (cs *clientStream) Request() *transport.StreamRequest {
+	return cs.req
+}
+
+func (cs *clientStream) SendMessage(_ context.Context, m *transport.StreamMessage) error {
+	if cs.closed.Load() { // If the stream is closed, we should not be sending messages on it.
+		return io.EOF
+	}
+	payload, err := readMessage(m)
+	if err != nil {
+		return err
+	}
+	if err := writeFrame(cs.writer, frameMessage, payload); err != nil {
+		return cs.toError(err)
+	}
+	return cs.toError(cs.writer.Flush())
+}
+
+func (cs *clientStream) ReceiveMessage(context.Context) (*transport.StreamMessage, error) {
+	if err := cs.readResponse(); err != nil {
+		return nil, err
+	}
+	typ, payload, err := readFrame(cs.reader, 0)
+	if err == io.EOF {
+		cs.finish(nil)
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, cs.finish(cs.toError(err))
+	}
+	switch typ {
+	case frameMessage:
+		return newStreamMessage(payload), nil
+	case frameError:
+		headers, err := decodeHeaders(bytes.NewReader(payload))
+		if err == nil {
+			err = getResponseErrorAndDeleteHeaderKeys(headers, cs.logger)
+		}
+		if err == nil {
+			err = yarpcerrors.InternalErrorf("stream error frame without an error code")
+		}
+		return nil, cs.finish(err)
+	default:
+		return nil, cs.finish(yarpcerrors.InternalErrorf("unexpected stream frame type %d from server", typ))
+	}
+}
+
+func (cs *clientStream) Headers() (transport.Headers, error) {
+	if err := cs.readResponse(); err != nil {
+		return transport.Headers{}, err
+	}
+	return cs.headers, nil
+}
+
+func (cs *clientStream) Close(context.Context) error {
+	if cs.closed.Swap(true) {
+		return nil
+	}
+	return cs.toError(cs.writer.Close())
+}
+
+// readResponse reads the response headers and opens the response body,
+// waiting for the server to start its response.
+func (cs *clientStream) readResponse() error {
+	cs.responseOnce.Do(func() {
+		if err := cs.openResponse(); err != nil {
+			cs.responseErr = cs.finish(err)
+		}
+	})
+	return cs.responseErr
+}
+
+func (cs *clientStream) openResponse() error {
+	treq := cs.req.Meta.ToRequest()
+	res := cs.call.Response()
+	headers, err := readHeaders(cs.format, res.Arg2Reader)
+	if err != nil {
+		if _, ok := err.(tchannel.SystemError); ok {
+			return cs.toError(err)
+		}
+		return errors.ResponseHeadersDecodeError(treq, err)
+	}
+	if match, resSvcName := checkServiceMatchAndDeleteHeaderKey(treq.Service, headers); !match {
+		return yarpcerrors.InternalErrorf("service name sent from the request "+
+			"does not match the service name received in the response: sent %q, got: %q", treq.Service, resSvcName)
+	}
+	body, err := res.Arg3Reader()
+	if err != nil {
+		return cs.toError(err)
+	}
+	cs.headers = headers
+	cs.reader = bufio.NewReader(body)
+	return nil
+}
+
+// finish reports the end of the stream to the peer chooser the first time
+// it is called, passing the error through.
+func (cs *clientStream) finish(err error) error {
+	cs.finishOnce.Do(func() {
+		close(cs.finished)
+		cs.onFinish(err)
+	})
+	return err
+}
+
+// finishOnDeadline ends streams that the caller abandons before reading
+// them to the end. TChannel fails the call at the deadline anyway.
+func (cs *clientStream) finishOnDeadline() {
+	select {
+	case <-cs.finished:
+	case <-cs.ctx.Done():
+		_ = cs.finish(cs.toError(cs.ctx.Err()))
+	}
+}
+
+func (cs *clientStream) toError(err error) error {
+	return toYARPCError(cs.req.Meta.ToRequest(), err)
+}
@@ -0,0 +1,205 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannel
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/tchannel-go"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/testtime"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+func encodeFrames(t *testing.T, payloads ...string) []byte {
+	var buf bytes.Buffer
+	for _, p := range payloads {
+		require.NoError(t, writeFrame(&buf, frameMessage, []byte(p)))
+	}
+	return buf.Bytes()
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeFrame(&buf, frameMessage, []byte("hello")))
+	require.NoError(t, writeFrame(&buf, frameMessage, nil))
+	require.NoError(t, writeFrame(&buf, frameError, bytes.Repeat([]byte("x"), 300)))
+
+	r := bufio.NewReader(&buf)
+
+	typ, payload, err := readFrame(r, 0)
+	require.NoError(t, err)
+	assert.Equal(t, frameMessage, typ)
+	assert.Equal(t, "hello", string(payload))
+
+	typ, payload, err = readFrame(r, 0)
+	require.NoError(t, err)
+	assert.Equal(t, frameMessage, typ)
+	assert.Empty(t, payload)
+
+	typ, payload, err = readFrame(r, 0)
+	require.NoError(t, err)
+	assert.Equal(t, frameError, typ)
+	assert.Len(t, payload, 300)
+
+	_, _, err = readFrame(r, 0)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestReadFrameErrors(t *testing.T) {
+	frame := encodeFrames(t, "hello")
+
+	t.Run("truncated", func(t *testing.T) {
+		_, _, err := readFrame(bufio.NewReader(bytes.NewReader(frame[:len(frame)-1])), 0)
+		assert.Equal(t, io.ErrUnexpectedEOF, err)
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		_, _, err := readFrame(bufio.NewReader(bytes.NewReader(frame)), 4)
+		assert.Equal(t, yarpcerrors.CodeResourceExhausted, yarpcerrors.FromError(err).Code())
+	})
+}
+
+func TestHandlerStream(t *testing.T) {
+	tests := []struct {
+		desc string
+		// handle echoes the messages of the stream and returns the given
+		// error after echoing the given number of messages.
+		echo    int
+		err     error
+		headers transport.Headers
+
+		wantMessages  []string
+		wantSystemErr bool
+		wantErrCode   yarpcerrors.Code
+	}{
+		{
+			desc:         "echo",
+			echo:         2,
+			headers:      transport.NewHeaders().With("foo", "bar"),
+			wantMessages: []string{"hello", "world"},
+		},
+		{
+			desc:         "error after messages",
+			echo:         1,
+			err:          yarpcerrors.InvalidArgumentErrorf("bad message"),
+			wantMessages: []string{"hello"},
+			wantErrCode:  yarpcerrors.CodeInvalidArgument,
+		},
+		{
+			desc:          "error before messages",
+			err:           yarpcerrors.InternalErrorf("great sadness"),
+			wantSystemErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			streamHandler := transporttest.NewMockStreamHandler(mockCtrl)
+			router := transporttest.NewMockRouter(mockCtrl)
+			router.EXPECT().Choose(gomock.Any(), gomock.Any()).
+				Return(transport.NewStreamHandlerSpec(streamHandler), nil)
+			streamHandler.EXPECT().HandleStream(gomock.Any()).
+				DoAndReturn(func(stream *transport.ServerStream) error {
+					if tt.headers.Len() > 0 {
+						require.NoError(t, stream.SendHeaders(tt.headers))
+					}
+					for i := 0; i < tt.echo; i++ {
+						msg, err := stream.ReceiveMessage(context.Background())
+						require.NoError(t, err)
+						require.NoError(t, stream.SendMessage(context.Background(), msg))
+					}
+					return tt.err
+				})
+
+			respRecorder := newResponseRecorder()
+			ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+			defer cancel()
+			handler{router: router}.handle(ctx, &fakeInboundCall{
+				service: "service",
+				caller:  "caller",
+				format:  tchannel.Raw,
+				method:  "hello",
+				arg2:    []byte{0x00, 0x00},
+				arg3:    encodeFrames(t, "hello", "world"),
+				resp:    respRecorder,
+			})
+
+			if tt.wantSystemErr {
+				assert.Error(t, respRecorder.systemErr)
+				assert.Equal(t, 0, respRecorder.arg3.Len(), "response must not be started")
+				return
+			}
+			require.NoError(t, respRecorder.systemErr)
+
+			headers, err := decodeHeaders(&respRecorder.arg2.Buffer)
+			require.NoError(t, err)
+			for k, v := range tt.headers.Items() {
+				got, _ := headers.Get(k)
+				assert.Equal(t, v, got, "header %q", k)
+			}
+
+			r := bufio.NewReader(&respRecorder.arg3.Buffer)
+			var messages []string
+			for {
+				typ, payload, err := readFrame(r, 0)
+				if err == io.EOF {
+					break
+				}
+				require.NoError(t, err)
+				if typ == frameError {
+					errHeaders, err := decodeHeaders(bytes.NewReader(payload))
+					require.NoError(t, err)
+					gotErr := getResponseErrorAndDeleteHeaderKeys(errHeaders, zap.NewNop())
+					assert.Equal(t, tt.wantErrCode, yarpcerrors.FromError(gotErr).Code())
+					continue
+				}
+				messages = append(messages, string(payload))
+			}
+			assert.Equal(t, tt.wantMessages, messages)
+		})
+	}
+}
+
+func TestServerStreamHeadersAfterMessage(t *testing.T) {
+	respRecorder := newResponseRecorder()
+	rw := newResponseWriter(respRecorder, tchannel.Raw, canonicalizedHeaderCase)
+	stream := newServerStream(context.Background(), &transport.StreamRequest{Meta: &transport.RequestMeta{}}, bytes.NewReader(nil), rw, 0, zap.NewNop())
+
+	msg := &transport.StreamMessage{Body: ioutil.NopCloser(bytes.NewReader([]byte("hello")))}
+	require.NoError(t, stream.SendMessage(context.Background(), msg))
+
+	err := stream.SendHeaders(transport.NewHeaders().With("foo", "bar"))
+	assert.Equal(t, yarpcerrors.CodeFailedPrecondition, yarpcerrors.FromError(err).Code())
+}
@@ -31,6 +31,9 @@ import (
 	backoffapi "go.uber.org/yarpc/api/backoff"
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/internal/headerlimit"
+	intnet "go.uber.org/yarpc/internal/net"
 	"go.uber.org/yarpc/pkg/lifecycle"
 	"go.uber.org/zap"
 )
@@ -66,6 +69,11 @@ type Transport struct {
 	connectorsGroup        sync.WaitGroup
 	connBackoffStrategy    backoffapi.Strategy
 	headerCase             headerCase
+	maxRequestBodySize     int64
+	headerLimits           headerlimit.Limits
+	warmConnections        int
+	clock                  clock.Clock
+	connReporter           *intnet.ConnReporter
 
 	peers map[string]*tchannelPeer
 }
@@ -111,6 +119,11 @@ func (o transportOptions) newTransport() *Transport {
 		tracer:              o.tracer,
 		logger:              logger,
 		headerCase:          headerCase,
+		maxRequestBodySize:  o.maxRequestBodySize,
+		headerLimits:        o.headerLimits,
+		warmConnections:     o.warmConnections,
+		clock:               o.clock,
+		connReporter:        intnet.NewConnReporter(transportName, o.connectionEventHook),
 	}
 }
 
@@ -198,12 +211,18 @@ func (t *Transport) start() error {
 	chopts := tchannel.ChannelOptions{
 		Tracer: t.tracer,
 		Handler: handler{
-			router:     t.router,
-			tracer:     t.tracer,
-			headerCase: t.headerCase,
+			router:             t.router,
+			tracer:             t.tracer,
+			headerCase:         t.headerCase,
+			maxRequestBodySize: t.maxRequestBodySize,
+			headerLimits:       t.headerLimits,
+			logger:             t.logger,
 		},
 		OnPeerStatusChanged: t.onPeerStatusChanged,
 	}
+	if t.connReporter != nil {
+		chopts.Dialer = t.connReporter.Dialer((&net.Dialer{}).DialContext, false)
+	}
 	ch, err := tchannel.NewChannel(t.name, &chopts)
 	if err != nil {
 		return err
@@ -211,7 +230,7 @@ func (t *Transport) start() error {
 	t.ch = ch
 
 	if t.listener != nil {
-		if err := t.ch.Serve(t.listener); err != nil {
+		if err := t.ch.Serve(t.connReporter.Listener(t.listener, false)); err != nil {
 			return err
 		}
 	} else {
@@ -229,8 +248,20 @@ func (t *Transport) start() error {
 
 		// TODO(abg): If addr was just the port (":4040"), we want to use
 		// ListenIP() + ":4040" rather than just ":4040".
-		if err := t.ch.ListenAndServe(addr); err != nil {
-			return err
+		if t.connReporter == nil {
+			if err := t.ch.ListenAndServe(addr); err != nil {
+				return err
+			}
+		} else {
+			// The listener is opened here rather than by the channel so that
+			// the connections it accepts are reported.
+			listener, err := net.Listen("tcp", addr)
+			if err != nil {
+				return err
+			}
+			if err := t.ch.Serve(t.connReporter.Listener(listener, false)); err != nil {
+				return err
+			}
 		}
 	}
 
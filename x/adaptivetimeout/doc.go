@@ -0,0 +1,43 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package adaptivetimeout sets the TTLs of outgoing requests from the
+// latencies observed for their procedures.
+//
+// Callers that don't know how long a procedure takes guess a timeout: too
+// short and healthy calls fail, too long and a slow downstream ties up the
+// caller long after it could have given up. The Middleware is unary outbound
+// middleware that learns the latency distribution of each procedure from the
+// calls it sends, and gives requests without a deadline a TTL at a
+// percentile of that distribution plus a margin, within configured bounds.
+// Requests that already have a deadline are sent unchanged; their latencies
+// are still observed.
+//
+// 	timeouts := adaptivetimeout.New(
+// 		adaptivetimeout.Percentile(0.999),
+// 		adaptivetimeout.Margin(20*time.Millisecond),
+// 		adaptivetimeout.Min(50*time.Millisecond),
+// 		adaptivetimeout.Max(5*time.Second),
+// 	)
+// 	outbound := middleware.ApplyUnaryOutbound(httpOutbound, timeouts)
+//
+// Until enough latencies of a procedure are observed, its requests get the
+// maximum TTL.
+package adaptivetimeout
@@ -0,0 +1,145 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package adaptivetimeout
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+)
+
+var (
+	_ middleware.UnaryOutbound = (*Middleware)(nil)
+	_ middleware.Named         = (*Middleware)(nil)
+)
+
+type procedureKey struct {
+	service   string
+	procedure string
+}
+
+// Middleware is unary outbound middleware that sets the TTLs of requests
+// without a deadline from the latencies observed for their procedures.
+type Middleware struct {
+	opts options
+
+	mu      sync.RWMutex
+	windows map[procedureKey]*window
+
+	ttls       *metrics.CounterVector
+	learnedTTL *metrics.GaugeVector
+}
+
+// New builds a new adaptive timeout Middleware.
+func New(opts ...Option) *Middleware {
+	m := &Middleware{
+		opts:    applyOptions(opts...),
+		windows: make(map[procedureKey]*window),
+	}
+	m.ttls, _ = m.opts.meter.CounterVector(metrics.Spec{
+		Name:    "adaptive_ttls",
+		Help:    "Number of requests given a TTL, by whether it was learned from observed latencies or the maximum.",
+		VarTags: []string{"procedure", "source"},
+	})
+	m.learnedTTL, _ = m.opts.meter.GaugeVector(metrics.Spec{
+		Name:    "adaptive_ttl_ms",
+		Help:    "TTL in milliseconds learned from the observed latencies of a procedure.",
+		VarTags: []string{"procedure"},
+	})
+	return m
+}
+
+// MiddlewareName implements middleware.Named.
+func (m *Middleware) MiddlewareName() string { return "adaptivetimeout" }
+
+// Call implements middleware.UnaryOutbound.
+func (m *Middleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	w := m.window(req)
+	if _, ok := ctx.Deadline(); !ok {
+		if ttl := m.ttl(req.Procedure, w); ttl > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, ttl)
+			defer cancel()
+		}
+	}
+
+	start := m.opts.clock.Now()
+	res, err := out.Call(ctx, req)
+	w.observe(m.opts.clock.Now().Sub(start), &m.opts)
+	return res, err
+}
+
+// ttl returns the TTL for requests to a procedure, or zero to send them
+// without a deadline.
+func (m *Middleware) ttl(procedure string, w *window) time.Duration {
+	latency := w.get()
+	if latency == 0 {
+		if m.opts.max > 0 {
+			m.observe(procedure, "max")
+		}
+		return m.opts.max
+	}
+
+	ttl := latency + m.opts.margin
+	if m.opts.min > 0 && ttl < m.opts.min {
+		ttl = m.opts.min
+	}
+	if m.opts.max > 0 && ttl > m.opts.max {
+		ttl = m.opts.max
+	}
+	m.observe(procedure, "learned")
+	if gauge, err := m.learnedTTL.Get("procedure", procedure); err == nil {
+		gauge.Store(int64(ttl / time.Millisecond))
+	}
+	return ttl
+}
+
+func (m *Middleware) observe(procedure, source string) {
+	if counter, err := m.ttls.Get("procedure", procedure, "source", source); err == nil {
+		counter.Inc()
+	}
+}
+
+// window returns the window of latencies of the procedure of a request,
+// creating it if needed.
+func (m *Middleware) window(req *transport.Request) *window {
+	key := procedureKey{service: req.Service, procedure: req.Procedure}
+
+	m.mu.RLock()
+	w, ok := m.windows[key]
+	m.mu.RUnlock()
+	if ok {
+		return w
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if w, ok := m.windows[key]; ok {
+		return w
+	}
+	w = newWindow(m.opts.window)
+	m.windows[key] = w
+	return w
+}
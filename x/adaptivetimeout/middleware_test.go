@@ -0,0 +1,162 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package adaptivetimeout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+)
+
+// slowOutbound is an outbound whose calls take the given latency on a fake
+// clock. It records the TTL of the last call, or zero if it had no deadline.
+type slowOutbound struct {
+	transport.UnaryOutbound
+
+	clock   *clock.FakeClock
+	latency time.Duration
+	ttl     time.Duration
+}
+
+func (o *slowOutbound) Call(ctx context.Context, _ *transport.Request) (*transport.Response, error) {
+	o.ttl = 0
+	if deadline, ok := ctx.Deadline(); ok {
+		o.ttl = time.Until(deadline)
+	}
+	o.clock.Add(o.latency)
+	return &transport.Response{}, nil
+}
+
+func call(t *testing.T, m *Middleware, ctx context.Context, out *slowOutbound, procedure string) {
+	_, err := m.Call(ctx, &transport.Request{Service: "service", Procedure: procedure}, out)
+	require.NoError(t, err)
+}
+
+func counter(root *metrics.Root, procedure, source string) int64 {
+	for _, s := range root.Snapshot().Counters {
+		if s.Name == "adaptive_ttls" && s.Tags["procedure"] == procedure && s.Tags["source"] == source {
+			return s.Value
+		}
+	}
+	return 0
+}
+
+func TestLearnsTTL(t *testing.T) {
+	fake := clock.NewFake()
+	root := metrics.New()
+	m := New(
+		Percentile(0.9),
+		Margin(5*time.Millisecond),
+		Max(time.Minute),
+		Window(10),
+		MinSamples(10),
+		Metrics(root.Scope()),
+		withClock(fake),
+	)
+	out := &slowOutbound{clock: fake}
+
+	// Until enough latencies are observed, requests get the maximum TTL.
+	for i := 1; i <= 10; i++ {
+		out.latency = time.Duration(i) * 10 * time.Millisecond
+		call(t, m, context.Background(), out, "echo")
+		assert.InDelta(t, time.Minute, out.ttl, float64(time.Second))
+	}
+	assert.Equal(t, int64(10), counter(root, "echo", "max"))
+
+	// The 90th percentile of 10ms to 100ms is 90ms.
+	call(t, m, context.Background(), out, "echo")
+	assert.InDelta(t, 95*time.Millisecond, out.ttl, float64(5*time.Millisecond))
+	assert.Equal(t, int64(1), counter(root, "echo", "learned"))
+
+	// Other procedures are learned separately.
+	call(t, m, context.Background(), out, "other")
+	assert.InDelta(t, time.Minute, out.ttl, float64(time.Second))
+}
+
+func TestKeepsDeadline(t *testing.T) {
+	fake := clock.NewFake()
+	m := New(Max(time.Minute), Window(1), withClock(fake))
+	out := &slowOutbound{clock: fake, latency: time.Millisecond}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	call(t, m, ctx, out, "echo")
+	assert.InDelta(t, time.Hour, out.ttl, float64(time.Second))
+
+	// The latency of calls with a deadline is still observed.
+	call(t, m, context.Background(), out, "echo")
+	assert.InDelta(t, 11*time.Millisecond, out.ttl, float64(5*time.Millisecond))
+}
+
+func TestBounds(t *testing.T) {
+	tests := []struct {
+		desc    string
+		opts    []Option
+		latency time.Duration
+		want    time.Duration
+	}{
+		{
+			desc:    "below min",
+			opts:    []Option{Min(time.Second)},
+			latency: time.Millisecond,
+			want:    time.Second,
+		},
+		{
+			desc:    "above max",
+			opts:    []Option{Max(time.Second)},
+			latency: time.Minute,
+			want:    time.Second,
+		},
+		{
+			desc:    "within bounds",
+			opts:    []Option{Min(time.Millisecond), Max(time.Minute), Margin(0)},
+			latency: time.Second,
+			want:    time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			fake := clock.NewFake()
+			m := New(append(tt.opts, Window(1), withClock(fake))...)
+			out := &slowOutbound{clock: fake, latency: tt.latency}
+
+			call(t, m, context.Background(), out, "echo")
+			call(t, m, context.Background(), out, "echo")
+			assert.InDelta(t, tt.want, out.ttl, float64(5*time.Millisecond))
+		})
+	}
+}
+
+func TestNoMaxSendsWithoutDeadline(t *testing.T) {
+	fake := clock.NewFake()
+	m := New(withClock(fake))
+	out := &slowOutbound{clock: fake, latency: time.Millisecond}
+
+	call(t, m, context.Background(), out, "echo")
+	assert.Zero(t, out.ttl, "expected no deadline before latencies are known")
+}
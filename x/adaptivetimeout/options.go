@@ -0,0 +1,144 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package adaptivetimeout
+
+import (
+	"time"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/internal/clock"
+)
+
+const (
+	_defaultPercentile = 0.99
+	_defaultMargin     = 10 * time.Millisecond
+	_defaultWindow     = 1000
+	_defaultMinSamples = 100
+)
+
+// Option customizes the behavior of a Middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	percentile float64
+	margin     time.Duration
+	min        time.Duration
+	max        time.Duration
+	window     int
+	minSamples int
+	meter      *metrics.Scope
+	clock      clock.Clock
+}
+
+// Percentile specifies the percentile of the observed latencies of a
+// procedure, between 0 and 1, that its TTL is based on. Defaults to 0.99.
+func Percentile(p float64) Option {
+	return optionFunc(func(opts *options) {
+		opts.percentile = p
+	})
+}
+
+// Margin specifies how much longer than the percentile of observed latencies
+// TTLs are, to absorb variations in latency that the window of observed
+// latencies hasn't seen. Defaults to 10 milliseconds.
+func Margin(margin time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.margin = margin
+	})
+}
+
+// Min specifies the shortest TTL the Middleware sets, however fast a
+// procedure is. By default, TTLs have no minimum.
+func Min(ttl time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.min = ttl
+	})
+}
+
+// Max specifies the longest TTL the Middleware sets, however slow a
+// procedure is. Requests to procedures whose latencies are not known yet get
+// this TTL. By default, TTLs have no maximum, and requests to such
+// procedures are sent without a deadline.
+func Max(ttl time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.max = ttl
+	})
+}
+
+// Window specifies how many of the most recent latencies of each procedure
+// its TTL is based on. Defaults to 1000.
+func Window(size int) Option {
+	return optionFunc(func(opts *options) {
+		opts.window = size
+	})
+}
+
+// MinSamples specifies how many latencies of a procedure must be observed
+// before its TTL is based on them. Defaults to 100, or the size of the
+// window if it's smaller.
+func MinSamples(n int) Option {
+	return optionFunc(func(opts *options) {
+		opts.minSamples = n
+	})
+}
+
+// Metrics specifies the scope to which metrics about TTLs are reported. By
+// default, no metrics are reported.
+func Metrics(meter *metrics.Scope) Option {
+	return optionFunc(func(opts *options) {
+		opts.meter = meter
+	})
+}
+
+func withClock(clock clock.Clock) Option {
+	return optionFunc(func(opts *options) {
+		opts.clock = clock
+	})
+}
+
+func applyOptions(opts ...Option) options {
+	options := options{
+		percentile: _defaultPercentile,
+		margin:     _defaultMargin,
+		window:     _defaultWindow,
+		minSamples: _defaultMinSamples,
+		clock:      clock.NewReal(),
+	}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	if options.window < 1 {
+		options.window = 1
+	}
+	if options.minSamples > options.window {
+		options.minSamples = options.window
+	}
+	if options.minSamples < 1 {
+		options.minSamples = 1
+	}
+	return options
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package adaptivetimeout
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// window holds the most recent latencies of a procedure and the percentile
+// of them. Sorting the window on every call would be wasteful, so the
+// percentile is recomputed only after a tenth of the window changed.
+type window struct {
+	mu sync.Mutex
+
+	latencies  []time.Duration
+	next       int
+	percentile time.Duration
+	stale      int
+}
+
+func newWindow(size int) *window {
+	return &window{latencies: make([]time.Duration, 0, size)}
+}
+
+// observe adds a latency to the window, evicting the oldest latency if it's
+// full.
+func (w *window) observe(latency time.Duration, opts *options) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.latencies) < cap(w.latencies) {
+		w.latencies = append(w.latencies, latency)
+	} else {
+		w.latencies[w.next] = latency
+		w.next = (w.next + 1) % len(w.latencies)
+	}
+
+	w.stale++
+	if len(w.latencies) < opts.minSamples {
+		return
+	}
+	if w.percentile > 0 && w.stale < (cap(w.latencies)+9)/10 {
+		return
+	}
+
+	sorted := make([]time.Duration, len(w.latencies))
+	copy(sorted, w.latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	i := int(math.Ceil(opts.percentile*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	// Keep the percentile positive so that it tells apart a learned latency
+	// from one that isn't known yet.
+	w.percentile = sorted[i]
+	if w.percentile <= 0 {
+		w.percentile = time.Nanosecond
+	}
+	w.stale = 0
+}
+
+// get returns the percentile of the latencies in the window, or zero if not
+// enough latencies were observed.
+func (w *window) get() time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.percentile
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package admission provides inbound middleware that limits the number of
+// requests handled concurrently, queues the excess by priority, and sheds
+// low-priority work first when the service is overloaded.
+//
+// Requests are classified into priority tiers, by procedure or by a header
+// set by callers. Higher priorities are more important. When all handling
+// slots are busy, requests wait in the queue of their tier, and a free slot
+// always goes to the oldest request of the highest non-empty tier. When the
+// queue is full, an incoming request takes the place of the newest queued
+// request of a lower tier, which fails; if there is no such request, the
+// incoming request fails instead. Failed requests get ResourceExhausted
+// errors.
+//
+// 	admit := admission.New(
+// 		admission.MaxConcurrent(64),
+// 		admission.MaxQueued(256),
+// 		admission.PriorityHeader("x-priority"),
+// 		admission.ProcedurePriority("Health::check", 100),
+// 		admission.ProcedurePriority("Reports::generate", -10),
+// 	)
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  admit,
+// 			Oneway: admit,
+// 		},
+// 	})
+//
+// Queued requests also fail when their context ends.
+package admission
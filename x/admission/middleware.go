@@ -0,0 +1,259 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package admission
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+	"sync"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var (
+	_ middleware.UnaryInbound  = (*Middleware)(nil)
+	_ middleware.OnewayInbound = (*Middleware)(nil)
+)
+
+// Middleware is inbound middleware that admits requests by priority.
+type Middleware struct {
+	opts options
+
+	mu      sync.Mutex
+	running int
+	queued  int
+	queues  map[Priority]*list.List // of *waiter, oldest first
+
+	queuedGauge *metrics.GaugeVector
+	shed        *metrics.CounterVector
+}
+
+// waiter is a request waiting for a handling slot.
+type waiter struct {
+	priority Priority
+	elem     *list.Element // nil once the waiter left the queue
+	admitted chan error
+}
+
+// New builds a new admission Middleware.
+func New(opts ...Option) *Middleware {
+	m := &Middleware{
+		opts:   applyOptions(opts...),
+		queues: make(map[Priority]*list.List),
+	}
+	meter := m.opts.meter
+	m.queuedGauge, _ = meter.GaugeVector(metrics.Spec{
+		Name:    "admission_queued",
+		Help:    "Number of requests waiting for a handling slot.",
+		VarTags: []string{"priority"},
+	})
+	m.shed, _ = meter.CounterVector(metrics.Spec{
+		Name:    "admission_shed",
+		Help:    "Number of requests failed because the service was overloaded.",
+		VarTags: []string{"priority"},
+	})
+	return m
+}
+
+// Handle admits the unary request before handling it.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	if err := m.acquire(ctx, req); err != nil {
+		return err
+	}
+	defer m.release()
+	return h.Handle(ctx, req, resw)
+}
+
+// HandleOneway admits the oneway request before handling it.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	if err := m.acquire(ctx, req); err != nil {
+		return err
+	}
+	defer m.release()
+	return h.HandleOneway(ctx, req)
+}
+
+// classify returns the priority of the request.
+func (m *Middleware) classify(req *transport.Request) Priority {
+	if p, ok := m.opts.procedures[req.Procedure]; ok {
+		return p
+	}
+	if m.opts.header != "" {
+		if v, ok := req.Headers.Get(m.opts.header); ok {
+			if p, err := strconv.Atoi(v); err == nil {
+				return Priority(p)
+			}
+		}
+	}
+	return m.opts.defaultPriority
+}
+
+// acquire waits for a handling slot for the request, or returns an error if
+// the request is shed or its context ends first.
+func (m *Middleware) acquire(ctx context.Context, req *transport.Request) error {
+	priority := m.classify(req)
+
+	m.mu.Lock()
+	if m.running < m.opts.maxConcurrent {
+		m.running++
+		m.mu.Unlock()
+		return nil
+	}
+
+	w, err := m.enqueue(priority)
+	m.mu.Unlock()
+	if err != nil {
+		return m.shedError(req, priority)
+	}
+
+	select {
+	case err := <-w.admitted:
+		if err != nil {
+			return m.shedError(req, priority)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	m.mu.Lock()
+	if w.elem != nil {
+		m.dequeue(w)
+		m.mu.Unlock()
+		return ctx.Err()
+	}
+	m.mu.Unlock()
+
+	// The request left the queue while its context ended.
+	if err := <-w.admitted; err == nil {
+		m.release()
+	}
+	return ctx.Err()
+}
+
+// enqueue adds a waiter for the given priority to the queue, evicting a
+// lower priority waiter if the queue is full. It must be called with the
+// mutex locked.
+func (m *Middleware) enqueue(priority Priority) (*waiter, error) {
+	q := m.queues[priority]
+	if limit, ok := m.opts.tierMaxQueued[priority]; ok && q != nil && q.Len() >= limit {
+		return nil, errShed
+	}
+	if m.queued >= m.opts.maxQueued {
+		victim := m.lowestQueueBelow(priority)
+		if victim == nil {
+			return nil, errShed
+		}
+		evicted := victim.Back().Value.(*waiter)
+		m.dequeue(evicted)
+		evicted.admitted <- errShed
+	}
+
+	if q == nil {
+		q = list.New()
+		m.queues[priority] = q
+	}
+	w := &waiter{priority: priority, admitted: make(chan error, 1)}
+	w.elem = q.PushBack(w)
+	m.queued++
+	m.setQueued(priority, q.Len())
+	return w, nil
+}
+
+// dequeue removes the waiter from its queue. It must be called with the
+// mutex locked.
+func (m *Middleware) dequeue(w *waiter) {
+	q := m.queues[w.priority]
+	q.Remove(w.elem)
+	w.elem = nil
+	m.queued--
+	m.setQueued(w.priority, q.Len())
+	if q.Len() == 0 {
+		delete(m.queues, w.priority)
+	}
+}
+
+// release hands the slot of a finished request to the oldest waiter of the
+// highest priority, if any.
+func (m *Middleware) release() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	q := m.highestQueue()
+	if q == nil {
+		m.running--
+		return
+	}
+	w := q.Front().Value.(*waiter)
+	m.dequeue(w)
+	w.admitted <- nil
+}
+
+// lowestQueueBelow returns the non-empty queue with the lowest priority
+// below the given one. It must be called with the mutex locked.
+func (m *Middleware) lowestQueueBelow(priority Priority) *list.List {
+	var (
+		lowest *list.List
+		lp     Priority
+	)
+	for p, q := range m.queues {
+		if p < priority && (lowest == nil || p < lp) {
+			lowest, lp = q, p
+		}
+	}
+	return lowest
+}
+
+// highestQueue returns the non-empty queue with the highest priority. It
+// must be called with the mutex locked.
+func (m *Middleware) highestQueue() *list.List {
+	var (
+		highest *list.List
+		hp      Priority
+	)
+	for p, q := range m.queues {
+		if highest == nil || p > hp {
+			highest, hp = q, p
+		}
+	}
+	return highest
+}
+
+func (m *Middleware) setQueued(priority Priority, n int) {
+	if g, err := m.queuedGauge.Get("priority", strconv.Itoa(int(priority))); err == nil {
+		g.Store(int64(n))
+	}
+}
+
+func (m *Middleware) shedError(req *transport.Request, priority Priority) error {
+	if c, err := m.shed.Get("priority", strconv.Itoa(int(priority))); err == nil {
+		c.Inc()
+	}
+	return yarpcerrors.ResourceExhaustedErrorf(
+		"request to procedure %q with priority %d was shed because service %q is overloaded",
+		req.Procedure, priority, req.Service)
+}
+
+// errShed signals a shed request internally.
+var errShed = yarpcerrors.ResourceExhaustedErrorf("request was shed")
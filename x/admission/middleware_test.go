@@ -0,0 +1,210 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package admission
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// blockingHandler is a unary handler that records the procedures it handles
+// and blocks until it is released.
+type blockingHandler struct {
+	handled chan string
+	release chan struct{}
+}
+
+func newBlockingHandler() *blockingHandler {
+	return &blockingHandler{
+		handled: make(chan string, 10),
+		release: make(chan struct{}),
+	}
+}
+
+func (h *blockingHandler) Handle(ctx context.Context, req *transport.Request, _ transport.ResponseWriter) error {
+	h.handled <- req.Procedure
+	<-h.release
+	return nil
+}
+
+func request(procedure string, priority int) *transport.Request {
+	return &transport.Request{
+		Service:   "svc",
+		Procedure: procedure,
+		Headers:   transport.NewHeaders().With("x-priority", strconv.Itoa(priority)),
+	}
+}
+
+// handle handles the request in the background, returning a channel with
+// its result.
+func handle(ctx context.Context, m *Middleware, h transport.UnaryHandler, req *transport.Request) <-chan error {
+	errc := make(chan error, 1)
+	go func() { errc <- m.Handle(ctx, req, nil, h) }()
+	return errc
+}
+
+func waitQueued(t *testing.T, m *Middleware, n int) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		m.mu.Lock()
+		queued := m.queued
+		m.mu.Unlock()
+		if queued == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("expected %d queued requests", n)
+}
+
+func TestHighestPriorityFirst(t *testing.T) {
+	ctx := context.Background()
+	h := newBlockingHandler()
+	m := New(MaxConcurrent(1), PriorityHeader("x-priority"))
+
+	first := handle(ctx, m, h, request("first", 0))
+	assert.Equal(t, "first", <-h.handled)
+
+	low := handle(ctx, m, h, request("low", -1))
+	waitQueued(t, m, 1)
+	high := handle(ctx, m, h, request("high", 1))
+	waitQueued(t, m, 2)
+	high2 := handle(ctx, m, h, request("high2", 1))
+	waitQueued(t, m, 3)
+
+	var order []string
+	for range [3]struct{}{} {
+		h.release <- struct{}{}
+		order = append(order, <-h.handled)
+	}
+	h.release <- struct{}{}
+	assert.Equal(t, []string{"high", "high2", "low"}, order)
+
+	for _, errc := range []<-chan error{first, low, high, high2} {
+		assert.NoError(t, <-errc)
+	}
+	assert.Equal(t, 0, m.running)
+}
+
+func TestShedLowPriorityFirst(t *testing.T) {
+	ctx := context.Background()
+	h := newBlockingHandler()
+	m := New(MaxConcurrent(1), MaxQueued(1), PriorityHeader("x-priority"))
+
+	running := handle(ctx, m, h, request("running", 0))
+	<-h.handled
+
+	low := handle(ctx, m, h, request("low", -1))
+	waitQueued(t, m, 1)
+
+	// A request with the same priority cannot take the place of the queued
+	// one.
+	err := m.Handle(ctx, request("same", -1), nil, h)
+	require.Error(t, err)
+	assert.True(t, yarpcerrors.IsResourceExhausted(err))
+
+	high := handle(ctx, m, h, request("high", 1))
+	err = <-low
+	require.Error(t, err, "low priority request must be evicted")
+	assert.True(t, yarpcerrors.IsResourceExhausted(err))
+	assert.Contains(t, err.Error(), `procedure "low" with priority -1`)
+
+	close(h.release)
+	assert.Equal(t, "high", <-h.handled)
+	assert.NoError(t, <-running)
+	assert.NoError(t, <-high)
+}
+
+func TestTierMaxQueued(t *testing.T) {
+	ctx := context.Background()
+	h := newBlockingHandler()
+	m := New(MaxConcurrent(1), TierMaxQueued(0, 1))
+
+	running := handle(ctx, m, h, request("running", 0))
+	<-h.handled
+	queued := handle(ctx, m, h, request("queued", 0))
+	waitQueued(t, m, 1)
+
+	err := m.Handle(ctx, request("rejected", 0), nil, h)
+	assert.True(t, yarpcerrors.IsResourceExhausted(err))
+
+	close(h.release)
+	assert.NoError(t, <-running)
+	assert.NoError(t, <-queued)
+}
+
+func TestQueuedContextEnds(t *testing.T) {
+	h := newBlockingHandler()
+	m := New(MaxConcurrent(1))
+
+	running := handle(context.Background(), m, h, request("running", 0))
+	<-h.handled
+
+	ctx, cancel := context.WithCancel(context.Background())
+	queued := handle(ctx, m, h, request("queued", 0))
+	waitQueued(t, m, 1)
+	cancel()
+	assert.Equal(t, context.Canceled, <-queued)
+	waitQueued(t, m, 0)
+
+	close(h.release)
+	assert.NoError(t, <-running)
+	assert.Equal(t, 0, m.running)
+}
+
+func TestClassify(t *testing.T) {
+	m := New(
+		PriorityHeader("x-priority"),
+		ProcedurePriority("health", 100),
+		DefaultPriority(-5),
+	)
+
+	assert.Equal(t, Priority(100), m.classify(request("health", 1)), "procedure must take precedence")
+	assert.Equal(t, Priority(3), m.classify(request("other", 3)))
+	assert.Equal(t, Priority(-5), m.classify(&transport.Request{Procedure: "other"}))
+
+	req := &transport.Request{Headers: transport.NewHeaders().With("x-priority", "high")}
+	assert.Equal(t, Priority(-5), m.classify(req), "invalid priorities must be ignored")
+
+	assert.Equal(t, Priority(0), New().classify(request("other", 3)), "header must be opt-in")
+}
+
+type onewayHandler struct{ called bool }
+
+func (h *onewayHandler) HandleOneway(context.Context, *transport.Request) error {
+	h.called = true
+	return nil
+}
+
+func TestHandleOneway(t *testing.T) {
+	m := New(MaxConcurrent(1))
+	h := &onewayHandler{}
+	require.NoError(t, m.HandleOneway(context.Background(), request("oneway", 0), h))
+	assert.True(t, h.called)
+	assert.Equal(t, 0, m.running)
+}
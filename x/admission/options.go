@@ -0,0 +1,122 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package admission
+
+import "go.uber.org/net/metrics"
+
+const (
+	_defaultMaxConcurrent = 100
+	_defaultMaxQueued     = 100
+)
+
+// Priority is the priority tier of a request. Higher priorities are more
+// important.
+type Priority int
+
+// Option customizes the behavior of a Middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	maxConcurrent   int
+	maxQueued       int
+	tierMaxQueued   map[Priority]int
+	procedures      map[string]Priority
+	header          string
+	defaultPriority Priority
+	meter           *metrics.Scope
+}
+
+// MaxConcurrent specifies the maximum number of requests handled
+// concurrently. Defaults to 100.
+func MaxConcurrent(n int) Option {
+	return optionFunc(func(opts *options) {
+		opts.maxConcurrent = n
+	})
+}
+
+// MaxQueued specifies the maximum number of requests waiting for a handling
+// slot, across all tiers. Defaults to 100.
+func MaxQueued(n int) Option {
+	return optionFunc(func(opts *options) {
+		opts.maxQueued = n
+	})
+}
+
+// TierMaxQueued specifies the maximum number of requests of the given
+// priority waiting for a handling slot. Requests of that priority fail when
+// its queue is full. By default, tiers are only limited by MaxQueued.
+func TierMaxQueued(priority Priority, n int) Option {
+	return optionFunc(func(opts *options) {
+		opts.tierMaxQueued[priority] = n
+	})
+}
+
+// ProcedurePriority specifies the priority of requests to the given
+// procedure. It takes precedence over the priority header.
+func ProcedurePriority(procedure string, priority Priority) Option {
+	return optionFunc(func(opts *options) {
+		opts.procedures[procedure] = priority
+	})
+}
+
+// PriorityHeader specifies the request header from which callers may set the
+// priority of their requests, as an integer. By default, the priority of
+// requests is not read from their headers.
+func PriorityHeader(header string) Option {
+	return optionFunc(func(opts *options) {
+		opts.header = header
+	})
+}
+
+// DefaultPriority specifies the priority of requests that are not otherwise
+// classified. Defaults to zero.
+func DefaultPriority(priority Priority) Option {
+	return optionFunc(func(opts *options) {
+		opts.defaultPriority = priority
+	})
+}
+
+// Metrics specifies the scope to which metrics about queued and shed
+// requests are reported. By default, no metrics are reported.
+func Metrics(meter *metrics.Scope) Option {
+	return optionFunc(func(opts *options) {
+		opts.meter = meter
+	})
+}
+
+func applyOptions(opts ...Option) options {
+	options := options{
+		maxConcurrent: _defaultMaxConcurrent,
+		maxQueued:     _defaultMaxQueued,
+		tierMaxQueued: make(map[Priority]int),
+		procedures:    make(map[string]Priority),
+	}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return options
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package alias provides router middleware that keeps deprecated procedure
+// names working after procedures are renamed.
+//
+// Calls to an alias are routed to the procedure it names, and reported as
+// deprecated: the router counts them, logs the first call from each caller,
+// and tells callers the new name in the DeprecationHeader response header of
+// unary calls.
+//
+// 	aliases, err := alias.NewRouter([]alias.Alias{
+// 		{Service: "users", Name: "Users::GetUser", Procedure: "Users::Get"},
+// 	}, alias.Logger(logger), alias.Metrics(scope))
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name:             "users",
+// 		RouterMiddleware: aliases,
+// 	})
+//
+// Aliases are listed with the procedures of the dispatcher, marked as
+// deprecated, so that introspection and debug pages show them.
+package alias
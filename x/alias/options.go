@@ -0,0 +1,66 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package alias
+
+import (
+	"go.uber.org/net/metrics"
+	"go.uber.org/zap"
+)
+
+// Option customizes the behavior of a Router.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	logger *zap.Logger
+	meter  *metrics.Scope
+}
+
+// Logger specifies the logger to which the first call from each caller to
+// each alias is logged. By default, nothing is logged.
+func Logger(logger *zap.Logger) Option {
+	return optionFunc(func(opts *options) {
+		opts.logger = logger
+	})
+}
+
+// Metrics specifies the scope to which calls to aliases are reported. By
+// default, no metrics are reported.
+func Metrics(meter *metrics.Scope) Option {
+	return optionFunc(func(opts *options) {
+		opts.meter = meter
+	})
+}
+
+func applyOptions(opts ...Option) options {
+	options := options{
+		logger: zap.NewNop(),
+	}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return options
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package alias
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/zap"
+)
+
+// DeprecationHeader is the application header of responses to calls to
+// aliases. Its value is the name of the procedure that callers should call
+// instead.
+const DeprecationHeader = "yarpc-deprecated-use"
+
+var _ middleware.Router = (*Router)(nil)
+
+// Alias is a deprecated name of a procedure.
+type Alias struct {
+	// Service of the alias. Empty to alias the procedure of every
+	// service.
+	Service string
+
+	// Name is the deprecated name of the procedure.
+	Name string
+
+	// Procedure is the name that calls to the alias are routed to.
+	Procedure string
+}
+
+type aliasKey struct {
+	service string
+	name    string
+}
+
+type callerKey struct {
+	alias  aliasKey
+	caller string
+}
+
+// Router is router middleware that routes calls to aliases.
+type Router struct {
+	list    []Alias
+	aliases map[aliasKey]Alias
+	logger  *zap.Logger
+	calls   *metrics.CounterVector
+
+	// Callers whose calls to aliases were logged.
+	logged sync.Map // map[callerKey]struct{}
+}
+
+// NewRouter builds a Router for the given aliases. Aliases must have names
+// and procedures, and may not alias procedures to themselves.
+func NewRouter(aliases []Alias, opts ...Option) (*Router, error) {
+	options := applyOptions(opts...)
+	r := &Router{
+		list:    append([]Alias(nil), aliases...),
+		aliases: make(map[aliasKey]Alias, len(aliases)),
+		logger:  options.logger,
+	}
+	for _, a := range aliases {
+		if a.Name == "" || a.Procedure == "" {
+			return nil, fmt.Errorf("alias %q of procedure %q must have a name and a procedure", a.Name, a.Procedure)
+		}
+		if a.Name == a.Procedure {
+			return nil, fmt.Errorf("procedure %q cannot be an alias of itself", a.Name)
+		}
+		key := aliasKey{service: a.Service, name: a.Name}
+		if _, ok := r.aliases[key]; ok {
+			return nil, fmt.Errorf("alias %q of service %q is registered more than once", a.Name, a.Service)
+		}
+		r.aliases[key] = a
+	}
+	r.calls, _ = options.meter.CounterVector(metrics.Spec{
+		Name:    "deprecated_procedure_calls",
+		Help:    "Number of calls to deprecated procedure aliases.",
+		VarTags: []string{"service", "procedure", "caller"},
+	})
+	return r, nil
+}
+
+// MiddlewareName implements middleware.Named.
+func (r *Router) MiddlewareName() string { return "alias" }
+
+// Procedures returns the procedures of the given router, and a deprecated
+// copy of each aliased procedure named after its alias.
+func (r *Router) Procedures(router transport.Router) []transport.Procedure {
+	procedures := router.Procedures()
+	for _, p := range procedures {
+		for _, a := range r.list {
+			if a.Procedure != p.Name || (a.Service != "" && a.Service != p.Service) {
+				continue
+			}
+			alias := p
+			alias.Name = a.Name
+			alias.Metadata.Deprecated = true
+			alias.Metadata.DeprecationMessage = fmt.Sprintf("use %s instead", a.Procedure)
+			procedures = append(procedures, alias)
+		}
+	}
+	return procedures
+}
+
+// Choose routes calls to aliases to the procedures they name, and calls to
+// other procedures as the given router does.
+func (r *Router) Choose(ctx context.Context, req *transport.Request, router transport.Router) (transport.HandlerSpec, error) {
+	a, ok := r.lookup(req.Service, req.Procedure)
+	if !ok {
+		return router.Choose(ctx, req)
+	}
+
+	aliased := *req
+	aliased.Procedure = a.Procedure
+	spec, err := router.Choose(ctx, &aliased)
+	if err != nil {
+		return spec, err
+	}
+
+	r.report(req, a)
+	switch spec.Type() {
+	case transport.Unary:
+		return transport.NewUnaryHandlerSpec(deprecatedHandler{spec.Unary(), a.Procedure}), nil
+	default:
+		return spec, nil
+	}
+}
+
+func (r *Router) lookup(service, name string) (Alias, bool) {
+	if a, ok := r.aliases[aliasKey{service: service, name: name}]; ok {
+		return a, true
+	}
+	a, ok := r.aliases[aliasKey{name: name}]
+	return a, ok
+}
+
+func (r *Router) report(req *transport.Request, a Alias) {
+	if c, err := r.calls.Get(
+		"service", req.Service,
+		"procedure", a.Name,
+		"caller", req.Caller,
+	); err == nil {
+		c.Inc()
+	}
+
+	key := callerKey{alias: aliasKey{service: req.Service, name: a.Name}, caller: req.Caller}
+	if _, logged := r.logged.LoadOrStore(key, struct{}{}); !logged {
+		r.logger.Warn("call to deprecated procedure alias",
+			zap.String("service", req.Service),
+			zap.String("procedure", a.Name),
+			zap.String("use", a.Procedure),
+			zap.String("caller", req.Caller))
+	}
+}
+
+// deprecatedHandler tells callers of an alias which procedure to call
+// instead.
+type deprecatedHandler struct {
+	transport.UnaryHandler
+
+	procedure string
+}
+
+func (h deprecatedHandler) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	resw.AddHeaders(transport.NewHeaders().With(DeprecationHeader, h.procedure))
+	return h.UnaryHandler.Handle(ctx, req, resw)
+}
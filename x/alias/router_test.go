@@ -0,0 +1,148 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package alias
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type unaryHandlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f unaryHandlerFunc) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	return f(ctx, req, resw)
+}
+
+func newTable(t *testing.T, opts ...Option) transport.RouteTable {
+	aliases, err := NewRouter([]Alias{
+		{Service: "users", Name: "Users::GetUser", Procedure: "Users::Get"},
+	}, opts...)
+	require.NoError(t, err)
+
+	router := yarpc.NewMapRouter("users")
+	router.Register([]transport.Procedure{
+		{
+			Name: "Users::Get",
+			HandlerSpec: transport.NewUnaryHandlerSpec(unaryHandlerFunc(
+				func(_ context.Context, _ *transport.Request, resw transport.ResponseWriter) error {
+					_, err := resw.Write([]byte("user"))
+					return err
+				})),
+		},
+	})
+	return middleware.ApplyRouteTable(router, aliases)
+}
+
+func TestChooseAlias(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	root := metrics.New()
+	table := newTable(t, Logger(zap.New(core)), Metrics(root.Scope()))
+
+	for i := 0; i < 2; i++ {
+		req := &transport.Request{Caller: "old-client", Service: "users", Procedure: "Users::GetUser"}
+		spec, err := table.Choose(context.Background(), req)
+		require.NoError(t, err)
+
+		resw := &transporttest.FakeResponseWriter{}
+		require.NoError(t, spec.Unary().Handle(context.Background(), req, resw))
+		assert.Equal(t, "user", resw.Body.String())
+		use, _ := resw.Headers.Get(DeprecationHeader)
+		assert.Equal(t, "Users::Get", use)
+	}
+
+	require.Len(t, root.Snapshot().Counters, 1)
+	counter := root.Snapshot().Counters[0]
+	assert.Equal(t, "deprecated_procedure_calls", counter.Name)
+	assert.Equal(t, int64(2), counter.Value)
+	assert.Equal(t, "old-client", counter.Tags["caller"])
+
+	assert.Equal(t, 1, logs.FilterMessage("call to deprecated procedure alias").Len(),
+		"only the first call from each caller should be logged")
+}
+
+func TestChooseWithoutAlias(t *testing.T) {
+	table := newTable(t)
+
+	spec, err := table.Choose(context.Background(), &transport.Request{Service: "users", Procedure: "Users::Get"})
+	require.NoError(t, err)
+	resw := &transporttest.FakeResponseWriter{}
+	require.NoError(t, spec.Unary().Handle(context.Background(), &transport.Request{}, resw))
+	_, ok := resw.Headers.Get(DeprecationHeader)
+	assert.False(t, ok, "calls to procedures should not be deprecated")
+
+	_, err = table.Choose(context.Background(), &transport.Request{Service: "users", Procedure: "Users::Unknown"})
+	assert.Equal(t, yarpcerrors.CodeUnimplemented, yarpcerrors.FromError(err).Code())
+}
+
+func TestProcedures(t *testing.T) {
+	procedures := newTable(t).Procedures()
+	require.Len(t, procedures, 2)
+
+	assert.Equal(t, "Users::Get", procedures[0].Name)
+	assert.False(t, procedures[0].Metadata.Deprecated)
+
+	assert.Equal(t, "Users::GetUser", procedures[1].Name)
+	assert.True(t, procedures[1].Metadata.Deprecated)
+	assert.Equal(t, "use Users::Get instead", procedures[1].Metadata.DeprecationMessage)
+}
+
+func TestNewRouterErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		aliases []Alias
+		wantErr string
+	}{
+		{
+			desc:    "no procedure",
+			aliases: []Alias{{Name: "old"}},
+			wantErr: "must have a name and a procedure",
+		},
+		{
+			desc:    "self alias",
+			aliases: []Alias{{Name: "old", Procedure: "old"}},
+			wantErr: "cannot be an alias of itself",
+		},
+		{
+			desc:    "duplicate",
+			aliases: []Alias{{Name: "old", Procedure: "a"}, {Name: "old", Procedure: "b"}},
+			wantErr: "registered more than once",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := NewRouter(tt.aliases)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package authz
+
+import (
+	"context"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+// Request describes an inbound request to be authorized.
+type Request struct {
+	// Subject is the entity that sent the request.
+	Subject Subject `json:"subject"`
+
+	Service   string            `json:"service"`
+	Procedure string            `json:"procedure"`
+	Encoding  string            `json:"encoding"`
+	Headers   map[string]string `json:"headers"`
+}
+
+// Subject describes the sender of a request.
+type Subject struct {
+	// Caller is the name of the calling service as reported by the caller.
+	Caller string `json:"caller"`
+
+	// Address is the network address of the peer that sent the request, if
+	// known.
+	Address string `json:"address,omitempty"`
+
+	// ID is the URI identity, such as a SPIFFE ID, of the TLS client
+	// certificate of the peer, if any. Unlike Caller, it is verified by the
+	// TLS handshake.
+	ID string `json:"id,omitempty"`
+}
+
+// Decision is the outcome of authorizing a request.
+type Decision struct {
+	// Allowed reports whether the request may be handled.
+	Allowed bool
+
+	// Reason explains the decision. The reason for denying a request is
+	// reported to the caller.
+	Reason string
+
+	// Annotations are made available to the handlers of allowed requests
+	// through AnnotationsFromContext.
+	Annotations map[string]string
+}
+
+// Allow builds a Decision that allows a request.
+func Allow() Decision {
+	return Decision{Allowed: true}
+}
+
+// Deny builds a Decision that denies a request for the given reason.
+func Deny(reason string) Decision {
+	return Decision{Reason: reason}
+}
+
+// Decider decides whether requests are allowed.
+type Decider interface {
+	Decide(ctx context.Context, req *Request) (Decision, error)
+}
+
+// DeciderFunc is a function that implements Decider.
+type DeciderFunc func(context.Context, *Request) (Decision, error)
+
+// Decide calls f.
+func (f DeciderFunc) Decide(ctx context.Context, req *Request) (Decision, error) {
+	return f(ctx, req)
+}
+
+type annotationsKey struct{}
+
+// AnnotationsFromContext returns the annotations of the decision that
+// allowed the request being handled, if any.
+func AnnotationsFromContext(ctx context.Context) map[string]string {
+	annotations, _ := ctx.Value(annotationsKey{}).(map[string]string)
+	return annotations
+}
+
+// newRequest describes a transport request for a Decider.
+func newRequest(ctx context.Context, req *transport.Request) *Request {
+	r := &Request{
+		Subject:   Subject{Caller: req.Caller},
+		Service:   req.Service,
+		Procedure: req.Procedure,
+		Encoding:  string(req.Encoding),
+		Headers:   req.Headers.Items(),
+	}
+	if peer, ok := transport.RemotePeerFromContext(ctx); ok {
+		r.Subject.Address = peer.Address
+		if peer.TLS != nil && len(peer.TLS.PeerCertificates) > 0 {
+			if uris := peer.TLS.PeerCertificates[0].URIs; len(uris) == 1 {
+				r.Subject.ID = uris[0].String()
+			}
+		}
+	}
+	return r
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package authz
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+// VerifyCaller builds a Decider that only allows requests whose caller name
+// is proven by the TLS client certificate of the peer that sent them. This
+// prevents clients from claiming the caller name of other services.
+//
+// The identities map lists, for each caller name, the identities that may
+// claim it: SPIFFE IDs or other URI SANs, or DNS SANs of client
+// certificates.
+//
+// 	authorize := authz.New(authz.VerifyCaller(map[string][]string{
+// 		"users":   {"spiffe://example.org/users"},
+// 		"billing": {"spiffe://example.org/billing", "billing.example.org"},
+// 	}))
+//
+// Requests from callers that are not listed, from peers without client
+// certificates, and from peers whose certificates carry none of the
+// identities of the caller are denied.
+//
+// VerifyCaller trusts the identities of the leaf certificate the peer
+// presented and does not verify its chain itself. The TLS configuration of
+// the inbound must verify it during the handshake, either by setting
+// ClientCAs with ClientAuth set to tls.RequireAndVerifyClientCert, or with
+// a VerifyPeerCertificate callback such as the one in the ServerTLSConfig of
+// go.uber.org/yarpc/x/spiffe. Otherwise any client can present a
+// self-signed certificate that claims the identity of another service.
+//
+// 	tlsConfig := &tls.Config{
+// 		Certificates: []tls.Certificate{serverCert},
+// 		ClientCAs:    trustedCAs,
+// 		ClientAuth:   tls.RequireAndVerifyClientCert,
+// 	}
+func VerifyCaller(identities map[string][]string) Decider {
+	allowed := make(map[string]map[string]struct{}, len(identities))
+	for caller, ids := range identities {
+		set := make(map[string]struct{}, len(ids))
+		for _, id := range ids {
+			set[id] = struct{}{}
+		}
+		allowed[caller] = set
+	}
+	return callerVerifier(allowed)
+}
+
+type callerVerifier map[string]map[string]struct{}
+
+func (v callerVerifier) Decide(ctx context.Context, req *Request) (Decision, error) {
+	ids, ok := v[req.Subject.Caller]
+	if !ok {
+		return Deny("caller has no known identities"), nil
+	}
+
+	peer, ok := transport.RemotePeerFromContext(ctx)
+	if !ok || peer.TLS == nil || len(peer.TLS.PeerCertificates) == 0 {
+		return Deny("peer did not present a TLS client certificate"), nil
+	}
+
+	cert := peer.TLS.PeerCertificates[0]
+	for _, uri := range cert.URIs {
+		if _, ok := ids[uri.String()]; ok {
+			return Allow(), nil
+		}
+	}
+	for _, name := range cert.DNSNames {
+		if _, ok := ids[name]; ok {
+			return Allow(), nil
+		}
+	}
+	return Deny(fmt.Sprintf("TLS client identity does not match caller %q", req.Subject.Caller)), nil
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package authz
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+)
+
+func TestVerifyCaller(t *testing.T) {
+	decider := VerifyCaller(map[string][]string{
+		"users":   {"spiffe://example.org/users"},
+		"billing": {"billing.example.org"},
+	})
+
+	withCert := func(cert *x509.Certificate) context.Context {
+		return transport.WithRemotePeer(context.Background(), transport.RemotePeer{
+			TLS: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}},
+		})
+	}
+	usersCert := &x509.Certificate{
+		URIs: []*url.URL{{Scheme: "spiffe", Host: "example.org", Path: "/users"}},
+	}
+	billingCert := &x509.Certificate{DNSNames: []string{"billing.example.org"}}
+
+	tests := []struct {
+		desc       string
+		ctx        context.Context
+		caller     string
+		wantReason string
+	}{
+		{desc: "SPIFFE ID", ctx: withCert(usersCert), caller: "users"},
+		{desc: "DNS name", ctx: withCert(billingCert), caller: "billing"},
+		{
+			desc:       "spoofed caller",
+			ctx:        withCert(usersCert),
+			caller:     "billing",
+			wantReason: `TLS client identity does not match caller "billing"`,
+		},
+		{
+			desc:       "unknown caller",
+			ctx:        withCert(usersCert),
+			caller:     "unknown",
+			wantReason: "caller has no known identities",
+		},
+		{
+			desc:       "no TLS",
+			ctx:        context.Background(),
+			caller:     "users",
+			wantReason: "peer did not present a TLS client certificate",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			decision, err := decider.Decide(tt.ctx, &Request{Subject: Subject{Caller: tt.caller}})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantReason == "", decision.Allowed)
+			assert.Equal(t, tt.wantReason, decision.Reason)
+		})
+	}
+}
+
+func TestVerifyCallerRejectsUnverifiedCertificates(t *testing.T) {
+	ca := newTestCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "ca"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil)
+	usersID := &url.URL{Scheme: "spiffe", Host: "example.org", Path: "/users"}
+	clientTemplate := func() *x509.Certificate {
+		return &x509.Certificate{
+			Subject:     pkix.Name{CommonName: "users"},
+			URIs:        []*url.URL{usersID},
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}
+	}
+	server := newTestCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "server"},
+		DNSNames:    []string{"server.example.org"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+
+	trusted := x509.NewCertPool()
+	trusted.AddCert(ca.Leaf)
+	serverConfig := &tls.Config{
+		Certificates: []tls.Certificate{*server},
+		ClientCAs:    trusted,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	decider := VerifyCaller(map[string][]string{"users": {usersID.String()}})
+
+	t.Run("signed by a trusted CA", func(t *testing.T) {
+		state, err := handshake(serverConfig, trusted, newTestCert(t, clientTemplate(), ca))
+		require.NoError(t, err)
+
+		ctx := transport.WithRemotePeer(context.Background(), transport.RemotePeer{TLS: &state})
+		decision, err := decider.Decide(ctx, &Request{Subject: Subject{Caller: "users"}})
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+	})
+
+	t.Run("self-signed", func(t *testing.T) {
+		// The certificate claims the identity of users, but the handshake
+		// fails before the request can reach the Decider.
+		_, err := handshake(serverConfig, trusted, newTestCert(t, clientTemplate(), nil))
+		assert.Error(t, err)
+	})
+}
+
+// newTestCert builds a certificate from the template, signed by the parent
+// or self-signed if the parent is nil.
+func newTestCert(t *testing.T, template *x509.Certificate, parent *tls.Certificate) *tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template.SerialNumber, err = rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	signer, signerKey := template, interface{}(key)
+	if parent != nil {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+// handshake runs a TLS handshake between a server with the given
+// configuration and a client presenting the given certificate, and returns
+// the connection state seen by the server.
+func handshake(serverConfig *tls.Config, roots *x509.CertPool, clientCert *tls.Certificate) (tls.ConnectionState, error) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer clientConn.Close()
+
+		client := tls.Client(clientConn, &tls.Config{
+			// Present the certificate even if the server does not list its
+			// issuer as acceptable, as a malicious client would.
+			GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				return clientCert, nil
+			},
+			RootCAs:    roots,
+			ServerName: "server.example.org",
+		})
+		if client.Handshake() == nil {
+			// Read until the server closes the connection so that it can
+			// finish the handshake.
+			_, _ = client.Read(make([]byte, 1))
+		}
+	}()
+
+	server := tls.Server(serverConn, serverConfig)
+	err := server.Handshake()
+	state := server.ConnectionState()
+	serverConn.Close()
+	<-done
+	return state, err
+}
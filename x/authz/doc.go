@@ -0,0 +1,53 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package authz authorizes inbound requests against a pluggable policy.
+//
+// The Middleware describes every inbound request as a Request, naming the
+// caller, the peer that sent it, the procedure and the headers, and asks a
+// Decider whether to allow it. Denied requests fail with a PermissionDenied
+// error before they reach their handlers. Deciders may annotate allowed
+// requests, for example with the role of the caller, and handlers read those
+// annotations with AnnotationsFromContext.
+//
+// 	authorize := authz.New(authz.DeciderFunc(func(ctx context.Context, req *authz.Request) (authz.Decision, error) {
+// 		if req.Procedure == "Admin::reset" && req.Caller != "admin-tool" {
+// 			return authz.Deny("only admin-tool may reset"), nil
+// 		}
+// 		return authz.Allow(), nil
+// 	}))
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  authorize,
+// 			Oneway: authorize,
+// 			Stream: authorize,
+// 		},
+// 	})
+//
+// Caller names are reported by callers and are not verified by default.
+// VerifyCaller builds a Decider that checks them against the identities of
+// TLS client certificates, such as SPIFFE IDs, so that clients cannot claim
+// the names of other services. It relies on the inbound to verify the
+// certificate chains of clients during the TLS handshake.
+//
+// The opa subpackage provides a Decider that evaluates policies centrally
+// with Open Policy Agent.
+package authz
@@ -0,0 +1,122 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package authz
+
+import (
+	"context"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+var (
+	_ middleware.UnaryInbound  = (*Middleware)(nil)
+	_ middleware.OnewayInbound = (*Middleware)(nil)
+	_ middleware.StreamInbound = (*Middleware)(nil)
+)
+
+// Middleware is inbound middleware that only handles requests that its
+// Decider allows.
+type Middleware struct {
+	decider Decider
+	opts    options
+}
+
+// New builds a new authorization Middleware.
+func New(decider Decider, opts ...Option) *Middleware {
+	return &Middleware{decider: decider, opts: applyOptions(opts...)}
+}
+
+// Handle authorizes the unary request before handling it.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	ctx, err := m.authorize(ctx, req)
+	if err != nil {
+		return err
+	}
+	return h.Handle(ctx, req, resw)
+}
+
+// HandleOneway authorizes the oneway request before handling it.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	ctx, err := m.authorize(ctx, req)
+	if err != nil {
+		return err
+	}
+	return h.HandleOneway(ctx, req)
+}
+
+// HandleStream authorizes the stream before handling it.
+func (m *Middleware) HandleStream(s *transport.ServerStream, h transport.StreamHandler) error {
+	ctx, err := m.authorize(s.Context(), s.Request().Meta.ToRequest())
+	if err != nil {
+		return err
+	}
+	if ctx != s.Context() {
+		// NewServerStream only fails for nil streams.
+		s, _ = transport.NewServerStream(contextStream{ServerStream: s, ctx: ctx})
+	}
+	return h.HandleStream(s)
+}
+
+// authorize asks the Decider whether the request is allowed, returning a
+// context with the annotations of the decision if it is.
+func (m *Middleware) authorize(ctx context.Context, req *transport.Request) (context.Context, error) {
+	decision, err := m.decider.Decide(ctx, newRequest(ctx, req))
+	if err != nil {
+		m.opts.logger.Warn("failed to authorize request",
+			zap.String("caller", req.Caller),
+			zap.String("procedure", req.Procedure),
+			zap.Error(err))
+		if m.opts.failOpen {
+			return ctx, nil
+		}
+		return ctx, yarpcerrors.UnavailableErrorf("failed to authorize request to procedure %q of service %q", req.Procedure, req.Service)
+	}
+
+	if !decision.Allowed {
+		m.opts.logger.Info("denied request",
+			zap.String("caller", req.Caller),
+			zap.String("procedure", req.Procedure),
+			zap.String("reason", decision.Reason))
+		if decision.Reason == "" {
+			return ctx, yarpcerrors.PermissionDeniedErrorf("caller %q may not call procedure %q of service %q", req.Caller, req.Procedure, req.Service)
+		}
+		return ctx, yarpcerrors.PermissionDeniedErrorf("caller %q may not call procedure %q of service %q: %v", req.Caller, req.Procedure, req.Service, decision.Reason)
+	}
+
+	if len(decision.Annotations) > 0 {
+		ctx = context.WithValue(ctx, annotationsKey{}, decision.Annotations)
+	}
+	return ctx, nil
+}
+
+// contextStream replaces the context of a stream.
+type contextStream struct {
+	*transport.ServerStream
+
+	ctx context.Context
+}
+
+func (s contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package authz
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type unaryHandlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f unaryHandlerFunc) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	return f(ctx, req, resw)
+}
+
+func TestMiddleware(t *testing.T) {
+	decider := DeciderFunc(func(ctx context.Context, req *Request) (Decision, error) {
+		switch req.Procedure {
+		case "allowed":
+			return Decision{Allowed: true, Annotations: map[string]string{"role": "admin"}}, nil
+		case "denied":
+			return Deny("not an admin"), nil
+		default:
+			return Decision{}, errors.New("policy unavailable")
+		}
+	})
+
+	tests := []struct {
+		procedure string
+		opts      []Option
+		wantCode  yarpcerrors.Code
+		wantRole  string
+	}{
+		{procedure: "allowed", wantRole: "admin"},
+		{procedure: "denied", wantCode: yarpcerrors.CodePermissionDenied},
+		{procedure: "broken", wantCode: yarpcerrors.CodeUnavailable},
+		{procedure: "broken", opts: []Option{FailOpen()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.procedure, func(t *testing.T) {
+			var handled bool
+			h := unaryHandlerFunc(func(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+				handled = true
+				assert.Equal(t, tt.wantRole, AnnotationsFromContext(ctx)["role"])
+				return nil
+			})
+
+			err := New(decider, tt.opts...).Handle(context.Background(), &transport.Request{
+				Caller:    "caller",
+				Service:   "service",
+				Procedure: tt.procedure,
+			}, new(transporttest.FakeResponseWriter), h)
+			if tt.wantCode == yarpcerrors.CodeOK {
+				assert.NoError(t, err)
+				assert.True(t, handled, "request should be handled")
+				return
+			}
+			assert.Equal(t, tt.wantCode, yarpcerrors.FromError(err).Code())
+			assert.False(t, handled, "request should not be handled")
+		})
+	}
+}
+
+func TestRequestSubject(t *testing.T) {
+	var got *Request
+	decider := DeciderFunc(func(ctx context.Context, req *Request) (Decision, error) {
+		got = req
+		return Allow(), nil
+	})
+
+	ctx := transport.WithRemotePeer(context.Background(), transport.RemotePeer{
+		Address: "10.0.0.1:1234",
+		TLS: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{
+			URIs: []*url.URL{{Scheme: "spiffe", Host: "example.org", Path: "/caller"}},
+		}}},
+	})
+	h := unaryHandlerFunc(func(context.Context, *transport.Request, transport.ResponseWriter) error { return nil })
+	err := New(decider).Handle(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Procedure: "procedure",
+		Encoding:  "json",
+		Headers:   transport.NewHeaders().With("key", "value"),
+	}, new(transporttest.FakeResponseWriter), h)
+	require.NoError(t, err)
+
+	assert.Equal(t, &Request{
+		Subject: Subject{
+			Caller:  "caller",
+			Address: "10.0.0.1:1234",
+			ID:      "spiffe://example.org/caller",
+		},
+		Service:   "service",
+		Procedure: "procedure",
+		Encoding:  "json",
+		Headers:   map[string]string{"key": "value"},
+	}, got)
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package opa authorizes requests with policies evaluated by Open Policy
+// Agent.
+//
+// The Decider queries a rule through the OPA Data API, usually of an OPA
+// server running next to the service, with the authz.Request as input. The
+// rule may evaluate to a boolean, or to an object with an "allow" boolean, a
+// "reason" string and "annotations" of string values. Undefined rules deny
+// all requests.
+//
+// 	package yarpc.authz
+//
+// 	default allow = false
+//
+// 	allow {
+// 		input.subject.id == "spiffe://example.org/admin-tool"
+// 	}
+//
+// 	allow {
+// 		not startswith(input.procedure, "Admin::")
+// 	}
+//
+// 	decider := opa.New("http://localhost:8181/v1/data/yarpc/authz/allow")
+// 	authorize := authz.New(decider)
+package opa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"go.uber.org/yarpc/x/authz"
+)
+
+// Option customizes the behavior of a Decider.
+type Option interface {
+	apply(*Decider)
+}
+
+type optionFunc func(*Decider)
+
+func (f optionFunc) apply(d *Decider) { f(d) }
+
+// HTTPClient specifies the HTTP client used to query OPA. Defaults to
+// http.DefaultClient.
+func HTTPClient(client *http.Client) Option {
+	return optionFunc(func(d *Decider) {
+		d.client = client
+	})
+}
+
+var _ authz.Decider = (*Decider)(nil)
+
+// Decider is an authz.Decider that queries a rule of Open Policy Agent.
+type Decider struct {
+	url    string
+	client *http.Client
+}
+
+// New builds a Decider that queries the rule at the given Data API URL, for
+// example "http://localhost:8181/v1/data/yarpc/authz/allow".
+func New(url string, opts ...Option) *Decider {
+	d := &Decider{url: url, client: http.DefaultClient}
+	for _, opt := range opts {
+		opt.apply(d)
+	}
+	return d
+}
+
+type query struct {
+	Input *authz.Request `json:"input"`
+}
+
+type result struct {
+	Result json.RawMessage `json:"result"`
+}
+
+type decision struct {
+	Allow       bool              `json:"allow"`
+	Reason      string            `json:"reason"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// Decide evaluates the rule with the request as input.
+func (d *Decider) Decide(ctx context.Context, req *authz.Request) (authz.Decision, error) {
+	body, err := json.Marshal(query{Input: req})
+	if err != nil {
+		return authz.Decision{}, err
+	}
+	hreq, err := http.NewRequest("POST", d.url, bytes.NewReader(body))
+	if err != nil {
+		return authz.Decision{}, err
+	}
+	hreq.Header.Set("Content-Type", "application/json")
+	res, err := d.client.Do(hreq.WithContext(ctx))
+	if err != nil {
+		return authz.Decision{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return authz.Decision{}, fmt.Errorf("opa: %v returned %v", d.url, res.Status)
+	}
+
+	var r result
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return authz.Decision{}, fmt.Errorf("opa: failed to decode response: %v", err)
+	}
+	if len(r.Result) == 0 {
+		return authz.Deny("policy is undefined"), nil
+	}
+
+	var allow bool
+	if err := json.Unmarshal(r.Result, &allow); err == nil {
+		if !allow {
+			return authz.Deny("denied by policy"), nil
+		}
+		return authz.Allow(), nil
+	}
+	var dec decision
+	if err := json.Unmarshal(r.Result, &dec); err != nil {
+		return authz.Decision{}, fmt.Errorf("opa: policy result is neither a boolean nor a decision: %s", r.Result)
+	}
+	if !dec.Allow && dec.Reason == "" {
+		dec.Reason = "denied by policy"
+	}
+	return authz.Decision{Allowed: dec.Allow, Reason: dec.Reason, Annotations: dec.Annotations}, nil
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package opa
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/x/authz"
+)
+
+func TestDecide(t *testing.T) {
+	tests := []struct {
+		desc    string
+		status  int
+		body    string
+		want    authz.Decision
+		wantErr bool
+	}{
+		{
+			desc:   "allowed",
+			status: http.StatusOK,
+			body:   `{"result": true}`,
+			want:   authz.Allow(),
+		},
+		{
+			desc:   "denied",
+			status: http.StatusOK,
+			body:   `{"result": false}`,
+			want:   authz.Deny("denied by policy"),
+		},
+		{
+			desc:   "undefined",
+			status: http.StatusOK,
+			body:   `{}`,
+			want:   authz.Deny("policy is undefined"),
+		},
+		{
+			desc:   "decision",
+			status: http.StatusOK,
+			body:   `{"result": {"allow": true, "annotations": {"role": "admin"}}}`,
+			want:   authz.Decision{Allowed: true, Annotations: map[string]string{"role": "admin"}},
+		},
+		{
+			desc:   "denied with reason",
+			status: http.StatusOK,
+			body:   `{"result": {"allow": false, "reason": "not an admin"}}`,
+			want:   authz.Deny("not an admin"),
+		},
+		{
+			desc:    "unexpected result",
+			status:  http.StatusOK,
+			body:    `{"result": "yes"}`,
+			wantErr: true,
+		},
+		{
+			desc:    "server error",
+			status:  http.StatusInternalServerError,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/v1/data/yarpc/authz/allow", r.URL.Path)
+				var q struct {
+					Input authz.Request `json:"input"`
+				}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&q))
+				assert.Equal(t, "caller", q.Input.Subject.Caller)
+				assert.Equal(t, "Admin::reset", q.Input.Procedure)
+
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			d := New(server.URL+"/v1/data/yarpc/authz/allow", HTTPClient(server.Client()))
+			got, err := d.Decide(context.Background(), &authz.Request{
+				Subject:   authz.Subject{Caller: "caller"},
+				Service:   "service",
+				Procedure: "Admin::reset",
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
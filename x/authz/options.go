@@ -0,0 +1,60 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package authz
+
+import "go.uber.org/zap"
+
+// Option customizes the behavior of a Middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	failOpen bool
+	logger   *zap.Logger
+}
+
+// FailOpen specifies that requests are allowed when the Decider fails. By
+// default, requests fail with an Unavailable error when the Decider fails.
+func FailOpen() Option {
+	return optionFunc(func(opts *options) {
+		opts.failOpen = true
+	})
+}
+
+// Logger specifies a logger for denied requests and Decider failures.
+func Logger(logger *zap.Logger) Option {
+	return optionFunc(func(opts *options) {
+		opts.logger = logger
+	})
+}
+
+func applyOptions(opts ...Option) options {
+	options := options{logger: zap.NewNop()}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return options
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package batch
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// _batchHeader marks a request as a batch. Its value is the number of calls
+// in the batch.
+const _batchHeader = "yarpc-batch"
+
+// batchBody is the body of a batched request.
+type batchBody struct {
+	Items []item `json:"items"`
+}
+
+// item is a single call within a batch.
+type item struct {
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    []byte            `json:"body"`
+}
+
+func encodeBatch(items []item) ([]byte, error) {
+	return json.Marshal(batchBody{Items: items})
+}
+
+func decodeBatch(size string, r io.Reader) ([]item, error) {
+	n, err := strconv.Atoi(size)
+	if err != nil {
+		return nil, yarpcerrors.InvalidArgumentErrorf("invalid batch size %q", size)
+	}
+	var body batchBody
+	if err := json.NewDecoder(r).Decode(&body); err != nil {
+		return nil, yarpcerrors.InvalidArgumentErrorf("failed to decode batch: %v", err)
+	}
+	if len(body.Items) != n {
+		return nil, yarpcerrors.InvalidArgumentErrorf("batch has %d items, expected %d", len(body.Items), n)
+	}
+	return body.Items, nil
+}
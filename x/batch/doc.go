@@ -0,0 +1,47 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package batch combines multiple oneway calls into a single transport-level
+// request to cut per-call overhead for high-volume, telemetry-style
+// procedures.
+//
+// On the client, wrap a oneway outbound with NewOutbound. Calls to the same
+// procedure are collected until the batch is full or the oldest call has
+// waited for the maximum latency, and are then sent together.
+//
+// 	outbound := batch.NewOutbound(
+// 		http.NewTransport().NewSingleOutbound(url),
+// 		batch.MaxBatchSize(100),
+// 		batch.MaxLatency(10*time.Millisecond),
+// 	)
+//
+// On the server, add the Unbatcher as oneway inbound middleware. It splits
+// batched requests back into individual requests before they reach the
+// handler. Requests that are not batched pass through unchanged.
+//
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Oneway: batch.Unbatcher,
+// 		},
+// 	})
+//
+// Servers must install the Unbatcher before clients start batching.
+package batch
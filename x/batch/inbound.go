@@ -0,0 +1,60 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package batch
+
+import (
+	"bytes"
+	"context"
+
+	"go.uber.org/multierr"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+)
+
+// Unbatcher is oneway inbound middleware that splits batched requests into
+// the individual requests they contain and calls the handler once for each.
+//
+// The handler is called sequentially for every request in the batch, in the
+// order in which the requests were made. Errors returned by the handler are
+// combined and returned once the whole batch has been handled.
+var Unbatcher middleware.OnewayInbound = unbatcher{}
+
+type unbatcher struct{}
+
+func (unbatcher) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	size, ok := req.Headers.Get(_batchHeader)
+	if !ok {
+		return h.HandleOneway(ctx, req)
+	}
+	items, err := decodeBatch(size, req.Body)
+	if err != nil {
+		return err
+	}
+
+	var errs error
+	for _, it := range items {
+		r := *req
+		r.Headers = transport.HeadersFromMap(it.Headers)
+		r.Body = bytes.NewReader(it.Body)
+		errs = multierr.Append(errs, h.HandleOneway(ctx, &r))
+	}
+	return errs
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package batch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+func bytesReader(s string) io.Reader {
+	return bytes.NewReader([]byte(s))
+}
+
+func TestUnbatcherPassesThroughRegularRequests(t *testing.T) {
+	var got []string
+	err := Unbatcher.HandleOneway(context.Background(), newRequest("proc", "a"),
+		onewayHandlerFunc(func(ctx context.Context, req *transport.Request) error {
+			body, err := ioutil.ReadAll(req.Body)
+			require.NoError(t, err)
+			got = append(got, string(body))
+			return nil
+		}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, got)
+}
+
+func TestUnbatcherCombinesErrors(t *testing.T) {
+	body, err := encodeBatch([]item{{Body: []byte("a")}, {Body: []byte("b")}, {Body: []byte("c")}})
+	require.NoError(t, err)
+
+	req := newRequest("proc", "")
+	req.Headers = transport.NewHeaders().With(_batchHeader, "3")
+	req.Body = bytes.NewReader(body)
+
+	var calls int
+	err = Unbatcher.HandleOneway(context.Background(), req,
+		onewayHandlerFunc(func(ctx context.Context, req *transport.Request) error {
+			calls++
+			body, err := ioutil.ReadAll(req.Body)
+			require.NoError(t, err)
+			if string(body) == "b" {
+				return nil
+			}
+			return errors.New("failed " + string(body))
+		}))
+	assert.Equal(t, 3, calls, "every request in the batch must be handled")
+	assert.EqualError(t, err, "failed a; failed c")
+}
+
+func TestUnbatcherInvalidBatch(t *testing.T) {
+	valid, err := encodeBatch([]item{{Body: []byte("a")}, {Body: []byte("b")}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		desc    string
+		size    string
+		body    []byte
+		wantErr string
+	}{
+		{
+			desc:    "invalid size",
+			size:    "two",
+			body:    valid,
+			wantErr: `invalid batch size "two"`,
+		},
+		{
+			desc:    "invalid body",
+			size:    "2",
+			body:    []byte("not json"),
+			wantErr: "failed to decode batch",
+		},
+		{
+			desc:    "size mismatch",
+			size:    "3",
+			body:    valid,
+			wantErr: "batch has 2 items, expected 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := newRequest("proc", "")
+			req.Headers = transport.NewHeaders().With(_batchHeader, tt.size)
+			req.Body = bytes.NewReader(tt.body)
+
+			err := Unbatcher.HandleOneway(context.Background(), req,
+				onewayHandlerFunc(func(ctx context.Context, req *transport.Request) error {
+					t.Fatal("handler must not be called")
+					return nil
+				}))
+			require.Error(t, err)
+			assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.FromError(err).Code())
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package batch
+
+import (
+	"time"
+
+	"go.uber.org/yarpc/internal/clock"
+)
+
+const (
+	_defaultMaxBatchSize = 100
+	_defaultMaxLatency   = 10 * time.Millisecond
+	_defaultTimeout      = 5 * time.Second
+)
+
+// Option customizes the behavior of a batching Outbound.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	maxBatchSize  int
+	maxBatchBytes int
+	maxLatency    time.Duration
+	timeout       time.Duration
+	clock         clock.Clock
+}
+
+// MaxBatchSize specifies the maximum number of calls in a batch. A batch is
+// sent as soon as it reaches this size.
+//
+// Defaults to 100.
+func MaxBatchSize(size int) Option {
+	return optionFunc(func(opts *options) {
+		opts.maxBatchSize = size
+	})
+}
+
+// MaxBatchBytes specifies the total size of request bodies at which a batch
+// is sent, even if it has not reached MaxBatchSize.
+//
+// Defaults to 0, which does not limit batches by size in bytes.
+func MaxBatchBytes(bytes int) Option {
+	return optionFunc(func(opts *options) {
+		opts.maxBatchBytes = bytes
+	})
+}
+
+// MaxLatency specifies the maximum amount of time a call waits for its batch
+// to fill up before the batch is sent anyway.
+//
+// Defaults to 10 milliseconds.
+func MaxLatency(latency time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.maxLatency = latency
+	})
+}
+
+// Timeout specifies the timeout for sending a batch through the wrapped
+// outbound. Defaults to five seconds.
+func Timeout(timeout time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.timeout = timeout
+	})
+}
+
+// withClock specifies the clock used to schedule flushes.
+// It is only used for testing.
+func withClock(clock clock.Clock) Option {
+	return optionFunc(func(opts *options) {
+		opts.clock = clock
+	})
+}
+
+func applyOptions(opts ...Option) options {
+	options := options{
+		maxBatchSize: _defaultMaxBatchSize,
+		maxLatency:   _defaultMaxLatency,
+		timeout:      _defaultTimeout,
+		clock:        clock.NewReal(),
+	}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return options
+}
@@ -0,0 +1,289 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package batch
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strconv"
+	"sync"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var _ transport.OnewayOutbound = (*Outbound)(nil)
+
+// Outbound is a oneway outbound that batches calls to the same procedure and
+// sends each batch as a single request through another oneway outbound.
+//
+// Calls block until their batch has been sent and return the result of
+// sending the batch.
+type Outbound struct {
+	once *lifecycle.Once
+	out  transport.OnewayOutbound
+	opts options
+
+	mu       sync.Mutex
+	pending  map[batchKey]*batch
+	stopping bool
+	sending  sync.WaitGroup
+}
+
+// batchKey identifies the calls that may share a batch.
+type batchKey struct {
+	caller          string
+	service         string
+	procedure       string
+	encoding        transport.Encoding
+	shardKey        string
+	routingKey      string
+	routingDelegate string
+}
+
+// batch is a set of calls waiting to be sent together.
+type batch struct {
+	key   batchKey
+	items []*item
+	bytes int
+	timer clock.Timer
+
+	// done is closed after the batch has been sent, at which point ack and
+	// err hold the result.
+	done chan struct{}
+	ack  transport.Ack
+	err  error
+}
+
+// NewOutbound builds a new batching Outbound which sends batches through the
+// given outbound.
+//
+// The returned Outbound manages the lifecycle of the wrapped outbound.
+func NewOutbound(out transport.OnewayOutbound, opts ...Option) *Outbound {
+	return &Outbound{
+		once:    lifecycle.NewOnce(),
+		out:     out,
+		opts:    applyOptions(opts...),
+		pending: make(map[batchKey]*batch),
+	}
+}
+
+// Transports returns the transports used by the wrapped outbound.
+func (o *Outbound) Transports() []transport.Transport {
+	return o.out.Transports()
+}
+
+// Start starts the wrapped outbound.
+func (o *Outbound) Start() error {
+	return o.once.Start(o.out.Start)
+}
+
+// Stop sends all pending batches and stops the wrapped outbound.
+func (o *Outbound) Stop() error {
+	return o.once.Stop(o.doStop)
+}
+
+func (o *Outbound) doStop() error {
+	o.mu.Lock()
+	o.stopping = true
+	batches := make([]*batch, 0, len(o.pending))
+	for _, b := range o.pending {
+		o.detach(b)
+		batches = append(batches, b)
+	}
+	o.mu.Unlock()
+
+	for _, b := range batches {
+		o.send(b)
+	}
+	o.sending.Wait()
+	return o.out.Stop()
+}
+
+// IsRunning returns whether the outbound is running.
+func (o *Outbound) IsRunning() bool {
+	return o.once.IsRunning()
+}
+
+// CallOneway adds the request to a batch and blocks until that batch has
+// been sent or the context finishes. Calls whose context finishes first are
+// removed from their batch, unless it is already being sent.
+func (o *Outbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	if err := o.once.WaitUntilRunning(ctx); err != nil {
+		return nil, err
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+	}
+	it := item{Body: body}
+	if req.Headers.Len() > 0 {
+		it.Headers = req.Headers.Items()
+	}
+
+	key := batchKey{
+		caller:          req.Caller,
+		service:         req.Service,
+		procedure:       req.Procedure,
+		encoding:        req.Encoding,
+		shardKey:        req.ShardKey,
+		routingKey:      req.RoutingKey,
+		routingDelegate: req.RoutingDelegate,
+	}
+
+	b, added, full, err := o.add(key, it)
+	if err != nil {
+		return nil, err
+	}
+	if full {
+		o.send(b)
+	}
+
+	select {
+	case <-b.done:
+		return b.ack, b.err
+	case <-ctx.Done():
+		o.remove(b, added)
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, yarpcerrors.DeadlineExceededErrorf(
+				"timed out waiting for the batch of a call to %q to be sent", req.Procedure)
+		}
+		return nil, yarpcerrors.CancelledErrorf(
+			"call to %q canceled while waiting for its batch to be sent", req.Procedure)
+	}
+}
+
+// add adds the call to the pending batch for its key, starting a new batch
+// if necessary, and reports whether the batch is full, in which case it is
+// detached and the caller must send it. Calls fail once the outbound is
+// stopping, since their batch would be sent after the wrapped outbound stops.
+func (o *Outbound) add(key batchKey, it item) (_ *batch, _ *item, full bool, _ error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.stopping {
+		return nil, nil, false, yarpcerrors.FailedPreconditionErrorf("batch outbound is stopping")
+	}
+	b, ok := o.pending[key]
+	if !ok {
+		b = &batch{key: key, done: make(chan struct{})}
+		o.pending[key] = b
+		b.timer = o.opts.clock.AfterFunc(o.opts.maxLatency, func() { o.flush(b) })
+	}
+	added := &it
+	b.items = append(b.items, added)
+	b.bytes += len(it.Body)
+	full = len(b.items) >= o.opts.maxBatchSize ||
+		(o.opts.maxBatchBytes > 0 && b.bytes >= o.opts.maxBatchBytes)
+	if full {
+		o.detach(b)
+	}
+	return b, added, full, nil
+}
+
+// remove removes a call from the given batch if the batch is still pending,
+// discarding the batch if it was the only call.
+func (o *Outbound) remove(b *batch, it *item) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.pending[b.key] != b {
+		// The batch is being sent already.
+		return
+	}
+	for i, other := range b.items {
+		if other == it {
+			b.items = append(b.items[:i], b.items[i+1:]...)
+			b.bytes -= len(it.Body)
+			break
+		}
+	}
+	if len(b.items) == 0 {
+		delete(o.pending, b.key)
+		b.timer.Stop()
+	}
+}
+
+// flush sends the given batch if it is still pending.
+func (o *Outbound) flush(b *batch) {
+	o.mu.Lock()
+	if o.pending[b.key] != b {
+		// The batch filled up and was sent already.
+		o.mu.Unlock()
+		return
+	}
+	o.detach(b)
+	o.mu.Unlock()
+
+	o.send(b)
+}
+
+// detach removes the batch from the pending batches so that no more calls
+// are added to it. The caller must hold the lock and must call send
+// afterwards.
+func (o *Outbound) detach(b *batch) {
+	delete(o.pending, b.key)
+	b.timer.Stop()
+	o.sending.Add(1)
+}
+
+func (o *Outbound) send(b *batch) {
+	defer o.sending.Done()
+	defer close(b.done)
+
+	req := &transport.Request{
+		Caller:          b.key.caller,
+		Service:         b.key.service,
+		Procedure:       b.key.procedure,
+		Encoding:        b.key.encoding,
+		ShardKey:        b.key.shardKey,
+		RoutingKey:      b.key.routingKey,
+		RoutingDelegate: b.key.routingDelegate,
+	}
+	if len(b.items) == 1 {
+		// A batch of one is sent as a regular request.
+		req.Headers = transport.HeadersFromMap(b.items[0].Headers)
+		req.Body = bytes.NewReader(b.items[0].Body)
+	} else {
+		items := make([]item, len(b.items))
+		for i, it := range b.items {
+			items[i] = *it
+		}
+		body, err := encodeBatch(items)
+		if err != nil {
+			b.err = err
+			return
+		}
+		req.Headers = transport.NewHeaders().With(_batchHeader, strconv.Itoa(len(b.items)))
+		req.Body = bytes.NewReader(body)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), o.opts.timeout)
+	defer cancel()
+	b.ack, b.err = o.out.CallOneway(ctx, req)
+}
@@ -0,0 +1,346 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package batch
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/internal/testtime"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type call struct {
+	procedure string
+	headers   map[string]string
+	body      string
+}
+
+// fakeOutbound records the requests it receives and passes them through the
+// Unbatcher to record the individual calls.
+type fakeOutbound struct {
+	transport.Outbound
+
+	mu       sync.Mutex
+	err      error
+	requests []*transport.Request
+	calls    []call
+}
+
+func (o *fakeOutbound) Start() error                      { return nil }
+func (o *fakeOutbound) Stop() error                       { return nil }
+func (o *fakeOutbound) Transports() []transport.Transport { return nil }
+
+func (o *fakeOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.err != nil {
+		return nil, o.err
+	}
+	o.requests = append(o.requests, req)
+	return nil, Unbatcher.HandleOneway(ctx, req, onewayHandlerFunc(func(ctx context.Context, req *transport.Request) error {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return err
+		}
+		o.calls = append(o.calls, call{procedure: req.Procedure, headers: req.Headers.Items(), body: string(body)})
+		return nil
+	}))
+}
+
+func (o *fakeOutbound) Requests() []*transport.Request {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]*transport.Request(nil), o.requests...)
+}
+
+func (o *fakeOutbound) Calls() []call {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]call(nil), o.calls...)
+}
+
+type onewayHandlerFunc func(context.Context, *transport.Request) error
+
+func (f onewayHandlerFunc) HandleOneway(ctx context.Context, req *transport.Request) error {
+	return f(ctx, req)
+}
+
+func newRequest(procedure, body string) *transport.Request {
+	return &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Procedure: procedure,
+		Encoding:  "raw",
+		Headers:   transport.NewHeaders().With("body", body),
+		Body:      bytesReader(body),
+	}
+}
+
+// callAsync makes a call in the background and returns a channel that
+// receives its result.
+func callAsync(o *Outbound, req *transport.Request) <-chan error {
+	errc := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+		defer cancel()
+		_, err := o.CallOneway(ctx, req)
+		errc <- err
+	}()
+	return errc
+}
+
+func waitForResult(t *testing.T, errc <-chan error) error {
+	select {
+	case err := <-errc:
+		return err
+	case <-time.After(testtime.Second):
+		t.Fatal("timed out waiting for the call to return")
+		return nil
+	}
+}
+
+// waitForPending waits until the outbound has n calls waiting to be sent.
+func waitForPending(t *testing.T, o *Outbound, n int) {
+	deadline := time.Now().Add(testtime.Second)
+	for time.Now().Before(deadline) {
+		o.mu.Lock()
+		pending := 0
+		for _, b := range o.pending {
+			pending += len(b.items)
+		}
+		o.mu.Unlock()
+		if pending == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d pending calls", n)
+}
+
+func startOutbound(t *testing.T, out transport.OnewayOutbound, opts ...Option) *Outbound {
+	o := NewOutbound(out, opts...)
+	require.NoError(t, o.Start())
+	return o
+}
+
+func TestBatchBySize(t *testing.T) {
+	out := &fakeOutbound{}
+	o := startOutbound(t, out, MaxBatchSize(3), MaxLatency(time.Hour), withClock(clock.NewFake()))
+	defer o.Stop()
+
+	var results []<-chan error
+	for _, body := range []string{"a", "b", "c"} {
+		results = append(results, callAsync(o, newRequest("proc", body)))
+	}
+	for _, errc := range results {
+		assert.NoError(t, waitForResult(t, errc))
+	}
+
+	requests := out.Requests()
+	require.Len(t, requests, 1)
+	size, ok := requests[0].Headers.Get(_batchHeader)
+	assert.True(t, ok)
+	assert.Equal(t, "3", size)
+	assert.Equal(t, "proc", requests[0].Procedure)
+
+	assert.ElementsMatch(t, []call{
+		{procedure: "proc", headers: map[string]string{"body": "a"}, body: "a"},
+		{procedure: "proc", headers: map[string]string{"body": "b"}, body: "b"},
+		{procedure: "proc", headers: map[string]string{"body": "c"}, body: "c"},
+	}, out.Calls())
+}
+
+func TestBatchByBytes(t *testing.T) {
+	out := &fakeOutbound{}
+	o := startOutbound(t, out, MaxBatchBytes(4), MaxLatency(time.Hour), withClock(clock.NewFake()))
+	defer o.Stop()
+
+	first := callAsync(o, newRequest("proc", "ab"))
+	waitForPending(t, o, 1)
+	second := callAsync(o, newRequest("proc", "cd"))
+
+	assert.NoError(t, waitForResult(t, first))
+	assert.NoError(t, waitForResult(t, second))
+	assert.Len(t, out.Requests(), 1)
+	assert.Len(t, out.Calls(), 2)
+}
+
+func TestBatchByLatency(t *testing.T) {
+	out := &fakeOutbound{}
+	clk := clock.NewFake()
+	o := startOutbound(t, out, MaxLatency(time.Second), withClock(clk))
+	defer o.Stop()
+
+	first := callAsync(o, newRequest("proc", "a"))
+	second := callAsync(o, newRequest("proc", "b"))
+	waitForPending(t, o, 2)
+	assert.Empty(t, out.Requests(), "batch must not be sent before the latency elapses")
+
+	clk.Add(time.Second)
+	assert.NoError(t, waitForResult(t, first))
+	assert.NoError(t, waitForResult(t, second))
+	assert.Len(t, out.Requests(), 1)
+	assert.Len(t, out.Calls(), 2)
+}
+
+func TestSingleCallSentUnbatched(t *testing.T) {
+	out := &fakeOutbound{}
+	clk := clock.NewFake()
+	o := startOutbound(t, out, withClock(clk))
+	defer o.Stop()
+
+	errc := callAsync(o, newRequest("proc", "a"))
+	waitForPending(t, o, 1)
+	clk.Add(_defaultMaxLatency)
+	assert.NoError(t, waitForResult(t, errc))
+
+	requests := out.Requests()
+	require.Len(t, requests, 1)
+	_, ok := requests[0].Headers.Get(_batchHeader)
+	assert.False(t, ok, "a batch of one must be sent as a regular request")
+	assert.Equal(t, []call{
+		{procedure: "proc", headers: map[string]string{"body": "a"}, body: "a"},
+	}, out.Calls())
+}
+
+func TestBatchesPerProcedure(t *testing.T) {
+	out := &fakeOutbound{}
+	o := startOutbound(t, out, MaxBatchSize(2), MaxLatency(time.Hour), withClock(clock.NewFake()))
+	defer o.Stop()
+
+	results := []<-chan error{
+		callAsync(o, newRequest("foo", "a")),
+		callAsync(o, newRequest("bar", "b")),
+		callAsync(o, newRequest("foo", "c")),
+		callAsync(o, newRequest("bar", "d")),
+	}
+	for _, errc := range results {
+		assert.NoError(t, waitForResult(t, errc))
+	}
+
+	requests := out.Requests()
+	require.Len(t, requests, 2)
+	assert.ElementsMatch(t, []string{"foo", "bar"}, []string{requests[0].Procedure, requests[1].Procedure})
+	for _, c := range out.Calls() {
+		switch c.body {
+		case "a", "c":
+			assert.Equal(t, "foo", c.procedure)
+		default:
+			assert.Equal(t, "bar", c.procedure)
+		}
+	}
+}
+
+func TestBatchError(t *testing.T) {
+	out := &fakeOutbound{err: errors.New("great sadness")}
+	o := startOutbound(t, out, MaxBatchSize(2), MaxLatency(time.Hour), withClock(clock.NewFake()))
+	defer o.Stop()
+
+	first := callAsync(o, newRequest("proc", "a"))
+	second := callAsync(o, newRequest("proc", "b"))
+	assert.EqualError(t, waitForResult(t, first), "great sadness")
+	assert.EqualError(t, waitForResult(t, second), "great sadness")
+}
+
+func TestCallContextDone(t *testing.T) {
+	out := &fakeOutbound{}
+	o := startOutbound(t, out, MaxLatency(time.Hour), withClock(clock.NewFake()))
+	defer o.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := o.CallOneway(ctx, newRequest("proc", "a"))
+	assert.Equal(t, yarpcerrors.CodeCancelled, yarpcerrors.FromError(err).Code())
+	waitForPending(t, o, 0)
+}
+
+func TestCallTimeoutRemovesCallFromBatch(t *testing.T) {
+	out := &fakeOutbound{}
+	o := startOutbound(t, out, MaxLatency(time.Hour), withClock(clock.NewFake()))
+
+	first := callAsync(o, newRequest("proc", "a"))
+	waitForPending(t, o, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := o.CallOneway(ctx, newRequest("proc", "b"))
+	assert.Equal(t, yarpcerrors.CodeDeadlineExceeded, yarpcerrors.FromError(err).Code())
+	waitForPending(t, o, 1)
+
+	require.NoError(t, o.Stop())
+	assert.NoError(t, waitForResult(t, first))
+	assert.Equal(t, []call{{procedure: "proc", headers: map[string]string{"body": "a"}, body: "a"}}, out.Calls(),
+		"calls that timed out must not be sent")
+}
+
+func TestStopSendsPendingBatches(t *testing.T) {
+	out := &fakeOutbound{}
+	o := startOutbound(t, out, MaxLatency(time.Hour), withClock(clock.NewFake()))
+
+	first := callAsync(o, newRequest("proc", "a"))
+	second := callAsync(o, newRequest("proc", "b"))
+	waitForPending(t, o, 2)
+
+	require.NoError(t, o.Stop())
+	assert.NoError(t, waitForResult(t, first))
+	assert.NoError(t, waitForResult(t, second))
+	assert.Len(t, out.Requests(), 1)
+	assert.Len(t, out.Calls(), 2)
+}
+
+func TestCallBeforeStart(t *testing.T) {
+	o := NewOutbound(&fakeOutbound{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := o.CallOneway(ctx, newRequest("proc", "a"))
+	assert.Error(t, err)
+	assert.False(t, o.IsRunning())
+}
+
+func TestCallAfterStop(t *testing.T) {
+	out := &fakeOutbound{}
+	clk := clock.NewFake()
+	o := startOutbound(t, out, MaxLatency(time.Millisecond), withClock(clk))
+	require.NoError(t, o.Stop())
+
+	ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+	defer cancel()
+	_, err := o.CallOneway(ctx, newRequest("proc", "a"))
+	assert.Error(t, err)
+
+	// Calls that got past the lifecycle check before Stop began must not
+	// start a batch either.
+	_, _, _, err = o.add(batchKey{procedure: "proc"}, item{Body: []byte("b")})
+	assert.Equal(t, yarpcerrors.CodeFailedPrecondition, yarpcerrors.FromError(err).Code())
+	assert.Empty(t, o.pending)
+
+	clk.Add(time.Second)
+	assert.Empty(t, out.Requests())
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package bodylimit provides inbound middleware that limits the size of
+// request bodies per procedure.
+//
+// Requests whose bodies are larger than the limit for their procedure fail
+// with a ResourceExhausted error. Bodies whose size is known ahead of time,
+// like HTTP requests with a Content-Length, are rejected before the handler
+// is called; other bodies fail as soon as the handler reads past the limit.
+//
+// 	limits := bodylimit.New(
+// 		bodylimit.Default(1024*1024),
+// 		bodylimit.Procedure("Store::upload", 64*1024*1024),
+// 	)
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  limits,
+// 			Oneway: limits,
+// 		},
+// 	})
+//
+// Limits that apply to all procedures of an inbound are better configured on
+// the transport, for example with the MaxRequestBodySize options of the HTTP
+// and TChannel transports, or ServerMaxRecvMsgSize for gRPC.
+package bodylimit
@@ -0,0 +1,104 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bodylimit
+
+import (
+	"context"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	intbodylimit "go.uber.org/yarpc/internal/bodylimit"
+)
+
+var (
+	_ middleware.UnaryInbound  = (*Middleware)(nil)
+	_ middleware.OnewayInbound = (*Middleware)(nil)
+)
+
+// Option customizes the limits enforced by a Middleware.
+type Option interface {
+	apply(*Middleware)
+}
+
+type optionFunc func(*Middleware)
+
+func (f optionFunc) apply(m *Middleware) { f(m) }
+
+// Default limits the size of request bodies for procedures that do not have
+// a limit of their own. Bodies are unlimited by default.
+func Default(bytes int64) Option {
+	return optionFunc(func(m *Middleware) {
+		m.defaultLimit = bytes
+	})
+}
+
+// Procedure limits the size of request bodies for the procedure with the
+// given name, overriding the default limit. A limit of zero or less leaves
+// the procedure unlimited.
+func Procedure(procedure string, bytes int64) Option {
+	return optionFunc(func(m *Middleware) {
+		m.procedures[procedure] = bytes
+	})
+}
+
+// Middleware is inbound middleware that limits the size of request bodies.
+type Middleware struct {
+	defaultLimit int64
+	procedures   map[string]int64
+}
+
+// New builds a new Middleware with the given limits.
+func New(opts ...Option) *Middleware {
+	m := &Middleware{procedures: make(map[string]int64)}
+	for _, opt := range opts {
+		opt.apply(m)
+	}
+	return m
+}
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	if err := m.limit(req); err != nil {
+		return err
+	}
+	return h.Handle(ctx, req, resw)
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	if err := m.limit(req); err != nil {
+		return err
+	}
+	return h.HandleOneway(ctx, req)
+}
+
+func (m *Middleware) limit(req *transport.Request) error {
+	limit, ok := m.procedures[req.Procedure]
+	if !ok {
+		limit = m.defaultLimit
+	}
+	body, err := intbodylimit.Limit(req.Body, limit)
+	if err != nil {
+		return err
+	}
+	req.Body = body
+	return nil
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bodylimit
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type readingHandler struct {
+	called bool
+	body   []byte
+}
+
+func (h *readingHandler) Handle(_ context.Context, req *transport.Request, _ transport.ResponseWriter) error {
+	return h.read(req)
+}
+
+func (h *readingHandler) HandleOneway(_ context.Context, req *transport.Request) error {
+	return h.read(req)
+}
+
+func (h *readingHandler) read(req *transport.Request) (err error) {
+	h.called = true
+	h.body, err = ioutil.ReadAll(req.Body)
+	return err
+}
+
+func TestMiddleware(t *testing.T) {
+	m := New(
+		Default(5),
+		Procedure("upload", 10),
+		Procedure("unlimited", 0),
+	)
+
+	tests := []struct {
+		desc       string
+		procedure  string
+		body       string
+		sized      bool
+		wantErr    bool
+		wantCalled bool
+	}{
+		{desc: "default under limit", procedure: "echo", body: "hello", wantCalled: true},
+		{desc: "default over limit", procedure: "echo", body: "hello world", wantErr: true, wantCalled: true},
+		{desc: "default over limit sized", procedure: "echo", body: "hello world", sized: true, wantErr: true},
+		{desc: "procedure under limit", procedure: "upload", body: "hello worl", wantCalled: true},
+		{desc: "procedure over limit sized", procedure: "upload", body: "hello world", sized: true, wantErr: true},
+		{desc: "unlimited procedure", procedure: "unlimited", body: strings.Repeat("a", 100), wantCalled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			newRequest := func() *transport.Request {
+				req := &transport.Request{Procedure: tt.procedure, Body: ioutil.NopCloser(strings.NewReader(tt.body))}
+				if tt.sized {
+					req.Body = bytes.NewReader([]byte(tt.body))
+				}
+				return req
+			}
+
+			checkErr := func(h *readingHandler, err error) {
+				assert.Equal(t, tt.wantCalled, h.called, "handler called")
+				if tt.wantErr {
+					require.Error(t, err)
+					assert.Equal(t, yarpcerrors.CodeResourceExhausted, yarpcerrors.FromError(err).Code())
+					return
+				}
+				require.NoError(t, err)
+				assert.Equal(t, tt.body, string(h.body))
+			}
+
+			var unary readingHandler
+			checkErr(&unary, m.Handle(context.Background(), newRequest(), new(transporttest.FakeResponseWriter), &unary))
+
+			var oneway readingHandler
+			checkErr(&oneway, m.HandleOneway(context.Background(), newRequest(), &oneway))
+		})
+	}
+}
+
+func TestMiddlewareNoLimits(t *testing.T) {
+	var h readingHandler
+	body := strings.Repeat("a", 1024)
+	err := New().Handle(context.Background(), &transport.Request{Body: strings.NewReader(body)}, new(transporttest.FakeResponseWriter), &h)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(h.body))
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package callgraph records the calls a process handles and makes, and
+// exports them as a live dependency map.
+//
+// A Graph is inbound and outbound middleware that aggregates the calls it
+// observes into edges between callers and services, keyed by procedure and
+// transport.
+//
+// 	graph := callgraph.New(
+// 		callgraph.Logger(logger),
+// 		callgraph.LogInterval(time.Minute),
+// 	)
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  graph,
+// 			Oneway: graph,
+// 			Stream: graph,
+// 		},
+// 		OutboundMiddleware: yarpc.OutboundMiddleware{
+// 			Unary:  graph,
+// 			Oneway: graph,
+// 			Stream: graph,
+// 		},
+// 	})
+// 	http.Handle("/debug/yarpc/callgraph", graph.Handler())
+//
+// The handler serves the edges as JSON, or as a Graphviz digraph if the
+// request has the query parameter "format=dot". If a log interval is given,
+// the Graph also logs its edges periodically while it is running; start and
+// stop it with the dispatcher.
+package callgraph
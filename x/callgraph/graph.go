@@ -0,0 +1,290 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package callgraph
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/zap"
+)
+
+// Direction is the direction of a call relative to the process.
+type Direction string
+
+const (
+	// Inbound calls are handled by the process.
+	Inbound Direction = "inbound"
+	// Outbound calls are made by the process.
+	Outbound Direction = "outbound"
+)
+
+var (
+	_ middleware.UnaryInbound   = (*Graph)(nil)
+	_ middleware.OnewayInbound  = (*Graph)(nil)
+	_ middleware.StreamInbound  = (*Graph)(nil)
+	_ middleware.UnaryOutbound  = (*Graph)(nil)
+	_ middleware.OnewayOutbound = (*Graph)(nil)
+	_ middleware.StreamOutbound = (*Graph)(nil)
+	_ transport.Lifecycle       = (*Graph)(nil)
+)
+
+// Edge is a kind of call between a caller and a service.
+type Edge struct {
+	Direction Direction `json:"direction"`
+	Caller    string    `json:"caller"`
+	Service   string    `json:"service"`
+	Procedure string    `json:"procedure"`
+	Transport string    `json:"transport"`
+}
+
+// EdgeStats is an Edge with the number of calls observed along it.
+type EdgeStats struct {
+	Edge
+
+	Calls  int64 `json:"calls"`
+	Errors int64 `json:"errors"`
+}
+
+type counters struct {
+	calls  atomic.Int64
+	errors atomic.Int64
+}
+
+// Graph is middleware that aggregates the calls it observes into edges.
+type Graph struct {
+	opts options
+	once *lifecycle.Once
+
+	lock  sync.RWMutex
+	edges map[Edge]*counters
+
+	// transports caches the transport names of outbounds.
+	transportsLock sync.RWMutex
+	transports     map[interface{}]string
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New builds a new Graph.
+func New(opts ...Option) *Graph {
+	options := options{logger: zap.NewNop()}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return &Graph{
+		opts:       options,
+		once:       lifecycle.NewOnce(),
+		edges:      make(map[Edge]*counters),
+		transports: make(map[interface{}]string),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Edges returns the edges observed so far, sorted by direction, caller,
+// service, procedure, and transport.
+func (g *Graph) Edges() []EdgeStats {
+	g.lock.RLock()
+	edges := make([]EdgeStats, 0, len(g.edges))
+	for edge, c := range g.edges {
+		edges = append(edges, EdgeStats{
+			Edge:   edge,
+			Calls:  c.calls.Load(),
+			Errors: c.errors.Load(),
+		})
+	}
+	g.lock.RUnlock()
+
+	sort.Slice(edges, func(i, j int) bool {
+		return edges[i].less(edges[j].Edge)
+	})
+	return edges
+}
+
+func (e Edge) less(o Edge) bool {
+	if e.Direction != o.Direction {
+		return e.Direction < o.Direction
+	}
+	if e.Caller != o.Caller {
+		return e.Caller < o.Caller
+	}
+	if e.Service != o.Service {
+		return e.Service < o.Service
+	}
+	if e.Procedure != o.Procedure {
+		return e.Procedure < o.Procedure
+	}
+	return e.Transport < o.Transport
+}
+
+// Handle implements middleware.UnaryInbound.
+func (g *Graph) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	err := h.Handle(ctx, req, resw)
+	g.record(Inbound, req.ToRequestMeta(), req.Transport, err)
+	return err
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (g *Graph) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	err := h.HandleOneway(ctx, req)
+	g.record(Inbound, req.ToRequestMeta(), req.Transport, err)
+	return err
+}
+
+// HandleStream implements middleware.StreamInbound.
+func (g *Graph) HandleStream(s *transport.ServerStream, h transport.StreamHandler) error {
+	err := h.HandleStream(s)
+	meta := s.Request().Meta
+	g.record(Inbound, meta, meta.Transport, err)
+	return err
+}
+
+// Call implements middleware.UnaryOutbound.
+func (g *Graph) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	res, err := out.Call(ctx, req)
+	g.record(Outbound, req.ToRequestMeta(), g.transportName(out), err)
+	return res, err
+}
+
+// CallOneway implements middleware.OnewayOutbound.
+func (g *Graph) CallOneway(ctx context.Context, req *transport.Request, out transport.OnewayOutbound) (transport.Ack, error) {
+	ack, err := out.CallOneway(ctx, req)
+	g.record(Outbound, req.ToRequestMeta(), g.transportName(out), err)
+	return ack, err
+}
+
+// CallStream implements middleware.StreamOutbound.
+func (g *Graph) CallStream(ctx context.Context, req *transport.StreamRequest, out transport.StreamOutbound) (*transport.ClientStream, error) {
+	stream, err := out.CallStream(ctx, req)
+	g.record(Outbound, req.Meta, g.transportName(out), err)
+	return stream, err
+}
+
+func (g *Graph) record(direction Direction, meta *transport.RequestMeta, transportName string, err error) {
+	edge := Edge{
+		Direction: direction,
+		Caller:    meta.Caller,
+		Service:   meta.Service,
+		Procedure: meta.Procedure,
+		Transport: transportName,
+	}
+
+	g.lock.RLock()
+	c, ok := g.edges[edge]
+	g.lock.RUnlock()
+	if !ok {
+		g.lock.Lock()
+		if c, ok = g.edges[edge]; !ok {
+			c = &counters{}
+			g.edges[edge] = c
+		}
+		g.lock.Unlock()
+	}
+
+	c.calls.Inc()
+	if err != nil {
+		c.errors.Inc()
+	}
+}
+
+// transportName returns the name of the transport of an outbound, as
+// reported by its introspection, or an empty string.
+func (g *Graph) transportName(out interface{}) string {
+	g.transportsLock.RLock()
+	name, ok := g.transports[out]
+	g.transportsLock.RUnlock()
+	if ok {
+		return name
+	}
+
+	if o, ok := out.(introspection.IntrospectableOutbound); ok {
+		name = o.Introspect().Transport
+	}
+	g.transportsLock.Lock()
+	g.transports[out] = name
+	g.transportsLock.Unlock()
+	return name
+}
+
+// Start starts logging the edges of the Graph periodically, if a log
+// interval was given.
+func (g *Graph) Start() error {
+	return g.once.Start(func() error {
+		if g.opts.logInterval <= 0 {
+			close(g.done)
+			return nil
+		}
+		go g.logLoop()
+		return nil
+	})
+}
+
+// Stop stops logging the edges of the Graph.
+func (g *Graph) Stop() error {
+	return g.once.Stop(func() error {
+		close(g.stop)
+		<-g.done
+		return nil
+	})
+}
+
+// IsRunning returns whether the Graph is running.
+func (g *Graph) IsRunning() bool {
+	return g.once.IsRunning()
+}
+
+func (g *Graph) logLoop() {
+	defer close(g.done)
+
+	ticker := time.NewTicker(g.opts.logInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			g.log()
+		case <-g.stop:
+			return
+		}
+	}
+}
+
+func (g *Graph) log() {
+	for _, e := range g.Edges() {
+		g.opts.logger.Info("Observed call graph edge.",
+			zap.String("direction", string(e.Direction)),
+			zap.String("caller", e.Caller),
+			zap.String("service", e.Service),
+			zap.String("procedure", e.Procedure),
+			zap.String("transport", e.Transport),
+			zap.Int64("calls", e.Calls),
+			zap.Int64("errors", e.Errors),
+		)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package callgraph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type unaryHandlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f unaryHandlerFunc) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	return f(ctx, req, resw)
+}
+
+// introspectableOutbound is a FakeOutbound that reports a transport name.
+type introspectableOutbound struct {
+	*transporttest.FakeOutbound
+}
+
+func (introspectableOutbound) Introspect() introspection.OutboundStatus {
+	return introspection.OutboundStatus{Transport: "fake"}
+}
+
+func newRequest(caller, service, procedure string) *transport.Request {
+	return &transport.Request{
+		Caller:    caller,
+		Service:   service,
+		Transport: "http",
+		Procedure: procedure,
+	}
+}
+
+func record(t *testing.T, g *Graph) {
+	ok := unaryHandlerFunc(func(context.Context, *transport.Request, transport.ResponseWriter) error {
+		return nil
+	})
+	fail := unaryHandlerFunc(func(context.Context, *transport.Request, transport.ResponseWriter) error {
+		return errors.New("great sadness")
+	})
+	resw := &transporttest.FakeResponseWriter{}
+	require.NoError(t, g.Handle(context.Background(), newRequest("client", "myservice", "hello"), resw, ok))
+	require.NoError(t, g.Handle(context.Background(), newRequest("client", "myservice", "hello"), resw, ok))
+	require.Error(t, g.Handle(context.Background(), newRequest("client", "myservice", "hello"), resw, fail))
+
+	out := introspectableOutbound{transporttest.NewFakeOutbound()}
+	out.ExpectCall("get").Return(&transport.Response{}, nil)
+	_, err := g.Call(context.Background(), &transport.Request{
+		Caller:    "myservice",
+		Service:   "storage",
+		Procedure: "get",
+	}, out)
+	require.NoError(t, err)
+}
+
+func TestEdges(t *testing.T) {
+	g := New()
+	record(t, g)
+
+	assert.Equal(t, []EdgeStats{
+		{
+			Edge: Edge{
+				Direction: Inbound,
+				Caller:    "client",
+				Service:   "myservice",
+				Procedure: "hello",
+				Transport: "http",
+			},
+			Calls:  3,
+			Errors: 1,
+		},
+		{
+			Edge: Edge{
+				Direction: Outbound,
+				Caller:    "myservice",
+				Service:   "storage",
+				Procedure: "get",
+				Transport: "fake",
+			},
+			Calls: 1,
+		},
+	}, g.Edges())
+}
+
+func TestHandler(t *testing.T) {
+	g := New()
+	record(t, g)
+
+	t.Run("json", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		g.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var edges []EdgeStats
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &edges))
+		assert.Equal(t, g.Edges(), edges)
+	})
+
+	t.Run("dot", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		g.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/?format=dot", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "digraph callgraph {\n"+
+			"\t\"client\" -> \"myservice\" [label=\"inbound hello (http) calls=3 errors=1\"];\n"+
+			"\t\"myservice\" -> \"storage\" [label=\"outbound get (fake) calls=1 errors=0\"];\n"+
+			"}\n", w.Body.String())
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		g.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/?format=xml", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestLogExport(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	g := New(Logger(zap.New(core)), LogInterval(time.Millisecond))
+	record(t, g)
+
+	require.NoError(t, g.Start())
+	assert.True(t, g.IsRunning())
+	deadline := time.Now().Add(time.Second)
+	for logs.Len() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	require.NoError(t, g.Stop())
+	assert.False(t, g.IsRunning())
+
+	entries := logs.AllUntimed()
+	require.True(t, len(entries) >= 2, "expected edges to be logged")
+	assert.Equal(t, "client", entries[0].ContextMap()["caller"])
+	assert.Equal(t, int64(3), entries[0].ContextMap()["calls"])
+}
+
+func TestLifecycleWithoutLogInterval(t *testing.T) {
+	g := New()
+	require.NoError(t, g.Start())
+	require.NoError(t, g.Stop())
+
+	g = New(LogInterval(time.Millisecond))
+	require.NoError(t, g.Stop(), "stop before start must not block")
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package callgraph
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+// Handler returns an HTTP handler that serves the edges of the Graph as JSON,
+// or as a Graphviz digraph if the request has the query parameter
+// "format=dot".
+func (g *Graph) Handler() http.Handler {
+	return http.HandlerFunc(g.serveHTTP)
+}
+
+func (g *Graph) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	edges := g.Edges()
+	switch format := req.URL.Query().Get("format"); format {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(edges); err != nil {
+			g.opts.logger.Error("Failed to write call graph.", zap.Error(err))
+		}
+	case "dot":
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		if _, err := w.Write(dot(edges)); err != nil {
+			g.opts.logger.Error("Failed to write call graph.", zap.Error(err))
+		}
+	default:
+		http.Error(w, fmt.Sprintf("unknown format %q", format), http.StatusBadRequest)
+	}
+}
+
+// dot renders edges as a Graphviz digraph with an arrow from the caller to
+// the service of each edge.
+func dot(edges []EdgeStats) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("digraph callgraph {\n")
+	for _, e := range edges {
+		label := fmt.Sprintf("%s %s (%s) calls=%d errors=%d",
+			e.Direction, e.Procedure, e.Transport, e.Calls, e.Errors)
+		fmt.Fprintf(&buf, "\t%s -> %s [label=%s];\n",
+			strconv.Quote(e.Caller), strconv.Quote(e.Service), strconv.Quote(label))
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package checksum verifies the integrity of request and response bodies
+// end to end.
+//
+// The Middleware is unary outbound and inbound middleware. On the client,
+// it computes a CRC-32C checksum of the request body and sends it in a
+// header. On the server, it verifies the request body against the checksum,
+// failing the request with a DataLoss error if they do not match, and sends
+// a checksum of the response body back, which the client verifies in turn.
+// This catches corruption introduced by proxies or by middleware that
+// rewrites bodies incorrectly.
+//
+// 	checksums := checksum.New(checksum.Procedures("KeyValue::setValue"))
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary: checksums,
+// 		},
+// 		OutboundMiddleware: yarpc.OutboundMiddleware{
+// 			Unary: checksums,
+// 		},
+// 	})
+//
+// Servers only verify requests that carry a checksum, and only send
+// checksums of responses to requests that carried one, so clients and
+// servers may adopt the middleware independently. Responses that handlers
+// stream with transport.StreamResponse are sent without a checksum.
+package checksum
@@ -0,0 +1,214 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package checksum
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/responsewriter"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// HeaderKey is the application header that carries the checksum of a body.
+const HeaderKey = "yarpc-checksum"
+
+const _crc32cPrefix = "crc32c="
+
+var _crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+var (
+	_ middleware.UnaryOutbound = (*Middleware)(nil)
+	_ middleware.UnaryInbound  = (*Middleware)(nil)
+	_ middleware.Named         = (*Middleware)(nil)
+)
+
+// Middleware is unary outbound and inbound middleware that checksums
+// request and response bodies.
+type Middleware struct {
+	opts options
+
+	mismatches *metrics.CounterVector
+}
+
+// New builds a new checksum Middleware.
+func New(opts ...Option) *Middleware {
+	m := &Middleware{opts: applyOptions(opts...)}
+	m.mismatches, _ = m.opts.meter.CounterVector(metrics.Spec{
+		Name:    "checksum_mismatches",
+		Help:    "Number of bodies that did not match their checksum.",
+		VarTags: []string{"procedure", "direction"},
+	})
+	return m
+}
+
+// MiddlewareName implements middleware.Named.
+func (m *Middleware) MiddlewareName() string { return "checksum" }
+
+// Call implements middleware.UnaryOutbound.
+func (m *Middleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	if !m.enabled(req.Procedure) {
+		return out.Call(ctx, req)
+	}
+
+	body, err := readBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	// The caller's request is left untouched.
+	r := *req
+	r.Body = bytes.NewReader(body)
+	r.Headers = cloneHeaders(req.Headers).With(HeaderKey, sum(body))
+
+	res, err := out.Call(ctx, &r)
+	if res == nil || res.Body == nil {
+		return res, err
+	}
+	want, ok := res.Headers.Get(HeaderKey)
+	if !ok {
+		// The server does not send checksums.
+		return res, err
+	}
+	res.Headers.Del(HeaderKey)
+
+	resBody, readErr := readBody(res.Body)
+	_ = res.Body.Close()
+	if readErr != nil {
+		return nil, readErr
+	}
+	if !m.verify(resBody, want, req.Procedure, "response") {
+		return nil, yarpcerrors.DataLossErrorf(
+			"checksum of the response body of procedure %q does not match", req.Procedure)
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(resBody))
+	return res, err
+}
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	want, ok := req.Headers.Get(HeaderKey)
+	if !ok {
+		return h.Handle(ctx, req, resw)
+	}
+
+	body, err := readBody(req.Body)
+	if err != nil {
+		return err
+	}
+	if !m.verify(body, want, req.Procedure, "request") {
+		return yarpcerrors.DataLossErrorf(
+			"checksum of the request body of procedure %q does not match", req.Procedure)
+	}
+	r := *req
+	r.Body = bytes.NewReader(body)
+	r.Headers = cloneHeaders(req.Headers)
+	r.Headers.Del(HeaderKey)
+
+	// The response is buffered so that its checksum can be sent in a header
+	// before the body.
+	w := &bufferedWriter{Wrapper: responsewriter.Wrapper{ResponseWriter: resw}}
+	err = h.Handle(ctx, &r, w)
+	if w.streaming {
+		// The body was already sent, so there is no checksum to send.
+		return err
+	}
+	resw.AddHeaders(transport.NewHeaders().With(HeaderKey, sum(w.body.Bytes())))
+	if _, writeErr := w.body.WriteTo(resw); writeErr != nil && err == nil {
+		err = writeErr
+	}
+	return err
+}
+
+func (m *Middleware) enabled(procedure string) bool {
+	if m.opts.procedures == nil {
+		return true
+	}
+	_, ok := m.opts.procedures[procedure]
+	return ok
+}
+
+// verify reports whether the body matches the given checksum. Checksums
+// computed with unknown algorithms are not verified.
+func (m *Middleware) verify(body []byte, checksum, procedure, direction string) bool {
+	if !strings.HasPrefix(checksum, _crc32cPrefix) || checksum == sum(body) {
+		return true
+	}
+	if counter, err := m.mismatches.Get("procedure", procedure, "direction", direction); err == nil {
+		counter.Inc()
+	}
+	return false
+}
+
+func sum(body []byte) string {
+	return fmt.Sprintf("%s%08x", _crc32cPrefix, crc32.Checksum(body, _crc32cTable))
+}
+
+func readBody(body io.Reader) ([]byte, error) {
+	if body == nil {
+		return nil, nil
+	}
+	return ioutil.ReadAll(body)
+}
+
+// cloneHeaders copies headers so that adding or removing the checksum does
+// not change the headers of the caller.
+func cloneHeaders(headers transport.Headers) transport.Headers {
+	clone := transport.NewHeadersWithCapacity(headers.Len() + 1)
+	for k, v := range headers.OriginalItems() {
+		clone = clone.With(k, v)
+	}
+	return clone
+}
+
+// bufferedWriter buffers the body of a response, unless the handler
+// streams it.
+type bufferedWriter struct {
+	responsewriter.Wrapper
+
+	body      bytes.Buffer
+	streaming bool
+}
+
+// StreamResponse implements transport.StreamingResponseWriter. Streamed
+// responses are sent without a checksum since it would have to precede the
+// body.
+func (w *bufferedWriter) StreamResponse() bool {
+	if w.body.Len() > 0 || !w.Wrapper.StreamResponse() {
+		return false
+	}
+	w.streaming = true
+	return true
+}
+
+func (w *bufferedWriter) Write(p []byte) (int, error) {
+	if w.streaming {
+		return w.ResponseWriter.Write(p)
+	}
+	return w.body.Write(p)
+}
@@ -0,0 +1,241 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package checksum
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type handlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f handlerFunc) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	return f(ctx, req, resw)
+}
+
+type outboundFunc func(context.Context, *transport.Request) (*transport.Response, error)
+
+func (f outboundFunc) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	return f(ctx, req)
+}
+
+func (outboundFunc) Start() error                      { return nil }
+func (outboundFunc) Stop() error                       { return nil }
+func (outboundFunc) IsRunning() bool                   { return true }
+func (outboundFunc) Transports() []transport.Transport { return nil }
+
+type responseWriter struct {
+	bytes.Buffer
+
+	headers transport.Headers
+}
+
+func (w *responseWriter) AddHeaders(h transport.Headers) {
+	for k, v := range h.OriginalItems() {
+		w.headers = w.headers.With(k, v)
+	}
+}
+
+func (w *responseWriter) SetApplicationError() {}
+
+// server returns an outbound that passes requests to the handler through
+// the inbound middleware, corrupting bodies with the given functions.
+func server(m *Middleware, h handlerFunc, corruptRequest, corruptResponse func([]byte) []byte) outboundFunc {
+	return func(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		if corruptRequest != nil {
+			body = corruptRequest(body)
+		}
+		r := *req
+		r.Body = bytes.NewReader(body)
+
+		var w responseWriter
+		if err := m.Handle(ctx, &r, &w, h); err != nil {
+			return nil, err
+		}
+		resBody := w.Bytes()
+		if corruptResponse != nil {
+			resBody = corruptResponse(resBody)
+		}
+		return &transport.Response{
+			Headers: w.headers,
+			Body:    ioutil.NopCloser(bytes.NewReader(resBody)),
+		}, nil
+	}
+}
+
+func echo(_ context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	checksummed := "no"
+	if _, ok := req.Headers.Get(HeaderKey); ok {
+		checksummed = "yes"
+	}
+	resw.AddHeaders(transport.NewHeaders().With("checksummed", checksummed))
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	_, err = resw.Write(body)
+	return err
+}
+
+func flip(body []byte) []byte {
+	corrupt := append([]byte(nil), body...)
+	corrupt[0] ^= 0xff
+	return corrupt
+}
+
+func mismatches(root *metrics.Root, direction string) int64 {
+	for _, s := range root.Snapshot().Counters {
+		if s.Name == "checksum_mismatches" && s.Tags["direction"] == direction {
+			return s.Value
+		}
+	}
+	return 0
+}
+
+func call(t *testing.T, m *Middleware, out transport.UnaryOutbound, procedure string) (*transport.Response, string, error) {
+	req := &transport.Request{
+		Procedure: procedure,
+		Headers:   transport.NewHeaders().With("foo", "bar"),
+		Body:      strings.NewReader("hello"),
+	}
+	res, err := m.Call(context.Background(), req, out)
+	_, ok := req.Headers.Get(HeaderKey)
+	assert.False(t, ok, "caller's headers must not be modified")
+	if err != nil {
+		return res, "", err
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	return res, string(body), nil
+}
+
+func TestRoundTrip(t *testing.T) {
+	m := New()
+	res, body, err := call(t, m, server(m, echo, nil, nil), "echo")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", body)
+
+	checksummed, _ := res.Headers.Get("checksummed")
+	assert.Equal(t, "no", checksummed, "handler must not see the checksum header")
+	_, ok := res.Headers.Get(HeaderKey)
+	assert.False(t, ok, "caller must not see the checksum header")
+}
+
+func TestRequestCorrupted(t *testing.T) {
+	root := metrics.New()
+	m := New(Metrics(root.Scope()))
+	_, _, err := call(t, m, server(m, echo, flip, nil), "echo")
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeDataLoss, yarpcerrors.FromError(err).Code())
+	assert.Equal(t, int64(1), mismatches(root, "request"))
+}
+
+func TestResponseCorrupted(t *testing.T) {
+	root := metrics.New()
+	m := New(Metrics(root.Scope()))
+	_, _, err := call(t, m, server(m, echo, nil, flip), "echo")
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeDataLoss, yarpcerrors.FromError(err).Code())
+	assert.Equal(t, int64(1), mismatches(root, "response"))
+}
+
+func TestProcedures(t *testing.T) {
+	m := New(Procedures("other"))
+	// Without a checksum on the request, the server neither verifies the
+	// request nor checksums the response, so corruption goes unnoticed.
+	res, body, err := call(t, m, server(m, echo, flip, nil), "echo")
+	require.NoError(t, err)
+	assert.NotEqual(t, "hello", body)
+	_, ok := res.Headers.Get(HeaderKey)
+	assert.False(t, ok)
+
+	_, _, err = call(t, m, server(m, echo, flip, nil), "other")
+	assert.Equal(t, yarpcerrors.CodeDataLoss, yarpcerrors.FromError(err).Code())
+}
+
+func TestServerWithoutChecksums(t *testing.T) {
+	m := New()
+	plain := outboundFunc(func(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+		var w responseWriter
+		if err := echo(ctx, req, &w); err != nil {
+			return nil, err
+		}
+		return &transport.Response{Headers: w.headers, Body: ioutil.NopCloser(&w.Buffer)}, nil
+	})
+	_, body, err := call(t, m, plain, "echo")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", body)
+}
+
+func TestUnknownAlgorithm(t *testing.T) {
+	m := New()
+	var w responseWriter
+	req := &transport.Request{
+		Procedure: "echo",
+		Headers:   transport.NewHeaders().With(HeaderKey, "sha1=abc"),
+		Body:      strings.NewReader("hello"),
+	}
+	require.NoError(t, m.Handle(context.Background(), req, &w, handlerFunc(echo)))
+	assert.Equal(t, "hello", w.String())
+}
+
+type streamingResponseWriter struct {
+	responseWriter
+
+	streaming bool
+}
+
+func (w *streamingResponseWriter) StreamResponse() bool {
+	w.streaming = true
+	return true
+}
+
+func TestStreamedResponse(t *testing.T) {
+	m := New()
+	req := &transport.Request{
+		Procedure: "echo",
+		Headers:   transport.NewHeaders().With(HeaderKey, sum([]byte("hello"))),
+		Body:      strings.NewReader("hello"),
+	}
+	var w streamingResponseWriter
+	err := m.Handle(context.Background(), req, &w, handlerFunc(func(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+		require.True(t, transport.StreamResponse(resw))
+		return echo(ctx, req, resw)
+	}))
+	require.NoError(t, err)
+	assert.True(t, w.streaming)
+	assert.Equal(t, "hello", w.String())
+	_, ok := w.headers.Get(HeaderKey)
+	assert.False(t, ok, "streamed responses must not carry a checksum")
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package checksum
+
+import "go.uber.org/net/metrics"
+
+// Option customizes the behavior of a Middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	procedures map[string]struct{}
+	meter      *metrics.Scope
+}
+
+// Procedures specifies the procedures whose outbound requests carry
+// checksums. By default, all outbound requests do. Inbound requests are
+// verified whenever they carry a checksum.
+func Procedures(procedures ...string) Option {
+	return optionFunc(func(opts *options) {
+		if opts.procedures == nil {
+			opts.procedures = make(map[string]struct{}, len(procedures))
+		}
+		for _, p := range procedures {
+			opts.procedures[p] = struct{}{}
+		}
+	})
+}
+
+// Metrics specifies the scope to which metrics about checksum mismatches are
+// reported. By default, no metrics are reported.
+func Metrics(meter *metrics.Scope) Option {
+	return optionFunc(func(opts *options) {
+		opts.meter = meter
+	})
+}
+
+func applyOptions(opts ...Option) options {
+	var options options
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return options
+}
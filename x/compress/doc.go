@@ -0,0 +1,49 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package compress compresses request and response bodies independently of
+// the transport.
+//
+// Some transports, like TChannel, cannot compress bodies themselves. The
+// Middleware is unary outbound and inbound middleware that compresses the
+// bodies of requests and responses of the chosen encodings, marking them
+// with a header, so that services using any transport can save bandwidth.
+//
+// 	compression := compress.New(compress.Encodings(thrift.Encoding, json.Encoding))
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary: compression,
+// 		},
+// 		OutboundMiddleware: yarpc.OutboundMiddleware{
+// 			Unary: compression,
+// 		},
+// 	})
+//
+// Compression is negotiated: clients list the compressors they support in
+// the AcceptEncodingHeader of their requests, and servers list theirs in
+// their responses. Servers compress responses with the first compressor of
+// their own that the client supports, and clients compress requests to a
+// service only after it advertised a compressor they support. Clients and
+// servers may therefore adopt the middleware independently.
+//
+// Responses that handlers stream with transport.StreamResponse are
+// compressed as they are written, even if they are smaller than MinSize.
+package compress
@@ -0,0 +1,70 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package compress
+
+import (
+	"io"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// GRPC adapts a Compressor to the compressors of gRPC, so that gRPC
+// transports may compress messages with it once it is registered with
+// encoding.RegisterCompressor. Register it on both ends and select it on
+// outbounds with the Compressor option of the gRPC transport.
+//
+// 	encoding.RegisterCompressor(compress.GRPC(compress.Gzip(gzip.BestSpeed)))
+func GRPC(c Compressor) encoding.Compressor {
+	return grpcCompressor{c}
+}
+
+type grpcCompressor struct {
+	Compressor
+}
+
+func (c grpcCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	rc, err := c.Compressor.Decompress(r)
+	if err != nil {
+		return nil, err
+	}
+	return &closeOnEOF{rc: rc}, nil
+}
+
+// closeOnEOF closes a reader once it's fully read, since gRPC reads
+// decompressed messages to the end but never closes them.
+type closeOnEOF struct {
+	rc     io.ReadCloser
+	closed bool
+}
+
+func (r *closeOnEOF) Read(p []byte) (int, error) {
+	if r.closed {
+		return 0, io.EOF
+	}
+	n, err := r.rc.Read(p)
+	if err == io.EOF {
+		r.closed = true
+		if cerr := r.rc.Close(); cerr != nil {
+			return n, cerr
+		}
+	}
+	return n, err
+}
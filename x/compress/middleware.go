@@ -0,0 +1,326 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package compress
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/responsewriter"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	// ContentEncodingHeader is the application header that names the
+	// compressor of a compressed body.
+	ContentEncodingHeader = "yarpc-content-encoding"
+
+	// AcceptEncodingHeader is the application header in which clients and
+	// servers list the compressors they support, separated by commas.
+	AcceptEncodingHeader = "yarpc-accept-encoding"
+)
+
+var (
+	_ middleware.UnaryOutbound = (*Middleware)(nil)
+	_ middleware.UnaryInbound  = (*Middleware)(nil)
+	_ middleware.Named         = (*Middleware)(nil)
+)
+
+// Middleware is unary outbound and inbound middleware that compresses
+// request and response bodies.
+type Middleware struct {
+	opts   options
+	accept string
+
+	mu sync.RWMutex
+	// Compressors that services advertised, by service name.
+	accepted map[string]Compressor
+}
+
+// New builds a new compression Middleware.
+func New(opts ...Option) *Middleware {
+	m := &Middleware{
+		opts:     applyOptions(opts...),
+		accepted: make(map[string]Compressor),
+	}
+	names := make([]string, len(m.opts.compressors))
+	for i, c := range m.opts.compressors {
+		names[i] = c.Name()
+	}
+	m.accept = strings.Join(names, ",")
+	return m
+}
+
+// MiddlewareName implements middleware.Named.
+func (m *Middleware) MiddlewareName() string { return "compress" }
+
+// Call implements middleware.UnaryOutbound.
+func (m *Middleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	if !m.enabled(req.Encoding) {
+		return out.Call(ctx, req)
+	}
+
+	r := *req
+	r.Headers = cloneHeaders(req.Headers).With(AcceptEncodingHeader, m.accept)
+	if c := m.acceptedBy(req.Service); c != nil {
+		body, compressed, err := m.compress(c, req.Body)
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+		if compressed {
+			r.Headers = r.Headers.With(ContentEncodingHeader, c.Name())
+		}
+	}
+
+	res, err := out.Call(ctx, &r)
+	if res == nil {
+		return res, err
+	}
+	if accept, ok := res.Headers.Get(AcceptEncodingHeader); ok {
+		res.Headers.Del(AcceptEncodingHeader)
+		m.setAccepted(req.Service, m.negotiate(accept))
+	}
+	name, ok := res.Headers.Get(ContentEncodingHeader)
+	if !ok || res.Body == nil {
+		return res, err
+	}
+	res.Headers.Del(ContentEncodingHeader)
+	// A response that cannot be decompressed is the fault of the server.
+	body, decompressErr := m.decompress(name, res.Body, yarpcerrors.InternalErrorf)
+	_ = res.Body.Close()
+	if decompressErr != nil {
+		return nil, decompressErr
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return res, err
+}
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	if !m.enabled(req.Encoding) {
+		return h.Handle(ctx, req, resw)
+	}
+
+	r := *req
+	r.Headers = cloneHeaders(req.Headers)
+	if name, ok := req.Headers.Get(ContentEncodingHeader); ok {
+		body, err := m.decompress(name, req.Body, yarpcerrors.InvalidArgumentErrorf)
+		if err != nil {
+			return err
+		}
+		r.Body = bytes.NewReader(body)
+		r.Headers.Del(ContentEncodingHeader)
+	}
+	var c Compressor
+	if accept, ok := req.Headers.Get(AcceptEncodingHeader); ok {
+		c = m.negotiate(accept)
+		r.Headers.Del(AcceptEncodingHeader)
+	}
+
+	// The response is buffered so that the header marking it compressed can
+	// be sent before the body.
+	w := &bufferedWriter{
+		Wrapper:    responsewriter.Wrapper{ResponseWriter: resw},
+		accept:     m.accept,
+		compressor: c,
+	}
+	err := h.Handle(ctx, &r, w)
+	if w.streaming {
+		if w.compressed != nil {
+			if closeErr := w.compressed.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}
+
+	headers := transport.NewHeaders().With(AcceptEncodingHeader, m.accept)
+	var body io.Reader = &w.body
+	if c != nil {
+		compressedBody, compressed, compressErr := m.compress(c, &w.body)
+		if compressErr != nil {
+			return compressErr
+		}
+		body = compressedBody
+		if compressed {
+			headers = headers.With(ContentEncodingHeader, c.Name())
+		}
+	}
+	resw.AddHeaders(headers)
+	if _, writeErr := io.Copy(resw, body); writeErr != nil && err == nil {
+		err = writeErr
+	}
+	return err
+}
+
+func (m *Middleware) enabled(encoding transport.Encoding) bool {
+	if m.opts.encodings == nil {
+		return true
+	}
+	_, ok := m.opts.encodings[encoding]
+	return ok
+}
+
+// negotiate returns the first of our compressors that appears in the given
+// list of compressor names, or nil if there is none.
+func (m *Middleware) negotiate(accept string) Compressor {
+	names := strings.Split(accept, ",")
+	for _, c := range m.opts.compressors {
+		for _, name := range names {
+			if strings.TrimSpace(name) == c.Name() {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Middleware) acceptedBy(service string) Compressor {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.accepted[service]
+}
+
+func (m *Middleware) setAccepted(service string, c Compressor) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c == nil {
+		delete(m.accepted, service)
+		return
+	}
+	m.accepted[service] = c
+}
+
+// compress returns the body compressed with the compressor, and whether it
+// was compressed at all: bodies smaller than the minimum size are not.
+func (m *Middleware) compress(c Compressor, body io.Reader) (io.Reader, bool, error) {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = ioutil.ReadAll(body); err != nil {
+			return nil, false, err
+		}
+	}
+	if len(data) < m.opts.minSize {
+		return bytes.NewReader(data), false, nil
+	}
+
+	var buf bytes.Buffer
+	w, err := c.Compress(&buf)
+	if err != nil {
+		return nil, false, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, false, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, false, err
+	}
+	return &buf, true, nil
+}
+
+// decompress returns the body decompressed with the named compressor. Bodies
+// that cannot be decompressed are reported with errorf.
+func (m *Middleware) decompress(name string, body io.Reader, errorf func(string, ...interface{}) error) ([]byte, error) {
+	for _, c := range m.opts.compressors {
+		if c.Name() != name {
+			continue
+		}
+		if body == nil {
+			body = bytes.NewReader(nil)
+		}
+		r, err := c.Decompress(body)
+		if err != nil {
+			return nil, errorf("could not decompress %s body: %v", name, err)
+		}
+		defer r.Close()
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, errorf("could not decompress %s body: %v", name, err)
+		}
+		return data, nil
+	}
+	return nil, yarpcerrors.UnimplementedErrorf("unsupported body compression %q", name)
+}
+
+// cloneHeaders copies headers so that adding or removing compression headers
+// does not change the headers of the caller.
+func cloneHeaders(headers transport.Headers) transport.Headers {
+	clone := transport.NewHeadersWithCapacity(headers.Len() + 2)
+	for k, v := range headers.OriginalItems() {
+		clone = clone.With(k, v)
+	}
+	return clone
+}
+
+// bufferedWriter buffers the body of a response, unless the handler
+// streams it.
+type bufferedWriter struct {
+	responsewriter.Wrapper
+
+	accept     string
+	compressor Compressor
+
+	body       bytes.Buffer
+	streaming  bool
+	compressed io.WriteCloser
+}
+
+// StreamResponse implements transport.StreamingResponseWriter. Streamed
+// responses are compressed as they are written, regardless of their size.
+func (w *bufferedWriter) StreamResponse() bool {
+	if w.body.Len() > 0 {
+		return false
+	}
+	headers := transport.NewHeaders().With(AcceptEncodingHeader, w.accept)
+	var compressed io.WriteCloser
+	if w.compressor != nil {
+		var err error
+		if compressed, err = w.compressor.Compress(w.ResponseWriter); err != nil {
+			// Keep buffering; Handle reports the error.
+			return false
+		}
+		headers = headers.With(ContentEncodingHeader, w.compressor.Name())
+	}
+	if !w.Wrapper.StreamResponse() {
+		return false
+	}
+	w.ResponseWriter.AddHeaders(headers)
+	w.streaming, w.compressed = true, compressed
+	return true
+}
+
+func (w *bufferedWriter) Write(p []byte) (int, error) {
+	if w.compressed != nil {
+		return w.compressed.Write(p)
+	}
+	if w.streaming {
+		return w.ResponseWriter.Write(p)
+	}
+	return w.body.Write(p)
+}
@@ -0,0 +1,229 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package compress
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type outboundFunc func(context.Context, *transport.Request) (*transport.Response, error)
+
+func (f outboundFunc) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	return f(ctx, req)
+}
+
+func (outboundFunc) Start() error                      { return nil }
+func (outboundFunc) Stop() error                       { return nil }
+func (outboundFunc) IsRunning() bool                   { return true }
+func (outboundFunc) Transports() []transport.Transport { return nil }
+
+type handlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f handlerFunc) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	return f(ctx, req, resw)
+}
+
+type responseWriter struct {
+	bytes.Buffer
+
+	headers transport.Headers
+}
+
+func (w *responseWriter) AddHeaders(h transport.Headers) {
+	for k, v := range h.OriginalItems() {
+		w.headers = w.headers.With(k, v)
+	}
+}
+
+func (w *responseWriter) SetApplicationError() {}
+
+func echo(_ context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	_, err = resw.Write(body)
+	return err
+}
+
+// wire records what was sent over the wire between a client and a server.
+type wire struct {
+	requestHeaders  transport.Headers
+	requestBody     []byte
+	responseHeaders transport.Headers
+	responseBody    []byte
+}
+
+// server returns an outbound that passes requests to the echo handler through
+// the inbound middleware, recording the last exchange on the wire.
+func server(m *Middleware, w *wire) outboundFunc {
+	return func(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		w.requestHeaders, w.requestBody = req.Headers, body
+
+		r := *req
+		r.Body = bytes.NewReader(body)
+		var resw responseWriter
+		var h transport.UnaryHandler = handlerFunc(echo)
+		if m != nil {
+			err = m.Handle(ctx, &r, &resw, h)
+		} else {
+			err = h.Handle(ctx, &r, &resw)
+		}
+		if err != nil {
+			return nil, err
+		}
+		// The client removes compression headers from the response.
+		w.responseHeaders, w.responseBody = cloneHeaders(resw.headers), resw.Bytes()
+		return &transport.Response{
+			Headers: resw.headers,
+			Body:    ioutil.NopCloser(bytes.NewReader(resw.Bytes())),
+		}, nil
+	}
+}
+
+func call(t *testing.T, m *Middleware, out transport.UnaryOutbound, encoding transport.Encoding, body string) string {
+	res, err := m.Call(context.Background(), &transport.Request{
+		Service:  "service",
+		Encoding: encoding,
+		Headers:  transport.NewHeaders(),
+		Body:     strings.NewReader(body),
+	}, out)
+	require.NoError(t, err)
+	_, ok := res.Headers.Get(ContentEncodingHeader)
+	assert.False(t, ok, "caller must not see compression headers")
+	got, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	return string(got)
+}
+
+func TestNegotiation(t *testing.T) {
+	client, srv := New(MinSize(10)), New(MinSize(10))
+	var w wire
+	out := server(srv, &w)
+	body := strings.Repeat("hello ", 100)
+
+	// The client does not know whether the server supports compression yet.
+	assert.Equal(t, body, call(t, client, out, "json", body))
+	assert.Equal(t, body, string(w.requestBody), "first request must not be compressed")
+	assert.True(t, len(w.responseBody) < len(body), "response must be compressed")
+	encoding, _ := w.responseHeaders.Get(ContentEncodingHeader)
+	assert.Equal(t, "gzip", encoding)
+
+	assert.Equal(t, body, call(t, client, out, "json", body))
+	assert.True(t, len(w.requestBody) < len(body), "request must be compressed once the server advertised gzip")
+	encoding, _ = w.requestHeaders.Get(ContentEncodingHeader)
+	assert.Equal(t, "gzip", encoding)
+
+	// Small bodies are not compressed.
+	assert.Equal(t, "hi", call(t, client, out, "json", "hi"))
+	assert.Equal(t, "hi", string(w.requestBody))
+	assert.Equal(t, "hi", string(w.responseBody))
+	_, ok := w.responseHeaders.Get(ContentEncodingHeader)
+	assert.False(t, ok)
+}
+
+func TestServerWithoutCompression(t *testing.T) {
+	client := New(MinSize(0))
+	var w wire
+	out := server(nil, &w)
+
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, "hello", call(t, client, out, "json", "hello"))
+		assert.Equal(t, "hello", string(w.requestBody))
+	}
+}
+
+func TestEncodings(t *testing.T) {
+	client, srv := New(MinSize(0), Encodings("thrift")), New(MinSize(0), Encodings("thrift"))
+	var w wire
+	out := server(srv, &w)
+
+	assert.Equal(t, "hello", call(t, client, out, "json", "hello"))
+	_, ok := w.requestHeaders.Get(AcceptEncodingHeader)
+	assert.False(t, ok, "other encodings must not be compressed")
+	assert.Equal(t, "hello", string(w.responseBody))
+
+	assert.Equal(t, "hello", call(t, client, out, "thrift", "hello"))
+	_, ok = w.responseHeaders.Get(ContentEncodingHeader)
+	assert.True(t, ok)
+}
+
+func TestInvalidRequestBodies(t *testing.T) {
+	srv := New()
+	tests := []struct {
+		desc     string
+		encoding string
+		wantCode yarpcerrors.Code
+	}{
+		{desc: "unknown compressor", encoding: "br", wantCode: yarpcerrors.CodeUnimplemented},
+		{desc: "corrupt body", encoding: "gzip", wantCode: yarpcerrors.CodeInvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := srv.Handle(context.Background(), &transport.Request{
+				Encoding: "json",
+				Headers:  transport.NewHeaders().With(ContentEncodingHeader, tt.encoding),
+				Body:     strings.NewReader("not compressed"),
+			}, &responseWriter{}, handlerFunc(echo))
+			require.Error(t, err)
+			assert.Equal(t, tt.wantCode, yarpcerrors.FromError(err).Code())
+		})
+	}
+}
+
+type streamingResponseWriter struct {
+	responseWriter
+}
+
+func (w *streamingResponseWriter) StreamResponse() bool { return true }
+
+func TestStreamedResponse(t *testing.T) {
+	m := New()
+	req := &transport.Request{
+		Headers: transport.NewHeaders().With(AcceptEncodingHeader, "gzip"),
+		Body:    strings.NewReader("hello"),
+	}
+	var w streamingResponseWriter
+	err := m.Handle(context.Background(), req, &w, handlerFunc(func(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+		require.True(t, transport.StreamResponse(resw))
+		return echo(ctx, req, resw)
+	}))
+	require.NoError(t, err)
+
+	name, ok := w.headers.Get(ContentEncodingHeader)
+	require.True(t, ok, "streamed responses must be compressed")
+	body, err := m.decompress(name, &w.Buffer, yarpcerrors.InternalErrorf)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(body))
+}
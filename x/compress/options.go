@@ -0,0 +1,85 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package compress
+
+import (
+	"compress/gzip"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+const _defaultMinSize = 1024
+
+// Option customizes the behavior of a Middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	compressors []Compressor
+	encodings   map[transport.Encoding]struct{}
+	minSize     int
+}
+
+// Compressors specifies the supported compressors, in order of preference.
+// Defaults to gzip at the default compression level.
+func Compressors(compressors ...Compressor) Option {
+	return optionFunc(func(opts *options) {
+		opts.compressors = compressors
+	})
+}
+
+// Encodings specifies the encodings whose bodies are compressed. By
+// default, bodies of all encodings are compressed.
+func Encodings(encodings ...transport.Encoding) Option {
+	return optionFunc(func(opts *options) {
+		if opts.encodings == nil {
+			opts.encodings = make(map[transport.Encoding]struct{}, len(encodings))
+		}
+		for _, e := range encodings {
+			opts.encodings[e] = struct{}{}
+		}
+	})
+}
+
+// MinSize specifies the size in bytes below which bodies are sent
+// uncompressed, since compressing them costs more than it saves. Defaults to
+// 1024.
+func MinSize(size int) Option {
+	return optionFunc(func(opts *options) {
+		opts.minSize = size
+	})
+}
+
+func applyOptions(opts ...Option) options {
+	options := options{
+		compressors: []Compressor{Gzip(gzip.DefaultCompression)},
+		minSize:     _defaultMinSize,
+	}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return options
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zstd
+
+import (
+	"errors"
+
+	kzstd "github.com/klauspost/compress/zstd"
+)
+
+// TrainDictionary builds a dictionary of at most size bytes from samples of
+// the payloads it will compress, like recorded request bodies. The more
+// representative the samples, the better the dictionary; a few thousand
+// samples are typical. The ID identifies the dictionary and must be unique
+// among the dictionaries of a service; it must not be zero.
+func TrainDictionary(id uint32, samples [][]byte, size int) ([]byte, error) {
+	if id == 0 {
+		return nil, errors.New("zstd dictionary ID must not be zero")
+	}
+	if len(samples) == 0 {
+		return nil, errors.New("cannot train a zstd dictionary without samples")
+	}
+
+	// The dictionary's content holds the most recent samples, which end up
+	// closest to the data it compresses.
+	var history []byte
+	for i := len(samples) - 1; i >= 0 && len(history) < size; i-- {
+		history = append(samples[i][:len(samples[i]):len(samples[i])], history...)
+	}
+	if len(history) > size {
+		history = history[len(history)-size:]
+	}
+	return kzstd.BuildDict(kzstd.BuildDictOptions{
+		ID:       id,
+		Contents: samples,
+		History:  history,
+		Offsets:  [3]int{1, 4, 8},
+	})
+}
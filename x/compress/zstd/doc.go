@@ -0,0 +1,39 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package zstd provides a Zstandard compressor for package compress.
+//
+// Zstandard compresses about as well as gzip at a fraction of its CPU cost,
+// which matters for high-throughput services. Small payloads, like most RPC
+// bodies, compress much better with a dictionary trained on samples of
+// them; both ends must use the same dictionary.
+//
+// 	dict, err := zstd.TrainDictionary(1, samples, 64<<10)
+// 	// ...
+// 	compressor, err := zstd.New(zstd.Dictionary(dict))
+// 	// ...
+// 	compression := compress.New(compress.Compressors(compressor, compress.Gzip(gzip.BestSpeed)))
+//
+// Compressors with a dictionary are named after the ID of the dictionary,
+// like "zstd-dict-1", so that they are only negotiated with peers that have
+// the same dictionary.
+//
+// The compressor also works with gRPC transports through compress.GRPC.
+package zstd
@@ -0,0 +1,177 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zstd
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	kzstd "github.com/klauspost/compress/zstd"
+	"go.uber.org/yarpc/x/compress"
+)
+
+const _defaultLevel = 3
+
+// Option customizes the behavior of a Zstandard compressor.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	level int
+	dict  []byte
+}
+
+// Level specifies the Zstandard compression level, between 1 and 22; higher
+// levels compress better but more slowly. Defaults to 3.
+func Level(level int) Option {
+	return optionFunc(func(opts *options) {
+		opts.level = level
+	})
+}
+
+// Dictionary specifies a dictionary, as built by TrainDictionary or the
+// zstd command line tool, with which to compress and decompress. By
+// default, no dictionary is used.
+func Dictionary(dict []byte) Option {
+	return optionFunc(func(opts *options) {
+		opts.dict = dict
+	})
+}
+
+// New builds a Zstandard compressor.
+func New(opts ...Option) (compress.Compressor, error) {
+	options := options{level: _defaultLevel}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+
+	c := &compressor{name: "zstd"}
+	encoderOptions := []kzstd.EOption{
+		kzstd.WithEncoderLevel(kzstd.EncoderLevelFromZstd(options.level)),
+		// Bodies are compressed whole, so concurrency would only add
+		// overhead.
+		kzstd.WithEncoderConcurrency(1),
+	}
+	decoderOptions := []kzstd.DOption{kzstd.WithDecoderConcurrency(1)}
+	if options.dict != nil {
+		info, err := kzstd.InspectDictionary(options.dict)
+		if err != nil {
+			return nil, fmt.Errorf("invalid zstd dictionary: %v", err)
+		}
+		c.name = fmt.Sprintf("zstd-dict-%d", info.ID())
+		encoderOptions = append(encoderOptions, kzstd.WithEncoderDict(options.dict))
+		decoderOptions = append(decoderOptions, kzstd.WithDecoderDicts(options.dict))
+	}
+
+	// Build an encoder and a decoder upfront to validate the options.
+	enc, err := kzstd.NewWriter(nil, encoderOptions...)
+	if err != nil {
+		return nil, err
+	}
+	dec, err := kzstd.NewReader(nil, decoderOptions...)
+	if err != nil {
+		return nil, err
+	}
+	c.encoders.Put(enc)
+	c.decoders.Put(dec)
+	c.encoders.New = func() interface{} {
+		enc, _ := kzstd.NewWriter(nil, encoderOptions...)
+		return enc
+	}
+	c.decoders.New = func() interface{} {
+		dec, _ := kzstd.NewReader(nil, decoderOptions...)
+		return dec
+	}
+	return c, nil
+}
+
+// compressor pools encoders and decoders, which are expensive to build.
+type compressor struct {
+	name     string
+	encoders sync.Pool
+	decoders sync.Pool
+}
+
+func (c *compressor) Name() string { return c.name }
+
+func (c *compressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	enc := c.encoders.Get().(*kzstd.Encoder)
+	enc.Reset(w)
+	return &writer{Encoder: enc, pool: &c.encoders}, nil
+}
+
+func (c *compressor) Decompress(r io.Reader) (io.ReadCloser, error) {
+	dec := c.decoders.Get().(*kzstd.Decoder)
+	if err := dec.Reset(r); err != nil {
+		c.decoders.Put(dec)
+		return nil, err
+	}
+	return &reader{Decoder: dec, pool: &c.decoders}, nil
+}
+
+// writer returns its encoder to the pool once closed.
+type writer struct {
+	*kzstd.Encoder
+
+	pool *sync.Pool
+}
+
+func (w *writer) Close() error {
+	if w.Encoder == nil {
+		return nil
+	}
+	err := w.Encoder.Close()
+	w.Encoder.Reset(nil)
+	w.pool.Put(w.Encoder)
+	w.Encoder = nil
+	return err
+}
+
+// reader returns its decoder to the pool once closed.
+type reader struct {
+	*kzstd.Decoder
+
+	pool *sync.Pool
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if r.Decoder == nil {
+		return 0, io.ErrClosedPipe
+	}
+	return r.Decoder.Read(p)
+}
+
+func (r *reader) Close() error {
+	if r.Decoder == nil {
+		return nil
+	}
+	// Detach the decoder from the compressed stream before it's reused.
+	_ = r.Decoder.Reset(nil)
+	r.pool.Put(r.Decoder)
+	r.Decoder = nil
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zstd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/x/compress"
+)
+
+func roundTrip(t *testing.T, c compress.Compressor, body []byte) []byte {
+	var buf bytes.Buffer
+	w, err := c.Compress(&buf)
+	require.NoError(t, err)
+	_, err = w.Write(body)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	compressed := buf.Bytes()
+
+	r, err := c.Decompress(bytes.NewReader(compressed))
+	require.NoError(t, err)
+	got, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, body, got)
+	return compressed
+}
+
+func TestRoundTrip(t *testing.T) {
+	c, err := New(Level(1))
+	require.NoError(t, err)
+	assert.Equal(t, "zstd", c.Name())
+
+	body := bytes.Repeat([]byte("hello world "), 1000)
+	// Encoders and decoders are reused across bodies.
+	for i := 0; i < 3; i++ {
+		compressed := roundTrip(t, c, body)
+		assert.True(t, len(compressed) < len(body)/10, "expected repetitive body to compress")
+	}
+}
+
+func TestDictionary(t *testing.T) {
+	var samples [][]byte
+	for i := 0; i < 200; i++ {
+		samples = append(samples, []byte(fmt.Sprintf(
+			`{"user_id":%d,"name":"user-%d","email":"user-%d@example.com","active":true,"roles":["reader","writer"]}`, i, i, i)))
+	}
+	dict, err := TrainDictionary(7, samples, 4<<10)
+	require.NoError(t, err)
+
+	withDict, err := New(Dictionary(dict))
+	require.NoError(t, err)
+	assert.Equal(t, "zstd-dict-7", withDict.Name())
+	plain, err := New()
+	require.NoError(t, err)
+
+	body := []byte(`{"user_id":4242,"name":"user-4242","email":"user-4242@example.com","active":true,"roles":["reader","writer"]}`)
+	assert.True(t, len(roundTrip(t, withDict, body)) < len(roundTrip(t, plain, body)),
+		"expected the dictionary to compress small bodies better")
+
+	_, err = New(Dictionary([]byte("not a dictionary")))
+	assert.Error(t, err)
+
+	_, err = TrainDictionary(0, samples, 4<<10)
+	assert.Error(t, err, "expected zero ID to be rejected")
+	_, err = TrainDictionary(1, nil, 4<<10)
+	assert.Error(t, err, "expected samples to be required")
+}
@@ -100,13 +100,19 @@ var (
 			<th>Encoding</th>
 			<th>Signature</th>
 			<th>RPC Type</th>
+			<th>Description</th>
 		</tr>
 		{{range .Procedures}}
 		<tr>
-			<td>{{.Name}}</td>
+			<td>{{if .Deprecated}}<del>{{.Name}}</del>{{else}}{{.Name}}{{end}}</td>
 			<td>{{.Encoding}}</td>
 			<td>{{.Signature}}</td>
 			<td>{{.RPCType}}</td>
+			<td>
+				{{.Description}}
+				{{if .Idempotent}}<br /><small>Idempotent</small>{{end}}
+				{{if .Deprecated}}<br /><small>Deprecated{{with .DeprecationMessage}}: {{.}}{{end}}</small>{{end}}
+			</td>
 		</tr>
 		{{end}}
 	</table>
@@ -170,6 +176,27 @@ var (
 		</tbody>
 		{{end}}
 	</table>
+	<h3>Middleware</h3>
+	<table>
+		<tr>
+			<th>Direction</th>
+			<th>RPC Type</th>
+			<th>Middleware</th>
+		</tr>
+		{{range .Middleware}}
+		<tr>
+			<td>{{.Direction}}</td>
+			<td>{{.RPCType}}</td>
+			<td>
+				<ol>
+				{{range .Middleware}}
+					<li>{{.}}</li>
+				{{end}}
+				</ol>
+			</td>
+		</tr>
+		{{end}}
+	</table>
 {{end}}
 	</body>
 </html>
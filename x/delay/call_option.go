@@ -0,0 +1,50 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package delay
+
+import (
+	"time"
+
+	"go.uber.org/yarpc"
+)
+
+const (
+	// _forHeader holds the duration by which a request is delayed.
+	_forHeader = "yarpc-delay-for"
+
+	// _untilHeader holds the time until which a request is delayed, in
+	// RFC 3339 format.
+	_untilHeader = "yarpc-delay-until"
+)
+
+// For delays sending a oneway request by the given duration.
+//
+// 	err := client.CallOneway(ctx, "reminder", body, delay.For(time.Hour))
+func For(d time.Duration) yarpc.CallOption {
+	return yarpc.WithHeader(_forHeader, d.String())
+}
+
+// Until delays sending a oneway request until the given time.
+//
+// 	err := client.CallOneway(ctx, "report", body, delay.Until(midnight))
+func Until(t time.Time) yarpc.CallOption {
+	return yarpc.WithHeader(_untilHeader, t.Format(time.RFC3339Nano))
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package delay provides a oneway outbound that holds requests back until a
+// later time, for retry-later and deferred-work patterns that would otherwise
+// need an external queue.
+//
+// Wrap a oneway outbound with NewOutbound and pass For or Until to the calls
+// that should be delayed. Calls without these options are sent right away.
+//
+// 	outbound := delay.NewOutbound(http.NewTransport().NewSingleOutbound(url))
+// 	...
+// 	err := client.CallOneway(ctx, "reminder", body, delay.For(time.Hour))
+//
+// The ack returned by a delayed call only indicates that the request was
+// scheduled. Delayed requests are held in memory and are lost when the
+// outbound is stopped, unless a Store is provided to persist them. Persisted
+// requests are scheduled again when the outbound is started with the same
+// store.
+//
+// Delayed requests are sent once. To retry requests that fail when they are
+// sent, wrap an outbound from the x/spool package.
+//
+// If the outbound for a call is not a delay outbound, the options have no
+// effect and the request is sent right away.
+package delay
@@ -0,0 +1,93 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package delay
+
+import (
+	"time"
+
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/x/spool"
+	"go.uber.org/zap"
+)
+
+const _defaultTimeout = 5 * time.Second
+
+// Option customizes the behavior of a delay Outbound.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	store   spool.Store
+	timeout time.Duration
+	logger  *zap.Logger
+	clock   clock.Clock
+}
+
+// Store specifies a store in which delayed requests are persisted until they
+// are sent, so that they survive process restarts.
+//
+// By default, delayed requests are only held in memory.
+func Store(store spool.Store) Option {
+	return optionFunc(func(opts *options) {
+		opts.store = store
+	})
+}
+
+// Timeout specifies the timeout for sending a delayed request once it is
+// due. Defaults to five seconds.
+func Timeout(timeout time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.timeout = timeout
+	})
+}
+
+// Logger specifies the logger that should be used to log.
+// Default value is noop zap logger.
+func Logger(logger *zap.Logger) Option {
+	return optionFunc(func(opts *options) {
+		opts.logger = logger
+	})
+}
+
+// withClock specifies the clock used to schedule requests.
+// It is only used for testing.
+func withClock(clock clock.Clock) Option {
+	return optionFunc(func(opts *options) {
+		opts.clock = clock
+	})
+}
+
+func applyOptions(opts ...Option) options {
+	options := options{
+		timeout: _defaultTimeout,
+		logger:  zap.NewNop(),
+		clock:   clock.NewReal(),
+	}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return options
+}
@@ -0,0 +1,272 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package delay
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/yarpc/x/spool"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+var _ transport.OnewayOutbound = (*Outbound)(nil)
+
+// Outbound is a oneway outbound that sends requests made with For or Until
+// through another oneway outbound once their delay has elapsed.
+type Outbound struct {
+	once *lifecycle.Once
+	out  transport.OnewayOutbound
+	opts options
+
+	seq     atomic.Uint64
+	mu      sync.Mutex
+	timers  map[string]clock.Timer
+	sending sync.WaitGroup
+}
+
+// NewOutbound builds a new delay Outbound which sends requests through the
+// given outbound.
+//
+// The returned Outbound manages the lifecycle of the wrapped outbound.
+func NewOutbound(out transport.OnewayOutbound, opts ...Option) *Outbound {
+	return &Outbound{
+		once:   lifecycle.NewOnce(),
+		out:    out,
+		opts:   applyOptions(opts...),
+		timers: make(map[string]clock.Timer),
+	}
+}
+
+// Transports returns the transports used by the wrapped outbound.
+func (o *Outbound) Transports() []transport.Transport {
+	return o.out.Transports()
+}
+
+// Start starts the wrapped outbound and schedules requests left in the store
+// by a previous process, if a store was provided.
+func (o *Outbound) Start() error {
+	return o.once.Start(o.start)
+}
+
+func (o *Outbound) start() error {
+	if err := o.out.Start(); err != nil {
+		return err
+	}
+	if o.opts.store == nil {
+		return nil
+	}
+
+	records, err := o.opts.store.List()
+	if err != nil {
+		return err
+	}
+	for _, r := range records {
+		o.schedule(r)
+	}
+	return nil
+}
+
+// Stop stops the wrapped outbound. Requests that have not been sent yet are
+// dropped, unless a store was provided, in which case they remain in the
+// store.
+func (o *Outbound) Stop() error {
+	return o.once.Stop(o.doStop)
+}
+
+func (o *Outbound) doStop() error {
+	o.mu.Lock()
+	for _, t := range o.timers {
+		t.Stop()
+	}
+	pending := len(o.timers)
+	o.timers = make(map[string]clock.Timer)
+	o.mu.Unlock()
+
+	if pending > 0 && o.opts.store == nil {
+		o.opts.logger.Warn("dropping delayed requests that have not been sent",
+			zap.Int("count", pending))
+	}
+
+	o.sending.Wait()
+	return o.out.Stop()
+}
+
+// IsRunning returns whether the outbound is running.
+func (o *Outbound) IsRunning() bool {
+	return o.once.IsRunning()
+}
+
+// CallOneway schedules requests made with For or Until and returns an ack as
+// soon as they have been scheduled. Other requests are sent right away.
+func (o *Outbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	if err := o.once.WaitUntilRunning(ctx); err != nil {
+		return nil, err
+	}
+
+	deliverAt, ok, err := o.deliverAt(req.Headers)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return o.out.CallOneway(ctx, req)
+	}
+
+	var body []byte
+	if req.Body != nil {
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	now := o.opts.clock.Now()
+	record := spool.Record{
+		ID:              fmt.Sprintf("%020d-%010d", now.UnixNano(), o.seq.Inc()),
+		Caller:          req.Caller,
+		Service:         req.Service,
+		Procedure:       req.Procedure,
+		Encoding:        string(req.Encoding),
+		ShardKey:        req.ShardKey,
+		RoutingKey:      req.RoutingKey,
+		RoutingDelegate: req.RoutingDelegate,
+		Body:            body,
+		CreatedAt:       now,
+		DeliverAt:       deliverAt,
+	}
+	for k, v := range req.Headers.Items() {
+		if k == _forHeader || k == _untilHeader {
+			continue
+		}
+		if record.Headers == nil {
+			record.Headers = make(map[string]string, req.Headers.Len())
+		}
+		record.Headers[k] = v
+	}
+
+	if o.opts.store != nil {
+		if err := o.opts.store.Put(record); err != nil {
+			return nil, yarpcerrors.InternalErrorf("failed to persist delayed request to %q: %v", req.Procedure, err)
+		}
+	}
+	o.schedule(record)
+	status := transport.AckStatusQueued
+	if o.opts.store != nil {
+		status = transport.AckStatusPersisted
+	}
+	return ack{id: record.ID, status: status}, nil
+}
+
+// deliverAt returns the time at which a request with the given headers should
+// be sent, if it was delayed.
+func (o *Outbound) deliverAt(headers transport.Headers) (time.Time, bool, error) {
+	if v, ok := headers.Get(_untilHeader); ok {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return time.Time{}, false, yarpcerrors.InvalidArgumentErrorf("invalid delay %q: %v", v, err)
+		}
+		return t, true, nil
+	}
+	if v, ok := headers.Get(_forHeader); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return time.Time{}, false, yarpcerrors.InvalidArgumentErrorf("invalid delay %q: %v", v, err)
+		}
+		return o.opts.clock.Now().Add(d), true, nil
+	}
+	return time.Time{}, false, nil
+}
+
+func (o *Outbound) schedule(r spool.Record) {
+	wait := r.DeliverAt.Sub(o.opts.clock.Now())
+	if wait < 0 {
+		wait = 0
+	}
+
+	// The lock is held while the timer is registered so that send cannot
+	// observe the timers before the record has been added.
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.timers[r.ID] = o.opts.clock.AfterFunc(wait, func() { o.send(r) })
+}
+
+func (o *Outbound) send(r spool.Record) {
+	o.mu.Lock()
+	if _, ok := o.timers[r.ID]; !ok {
+		// The outbound was stopped.
+		o.mu.Unlock()
+		return
+	}
+	delete(o.timers, r.ID)
+	o.sending.Add(1)
+	o.mu.Unlock()
+	defer o.sending.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), o.opts.timeout)
+	defer cancel()
+	if _, err := o.out.CallOneway(ctx, request(r)); err != nil {
+		o.opts.logger.Error("failed to send delayed request",
+			zap.String("procedure", r.Procedure), zap.Error(err))
+	}
+
+	if o.opts.store != nil {
+		if err := o.opts.store.Delete(r.ID); err != nil {
+			o.opts.logger.Error("failed to delete sent request from store",
+				zap.String("procedure", r.Procedure), zap.Error(err))
+		}
+	}
+}
+
+func request(r spool.Record) *transport.Request {
+	return &transport.Request{
+		Caller:          r.Caller,
+		Service:         r.Service,
+		Procedure:       r.Procedure,
+		Encoding:        transport.Encoding(r.Encoding),
+		Headers:         transport.HeadersFromMap(r.Headers),
+		ShardKey:        r.ShardKey,
+		RoutingKey:      r.RoutingKey,
+		RoutingDelegate: r.RoutingDelegate,
+		Body:            bytes.NewReader(r.Body),
+	}
+}
+
+// ack is returned for requests that have been scheduled.
+type ack struct {
+	id     string
+	status transport.AckStatus
+}
+
+func (a ack) String() string {
+	return "delayed:" + a.id
+}
+
+func (a ack) Status() transport.AckStatus {
+	return a.status
+}
@@ -0,0 +1,258 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package delay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiencoding "go.uber.org/yarpc/api/encoding"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/internal/testtime"
+	"go.uber.org/yarpc/x/spool"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type call struct {
+	procedure string
+	headers   map[string]string
+	body      string
+}
+
+// fakeOutbound records sent calls and fails if err is set.
+type fakeOutbound struct {
+	transport.Outbound
+
+	err   error
+	calls chan call
+}
+
+func newFakeOutbound() *fakeOutbound {
+	return &fakeOutbound{calls: make(chan call, 10)}
+}
+
+func (o *fakeOutbound) Start() error                      { return nil }
+func (o *fakeOutbound) Stop() error                       { return nil }
+func (o *fakeOutbound) Transports() []transport.Transport { return nil }
+
+func (o *fakeOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	o.calls <- call{procedure: req.Procedure, headers: req.Headers.Items(), body: string(body)}
+	return nil, o.err
+}
+
+func waitForCall(t *testing.T, out *fakeOutbound) call {
+	select {
+	case c := <-out.calls:
+		return c
+	case <-time.After(testtime.Second):
+		t.Fatal("timed out waiting for the request to be sent")
+		return call{}
+	}
+}
+
+func assertNoCall(t *testing.T, out *fakeOutbound) {
+	select {
+	case c := <-out.calls:
+		t.Fatalf("unexpected call: %v", c)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// newRequest builds a request with the given call options applied.
+func newRequest(t *testing.T, body string, opts ...apiencoding.CallOption) *transport.Request {
+	req := &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Procedure: "procedure",
+		Encoding:  "raw",
+		Body:      bytes.NewReader([]byte(body)),
+	}
+	opts = append(opts, apiencoding.WithHeader("foo", "bar"))
+	_, err := apiencoding.NewOutboundCall(opts...).WriteToRequest(context.Background(), req)
+	require.NoError(t, err)
+	return req
+}
+
+func callOneway(t *testing.T, o *Outbound, req *transport.Request) (transport.Ack, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+	defer cancel()
+	return o.CallOneway(ctx, req)
+}
+
+func TestCallWithoutDelay(t *testing.T) {
+	out := newFakeOutbound()
+	o := NewOutbound(out, withClock(clock.NewFake()))
+	require.NoError(t, o.Start())
+	defer o.Stop()
+
+	_, err := callOneway(t, o, newRequest(t, "hello"))
+	require.NoError(t, err)
+	assert.Equal(t, call{
+		procedure: "procedure",
+		headers:   map[string]string{"foo": "bar"},
+		body:      "hello",
+	}, waitForCall(t, out))
+}
+
+func TestCallDelayed(t *testing.T) {
+	tests := []struct {
+		desc string
+		opt  func(now time.Time) apiencoding.CallOption
+	}{
+		{
+			desc: "for",
+			opt: func(time.Time) apiencoding.CallOption {
+				return apiencoding.CallOption(For(time.Minute))
+			},
+		},
+		{
+			desc: "until",
+			opt: func(now time.Time) apiencoding.CallOption {
+				return apiencoding.CallOption(Until(now.Add(time.Minute)))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			clk := clock.NewFake()
+			out := newFakeOutbound()
+			o := NewOutbound(out, withClock(clk))
+			require.NoError(t, o.Start())
+			defer o.Stop()
+
+			ack, err := callOneway(t, o, newRequest(t, "hello", tt.opt(clk.Now())))
+			require.NoError(t, err)
+			assert.Contains(t, ack.String(), "delayed:")
+			assert.Equal(t, transport.AckStatusQueued, transport.AckStatusOf(ack))
+
+			clk.Add(59 * time.Second)
+			assertNoCall(t, out)
+
+			clk.Add(time.Second)
+			assert.Equal(t, call{
+				procedure: "procedure",
+				headers:   map[string]string{"foo": "bar"},
+				body:      "hello",
+			}, waitForCall(t, out), "delay headers must not be sent")
+		})
+	}
+}
+
+func TestCallInvalidDelay(t *testing.T) {
+	out := newFakeOutbound()
+	o := NewOutbound(out)
+	require.NoError(t, o.Start())
+	defer o.Stop()
+
+	for _, header := range []string{_forHeader, _untilHeader} {
+		req := newRequest(t, "hello", apiencoding.WithHeader(header, "soon"))
+		_, err := callOneway(t, o, req)
+		require.Error(t, err)
+		assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.FromError(err).Code())
+	}
+}
+
+func TestSendFailureIsNotRetried(t *testing.T) {
+	out := newFakeOutbound()
+	out.err = errors.New("great sadness")
+	clk := clock.NewFake()
+	store := spool.NewMemoryStore()
+	o := NewOutbound(out, Store(store), withClock(clk))
+	require.NoError(t, o.Start())
+	defer o.Stop()
+
+	_, err := callOneway(t, o, newRequest(t, "hello", apiencoding.CallOption(For(time.Second))))
+	require.NoError(t, err)
+
+	clk.Add(time.Second)
+	assert.Equal(t, "hello", waitForCall(t, out).body)
+	assertNoCall(t, out)
+	waitForEmptyStore(t, store)
+}
+
+func TestStopDropsPendingRequests(t *testing.T) {
+	out := newFakeOutbound()
+	clk := clock.NewFake()
+	o := NewOutbound(out, withClock(clk))
+	require.NoError(t, o.Start())
+
+	_, err := callOneway(t, o, newRequest(t, "hello", apiencoding.CallOption(For(time.Second))))
+	require.NoError(t, err)
+	require.NoError(t, o.Stop())
+
+	clk.Add(time.Second)
+	assertNoCall(t, out)
+}
+
+func TestStorePersistsPendingRequests(t *testing.T) {
+	clk := clock.NewFake()
+	store := spool.NewMemoryStore()
+
+	out := newFakeOutbound()
+	o := NewOutbound(out, Store(store), withClock(clk))
+	require.NoError(t, o.Start())
+	_, err := callOneway(t, o, newRequest(t, "hello", apiencoding.CallOption(For(time.Minute))))
+	require.NoError(t, err)
+	require.NoError(t, o.Stop())
+
+	records, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, clk.Now().Add(time.Minute), records[0].DeliverAt)
+	assert.Equal(t, map[string]string{"foo": "bar"}, records[0].Headers)
+
+	// A new outbound with the same store picks up where the old one left off.
+	out = newFakeOutbound()
+	o = NewOutbound(out, Store(store), withClock(clk))
+	require.NoError(t, o.Start())
+	defer o.Stop()
+
+	clk.Add(30 * time.Second)
+	assertNoCall(t, out)
+	clk.Add(30 * time.Second)
+	assert.Equal(t, "hello", waitForCall(t, out).body)
+	waitForEmptyStore(t, store)
+}
+
+func waitForEmptyStore(t *testing.T, store spool.Store) {
+	deadline := time.Now().Add(testtime.Second)
+	for time.Now().Before(deadline) {
+		records, err := store.List()
+		require.NoError(t, err)
+		if len(records) == 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("timed out waiting for the store to drain")
+}
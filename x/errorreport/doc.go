@@ -0,0 +1,50 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package errorreport sends panics and errors of handlers to error
+// reporting services, like Sentry or Bugsnag.
+//
+// A Reporter receives a Report for each panic or error, with the request it
+// happened on. The Middleware is unary, oneway and stream inbound middleware
+// that reports panics in handlers, and errors that handlers return with
+// server error codes. Reports are rate limited per procedure, so that a
+// failing dependency doesn't flood the reporting service.
+//
+// 	reports := errorreport.New(
+// 		errorreport.ReporterFunc(func(ctx context.Context, r *errorreport.Report) {
+// 			sentryClient.CaptureException(r.Err, r.Tags())
+// 		}),
+// 		errorreport.Headers("x-tenant"),
+// 		errorreport.Rate(1, 10),
+// 	)
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  reports,
+// 			Oneway: reports,
+// 			Stream: reports,
+// 		},
+// 	})
+//
+// The Middleware doesn't recover panics: it reports them and lets them
+// continue to the middleware or transport that recovers them, like the
+// recovery middleware of the standard package, which adds the Middleware
+// when its options specify a Reporter.
+package errorreport
@@ -0,0 +1,155 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package errorreport
+
+import (
+	"context"
+	"runtime/debug"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var (
+	_ middleware.UnaryInbound  = (*Middleware)(nil)
+	_ middleware.OnewayInbound = (*Middleware)(nil)
+	_ middleware.StreamInbound = (*Middleware)(nil)
+	_ middleware.Named         = (*Middleware)(nil)
+)
+
+// Middleware is unary, oneway and stream inbound middleware that reports
+// panics and errors of handlers to a Reporter.
+type Middleware struct {
+	reporter Reporter
+	opts     options
+	limiter  *limiter
+
+	reports *metrics.CounterVector
+}
+
+// New builds a new error reporting Middleware.
+func New(reporter Reporter, opts ...Option) *Middleware {
+	m := &Middleware{
+		reporter: reporter,
+		opts:     applyOptions(opts...),
+	}
+	m.limiter = newLimiter(m.opts.clock, m.opts.rate, m.opts.burst)
+	m.reports, _ = m.opts.meter.CounterVector(metrics.Spec{
+		Name:    "error_reports",
+		Help:    "Number of panics and errors of handlers reported, or dropped by rate limiting.",
+		VarTags: []string{"procedure", "kind", "result"},
+	})
+	return m
+}
+
+// MiddlewareName implements middleware.Named.
+func (m *Middleware) MiddlewareName() string { return "errorreport" }
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	defer m.reportPanic(ctx, req)
+	err := h.Handle(ctx, req, resw)
+	m.reportError(ctx, req, err)
+	return err
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	defer m.reportPanic(ctx, req)
+	err := h.HandleOneway(ctx, req)
+	m.reportError(ctx, req, err)
+	return err
+}
+
+// HandleStream implements middleware.StreamInbound.
+func (m *Middleware) HandleStream(s *transport.ServerStream, h transport.StreamHandler) error {
+	req := s.Request().Meta.ToRequest()
+	defer m.reportPanic(s.Context(), req)
+	err := h.HandleStream(s)
+	m.reportError(s.Context(), req, err)
+	return err
+}
+
+// reportPanic must be deferred directly by the handling methods for the
+// builtin recover to see the panic, which it reports and resumes.
+func (m *Middleware) reportPanic(ctx context.Context, req *transport.Request) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	if m.allow(req.Procedure, "panic") {
+		r := m.newReport(req)
+		r.Panic = p
+		r.Stack = debug.Stack()
+		r.Err = panicError(req.Procedure, p)
+		m.reporter.Report(ctx, r)
+	}
+	panic(p)
+}
+
+func (m *Middleware) reportError(ctx context.Context, req *transport.Request, err error) {
+	if err == nil {
+		return
+	}
+	if _, ok := m.opts.codes[yarpcerrors.FromError(err).Code()]; !ok {
+		return
+	}
+	if m.allow(req.Procedure, "error") {
+		r := m.newReport(req)
+		r.Err = err
+		m.reporter.Report(ctx, r)
+	}
+}
+
+// allow reports whether a report may be sent within the rate limit, and
+// counts it.
+func (m *Middleware) allow(procedure, kind string) bool {
+	allowed := m.limiter.allow(procedure)
+	result := "reported"
+	if !allowed {
+		result = "dropped"
+	}
+	if counter, err := m.reports.Get("procedure", procedure, "kind", kind, "result", result); err == nil {
+		counter.Inc()
+	}
+	return allowed
+}
+
+func (m *Middleware) newReport(req *transport.Request) *Report {
+	r := &Report{
+		Caller:    req.Caller,
+		Service:   req.Service,
+		Procedure: req.Procedure,
+		Encoding:  string(req.Encoding),
+		Transport: req.Transport,
+	}
+	for _, k := range m.opts.headers {
+		if v, ok := req.Headers.Get(k); ok {
+			if r.Headers == nil {
+				r.Headers = make(map[string]string, len(m.opts.headers))
+			}
+			r.Headers[k] = v
+		}
+	}
+	return r
+}
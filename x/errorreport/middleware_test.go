@@ -0,0 +1,130 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package errorreport
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type handlerFunc func() error
+
+func (f handlerFunc) Handle(context.Context, *transport.Request, transport.ResponseWriter) error {
+	return f()
+}
+
+func request() *transport.Request {
+	return &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Procedure: "echo",
+		Encoding:  "raw",
+		Headers:   transport.NewHeaders().With("x-tenant", "acme").With("authorization", "secret"),
+	}
+}
+
+func TestReportsErrors(t *testing.T) {
+	var reports []*Report
+	m := New(ReporterFunc(func(_ context.Context, r *Report) {
+		reports = append(reports, r)
+	}), Headers("x-tenant"))
+
+	tests := []struct {
+		err    error
+		report bool
+	}{
+		{err: nil},
+		{err: yarpcerrors.InvalidArgumentErrorf("bad request")},
+		{err: yarpcerrors.InternalErrorf("oops"), report: true},
+		{err: errors.New("unknown"), report: true},
+	}
+	for _, tt := range tests {
+		reports = nil
+		err := m.Handle(context.Background(), request(), nil, handlerFunc(func() error { return tt.err }))
+		assert.Equal(t, tt.err, err)
+		if !tt.report {
+			assert.Empty(t, reports, "unexpected report for %v", tt.err)
+			continue
+		}
+		require.Len(t, reports, 1, "expected report for %v", tt.err)
+		r := reports[0]
+		assert.Equal(t, tt.err, r.Err)
+		assert.Nil(t, r.Panic)
+		assert.Equal(t, "echo", r.Procedure)
+		assert.Equal(t, "caller", r.Caller)
+		assert.Equal(t, map[string]string{"x-tenant": "acme"}, r.Headers, "only selected headers must be reported")
+		assert.Equal(t, "acme", r.Tags()["header.x-tenant"])
+	}
+}
+
+func TestReportsPanics(t *testing.T) {
+	var reports []*Report
+	m := New(ReporterFunc(func(_ context.Context, r *Report) {
+		reports = append(reports, r)
+	}))
+
+	assert.PanicsWithValue(t, "great sadness", func() {
+		_ = m.Handle(context.Background(), request(), nil, handlerFunc(func() error { panic("great sadness") }))
+	}, "panics must be resumed")
+
+	require.Len(t, reports, 1)
+	assert.Equal(t, "great sadness", reports[0].Panic)
+	assert.NotEmpty(t, reports[0].Stack)
+	assert.Equal(t, yarpcerrors.CodeInternal, yarpcerrors.FromError(reports[0].Err).Code())
+	assert.Nil(t, reports[0].Headers)
+}
+
+func TestRateLimit(t *testing.T) {
+	fake := clock.NewFake()
+	root := metrics.New()
+	reported := 0
+	m := New(ReporterFunc(func(context.Context, *Report) { reported++ }),
+		Rate(1, 2), Metrics(root.Scope()), withClock(fake))
+
+	fail := handlerFunc(func() error { return yarpcerrors.InternalErrorf("oops") })
+	for i := 0; i < 5; i++ {
+		_ = m.Handle(context.Background(), request(), nil, fail)
+	}
+	assert.Equal(t, 2, reported, "expected a burst of reports")
+
+	fake.Add(time.Second)
+	for i := 0; i < 5; i++ {
+		_ = m.Handle(context.Background(), request(), nil, fail)
+	}
+	assert.Equal(t, 3, reported, "expected one more report after a second")
+
+	var dropped int64
+	for _, c := range root.Snapshot().Counters {
+		if c.Name == "error_reports" && c.Tags["result"] == "dropped" {
+			dropped = c.Value
+		}
+	}
+	assert.Equal(t, int64(7), dropped)
+}
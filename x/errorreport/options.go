@@ -0,0 +1,114 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package errorreport
+
+import (
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	_defaultRate  = 1
+	_defaultBurst = 10
+)
+
+var _defaultCodes = []yarpcerrors.Code{
+	yarpcerrors.CodeUnknown,
+	yarpcerrors.CodeInternal,
+	yarpcerrors.CodeDataLoss,
+}
+
+// Option customizes the behavior of a Middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	headers []string
+	codes   map[yarpcerrors.Code]struct{}
+	rate    float64
+	burst   int
+	meter   *metrics.Scope
+	clock   clock.Clock
+}
+
+// Headers specifies the request headers included in reports. By default,
+// no headers are included.
+func Headers(keys ...string) Option {
+	return optionFunc(func(opts *options) {
+		opts.headers = append(opts.headers, keys...)
+	})
+}
+
+// Codes specifies the codes of the errors that are reported. Errors that
+// aren't YARPC errors have the Unknown code. Defaults to Unknown, Internal
+// and DataLoss; panics are always reported.
+func Codes(codes ...yarpcerrors.Code) Option {
+	return optionFunc(func(opts *options) {
+		opts.codes = make(map[yarpcerrors.Code]struct{}, len(codes))
+		for _, c := range codes {
+			opts.codes[c] = struct{}{}
+		}
+	})
+}
+
+// Rate specifies how many reports per second, on average, are sent for
+// each procedure, and how many may be sent in a burst. Reports beyond the
+// rate are dropped and counted. Defaults to one per second in bursts of
+// ten.
+func Rate(perSecond float64, burst int) Option {
+	return optionFunc(func(opts *options) {
+		opts.rate = perSecond
+		opts.burst = burst
+	})
+}
+
+// Metrics specifies the scope to which metrics about reports are reported.
+// By default, no metrics are reported.
+func Metrics(meter *metrics.Scope) Option {
+	return optionFunc(func(opts *options) {
+		opts.meter = meter
+	})
+}
+
+func withClock(clock clock.Clock) Option {
+	return optionFunc(func(opts *options) {
+		opts.clock = clock
+	})
+}
+
+func applyOptions(opts ...Option) options {
+	options := options{
+		rate:  _defaultRate,
+		burst: _defaultBurst,
+		clock: clock.NewReal(),
+	}
+	Codes(_defaultCodes...).apply(&options)
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return options
+}
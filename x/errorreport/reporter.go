@@ -0,0 +1,91 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package errorreport
+
+import (
+	"context"
+
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// Reporter sends reports of panics and errors to a reporting service.
+//
+// Reporters are called on the request path and must not block for long;
+// reporters backed by remote services should send reports asynchronously.
+type Reporter interface {
+	Report(ctx context.Context, report *Report)
+}
+
+// ReporterFunc is a Reporter implemented as a function.
+type ReporterFunc func(ctx context.Context, report *Report)
+
+// Report implements Reporter.
+func (f ReporterFunc) Report(ctx context.Context, report *Report) {
+	f(ctx, report)
+}
+
+// Report describes a panic or error of a handler and the request it
+// happened on.
+type Report struct {
+	Caller    string
+	Service   string
+	Procedure string
+	Encoding  string
+	Transport string
+
+	// Headers holds the request headers selected with the Headers option.
+	// Other headers are left out, since they may hold credentials.
+	Headers map[string]string
+
+	// Panic is the value the handler panicked with, or nil if the handler
+	// returned an error.
+	Panic interface{}
+
+	// Stack is the stack trace of the panic, if the handler panicked.
+	Stack []byte
+
+	// Err is the error the handler returned, or an error describing the
+	// panic.
+	Err error
+}
+
+// Tags returns the request attributes of the report as a flat map, as most
+// reporting services accept them.
+func (r *Report) Tags() map[string]string {
+	tags := map[string]string{
+		"caller":    r.Caller,
+		"service":   r.Service,
+		"procedure": r.Procedure,
+		"encoding":  r.Encoding,
+		"transport": r.Transport,
+	}
+	if r.Err != nil {
+		tags["code"] = yarpcerrors.FromError(r.Err).Code().String()
+	}
+	for k, v := range r.Headers {
+		tags["header."+k] = v
+	}
+	return tags
+}
+
+func panicError(procedure string, p interface{}) error {
+	return yarpcerrors.InternalErrorf("handler for procedure %q panicked: %v", procedure, p)
+}
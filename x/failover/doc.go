@@ -0,0 +1,41 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package failover provides an outbound that fails over between an ordered
+// list of destinations, for example from the local cluster to a remote
+// region.
+//
+// 	outbound, err := failover.NewOutbound([]failover.Destination{
+// 		{Name: "local", Outbound: local},
+// 		{Name: "remote", Outbound: remote},
+// 	})
+//
+// Calls go to the first healthy destination. A destination becomes
+// unhealthy when a call to it fails with one of the failover error codes,
+// Unavailable by default, in which case the call is sent to the next
+// destination right away. Destinations whose outbounds choose peers from a
+// peer list without available peers are skipped.
+//
+// Unhealthy destinations are probed with live traffic: once the probe
+// interval has passed, the next call goes to the unhealthy destination
+// again, and calls fail back to it if it succeeds.
+//
+// The failover outbound starts and stops the outbounds of its destinations.
+package failover
@@ -0,0 +1,265 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package failover
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+var (
+	_ transport.UnaryOutbound  = (*Outbound)(nil)
+	_ transport.OnewayOutbound = (*Outbound)(nil)
+)
+
+// Destination is an outbound that calls fail over to or from.
+type Destination struct {
+	// Name identifies the destination in logs.
+	Name string
+
+	// Outbound receives the calls sent to this destination. Unary calls are
+	// only sent to destinations whose outbound is a transport.UnaryOutbound,
+	// and oneway calls to destinations whose outbound is a
+	// transport.OnewayOutbound.
+	Outbound transport.Outbound
+}
+
+// Outbound is an outbound that sends calls to the first healthy destination
+// of an ordered list.
+type Outbound struct {
+	once         *lifecycle.Once
+	opts         options
+	destinations []Destination
+
+	mu sync.Mutex
+	// Time at which each unhealthy destination failed, by index. Healthy
+	// destinations have the zero time.
+	failedAt []time.Time
+}
+
+// NewOutbound builds an Outbound that fails over between the given
+// destinations, in order of preference. Destinations must have unique,
+// non-empty names.
+func NewOutbound(destinations []Destination, opts ...Option) (*Outbound, error) {
+	if len(destinations) == 0 {
+		return nil, fmt.Errorf("failover outbound requires at least one destination")
+	}
+	names := make(map[string]struct{}, len(destinations))
+	for i, d := range destinations {
+		if d.Name == "" {
+			return nil, fmt.Errorf("failover outbound destination %d has no name", i)
+		}
+		if d.Outbound == nil {
+			return nil, fmt.Errorf("failover outbound destination %q has no outbound", d.Name)
+		}
+		if _, ok := names[d.Name]; ok {
+			return nil, fmt.Errorf("failover outbound has more than one destination named %q", d.Name)
+		}
+		names[d.Name] = struct{}{}
+	}
+
+	return &Outbound{
+		once:         lifecycle.NewOnce(),
+		opts:         applyOptions(opts...),
+		destinations: append([]Destination(nil), destinations...),
+		failedAt:     make([]time.Time, len(destinations)),
+	}, nil
+}
+
+// Transports returns the transports used by the outbounds of all
+// destinations.
+func (o *Outbound) Transports() []transport.Transport {
+	var transports []transport.Transport
+	for _, d := range o.destinations {
+		transports = append(transports, d.Outbound.Transports()...)
+	}
+	return transports
+}
+
+// Start starts the outbounds of all destinations.
+func (o *Outbound) Start() error {
+	return o.once.Start(o.start)
+}
+
+func (o *Outbound) start() error {
+	var errs error
+	for _, d := range o.destinations {
+		errs = multierr.Append(errs, d.Outbound.Start())
+	}
+	return errs
+}
+
+// Stop stops the outbounds of all destinations.
+func (o *Outbound) Stop() error {
+	return o.once.Stop(o.stop)
+}
+
+func (o *Outbound) stop() error {
+	var errs error
+	for _, d := range o.destinations {
+		errs = multierr.Append(errs, d.Outbound.Stop())
+	}
+	return errs
+}
+
+// IsRunning returns whether the outbound is running.
+func (o *Outbound) IsRunning() bool {
+	return o.once.IsRunning()
+}
+
+// Call sends a unary request to the first healthy destination that supports
+// unary calls, failing over to the next ones if it fails.
+func (o *Outbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	var res *transport.Response
+	err := o.failover(req, "unary", func(out transport.Outbound, r *transport.Request) (bool, error) {
+		unary, ok := out.(transport.UnaryOutbound)
+		if !ok {
+			return false, nil
+		}
+		var err error
+		res, err = unary.Call(ctx, r)
+		return true, err
+	})
+	return res, err
+}
+
+// CallOneway sends a oneway request to the first healthy destination that
+// supports oneway calls, failing over to the next ones if it fails.
+func (o *Outbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	var ack transport.Ack
+	err := o.failover(req, "oneway", func(out transport.Outbound, r *transport.Request) (bool, error) {
+		oneway, ok := out.(transport.OnewayOutbound)
+		if !ok {
+			return false, nil
+		}
+		var err error
+		ack, err = oneway.CallOneway(ctx, r)
+		return true, err
+	})
+	return ack, err
+}
+
+// failover calls the destinations in order until one of them succeeds or
+// fails with an error that does not cause a failover. The call function
+// reports whether the outbound of the destination supports the call.
+func (o *Outbound) failover(req *transport.Request, rpcType string, call func(transport.Outbound, *transport.Request) (bool, error)) error {
+	// The body is buffered so that it can be sent to every destination.
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return err
+		}
+	}
+
+	var lastErr error
+	for _, i := range o.order() {
+		d := o.destinations[i]
+		if !hasAvailablePeers(d.Outbound) {
+			continue
+		}
+
+		r := *req
+		r.Body = bytes.NewReader(body)
+		supported, err := call(d.Outbound, &r)
+		if !supported {
+			continue
+		}
+		if err == nil || !o.shouldFailover(err) {
+			o.markHealthy(i)
+			return err
+		}
+		o.markFailed(i, err)
+		lastErr = err
+	}
+	if lastErr != nil {
+		return lastErr
+	}
+	return yarpcerrors.UnavailableErrorf("failover outbound has no available destination for %s calls", rpcType)
+}
+
+// order returns the indexes of the destinations in the order they should be
+// tried: healthy destinations and unhealthy ones due for a probe first, in
+// order of preference, then the other unhealthy ones as a last resort.
+func (o *Outbound) order() []int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := o.opts.clock.Now()
+	order := make([]int, 0, len(o.destinations))
+	var unhealthy []int
+	for i, failedAt := range o.failedAt {
+		if failedAt.IsZero() || !now.Before(failedAt.Add(o.opts.probeInterval)) {
+			order = append(order, i)
+		} else {
+			unhealthy = append(unhealthy, i)
+		}
+	}
+	return append(order, unhealthy...)
+}
+
+func (o *Outbound) markHealthy(i int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if !o.failedAt[i].IsZero() {
+		o.failedAt[i] = time.Time{}
+		o.opts.logger.Info("failover destination recovered", zap.String("destination", o.destinations[i].Name))
+	}
+}
+
+func (o *Outbound) markFailed(i int, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	// A failed probe restarts the probe interval.
+	o.failedAt[i] = o.opts.clock.Now()
+	o.opts.logger.Warn("failing over from destination",
+		zap.String("destination", o.destinations[i].Name), zap.Error(err))
+}
+
+func (o *Outbound) shouldFailover(err error) bool {
+	_, ok := o.opts.codes[yarpcerrors.FromError(err).Code()]
+	return ok
+}
+
+// hasAvailablePeers reports whether the outbound may have peers to send calls
+// to. Outbounds are assumed to have peers unless they choose peers from a
+// list that reports having none available.
+func hasAvailablePeers(out transport.Outbound) bool {
+	co, ok := out.(interface{ Chooser() peer.Chooser })
+	if !ok {
+		return true
+	}
+	list, ok := co.Chooser().(interface{ NumAvailable() int })
+	return !ok || list.NumAvailable() > 0
+}
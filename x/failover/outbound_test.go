@@ -0,0 +1,227 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package failover
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type fakeOutbound struct {
+	running bool
+	err     error
+	calls   []string
+}
+
+func (o *fakeOutbound) Transports() []transport.Transport { return nil }
+func (o *fakeOutbound) Start() error                      { o.running = true; return nil }
+func (o *fakeOutbound) Stop() error                       { o.running = false; return nil }
+func (o *fakeOutbound) IsRunning() bool                   { return o.running }
+
+func (o *fakeOutbound) Call(_ context.Context, req *transport.Request) (*transport.Response, error) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	o.calls = append(o.calls, string(body))
+	if o.err != nil {
+		return nil, o.err
+	}
+	return &transport.Response{}, nil
+}
+
+type chooserOutbound struct {
+	fakeOutbound
+	chooser peer.Chooser
+}
+
+func (o *chooserOutbound) Chooser() peer.Chooser { return o.chooser }
+
+type emptyList struct{ peer.Chooser }
+
+func (emptyList) NumAvailable() int { return 0 }
+
+func call(o *Outbound, body string) error {
+	_, err := o.Call(context.Background(), &transport.Request{Body: strings.NewReader(body)})
+	return err
+}
+
+func TestNewOutboundErrors(t *testing.T) {
+	out := &fakeOutbound{}
+	tests := []struct {
+		desc         string
+		destinations []Destination
+		wantErr      string
+	}{
+		{
+			desc:    "no destinations",
+			wantErr: "requires at least one destination",
+		},
+		{
+			desc:         "no name",
+			destinations: []Destination{{Outbound: out}},
+			wantErr:      "destination 0 has no name",
+		},
+		{
+			desc:         "no outbound",
+			destinations: []Destination{{Name: "local"}},
+			wantErr:      `destination "local" has no outbound`,
+		},
+		{
+			desc: "duplicate name",
+			destinations: []Destination{
+				{Name: "local", Outbound: out},
+				{Name: "local", Outbound: out},
+			},
+			wantErr: `more than one destination named "local"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := NewOutbound(tt.destinations)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestFailoverAndFailback(t *testing.T) {
+	local := &fakeOutbound{err: yarpcerrors.UnavailableErrorf("down")}
+	remote := &fakeOutbound{}
+	clk := clock.NewFake()
+	o, err := NewOutbound([]Destination{
+		{Name: "local", Outbound: local},
+		{Name: "remote", Outbound: remote},
+	}, ProbeInterval(time.Minute), withClock(clk))
+	require.NoError(t, err)
+
+	require.NoError(t, call(o, "1"), "call should fail over")
+	assert.Equal(t, []string{"1"}, local.calls)
+	assert.Equal(t, []string{"1"}, remote.calls, "remote should receive the full body")
+
+	require.NoError(t, call(o, "2"))
+	assert.Equal(t, []string{"1"}, local.calls, "unhealthy destination should be skipped")
+
+	clk.Add(time.Minute)
+	require.NoError(t, call(o, "3"), "failed probe should fail over")
+	assert.Equal(t, []string{"1", "3"}, local.calls, "destination should be probed")
+
+	require.NoError(t, call(o, "4"))
+	assert.Equal(t, []string{"1", "3"}, local.calls, "failed probe should restart the interval")
+
+	local.err = nil
+	clk.Add(time.Minute)
+	require.NoError(t, call(o, "5"))
+	require.NoError(t, call(o, "6"))
+	assert.Equal(t, []string{"1", "3", "5", "6"}, local.calls, "calls should fail back")
+	assert.Equal(t, []string{"1", "2", "3", "4"}, remote.calls)
+}
+
+func TestFailoverCodes(t *testing.T) {
+	local := &fakeOutbound{err: yarpcerrors.InternalErrorf("broken")}
+	remote := &fakeOutbound{}
+	o, err := NewOutbound([]Destination{
+		{Name: "local", Outbound: local},
+		{Name: "remote", Outbound: remote},
+	})
+	require.NoError(t, err)
+
+	err = call(o, "a")
+	assert.Equal(t, yarpcerrors.CodeInternal, yarpcerrors.FromError(err).Code())
+	assert.Empty(t, remote.calls, "internal errors should not fail over by default")
+
+	o, err = NewOutbound([]Destination{
+		{Name: "local", Outbound: local},
+		{Name: "remote", Outbound: remote},
+	}, FailoverCodes(yarpcerrors.CodeInternal))
+	require.NoError(t, err)
+	require.NoError(t, call(o, "b"))
+	assert.Equal(t, []string{"b"}, remote.calls)
+}
+
+func TestAllDestinationsFail(t *testing.T) {
+	local := &fakeOutbound{err: yarpcerrors.UnavailableErrorf("local down")}
+	remote := &fakeOutbound{err: yarpcerrors.UnavailableErrorf("remote down")}
+	o, err := NewOutbound([]Destination{
+		{Name: "local", Outbound: local},
+		{Name: "remote", Outbound: remote},
+	})
+	require.NoError(t, err)
+
+	err = call(o, "a")
+	assert.Equal(t, yarpcerrors.UnavailableErrorf("remote down"), err)
+
+	err = call(o, "b")
+	assert.Equal(t, yarpcerrors.UnavailableErrorf("remote down"), err)
+	assert.Equal(t, []string{"a", "b"}, local.calls, "unhealthy destinations are a last resort")
+}
+
+func TestSkipEmptyPeerList(t *testing.T) {
+	local := &chooserOutbound{chooser: emptyList{}}
+	remote := &fakeOutbound{}
+	o, err := NewOutbound([]Destination{
+		{Name: "local", Outbound: local},
+		{Name: "remote", Outbound: remote},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, call(o, "a"))
+	assert.Empty(t, local.calls)
+	assert.Equal(t, []string{"a"}, remote.calls)
+}
+
+func TestNoOnewayDestination(t *testing.T) {
+	o, err := NewOutbound([]Destination{{Name: "local", Outbound: &fakeOutbound{}}})
+	require.NoError(t, err)
+
+	_, err = o.CallOneway(context.Background(), &transport.Request{})
+	assert.Equal(t, yarpcerrors.CodeUnavailable, yarpcerrors.FromError(err).Code())
+}
+
+func TestLifecycle(t *testing.T) {
+	local := &fakeOutbound{}
+	remote := &fakeOutbound{}
+	o, err := NewOutbound([]Destination{
+		{Name: "local", Outbound: local},
+		{Name: "remote", Outbound: remote},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, o.Start())
+	assert.True(t, o.IsRunning())
+	assert.True(t, local.running)
+	assert.True(t, remote.running)
+
+	require.NoError(t, o.Stop())
+	assert.False(t, o.IsRunning())
+	assert.False(t, local.running)
+	assert.False(t, remote.running)
+}
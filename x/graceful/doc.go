@@ -0,0 +1,43 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package graceful runs a Dispatcher until the process is asked to shut
+// down, then drains it gracefully.
+//
+// Serve starts the Dispatcher and blocks until the process receives SIGTERM
+// or SIGINT. It then marks the service as not ready, waits for a pre-stop
+// delay so that load balancers stop sending it new requests, and stops the
+// Dispatcher, which drains its inbounds.
+//
+// 	readiness := graceful.NewReadiness()
+// 	http.Handle("/health/ready", readiness)
+//
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{Name: "myservice"})
+// 	// ...register procedures...
+// 	if err := graceful.Serve(dispatcher,
+// 		graceful.WithReadiness(readiness),
+// 		graceful.PreStopDelay(5*time.Second),
+// 	); err != nil {
+// 		log.Fatal(err)
+// 	}
+//
+// A second signal received during the pre-stop delay stops the Dispatcher
+// right away.
+package graceful
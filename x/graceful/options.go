@@ -0,0 +1,116 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package graceful
+
+import (
+	"os"
+	"syscall"
+	"time"
+
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/zap"
+)
+
+// Option customizes the behavior of Serve.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	signals      []os.Signal
+	preStopDelay time.Duration
+	stopTimeout  time.Duration
+	readiness    *Readiness
+	logger       *zap.Logger
+	clock        clock.Clock
+
+	// signalc replaces the OS signals in tests.
+	signalc <-chan os.Signal
+}
+
+// Signals specifies the signals that make Serve shut down. Defaults to
+// SIGTERM and SIGINT.
+func Signals(signals ...os.Signal) Option {
+	return optionFunc(func(opts *options) {
+		opts.signals = signals
+	})
+}
+
+// PreStopDelay specifies how long Serve waits after marking the service as
+// not ready before it stops the Dispatcher, giving load balancers time to
+// stop sending new requests. Defaults to no delay.
+func PreStopDelay(d time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.preStopDelay = d
+	})
+}
+
+// StopTimeout specifies how long Serve waits for the Dispatcher to stop
+// before giving up and returning an error. Defaults to waiting forever.
+func StopTimeout(d time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.stopTimeout = d
+	})
+}
+
+// WithReadiness specifies a Readiness that Serve marks as ready once the
+// Dispatcher has started, and as not ready as soon as shutdown begins.
+func WithReadiness(r *Readiness) Option {
+	return optionFunc(func(opts *options) {
+		opts.readiness = r
+	})
+}
+
+// Logger specifies a logger for the progress of the shutdown. Defaults to
+// no logging.
+func Logger(logger *zap.Logger) Option {
+	return optionFunc(func(opts *options) {
+		opts.logger = logger
+	})
+}
+
+func withClock(clock clock.Clock) Option {
+	return optionFunc(func(opts *options) {
+		opts.clock = clock
+	})
+}
+
+func withSignals(c <-chan os.Signal) Option {
+	return optionFunc(func(opts *options) {
+		opts.signalc = c
+	})
+}
+
+func applyOptions(opts ...Option) options {
+	options := options{
+		signals: []os.Signal{syscall.SIGTERM, os.Interrupt},
+		logger:  zap.NewNop(),
+		clock:   clock.NewReal(),
+	}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return options
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package graceful
+
+import (
+	"net/http"
+
+	"go.uber.org/atomic"
+)
+
+// Readiness tracks whether a service is ready to receive requests. It is an
+// http.Handler that responds with 200 when the service is ready and 503
+// otherwise, suitable for load balancer and orchestrator readiness checks.
+//
+// A Readiness is not ready until Serve has started the Dispatcher, and is
+// no longer ready as soon as Serve starts shutting down.
+type Readiness struct {
+	ready atomic.Bool
+}
+
+var _ http.Handler = (*Readiness)(nil)
+
+// NewReadiness builds a new Readiness that is not ready.
+func NewReadiness() *Readiness {
+	return &Readiness{}
+}
+
+// Ready reports whether the service is ready to receive requests.
+func (r *Readiness) Ready() bool {
+	return r.ready.Load()
+}
+
+// ServeHTTP implements http.Handler.
+func (r *Readiness) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	if r.Ready() {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ready\n"))
+		return
+	}
+	w.WriteHeader(http.StatusServiceUnavailable)
+	_, _ = w.Write([]byte("not ready\n"))
+}
+
+func (r *Readiness) set(ready bool) {
+	if r != nil {
+		r.ready.Store(ready)
+	}
+}
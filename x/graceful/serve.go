@@ -0,0 +1,94 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package graceful
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+
+	"go.uber.org/yarpc"
+	"go.uber.org/zap"
+)
+
+// Serve starts the Dispatcher and blocks until the process receives one of
+// the shutdown signals. It then marks the service as not ready, waits for
+// the pre-stop delay, and stops the Dispatcher.
+//
+// Serve returns the error that prevented the Dispatcher from starting or
+// stopping, if any.
+func Serve(d *yarpc.Dispatcher, opts ...Option) error {
+	o := applyOptions(opts...)
+
+	// Signals are subscribed to before starting so that signals received
+	// during startup are not lost.
+	signals := o.signalc
+	if signals == nil {
+		c := make(chan os.Signal, 2)
+		signal.Notify(c, o.signals...)
+		defer signal.Stop(c)
+		signals = c
+	}
+
+	if err := d.Start(); err != nil {
+		return err
+	}
+	o.readiness.set(true)
+	o.logger.Info("dispatcher started, waiting for a shutdown signal")
+
+	sig := <-signals
+	o.logger.Info("received shutdown signal", zap.Stringer("signal", sig))
+	o.readiness.set(false)
+
+	if o.preStopDelay > 0 {
+		o.logger.Info("waiting before stopping the dispatcher", zap.Duration("delay", o.preStopDelay))
+		select {
+		case <-o.clock.After(o.preStopDelay):
+		case sig := <-signals:
+			o.logger.Info("received another shutdown signal, stopping now", zap.Stringer("signal", sig))
+		}
+	}
+
+	o.logger.Info("stopping dispatcher")
+	if err := stop(d, o); err != nil {
+		return err
+	}
+	o.logger.Info("dispatcher stopped")
+	return nil
+}
+
+func stop(d *yarpc.Dispatcher, o options) error {
+	if o.stopTimeout <= 0 {
+		return d.Stop()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- d.Stop()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-o.clock.After(o.stopTimeout):
+		return fmt.Errorf("dispatcher %q did not stop within %v", d.Name(), o.stopTimeout)
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package graceful
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+)
+
+// inbound is a transport.Inbound that records whether it runs.
+type inbound struct {
+	running  atomic.Bool
+	startErr error
+	stopped  chan struct{}
+}
+
+func newInbound() *inbound {
+	return &inbound{stopped: make(chan struct{})}
+}
+
+func (i *inbound) Start() error {
+	if i.startErr != nil {
+		return i.startErr
+	}
+	i.running.Store(true)
+	return nil
+}
+
+func (i *inbound) Stop() error {
+	<-i.stopped
+	i.running.Store(false)
+	return nil
+}
+
+func (i *inbound) IsRunning() bool                   { return i.running.Load() }
+func (i *inbound) Transports() []transport.Transport { return nil }
+func (i *inbound) SetRouter(transport.Router)        {}
+
+func newDispatcher(i *inbound) *yarpc.Dispatcher {
+	return yarpc.NewDispatcher(yarpc.Config{
+		Name:     "test",
+		Inbounds: yarpc.Inbounds{i},
+	})
+}
+
+func waitFor(t *testing.T, cond func() bool, msg string) {
+	for n := 0; n < 100; n++ {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal(msg)
+}
+
+func TestServe(t *testing.T) {
+	fake := clock.NewFake()
+	readiness := NewReadiness()
+	signals := make(chan os.Signal, 2)
+	in := newInbound()
+	close(in.stopped)
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- Serve(newDispatcher(in),
+			WithReadiness(readiness),
+			PreStopDelay(5*time.Second),
+			withClock(fake),
+			withSignals(signals),
+		)
+	}()
+
+	waitFor(t, readiness.Ready, "service must become ready")
+	assert.True(t, in.IsRunning())
+
+	signals <- syscall.SIGTERM
+	waitFor(t, func() bool { return !readiness.Ready() }, "service must stop being ready")
+
+	// Advance the clock until Serve returns; the inbound must keep running
+	// for the whole pre-stop delay.
+	var elapsed time.Duration
+	for {
+		select {
+		case err := <-errc:
+			require.NoError(t, err)
+			assert.True(t, elapsed >= 5*time.Second, "stopped after %v", elapsed)
+			assert.False(t, in.IsRunning())
+			return
+		case <-time.After(10 * time.Millisecond):
+			assert.True(t, in.IsRunning(), "inbound stopped after %v", elapsed)
+			fake.Add(time.Second)
+			elapsed += time.Second
+		}
+	}
+}
+
+func TestServeSecondSignal(t *testing.T) {
+	signals := make(chan os.Signal, 2)
+	signals <- syscall.SIGTERM
+	signals <- syscall.SIGINT
+	in := newInbound()
+	close(in.stopped)
+
+	err := Serve(newDispatcher(in), PreStopDelay(time.Hour), withClock(clock.NewFake()), withSignals(signals))
+	require.NoError(t, err)
+	assert.False(t, in.IsRunning())
+}
+
+func TestServeStartError(t *testing.T) {
+	readiness := NewReadiness()
+	in := newInbound()
+	in.startErr = errors.New("great sadness")
+
+	err := Serve(newDispatcher(in), WithReadiness(readiness), withSignals(make(chan os.Signal)))
+	assert.EqualError(t, err, "great sadness")
+	assert.False(t, readiness.Ready())
+}
+
+func TestServeStopTimeout(t *testing.T) {
+	fake := clock.NewFake()
+	signals := make(chan os.Signal, 1)
+	signals <- syscall.SIGTERM
+	in := newInbound()
+	defer close(in.stopped)
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- Serve(newDispatcher(in), StopTimeout(time.Second), withClock(fake), withSignals(signals))
+	}()
+
+	for {
+		select {
+		case err := <-errc:
+			assert.EqualError(t, err, `dispatcher "test" did not stop within 1s`)
+			return
+		case <-time.After(10 * time.Millisecond):
+			fake.Add(time.Second)
+		}
+	}
+}
+
+func TestReadinessHandler(t *testing.T) {
+	r := NewReadiness()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	r.set(true)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ready\n", w.Body.String())
+}
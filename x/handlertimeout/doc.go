@@ -0,0 +1,42 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package handlertimeout provides inbound middleware that enforces the
+// deadline of unary requests on the server.
+//
+// The middleware cancels the context of the handler at the deadline of the
+// request and fails the request with a DeadlineExceeded error right away,
+// even if the handler has not returned yet. Anything the handler writes to
+// the response afterwards is discarded.
+//
+// Handlers that ignore the cancellation of their context keep running past
+// the deadline. The middleware watches for them: handlers that are still
+// running a grace period after the deadline are reported with the
+// handler_deadline_ignored metric, and the number of such handlers currently
+// running with the handler_running_past_deadline gauge.
+//
+// 	timeouts := handlertimeout.New(handlertimeout.Metrics(scope))
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary: timeouts,
+// 		},
+// 	})
+package handlertimeout
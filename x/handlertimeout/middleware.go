@@ -0,0 +1,226 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handlertimeout
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var (
+	_ middleware.UnaryInbound = (*Middleware)(nil)
+	_ middleware.Named        = (*Middleware)(nil)
+)
+
+// Middleware is unary inbound middleware that fails requests whose handlers
+// do not return before the deadline of the request.
+type Middleware struct {
+	opts options
+
+	timeouts *metrics.CounterVector
+	ignored  *metrics.CounterVector
+	running  *metrics.Gauge
+}
+
+// New builds a new handler timeout Middleware.
+func New(opts ...Option) *Middleware {
+	m := &Middleware{opts: applyOptions(opts...)}
+	meter := m.opts.meter
+	m.timeouts, _ = meter.CounterVector(metrics.Spec{
+		Name:    "handler_timeouts",
+		Help:    "Number of requests whose handler did not return before the deadline.",
+		VarTags: []string{"procedure"},
+	})
+	m.ignored, _ = meter.CounterVector(metrics.Spec{
+		Name:    "handler_deadline_ignored",
+		Help:    "Number of handlers still running a grace period after the deadline of their request.",
+		VarTags: []string{"procedure"},
+	})
+	m.running, _ = meter.Gauge(metrics.Spec{
+		Name: "handler_running_past_deadline",
+		Help: "Number of handlers currently running past the deadline of their request and its grace period.",
+	})
+	return m
+}
+
+// MiddlewareName implements middleware.Named.
+func (m *Middleware) MiddlewareName() string { return "handlertimeout" }
+
+// Handle implements middleware.UnaryInbound. Requests without a deadline are
+// passed through to the handler.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return h.Handle(ctx, req, resw)
+	}
+
+	// The handler gets a context that only the middleware cancels, after it
+	// has abandoned the writer, so that handlers cannot write after
+	// observing the cancellation. The request context may be cancelled
+	// first, for example by the transport when the deadline passes.
+	hctx := newHandlerContext(ctx, deadline)
+	w := &writer{w: resw}
+	timer := m.opts.clock.AfterFunc(deadline.Sub(m.opts.clock.Now()), func() {
+		w.abandon()
+		hctx.cancel(context.DeadlineExceeded)
+	})
+
+	done := make(chan struct{})
+	var err error
+	go func() {
+		defer close(done)
+		err = h.Handle(hctx, req, w)
+	}()
+
+	select {
+	case <-done:
+		if timer.Stop() {
+			hctx.cancel(context.Canceled)
+			return err
+		}
+		// The handler returned, but only after the deadline.
+	case <-hctx.Done():
+	case <-ctx.Done():
+		timer.Stop()
+		w.abandon()
+		hctx.cancel(ctx.Err())
+	}
+
+	w.abandon()
+	inc(m.timeouts, req.Procedure)
+	// The grace timer is created before returning so that it starts at the
+	// deadline.
+	go m.watch(req.Procedure, done, m.opts.clock.Timer(m.opts.grace))
+
+	return yarpcerrors.DeadlineExceededErrorf(
+		"handler of procedure %q of service %q did not return before the deadline", req.Procedure, req.Service)
+}
+
+// watch reports handlers that are still running a grace period after the
+// deadline of their request.
+func (m *Middleware) watch(procedure string, done <-chan struct{}, grace clock.Timer) {
+	select {
+	case <-done:
+		grace.Stop()
+		return
+	case <-grace.C():
+	}
+
+	inc(m.ignored, procedure)
+	m.running.Inc()
+	<-done
+	m.running.Dec()
+}
+
+func inc(cv *metrics.CounterVector, procedure string) {
+	if counter, err := cv.Get("procedure", procedure); err == nil {
+		counter.Inc()
+	}
+}
+
+// writer is a ResponseWriter that discards writes once the request has
+// timed out, since the transport may have already reused the underlying
+// ResponseWriter by then.
+type writer struct {
+	mu        sync.Mutex
+	w         transport.ResponseWriter
+	abandoned bool
+}
+
+func (w *writer) abandon() {
+	w.mu.Lock()
+	w.abandoned = true
+	w.mu.Unlock()
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.abandoned {
+		return len(p), nil
+	}
+	return w.w.Write(p)
+}
+
+func (w *writer) AddHeaders(headers transport.Headers) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.abandoned {
+		w.w.AddHeaders(headers)
+	}
+}
+
+func (w *writer) SetApplicationError() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.abandoned {
+		w.w.SetApplicationError()
+	}
+}
+
+// StreamResponse implements transport.StreamingResponseWriter.
+func (w *writer) StreamResponse() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return !w.abandoned && transport.StreamResponse(w.w)
+}
+
+// handlerContext carries the values and the deadline of a request context,
+// but is only cancelled by the middleware.
+type handlerContext struct {
+	context.Context
+
+	deadline time.Time
+	done     chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+func newHandlerContext(ctx context.Context, deadline time.Time) *handlerContext {
+	return &handlerContext{Context: ctx, deadline: deadline, done: make(chan struct{})}
+}
+
+func (c *handlerContext) cancel(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err == nil {
+		c.err = err
+		close(c.done)
+	}
+}
+
+func (c *handlerContext) Deadline() (time.Time, bool) { return c.deadline, true }
+
+func (c *handlerContext) Done() <-chan struct{} { return c.done }
+
+func (c *handlerContext) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
@@ -0,0 +1,215 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handlertimeout
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type handlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f handlerFunc) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	return f(ctx, req, resw)
+}
+
+type responseWriter struct {
+	bytes.Buffer
+
+	headers  transport.Headers
+	appError bool
+}
+
+func (w *responseWriter) AddHeaders(h transport.Headers) { w.headers = h }
+func (w *responseWriter) SetApplicationError()           { w.appError = true }
+
+func value(snapshots []metrics.Snapshot, name string) int64 {
+	for _, s := range snapshots {
+		if s.Name == name {
+			return s.Value
+		}
+	}
+	return 0
+}
+
+func TestHandlerReturnsInTime(t *testing.T) {
+	root := metrics.New()
+	m := New(Metrics(root.Scope()), withClock(clock.NewFake()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	var w responseWriter
+	err := m.Handle(ctx, &transport.Request{Procedure: "echo"}, &w,
+		handlerFunc(func(ctx context.Context, _ *transport.Request, resw transport.ResponseWriter) error {
+			resw.AddHeaders(transport.NewHeaders().With("foo", "bar"))
+			resw.SetApplicationError()
+			_, err := resw.Write([]byte("hello"))
+			return err
+		}))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", w.String())
+	assert.Equal(t, transport.NewHeaders().With("foo", "bar"), w.headers)
+	assert.True(t, w.appError)
+	assert.Equal(t, int64(0), value(root.Snapshot().Counters, "handler_timeouts"))
+}
+
+func TestHandlerWithoutDeadline(t *testing.T) {
+	m := New()
+	var w responseWriter
+	err := m.Handle(context.Background(), &transport.Request{}, &w,
+		handlerFunc(func(ctx context.Context, _ *transport.Request, _ transport.ResponseWriter) error {
+			_, ok := ctx.Deadline()
+			assert.False(t, ok)
+			return yarpcerrors.InternalErrorf("great sadness")
+		}))
+	assert.Equal(t, yarpcerrors.InternalErrorf("great sadness"), err)
+}
+
+func TestHandlerTimeout(t *testing.T) {
+	tests := []struct {
+		desc        string
+		ignoreFor   time.Duration
+		wantIgnored int64
+	}{
+		{desc: "respects cancellation"},
+		{desc: "returns within grace", ignoreFor: time.Second},
+		{desc: "ignores cancellation", ignoreFor: 3 * time.Second, wantIgnored: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			fake := clock.NewFake()
+			root := metrics.New()
+			m := New(Metrics(root.Scope()), Grace(2*time.Second), withClock(fake))
+
+			deadline := time.Now().Add(time.Hour)
+			ctx, cancel := context.WithDeadline(context.Background(), deadline)
+			defer cancel()
+
+			release := make(chan struct{})
+			returned := make(chan struct{})
+			handler := handlerFunc(func(ctx context.Context, _ *transport.Request, resw transport.ResponseWriter) error {
+				defer close(returned)
+				if tt.ignoreFor == 0 {
+					<-ctx.Done()
+				} else {
+					<-release
+				}
+				_, err := resw.Write([]byte("too late"))
+				return err
+			})
+
+			errs := make(chan error)
+			var w responseWriter
+			go func() {
+				errs <- m.Handle(ctx, &transport.Request{Service: "svc", Procedure: "echo"}, &w, handler)
+			}()
+
+			// Wait for the deadline timer to be scheduled before expiring it.
+			time.Sleep(10 * time.Millisecond)
+			fake.Set(deadline)
+			err := <-errs
+			assert.True(t, yarpcerrors.IsDeadlineExceeded(err), "unexpected error %v", err)
+
+			fake.Add(tt.ignoreFor)
+			close(release)
+			<-returned
+			fake.Add(2 * time.Second)
+
+			// Wait for the watch goroutine to observe the handler.
+			time.Sleep(10 * time.Millisecond)
+			snap := root.Snapshot()
+			assert.Equal(t, int64(1), value(snap.Counters, "handler_timeouts"))
+			assert.Equal(t, tt.wantIgnored, value(snap.Counters, "handler_deadline_ignored"))
+			assert.Equal(t, int64(0), value(snap.Gauges, "handler_running_past_deadline"))
+			assert.Empty(t, w.String(), "writes after the deadline must be discarded")
+		})
+	}
+}
+
+func TestRequestContextCancelledFirst(t *testing.T) {
+	// The deadline timer of the middleware never fires, so the request
+	// context is cancelled first, as when the transport enforces the deadline.
+	m := New(withClock(clock.NewFake()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	returned := make(chan struct{})
+	var w responseWriter
+	errs := make(chan error)
+	go func() {
+		errs <- m.Handle(ctx, &transport.Request{Service: "svc", Procedure: "echo"}, &w,
+			handlerFunc(func(ctx context.Context, _ *transport.Request, resw transport.ResponseWriter) error {
+				defer close(returned)
+				deadline, ok := ctx.Deadline()
+				assert.True(t, ok)
+				assert.False(t, deadline.IsZero())
+				<-ctx.Done()
+				assert.Equal(t, context.Canceled, ctx.Err())
+				_, err := resw.Write([]byte("too late"))
+				return err
+			}))
+	}()
+	cancel()
+
+	err := <-errs
+	assert.True(t, yarpcerrors.IsDeadlineExceeded(err), "unexpected error %v", err)
+	<-returned
+	assert.Empty(t, w.String(), "handlers must not write after observing the cancellation")
+}
+
+type streamingResponseWriter struct {
+	responseWriter
+
+	streaming bool
+}
+
+func (w *streamingResponseWriter) StreamResponse() bool {
+	w.streaming = true
+	return true
+}
+
+func TestStreamResponse(t *testing.T) {
+	m := New(withClock(clock.NewFake()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	var w streamingResponseWriter
+	err := m.Handle(ctx, &transport.Request{Procedure: "echo"}, &w,
+		handlerFunc(func(ctx context.Context, _ *transport.Request, resw transport.ResponseWriter) error {
+			assert.True(t, transport.StreamResponse(resw))
+			_, err := resw.Write([]byte("hello"))
+			return err
+		}))
+	require.NoError(t, err)
+	assert.True(t, w.streaming)
+	assert.Equal(t, "hello", w.String())
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package killswitch disables procedures, or puts a service in read-only
+// mode, while it's running.
+//
+// During incidents and migrations, operators need to stop some requests
+// without deploying: a procedure that corrupts data, a write path whose
+// database is being migrated. The Middleware is unary, oneway and stream
+// inbound middleware that rejects requests to disabled procedures with an
+// Unimplemented error, or the error code a rule specifies, and in read-only
+// mode rejects requests to procedures that aren't read-only with a
+// FailedPrecondition error.
+//
+// 	switches := killswitch.New(killswitch.ReadOnlyProcedures("KeyValue::getValue"))
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  switches,
+// 			Oneway: switches,
+// 			Stream: switches,
+// 		},
+// 	})
+//
+// Procedures registered as idempotent for the service of a request with
+// yarpc.RegisterIdempotentProcedures, like procedures with Idempotent
+// metadata registered with the Dispatcher, are read-only too.
+//
+// The State of the switches is replaced with SetState, with Reload from YAML
+// or JSON configuration, for example when a configuration file changes, or
+// over HTTP by mounting the handler returned by Handler on an admin server:
+//
+// 	mux.Handle("/killswitch", switches.Handler())
+//
+// 	$ curl -X PUT -d '{"disabled": [{"procedure": "KeyValue::setValue"}]}' localhost:8080/killswitch
+// 	$ curl -X PUT -d '{"readOnly": true}' localhost:8080/killswitch
+//
+// Procedures of rules may end with "*" to match all procedures starting
+// with what precedes it, like "KeyValue::*".
+package killswitch
@@ -0,0 +1,157 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package killswitch
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var (
+	_ middleware.UnaryInbound  = (*Middleware)(nil)
+	_ middleware.OnewayInbound = (*Middleware)(nil)
+	_ middleware.StreamInbound = (*Middleware)(nil)
+	_ middleware.Named         = (*Middleware)(nil)
+)
+
+// Middleware is unary, oneway and stream inbound middleware that rejects
+// requests to disabled procedures, and to procedures that aren't read-only
+// in read-only mode.
+type Middleware struct {
+	opts options
+
+	// rules holds a *rules, which is replaced as a whole so that requests
+	// read it without locking.
+	rules atomic.Value
+
+	rejected *metrics.CounterVector
+}
+
+// New builds a new kill switch Middleware. It panics if the initial state is
+// invalid.
+func New(opts ...Option) *Middleware {
+	m := &Middleware{opts: applyOptions(opts...)}
+	if err := m.SetState(m.opts.state); err != nil {
+		panic(err)
+	}
+	m.rejected, _ = m.opts.meter.CounterVector(metrics.Spec{
+		Name:    "killswitch_rejected",
+		Help:    "Number of requests rejected by kill switches.",
+		VarTags: []string{"procedure", "reason"},
+	})
+	return m
+}
+
+// MiddlewareName implements middleware.Named.
+func (m *Middleware) MiddlewareName() string { return "killswitch" }
+
+// State returns the current state of the switches.
+func (m *Middleware) State() State {
+	return m.rules.Load().(*rules).state
+}
+
+// SetState replaces the state of the switches. Requests that already passed
+// the Middleware are not affected.
+func (m *Middleware) SetState(state State) error {
+	if err := state.validate(); err != nil {
+		return err
+	}
+	state.Disabled = append([]Rule(nil), state.Disabled...)
+	m.rules.Store(newRules(state))
+	return nil
+}
+
+// Reload replaces the state of the switches with the State parsed from the
+// given YAML or JSON. See ParseState.
+func (m *Middleware) Reload(data []byte) error {
+	state, err := ParseState(data)
+	if err != nil {
+		return err
+	}
+	return m.SetState(state)
+}
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	if err := m.check(req.Service, req.Procedure); err != nil {
+		return err
+	}
+	return h.Handle(ctx, req, resw)
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	if err := m.check(req.Service, req.Procedure); err != nil {
+		return err
+	}
+	return h.HandleOneway(ctx, req)
+}
+
+// HandleStream implements middleware.StreamInbound.
+func (m *Middleware) HandleStream(s *transport.ServerStream, h transport.StreamHandler) error {
+	meta := s.Request().Meta
+	if err := m.check(meta.Service, meta.Procedure); err != nil {
+		return err
+	}
+	return h.HandleStream(s)
+}
+
+// check returns the error with which requests to the procedure are
+// rejected, if they are.
+func (m *Middleware) check(service, procedure string) error {
+	r := m.rules.Load().(*rules)
+	if rule := r.match(procedure); rule != nil {
+		m.count(procedure, "disabled")
+		code := rule.Code
+		if code == yarpcerrors.CodeOK {
+			code = yarpcerrors.CodeUnimplemented
+		}
+		if rule.Message != "" {
+			return yarpcerrors.Newf(code, "%s", rule.Message)
+		}
+		return yarpcerrors.Newf(code, "procedure %q of service %q is disabled", procedure, service)
+	}
+	if r.state.ReadOnly && !m.isReadOnly(service, procedure) {
+		m.count(procedure, "read_only")
+		return yarpcerrors.FailedPreconditionErrorf(
+			"service %q is read-only, procedure %q is not available", service, procedure)
+	}
+	return nil
+}
+
+func (m *Middleware) isReadOnly(service, procedure string) bool {
+	if _, ok := m.opts.readOnly[procedure]; ok {
+		return true
+	}
+	return yarpc.IsIdempotentProcedure(service, procedure)
+}
+
+func (m *Middleware) count(procedure, reason string) {
+	if counter, err := m.rejected.Get("procedure", procedure, "reason", reason); err == nil {
+		counter.Inc()
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package killswitch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type nopHandler struct{}
+
+func (nopHandler) Handle(context.Context, *transport.Request, transport.ResponseWriter) error {
+	return nil
+}
+
+func handle(m *Middleware, procedure string) error {
+	return m.Handle(context.Background(), &transport.Request{Service: "kv", Procedure: procedure}, nil, nopHandler{})
+}
+
+func TestDisabled(t *testing.T) {
+	root := metrics.New()
+	m := New(Metrics(root.Scope()), InitialState(State{Disabled: []Rule{
+		{Procedure: "KeyValue::setValue"},
+		{Procedure: "Admin::*", Code: yarpcerrors.CodeUnavailable, Message: "admin is paused"},
+	}}))
+
+	err := handle(m, "KeyValue::setValue")
+	assert.Equal(t, yarpcerrors.CodeUnimplemented, yarpcerrors.FromError(err).Code())
+	assert.Contains(t, err.Error(), `procedure "KeyValue::setValue" of service "kv" is disabled`)
+
+	err = handle(m, "Admin::flush")
+	assert.Equal(t, yarpcerrors.CodeUnavailable, yarpcerrors.FromError(err).Code())
+	assert.Equal(t, "admin is paused", yarpcerrors.FromError(err).Message())
+
+	assert.NoError(t, handle(m, "KeyValue::getValue"))
+
+	var rejected int64
+	for _, c := range root.Snapshot().Counters {
+		if c.Name == "killswitch_rejected" {
+			rejected += c.Value
+		}
+	}
+	assert.Equal(t, int64(2), rejected)
+}
+
+func TestReadOnly(t *testing.T) {
+	defer yarpc.RegisterIdempotentProcedures("kv", "KeyValue::listValues")()
+	defer yarpc.RegisterIdempotentProcedures("other", "KeyValue::setValue")()
+	m := New(ReadOnlyProcedures("KeyValue::getValue"))
+
+	require.NoError(t, m.SetState(State{ReadOnly: true}))
+	assert.NoError(t, handle(m, "KeyValue::getValue"))
+	assert.NoError(t, handle(m, "KeyValue::listValues"), "idempotent procedures are read-only")
+	err := handle(m, "KeyValue::setValue")
+	assert.Equal(t, yarpcerrors.CodeFailedPrecondition, yarpcerrors.FromError(err).Code(),
+		"procedures idempotent for other services are not read-only")
+
+	require.NoError(t, m.SetState(State{}))
+	assert.NoError(t, handle(m, "KeyValue::setValue"))
+}
+
+func TestReload(t *testing.T) {
+	m := New()
+
+	require.NoError(t, m.Reload([]byte(`
+disabled:
+  - procedure: KeyValue::setValue
+    code: unavailable
+readOnly: true
+`)))
+	assert.Equal(t, State{
+		Disabled: []Rule{{Procedure: "KeyValue::setValue", Code: yarpcerrors.CodeUnavailable}},
+		ReadOnly: true,
+	}, m.State())
+
+	for _, invalid := range []string{
+		`disabled: [{code: unavailable}]`,
+		`disabled: [{procedure: "Key*::get"}]`,
+		`disabled: [{procedure: foo, code: sad}]`,
+		`unknown: true`,
+	} {
+		assert.Error(t, m.Reload([]byte(invalid)), "expected %q to be invalid", invalid)
+	}
+	assert.True(t, m.State().ReadOnly, "invalid states must not be applied")
+}
+
+func TestHandler(t *testing.T) {
+	m := New()
+	server := httptest.NewServer(m.Handler())
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodPut, server.URL,
+		strings.NewReader(`{"disabled": [{"procedure": "KeyValue::setValue"}]}`))
+	require.NoError(t, err)
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Error(t, handle(m, "KeyValue::setValue"))
+
+	req, err = http.NewRequest(http.MethodPut, server.URL, strings.NewReader(`{"disabled": [{}]}`))
+	require.NoError(t, err)
+	res, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	res, err = http.Post(server.URL, "application/json", strings.NewReader("{}"))
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package killswitch
+
+import "go.uber.org/net/metrics"
+
+// Option customizes the behavior of a Middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	state    State
+	readOnly map[string]struct{}
+	meter    *metrics.Scope
+}
+
+// InitialState specifies the state of the switches when the Middleware is
+// built. By default, no procedure is disabled and the service isn't
+// read-only.
+func InitialState(state State) Option {
+	return optionFunc(func(opts *options) {
+		opts.state = state
+	})
+}
+
+// ReadOnlyProcedures specifies procedures that only read, which are served
+// in read-only mode, in addition to the procedures registered as
+// idempotent.
+func ReadOnlyProcedures(procedures ...string) Option {
+	return optionFunc(func(opts *options) {
+		for _, p := range procedures {
+			opts.readOnly[p] = struct{}{}
+		}
+	})
+}
+
+// Metrics specifies the scope to which metrics about rejected requests are
+// reported. By default, no metrics are reported.
+func Metrics(meter *metrics.Scope) Option {
+	return optionFunc(func(opts *options) {
+		opts.meter = meter
+	})
+}
+
+func applyOptions(opts ...Option) options {
+	options := options{readOnly: make(map[string]struct{})}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return options
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package killswitch
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/yarpc/yarpcerrors"
+	"gopkg.in/yaml.v2"
+)
+
+// State is the state of the switches of a Middleware.
+type State struct {
+	// Disabled lists the rules of disabled procedures.
+	Disabled []Rule `json:"disabled,omitempty" yaml:"disabled"`
+
+	// ReadOnly rejects requests to procedures that aren't read-only.
+	ReadOnly bool `json:"readOnly,omitempty" yaml:"readOnly"`
+}
+
+// Rule disables procedures.
+type Rule struct {
+	// Procedure is the name of the disabled procedure. A trailing "*"
+	// matches all procedures that start with what precedes it.
+	Procedure string `json:"procedure" yaml:"procedure"`
+
+	// Code is the code of the errors with which requests are rejected.
+	// Defaults to Unimplemented.
+	Code yarpcerrors.Code `json:"code,omitempty" yaml:"code"`
+
+	// Message is the message of the errors with which requests are
+	// rejected. Defaults to a message naming the procedure.
+	Message string `json:"message,omitempty" yaml:"message"`
+}
+
+// ParseState parses a State from YAML, or JSON, which is valid YAML.
+//
+// 	disabled:
+// 	  - procedure: KeyValue::setValue
+// 	    code: unavailable
+// 	    message: writes are paused for a migration
+// 	readOnly: false
+func ParseState(data []byte) (State, error) {
+	var state State
+	if err := yaml.UnmarshalStrict(data, &state); err != nil {
+		return State{}, fmt.Errorf("invalid kill switch state: %v", err)
+	}
+	return state, state.validate()
+}
+
+func (s State) validate() error {
+	for _, r := range s.Disabled {
+		if r.Procedure == "" {
+			return errors.New("invalid kill switch state: rules must name a procedure")
+		}
+		if i := strings.Index(r.Procedure, "*"); i >= 0 && i != len(r.Procedure)-1 {
+			return fmt.Errorf("invalid kill switch state: %q may only end with *", r.Procedure)
+		}
+	}
+	return nil
+}
+
+// rules indexes the rules of a State for matching.
+type rules struct {
+	state    State
+	exact    map[string]*Rule
+	prefixes []*Rule
+}
+
+func newRules(state State) *rules {
+	r := &rules{state: state, exact: make(map[string]*Rule)}
+	for i := range state.Disabled {
+		rule := &state.Disabled[i]
+		if strings.HasSuffix(rule.Procedure, "*") {
+			r.prefixes = append(r.prefixes, rule)
+		} else {
+			r.exact[rule.Procedure] = rule
+		}
+	}
+	return r
+}
+
+// match returns the rule that disables the procedure, if any.
+func (r *rules) match(procedure string) *Rule {
+	if rule, ok := r.exact[procedure]; ok {
+		return rule
+	}
+	for _, rule := range r.prefixes {
+		if strings.HasPrefix(procedure, strings.TrimSuffix(rule.Procedure, "*")) {
+			return rule
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package lifecycletest drives random sequences of Start, Stop, and calls
+// against objects with lifecycles, such as transports, outbounds, and peer
+// lists, to find lifecycle deadlocks and use-after-stop bugs.
+//
+// Tests describe how to build the object under test and which calls may be
+// made on it.
+//
+// 	func TestOutboundLifecycle(t *testing.T) {
+// 		lifecycletest.Run(t, func(t testing.TB) lifecycletest.Subject {
+// 			out := http.NewTransport().NewSingleOutbound(url)
+// 			return lifecycletest.Subject{
+// 				Lifecycle: out,
+// 				Calls: map[string]lifecycletest.Call{
+// 					"call": func(ctx context.Context) error {
+// 						_, err := out.Call(ctx, request)
+// 						return err
+// 					},
+// 				},
+// 			}
+// 		})
+// 	}
+//
+// Half of the rounds run operations one at a time and check that
+//
+// 	IsRunning reports true after a successful Start, until Stop is called
+// 	IsRunning reports false after a successful Stop
+// 	calls fail while the subject is not running
+//
+// The other rounds run operations from several goroutines at once; run the
+// tests with the race detector to find data races between them. In every
+// round, operations that panic or that do not return in time fail the test.
+// Failures report the seed and the operations of the round so that they can
+// be reproduced with the Seed option.
+package lifecycletest
@@ -0,0 +1,114 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package lifecycletest
+
+import (
+	"time"
+)
+
+const (
+	_defaultRounds      = 20
+	_defaultSteps       = 20
+	_defaultConcurrency = 4
+	_defaultOpTimeout   = 5 * time.Second
+	_defaultCallTimeout = 100 * time.Millisecond
+)
+
+// Option customizes the behavior of Run.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	seed        int64
+	rounds      int
+	steps       int
+	concurrency int
+	opTimeout   time.Duration
+	callTimeout time.Duration
+}
+
+// Seed specifies the seed of the random sequences of operations, to
+// reproduce a failure. Defaults to a seed based on the current time.
+func Seed(seed int64) Option {
+	return optionFunc(func(opts *options) {
+		opts.seed = seed
+	})
+}
+
+// Rounds specifies how many subjects are built and driven. Defaults to 20.
+func Rounds(n int) Option {
+	return optionFunc(func(opts *options) {
+		opts.rounds = n
+	})
+}
+
+// Steps specifies how many operations are run against each subject.
+// Defaults to 20.
+func Steps(n int) Option {
+	return optionFunc(func(opts *options) {
+		opts.steps = n
+	})
+}
+
+// Concurrency specifies how many goroutines run operations at once in
+// concurrent rounds. Defaults to 4.
+func Concurrency(n int) Option {
+	return optionFunc(func(opts *options) {
+		opts.concurrency = n
+	})
+}
+
+// OpTimeout specifies how long an operation may run before it is considered
+// deadlocked. Defaults to 5 seconds.
+func OpTimeout(d time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.opTimeout = d
+	})
+}
+
+// CallTimeout specifies the timeout of the contexts given to calls. Calls
+// made while the subject is not running often wait for it to start until
+// their context ends. Defaults to 100 milliseconds.
+func CallTimeout(d time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.callTimeout = d
+	})
+}
+
+func applyOptions(opts ...Option) options {
+	options := options{
+		seed:        time.Now().UnixNano(),
+		rounds:      _defaultRounds,
+		steps:       _defaultSteps,
+		concurrency: _defaultConcurrency,
+		opTimeout:   _defaultOpTimeout,
+		callTimeout: _defaultCallTimeout,
+	}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return options
+}
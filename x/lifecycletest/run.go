@@ -0,0 +1,250 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package lifecycletest
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/atomic"
+	"go.uber.org/yarpc/api/transport"
+)
+
+const (
+	_start = "Start"
+	_stop  = "Stop"
+)
+
+// Call is an operation that is only valid while a subject is running, such
+// as sending a request through an outbound or choosing a peer from a peer
+// list. It must return an error if the subject is not running.
+type Call func(ctx context.Context) error
+
+// Subject is an object under test.
+type Subject struct {
+	// Lifecycle is started and stopped in random order.
+	Lifecycle transport.Lifecycle
+
+	// Calls are the operations, by name, that are interleaved with Start
+	// and Stop.
+	Calls map[string]Call
+}
+
+// Run builds subjects with newSubject and drives each of them through a
+// random sequence of Start, Stop, and calls, failing the test if an
+// operation panics, deadlocks, or violates the lifecycle of the subject.
+//
+// Every subject is stopped at the end of its round.
+func Run(t testing.TB, newSubject func(testing.TB) Subject, opts ...Option) {
+	options := applyOptions(opts...)
+	rand := rand.New(rand.NewSource(options.seed))
+
+	for i := 0; i < options.rounds; i++ {
+		r := round{
+			t:          t,
+			opts:       options,
+			subject:    newSubject(t),
+			concurrent: i%2 == 1,
+		}
+		ops := r.ops(rand)
+		ok := false
+		if r.concurrent {
+			ok = r.runConcurrent(rand, ops)
+		} else {
+			ok = r.runSequential(ops)
+		}
+		// A deadlocked subject cannot be stopped either.
+		if !ok || !r.do(_stop) {
+			return
+		}
+	}
+}
+
+// round drives a single subject.
+type round struct {
+	t          testing.TB
+	opts       options
+	subject    Subject
+	concurrent bool
+
+	mu      sync.Mutex
+	history []string
+}
+
+// ops returns a random sequence of operations against the subject.
+func (r *round) ops(rand *rand.Rand) []string {
+	names := []string{_start, _stop}
+	calls := make([]string, 0, len(r.subject.Calls))
+	for name := range r.subject.Calls {
+		calls = append(calls, name)
+	}
+	// Map iteration order is random; sort to keep runs reproducible.
+	sort.Strings(calls)
+	names = append(names, calls...)
+
+	ops := make([]string, r.opts.steps)
+	for i := range ops {
+		ops[i] = names[rand.Intn(len(names))]
+	}
+	return ops
+}
+
+func (r *round) runSequential(ops []string) bool {
+	var stopped bool
+	for _, op := range ops {
+		ok, err := r.run(op)
+		if !ok {
+			return false
+		}
+
+		running := r.subject.Lifecycle.IsRunning()
+		switch op {
+		case _start:
+			if err == nil && !stopped && !running {
+				r.fail("%v succeeded but the subject is not running", op)
+			}
+		case _stop:
+			stopped = true
+			if err == nil && running {
+				r.fail("%v succeeded but the subject is still running", op)
+			}
+		default:
+			if err == nil && !running {
+				r.fail("call %q succeeded while the subject is not running", op)
+			}
+		}
+	}
+	return true
+}
+
+func (r *round) runConcurrent(rand *rand.Rand, ops []string) bool {
+	queues := make([][]string, r.opts.concurrency)
+	for _, op := range ops {
+		i := rand.Intn(len(queues))
+		queues[i] = append(queues[i], op)
+	}
+
+	var (
+		wg     sync.WaitGroup
+		failed atomic.Bool
+	)
+	for _, queue := range queues {
+		wg.Add(1)
+		go func(queue []string) {
+			defer wg.Done()
+			for _, op := range queue {
+				if !r.do(op) {
+					failed.Store(true)
+					return
+				}
+			}
+		}(queue)
+	}
+	wg.Wait()
+	return !failed.Load()
+}
+
+// do runs an operation, reporting whether it returned in time without
+// panicking.
+func (r *round) do(op string) bool {
+	ok, _ := r.run(op)
+	return ok
+}
+
+// run runs an operation under a watchdog, returning whether it returned in
+// time without panicking, and its error.
+func (r *round) run(op string) (bool, error) {
+	r.mu.Lock()
+	r.history = append(r.history, op)
+	r.mu.Unlock()
+
+	type result struct {
+		err   error
+		panic interface{}
+	}
+	done := make(chan result, 1)
+	go func() {
+		var res result
+		defer func() {
+			res.panic = recover()
+			done <- res
+		}()
+		res.err = r.call(op)
+	}()
+
+	timer := time.NewTimer(r.opts.opTimeout)
+	defer timer.Stop()
+
+	select {
+	case res := <-done:
+		if res.panic != nil {
+			r.fail("%v panicked: %v", op, res.panic)
+			return false, res.err
+		}
+		return true, res.err
+	case <-timer.C:
+		r.fail("%v did not return within %v, goroutines:\n%s", op, r.opts.opTimeout, stacks())
+		return false, nil
+	}
+}
+
+func (r *round) call(op string) error {
+	switch op {
+	case _start:
+		return r.subject.Lifecycle.Start()
+	case _stop:
+		return r.subject.Lifecycle.Stop()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), r.opts.callTimeout)
+	defer cancel()
+	return r.subject.Calls[op](ctx)
+}
+
+func (r *round) fail(format string, args ...interface{}) {
+	r.mu.Lock()
+	history := strings.Join(r.history, ", ")
+	r.mu.Unlock()
+
+	mode := "sequential"
+	if r.concurrent {
+		mode = "concurrent"
+	}
+	r.t.Errorf("%v (seed %v, %v round, operations: %v)", fmt.Sprintf(format, args...), r.opts.seed, mode, history)
+}
+
+// stacks returns the stack traces of all goroutines.
+func stacks() []byte {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
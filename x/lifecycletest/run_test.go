@@ -0,0 +1,208 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package lifecycletest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/peer/roundrobin"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/yarpc/pkg/lifecycletest"
+	yarpchttp "go.uber.org/yarpc/transport/http"
+	"go.uber.org/yarpc/yarpctest"
+)
+
+// recorder records the failures of a test instead of failing it.
+type recorder struct {
+	testing.TB
+
+	errors []string
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// once is a well-behaved subject.
+type once struct {
+	once *lifecycle.Once
+}
+
+func newOnce(testing.TB) Subject {
+	o := &once{once: lifecycle.NewOnce()}
+	return Subject{
+		Lifecycle: o,
+		Calls:     map[string]Call{"call": o.call},
+	}
+}
+
+func (o *once) Start() error    { return o.once.Start(nil) }
+func (o *once) Stop() error     { return o.once.Stop(nil) }
+func (o *once) IsRunning() bool { return o.once.IsRunning() }
+
+func (o *once) call(ctx context.Context) error {
+	return o.once.WaitUntilRunning(ctx)
+}
+
+func TestRunWellBehaved(t *testing.T) {
+	r := &recorder{TB: t}
+	Run(r, newOnce, Seed(1), CallTimeout(time.Millisecond))
+	assert.Empty(t, r.errors)
+}
+
+func TestRunUseAfterStop(t *testing.T) {
+	newSubject := func(testing.TB) Subject {
+		lc := lifecycletest.NewNop()
+		return Subject{
+			Lifecycle: lc,
+			Calls: map[string]Call{
+				// Succeeds whether or not the lifecycle is running.
+				"call": func(context.Context) error { return nil },
+			},
+		}
+	}
+
+	r := &recorder{TB: t}
+	Run(r, newSubject, Seed(1), Rounds(1))
+	if assert.NotEmpty(t, r.errors) {
+		assert.Contains(t, r.errors[0], `call "call" succeeded while the subject is not running`)
+		assert.Contains(t, r.errors[0], "seed 1, sequential round")
+	}
+}
+
+// idle never reports that it is running.
+type idle struct{}
+
+func (idle) Start() error    { return nil }
+func (idle) Stop() error     { return nil }
+func (idle) IsRunning() bool { return false }
+
+func TestRunStartNotRunning(t *testing.T) {
+	r := &recorder{TB: t}
+	Run(r, func(testing.TB) Subject {
+		return Subject{Lifecycle: idle{}}
+	}, Seed(2), Rounds(1), Steps(1))
+
+	// Seed 2 picks Start.
+	if assert.Len(t, r.errors, 1) {
+		assert.Contains(t, r.errors[0], "Start succeeded but the subject is not running")
+		assert.Contains(t, r.errors[0], "operations: Start")
+	}
+}
+
+// stuck never returns from Stop.
+type stuck struct{ idle }
+
+func (stuck) Stop() error {
+	select {}
+}
+
+func TestRunDeadlock(t *testing.T) {
+	r := &recorder{TB: t}
+	Run(r, func(testing.TB) Subject {
+		return Subject{Lifecycle: stuck{}}
+	}, Rounds(2), Steps(0), OpTimeout(10*time.Millisecond))
+
+	// The round ends with Stop, which deadlocks, so no further rounds run.
+	if assert.Len(t, r.errors, 1) {
+		assert.Contains(t, r.errors[0], "Stop did not return within 10ms, goroutines:")
+		assert.Contains(t, r.errors[0], "lifecycletest.stuck.Stop")
+	}
+}
+
+func TestRunPanic(t *testing.T) {
+	newSubject := func(testing.TB) Subject {
+		return Subject{
+			Lifecycle: lifecycletest.NewNop(),
+			Calls: map[string]Call{
+				"call": func(context.Context) error { panic("great sadness") },
+			},
+		}
+	}
+
+	r := &recorder{TB: t}
+	Run(r, newSubject, Seed(1), Rounds(2))
+	if assert.NotEmpty(t, r.errors) {
+		assert.Contains(t, r.errors[0], "call panicked: great sadness")
+	}
+}
+
+func TestRunHTTPOutbound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Rpc-Status", "success")
+	}))
+	defer server.Close()
+
+	trans := yarpchttp.NewTransport()
+	if err := trans.Start(); !assert.NoError(t, err) {
+		return
+	}
+	defer trans.Stop()
+
+	Run(t, func(testing.TB) Subject {
+		out := trans.NewSingleOutbound(server.URL)
+		return Subject{
+			Lifecycle: out,
+			Calls: map[string]Call{
+				"call": func(ctx context.Context) error {
+					_, err := out.Call(ctx, &transport.Request{
+						Caller:    "caller",
+						Service:   "service",
+						Procedure: "procedure",
+						Encoding:  "raw",
+					})
+					return err
+				},
+			},
+		}
+	})
+}
+
+func TestRunRoundRobin(t *testing.T) {
+	Run(t, func(testing.TB) Subject {
+		list := roundrobin.New(yarpctest.NewFakeTransport())
+		err := list.Update(peer.ListUpdates{
+			Additions: []peer.Identifier{hostport.PeerIdentifier("127.0.0.1:1")},
+		})
+		assert.NoError(t, err)
+		return Subject{
+			Lifecycle: list,
+			Calls: map[string]Call{
+				"choose": func(ctx context.Context) error {
+					_, onFinish, err := list.Choose(ctx, &transport.Request{})
+					if err == nil {
+						onFinish(nil)
+					}
+					return err
+				},
+			},
+		}
+	})
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package mirror provides outbound middleware that mirrors a share of
+// requests to a shadow outbound, to validate a new backend with production
+// traffic before sending it real requests.
+//
+// Mirrored requests are sent to the shadow outbound in the background while
+// the original request proceeds as usual. The caller always receives the
+// response of the original outbound; responses of the shadow outbound are
+// compared with it and discarded.
+//
+// 	shadow := http.NewTransport().NewSingleOutbound("http://canary:8080")
+// 	mirrored := middleware.ApplyUnaryOutbound(
+// 		primary,
+// 		mirror.New(shadow, mirror.Percentage(5), mirror.Metrics(scope)),
+// 	)
+//
+// The shadow outbound is not started or stopped by the middleware. Register
+// it with the dispatcher under its own outbound key, or manage its lifecycle
+// separately. Requests are not mirrored while the shadow outbound is not
+// running.
+//
+// The request body of mirrored requests and the response body of their
+// original response are read into memory so that both outbounds can read
+// them. Requests which cannot be mirrored right away because too many
+// mirrored requests are in flight are sent to the original outbound only.
+//
+// Mirroring only makes sense for procedures without side effects, or whose
+// shadow backend is isolated from production data.
+package mirror
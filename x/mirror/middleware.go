@@ -0,0 +1,201 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"sync"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+// Results of comparing the response of a shadow outbound with the response of
+// the original outbound.
+const (
+	_resultMatch         = "match"
+	_resultErrorMismatch = "error_mismatch"
+	_resultBodyMismatch  = "body_mismatch"
+)
+
+var _ middleware.UnaryOutbound = (*Middleware)(nil)
+
+// Middleware is a unary outbound middleware that mirrors requests to a
+// shadow outbound.
+type Middleware struct {
+	shadow transport.UnaryOutbound
+	opts   options
+	slots  chan struct{}
+
+	results *metrics.CounterVector
+	dropped *metrics.Counter
+
+	// inflight tracks mirrored requests; used by tests.
+	inflight sync.WaitGroup
+}
+
+// New builds a Middleware that mirrors requests to the given shadow
+// outbound.
+func New(shadow transport.UnaryOutbound, opts ...Option) *Middleware {
+	m := &Middleware{
+		shadow: shadow,
+		opts:   applyOptions(opts...),
+	}
+	m.slots = make(chan struct{}, m.opts.maxConcurrent)
+
+	logger, meter := m.opts.logger, m.opts.meter
+	var err error
+	m.results, err = meter.CounterVector(metrics.Spec{
+		Name:    "mirror_requests",
+		Help:    "Number of mirrored requests by comparison result.",
+		VarTags: []string{"procedure", "result"},
+	})
+	if err != nil {
+		logger.Error("Failed to create mirror requests vector.", zap.Error(err))
+	}
+	m.dropped, err = meter.Counter(metrics.Spec{
+		Name: "mirror_dropped",
+		Help: "Number of requests not mirrored because too many mirrored requests were in flight.",
+	})
+	if err != nil {
+		logger.Error("Failed to create mirror dropped counter.", zap.Error(err))
+	}
+	return m
+}
+
+// Call sends the request to the given outbound and, for a share of requests,
+// to the shadow outbound.
+func (m *Middleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	if !m.shouldMirror() {
+		return out.Call(ctx, req)
+	}
+
+	select {
+	case m.slots <- struct{}{}:
+	default:
+		m.dropped.Inc()
+		return out.Call(ctx, req)
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			<-m.slots
+			return nil, err
+		}
+	}
+
+	shadowReq := *req
+	shadowReq.Headers = req.Headers.Clone()
+	shadowReq.Body = bytes.NewReader(body)
+
+	primaryReq := *req
+	primaryReq.Body = bytes.NewReader(body)
+
+	primary := make(chan outcome, 1)
+	m.inflight.Add(1)
+	go m.mirror(&shadowReq, primary)
+
+	res, err := out.Call(ctx, &primaryReq)
+	res, o, err := readOutcome(res, err)
+	primary <- o
+	return res, err
+}
+
+func (m *Middleware) shouldMirror() bool {
+	if m.opts.percentage <= 0 || !m.shadow.IsRunning() {
+		return false
+	}
+	return m.opts.percentage >= 100 || m.opts.random()*100 < m.opts.percentage
+}
+
+// mirror sends the request to the shadow outbound and compares its response
+// with the outcome of the original request once it is known.
+func (m *Middleware) mirror(req *transport.Request, primary <-chan outcome) {
+	defer m.inflight.Done()
+	defer func() { <-m.slots }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.opts.timeout)
+	defer cancel()
+	_, shadow, _ := readOutcome(m.shadow.Call(ctx, req))
+
+	p := <-primary
+	result := m.compare(p, shadow)
+	if counter, err := m.results.Get("procedure", req.Procedure, "result", result); err == nil {
+		counter.Inc()
+	}
+	if result != _resultMatch {
+		m.opts.logger.Debug("Mirrored response does not match.",
+			zap.String("procedure", req.Procedure),
+			zap.String("result", result),
+			zap.Stringer("code", p.code),
+			zap.Stringer("shadowCode", shadow.code),
+		)
+	}
+}
+
+func (m *Middleware) compare(primary, shadow outcome) string {
+	if primary.code != shadow.code || primary.applicationError != shadow.applicationError {
+		return _resultErrorMismatch
+	}
+	if primary.code == yarpcerrors.CodeOK && !m.opts.compare(primary.body, shadow.body) {
+		return _resultBodyMismatch
+	}
+	return _resultMatch
+}
+
+// outcome is the part of the result of a call that is compared.
+type outcome struct {
+	code             yarpcerrors.Code
+	applicationError bool
+	body             []byte
+}
+
+// readOutcome reads the body of a response into memory, returning a response
+// whose body may be read again along with the outcome of the call.
+func readOutcome(res *transport.Response, err error) (*transport.Response, outcome, error) {
+	if err != nil {
+		return res, outcome{code: yarpcerrors.FromError(err).Code()}, err
+	}
+	if res == nil {
+		return res, outcome{}, nil
+	}
+
+	o := outcome{applicationError: res.ApplicationError}
+	if res.Body != nil {
+		body, err := ioutil.ReadAll(res.Body)
+		if cerr := res.Body.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return nil, outcome{code: yarpcerrors.FromError(err).Code()}, err
+		}
+		o.body = body
+		res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	return res, o, nil
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// fakeOutbound is a running unary outbound that records the bodies of the
+// requests it receives.
+type fakeOutbound struct {
+	transport.UnaryOutbound
+
+	mu     sync.Mutex
+	bodies []string
+	call   func(*transport.Request) (*transport.Response, error)
+}
+
+func (o *fakeOutbound) IsRunning() bool { return true }
+
+func (o *fakeOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	o.mu.Lock()
+	o.bodies = append(o.bodies, string(body))
+	o.mu.Unlock()
+	return o.call(req)
+}
+
+func (o *fakeOutbound) received() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.bodies
+}
+
+func respond(body string) func(*transport.Request) (*transport.Response, error) {
+	return func(*transport.Request) (*transport.Response, error) {
+		return &transport.Response{Body: ioutil.NopCloser(bytes.NewReader([]byte(body)))}, nil
+	}
+}
+
+func fail(err error) func(*transport.Request) (*transport.Response, error) {
+	return func(*transport.Request) (*transport.Response, error) {
+		return nil, err
+	}
+}
+
+func request(body string) *transport.Request {
+	return &transport.Request{
+		Service:   "service",
+		Procedure: "procedure",
+		Headers:   transport.NewHeaders().With("key", "value"),
+		Body:      bytes.NewReader([]byte(body)),
+	}
+}
+
+func TestMirror(t *testing.T) {
+	tests := []struct {
+		desc    string
+		primary func(*transport.Request) (*transport.Response, error)
+		shadow  func(*transport.Request) (*transport.Response, error)
+		result  string
+	}{
+		{
+			desc:    "match",
+			primary: respond("world"),
+			shadow:  respond("world"),
+			result:  _resultMatch,
+		},
+		{
+			desc:    "same error",
+			primary: fail(yarpcerrors.NotFoundErrorf("nope")),
+			shadow:  fail(yarpcerrors.NotFoundErrorf("no such thing")),
+			result:  _resultMatch,
+		},
+		{
+			desc:    "body mismatch",
+			primary: respond("world"),
+			shadow:  respond("moon"),
+			result:  _resultBodyMismatch,
+		},
+		{
+			desc:    "shadow fails",
+			primary: respond("world"),
+			shadow:  fail(yarpcerrors.InternalErrorf("great sadness")),
+			result:  _resultErrorMismatch,
+		},
+		{
+			desc:    "primary fails",
+			primary: fail(yarpcerrors.UnavailableErrorf("great sadness")),
+			shadow:  respond("world"),
+			result:  _resultErrorMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			primary := &fakeOutbound{call: tt.primary}
+			shadow := &fakeOutbound{call: tt.shadow}
+			m := New(shadow, Logger(zap.New(core)))
+
+			res, err := m.Call(context.Background(), request("hello"), primary)
+			m.inflight.Wait()
+
+			if err == nil {
+				body, err := ioutil.ReadAll(res.Body)
+				require.NoError(t, err)
+				assert.Equal(t, "world", string(body), "response body must be readable")
+			}
+			assert.Equal(t, []string{"hello"}, primary.received())
+			assert.Equal(t, []string{"hello"}, shadow.received())
+
+			mismatches := logs.FilterMessage("Mirrored response does not match.").AllUntimed()
+			if tt.result == _resultMatch {
+				assert.Empty(t, mismatches)
+				return
+			}
+			require.Len(t, mismatches, 1)
+			assert.Equal(t, tt.result, mismatches[0].ContextMap()["result"])
+		})
+	}
+}
+
+func TestMirrorPercentage(t *testing.T) {
+	primary := &fakeOutbound{call: respond("world")}
+	shadow := &fakeOutbound{call: respond("world")}
+	rolls := []float64{0.1, 0.3, 0.29, 0.9}
+	m := New(shadow, Percentage(30), withRandom(func() float64 {
+		r := rolls[0]
+		rolls = rolls[1:]
+		return r
+	}))
+
+	for _, body := range []string{"a", "b", "c", "d"} {
+		_, err := m.Call(context.Background(), request(body), primary)
+		require.NoError(t, err)
+	}
+	m.inflight.Wait()
+
+	assert.Equal(t, []string{"a", "b", "c", "d"}, primary.received())
+	assert.ElementsMatch(t, []string{"a", "c"}, shadow.received(), "shadow calls run concurrently")
+}
+
+func TestMirrorMaxConcurrent(t *testing.T) {
+	unblock := make(chan struct{})
+	primary := &fakeOutbound{call: respond("world")}
+	shadow := &fakeOutbound{call: func(*transport.Request) (*transport.Response, error) {
+		<-unblock
+		return respond("world")(nil)
+	}}
+	m := New(shadow, MaxConcurrent(1), Timeout(time.Minute))
+
+	_, err := m.Call(context.Background(), request("a"), primary)
+	require.NoError(t, err)
+	_, err = m.Call(context.Background(), request("b"), primary)
+	require.NoError(t, err)
+	close(unblock)
+	m.inflight.Wait()
+
+	assert.Equal(t, []string{"a", "b"}, primary.received())
+	assert.Equal(t, []string{"a"}, shadow.received())
+}
+
+func TestMirrorShadowHeadersAreIndependent(t *testing.T) {
+	primary := &fakeOutbound{call: func(req *transport.Request) (*transport.Response, error) {
+		req.Headers.Del("key")
+		return respond("world")(req)
+	}}
+	var shadowHeaders transport.Headers
+	shadow := &fakeOutbound{call: func(req *transport.Request) (*transport.Response, error) {
+		shadowHeaders = req.Headers
+		return respond("world")(req)
+	}}
+	m := New(shadow)
+
+	_, err := m.Call(context.Background(), request("hello"), primary)
+	require.NoError(t, err)
+	m.inflight.Wait()
+
+	v, ok := shadowHeaders.Get("key")
+	assert.True(t, ok)
+	assert.Equal(t, "value", v)
+}
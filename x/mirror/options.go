@@ -0,0 +1,130 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package mirror
+
+import (
+	"bytes"
+	"math/rand"
+	"time"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/zap"
+)
+
+const (
+	_defaultPercentage    = 100
+	_defaultTimeout       = time.Second
+	_defaultMaxConcurrent = 100
+)
+
+// Option customizes the behavior of a Middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	percentage    float64
+	timeout       time.Duration
+	maxConcurrent int
+	compare       func(primary, shadow []byte) bool
+	meter         *metrics.Scope
+	logger        *zap.Logger
+	random        func() float64
+}
+
+// Percentage specifies the share of requests, between 0 and 100, that are
+// mirrored to the shadow outbound. Defaults to 100.
+func Percentage(percentage float64) Option {
+	return optionFunc(func(opts *options) {
+		opts.percentage = percentage
+	})
+}
+
+// Timeout specifies how long mirrored requests may take. Mirrored requests
+// do not inherit the deadline of the original request since they may
+// outlive it. Defaults to one second.
+func Timeout(timeout time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.timeout = timeout
+	})
+}
+
+// MaxConcurrent specifies the maximum number of mirrored requests in
+// flight. Requests beyond this limit are not mirrored. Defaults to 100.
+func MaxConcurrent(n int) Option {
+	return optionFunc(func(opts *options) {
+		opts.maxConcurrent = n
+	})
+}
+
+// CompareBodies specifies the function used to decide whether the response
+// bodies of the original and shadow outbounds match. Encodings whose
+// serialization is not deterministic, like Protobuf messages with maps,
+// need a comparison that decodes the bodies first. Defaults to comparing
+// the bodies byte for byte.
+func CompareBodies(compare func(primary, shadow []byte) bool) Option {
+	return optionFunc(func(opts *options) {
+		opts.compare = compare
+	})
+}
+
+// Metrics specifies the scope to which comparison metrics are reported.
+// By default, no metrics are reported.
+func Metrics(meter *metrics.Scope) Option {
+	return optionFunc(func(opts *options) {
+		opts.meter = meter
+	})
+}
+
+// Logger specifies the logger that should be used to log.
+// Default value is noop zap logger.
+func Logger(logger *zap.Logger) Option {
+	return optionFunc(func(opts *options) {
+		opts.logger = logger
+	})
+}
+
+// withRandom specifies the source of random numbers in [0, 1) used to pick
+// the requests that are mirrored.
+func withRandom(random func() float64) Option {
+	return optionFunc(func(opts *options) {
+		opts.random = random
+	})
+}
+
+func applyOptions(opts ...Option) options {
+	options := options{
+		percentage:    _defaultPercentage,
+		timeout:       _defaultTimeout,
+		maxConcurrent: _defaultMaxConcurrent,
+		compare:       bytes.Equal,
+		logger:        zap.NewNop(),
+		random:        rand.Float64,
+	}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return options
+}
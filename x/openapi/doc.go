@@ -0,0 +1,47 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package openapi describes the procedures registered with a YARPC dispatcher
+// as an OpenAPI 3.0 document, so that consumers and API gateways can discover
+// the contracts of YARPC services.
+//
+// Serve the document of a running dispatcher with NewHandler:
+//
+// 	mux := http.NewServeMux()
+// 	mux.Handle("/openapi.json", openapi.NewHandler(dispatcher))
+//
+// or build it from procedures without starting anything, for example to
+// check it into the repository from a go:generate program:
+//
+// 	doc := openapi.NewDocument(kvpb.BuildKeyValueYARPCProcedures(handler), openapi.Title("keyvalue"))
+// 	json.NewEncoder(os.Stdout).Encode(doc)
+//
+// Every unary and oneway procedure becomes a POST operation at the path
+// "/<service>/<procedure>", or "/<procedure>" for procedures without a
+// service. The HTTP inbound routes requests by the Rpc-Service,
+// Rpc-Procedure, and Rpc-Encoding headers rather than the path, so these
+// headers are listed as required parameters of each operation. Streaming
+// procedures cannot be described and are omitted.
+//
+// Body schemas are derived from the Go types of the JSON and protobuf
+// encodings by reflection, following the rules of encoding/json. The
+// response schema of protobuf procedures is not known until they are called
+// and is left unspecified, as are the bodies of other encodings.
+package openapi
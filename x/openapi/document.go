@@ -0,0 +1,112 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openapi
+
+// Version is the version of the OpenAPI specification that documents
+// conform to.
+const Version = "3.0.0"
+
+// Document is the root of an OpenAPI document. It holds the subset of the
+// specification needed to describe YARPC procedures.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components *Components          `json:"components,omitempty"`
+}
+
+// Info holds metadata about the API.
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem describes the operations available at a path. YARPC procedures
+// are always called with POST.
+type PathItem struct {
+	Post *Operation `json:"post,omitempty"`
+}
+
+// Operation describes a single procedure.
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Tags        []string             `json:"tags,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+
+	// Service, Procedure, and RPCType identify the YARPC procedure behind
+	// this operation.
+	Service   string `json:"x-yarpc-service,omitempty"`
+	Procedure string `json:"x-yarpc-procedure"`
+	RPCType   string `json:"x-yarpc-rpc-type"`
+
+	// Idempotent, RequestSchema, and ResponseSchema carry the procedure
+	// metadata that has no counterpart in OpenAPI.
+	Idempotent     bool   `json:"x-yarpc-idempotent,omitempty"`
+	RequestSchema  string `json:"x-yarpc-request-schema,omitempty"`
+	ResponseSchema string `json:"x-yarpc-response-schema,omitempty"`
+}
+
+// Parameter describes a request header.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+// RequestBody describes the bodies accepted by an operation, keyed by
+// content type.
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response describes a response of an operation.
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType describes a body with a given content type.
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Components holds the schemas referenced by the document.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// Schema describes a JSON value. The empty Schema matches any value.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
@@ -0,0 +1,212 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/introspection"
+	yarpchttp "go.uber.org/yarpc/transport/http"
+)
+
+// Content types used for bodies of the encodings that ship with YARPC.
+var _contentTypes = map[transport.Encoding]string{
+	"json":   "application/json",
+	"proto":  "application/x-protobuf",
+	"raw":    "application/octet-stream",
+	"thrift": "application/x-thrift",
+}
+
+// NewHandler returns an http.Handler that serves the OpenAPI document of the
+// procedures currently registered with the given dispatcher as JSON.
+func NewHandler(dispatcher *yarpc.Dispatcher, opts ...Option) http.Handler {
+	opts = append([]Option{Title(dispatcher.Name())}, opts...)
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		doc := NewDocument(dispatcher.Router().Procedures(), opts...)
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(doc); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// NewDocument builds an OpenAPI document that describes the given unary and
+// oneway procedures. Procedures registered for multiple encodings, like
+// those of protobuf services, are described by a single operation.
+func NewDocument(procedures []transport.Procedure, opts ...Option) *Document {
+	options := applyOptions(opts...)
+	doc := &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:   options.title,
+			Version: options.version,
+		},
+		Paths: make(map[string]*PathItem),
+	}
+
+	schemas := newSchemaBuilder()
+	for _, p := range procedures {
+		rpcType := p.HandlerSpec.Type()
+		if rpcType != transport.Unary && rpcType != transport.Oneway {
+			continue
+		}
+
+		path := "/" + operationID(p)
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &PathItem{Post: newOperation(p)}
+			doc.Paths[path] = item
+		}
+		addEncoding(item.Post, p, schemas)
+	}
+
+	if len(schemas.schemas) > 0 {
+		doc.Components = &Components{Schemas: schemas.schemas}
+	}
+	return doc
+}
+
+// newOperation builds an operation for the given procedure without any
+// bodies.
+func newOperation(p transport.Procedure) *Operation {
+	service := &Schema{Type: "string"}
+	var tags []string
+	if p.Service != "" {
+		service.Enum = []string{p.Service}
+		tags = []string{p.Service}
+	}
+
+	op := &Operation{
+		OperationID: operationID(p),
+		Tags:        tags,
+		Description: description(p.Metadata),
+		Deprecated:  p.Metadata.Deprecated,
+		Parameters: []*Parameter{
+			header(yarpchttp.CallerHeader, "Name of the calling service.", &Schema{Type: "string"}),
+			header(yarpchttp.ServiceHeader, "Name of the called service.", service),
+			header(yarpchttp.ProcedureHeader, "Name of the called procedure.", &Schema{Type: "string", Enum: []string{p.Name}}),
+			header(yarpchttp.EncodingHeader, "Encoding of the request and response bodies.", &Schema{Type: "string"}),
+			header(yarpchttp.TTLMSHeader, "Time to live of the request in milliseconds.", &Schema{Type: "integer", Format: "int64"}),
+		},
+		RequestBody: &RequestBody{Content: make(map[string]*MediaType)},
+		Responses: map[string]*Response{
+			"default": {
+				Description: "The request failed. The " + yarpchttp.ErrorCodeHeader +
+					" header holds the error code and the body holds the error message.",
+			},
+		},
+		Service:   p.Service,
+		Procedure: p.Name,
+		RPCType:   p.HandlerSpec.Type().String(),
+
+		Idempotent:     p.Metadata.Idempotent,
+		RequestSchema:  p.Metadata.RequestSchema,
+		ResponseSchema: p.Metadata.ResponseSchema,
+	}
+	if p.HandlerSpec.Type() == transport.Oneway {
+		op.Responses["200"] = &Response{Description: "The request was accepted."}
+	} else {
+		op.Responses["200"] = &Response{
+			Description: "The request succeeded.",
+			Content:     make(map[string]*MediaType),
+		}
+	}
+	return op
+}
+
+// description returns the description of an operation, which mentions the
+// deprecation message since OpenAPI has no field of its own for it.
+func description(m transport.ProcedureMetadata) string {
+	if !m.Deprecated || m.DeprecationMessage == "" {
+		return m.Description
+	}
+	if m.Description == "" {
+		return "Deprecated: " + m.DeprecationMessage
+	}
+	return m.Description + "\n\nDeprecated: " + m.DeprecationMessage
+}
+
+// addEncoding adds the bodies of the given procedure's encoding to the given
+// operation.
+func addEncoding(op *Operation, p transport.Procedure, schemas *schemaBuilder) {
+	if p.Encoding != "" {
+		for _, param := range op.Parameters {
+			if param.Name == yarpchttp.EncodingHeader {
+				param.Schema.Enum = append(param.Schema.Enum, string(p.Encoding))
+			}
+		}
+	}
+
+	contentType, ok := _contentTypes[p.Encoding]
+	if !ok {
+		contentType = "application/octet-stream"
+	}
+
+	var handler interface{}
+	if p.HandlerSpec.Type() == transport.Unary {
+		handler = p.HandlerSpec.Unary()
+	} else {
+		handler = p.HandlerSpec.Oneway()
+	}
+
+	request := &MediaType{Schema: &Schema{Type: "string", Format: "binary"}}
+	response := &MediaType{Schema: &Schema{Type: "string", Format: "binary"}}
+	if p.Encoding == "json" {
+		request.Schema = &Schema{}
+		response.Schema = &Schema{}
+		if th, ok := handler.(introspection.TypedHandler); ok {
+			if t := th.RequestType(); t != nil {
+				request.Schema = schemas.Schema(t)
+			}
+			if t := th.ResponseType(); t != nil {
+				response.Schema = schemas.Schema(t)
+			}
+		}
+	}
+
+	op.RequestBody.Content[contentType] = request
+	if content := op.Responses["200"].Content; content != nil {
+		content[contentType] = response
+	}
+}
+
+// operationID identifies the given procedure within the document. The
+// service is omitted for procedures that have not been registered with a
+// dispatcher yet.
+func operationID(p transport.Procedure) string {
+	if p.Service == "" {
+		return p.Name
+	}
+	return p.Service + "/" + p.Name
+}
+
+func header(name, description string, schema *Schema) *Parameter {
+	return &Parameter{
+		Name:        name,
+		In:          "header",
+		Description: description,
+		Required:    true,
+		Schema:      schema,
+	}
+}
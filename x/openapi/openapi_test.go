@@ -0,0 +1,219 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	yarpcjson "go.uber.org/yarpc/encoding/json"
+	"go.uber.org/yarpc/encoding/raw"
+	"go.uber.org/yarpc/internal/examples/protobuf/examplepb"
+	"go.uber.org/yarpc/x/openapi"
+)
+
+type getRequest struct {
+	Key string `json:"key"`
+}
+
+type getResponse struct {
+	Value string `json:"value"`
+}
+
+func get(context.Context, *getRequest) (*getResponse, error) {
+	return nil, nil
+}
+
+func fire(context.Context, map[string]interface{}) error {
+	return nil
+}
+
+func echo(_ context.Context, body []byte) ([]byte, error) {
+	return body, nil
+}
+
+func TestNewDocument(t *testing.T) {
+	var procedures []transport.Procedure
+	procedures = append(procedures, yarpcjson.Procedure("get", get)...)
+	procedures = append(procedures, yarpcjson.OnewayProcedure("fire", fire)...)
+	procedures = append(procedures, raw.Procedure("echo", echo)...)
+	procedures = append(procedures, examplepb.BuildKeyValueYARPCProcedures(nil)...)
+	procedures = append(procedures, examplepb.BuildFooYARPCProcedures(nil)...)
+	for i := range procedures {
+		procedures[i].Service = "keyvalue"
+	}
+
+	doc := openapi.NewDocument(procedures, openapi.Title("keyvalue"), openapi.APIVersion("2.0.0"))
+	assert.Equal(t, "3.0.0", doc.OpenAPI)
+	assert.Equal(t, openapi.Info{Title: "keyvalue", Version: "2.0.0"}, doc.Info)
+
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	assert.ElementsMatch(t, []string{
+		"/keyvalue/get",
+		"/keyvalue/fire",
+		"/keyvalue/echo",
+		"/keyvalue/uber.yarpc.internal.examples.protobuf.example.KeyValue::GetValue",
+		"/keyvalue/uber.yarpc.internal.examples.protobuf.example.KeyValue::SetValue",
+	}, paths, "streaming procedures must be omitted")
+
+	t.Run("json unary", func(t *testing.T) {
+		op := doc.Paths["/keyvalue/get"].Post
+		require.NotNil(t, op)
+		assert.Equal(t, "keyvalue/get", op.OperationID)
+		assert.Equal(t, []string{"keyvalue"}, op.Tags)
+		assert.Equal(t, "keyvalue", op.Service)
+		assert.Equal(t, "get", op.Procedure)
+		assert.Equal(t, "Unary", op.RPCType)
+
+		params := make(map[string]*openapi.Schema)
+		for _, param := range op.Parameters {
+			assert.Equal(t, "header", param.In)
+			assert.True(t, param.Required)
+			params[param.Name] = param.Schema
+		}
+		assert.Equal(t, []string{"keyvalue"}, params["Rpc-Service"].Enum)
+		assert.Equal(t, []string{"get"}, params["Rpc-Procedure"].Enum)
+		assert.Equal(t, []string{"json"}, params["Rpc-Encoding"].Enum)
+		assert.Contains(t, params, "Rpc-Caller")
+		assert.Contains(t, params, "Context-TTL-MS")
+
+		assert.Equal(t,
+			&openapi.Schema{Ref: "#/components/schemas/openapi_test.getRequest"},
+			op.RequestBody.Content["application/json"].Schema)
+		assert.Equal(t,
+			&openapi.Schema{Ref: "#/components/schemas/openapi_test.getResponse"},
+			op.Responses["200"].Content["application/json"].Schema)
+		assert.Contains(t, op.Responses, "default")
+	})
+
+	t.Run("json oneway", func(t *testing.T) {
+		op := doc.Paths["/keyvalue/fire"].Post
+		require.NotNil(t, op)
+		assert.Equal(t, "Oneway", op.RPCType)
+		assert.Equal(t,
+			&openapi.Schema{Type: "object", AdditionalProperties: &openapi.Schema{}},
+			op.RequestBody.Content["application/json"].Schema)
+		assert.Empty(t, op.Responses["200"].Content)
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		op := doc.Paths["/keyvalue/echo"].Post
+		require.NotNil(t, op)
+		binary := &openapi.Schema{Type: "string", Format: "binary"}
+		assert.Equal(t, binary, op.RequestBody.Content["application/octet-stream"].Schema)
+		assert.Equal(t, binary, op.Responses["200"].Content["application/octet-stream"].Schema)
+	})
+
+	t.Run("protobuf", func(t *testing.T) {
+		op := doc.Paths["/keyvalue/uber.yarpc.internal.examples.protobuf.example.KeyValue::SetValue"].Post
+		require.NotNil(t, op)
+		for _, param := range op.Parameters {
+			if param.Name == "Rpc-Encoding" {
+				assert.ElementsMatch(t, []string{"proto", "json"}, param.Schema.Enum)
+			}
+		}
+		assert.Equal(t,
+			&openapi.Schema{Ref: "#/components/schemas/examplepb.SetValueRequest"},
+			op.RequestBody.Content["application/json"].Schema)
+		assert.Equal(t,
+			&openapi.Schema{Type: "string", Format: "binary"},
+			op.RequestBody.Content["application/x-protobuf"].Schema)
+		assert.Equal(t, &openapi.Schema{}, op.Responses["200"].Content["application/json"].Schema)
+		assert.Equal(t, "uber.yarpc.internal.examples.protobuf.example.SetValueRequest", op.RequestSchema)
+		assert.Equal(t, "uber.yarpc.internal.examples.protobuf.example.SetValueResponse", op.ResponseSchema)
+	})
+
+	require.NotNil(t, doc.Components)
+	assert.Contains(t, doc.Components.Schemas, "openapi_test.getRequest")
+	assert.Contains(t, doc.Components.Schemas, "examplepb.SetValueRequest")
+}
+
+func TestNewDocumentWithoutService(t *testing.T) {
+	doc := openapi.NewDocument(yarpcjson.Procedure("get", get))
+	assert.Equal(t, "yarpc", doc.Info.Title)
+	assert.Equal(t, "1.0.0", doc.Info.Version)
+
+	require.Contains(t, doc.Paths, "/get")
+	op := doc.Paths["/get"].Post
+	assert.Equal(t, "get", op.OperationID)
+	assert.Empty(t, op.Tags)
+	assert.Empty(t, op.Service)
+}
+
+func TestNewDocumentMetadata(t *testing.T) {
+	procedures := yarpcjson.Procedure("get", get)
+	procedures[0].Metadata = transport.ProcedureMetadata{
+		Description:        "Gets a value.",
+		Idempotent:         true,
+		Deprecated:         true,
+		DeprecationMessage: "Use getValue instead.",
+	}
+	procedures = append(procedures, yarpcjson.Procedure("getValue", get)...)
+
+	doc := openapi.NewDocument(procedures)
+
+	op := doc.Paths["/get"].Post
+	require.NotNil(t, op)
+	assert.Equal(t, "Gets a value.\n\nDeprecated: Use getValue instead.", op.Description)
+	assert.True(t, op.Deprecated)
+	assert.True(t, op.Idempotent)
+
+	op = doc.Paths["/getValue"].Post
+	require.NotNil(t, op)
+	assert.Empty(t, op.Description)
+	assert.False(t, op.Deprecated)
+	assert.False(t, op.Idempotent)
+}
+
+func TestNewHandler(t *testing.T) {
+	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+		Name: "keyvalue",
+		InboundMiddleware: yarpc.InboundMiddleware{
+			Unary: middleware.NopUnaryInbound,
+		},
+	})
+	dispatcher.Register(yarpcjson.Procedure("get", get))
+
+	recorder := httptest.NewRecorder()
+	openapi.NewHandler(dispatcher).ServeHTTP(recorder, httptest.NewRequest("GET", "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	var doc openapi.Document
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &doc))
+	assert.Equal(t, "keyvalue", doc.Info.Title)
+	require.Contains(t, doc.Paths, "/keyvalue/get")
+	assert.Equal(t,
+		&openapi.Schema{Ref: "#/components/schemas/openapi_test.getRequest"},
+		doc.Paths["/keyvalue/get"].Post.RequestBody.Content["application/json"].Schema,
+		"types must be visible through inbound middleware")
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openapi
+
+// Option customizes the generated document.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	title   string
+	version string
+}
+
+// Title sets the title of the API.
+//
+// Defaults to the name of the dispatcher for NewHandler, and "yarpc"
+// otherwise.
+func Title(title string) Option {
+	return optionFunc(func(opts *options) {
+		opts.title = title
+	})
+}
+
+// APIVersion sets the version of the API, which is unrelated to the version
+// of the OpenAPI specification.
+//
+// Defaults to "1.0.0".
+func APIVersion(version string) Option {
+	return optionFunc(func(opts *options) {
+		opts.version = version
+	})
+}
+
+// applyOptions creates new options based on the given options.
+func applyOptions(opts ...Option) options {
+	options := options{
+		title:   "yarpc",
+		version: "1.0.0",
+	}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return options
+}
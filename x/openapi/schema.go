@@ -0,0 +1,218 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	_timeType          = reflect.TypeOf(time.Time{})
+	_jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	_textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	_protoEnumType     = reflect.TypeOf((*protoEnum)(nil)).Elem()
+)
+
+// protoEnum is implemented by generated protobuf enums, which jsonpb
+// encodes as strings.
+type protoEnum interface {
+	EnumDescriptor() ([]byte, []int)
+}
+
+// schemaBuilder builds schemas for Go types. Named struct types are added
+// to the components of the document and referenced by name, which allows
+// recursive types.
+type schemaBuilder struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// Schema returns the schema of JSON values decoded into or encoded from
+// the given type.
+func (b *schemaBuilder) Schema(t reflect.Type) *Schema {
+	switch {
+	case t == _timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Implements(_protoEnumType):
+		return &Schema{Type: "string"}
+	case implements(t, _jsonMarshalerType):
+		// Custom encodings cannot be described.
+		return &Schema{}
+	case implements(t, _textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.Schema(t.Elem())}
+	case reflect.Array:
+		return &Schema{Type: "array", Items: b.Schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.Schema(t.Elem())}
+	case reflect.Ptr:
+		return b.Schema(t.Elem())
+	case reflect.Struct:
+		return b.structSchema(t)
+	default:
+		// Interfaces may hold any value. Other kinds cannot be encoded.
+		return &Schema{}
+	}
+}
+
+func (b *schemaBuilder) structSchema(t reflect.Type) *Schema {
+	if t.Name() == "" {
+		return b.objectSchema(t)
+	}
+
+	if name, ok := b.names[t]; ok {
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+
+	name := t.String()
+	for i := 2; b.schemas[name] != nil; i++ {
+		name = fmt.Sprintf("%v_%d", t.String(), i)
+	}
+	b.names[t] = name
+	// Reserve the name before building the schema so that recursive
+	// references to this type find it.
+	b.schemas[name] = &Schema{}
+	*b.schemas[name] = *b.objectSchema(t)
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func (b *schemaBuilder) objectSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	b.addProperties(schema, t)
+	return schema
+}
+
+// addProperties adds the fields of the given struct type to the given
+// schema, flattening embedded structs like encoding/json.
+func (b *schemaBuilder) addProperties(schema *Schema, t reflect.Type) {
+	// Fields of embedded structs are added after the other fields because
+	// the fields of the outer struct take precedence.
+	var embedded []reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, asString, ok := fieldName(field)
+		if !ok {
+			continue
+		}
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			embedded = append(embedded, fieldType)
+			continue
+		}
+		if field.PkgPath != "" {
+			// Unexported.
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		if _, ok := schema.Properties[name]; ok {
+			continue
+		}
+		if asString {
+			schema.Properties[name] = &Schema{Type: "string"}
+		} else {
+			schema.Properties[name] = b.Schema(field.Type)
+		}
+	}
+
+	for _, t := range embedded {
+		b.addProperties(schema, t)
+	}
+}
+
+// fieldName returns the JSON name of the given field, or an empty name if
+// it should be derived from the Go name, and whether the field is encoded
+// as a string. ok is false for fields that are not encoded.
+func fieldName(field reflect.StructField) (name string, asString bool, ok bool) {
+	if _, isOneof := field.Tag.Lookup("protobuf_oneof"); isOneof {
+		// Oneof fields are flattened into the message by jsonpb based on
+		// the wrapper types, which cannot be discovered by reflection.
+		return "", false, false
+	}
+	if tag, isProto := field.Tag.Lookup("protobuf"); isProto {
+		// jsonpb uses the lowerCamelCase name if present, and the
+		// original name otherwise.
+		for _, option := range strings.Split(tag, ",") {
+			if strings.HasPrefix(option, "json=") {
+				return strings.TrimPrefix(option, "json="), false, true
+			}
+			if strings.HasPrefix(option, "name=") {
+				name = strings.TrimPrefix(option, "name=")
+			}
+		}
+		return name, false, true
+	}
+
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	options := strings.Split(tag, ",")
+	for _, option := range options[1:] {
+		if option == "string" {
+			asString = true
+		}
+	}
+	return options[0], asString, true
+}
+
+// implements returns whether the given type or a pointer to it implements
+// the given interface.
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || (t.Kind() != reflect.Ptr && reflect.PtrTo(t).Implements(iface))
+}
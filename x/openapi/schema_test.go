@@ -0,0 +1,149 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package openapi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/yarpc/internal/examples/protobuf/examplepb"
+)
+
+type node struct {
+	Value    string  `json:"value"`
+	Children []*node `json:"children,omitempty"`
+}
+
+type embedded struct {
+	ID    int64 `json:"id,string"`
+	Inner int   `json:"inner"`
+}
+
+type outer struct {
+	embedded
+
+	Inner    string            `json:"inner"`
+	Ignored  string            `json:"-"`
+	Created  time.Time         `json:"created"`
+	Data     []byte            `json:"data"`
+	Labels   map[string]string `json:"labels"`
+	Ratio    float32           `json:"ratio"`
+	Anything interface{}       `json:"anything"`
+	private  string
+}
+
+func TestSchema(t *testing.T) {
+	tests := []struct {
+		desc    string
+		give    interface{}
+		want    *Schema
+		schemas map[string]*Schema
+	}{
+		{desc: "bool", give: true, want: &Schema{Type: "boolean"}},
+		{desc: "int32", give: int32(0), want: &Schema{Type: "integer", Format: "int32"}},
+		{desc: "int", give: 0, want: &Schema{Type: "integer", Format: "int64"}},
+		{desc: "float64", give: 0.0, want: &Schema{Type: "number", Format: "double"}},
+		{desc: "string", give: "", want: &Schema{Type: "string"}},
+		{
+			desc: "slice",
+			give: []string{},
+			want: &Schema{Type: "array", Items: &Schema{Type: "string"}},
+		},
+		{
+			desc: "map",
+			give: map[string]interface{}{},
+			want: &Schema{Type: "object", AdditionalProperties: &Schema{}},
+		},
+		{
+			desc: "anonymous struct",
+			give: struct {
+				A bool `json:"a"`
+			}{},
+			want: &Schema{
+				Type:       "object",
+				Properties: map[string]*Schema{"a": {Type: "boolean"}},
+			},
+		},
+		{
+			desc: "recursive struct",
+			give: &node{},
+			want: &Schema{Ref: "#/components/schemas/openapi.node"},
+			schemas: map[string]*Schema{
+				"openapi.node": {
+					Type: "object",
+					Properties: map[string]*Schema{
+						"value": {Type: "string"},
+						"children": {
+							Type:  "array",
+							Items: &Schema{Ref: "#/components/schemas/openapi.node"},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc: "struct fields",
+			give: outer{},
+			want: &Schema{Ref: "#/components/schemas/openapi.outer"},
+			schemas: map[string]*Schema{
+				"openapi.outer": {
+					Type: "object",
+					Properties: map[string]*Schema{
+						"id":       {Type: "string"},
+						"inner":    {Type: "string"},
+						"created":  {Type: "string", Format: "date-time"},
+						"data":     {Type: "string", Format: "byte"},
+						"labels":   {Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+						"ratio":    {Type: "number", Format: "float"},
+						"anything": {},
+					},
+				},
+			},
+		},
+		{
+			desc: "protobuf message",
+			give: &examplepb.SetValueRequest{},
+			want: &Schema{Ref: "#/components/schemas/examplepb.SetValueRequest"},
+			schemas: map[string]*Schema{
+				"examplepb.SetValueRequest": {
+					Type: "object",
+					Properties: map[string]*Schema{
+						"key":   {Type: "string"},
+						"value": {Type: "string"},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			b := newSchemaBuilder()
+			assert.Equal(t, tt.want, b.Schema(reflect.TypeOf(tt.give)))
+			if tt.schemas == nil {
+				tt.schemas = map[string]*Schema{}
+			}
+			assert.Equal(t, tt.schemas, b.schemas)
+		})
+	}
+}
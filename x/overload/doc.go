@@ -0,0 +1,53 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package overload provides inbound middleware that sheds load when the
+// process shows signs of overload.
+//
+// Concurrency limits need to know in advance how much work a service can
+// take. The Middleware instead watches the process: the fraction of time
+// spent in garbage collection pauses, the number of goroutines, the fraction
+// of time the container's CPU was throttled, and the size of the heap. When
+// any signal crosses its threshold, the service is considered overloaded and
+// the Middleware rejects a fraction of requests with ResourceExhausted
+// errors, which well-behaved callers retry elsewhere or back off from. The
+// service stays overloaded until every signal falls below a fraction of its
+// threshold, so that shedding doesn't flap on and off around a threshold.
+//
+// 	shed := overload.New(
+// 		overload.MaxGCPauseFraction(0.1),
+// 		overload.MaxGoroutines(10000),
+// 		overload.MaxCPUThrottledFraction(0.25),
+// 		overload.MaxHeapBytes(4<<30),
+// 		overload.ShedFraction(0.3),
+// 	)
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  shed,
+// 			Oneway: shed,
+// 		},
+// 	})
+//
+// Signals are sampled on the request path at most once per interval, so an
+// idle service does no work. Signals without a threshold are ignored. CPU
+// throttling is read from the cgroup of the process on Linux, and ignored
+// where it's not available.
+package overload
@@ -0,0 +1,186 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package overload
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var (
+	_ middleware.UnaryInbound  = (*Middleware)(nil)
+	_ middleware.OnewayInbound = (*Middleware)(nil)
+	_ middleware.Named         = (*Middleware)(nil)
+)
+
+// Middleware is unary and oneway inbound middleware that rejects a fraction
+// of requests while the process is overloaded.
+type Middleware struct {
+	opts options
+
+	mu         sync.Mutex
+	last       sample
+	sampledAt  time.Time
+	overloaded bool
+	// shed accumulates the shed fraction for each request while overloaded;
+	// a request is rejected each time it reaches one. This spreads rejected
+	// requests evenly instead of relying on chance.
+	shed float64
+
+	overloadedGauge *metrics.Gauge
+	breaches        *metrics.CounterVector
+	rejected        *metrics.CounterVector
+}
+
+// New builds a new overload Middleware.
+func New(opts ...Option) *Middleware {
+	m := &Middleware{opts: applyOptions(opts...)}
+	m.last = m.opts.sample()
+	m.sampledAt = m.opts.clock.Now()
+
+	meter := m.opts.meter
+	m.overloadedGauge, _ = meter.Gauge(metrics.Spec{
+		Name: "overloaded",
+		Help: "Whether the process is overloaded and sheds requests.",
+	})
+	m.breaches, _ = meter.CounterVector(metrics.Spec{
+		Name:    "overload_breaches",
+		Help:    "Number of samples in which a signal was above its threshold.",
+		VarTags: []string{"signal"},
+	})
+	m.rejected, _ = meter.CounterVector(metrics.Spec{
+		Name:    "overload_rejected",
+		Help:    "Number of requests rejected because the process was overloaded.",
+		VarTags: []string{"procedure"},
+	})
+	return m
+}
+
+// MiddlewareName implements middleware.Named.
+func (m *Middleware) MiddlewareName() string { return "overload" }
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	if err := m.admit(req); err != nil {
+		return err
+	}
+	return h.Handle(ctx, req, resw)
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	if err := m.admit(req); err != nil {
+		return err
+	}
+	return h.HandleOneway(ctx, req)
+}
+
+// admit returns an error if the request must be rejected.
+func (m *Middleware) admit(req *transport.Request) error {
+	m.mu.Lock()
+	now := m.opts.clock.Now()
+	if now.Sub(m.sampledAt) >= m.opts.interval {
+		m.update(m.opts.sample(), now)
+	}
+	reject := false
+	if m.overloaded {
+		m.shed += m.opts.shedFraction
+		if m.shed >= 1 {
+			m.shed--
+			reject = true
+		}
+	}
+	m.mu.Unlock()
+
+	if !reject {
+		return nil
+	}
+	if counter, err := m.rejected.Get("procedure", req.Procedure); err == nil {
+		counter.Inc()
+	}
+	return yarpcerrors.ResourceExhaustedErrorf(
+		"service %q is overloaded, rejected request to procedure %q", req.Service, req.Procedure)
+}
+
+// update records a new sample of the signals and decides whether the process
+// is overloaded. It must be called with the lock held.
+func (m *Middleware) update(s sample, now time.Time) {
+	elapsed := now.Sub(m.sampledAt)
+	levels := []struct {
+		signal string
+		level  float64
+	}{
+		{"gc_pause", level(fraction(s.gcPause-m.last.gcPause, elapsed), m.opts.maxGCPauseFraction)},
+		{"goroutines", level(float64(s.goroutines), float64(m.opts.maxGoroutines))},
+		{"cpu_throttled", level(fraction(s.throttled-m.last.throttled, elapsed), m.opts.maxCPUThrottledFraction)},
+		{"heap_bytes", level(float64(s.heapBytes), float64(m.opts.maxHeapBytes))},
+	}
+	m.last = s
+	m.sampledAt = now
+
+	breached, recovered := false, true
+	for _, l := range levels {
+		if l.level >= 1 {
+			breached = true
+			if counter, err := m.breaches.Get("signal", l.signal); err == nil {
+				counter.Inc()
+			}
+		}
+		if l.level >= m.opts.recovery {
+			recovered = false
+		}
+	}
+
+	switch {
+	case breached:
+		m.overloaded = true
+	case recovered:
+		m.overloaded = false
+		m.shed = 0
+	}
+	if m.overloaded {
+		m.overloadedGauge.Store(1)
+	} else {
+		m.overloadedGauge.Store(0)
+	}
+}
+
+// level returns how close a signal is to its threshold, where one means it's
+// at the threshold, or zero if the signal has no threshold.
+func level(value, threshold float64) float64 {
+	if threshold <= 0 {
+		return 0
+	}
+	return value / threshold
+}
+
+func fraction(d, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(d) / float64(elapsed)
+}
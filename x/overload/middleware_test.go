@@ -0,0 +1,129 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package overload
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type nopHandler struct{}
+
+func (nopHandler) Handle(context.Context, *transport.Request, transport.ResponseWriter) error {
+	return nil
+}
+
+// handle sends n requests through the middleware and returns how many were
+// rejected.
+func handle(t *testing.T, m *Middleware, n int) int {
+	rejected := 0
+	for i := 0; i < n; i++ {
+		err := m.Handle(context.Background(), &transport.Request{Service: "service", Procedure: "echo"}, nil, nopHandler{})
+		if err != nil {
+			require.True(t, yarpcerrors.IsResourceExhausted(err), "unexpected error: %v", err)
+			rejected++
+		}
+	}
+	return rejected
+}
+
+func counter(root *metrics.Root, name, tag, value string) int64 {
+	for _, s := range root.Snapshot().Counters {
+		if s.Name == name && s.Tags[tag] == value {
+			return s.Value
+		}
+	}
+	return 0
+}
+
+func TestShedsWhileOverloaded(t *testing.T) {
+	fake := clock.NewFake()
+	root := metrics.New()
+	var s sample
+	m := New(
+		MaxGoroutines(100),
+		ShedFraction(0.25),
+		Recovery(0.5),
+		Interval(time.Second),
+		Metrics(root.Scope()),
+		withClock(fake),
+		withSampler(func() sample { return s }),
+	)
+
+	assert.Zero(t, handle(t, m, 8), "expected no rejections below the threshold")
+
+	// Signals are only sampled once per interval.
+	s.goroutines = 100
+	assert.Zero(t, handle(t, m, 8), "expected no rejections before the next sample")
+
+	fake.Add(time.Second)
+	assert.Equal(t, 2, handle(t, m, 8))
+	assert.Equal(t, int64(1), counter(root, "overload_breaches", "signal", "goroutines"))
+	assert.Equal(t, int64(2), counter(root, "overload_rejected", "procedure", "echo"))
+
+	// Below the threshold but above the recovery level, the process is
+	// still overloaded.
+	s.goroutines = 60
+	fake.Add(time.Second)
+	assert.Equal(t, 2, handle(t, m, 8))
+
+	s.goroutines = 40
+	fake.Add(time.Second)
+	assert.Zero(t, handle(t, m, 8), "expected no rejections after recovery")
+}
+
+func TestCumulativeSignals(t *testing.T) {
+	fake := clock.NewFake()
+	var s sample
+	m := New(
+		MaxGCPauseFraction(0.1),
+		MaxCPUThrottledFraction(0.5),
+		ShedFraction(1),
+		withClock(fake),
+		withSampler(func() sample { return s }),
+	)
+
+	// 50ms of pauses in a second is below 10%.
+	s.gcPause = 50 * time.Millisecond
+	fake.Add(time.Second)
+	assert.Zero(t, handle(t, m, 1))
+
+	// 200ms more of pauses in a second is above 10%.
+	s.gcPause += 200 * time.Millisecond
+	fake.Add(time.Second)
+	assert.Equal(t, 1, handle(t, m, 1))
+
+	// Throttling keeps the process overloaded even though pauses stopped.
+	s.throttled = 600 * time.Millisecond
+	fake.Add(time.Second)
+	assert.Equal(t, 1, handle(t, m, 1))
+
+	fake.Add(time.Second)
+	assert.Zero(t, handle(t, m, 1))
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package overload
+
+import (
+	"time"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/internal/clock"
+)
+
+const (
+	_defaultShedFraction = 0.5
+	_defaultRecovery     = 0.8
+	_defaultInterval     = time.Second
+)
+
+// Option customizes the behavior of a Middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	maxGCPauseFraction      float64
+	maxGoroutines           int
+	maxCPUThrottledFraction float64
+	maxHeapBytes            uint64
+
+	shedFraction float64
+	recovery     float64
+	interval     time.Duration
+	meter        *metrics.Scope
+	clock        clock.Clock
+	sample       func() sample
+}
+
+// MaxGCPauseFraction specifies the fraction of time, between 0 and 1, the
+// process may spend in garbage collection pauses before it's overloaded. By
+// default, garbage collection pauses are ignored.
+func MaxGCPauseFraction(f float64) Option {
+	return optionFunc(func(opts *options) {
+		opts.maxGCPauseFraction = f
+	})
+}
+
+// MaxGoroutines specifies the number of goroutines the process may run
+// before it's overloaded. By default, goroutines are ignored.
+func MaxGoroutines(n int) Option {
+	return optionFunc(func(opts *options) {
+		opts.maxGoroutines = n
+	})
+}
+
+// MaxCPUThrottledFraction specifies the fraction of time the CPU of the
+// cgroup of the process may be throttled before it's overloaded. By default,
+// CPU throttling is ignored.
+func MaxCPUThrottledFraction(f float64) Option {
+	return optionFunc(func(opts *options) {
+		opts.maxCPUThrottledFraction = f
+	})
+}
+
+// MaxHeapBytes specifies the number of bytes of allocated heap objects the
+// process may hold before it's overloaded. By default, the size of the heap
+// is ignored.
+func MaxHeapBytes(n uint64) Option {
+	return optionFunc(func(opts *options) {
+		opts.maxHeapBytes = n
+	})
+}
+
+// ShedFraction specifies the fraction of requests, between 0 and 1,
+// rejected while the process is overloaded. Defaults to 0.5.
+func ShedFraction(f float64) Option {
+	return optionFunc(func(opts *options) {
+		opts.shedFraction = f
+	})
+}
+
+// Recovery specifies the fraction of its threshold, between 0 and 1, that
+// every signal must fall below for the process to no longer be overloaded.
+// Defaults to 0.8.
+func Recovery(f float64) Option {
+	return optionFunc(func(opts *options) {
+		opts.recovery = f
+	})
+}
+
+// Interval specifies how often signals are sampled. Defaults to one second.
+func Interval(d time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.interval = d
+	})
+}
+
+// Metrics specifies the scope to which metrics about overload are reported.
+// By default, no metrics are reported.
+func Metrics(meter *metrics.Scope) Option {
+	return optionFunc(func(opts *options) {
+		opts.meter = meter
+	})
+}
+
+func withClock(clock clock.Clock) Option {
+	return optionFunc(func(opts *options) {
+		opts.clock = clock
+	})
+}
+
+func withSampler(f func() sample) Option {
+	return optionFunc(func(opts *options) {
+		opts.sample = f
+	})
+}
+
+func applyOptions(opts ...Option) options {
+	options := options{
+		shedFraction: _defaultShedFraction,
+		recovery:     _defaultRecovery,
+		interval:     _defaultInterval,
+		clock:        clock.NewReal(),
+		sample:       sampleRuntime,
+	}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return options
+}
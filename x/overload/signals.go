@@ -0,0 +1,99 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package overload
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sample is a reading of the signals of the process. Pause and throttled
+// times are cumulative; the Middleware divides their growth between samples
+// by the time between them.
+type sample struct {
+	gcPause    time.Duration
+	goroutines int
+	throttled  time.Duration
+	heapBytes  uint64
+}
+
+func sampleRuntime() sample {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return sample{
+		gcPause:    time.Duration(stats.PauseTotalNs),
+		goroutines: runtime.NumGoroutine(),
+		throttled:  cgroupThrottled(),
+		heapBytes:  stats.HeapAlloc,
+	}
+}
+
+// Files holding the CPU statistics of the cgroup of the process, for
+// cgroups v2 and v1, with the key and unit of the throttled time in each.
+var _cgroupCPUStats = []struct {
+	path string
+	key  string
+	unit time.Duration
+}{
+	{"/sys/fs/cgroup/cpu.stat", "throttled_usec", time.Microsecond},
+	{"/sys/fs/cgroup/cpu/cpu.stat", "throttled_time", time.Nanosecond},
+	{"/sys/fs/cgroup/cpu,cpuacct/cpu.stat", "throttled_time", time.Nanosecond},
+}
+
+// cgroupThrottled returns how long the CPU of the cgroup of the process was
+// throttled, or zero if that's not available.
+func cgroupThrottled() time.Duration {
+	for _, stats := range _cgroupCPUStats {
+		f, err := os.Open(stats.path)
+		if err != nil {
+			continue
+		}
+		throttled, ok := parseCPUStat(f, stats.key)
+		f.Close()
+		if ok {
+			return throttled * stats.unit
+		}
+	}
+	return 0
+}
+
+// parseCPUStat reads the value of a key from a cpu.stat file of a cgroup,
+// which holds a key and a value separated by a space on each line.
+func parseCPUStat(r io.Reader, key string) (time.Duration, bool) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || fields[0] != key {
+			continue
+		}
+		v, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		return time.Duration(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peersim
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Distribution samples durations, such as the latency of a peer, using the
+// random number generator of the simulation.
+type Distribution func(*rand.Rand) time.Duration
+
+// Constant is a Distribution that always returns d.
+func Constant(d time.Duration) Distribution {
+	return func(*rand.Rand) time.Duration { return d }
+}
+
+// Uniform is a Distribution of durations between min and max, inclusive.
+func Uniform(min, max time.Duration) Distribution {
+	return func(r *rand.Rand) time.Duration {
+		if max <= min {
+			return min
+		}
+		return min + time.Duration(r.Int63n(int64(max-min)+1))
+	}
+}
+
+// Exponential is a Distribution of exponentially distributed durations with
+// the given mean, a common model of service times with a long tail.
+func Exponential(mean time.Duration) Distribution {
+	return func(r *rand.Rand) time.Duration {
+		return time.Duration(r.ExpFloat64() * float64(mean))
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package peersim simulates peer selection, to evaluate changes to peer
+// lists before they reach production.
+//
+// A simulation runs a peer.ChooserList against a fleet of simulated peers
+// with their own latency and error distributions, sending it a generated
+// load while peers go down and come back up. Time in the simulation is
+// virtual, so a simulation of minutes of traffic runs in moments, and runs
+// with the same seed are reproducible.
+//
+// 	result, err := peersim.Run(
+// 		func(t peer.Transport) peer.ChooserList {
+// 			return roundrobin.New(t, roundrobin.Seed(1))
+// 		},
+// 		[]peersim.Peer{
+// 			{ID: "fast", Latency: peersim.Exponential(10 * time.Millisecond)},
+// 			{ID: "slow", Latency: peersim.Exponential(50 * time.Millisecond)},
+// 			{ID: "flaky", Latency: peersim.Constant(10 * time.Millisecond), ErrorRate: 0.1},
+// 		},
+// 		peersim.Requests(100000),
+// 		peersim.Churn(time.Second, peersim.Uniform(time.Second, 5*time.Second)),
+// 	)
+//
+// The result reports how the requests were distributed across peers and the
+// latency of the requests, including how much requests waited behind others
+// on slow peers.
+package peersim
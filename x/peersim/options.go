@@ -0,0 +1,86 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peersim
+
+import "time"
+
+const (
+	_defaultRequests = 10000
+	_defaultRate     = 1000
+)
+
+// Option customizes a simulation.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	seed          int64
+	requests      int
+	rate          float64
+	churnInterval time.Duration
+	downtime      Distribution
+}
+
+// Seed specifies the seed of the random number generator of the simulation,
+// which samples arrivals, latencies, errors, and churn.
+//
+// Defaults to 0, so that simulations are reproducible.
+func Seed(seed int64) Option {
+	return optionFunc(func(opts *options) {
+		opts.seed = seed
+	})
+}
+
+// Requests specifies the number of requests the simulation sends.
+//
+// Defaults to 10000.
+func Requests(n int) Option {
+	return optionFunc(func(opts *options) {
+		opts.requests = n
+	})
+}
+
+// Rate specifies the average number of requests per second the simulation
+// sends. Requests arrive as a Poisson process.
+//
+// Defaults to 1000.
+func Rate(perSecond float64) Option {
+	return optionFunc(func(opts *options) {
+		opts.rate = perSecond
+	})
+}
+
+// Churn specifies that a random available peer goes down on average every
+// interval, and comes back up after a downtime sampled from the given
+// distribution.
+//
+// Peers do not churn by default.
+func Churn(interval time.Duration, downtime Distribution) Option {
+	return optionFunc(func(opts *options) {
+		opts.churnInterval = interval
+		opts.downtime = downtime
+	})
+}
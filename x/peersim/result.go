@@ -0,0 +1,129 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peersim
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Result reports the outcome of a simulation.
+type Result struct {
+	// Requests is the number of requests sent.
+	Requests int
+	// Unchosen is the number of requests for which the peer list could not
+	// choose a peer.
+	Unchosen int
+	// Failed is the number of requests that failed on the chosen peer.
+	Failed int
+	// Duration is the virtual time the simulation took.
+	Duration time.Duration
+
+	// Peers reports the requests each peer received, in the order the peers
+	// were given.
+	Peers []PeerResult
+
+	// Fairness is Jain's fairness index of the number of requests each peer
+	// received: 1 if all peers received as many requests, down to 1/n if a
+	// single of n peers received them all.
+	Fairness float64
+
+	// Latency summarizes the latency of requests sent to a peer.
+	Latency LatencySummary
+}
+
+// PeerResult reports the requests a simulated peer received.
+type PeerResult struct {
+	ID string
+	// Requests is the number of requests the peer received.
+	Requests int
+	// Errors is the number of requests that failed on the peer.
+	Errors int
+	// MaxPending is the largest number of requests pending on the peer at
+	// once.
+	MaxPending int
+	// Outages is the number of times the peer went down.
+	Outages int
+}
+
+// LatencySummary summarizes a distribution of latencies.
+type LatencySummary struct {
+	Mean time.Duration
+	P50  time.Duration
+	P90  time.Duration
+	P99  time.Duration
+	Max  time.Duration
+}
+
+// String returns a human-readable report of the result.
+func (r *Result) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "requests: %d, unchosen: %d, failed: %d, duration: %v\n",
+		r.Requests, r.Unchosen, r.Failed, r.Duration)
+	fmt.Fprintf(&buf, "fairness: %.4f\n", r.Fairness)
+	l := r.Latency
+	fmt.Fprintf(&buf, "latency: mean %v, p50 %v, p90 %v, p99 %v, max %v\n",
+		l.Mean, l.P50, l.P90, l.P99, l.Max)
+	for _, p := range r.Peers {
+		fmt.Fprintf(&buf, "peer %s: requests %d, errors %d, max pending %d, outages %d\n",
+			p.ID, p.Requests, p.Errors, p.MaxPending, p.Outages)
+	}
+	return buf.String()
+}
+
+func summarize(latencies []time.Duration) LatencySummary {
+	if len(latencies) == 0 {
+		return LatencySummary{}
+	}
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, l := range sorted {
+		total += l
+	}
+	quantile := func(q float64) time.Duration {
+		return sorted[int(q*float64(len(sorted)-1))]
+	}
+	return LatencySummary{
+		Mean: total / time.Duration(len(sorted)),
+		P50:  quantile(0.5),
+		P90:  quantile(0.9),
+		P99:  quantile(0.99),
+		Max:  sorted[len(sorted)-1],
+	}
+}
+
+// fairness returns Jain's fairness index of the requests each peer received.
+func fairness(peers []PeerResult) float64 {
+	var sum, sumSquares float64
+	for _, p := range peers {
+		x := float64(p.Requests)
+		sum += x
+		sumSquares += x * x
+	}
+	if sumSquares == 0 {
+		return 1
+	}
+	return sum * sum / (float64(len(peers)) * sumSquares)
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peersim
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// Peer describes a simulated peer.
+type Peer struct {
+	// ID identifies the peer to the peer list.
+	ID string
+
+	// Latency is the distribution of the time the peer takes to handle a
+	// request on its own.
+	Latency Distribution
+
+	// PendingLatency is added to the latency of a request for every other
+	// request pending on the peer when it is chosen, modelling a peer that
+	// slows down under load.
+	PendingLatency time.Duration
+
+	// ErrorRate is the probability, between 0 and 1, that a request to the
+	// peer fails.
+	ErrorRate float64
+}
+
+// Run simulates sending requests through the peer list built by newList to
+// the given peers, and reports how the peer list distributed them.
+//
+// The peer list receives all peers as soon as it starts. Choosing a peer
+// never blocks: if no peer is available, the request fails immediately and
+// counts as unchosen.
+func Run(newList func(peer.Transport) peer.ChooserList, peers []Peer, opts ...Option) (_ *Result, err error) {
+	options := options{
+		requests: _defaultRequests,
+		rate:     _defaultRate,
+	}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	if options.requests <= 0 {
+		return nil, errors.New("the number of requests must be greater than 0")
+	}
+	if options.rate <= 0 {
+		return nil, errors.New("the request rate must be greater than 0")
+	}
+	if options.churnInterval > 0 && options.downtime == nil {
+		return nil, errors.New("churn requires a downtime distribution")
+	}
+
+	f, err := newFleet(peers)
+	if err != nil {
+		return nil, err
+	}
+	list := newList(f)
+	if err := list.Start(); err != nil {
+		return nil, err
+	}
+	defer func() { err = multierr.Append(err, list.Stop()) }()
+
+	ids := make([]peer.Identifier, 0, len(peers))
+	for _, p := range peers {
+		ids = append(ids, hostport.PeerIdentifier(p.ID))
+	}
+	if err := list.Update(peer.ListUpdates{Additions: ids}); err != nil {
+		return nil, err
+	}
+
+	s := &simulation{
+		list:    list,
+		fleet:   f,
+		opts:    options,
+		rand:    rand.New(rand.NewSource(options.seed)),
+		request: &transport.Request{Caller: "peersim", Service: "peersim", Procedure: "peersim"},
+	}
+	return s.run(peers), nil
+}
+
+// simulation is the state of a running simulation. Events run one at a
+// time, so it needs no locking.
+type simulation struct {
+	list    peer.ChooserList
+	fleet   *fleet
+	opts    options
+	rand    *rand.Rand
+	events  events
+	request *transport.Request
+
+	sent      int
+	unchosen  int
+	failed    int
+	latencies []time.Duration
+}
+
+func (s *simulation) run(peers []Peer) *Result {
+	s.events.schedule(0, s.arrive)
+	if s.opts.churnInterval > 0 {
+		s.events.schedule(s.sample(Exponential(s.opts.churnInterval)), s.churn)
+	}
+	s.events.run()
+
+	result := &Result{
+		Requests: s.sent,
+		Unchosen: s.unchosen,
+		Failed:   s.failed,
+		Duration: s.events.now,
+		Latency:  summarize(s.latencies),
+	}
+	for _, p := range peers {
+		result.Peers = append(result.Peers, s.fleet.peers[p.ID].stats)
+	}
+	result.Fairness = fairness(result.Peers)
+	return result
+}
+
+func (s *simulation) sample(d Distribution) time.Duration {
+	if v := d(s.rand); v > 0 {
+		return v
+	}
+	return 0
+}
+
+// arrive sends a request and schedules the next one.
+func (s *simulation) arrive() {
+	s.sent++
+	if s.sent < s.opts.requests {
+		next := time.Duration(s.rand.ExpFloat64() / s.opts.rate * float64(time.Second))
+		s.events.schedule(next, s.arrive)
+	}
+
+	// An expired context makes peer lists fail rather than wait for a peer
+	// when none is available, since no time passes while they wait.
+	ctx, cancel := context.WithDeadline(context.Background(), time.Time{})
+	defer cancel()
+	chosen, onFinish, err := s.list.Choose(ctx, s.request)
+	if err != nil {
+		s.unchosen++
+		return
+	}
+
+	p, ok := s.fleet.peers[chosen.Identifier()]
+	if !ok {
+		// The peer list returned a peer that it did not retain from the
+		// fleet; count it as not chosen rather than fail the simulation.
+		onFinish(nil)
+		s.unchosen++
+		return
+	}
+
+	pending := p.Status().PendingRequestCount
+	p.stats.Requests++
+	if pending > p.stats.MaxPending {
+		p.stats.MaxPending = pending
+	}
+	latency := s.sample(p.spec.Latency)
+	if pending > 1 {
+		latency += time.Duration(pending-1) * p.spec.PendingLatency
+	}
+	var callErr error
+	if s.rand.Float64() < p.spec.ErrorRate {
+		callErr = yarpcerrors.InternalErrorf("simulated error from peer %q", p.spec.ID)
+	}
+
+	s.events.schedule(latency, func() {
+		if callErr != nil {
+			p.stats.Errors++
+			s.failed++
+		}
+		s.latencies = append(s.latencies, latency)
+		onFinish(callErr)
+	})
+}
+
+// churn takes a random available peer down, schedules it to come back up,
+// and schedules the next churn while requests remain to be sent.
+func (s *simulation) churn() {
+	if s.sent >= s.opts.requests {
+		return
+	}
+	s.events.schedule(s.sample(Exponential(s.opts.churnInterval)), s.churn)
+
+	var available []*simPeer
+	for _, id := range s.fleet.order {
+		if p := s.fleet.peers[id]; p.Status().ConnectionStatus == peer.Available {
+			available = append(available, p)
+		}
+	}
+	if len(available) == 0 {
+		return
+	}
+	p := available[s.rand.Intn(len(available))]
+	p.SetStatus(peer.Unavailable)
+	p.stats.Outages++
+	s.events.schedule(s.sample(s.opts.downtime), func() {
+		p.SetStatus(peer.Available)
+	})
+}
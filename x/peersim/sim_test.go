@@ -0,0 +1,157 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peersim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/peer/pendingheap"
+	"go.uber.org/yarpc/peer/roundrobin"
+)
+
+func newRoundRobin(t peer.Transport) peer.ChooserList {
+	return roundrobin.New(t, roundrobin.Seed(1))
+}
+
+func newPendingHeap(t peer.Transport) peer.ChooserList {
+	return pendingheap.New(t, pendingheap.Seed(1))
+}
+
+func TestRoundRobinIsFair(t *testing.T) {
+	result, err := Run(newRoundRobin, []Peer{
+		{ID: "a", Latency: Exponential(10 * time.Millisecond)},
+		{ID: "b", Latency: Exponential(10 * time.Millisecond)},
+		{ID: "c", Latency: Exponential(10 * time.Millisecond)},
+	}, Requests(3000))
+	require.NoError(t, err)
+
+	assert.Equal(t, 3000, result.Requests)
+	assert.Equal(t, 0, result.Unchosen)
+	assert.Equal(t, 0, result.Failed)
+	assert.InDelta(t, 1, result.Fairness, 0.0001)
+	require.Len(t, result.Peers, 3)
+	for _, p := range result.Peers {
+		assert.Equal(t, 1000, p.Requests, "peer %v", p.ID)
+	}
+	assert.True(t, result.Latency.P50 <= result.Latency.P99, "latency quantiles must be ordered")
+	assert.True(t, result.Duration > 2*time.Second, "3000 requests at 1000/s take about 3s, took %v", result.Duration)
+}
+
+func TestPendingHeapAvoidsSlowPeers(t *testing.T) {
+	peers := []Peer{
+		{ID: "fast", Latency: Constant(time.Millisecond), PendingLatency: time.Millisecond},
+		{ID: "slow", Latency: Constant(20 * time.Millisecond), PendingLatency: 20 * time.Millisecond},
+	}
+	rr, err := Run(newRoundRobin, peers, Rate(200))
+	require.NoError(t, err)
+	ph, err := Run(newPendingHeap, peers, Rate(200))
+	require.NoError(t, err)
+
+	assert.True(t, ph.Peers[0].Requests > ph.Peers[1].Requests,
+		"fewest pending requests must favor the fast peer: %v", ph)
+	assert.True(t, ph.Fairness < rr.Fairness)
+	assert.True(t, ph.Latency.P99 < rr.Latency.P99,
+		"fewest pending requests p99 %v must beat round robin p99 %v", ph.Latency.P99, rr.Latency.P99)
+}
+
+func TestReproducible(t *testing.T) {
+	peers := []Peer{
+		{ID: "a", Latency: Uniform(time.Millisecond, 10*time.Millisecond), ErrorRate: 0.1},
+		{ID: "b", Latency: Exponential(5 * time.Millisecond)},
+	}
+	opts := []Option{Seed(42), Requests(1000), Churn(100*time.Millisecond, Constant(50*time.Millisecond))}
+
+	first, err := Run(newPendingHeap, peers, opts...)
+	require.NoError(t, err)
+	second, err := Run(newPendingHeap, peers, opts...)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+}
+
+func TestChurn(t *testing.T) {
+	result, err := Run(newRoundRobin, []Peer{
+		{ID: "only", Latency: Constant(time.Millisecond)},
+	}, Requests(1000), Churn(100*time.Millisecond, Constant(100*time.Millisecond)))
+	require.NoError(t, err)
+
+	assert.True(t, result.Peers[0].Outages > 0, "peer must go down")
+	assert.True(t, result.Unchosen > 0, "requests must fail while the only peer is down")
+	assert.Equal(t, result.Requests, result.Unchosen+result.Peers[0].Requests)
+}
+
+func TestErrors(t *testing.T) {
+	result, err := Run(newRoundRobin, []Peer{
+		{ID: "broken", Latency: Constant(time.Millisecond), ErrorRate: 1},
+		{ID: "healthy", Latency: Constant(time.Millisecond)},
+	}, Requests(100))
+	require.NoError(t, err)
+
+	assert.Equal(t, 50, result.Failed)
+	assert.Equal(t, 50, result.Peers[0].Errors)
+	assert.Equal(t, 0, result.Peers[1].Errors)
+	assert.Contains(t, result.String(), "peer broken: requests 50, errors 50")
+}
+
+func TestRunInvalid(t *testing.T) {
+	valid := []Peer{{ID: "a", Latency: Constant(time.Millisecond)}}
+	tests := []struct {
+		msg   string
+		peers []Peer
+		opts  []Option
+	}{
+		{msg: "duplicate peer", peers: []Peer{valid[0], valid[0]}},
+		{msg: "no latency", peers: []Peer{{ID: "a"}}},
+		{msg: "no requests", peers: valid, opts: []Option{Requests(0)}},
+		{msg: "no rate", peers: valid, opts: []Option{Rate(0)}},
+		{msg: "churn without downtime", peers: valid, opts: []Option{Churn(time.Second, nil)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := Run(newRoundRobin, tt.peers, tt.opts...)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	var latencies []time.Duration
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, LatencySummary{
+		Mean: 50500 * time.Microsecond,
+		P50:  50 * time.Millisecond,
+		P90:  90 * time.Millisecond,
+		P99:  99 * time.Millisecond,
+		Max:  100 * time.Millisecond,
+	}, summarize(latencies))
+	assert.Equal(t, LatencySummary{}, summarize(nil))
+}
+
+func TestFairness(t *testing.T) {
+	assert.Equal(t, 1.0, fairness([]PeerResult{{Requests: 5}, {Requests: 5}}))
+	assert.Equal(t, 0.5, fairness([]PeerResult{{Requests: 10}, {Requests: 0}}))
+	assert.Equal(t, 1.0, fairness(nil))
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peersim
+
+import (
+	"fmt"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/peer/hostport"
+)
+
+// fleet is a peer.Transport that retains the simulated peers. The
+// simulation changes the status of the peers to simulate churn.
+type fleet struct {
+	peers map[string]*simPeer
+	order []string
+}
+
+var _ peer.Transport = (*fleet)(nil)
+
+// simPeer is a simulated peer and the statistics of the requests it
+// received.
+type simPeer struct {
+	*hostport.Peer
+
+	spec  Peer
+	stats PeerResult
+}
+
+func newFleet(specs []Peer) (*fleet, error) {
+	f := &fleet{peers: make(map[string]*simPeer, len(specs))}
+	for _, spec := range specs {
+		if _, ok := f.peers[spec.ID]; ok {
+			return nil, fmt.Errorf("duplicate peer %q", spec.ID)
+		}
+		if spec.Latency == nil {
+			return nil, fmt.Errorf("peer %q has no latency distribution", spec.ID)
+		}
+		p := &simPeer{
+			Peer:  hostport.NewPeer(hostport.PeerIdentifier(spec.ID), f),
+			spec:  spec,
+			stats: PeerResult{ID: spec.ID},
+		}
+		p.SetStatus(peer.Available)
+		f.peers[spec.ID] = p
+		f.order = append(f.order, spec.ID)
+	}
+	return f, nil
+}
+
+func (f *fleet) RetainPeer(id peer.Identifier, sub peer.Subscriber) (peer.Peer, error) {
+	p, ok := f.peers[id.Identifier()]
+	if !ok {
+		return nil, fmt.Errorf("peer %q is not part of the simulated fleet", id.Identifier())
+	}
+	p.Subscribe(sub)
+	return p, nil
+}
+
+func (f *fleet) ReleasePeer(id peer.Identifier, sub peer.Subscriber) error {
+	p, ok := f.peers[id.Identifier()]
+	if !ok {
+		return peer.ErrTransportHasNoReferenceToPeer{
+			TransportName:  "peersim",
+			PeerIdentifier: id.Identifier(),
+		}
+	}
+	return p.Unsubscribe(sub)
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package protohttp makes protobuf procedures reachable at RESTful paths with
+// JSON bodies on the HTTP inbound, as declared by google.api.http annotations.
+//
+// protoc-gen-yarpc-go generates a Build<Service>YARPCHTTPRules function for
+// every service with google.api.http annotations. Pass its rules to NewMux
+// and install the Mux as an interceptor on the HTTP inbound:
+//
+// 	mux, err := protohttp.NewMux("keyvalue", kvpb.BuildKeyValueYARPCHTTPRules())
+// 	if err != nil {
+// 		return err
+// 	}
+// 	inbound := http.NewTransport().NewInbound(":8080", http.Interceptor(mux.Interceptor))
+//
+// Requests matching a rule are translated into YARPC requests for the
+// corresponding procedure using the JSON encoding, and are then handled by
+// the HTTP inbound like any other request, including inbound middleware.
+// All other requests are passed to the inbound unchanged.
+//
+// Request messages are built from the request body, path variables and query
+// parameters following the google.api.http rules:
+//
+// 	option (google.api.http) = {
+// 		get: "/v1/{key}"
+// 	};
+//
+// routes "GET /v1/foo?version=3" to the procedure with the request
+// {"key": "foo", "version": "3"}. Path variables and query parameters are
+// passed as strings, which the JSON encoding accepts for string, numeric, and
+// enum fields.
+package protohttp
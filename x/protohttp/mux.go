@@ -0,0 +1,225 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package protohttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/yarpc/encoding/protobuf"
+	yarpchttp "go.uber.org/yarpc/transport/http"
+)
+
+const (
+	_defaultCaller  = "protohttp"
+	_defaultTimeout = time.Minute
+)
+
+// MuxOption customizes the behavior of a Mux.
+type MuxOption interface {
+	apply(*muxOptions)
+}
+
+type muxOptionFunc func(*muxOptions)
+
+func (f muxOptionFunc) apply(options *muxOptions) { f(options) }
+
+type muxOptions struct {
+	caller  string
+	timeout time.Duration
+}
+
+// Caller specifies the caller name for requests that do not specify one with
+// the Rpc-Caller header. Defaults to "protohttp".
+func Caller(caller string) MuxOption {
+	return muxOptionFunc(func(opts *muxOptions) {
+		opts.caller = caller
+	})
+}
+
+// Timeout specifies the timeout for requests that do not specify one with
+// the Context-TTL-MS header. Defaults to one minute.
+func Timeout(timeout time.Duration) MuxOption {
+	return muxOptionFunc(func(opts *muxOptions) {
+		opts.timeout = timeout
+	})
+}
+
+// Mux routes RESTful HTTP requests to procedures according to Rules.
+type Mux struct {
+	service string
+	routes  []route
+	opts    muxOptions
+}
+
+type route struct {
+	rule     Rule
+	template *pathTemplate
+}
+
+// NewMux builds a Mux that routes requests matching the given rules to
+// procedures of the given YARPC service.
+//
+// Rules are tried in order and the first matching rule is used. An error is
+// returned if any of the rules has an invalid path template.
+func NewMux(service string, rules []Rule, opts ...MuxOption) (*Mux, error) {
+	options := muxOptions{
+		caller:  _defaultCaller,
+		timeout: _defaultTimeout,
+	}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+
+	routes := make([]route, 0, len(rules))
+	for _, rule := range rules {
+		template, err := parseTemplate(rule.Path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule for %q: %v", rule.Procedure, err)
+		}
+		routes = append(routes, route{rule: rule, template: template})
+	}
+	return &Mux{service: service, routes: routes, opts: options}, nil
+}
+
+// Interceptor wraps the HTTP inbound's handler so that requests matching the
+// Mux's rules are translated into YARPC requests. Use it with the
+// http.Interceptor inbound option.
+func (m *Mux) Interceptor(yarpcHandler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		for _, r := range m.routes {
+			if r.rule.HTTPMethod != req.Method {
+				continue
+			}
+			vars, ok := r.template.match(req.URL.EscapedPath())
+			if !ok {
+				continue
+			}
+			m.serve(w, req, r.rule, vars, yarpcHandler)
+			return
+		}
+		yarpcHandler.ServeHTTP(w, req)
+	})
+}
+
+func (m *Mux) serve(w http.ResponseWriter, req *http.Request, rule Rule, vars map[string]string, yarpcHandler http.Handler) {
+	body, err := buildBody(req, rule, vars)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	yarpcReq, err := http.NewRequest(http.MethodPost, req.URL.Path, bytes.NewReader(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	yarpcReq = yarpcReq.WithContext(req.Context())
+	yarpcReq.RemoteAddr = req.RemoteAddr
+	for k, v := range req.Header {
+		yarpcReq.Header[k] = v
+	}
+	yarpcReq.Header.Set(yarpchttp.ServiceHeader, m.service)
+	yarpcReq.Header.Set(yarpchttp.ProcedureHeader, rule.Procedure)
+	yarpcReq.Header.Set(yarpchttp.EncodingHeader, string(protobuf.JSONEncoding))
+	if yarpcReq.Header.Get(yarpchttp.CallerHeader) == "" {
+		yarpcReq.Header.Set(yarpchttp.CallerHeader, m.opts.caller)
+	}
+	if yarpcReq.Header.Get(yarpchttp.TTLMSHeader) == "" {
+		ttl := int64(m.opts.timeout / time.Millisecond)
+		yarpcReq.Header.Set(yarpchttp.TTLMSHeader, strconv.FormatInt(ttl, 10))
+	}
+	yarpcHandler.ServeHTTP(w, yarpcReq)
+}
+
+// buildBody builds the JSON request message from the body, path variables,
+// and query parameters of the request.
+func buildBody(req *http.Request, rule Rule, vars map[string]string) ([]byte, error) {
+	message := make(map[string]interface{})
+
+	if rule.Body != "" {
+		data, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %v", err)
+		}
+		if len(bytes.TrimSpace(data)) > 0 {
+			if rule.Body == "*" {
+				err = json.Unmarshal(data, &message)
+			} else {
+				var value interface{}
+				if err = json.Unmarshal(data, &value); err == nil {
+					err = setField(message, rule.Body, value)
+				}
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode request body: %v", err)
+			}
+		}
+	}
+
+	// Query parameters populate the fields not bound by the body.
+	if rule.Body != "*" {
+		for name, values := range req.URL.Query() {
+			var value interface{} = values[0]
+			if len(values) > 1 {
+				value = values
+			}
+			if err := setField(message, name, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for field, value := range vars {
+		if err := setField(message, field, value); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(message)
+}
+
+// setField sets the field at the given dot-separated path of the message to
+// the given value, creating nested messages as needed.
+func setField(message map[string]interface{}, path string, value interface{}) error {
+	names := strings.Split(path, ".")
+	for _, name := range names[:len(names)-1] {
+		next, ok := message[name]
+		if !ok {
+			nested := make(map[string]interface{})
+			message[name] = nested
+			message = nested
+			continue
+		}
+		nested, ok := next.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("field %q of %q is not a message", name, path)
+		}
+		message = nested
+	}
+	message[names[len(names)-1]] = value
+	return nil
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package protohttp
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/internal/examples/protobuf/examplepb"
+	yarpchttp "go.uber.org/yarpc/transport/http"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type keyValueServer struct {
+	sync.Mutex
+
+	items  map[string]string
+	caller string
+}
+
+func (s *keyValueServer) GetValue(ctx context.Context, req *examplepb.GetValueRequest) (*examplepb.GetValueResponse, error) {
+	s.Lock()
+	defer s.Unlock()
+	s.caller = yarpc.CallFromContext(ctx).Caller()
+	value, ok := s.items[req.Key]
+	if !ok {
+		return nil, yarpcerrors.NotFoundErrorf("key %q not found", req.Key)
+	}
+	return &examplepb.GetValueResponse{Value: value}, nil
+}
+
+func (s *keyValueServer) SetValue(ctx context.Context, req *examplepb.SetValueRequest) (*examplepb.SetValueResponse, error) {
+	s.Lock()
+	defer s.Unlock()
+	s.items[req.Key] = req.Value
+	return &examplepb.SetValueResponse{}, nil
+}
+
+var _keyValueRules = []Rule{
+	{
+		Procedure:  "uber.yarpc.internal.examples.protobuf.example.KeyValue::GetValue",
+		HTTPMethod: http.MethodGet,
+		Path:       "/v1/keys/{key}",
+	},
+	{
+		Procedure:  "uber.yarpc.internal.examples.protobuf.example.KeyValue::SetValue",
+		HTTPMethod: http.MethodPut,
+		Path:       "/v1/keys/{key}",
+		Body:       "value",
+	},
+	{
+		Procedure:  "uber.yarpc.internal.examples.protobuf.example.KeyValue::SetValue",
+		HTTPMethod: http.MethodPost,
+		Path:       "/v1/keys",
+		Body:       "*",
+	},
+}
+
+// startServer starts a dispatcher serving the KeyValue service with the
+// given rules and returns the base URL of its HTTP inbound.
+func startServer(t *testing.T, server *keyValueServer, opts ...MuxOption) (string, func()) {
+	mux, err := NewMux("keyvalue", _keyValueRules, opts...)
+	require.NoError(t, err)
+
+	inbound := yarpchttp.NewTransport().NewInbound("127.0.0.1:0", yarpchttp.Interceptor(mux.Interceptor))
+	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+		Name:     "keyvalue",
+		Inbounds: yarpc.Inbounds{inbound},
+	})
+	dispatcher.Register(examplepb.BuildKeyValueYARPCProcedures(server))
+	require.NoError(t, dispatcher.Start())
+	return fmt.Sprintf("http://%s", inbound.Addr()), func() { assert.NoError(t, dispatcher.Stop()) }
+}
+
+func do(t *testing.T, method, url, body string, header http.Header) (int, string) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	return res.StatusCode, string(data)
+}
+
+func TestMux(t *testing.T) {
+	server := &keyValueServer{items: map[string]string{"foo": "bar"}}
+	url, stop := startServer(t, server)
+	defer stop()
+
+	t.Run("get with path variable", func(t *testing.T) {
+		status, body := do(t, http.MethodGet, url+"/v1/keys/foo", "", nil)
+		assert.Equal(t, http.StatusOK, status)
+		assert.JSONEq(t, `{"value": "bar"}`, body)
+		assert.Equal(t, _defaultCaller, server.caller)
+	})
+
+	t.Run("caller header", func(t *testing.T) {
+		status, _ := do(t, http.MethodGet, url+"/v1/keys/foo", "", http.Header{"Rpc-Caller": {"curl"}})
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "curl", server.caller)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		status, body := do(t, http.MethodGet, url+"/v1/keys/baz", "", nil)
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.Contains(t, body, `key "baz" not found`)
+	})
+
+	t.Run("body field", func(t *testing.T) {
+		status, _ := do(t, http.MethodPut, url+"/v1/keys/hello", `"world"`, nil)
+		assert.Equal(t, http.StatusOK, status)
+
+		status, body := do(t, http.MethodGet, url+"/v1/keys/hello", "", nil)
+		assert.Equal(t, http.StatusOK, status)
+		assert.JSONEq(t, `{"value": "world"}`, body)
+	})
+
+	t.Run("whole body", func(t *testing.T) {
+		status, _ := do(t, http.MethodPost, url+"/v1/keys", `{"key": "a", "value": "b"}`, nil)
+		assert.Equal(t, http.StatusOK, status)
+		server.Lock()
+		assert.Equal(t, "b", server.items["a"])
+		server.Unlock()
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		status, body := do(t, http.MethodPost, url+"/v1/keys", `{`, nil)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Contains(t, body, "failed to decode request body")
+	})
+
+	t.Run("unmatched requests reach the inbound", func(t *testing.T) {
+		status, _ := do(t, http.MethodDelete, url+"/v1/keys/foo", "", nil)
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+}
+
+func TestBuildBodyQueryParameters(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "/v1/keys/foo?a.b=1&c=2&c=3", nil)
+	require.NoError(t, err)
+
+	body, err := buildBody(req, Rule{}, map[string]string{"key": "foo"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"key": "foo", "a": {"b": "1"}, "c": ["2", "3"]}`, string(body))
+}
+
+func TestSetFieldConflict(t *testing.T) {
+	message := map[string]interface{}{"a": "1"}
+	err := setField(message, "a.b", "2")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `field "a" of "a.b" is not a message`)
+}
+
+func TestNewMuxInvalidRule(t *testing.T) {
+	_, err := NewMux("keyvalue", []Rule{{Procedure: "foo", HTTPMethod: http.MethodGet, Path: "v1"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid rule for "foo"`)
+}
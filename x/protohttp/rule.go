@@ -0,0 +1,217 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package protohttp
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Rule maps HTTP requests to a procedure, as declared by a google.api.http
+// annotation.
+type Rule struct {
+	// Procedure is the name of the procedure, for example,
+	// "uber.yarpc.KeyValue::GetValue".
+	Procedure string
+
+	// HTTPMethod is the HTTP method that requests must use, for example,
+	// "GET".
+	HTTPMethod string
+
+	// Path is the path template that requests must match, for example,
+	// "/v1/{key}".
+	Path string
+
+	// Body is the name of the request field to which the HTTP request body
+	// is mapped. "*" maps the body to the whole request message, and an empty
+	// string means that the request has no body.
+	Body string
+}
+
+type segmentKind int
+
+const (
+	literalSegment      segmentKind = iota // foo
+	wildcardSegment                        // *
+	deepWildcardSegment                    // **
+)
+
+type segment struct {
+	kind    segmentKind
+	literal string
+}
+
+// variable binds the path components matched by segments [start, end) to a
+// request field.
+type variable struct {
+	field      string
+	start, end int
+}
+
+// pathTemplate is a parsed google.api.http path template.
+type pathTemplate struct {
+	segments  []segment
+	variables []variable
+	verb      string
+}
+
+// parseTemplate parses a path template of the form
+//
+// 	Template = "/" Segments [ Verb ] ;
+// 	Segments = Segment { "/" Segment } ;
+// 	Segment  = "*" | "**" | LITERAL | Variable ;
+// 	Variable = "{" FieldPath [ "=" Segments ] "}" ;
+// 	Verb     = ":" LITERAL ;
+//
+// A "**" segment may only appear last.
+func parseTemplate(path string) (*pathTemplate, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("path template %q must start with /", path)
+	}
+
+	t := &pathTemplate{}
+	rest := path[1:]
+	if i := strings.LastIndex(rest, ":"); i >= 0 && i > strings.LastIndex(rest, "/") && i > strings.LastIndex(rest, "}") {
+		t.verb = rest[i+1:]
+		rest = rest[:i]
+	}
+
+	for {
+		if strings.HasPrefix(rest, "{") {
+			end := strings.Index(rest, "}")
+			if end < 0 {
+				return nil, fmt.Errorf("path template %q has an unterminated variable", path)
+			}
+			field, pattern := rest[1:end], "*"
+			if i := strings.Index(field, "="); i >= 0 {
+				field, pattern = field[:i], field[i+1:]
+			}
+			if field == "" {
+				return nil, fmt.Errorf("path template %q has a variable without a field", path)
+			}
+			start := len(t.segments)
+			for _, s := range strings.Split(pattern, "/") {
+				seg, err := parseSegment(path, s)
+				if err != nil {
+					return nil, err
+				}
+				t.segments = append(t.segments, seg)
+			}
+			t.variables = append(t.variables, variable{field: field, start: start, end: len(t.segments)})
+			rest = rest[end+1:]
+		} else {
+			s := rest
+			if i := strings.Index(rest, "/"); i >= 0 {
+				s = rest[:i]
+			}
+			seg, err := parseSegment(path, s)
+			if err != nil {
+				return nil, err
+			}
+			t.segments = append(t.segments, seg)
+			rest = rest[len(s):]
+		}
+
+		if rest == "" {
+			break
+		}
+		if !strings.HasPrefix(rest, "/") {
+			return nil, fmt.Errorf("path template %q has an invalid segment", path)
+		}
+		rest = rest[1:]
+	}
+
+	for i, seg := range t.segments {
+		if seg.kind == deepWildcardSegment && i != len(t.segments)-1 {
+			return nil, fmt.Errorf("path template %q may only use ** in the last segment", path)
+		}
+	}
+	return t, nil
+}
+
+func parseSegment(path, s string) (segment, error) {
+	switch {
+	case s == "*":
+		return segment{kind: wildcardSegment}, nil
+	case s == "**":
+		return segment{kind: deepWildcardSegment}, nil
+	case s == "" || strings.ContainsAny(s, "{}=*"):
+		return segment{}, fmt.Errorf("path template %q has an invalid segment %q", path, s)
+	default:
+		return segment{kind: literalSegment, literal: s}, nil
+	}
+}
+
+// match matches the given escaped URL path against the template and returns
+// the values of the variables in the template.
+func (t *pathTemplate) match(escapedPath string) (map[string]string, bool) {
+	if !strings.HasPrefix(escapedPath, "/") {
+		return nil, false
+	}
+	rest := escapedPath[1:]
+	if t.verb != "" {
+		if !strings.HasSuffix(rest, ":"+t.verb) {
+			return nil, false
+		}
+		rest = strings.TrimSuffix(rest, ":"+t.verb)
+	}
+
+	components := strings.Split(rest, "/")
+	for i, c := range components {
+		unescaped, err := url.PathUnescape(c)
+		if err != nil {
+			return nil, false
+		}
+		components[i] = unescaped
+	}
+
+	// positions[i] is the index of the first component matched by segment i.
+	positions := make([]int, len(t.segments)+1)
+	n := 0
+	for i, seg := range t.segments {
+		positions[i] = n
+		switch seg.kind {
+		case deepWildcardSegment:
+			n = len(components)
+		case wildcardSegment:
+			if n >= len(components) || components[n] == "" {
+				return nil, false
+			}
+			n++
+		default:
+			if n >= len(components) || components[n] != seg.literal {
+				return nil, false
+			}
+			n++
+		}
+	}
+	if n != len(components) {
+		return nil, false
+	}
+	positions[len(t.segments)] = n
+
+	vars := make(map[string]string, len(t.variables))
+	for _, v := range t.variables {
+		vars[v.field] = strings.Join(components[positions[v.start]:positions[v.end]], "/")
+	}
+	return vars, true
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package protohttp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTemplateErrors(t *testing.T) {
+	tests := []struct {
+		path    string
+		wantErr string
+	}{
+		{path: "v1/foo", wantErr: "must start with /"},
+		{path: "/v1/{key", wantErr: "unterminated variable"},
+		{path: "/v1/{=foo}", wantErr: "variable without a field"},
+		{path: "/v1//foo", wantErr: `invalid segment ""`},
+		{path: "/v1/**/foo", wantErr: "only use ** in the last segment"},
+		{path: "/v1/{key}foo", wantErr: "invalid segment"},
+		{path: "/v1/fo*", wantErr: `invalid segment "fo*"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			_, err := parseTemplate(tt.path)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestTemplateMatch(t *testing.T) {
+	tests := []struct {
+		template string
+		path     string
+		want     map[string]string
+		wantOK   bool
+	}{
+		{
+			template: "/v1/keys",
+			path:     "/v1/keys",
+			want:     map[string]string{},
+			wantOK:   true,
+		},
+		{
+			template: "/v1/keys",
+			path:     "/v1/keys/foo",
+		},
+		{
+			template: "/v1/keys/{key}",
+			path:     "/v1/keys/foo",
+			want:     map[string]string{"key": "foo"},
+			wantOK:   true,
+		},
+		{
+			template: "/v1/keys/{key}",
+			path:     "/v1/keys/",
+		},
+		{
+			template: "/v1/keys/{key}",
+			path:     "/v1/keys/a%2Fb",
+			want:     map[string]string{"key": "a/b"},
+			wantOK:   true,
+		},
+		{
+			template: "/v1/{name=shelves/*/books/*}",
+			path:     "/v1/shelves/1/books/2",
+			want:     map[string]string{"name": "shelves/1/books/2"},
+			wantOK:   true,
+		},
+		{
+			template: "/v1/{name=shelves/*/books/*}",
+			path:     "/v1/shelves/1/magazines/2",
+		},
+		{
+			template: "/v1/{book.shelf}/{book.id}",
+			path:     "/v1/1/2",
+			want:     map[string]string{"book.shelf": "1", "book.id": "2"},
+			wantOK:   true,
+		},
+		{
+			template: "/v1/files/{path=**}",
+			path:     "/v1/files/a/b/c",
+			want:     map[string]string{"path": "a/b/c"},
+			wantOK:   true,
+		},
+		{
+			template: "/v1/*/keys",
+			path:     "/v1/anything/keys",
+			want:     map[string]string{},
+			wantOK:   true,
+		},
+		{
+			template: "/v1/keys/{key}:clear",
+			path:     "/v1/keys/foo:clear",
+			want:     map[string]string{"key": "foo"},
+			wantOK:   true,
+		},
+		{
+			template: "/v1/keys/{key}:clear",
+			path:     "/v1/keys/foo",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template+" "+tt.path, func(t *testing.T) {
+			template, err := parseTemplate(tt.template)
+			require.NoError(t, err)
+
+			got, ok := template.match(tt.path)
+			assert.Equal(t, tt.wantOK, ok)
+			if tt.wantOK {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
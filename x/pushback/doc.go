@@ -0,0 +1,47 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package pushback provides outbound middleware that backs off from
+// overloaded services, implementing cooperative backpressure on the client.
+//
+// When a call fails with a ResourceExhausted error, the middleware considers
+// the destination service overloaded for a while. During that time, a share
+// of the calls to the service fail right away with a ResourceExhausted error
+// instead of adding to its load. Each further pushback increases the share
+// of calls shed, up to a maximum. Once the service stops pushing back, calls
+// flow normally again.
+//
+// Services may tell clients how long to back off with
+// yarpcerrors.Status.WithRetryAfter, or over HTTP with the standard
+// Retry-After header. Otherwise the middleware backs off for a default
+// duration.
+//
+// 	shedder := pushback.New(pushback.Metrics(scope))
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		OutboundMiddleware: yarpc.OutboundMiddleware{
+// 			Unary:  shedder,
+// 			Oneway: shedder,
+// 		},
+// 	})
+//
+// With MaxDelay, calls that would be shed instead wait for the end of the
+// back off if it ends soon enough.
+package pushback
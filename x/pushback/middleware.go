@@ -0,0 +1,202 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package pushback
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var (
+	_ middleware.UnaryOutbound  = (*Middleware)(nil)
+	_ middleware.OnewayOutbound = (*Middleware)(nil)
+	_ middleware.Named          = (*Middleware)(nil)
+)
+
+// Middleware is outbound middleware that sheds calls to services that push
+// back.
+type Middleware struct {
+	opts options
+
+	mu       sync.Mutex
+	services map[string]*backoff
+
+	pushbacks *metrics.CounterVector
+	shed      *metrics.CounterVector
+	delayed   *metrics.CounterVector
+}
+
+// backoff is the state of a service that pushed back.
+type backoff struct {
+	until time.Time
+	ratio float64
+}
+
+// New builds a new pushback Middleware.
+func New(opts ...Option) *Middleware {
+	m := &Middleware{
+		opts:     applyOptions(opts...),
+		services: make(map[string]*backoff),
+	}
+	meter := m.opts.meter
+	m.pushbacks, _ = meter.CounterVector(metrics.Spec{
+		Name:    "pushback_received",
+		Help:    "Number of calls that failed because the service pushed back.",
+		VarTags: []string{"dest"},
+	})
+	m.shed, _ = meter.CounterVector(metrics.Spec{
+		Name:    "pushback_shed",
+		Help:    "Number of calls failed by the client without sending them.",
+		VarTags: []string{"dest"},
+	})
+	m.delayed, _ = meter.CounterVector(metrics.Spec{
+		Name:    "pushback_delayed",
+		Help:    "Number of calls delayed until the end of a back off.",
+		VarTags: []string{"dest"},
+	})
+	return m
+}
+
+// MiddlewareName implements middleware.Named.
+func (m *Middleware) MiddlewareName() string { return "pushback" }
+
+// Call sheds the unary call if its service pushed back recently, and records
+// pushback from the service otherwise.
+func (m *Middleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	if err := m.admit(ctx, req.Service); err != nil {
+		return nil, err
+	}
+	res, err := out.Call(ctx, req)
+	m.observe(req.Service, err)
+	return res, err
+}
+
+// CallOneway sheds the oneway call if its service pushed back recently, and
+// records pushback from the service otherwise.
+func (m *Middleware) CallOneway(ctx context.Context, req *transport.Request, out transport.OnewayOutbound) (transport.Ack, error) {
+	if err := m.admit(ctx, req.Service); err != nil {
+		return nil, err
+	}
+	ack, err := out.CallOneway(ctx, req)
+	m.observe(req.Service, err)
+	return ack, err
+}
+
+// admit returns an error if the call to the given service should be shed,
+// after delaying it if allowed.
+func (m *Middleware) admit(ctx context.Context, service string) error {
+	m.mu.Lock()
+	b, ok := m.services[service]
+	var (
+		until time.Time
+		ratio float64
+	)
+	if ok {
+		until, ratio = b.until, b.ratio
+	}
+	m.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	now := m.opts.clock.Now()
+	wait := until.Sub(now)
+	if wait <= 0 {
+		m.mu.Lock()
+		if m.services[service] == b && !b.until.After(now) {
+			delete(m.services, service)
+		}
+		m.mu.Unlock()
+		return nil
+	}
+	if m.opts.random() >= ratio {
+		return nil
+	}
+
+	if m.canDelay(ctx, now, wait) {
+		inc(m.delayed, service)
+		select {
+		case <-m.opts.clock.After(wait):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	inc(m.shed, service)
+	return yarpcerrors.Newf(yarpcerrors.CodeResourceExhausted,
+		"call to service %q was shed by the client because the service is overloaded", service).
+		WithRetryAfter(wait)
+}
+
+func (m *Middleware) canDelay(ctx context.Context, now time.Time, wait time.Duration) bool {
+	if wait > m.opts.maxDelay {
+		return false
+	}
+	deadline, ok := ctx.Deadline()
+	return !ok || now.Add(wait).Before(deadline)
+}
+
+// observe records pushback from the service if the call failed with a
+// ResourceExhausted error.
+func (m *Middleware) observe(service string, err error) {
+	if !yarpcerrors.IsResourceExhausted(err) {
+		return
+	}
+	inc(m.pushbacks, service)
+
+	d, ok := yarpcerrors.FromError(err).RetryAfter()
+	if !ok {
+		d = m.opts.backoff
+	}
+	if d > m.opts.maxBackoff {
+		d = m.opts.maxBackoff
+	}
+	until := m.opts.clock.Now().Add(d)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, ok := m.services[service]
+	if !ok {
+		b = &backoff{}
+		m.services[service] = b
+	}
+	if until.After(b.until) {
+		b.until = until
+	}
+	b.ratio += m.opts.step
+	if b.ratio > m.opts.maxRatio {
+		b.ratio = m.opts.maxRatio
+	}
+}
+
+func inc(cv *metrics.CounterVector, service string) {
+	if counter, err := cv.Get("dest", service); err == nil {
+		counter.Inc()
+	}
+}
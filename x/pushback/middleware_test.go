@@ -0,0 +1,196 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package pushback
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// outbound is a unary outbound that counts its calls and fails them with err.
+type outbound struct {
+	transport.UnaryOutbound
+
+	calls int
+	err   error
+}
+
+func (o *outbound) Call(context.Context, *transport.Request) (*transport.Response, error) {
+	o.calls++
+	return &transport.Response{}, o.err
+}
+
+func call(m *Middleware, out transport.UnaryOutbound) error {
+	_, err := m.Call(context.Background(), &transport.Request{Service: "svc"}, out)
+	return err
+}
+
+func TestPushback(t *testing.T) {
+	fake := clock.NewFake()
+	roll := 0.0
+	m := New(withClock(fake), withRandom(func() float64 { return roll }), Step(0.5), MaxRatio(0.75))
+	out := &outbound{err: yarpcerrors.ResourceExhaustedErrorf("overloaded")}
+
+	require.Error(t, call(m, out))
+	require.Equal(t, 1, out.calls)
+
+	out.err = nil
+	roll = 0.49
+	err := call(m, out)
+	require.Error(t, err, "call must be shed")
+	assert.True(t, yarpcerrors.IsResourceExhausted(err))
+	assert.Equal(t, 1, out.calls, "shed call must not be sent")
+	retryAfter, ok := yarpcerrors.FromError(err).RetryAfter()
+	assert.True(t, ok)
+	assert.Equal(t, time.Second, retryAfter)
+
+	roll = 0.5
+	require.NoError(t, call(m, out), "calls beyond the ratio must be sent")
+	assert.Equal(t, 2, out.calls)
+
+	// Another pushback increases the ratio up to the maximum.
+	out.err = yarpcerrors.ResourceExhaustedErrorf("overloaded")
+	require.Error(t, call(m, out))
+	out.err = nil
+	roll = 0.74
+	require.Error(t, call(m, out))
+	roll = 0.75
+	require.NoError(t, call(m, out))
+
+	// Once the back off ends, all calls are sent.
+	fake.Add(time.Second)
+	roll = 0
+	require.NoError(t, call(m, out))
+	assert.Empty(t, m.services)
+}
+
+func TestPushbackRetryAfter(t *testing.T) {
+	fake := clock.NewFake()
+	m := New(withClock(fake), withRandom(func() float64 { return 0 }), MaxBackoff(time.Minute))
+	out := &outbound{
+		err: yarpcerrors.Newf(yarpcerrors.CodeResourceExhausted, "overloaded").WithRetryAfter(10 * time.Second),
+	}
+
+	require.Error(t, call(m, out))
+	out.err = nil
+
+	fake.Add(9 * time.Second)
+	err := call(m, out)
+	require.Error(t, err)
+	retryAfter, _ := yarpcerrors.FromError(err).RetryAfter()
+	assert.Equal(t, time.Second, retryAfter)
+
+	fake.Add(time.Second)
+	require.NoError(t, call(m, out))
+}
+
+func TestPushbackMaxBackoff(t *testing.T) {
+	fake := clock.NewFake()
+	m := New(withClock(fake), withRandom(func() float64 { return 0 }), MaxBackoff(2*time.Second))
+	out := &outbound{
+		err: yarpcerrors.Newf(yarpcerrors.CodeResourceExhausted, "overloaded").WithRetryAfter(time.Hour),
+	}
+
+	require.Error(t, call(m, out))
+	out.err = nil
+	require.Error(t, call(m, out))
+	fake.Add(2 * time.Second)
+	require.NoError(t, call(m, out))
+}
+
+func TestPushbackIgnoresOtherErrors(t *testing.T) {
+	m := New(withRandom(func() float64 { return 0 }))
+	out := &outbound{err: yarpcerrors.UnavailableErrorf("down")}
+
+	require.Error(t, call(m, out))
+	require.Error(t, call(m, out))
+	assert.Equal(t, 2, out.calls)
+	assert.Empty(t, m.services)
+}
+
+func TestPushbackIsPerService(t *testing.T) {
+	m := New(withRandom(func() float64 { return 0 }))
+	out := &outbound{err: yarpcerrors.ResourceExhaustedErrorf("overloaded")}
+
+	require.Error(t, call(m, out))
+	out.err = nil
+	_, err := m.Call(context.Background(), &transport.Request{Service: "other"}, out)
+	require.NoError(t, err)
+	assert.Equal(t, 2, out.calls)
+}
+
+func TestPushbackDelay(t *testing.T) {
+	fake := clock.NewFake()
+	m := New(withClock(fake), withRandom(func() float64 { return 0 }), MaxDelay(time.Second))
+	out := &outbound{err: yarpcerrors.ResourceExhaustedErrorf("overloaded")}
+	require.Error(t, call(m, out))
+	out.err = nil
+
+	done := make(chan error)
+	go func() { done <- call(m, out) }()
+
+	select {
+	case <-done:
+		t.Fatal("call must wait for the end of the back off")
+	case <-time.After(10 * time.Millisecond):
+	}
+	fake.Add(time.Second)
+	require.NoError(t, <-done)
+	assert.Equal(t, 2, out.calls)
+
+	// Calls whose deadline ends before the back off are shed.
+	out.err = yarpcerrors.ResourceExhaustedErrorf("overloaded")
+	require.Error(t, call(m, out))
+	ctx, cancel := context.WithDeadline(context.Background(), fake.Now().Add(500*time.Millisecond))
+	defer cancel()
+	_, err := m.Call(ctx, &transport.Request{Service: "svc"}, out)
+	assert.True(t, yarpcerrors.IsResourceExhausted(err))
+	assert.Equal(t, 3, out.calls)
+}
+
+type onewayOutbound struct {
+	transport.OnewayOutbound
+
+	err error
+}
+
+func (o *onewayOutbound) CallOneway(context.Context, *transport.Request) (transport.Ack, error) {
+	return nil, o.err
+}
+
+func TestPushbackOneway(t *testing.T) {
+	m := New(withRandom(func() float64 { return 0 }))
+	out := middleware.ApplyOnewayOutbound(&onewayOutbound{err: yarpcerrors.ResourceExhaustedErrorf("overloaded")}, m)
+
+	_, err := out.CallOneway(context.Background(), &transport.Request{Service: "svc"})
+	require.Error(t, err)
+	_, err = out.CallOneway(context.Background(), &transport.Request{Service: "svc"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "shed by the client")
+}
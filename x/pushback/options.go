@@ -0,0 +1,137 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package pushback
+
+import (
+	"math/rand"
+	"time"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/internal/clock"
+)
+
+const (
+	_defaultBackoff    = time.Second
+	_defaultMaxBackoff = 30 * time.Second
+	_defaultStep       = 0.25
+	_defaultMaxRatio   = 0.9
+)
+
+// Option customizes the behavior of a Middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	backoff    time.Duration
+	maxBackoff time.Duration
+	step       float64
+	maxRatio   float64
+	maxDelay   time.Duration
+	meter      *metrics.Scope
+	clock      clock.Clock
+	random     func() float64
+}
+
+// Backoff specifies how long to back off from a service that pushes back
+// without saying for how long. Defaults to one second.
+func Backoff(d time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.backoff = d
+	})
+}
+
+// MaxBackoff caps how long to back off from a service, regardless of the
+// duration it asks for. Defaults to 30 seconds.
+func MaxBackoff(d time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.maxBackoff = d
+	})
+}
+
+// Step specifies by how much each pushback from a service increases the
+// share of calls to that service that are shed, as a ratio between 0 and 1.
+// Defaults to 0.25.
+func Step(step float64) Option {
+	return optionFunc(func(opts *options) {
+		opts.step = step
+	})
+}
+
+// MaxRatio caps the share of calls to a service that are shed, as a ratio
+// between 0 and 1. Keeping some calls flowing lets clients notice when the
+// service recovers. Defaults to 0.9.
+func MaxRatio(ratio float64) Option {
+	return optionFunc(func(opts *options) {
+		opts.maxRatio = ratio
+	})
+}
+
+// MaxDelay specifies how long calls may wait for the end of a back off
+// instead of being shed. Calls are only delayed if the back off ends within
+// both this duration and their deadline. Defaults to zero, which sheds calls
+// right away.
+func MaxDelay(d time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.maxDelay = d
+	})
+}
+
+// Metrics specifies the scope to which metrics about shed calls are
+// reported. By default, no metrics are reported.
+func Metrics(meter *metrics.Scope) Option {
+	return optionFunc(func(opts *options) {
+		opts.meter = meter
+	})
+}
+
+func withClock(clock clock.Clock) Option {
+	return optionFunc(func(opts *options) {
+		opts.clock = clock
+	})
+}
+
+// withRandom specifies the source of random numbers in [0, 1) used to pick
+// the calls that are shed.
+func withRandom(random func() float64) Option {
+	return optionFunc(func(opts *options) {
+		opts.random = random
+	})
+}
+
+func applyOptions(opts ...Option) options {
+	options := options{
+		backoff:    _defaultBackoff,
+		maxBackoff: _defaultMaxBackoff,
+		step:       _defaultStep,
+		maxRatio:   _defaultMaxRatio,
+		clock:      clock.NewReal(),
+		random:     rand.Float64,
+	}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return options
+}
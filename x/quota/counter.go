@@ -0,0 +1,73 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package quota
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Counter counts requests per key in fixed time windows.
+//
+// Implementations backed by a store shared by all instances of a service
+// enforce rate limits across the fleet. For example, a Redis Counter may
+// INCR the key suffixed with the start of the window, setting the key to
+// EXPIRE after the window ends.
+type Counter interface {
+	// Increment adds one to the count of the key in the window that starts
+	// at the given time and lasts for the given length, and returns the new
+	// count. Counts of past windows may be discarded.
+	Increment(ctx context.Context, key string, start time.Time, length time.Duration) (int64, error)
+}
+
+// LocalCounter is a Counter that counts requests in memory.
+type LocalCounter struct {
+	mu      sync.Mutex
+	windows map[string]window
+}
+
+type window struct {
+	start time.Time
+	count int64
+}
+
+var _ Counter = (*LocalCounter)(nil)
+
+// NewLocalCounter builds a new LocalCounter.
+func NewLocalCounter() *LocalCounter {
+	return &LocalCounter{windows: make(map[string]window)}
+}
+
+// Increment adds one to the count of the key in the given window. Only the
+// latest window of each key is kept.
+func (c *LocalCounter) Increment(_ context.Context, key string, start time.Time, _ time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	w := c.windows[key]
+	if !w.start.Equal(start) {
+		w = window{start: start}
+	}
+	w.count++
+	c.windows[key] = w
+	return w.count, nil
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package quota provides inbound middleware that enforces per-caller request
+// quotas, protecting shared services from noisy neighbors.
+//
+// Each caller, as named by the Caller of its requests, has a Limit on the
+// rate of its requests and on the number of its requests handled
+// concurrently. Requests beyond their caller's limits fail with
+// ResourceExhausted errors.
+//
+// 	limit := quota.New(
+// 		quota.DefaultLimit(quota.Limit{Requests: 100, Per: time.Second, MaxConcurrent: 20}),
+// 		quota.CallerLimit("batch-jobs", quota.Limit{Requests: 10, Per: time.Second}),
+// 		quota.Capacity(200),
+// 	)
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  limit,
+// 			Oneway: limit,
+// 		},
+// 	})
+//
+// Request rates are counted in fixed windows by a Counter. The default
+// Counter is local to the process, so each instance of a service enforces
+// rate limits on its own; a Counter backed by a shared store, such as Redis,
+// enforces them across the fleet. Requests are allowed if the Counter fails.
+// Concurrency is always limited per instance.
+//
+// With a Capacity, the middleware also shares the concurrency of the
+// instance fairly between callers. Once half of the capacity is in use,
+// callers may only use their fair share of it, the capacity divided evenly
+// between the callers with requests in flight, so heavy callers are throttled
+// before light callers are affected.
+package quota
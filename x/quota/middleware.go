@@ -0,0 +1,179 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package quota
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+var (
+	_ middleware.UnaryInbound  = (*Middleware)(nil)
+	_ middleware.OnewayInbound = (*Middleware)(nil)
+)
+
+// Reasons for throttling requests, as reported in metrics.
+const (
+	_reasonRate        = "rate"
+	_reasonConcurrency = "concurrency"
+	_reasonFairShare   = "fair_share"
+	_reasonCapacity    = "capacity"
+)
+
+// Middleware is inbound middleware that enforces per-caller quotas.
+type Middleware struct {
+	opts options
+
+	mu       sync.Mutex
+	inflight map[string]int // by caller
+	total    int
+
+	throttled *metrics.CounterVector
+}
+
+// New builds a new quota Middleware.
+func New(opts ...Option) *Middleware {
+	m := &Middleware{
+		opts:     applyOptions(opts...),
+		inflight: make(map[string]int),
+	}
+	m.throttled, _ = m.opts.meter.CounterVector(metrics.Spec{
+		Name:    "quota_throttled",
+		Help:    "Number of requests failed because their caller exceeded its quota.",
+		VarTags: []string{"caller", "reason"},
+	})
+	return m
+}
+
+// Handle enforces the quota of the caller before handling the unary request.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	if err := m.acquire(ctx, req); err != nil {
+		return err
+	}
+	defer m.release(req.Caller)
+	return h.Handle(ctx, req, resw)
+}
+
+// HandleOneway enforces the quota of the caller before handling the oneway
+// request.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	if err := m.acquire(ctx, req); err != nil {
+		return err
+	}
+	defer m.release(req.Caller)
+	return h.HandleOneway(ctx, req)
+}
+
+func (m *Middleware) limit(caller string) Limit {
+	if limit, ok := m.opts.callerLimits[caller]; ok {
+		return limit
+	}
+	return m.opts.defaultLimit
+}
+
+// acquire counts the request against the quota of its caller, or returns an
+// error if the caller has exceeded its quota.
+func (m *Middleware) acquire(ctx context.Context, req *transport.Request) error {
+	limit := m.limit(req.Caller)
+
+	if limit.Requests > 0 && limit.Per > 0 {
+		start := m.opts.clock.Now().Truncate(limit.Per)
+		count, err := m.opts.counter.Increment(ctx, req.Caller, start, limit.Per)
+		if err != nil {
+			m.opts.logger.Warn("failed to count request towards quota",
+				zap.String("caller", req.Caller), zap.Error(err))
+		} else if count > limit.Requests {
+			return m.throttle(req, _reasonRate)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	inflight := m.inflight[req.Caller]
+	if limit.MaxConcurrent > 0 && inflight >= limit.MaxConcurrent {
+		return m.throttle(req, _reasonConcurrency)
+	}
+	if capacity := m.opts.capacity; capacity > 0 {
+		if m.total >= capacity {
+			return m.throttle(req, _reasonCapacity)
+		}
+		if 2*m.total >= capacity && inflight >= m.fairShare(inflight) {
+			return m.throttle(req, _reasonFairShare)
+		}
+	}
+	m.inflight[req.Caller] = inflight + 1
+	m.total++
+	return nil
+}
+
+// fairShare returns the share of the capacity of each caller with requests
+// in flight, counting the caller of a new request. It must be called with
+// the mutex locked.
+func (m *Middleware) fairShare(inflight int) int {
+	callers := len(m.inflight)
+	if inflight == 0 {
+		callers++
+	}
+	share := m.opts.capacity / callers
+	if share < 1 {
+		share = 1
+	}
+	return share
+}
+
+func (m *Middleware) release(caller string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.total--
+	if m.inflight[caller] <= 1 {
+		delete(m.inflight, caller)
+		return
+	}
+	m.inflight[caller]--
+}
+
+func (m *Middleware) throttle(req *transport.Request, reason string) error {
+	if c, err := m.throttled.Get("caller", req.Caller, "reason", reason); err == nil {
+		c.Inc()
+	}
+	switch reason {
+	case _reasonRate:
+		return yarpcerrors.ResourceExhaustedErrorf(
+			"caller %q exceeded its request rate quota for service %q", req.Caller, req.Service)
+	case _reasonConcurrency:
+		return yarpcerrors.ResourceExhaustedErrorf(
+			"caller %q exceeded its concurrent request quota for service %q", req.Caller, req.Service)
+	case _reasonFairShare:
+		return yarpcerrors.ResourceExhaustedErrorf(
+			"caller %q exceeded its fair share of service %q, which is busy", req.Caller, req.Service)
+	default:
+		return yarpcerrors.ResourceExhaustedErrorf(
+			"service %q is at capacity", req.Service)
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type unaryHandlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f unaryHandlerFunc) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	return f(ctx, req, resw)
+}
+
+var nopHandler = unaryHandlerFunc(func(context.Context, *transport.Request, transport.ResponseWriter) error {
+	return nil
+})
+
+func call(m *Middleware, caller string, h transport.UnaryHandler) error {
+	return m.Handle(context.Background(), &transport.Request{
+		Caller:    caller,
+		Service:   "service",
+		Procedure: "procedure",
+	}, new(transporttest.FakeResponseWriter), h)
+}
+
+// hold starts a request from the caller that is handled until release is
+// called, and returns once the request is being handled or has failed.
+func hold(t *testing.T, m *Middleware, caller string) (release func(), err error) {
+	handling := make(chan struct{})
+	done := make(chan struct{})
+	errs := make(chan error, 1)
+	go func() {
+		errs <- call(m, caller, unaryHandlerFunc(func(context.Context, *transport.Request, transport.ResponseWriter) error {
+			close(handling)
+			<-done
+			return nil
+		}))
+	}()
+	select {
+	case <-handling:
+		return func() {
+			close(done)
+			require.NoError(t, <-errs)
+		}, nil
+	case err := <-errs:
+		return func() {}, err
+	}
+}
+
+func assertThrottled(t *testing.T, err error, msg string) {
+	assert.Equal(t, yarpcerrors.CodeResourceExhausted, yarpcerrors.FromError(err).Code(), msg)
+}
+
+func TestRateLimit(t *testing.T) {
+	fakeClock := clock.NewFake()
+	m := New(
+		DefaultLimit(Limit{Requests: 2, Per: time.Second}),
+		CallerLimit("vip", Limit{}),
+		withClock(fakeClock),
+	)
+
+	assert.NoError(t, call(m, "a", nopHandler))
+	assert.NoError(t, call(m, "a", nopHandler))
+	assertThrottled(t, call(m, "a", nopHandler), "third request in window")
+	assert.NoError(t, call(m, "b", nopHandler), "other callers are unaffected")
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, call(m, "vip", nopHandler), "unlimited caller")
+	}
+
+	fakeClock.Add(time.Second)
+	assert.NoError(t, call(m, "a", nopHandler), "new window")
+}
+
+type failingCounter struct{}
+
+func (failingCounter) Increment(context.Context, string, time.Time, time.Duration) (int64, error) {
+	return 0, errors.New("great sadness")
+}
+
+func TestRateLimitCounterFailure(t *testing.T) {
+	m := New(DefaultLimit(Limit{Requests: 1, Per: time.Second}), WithCounter(failingCounter{}))
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, call(m, "a", nopHandler))
+	}
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	m := New(DefaultLimit(Limit{MaxConcurrent: 1}))
+
+	release, err := hold(t, m, "a")
+	require.NoError(t, err)
+	assertThrottled(t, call(m, "a", nopHandler), "second concurrent request")
+	assert.NoError(t, call(m, "b", nopHandler), "other callers are unaffected")
+
+	release()
+	assert.NoError(t, call(m, "a", nopHandler), "after the first request finished")
+}
+
+func TestFairShare(t *testing.T) {
+	m := New(Capacity(4))
+
+	var releases []func()
+	defer func() {
+		for _, release := range releases {
+			release()
+		}
+	}()
+	mustHold := func(caller string) {
+		release, err := hold(t, m, caller)
+		require.NoError(t, err)
+		releases = append(releases, release)
+	}
+
+	// The heavy caller may use more than its share while the service is
+	// not busy.
+	mustHold("heavy")
+	mustHold("heavy")
+	mustHold("light")
+
+	_, err := hold(t, m, "heavy")
+	assertThrottled(t, err, "heavy caller beyond its fair share")
+
+	mustHold("light")
+	_, err = hold(t, m, "other")
+	assertThrottled(t, err, "service at capacity")
+}
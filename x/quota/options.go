@@ -0,0 +1,130 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package quota
+
+import (
+	"time"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/zap"
+)
+
+// Limit is the quota of a caller. Zero fields are unlimited.
+type Limit struct {
+	// Requests is the number of requests the caller may send in each window
+	// of length Per.
+	Requests int64
+	Per      time.Duration
+
+	// MaxConcurrent is the number of requests of the caller that may be
+	// handled concurrently.
+	MaxConcurrent int
+}
+
+// Option customizes the behavior of a Middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	defaultLimit Limit
+	callerLimits map[string]Limit
+	capacity     int
+	counter      Counter
+	meter        *metrics.Scope
+	logger       *zap.Logger
+	clock        clock.Clock
+}
+
+// DefaultLimit specifies the limit of callers without a CallerLimit. By
+// default, such callers are unlimited.
+func DefaultLimit(limit Limit) Option {
+	return optionFunc(func(opts *options) {
+		opts.defaultLimit = limit
+	})
+}
+
+// CallerLimit specifies the limit of the given caller.
+func CallerLimit(caller string, limit Limit) Option {
+	return optionFunc(func(opts *options) {
+		opts.callerLimits[caller] = limit
+	})
+}
+
+// Capacity specifies the number of requests the service handles
+// concurrently that are shared fairly between callers. By default, callers
+// are only limited by their own limits.
+func Capacity(n int) Option {
+	return optionFunc(func(opts *options) {
+		opts.capacity = n
+	})
+}
+
+// WithCounter specifies the Counter that counts the requests of callers
+// towards their rate limits. Defaults to a Counter local to the process.
+func WithCounter(counter Counter) Option {
+	return optionFunc(func(opts *options) {
+		opts.counter = counter
+	})
+}
+
+// Metrics specifies the scope to which metrics about throttled requests are
+// reported. By default, no metrics are reported.
+func Metrics(meter *metrics.Scope) Option {
+	return optionFunc(func(opts *options) {
+		opts.meter = meter
+	})
+}
+
+// Logger specifies a logger for Counter failures.
+func Logger(logger *zap.Logger) Option {
+	return optionFunc(func(opts *options) {
+		opts.logger = logger
+	})
+}
+
+// withClock specifies the clock that determines rate windows. This is used
+// for testing.
+func withClock(c clock.Clock) Option {
+	return optionFunc(func(opts *options) {
+		opts.clock = c
+	})
+}
+
+func applyOptions(opts ...Option) options {
+	options := options{
+		callerLimits: make(map[string]Limit),
+		logger:       zap.NewNop(),
+		clock:        clock.NewReal(),
+	}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	if options.counter == nil {
+		options.counter = NewLocalCounter()
+	}
+	return options
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package replay rejects replayed requests to procedures that must run
+// exactly once.
+//
+// Retries, redeliveries and duplicated messages send the same request more
+// than once. Procedures that aren't idempotent, like charging a card, must
+// not act on it twice. Callers tag each logical request with a unique key in
+// an application header, reused on retries; the Middleware is unary and
+// oneway inbound middleware that remembers the keys each caller sent within
+// a time window and rejects requests whose key it has seen with an
+// AlreadyExists error.
+//
+// 	protect := replay.New(
+// 		replay.Procedures("Payments::charge", "Payments::refund"),
+// 		replay.Window(time.Hour),
+// 		replay.RequireKey(),
+// 	)
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "payments",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  protect,
+// 			Oneway: protect,
+// 		},
+// 	})
+//
+// Keys are forgotten when their handler fails, so that a caller may retry a
+// request that had no effect. Handlers that may fail after acting must be
+// made idempotent in the application instead.
+//
+// Keys are remembered in memory by default, which protects a single
+// instance of a service. Services with several instances share a Store,
+// like one backed by Redis or a database, with the WithStore option.
+package replay
@@ -0,0 +1,135 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package replay
+
+import (
+	"context"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var (
+	_ middleware.UnaryInbound  = (*Middleware)(nil)
+	_ middleware.OnewayInbound = (*Middleware)(nil)
+	_ middleware.Named         = (*Middleware)(nil)
+)
+
+// Middleware is unary and oneway inbound middleware that rejects replayed
+// requests.
+type Middleware struct {
+	opts options
+
+	replays     *metrics.CounterVector
+	storeErrors *metrics.Counter
+}
+
+// New builds a new replay protection Middleware.
+func New(opts ...Option) *Middleware {
+	m := &Middleware{opts: applyOptions(opts...)}
+	m.replays, _ = m.opts.meter.CounterVector(metrics.Spec{
+		Name:    "replays_rejected",
+		Help:    "Number of requests rejected as replays.",
+		VarTags: []string{"procedure"},
+	})
+	m.storeErrors, _ = m.opts.meter.Counter(metrics.Spec{
+		Name: "replay_store_errors",
+		Help: "Number of requests for which the replay store failed.",
+	})
+	return m
+}
+
+// MiddlewareName implements middleware.Named.
+func (m *Middleware) MiddlewareName() string { return "replay" }
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	key, err := m.remember(ctx, req)
+	if err != nil {
+		return err
+	}
+	err = h.Handle(ctx, req, resw)
+	m.forgetOnError(ctx, key, err)
+	return err
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	key, err := m.remember(ctx, req)
+	if err != nil {
+		return err
+	}
+	err = h.HandleOneway(ctx, req)
+	m.forgetOnError(ctx, key, err)
+	return err
+}
+
+// remember adds the key of the request to the store, returning the key, or
+// an error if the request must be rejected. The key is empty if the request
+// isn't protected.
+func (m *Middleware) remember(ctx context.Context, req *transport.Request) (string, error) {
+	if m.opts.procedures != nil {
+		if _, ok := m.opts.procedures[req.Procedure]; !ok {
+			return "", nil
+		}
+	}
+	id, ok := req.Headers.Get(m.opts.header)
+	if !ok || id == "" {
+		if m.opts.requireKey {
+			return "", yarpcerrors.InvalidArgumentErrorf(
+				"request to procedure %q has no %q header to protect it from replays", req.Procedure, m.opts.header)
+		}
+		return "", nil
+	}
+
+	// Keys are only unique per caller.
+	key := req.Caller + "\x00" + id
+	added, err := m.opts.store.Add(ctx, key, m.opts.window)
+	if err != nil {
+		m.storeErrors.Inc()
+		if m.opts.failOpen {
+			return "", nil
+		}
+		return "", yarpcerrors.UnavailableErrorf(
+			"failed to check request to procedure %q for replays: %v", req.Procedure, err)
+	}
+	if !added {
+		if counter, err := m.replays.Get("procedure", req.Procedure); err == nil {
+			counter.Inc()
+		}
+		return "", yarpcerrors.AlreadyExistsErrorf(
+			"request %q from caller %q to procedure %q was already received", id, req.Caller, req.Procedure)
+	}
+	return key, nil
+}
+
+// forgetOnError forgets the key of a failed request so that it may be
+// retried. Application errors are results, so their keys are kept.
+func (m *Middleware) forgetOnError(ctx context.Context, key string, err error) {
+	if key == "" || err == nil {
+		return
+	}
+	if m.opts.store.Remove(ctx, key) != nil {
+		m.storeErrors.Inc()
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package replay
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type handlerFunc func() error
+
+func (f handlerFunc) Handle(context.Context, *transport.Request, transport.ResponseWriter) error {
+	return f()
+}
+
+var ok = handlerFunc(func() error { return nil })
+
+func request(caller, procedure, id string) *transport.Request {
+	req := &transport.Request{Caller: caller, Service: "payments", Procedure: procedure}
+	if id != "" {
+		req.Headers = transport.NewHeaders().With("x-request-id", id)
+	}
+	return req
+}
+
+func handle(m *Middleware, req *transport.Request, h handlerFunc) error {
+	return m.Handle(context.Background(), req, nil, h)
+}
+
+func TestRejectsReplays(t *testing.T) {
+	m := New(Procedures("Payments::charge"))
+
+	assert.NoError(t, handle(m, request("shop", "Payments::charge", "1"), ok))
+	err := handle(m, request("shop", "Payments::charge", "1"), ok)
+	assert.Equal(t, yarpcerrors.CodeAlreadyExists, yarpcerrors.FromError(err).Code())
+
+	assert.NoError(t, handle(m, request("bank", "Payments::charge", "1"), ok), "keys are per caller")
+	assert.NoError(t, handle(m, request("shop", "Payments::charge", "2"), ok))
+	assert.NoError(t, handle(m, request("shop", "Payments::charge", ""), ok), "requests without keys are not protected")
+
+	assert.NoError(t, handle(m, request("shop", "Payments::list", "1"), ok))
+	assert.NoError(t, handle(m, request("shop", "Payments::list", "1"), ok), "other procedures are not protected")
+}
+
+func TestForgetsFailedRequests(t *testing.T) {
+	m := New()
+	fail := handlerFunc(func() error { return yarpcerrors.UnavailableErrorf("try again") })
+
+	assert.Error(t, handle(m, request("shop", "Payments::charge", "1"), fail))
+	assert.NoError(t, handle(m, request("shop", "Payments::charge", "1"), ok), "failed requests may be retried")
+	assert.Error(t, handle(m, request("shop", "Payments::charge", "1"), ok))
+}
+
+func TestRequireKey(t *testing.T) {
+	m := New(RequireKey())
+	err := handle(m, request("shop", "Payments::charge", ""), ok)
+	assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.FromError(err).Code())
+}
+
+type brokenStore struct{}
+
+func (brokenStore) Add(context.Context, string, time.Duration) (bool, error) {
+	return false, errors.New("great sadness")
+}
+
+func (brokenStore) Remove(context.Context, string) error { return nil }
+
+func TestStoreFailure(t *testing.T) {
+	err := handle(New(WithStore(brokenStore{})), request("shop", "Payments::charge", "1"), ok)
+	assert.Equal(t, yarpcerrors.CodeUnavailable, yarpcerrors.FromError(err).Code())
+
+	assert.NoError(t, handle(New(WithStore(brokenStore{}), FailOpen()), request("shop", "Payments::charge", "1"), ok))
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package replay
+
+import (
+	"time"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/x/requestid"
+)
+
+const (
+	_defaultWindow  = 10 * time.Minute
+	_defaultMaxKeys = 1000000
+)
+
+// Option customizes the behavior of a Middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	header     string
+	window     time.Duration
+	procedures map[string]struct{}
+	requireKey bool
+	failOpen   bool
+	store      Store
+	meter      *metrics.Scope
+}
+
+// Header specifies the application header that carries the keys of
+// requests. Defaults to the header of request IDs, "x-request-id".
+func Header(name string) Option {
+	return optionFunc(func(opts *options) {
+		opts.header = name
+	})
+}
+
+// Window specifies how long keys are remembered. Replays sent later than
+// that are not detected. Defaults to ten minutes.
+func Window(d time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.window = d
+	})
+}
+
+// Procedures specifies the procedures whose requests are protected from
+// replays. By default, requests to all procedures are.
+func Procedures(procedures ...string) Option {
+	return optionFunc(func(opts *options) {
+		if opts.procedures == nil {
+			opts.procedures = make(map[string]struct{}, len(procedures))
+		}
+		for _, p := range procedures {
+			opts.procedures[p] = struct{}{}
+		}
+	})
+}
+
+// RequireKey rejects protected requests without a key with an
+// InvalidArgument error. By default, they are handled unprotected.
+func RequireKey() Option {
+	return optionFunc(func(opts *options) {
+		opts.requireKey = true
+	})
+}
+
+// FailOpen handles requests unprotected when the Store fails. By default,
+// they are rejected with an Unavailable error.
+func FailOpen() Option {
+	return optionFunc(func(opts *options) {
+		opts.failOpen = true
+	})
+}
+
+// WithStore specifies where keys are remembered. Defaults to a memory
+// store of up to a million keys.
+func WithStore(store Store) Option {
+	return optionFunc(func(opts *options) {
+		opts.store = store
+	})
+}
+
+// Metrics specifies the scope to which metrics about replays are reported.
+// By default, no metrics are reported.
+func Metrics(meter *metrics.Scope) Option {
+	return optionFunc(func(opts *options) {
+		opts.meter = meter
+	})
+}
+
+func applyOptions(opts ...Option) options {
+	options := options{
+		header: requestid.DefaultHeader,
+		window: _defaultWindow,
+	}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	if options.store == nil {
+		options.store = NewMemoryStore(_defaultMaxKeys)
+	}
+	return options
+}
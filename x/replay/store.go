@@ -0,0 +1,117 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package replay
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/internal/clock"
+)
+
+// Store remembers the keys of requests.
+type Store interface {
+	// Add remembers the key for the given duration, unless it's already
+	// remembered. It returns whether the key was added; false means the
+	// request is a replay. Add must be atomic: of concurrent calls with the
+	// same key, only one may add it.
+	Add(ctx context.Context, key string, ttl time.Duration) (added bool, err error)
+
+	// Remove forgets the key.
+	Remove(ctx context.Context, key string) error
+}
+
+// NewMemoryStore builds a Store that remembers up to maxKeys keys in
+// memory. Once full, it forgets the oldest keys first, even before they
+// expire. Zero or less means no limit.
+func NewMemoryStore(maxKeys int) Store {
+	return newMemoryStore(clock.NewReal(), maxKeys)
+}
+
+type memoryStore struct {
+	clock   clock.Clock
+	maxKeys int
+
+	mu sync.Mutex
+	// keys indexes the elements of order, which holds entries oldest first.
+	keys  map[string]*list.Element
+	order *list.List
+}
+
+type entry struct {
+	key     string
+	expires time.Time
+}
+
+func newMemoryStore(clock clock.Clock, maxKeys int) *memoryStore {
+	return &memoryStore{
+		clock:   clock,
+		maxKeys: maxKeys,
+		keys:    make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (s *memoryStore) Add(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	now := s.clock.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire(now)
+	if e, ok := s.keys[key]; ok {
+		if e.Value.(*entry).expires.After(now) {
+			return false, nil
+		}
+		s.remove(e)
+	}
+	for s.maxKeys > 0 && s.order.Len() >= s.maxKeys {
+		s.remove(s.order.Front())
+	}
+	s.keys[key] = s.order.PushBack(&entry{key: key, expires: now.Add(ttl)})
+	return true, nil
+}
+
+func (s *memoryStore) Remove(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.keys[key]; ok {
+		s.remove(e)
+	}
+	return nil
+}
+
+// expire forgets expired keys from the front of the list. Keys with longer
+// TTLs may hold shorter-lived keys behind them until they expire; those
+// are checked on Add.
+func (s *memoryStore) expire(now time.Time) {
+	for e := s.order.Front(); e != nil && !e.Value.(*entry).expires.After(now); e = s.order.Front() {
+		s.remove(e)
+	}
+}
+
+func (s *memoryStore) remove(e *list.Element) {
+	s.order.Remove(e)
+	delete(s.keys, e.Value.(*entry).key)
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package replay
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/internal/clock"
+)
+
+func add(t *testing.T, s Store, key string, ttl time.Duration) bool {
+	added, err := s.Add(context.Background(), key, ttl)
+	require.NoError(t, err)
+	return added
+}
+
+func TestMemoryStore(t *testing.T) {
+	fake := clock.NewFake()
+	s := newMemoryStore(fake, 0)
+
+	assert.True(t, add(t, s, "a", time.Minute))
+	assert.False(t, add(t, s, "a", time.Minute), "expected replay")
+	assert.True(t, add(t, s, "b", time.Hour))
+
+	fake.Add(time.Minute)
+	assert.True(t, add(t, s, "a", time.Minute), "expected expired key to be added again")
+	assert.False(t, add(t, s, "b", time.Hour))
+
+	require.NoError(t, s.Remove(context.Background(), "b"))
+	assert.True(t, add(t, s, "b", time.Hour), "expected removed key to be added again")
+}
+
+func TestMemoryStoreMaxKeys(t *testing.T) {
+	s := newMemoryStore(clock.NewFake(), 2)
+
+	assert.True(t, add(t, s, "a", time.Hour))
+	assert.True(t, add(t, s, "b", time.Hour))
+	assert.True(t, add(t, s, "c", time.Hour))
+	assert.Equal(t, 2, s.order.Len())
+	assert.True(t, add(t, s, "a", time.Hour), "expected the oldest key to be forgotten")
+	assert.False(t, add(t, s, "c", time.Hour))
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package requestid provides middleware that gives every request a request
+// ID, also known as a correlation ID, and propagates it to the calls made
+// while handling the request.
+//
+// Inbound, the middleware reads the ID from the "x-request-id" application
+// header of requests, or generates one if the header is absent. Handlers get
+// the ID with FromContext. Outbound, the middleware sends the ID of the
+// context with every call, generating one if the context has none, so that
+// all calls made while handling a request share its ID.
+//
+// 	ids := requestid.New()
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  ids,
+// 			Oneway: ids,
+// 		},
+// 		OutboundMiddleware: yarpc.OutboundMiddleware{
+// 			Unary:  ids,
+// 			Oneway: ids,
+// 		},
+// 		Logging: yarpc.LoggingConfig{
+// 			ContextExtractor: requestid.ZapField,
+// 		},
+// 	})
+//
+// With ZapField as the context extractor, the dispatcher's request logs
+// include the ID. Errors returned by handlers, other than application errors,
+// are prefixed with the ID so that callers can report it.
+//
+// Streaming procedures are not supported.
+package requestid
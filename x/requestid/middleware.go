@@ -0,0 +1,187 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/responsewriter"
+	intyarpcerrors "go.uber.org/yarpc/internal/yarpcerrors"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// DefaultHeader is the application header that carries request IDs by
+// default.
+const DefaultHeader = "x-request-id"
+
+var (
+	_ middleware.UnaryInbound   = (*Middleware)(nil)
+	_ middleware.OnewayInbound  = (*Middleware)(nil)
+	_ middleware.UnaryOutbound  = (*Middleware)(nil)
+	_ middleware.OnewayOutbound = (*Middleware)(nil)
+	_ middleware.Ordered        = (*Middleware)(nil)
+)
+
+// Option customizes the behavior of a Middleware.
+type Option interface {
+	apply(*Middleware)
+}
+
+type optionFunc func(*Middleware)
+
+func (f optionFunc) apply(m *Middleware) { f(m) }
+
+// Header specifies the application header that carries request IDs.
+// Defaults to DefaultHeader.
+func Header(name string) Option {
+	return optionFunc(func(m *Middleware) {
+		m.header = name
+	})
+}
+
+// Generator specifies how request IDs are generated. By default, IDs are
+// 32 random hexadecimal digits.
+func Generator(generate func() string) Option {
+	return optionFunc(func(m *Middleware) {
+		m.generate = generate
+	})
+}
+
+// Middleware is inbound and outbound middleware that generates and
+// propagates request IDs.
+type Middleware struct {
+	header   string
+	generate func() string
+}
+
+// New builds a new Middleware.
+func New(opts ...Option) *Middleware {
+	m := &Middleware{
+		header:   DefaultHeader,
+		generate: randomID,
+	}
+	for _, opt := range opts {
+		opt.apply(m)
+	}
+	return m
+}
+
+// MiddlewareName implements middleware.Named.
+func (m *Middleware) MiddlewareName() string { return "requestid" }
+
+// MiddlewareOrdering implements middleware.Ordered. Request IDs must be
+// generated outside of retries so that all attempts share the same ID.
+func (m *Middleware) MiddlewareOrdering() middleware.Ordering {
+	return middleware.Ordering{
+		Outside: []string{"retry"},
+		Reason:  "all attempts must share the same request ID",
+	}
+}
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	ctx, id := m.inbound(ctx, req)
+	w := &responseWriter{Wrapper: responsewriter.Wrapper{ResponseWriter: resw}}
+	err := h.Handle(ctx, req, w)
+	if err != nil && !w.isApplicationError {
+		return annotate(err, id)
+	}
+	return err
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	ctx, id := m.inbound(ctx, req)
+	if err := h.HandleOneway(ctx, req); err != nil {
+		return annotate(err, id)
+	}
+	return nil
+}
+
+// Call implements middleware.UnaryOutbound.
+func (m *Middleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	return out.Call(ctx, m.outbound(ctx, req))
+}
+
+// CallOneway implements middleware.OnewayOutbound.
+func (m *Middleware) CallOneway(ctx context.Context, req *transport.Request, out transport.OnewayOutbound) (transport.Ack, error) {
+	return out.CallOneway(ctx, m.outbound(ctx, req))
+}
+
+// inbound returns the context for handling the request, carrying its ID.
+func (m *Middleware) inbound(ctx context.Context, req *transport.Request) (context.Context, string) {
+	id, ok := req.Headers.Get(m.header)
+	if !ok || id == "" {
+		id = m.generate()
+	}
+	return NewContext(ctx, id), id
+}
+
+// outbound returns a copy of the request with the request ID of the context
+// as a header. Requests that already have the header are left alone.
+func (m *Middleware) outbound(ctx context.Context, req *transport.Request) *transport.Request {
+	if _, ok := req.Headers.Get(m.header); ok {
+		return req
+	}
+	id, ok := FromContext(ctx)
+	if !ok {
+		id = m.generate()
+	}
+	r := *req
+	r.Headers = req.Headers.Clone().With(m.header, id)
+	return &r
+}
+
+// annotate prefixes the message of err with the request ID. Errors with
+// names, which are deprecated, are left alone because annotating them would
+// drop their names.
+func annotate(err error, id string) error {
+	status := yarpcerrors.FromError(err)
+	if status.Name() != "" {
+		return err
+	}
+	return intyarpcerrors.AnnotateWithInfo(status, "request %s", id)
+}
+
+func randomID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand only fails if the system has no source of randomness.
+		panic(err)
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// responseWriter records whether the handler reported an application error.
+type responseWriter struct {
+	responsewriter.Wrapper
+
+	isApplicationError bool
+}
+
+func (w *responseWriter) SetApplicationError() {
+	w.isApplicationError = true
+	w.ResponseWriter.SetApplicationError()
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package requestid
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap/zapcore"
+)
+
+type unaryHandlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f unaryHandlerFunc) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	return f(ctx, req, resw)
+}
+
+type onewayHandlerFunc func(context.Context, *transport.Request) error
+
+func (f onewayHandlerFunc) HandleOneway(ctx context.Context, req *transport.Request) error {
+	return f(ctx, req)
+}
+
+func fixedID(id string) Option {
+	return Generator(func() string { return id })
+}
+
+func TestInboundUsesHeader(t *testing.T) {
+	m := New(fixedID("generated"))
+	req := &transport.Request{
+		Procedure: "hello",
+		Headers:   transport.NewHeaders().With("x-request-id", "abc"),
+	}
+
+	var got string
+	err := m.Handle(context.Background(), req, &transporttest.FakeResponseWriter{},
+		unaryHandlerFunc(func(ctx context.Context, _ *transport.Request, _ transport.ResponseWriter) error {
+			got, _ = FromContext(ctx)
+			return nil
+		}))
+	require.NoError(t, err)
+	assert.Equal(t, "abc", got)
+}
+
+func TestInboundGeneratesID(t *testing.T) {
+	m := New(fixedID("generated"))
+
+	var got string
+	err := m.HandleOneway(context.Background(), &transport.Request{Procedure: "hello"},
+		onewayHandlerFunc(func(ctx context.Context, _ *transport.Request) error {
+			got, _ = FromContext(ctx)
+			return nil
+		}))
+	require.NoError(t, err)
+	assert.Equal(t, "generated", got)
+}
+
+func TestInboundAnnotatesErrors(t *testing.T) {
+	m := New(fixedID("abc"))
+	req := &transport.Request{Procedure: "hello"}
+
+	t.Run("transport error", func(t *testing.T) {
+		err := m.Handle(context.Background(), req, &transporttest.FakeResponseWriter{},
+			unaryHandlerFunc(func(context.Context, *transport.Request, transport.ResponseWriter) error {
+				return yarpcerrors.InternalErrorf("great sadness")
+			}))
+		require.Error(t, err)
+		assert.Equal(t, yarpcerrors.CodeInternal, yarpcerrors.FromError(err).Code())
+		assert.Equal(t, "request abc: great sadness", yarpcerrors.FromError(err).Message())
+	})
+
+	t.Run("oneway error", func(t *testing.T) {
+		err := m.HandleOneway(context.Background(), req,
+			onewayHandlerFunc(func(context.Context, *transport.Request) error {
+				return errors.New("great sadness")
+			}))
+		require.Error(t, err)
+		assert.Equal(t, "request abc: great sadness", yarpcerrors.FromError(err).Message())
+	})
+
+	t.Run("application error", func(t *testing.T) {
+		appErr := errors.New("not found")
+		err := m.Handle(context.Background(), req, &transporttest.FakeResponseWriter{},
+			unaryHandlerFunc(func(_ context.Context, _ *transport.Request, resw transport.ResponseWriter) error {
+				resw.SetApplicationError()
+				return appErr
+			}))
+		assert.Equal(t, appErr, err)
+	})
+}
+
+func TestOutboundPropagatesID(t *testing.T) {
+	tests := []struct {
+		desc    string
+		ctx     context.Context
+		headers transport.Headers
+		want    string
+	}{
+		{
+			desc: "from context",
+			ctx:  NewContext(context.Background(), "abc"),
+			want: "abc",
+		},
+		{
+			desc: "generated",
+			ctx:  context.Background(),
+			want: "generated",
+		},
+		{
+			desc:    "explicit header",
+			ctx:     NewContext(context.Background(), "abc"),
+			headers: transport.NewHeaders().With("x-request-id", "def"),
+			want:    "def",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			m := New(fixedID("generated"))
+			out := transporttest.NewFakeOutbound()
+			var got *transport.Request
+			out.ExpectCall("hello").Do(func(_ context.Context, req *transport.Request) (*transport.Response, error) {
+				got = req
+				return &transport.Response{}, nil
+			})
+
+			req := &transport.Request{Procedure: "hello", Headers: tt.headers}
+			_, err := m.Call(tt.ctx, req, out)
+			require.NoError(t, err)
+
+			id, _ := got.Headers.Get("x-request-id")
+			assert.Equal(t, tt.want, id)
+			_, ok := req.Headers.Get("x-request-id")
+			assert.Equal(t, tt.headers.Len() > 0, ok, "request must not be modified")
+		})
+	}
+}
+
+func TestHeaderOption(t *testing.T) {
+	m := New(Header("x-correlation-id"), fixedID("generated"))
+	out := transporttest.NewFakeOutbound()
+	var got *transport.Request
+	out.ExpectCall("hello").Do(func(_ context.Context, req *transport.Request) (*transport.Response, error) {
+		got = req
+		return &transport.Response{}, nil
+	})
+
+	_, err := m.Call(context.Background(), &transport.Request{Procedure: "hello"}, out)
+	require.NoError(t, err)
+	id, _ := got.Headers.Get("x-correlation-id")
+	assert.Equal(t, "generated", id)
+}
+
+func TestRandomID(t *testing.T) {
+	a, b := randomID(), randomID()
+	assert.Len(t, a, 32)
+	assert.NotEqual(t, a, b)
+}
+
+func TestZapField(t *testing.T) {
+	assert.Equal(t, zapcore.SkipType, ZapField(context.Background()).Type)
+
+	f := ZapField(NewContext(context.Background(), "abc"))
+	assert.Equal(t, "requestID", f.Key)
+	assert.Equal(t, "abc", f.String)
+}
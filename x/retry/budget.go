@@ -0,0 +1,88 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package retry
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/internal/clock"
+)
+
+// _budgetWindow is the period over which calls and retries are counted
+// against a retry budget.
+const _budgetWindow = 10 * time.Second
+
+// budget limits retries to a fraction of the calls made in a window, so
+// that retries cannot multiply the load on a service that is already
+// failing.
+type budget struct {
+	ratio      float64
+	minRetries int
+	clock      clock.Clock
+
+	mu          sync.Mutex
+	windowStart time.Time
+	calls       int
+	retries     int
+}
+
+func newBudget(ratio float64, minRetries int, clock clock.Clock) *budget {
+	return &budget{
+		ratio:       ratio,
+		minRetries:  minRetries,
+		clock:       clock,
+		windowStart: clock.Now(),
+	}
+}
+
+// call records a call made through the middleware.
+func (b *budget) call() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.roll()
+	b.calls++
+}
+
+// withdraw reports whether the budget allows another retry, and counts it if
+// it does.
+func (b *budget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.roll()
+	if float64(b.retries) >= float64(b.minRetries)+b.ratio*float64(b.calls) {
+		return false
+	}
+	b.retries++
+	return true
+}
+
+// roll starts a new window if the current one is over. The lock must be
+// held.
+func (b *budget) roll() {
+	if now := b.clock.Now(); now.Sub(b.windowStart) >= _budgetWindow {
+		b.windowStart = now
+		b.calls = 0
+		b.retries = 0
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package retry provides outbound middleware that retries failed unary
+// calls.
+//
+// By default, only calls to procedures declared idempotent are retried, and
+// only when they fail with an Unavailable error. Procedures are declared
+// idempotent for the service they belong to with
+// yarpc.RegisterIdempotentProcedures, which clients generated from Protobuf
+// methods with the idempotency_level option and from Thrift functions with
+// the idempotent annotation call automatically when they are built.
+//
+// 	retrier := retry.New(retry.MaxAttempts(3))
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		OutboundMiddleware: yarpc.OutboundMiddleware{
+// 			Unary: retrier,
+// 		},
+// 	})
+//
+// Attempts are spaced out by a backoff strategy and are never made if the
+// backoff would outlast the deadline of the call.
+//
+// A retry budget set with the Budget option bounds retries to a fraction of
+// the calls made through the middleware, so that retries do not multiply the
+// load on a service that is already failing.
+//
+// Retries prefer peers other than the ones earlier attempts failed on: the
+// middleware passes them to the peer chooser with peer.WithAvoidPeers. This
+// requires an outbound that records the peer of every attempt in the
+// transport.OutboundCallInfo of the call, like the HTTP outbound, and a peer
+// list that honors the hint, like the round-robin and peer heap lists.
+//
+// Every attempt of a call that may be retried gets its own tracing span,
+// tagged with the attempt number, the backoff waited before it, the peer that
+// received it, and whether it succeeded, was retried, or failed the call.
+package retry
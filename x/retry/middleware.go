@@ -0,0 +1,172 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package retry
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var (
+	_ middleware.UnaryOutbound = (*Middleware)(nil)
+	_ middleware.Ordered       = (*Middleware)(nil)
+)
+
+// Middleware is unary outbound middleware that retries failed calls.
+type Middleware struct {
+	opts   options
+	budget *budget // nil if retries are unlimited
+}
+
+// New builds a new retry middleware.
+func New(opts ...Option) *Middleware {
+	m := &Middleware{opts: applyOptions(opts...)}
+	if m.opts.budget {
+		m.budget = newBudget(m.opts.budgetRatio, m.opts.budgetMinRetries, m.opts.clock)
+	}
+	return m
+}
+
+// MiddlewareName implements middleware.Named.
+func (m *Middleware) MiddlewareName() string { return "retry" }
+
+// MiddlewareOrdering implements middleware.Ordered. Retries must be applied
+// outside of pushback so that every attempt respects the backoff of the
+// service.
+func (m *Middleware) MiddlewareOrdering() middleware.Ordering {
+	return middleware.Ordering{
+		Outside: []string{"pushback"},
+		Reason:  "every attempt must respect pushback",
+	}
+}
+
+// Call sends the request to the outbound, retrying it if it fails with a
+// retryable error and the request may be retried.
+func (m *Middleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	if m.budget != nil {
+		m.budget.call()
+	}
+	if m.opts.maxAttempts <= 1 || !m.opts.retryable(req) {
+		return out.Call(ctx, req)
+	}
+
+	// The body is buffered so that it can be sent again on every attempt.
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	backoff := m.opts.backoff.Backoff()
+	var (
+		failedPeers []string
+		wait        time.Duration
+	)
+	for attempt := 1; ; attempt++ {
+		attemptReq := *req
+		attemptReq.Body = bytes.NewReader(body)
+
+		// Retries prefer peers other than the ones earlier attempts failed
+		// on. Outbounds that record the peer of each attempt tell us which
+		// peer that was.
+		var info transport.OutboundCallInfo
+		attemptCtx := transport.WithOutboundCallInfo(ctx, &info)
+		if len(failedPeers) > 0 {
+			attemptCtx = peer.WithAvoidPeers(attemptCtx, failedPeers...)
+		}
+		attemptCtx, span := m.startAttemptSpan(attemptCtx, req, attempt, wait)
+		res, err := out.Call(attemptCtx, &attemptReq)
+		if info.Attempts > 0 && err != nil {
+			failedPeers = append(failedPeers, info.Peer)
+		}
+
+		retry := err != nil && attempt < m.opts.maxAttempts && m.shouldRetry(err)
+		if retry {
+			wait = backoff.Duration(uint(attempt - 1))
+			if deadline, ok := ctx.Deadline(); ok && !m.opts.clock.Now().Add(wait).Before(deadline) {
+				retry = false
+			}
+		}
+		if retry && m.budget != nil && !m.budget.withdraw() {
+			retry = false
+		}
+		finishAttemptSpan(span, info.Peer, err, retry)
+		if !retry {
+			return res, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return res, err
+		case <-m.opts.clock.After(wait):
+		}
+	}
+}
+
+// startAttemptSpan starts a span for an attempt of the call, recording how
+// long the middleware waited before making it.
+func (m *Middleware) startAttemptSpan(ctx context.Context, req *transport.Request, attempt int, backoff time.Duration) (context.Context, opentracing.Span) {
+	var parent opentracing.SpanContext // ok to be nil
+	if parentSpan := opentracing.SpanFromContext(ctx); parentSpan != nil {
+		parent = parentSpan.Context()
+	}
+	span := m.opts.tracer.StartSpan(
+		req.Procedure,
+		opentracing.ChildOf(parent),
+		opentracing.Tags{
+			"retry.attempt": attempt,
+			"retry.backoff": backoff.String(),
+		},
+	)
+	return opentracing.ContextWithSpan(ctx, span), span
+}
+
+// finishAttemptSpan records the peer and outcome of an attempt on its span.
+func finishAttemptSpan(span opentracing.Span, peer string, err error, retry bool) {
+	if peer != "" {
+		span.SetTag("retry.peer", peer)
+	}
+	switch {
+	case err == nil:
+		span.SetTag("retry.outcome", "success")
+	case retry:
+		span.SetTag("retry.outcome", "retried")
+	default:
+		span.SetTag("retry.outcome", "failed")
+	}
+	transport.UpdateSpanWithErr(span, err)
+	span.Finish()
+}
+
+func (m *Middleware) shouldRetry(err error) bool {
+	_, ok := m.opts.codes[yarpcerrors.FromError(err).Code()]
+	return ok
+}
@@ -0,0 +1,334 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package retry
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/backoff"
+	"go.uber.org/yarpc/api/backoff/backofftest"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// outbound is a unary outbound that records the bodies of its calls and
+// fails them with the given errors in turn.
+type outbound struct {
+	transport.UnaryOutbound
+
+	bodies []string
+	errs   []error
+}
+
+func (o *outbound) Call(_ context.Context, req *transport.Request) (*transport.Response, error) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	o.bodies = append(o.bodies, string(body))
+	if len(o.errs) == 0 {
+		return &transport.Response{}, nil
+	}
+	err, o.errs = o.errs[0], o.errs[1:]
+	return nil, err
+}
+
+// constant is a backoff.Strategy that always waits for the same duration.
+type constant time.Duration
+
+func (c constant) Backoff() backoff.Backoff    { return c }
+func (c constant) Duration(uint) time.Duration { return time.Duration(c) }
+
+func call(ctx context.Context, m *Middleware, procedure string, out transport.UnaryOutbound) error {
+	_, err := m.Call(ctx, &transport.Request{
+		Service:   "svc",
+		Procedure: procedure,
+		Body:      bytes.NewReader([]byte("body")),
+	}, out)
+	return err
+}
+
+func TestRetryIdempotentProcedures(t *testing.T) {
+	defer yarpc.RegisterIdempotentProcedures("svc", "KeyValue::getValue")()
+	defer yarpc.RegisterIdempotentProcedures("other", "KeyValue::setValue")()
+
+	unavailable := yarpcerrors.UnavailableErrorf("unavailable")
+	m := New(Backoff(constant(0)))
+
+	t.Run("idempotent", func(t *testing.T) {
+		out := &outbound{errs: []error{unavailable, unavailable}}
+		require.NoError(t, call(context.Background(), m, "KeyValue::getValue", out))
+		assert.Equal(t, []string{"body", "body", "body"}, out.bodies)
+	})
+
+	t.Run("not idempotent", func(t *testing.T) {
+		// KeyValue::setValue is only idempotent for another service.
+		out := &outbound{errs: []error{unavailable}}
+		assert.Equal(t, unavailable, call(context.Background(), m, "KeyValue::setValue", out))
+		assert.Len(t, out.bodies, 1)
+	})
+
+	t.Run("attempts exhausted", func(t *testing.T) {
+		out := &outbound{errs: []error{unavailable, unavailable, unavailable, unavailable}}
+		assert.Equal(t, unavailable, call(context.Background(), m, "KeyValue::getValue", out))
+		assert.Len(t, out.bodies, 3)
+	})
+
+	t.Run("not retryable code", func(t *testing.T) {
+		invalid := yarpcerrors.InvalidArgumentErrorf("invalid")
+		out := &outbound{errs: []error{invalid}}
+		assert.Equal(t, invalid, call(context.Background(), m, "KeyValue::getValue", out))
+		assert.Len(t, out.bodies, 1)
+	})
+}
+
+func TestRetryOptions(t *testing.T) {
+	internal := yarpcerrors.InternalErrorf("internal")
+	m := New(
+		MaxAttempts(2),
+		Backoff(constant(0)),
+		Retryable(func(*transport.Request) bool { return true }),
+		RetryCodes(yarpcerrors.CodeInternal),
+	)
+
+	out := &outbound{errs: []error{internal, internal}}
+	assert.Equal(t, internal, call(context.Background(), m, "KeyValue::setValue", out))
+	assert.Len(t, out.bodies, 2)
+
+	out = &outbound{errs: []error{yarpcerrors.UnavailableErrorf("unavailable")}}
+	assert.Error(t, call(context.Background(), m, "KeyValue::setValue", out))
+	assert.Len(t, out.bodies, 1)
+}
+
+func TestRetryPolicy(t *testing.T) {
+	unavailable := yarpcerrors.UnavailableErrorf("unavailable")
+	internal := yarpcerrors.InternalErrorf("internal")
+
+	tests := []struct {
+		desc         string
+		opts         []Option
+		results      []error
+		wantErr      error
+		wantAttempts int
+		wantBackoffs []uint
+	}{
+		{
+			desc:         "success",
+			results:      []error{nil},
+			wantAttempts: 1,
+		},
+		{
+			desc:         "retried until success",
+			results:      []error{unavailable, unavailable, nil},
+			wantAttempts: 3,
+			wantBackoffs: []uint{0, 1},
+		},
+		{
+			desc:         "attempts exhausted",
+			opts:         []Option{MaxAttempts(2)},
+			results:      []error{unavailable, unavailable},
+			wantErr:      unavailable,
+			wantAttempts: 2,
+			wantBackoffs: []uint{0},
+		},
+		{
+			desc:         "code not retried",
+			results:      []error{internal},
+			wantErr:      internal,
+			wantAttempts: 1,
+		},
+		{
+			desc:         "retried code",
+			opts:         []Option{RetryCodes(yarpcerrors.CodeInternal)},
+			results:      []error{internal, nil},
+			wantAttempts: 2,
+			wantBackoffs: []uint{0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			recorder := backofftest.Durations(0)
+			opts := append([]Option{
+				Backoff(recorder),
+				Retryable(func(*transport.Request) bool { return true }),
+			}, tt.opts...)
+
+			outcome := backofftest.Run(context.Background(), New(opts...), &transport.Request{
+				Service:   "svc",
+				Procedure: "proc",
+				Body:      bytes.NewReader([]byte("body")),
+			}, tt.results...)
+			assert.Equal(t, tt.wantErr, outcome.Err)
+			assert.Equal(t, tt.wantAttempts, outcome.Attempts)
+			backofftest.AssertAttempts(t, recorder, tt.wantBackoffs...)
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	fake := clock.NewFake()
+	m := New(
+		Backoff(constant(time.Second)),
+		Retryable(func(*transport.Request) bool { return true }),
+		withClock(fake),
+	)
+	unavailable := yarpcerrors.UnavailableErrorf("unavailable")
+
+	t.Run("waits for backoff", func(t *testing.T) {
+		out := &outbound{errs: []error{unavailable}}
+		done := make(chan error)
+		go func() { done <- call(context.Background(), m, "proc", out) }()
+
+		// The clock is advanced until the retry goes through since there is
+		// no telling when the middleware starts waiting.
+		for {
+			select {
+			case err := <-done:
+				require.NoError(t, err)
+				assert.Len(t, out.bodies, 2)
+				return
+			case <-time.After(time.Millisecond):
+				fake.Add(time.Second)
+			}
+		}
+	})
+
+	t.Run("backoff outlasts deadline", func(t *testing.T) {
+		ctx, cancel := context.WithDeadline(context.Background(), fake.Now().Add(time.Second))
+		defer cancel()
+
+		out := &outbound{errs: []error{unavailable}}
+		assert.Equal(t, unavailable, call(ctx, m, "proc", out))
+		assert.Len(t, out.bodies, 1)
+	})
+}
+
+// peerOutbound is a unary outbound that sends every attempt to a different
+// peer, records the peers each attempt asked to avoid, and fails all but the
+// last attempt.
+type peerOutbound struct {
+	transport.UnaryOutbound
+
+	calls    int
+	failures int
+	avoided  [][]string
+}
+
+func (o *peerOutbound) Call(ctx context.Context, _ *transport.Request) (*transport.Response, error) {
+	o.calls++
+	o.avoided = append(o.avoided, peer.AvoidPeersFromContext(ctx))
+	transport.RecordOutboundAttempt(ctx, fmt.Sprintf("peer-%d", o.calls), o.calls > 1)
+	if o.calls <= o.failures {
+		return nil, yarpcerrors.UnavailableErrorf("unavailable")
+	}
+	return &transport.Response{}, nil
+}
+
+func TestRetryAvoidsFailedPeers(t *testing.T) {
+	m := New(
+		Backoff(constant(0)),
+		Retryable(func(*transport.Request) bool { return true }),
+	)
+
+	var info transport.OutboundCallInfo
+	out := &peerOutbound{failures: 2}
+	require.NoError(t, call(transport.WithOutboundCallInfo(context.Background(), &info), m, "KeyValue::setValue", out))
+	assert.Equal(t, [][]string{nil, {"peer-1"}, {"peer-1", "peer-2"}}, out.avoided)
+	assert.Equal(t, transport.OutboundCallInfo{Peer: "peer-3", ConnectionReused: true, Attempts: 3}, info,
+		"attempts must be recorded for the caller")
+}
+
+func TestRetryAttemptSpans(t *testing.T) {
+	tracer := mocktracer.New()
+	m := New(
+		Backoff(constant(time.Millisecond)),
+		Retryable(func(*transport.Request) bool { return true }),
+		Tracer(tracer),
+	)
+
+	parent := tracer.StartSpan("parent")
+	ctx := opentracing.ContextWithSpan(context.Background(), parent)
+	out := &peerOutbound{failures: 1}
+	require.NoError(t, call(ctx, m, "KeyValue::setValue", out))
+	parent.Finish()
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 3)
+	parentID := spans[2].SpanContext.SpanID
+
+	first, second := spans[0], spans[1]
+	assert.Equal(t, "KeyValue::setValue", first.OperationName)
+	assert.Equal(t, parentID, first.ParentID)
+	assert.Equal(t, map[string]interface{}{
+		"retry.attempt": 1,
+		"retry.backoff": "0s",
+		"retry.peer":    "peer-1",
+		"retry.outcome": "retried",
+		"error":         true,
+	}, first.Tags())
+
+	assert.Equal(t, parentID, second.ParentID)
+	assert.Equal(t, map[string]interface{}{
+		"retry.attempt": 2,
+		"retry.backoff": "1ms",
+		"retry.peer":    "peer-2",
+		"retry.outcome": "success",
+	}, second.Tags())
+}
+
+func TestRetryBudget(t *testing.T) {
+	fake := clock.NewFake()
+	m := New(
+		MaxAttempts(2),
+		Backoff(constant(0)),
+		Retryable(func(*transport.Request) bool { return true }),
+		Budget(0.5, 0),
+		withClock(fake),
+	)
+	unavailable := yarpcerrors.UnavailableErrorf("unavailable")
+
+	// Each call earns half a retry, so only every other call is retried.
+	var attempts []int
+	for i := 0; i < 4; i++ {
+		out := &outbound{errs: []error{unavailable, unavailable}}
+		assert.Error(t, call(context.Background(), m, "KeyValue::setValue", out))
+		attempts = append(attempts, len(out.bodies))
+	}
+	assert.Equal(t, []int{2, 1, 2, 1}, attempts)
+
+	fake.Add(_budgetWindow)
+	out := &outbound{errs: []error{unavailable, unavailable}}
+	assert.Error(t, call(context.Background(), m, "KeyValue::setValue", out))
+	assert.Len(t, out.bodies, 2, "budget must be replenished in the next window")
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package retry
+
+import (
+	"github.com/opentracing/opentracing-go"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/backoff"
+	"go.uber.org/yarpc/api/transport"
+	ibackoff "go.uber.org/yarpc/internal/backoff"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const _defaultMaxAttempts = 3
+
+// Option customizes the behavior of a Middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	maxAttempts int
+	backoff     backoff.Strategy
+	retryable   func(*transport.Request) bool
+	codes       map[yarpcerrors.Code]struct{}
+	tracer      opentracing.Tracer
+	clock       clock.Clock
+
+	budget           bool
+	budgetRatio      float64
+	budgetMinRetries int
+}
+
+// MaxAttempts specifies how many times a call is attempted in total,
+// including the first attempt. Defaults to 3.
+func MaxAttempts(n int) Option {
+	return optionFunc(func(opts *options) {
+		opts.maxAttempts = n
+	})
+}
+
+// Backoff specifies the strategy used to decide how long to wait between
+// attempts. Defaults to an exponential backoff with full jitter starting at
+// 10 milliseconds.
+func Backoff(strategy backoff.Strategy) Option {
+	return optionFunc(func(opts *options) {
+		opts.backoff = strategy
+	})
+}
+
+// Retryable specifies which requests may be retried. By default, only
+// requests to procedures registered for their service with
+// yarpc.RegisterIdempotentProcedures are retried.
+func Retryable(f func(*transport.Request) bool) Option {
+	return optionFunc(func(opts *options) {
+		opts.retryable = f
+	})
+}
+
+// RetryCodes specifies the error codes after which calls are retried.
+// Defaults to CodeUnavailable.
+func RetryCodes(codes ...yarpcerrors.Code) Option {
+	return optionFunc(func(opts *options) {
+		opts.codes = make(map[yarpcerrors.Code]struct{}, len(codes))
+		for _, c := range codes {
+			opts.codes[c] = struct{}{}
+		}
+	})
+}
+
+// Tracer specifies the tracer used to record a span for every attempt of a
+// call that may be retried. The span carries the attempt number, the backoff
+// waited before the attempt, the peer that received it, and its outcome.
+// Defaults to the global tracer.
+func Tracer(tracer opentracing.Tracer) Option {
+	return optionFunc(func(opts *options) {
+		opts.tracer = tracer
+	})
+}
+
+// Budget limits the retries made by the middleware to the given fraction
+// of its calls, plus minRetries, counted over windows of ten seconds. Once
+// the budget of a window is spent, failed calls are not retried until the
+// next window, so that retries cannot multiply the load on a service that
+// is already failing.
+//
+// For example, Budget(0.2, 10) allows 10 retries in every window, plus one
+// for every five calls. Retries are unlimited by default.
+func Budget(ratio float64, minRetries int) Option {
+	return optionFunc(func(opts *options) {
+		opts.budget = true
+		opts.budgetRatio = ratio
+		opts.budgetMinRetries = minRetries
+	})
+}
+
+func withClock(clock clock.Clock) Option {
+	return optionFunc(func(opts *options) {
+		opts.clock = clock
+	})
+}
+
+func isIdempotent(req *transport.Request) bool {
+	return yarpc.IsIdempotentProcedure(req.Service, req.Procedure)
+}
+
+func applyOptions(opts ...Option) options {
+	options := options{
+		maxAttempts: _defaultMaxAttempts,
+		backoff:     ibackoff.DefaultExponential,
+		retryable:   isIdempotent,
+		codes:       map[yarpcerrors.Code]struct{}{yarpcerrors.CodeUnavailable: {}},
+		tracer:      opentracing.GlobalTracer(),
+		clock:       clock.NewReal(),
+	}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return options
+}
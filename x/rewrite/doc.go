@@ -0,0 +1,65 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package rewrite provides middleware that rewrites the procedures and
+// application headers of requests and responses according to rules, for
+// example to keep old callers working while a service is renamed or while
+// headers are migrated.
+//
+// Rules are usually loaded from YAML:
+//
+// 	rules:
+// 	  - match:
+// 	      caller: legacy-client
+// 	      procedure: "OldService::(.*)"
+// 	    procedure: "NewService::$1"
+// 	    requestHeaders:
+// 	      rename: {x-auth-token: x-token}
+// 	      remove: [x-debug]
+// 	      set: {x-migrated: "true"}
+// 	    responseHeaders:
+// 	      rename: {x-token: x-auth-token}
+//
+// 	rewrites, err := rewrite.NewFromYAML(f)
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		OutboundMiddleware: yarpc.OutboundMiddleware{
+// 			Unary:  rewrites,
+// 			Oneway: rewrites,
+// 		},
+// 	})
+//
+// A rule matches requests whose caller and service equal those of the rule,
+// if specified, and whose procedure fully matches the regular expression of
+// the rule, if specified. Every matching rule applies, in order, and each
+// rule matches the request as rewritten by the previous ones. The procedure
+// of a rule replaces the procedure of the request, and may refer to the
+// submatches of the procedure expression as $1, $2, and so on.
+//
+// Request headers are renamed, then removed, then set. Response headers are
+// rewritten the same way by the rules that matched the request, in reverse
+// order.
+//
+// The middleware may be used inbound as well. Inbound, rewriting procedures
+// does not change which handler requests are routed to, because middleware
+// runs after routing; it changes the procedure seen by the handler.
+//
+// Streaming procedures are not supported.
+package rewrite
@@ -0,0 +1,154 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package rewrite
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/responsewriter"
+)
+
+var (
+	_ middleware.UnaryInbound   = (*Middleware)(nil)
+	_ middleware.OnewayInbound  = (*Middleware)(nil)
+	_ middleware.UnaryOutbound  = (*Middleware)(nil)
+	_ middleware.OnewayOutbound = (*Middleware)(nil)
+)
+
+// Middleware is inbound and outbound middleware that rewrites requests and
+// responses according to rules.
+type Middleware struct {
+	rules []*rule
+}
+
+// New builds a Middleware with the rules of the given Config.
+func New(cfg Config) (*Middleware, error) {
+	rules := make([]*rule, len(cfg.Rules))
+	for i, r := range cfg.Rules {
+		compiled, err := compileRule(r)
+		if err != nil {
+			return nil, fmt.Errorf("rewrite rule %d: %v", i, err)
+		}
+		rules[i] = compiled
+	}
+	return &Middleware{rules: rules}, nil
+}
+
+// NewFromYAML builds a Middleware with the rules of a Config parsed from
+// YAML.
+func NewFromYAML(r io.Reader) (*Middleware, error) {
+	cfg, err := ParseYAML(r)
+	if err != nil {
+		return nil, err
+	}
+	return New(cfg)
+}
+
+// MiddlewareName implements middleware.Named.
+func (m *Middleware) MiddlewareName() string { return "rewrite" }
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	req, matched := m.rewrite(req)
+	if responseRewrites(matched) {
+		resw = &responseWriter{Wrapper: responsewriter.Wrapper{ResponseWriter: resw}, matched: matched}
+	}
+	return h.Handle(ctx, req, resw)
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	req, _ = m.rewrite(req)
+	return h.HandleOneway(ctx, req)
+}
+
+// Call implements middleware.UnaryOutbound.
+func (m *Middleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	req, matched := m.rewrite(req)
+	res, err := out.Call(ctx, req)
+	if res != nil && responseRewrites(matched) {
+		r := *res
+		r.Headers = rewriteResponseHeaders(matched, res.Headers)
+		res = &r
+	}
+	return res, err
+}
+
+// CallOneway implements middleware.OnewayOutbound.
+func (m *Middleware) CallOneway(ctx context.Context, req *transport.Request, out transport.OnewayOutbound) (transport.Ack, error) {
+	req, _ = m.rewrite(req)
+	return out.CallOneway(ctx, req)
+}
+
+// rewrite applies the matching rules to a copy of the request, returning it
+// and the rules that matched. Requests that no rule matches are returned
+// as-is.
+func (m *Middleware) rewrite(req *transport.Request) (*transport.Request, []*rule) {
+	var matched []*rule
+	r := req
+	for _, rl := range m.rules {
+		if !rl.matches(r) {
+			continue
+		}
+		if r == req {
+			c := *req
+			c.Headers = req.Headers.Clone()
+			r = &c
+		}
+		rl.apply(r)
+		matched = append(matched, rl)
+	}
+	return r, matched
+}
+
+func responseRewrites(matched []*rule) bool {
+	for _, rl := range matched {
+		if !rl.response.empty() {
+			return true
+		}
+	}
+	return false
+}
+
+// rewriteResponseHeaders returns a copy of the given headers rewritten by
+// the matched rules, in reverse order.
+func rewriteResponseHeaders(matched []*rule, headers transport.Headers) transport.Headers {
+	headers = headers.Clone()
+	for i := len(matched) - 1; i >= 0; i-- {
+		headers = matched[i].response.apply(headers)
+	}
+	return headers
+}
+
+// responseWriter rewrites the headers added to the response.
+type responseWriter struct {
+	responsewriter.Wrapper
+
+	matched []*rule
+}
+
+func (w *responseWriter) AddHeaders(h transport.Headers) {
+	w.ResponseWriter.AddHeaders(rewriteResponseHeaders(w.matched, h))
+}
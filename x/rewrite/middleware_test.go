@@ -0,0 +1,157 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package rewrite
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+)
+
+const _testRules = `
+rules:
+  - match:
+      caller: legacy-client
+      procedure: "OldService::(.*)"
+    procedure: "NewService::$1"
+    requestHeaders:
+      rename: {x-auth-token: x-token}
+      remove: [x-debug]
+      set: {x-migrated: "true"}
+    responseHeaders:
+      rename: {x-token: x-auth-token}
+  - match:
+      procedure: "NewService::.*"
+    requestHeaders:
+      set: {x-version: "2"}
+    responseHeaders:
+      remove: [x-internal]
+`
+
+type unaryHandlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f unaryHandlerFunc) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	return f(ctx, req, resw)
+}
+
+// unaryOutbound records the requests it is called with and responds with
+// the given headers.
+type unaryOutbound struct {
+	transport.UnaryOutbound
+
+	headers transport.Headers
+	req     *transport.Request
+}
+
+func (o *unaryOutbound) Call(_ context.Context, req *transport.Request) (*transport.Response, error) {
+	o.req = req
+	return &transport.Response{Headers: o.headers}, nil
+}
+
+func newTestMiddleware(t *testing.T) *Middleware {
+	m, err := NewFromYAML(strings.NewReader(_testRules))
+	require.NoError(t, err)
+	return m
+}
+
+func TestOutboundRewrite(t *testing.T) {
+	m := newTestMiddleware(t)
+	reqHeaders := transport.HeadersFromMap(map[string]string{
+		"x-auth-token": "secret",
+		"x-debug":      "1",
+		"x-other":      "kept",
+	})
+	req := &transport.Request{
+		Caller:    "legacy-client",
+		Service:   "svc",
+		Procedure: "OldService::Get",
+		Headers:   reqHeaders,
+	}
+	out := &unaryOutbound{headers: transport.HeadersFromMap(map[string]string{
+		"x-token":    "refreshed",
+		"x-internal": "1",
+	})}
+
+	res, err := m.Call(context.Background(), req, out)
+	require.NoError(t, err)
+
+	assert.Equal(t, "NewService::Get", out.req.Procedure)
+	assert.Equal(t, map[string]string{
+		"x-token":    "secret",
+		"x-other":    "kept",
+		"x-migrated": "true",
+		"x-version":  "2",
+	}, out.req.Headers.Items())
+	assert.Equal(t, map[string]string{"x-auth-token": "refreshed"}, res.Headers.Items())
+
+	assert.Equal(t, "OldService::Get", req.Procedure, "original request must not change")
+	assert.Len(t, reqHeaders.Items(), 3, "original headers must not change")
+	assert.Len(t, out.headers.Items(), 2, "original response headers must not change")
+}
+
+func TestOutboundNoMatch(t *testing.T) {
+	m := newTestMiddleware(t)
+	req := &transport.Request{
+		Caller:    "other-client",
+		Procedure: "OldService::Get",
+	}
+	out := &unaryOutbound{}
+
+	_, err := m.Call(context.Background(), req, out)
+	require.NoError(t, err)
+	assert.True(t, req == out.req, "unmatched requests should be sent as-is")
+}
+
+func TestInboundRewrite(t *testing.T) {
+	m := newTestMiddleware(t)
+	req := &transport.Request{
+		Caller:    "legacy-client",
+		Procedure: "OldService::Get",
+		Headers:   transport.NewHeaders().With("x-auth-token", "secret"),
+	}
+	resw := &transporttest.FakeResponseWriter{}
+
+	err := m.Handle(context.Background(), req, resw,
+		unaryHandlerFunc(func(_ context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+			assert.Equal(t, "NewService::Get", req.Procedure)
+			token, _ := req.Headers.Get("x-token")
+			assert.Equal(t, "secret", token)
+			resw.AddHeaders(transport.NewHeaders().With("x-token", "refreshed").With("x-internal", "1"))
+			return nil
+		}))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"x-auth-token": "refreshed"}, resw.Headers.Items())
+}
+
+func TestNewErrors(t *testing.T) {
+	_, err := NewFromYAML(strings.NewReader("rules:\n  - match: {procedure: \"(\"}\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rewrite rule 0: invalid procedure expression")
+
+	_, err = NewFromYAML(strings.NewReader("rules:\n  - unknown: true\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse rewrite rules")
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package rewrite
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"sort"
+
+	"go.uber.org/yarpc/api/transport"
+	"gopkg.in/yaml.v2"
+)
+
+// Config configures the rewrite rules of a Middleware.
+type Config struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule rewrites the requests it matches, and their responses.
+type Rule struct {
+	// Match specifies the requests the rule applies to. An empty Match
+	// matches all requests.
+	Match Match `yaml:"match"`
+
+	// Procedure replaces the procedure of matching requests, if
+	// non-empty. It may refer to submatches of Match.Procedure as $1, $2,
+	// and so on.
+	Procedure string `yaml:"procedure"`
+
+	// RequestHeaders rewrites the application headers of matching
+	// requests.
+	RequestHeaders HeaderRewrite `yaml:"requestHeaders"`
+
+	// ResponseHeaders rewrites the application headers of the responses
+	// to matching requests.
+	ResponseHeaders HeaderRewrite `yaml:"responseHeaders"`
+}
+
+// Match specifies the requests that a Rule applies to. Empty fields match
+// all requests.
+type Match struct {
+	// Caller matches requests from this caller.
+	Caller string `yaml:"caller"`
+
+	// Service matches requests to this service.
+	Service string `yaml:"service"`
+
+	// Procedure is a regular expression that matches the entire procedure
+	// of requests.
+	Procedure string `yaml:"procedure"`
+}
+
+// HeaderRewrite rewrites application headers. Headers are renamed first,
+// then removed, then set.
+type HeaderRewrite struct {
+	// Rename renames headers, from the keys of the map to their values.
+	Rename map[string]string `yaml:"rename"`
+
+	// Remove removes headers.
+	Remove []string `yaml:"remove"`
+
+	// Set sets headers, replacing their values if present.
+	Set map[string]string `yaml:"set"`
+}
+
+// ParseYAML parses a Config from YAML.
+func ParseYAML(r io.Reader) (Config, error) {
+	var cfg Config
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return cfg, err
+	}
+	if err := yaml.UnmarshalStrict(b, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse rewrite rules: %v", err)
+	}
+	return cfg, nil
+}
+
+// rule is a compiled Rule.
+type rule struct {
+	caller    string
+	service   string
+	procedure *regexp.Regexp
+
+	rewriteProcedure string
+	request          headerRewrite
+	response         headerRewrite
+}
+
+func compileRule(r Rule) (*rule, error) {
+	pattern := r.Match.Procedure
+	if pattern == "" {
+		pattern = ".*"
+	}
+	procedure, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid procedure expression %q: %v", r.Match.Procedure, err)
+	}
+	return &rule{
+		caller:           r.Match.Caller,
+		service:          r.Match.Service,
+		procedure:        procedure,
+		rewriteProcedure: r.Procedure,
+		request:          compileHeaderRewrite(r.RequestHeaders),
+		response:         compileHeaderRewrite(r.ResponseHeaders),
+	}, nil
+}
+
+func (r *rule) matches(req *transport.Request) bool {
+	return (r.caller == "" || r.caller == req.Caller) &&
+		(r.service == "" || r.service == req.Service) &&
+		r.procedure.MatchString(req.Procedure)
+}
+
+// apply rewrites the given request in place.
+func (r *rule) apply(req *transport.Request) {
+	if r.rewriteProcedure != "" {
+		req.Procedure = r.procedure.ReplaceAllString(req.Procedure, r.rewriteProcedure)
+	}
+	req.Headers = r.request.apply(req.Headers)
+}
+
+type headerPair struct{ key, value string }
+
+// headerRewrite is a compiled HeaderRewrite. Renames and sets are sorted by
+// key so that rewrites are deterministic.
+type headerRewrite struct {
+	rename []headerPair
+	remove []string
+	set    []headerPair
+}
+
+func compileHeaderRewrite(h HeaderRewrite) headerRewrite {
+	return headerRewrite{
+		rename: sortedPairs(h.Rename),
+		remove: h.Remove,
+		set:    sortedPairs(h.Set),
+	}
+}
+
+func sortedPairs(m map[string]string) []headerPair {
+	pairs := make([]headerPair, 0, len(m))
+	for k, v := range m {
+		pairs = append(pairs, headerPair{k, v})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].key < pairs[j].key })
+	return pairs
+}
+
+func (h headerRewrite) empty() bool {
+	return len(h.rename) == 0 && len(h.remove) == 0 && len(h.set) == 0
+}
+
+// apply rewrites the given headers, which must not be shared, and returns
+// them.
+func (h headerRewrite) apply(headers transport.Headers) transport.Headers {
+	for _, p := range h.rename {
+		if v, ok := headers.Get(p.key); ok {
+			headers.Del(p.key)
+			headers = headers.With(p.value, v)
+		}
+	}
+	for _, k := range h.remove {
+		headers.Del(k)
+	}
+	for _, p := range h.set {
+		headers = headers.With(p.key, p.value)
+	}
+	return headers
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package slo
+
+import (
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// Class is the classification of the outcome of a request.
+type Class int
+
+const (
+	// Success indicates that the request succeeded.
+	Success Class = iota
+	// ExpectedError indicates that the request failed in a way that does not
+	// count against the availability of the procedure, such as a request
+	// with invalid arguments.
+	ExpectedError
+	// UnexpectedError indicates that the request failed in a way that counts
+	// against the availability of the procedure.
+	UnexpectedError
+)
+
+// String returns a lowercase name for the Class.
+func (c Class) String() string {
+	switch c {
+	case Success:
+		return "success"
+	case ExpectedError:
+		return "expected_error"
+	case UnexpectedError:
+		return "unexpected_error"
+	default:
+		return "unknown"
+	}
+}
+
+// Classifier classifies the outcome of a request.
+//
+// err is the error returned by the handler and applicationError reports
+// whether the handler marked the response as an application error.
+type Classifier func(req *transport.Request, err error, applicationError bool) Class
+
+// DefaultClassifier is the Classifier used if none is specified.
+//
+// Application errors and errors whose codes indicate a fault of the caller
+// are classified as expected errors. All other errors are unexpected.
+func DefaultClassifier(req *transport.Request, err error, applicationError bool) Class {
+	if err == nil {
+		if applicationError {
+			return ExpectedError
+		}
+		return Success
+	}
+	if applicationError {
+		return ExpectedError
+	}
+	switch yarpcerrors.FromError(err).Code() {
+	case yarpcerrors.CodeCancelled,
+		yarpcerrors.CodeInvalidArgument,
+		yarpcerrors.CodeNotFound,
+		yarpcerrors.CodeAlreadyExists,
+		yarpcerrors.CodePermissionDenied,
+		yarpcerrors.CodeFailedPrecondition,
+		yarpcerrors.CodeAborted,
+		yarpcerrors.CodeOutOfRange,
+		yarpcerrors.CodeUnimplemented,
+		yarpcerrors.CodeUnauthenticated:
+		return ExpectedError
+	default:
+		return UnexpectedError
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package slo
+
+import (
+	"time"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/zap"
+)
+
+const (
+	_defaultWindow      = time.Minute
+	_defaultBuckets     = 12
+	_defaultObjective   = 0.999
+	_defaultMinRequests = 100
+)
+
+// Option customizes the behavior of a Tracker.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	window      time.Duration
+	buckets     int
+	objective   float64
+	minRequests int64
+	classifier  Classifier
+	meter       *metrics.Scope
+	logger      *zap.Logger
+	clock       clock.Clock
+}
+
+// Window specifies the duration over which availability is computed.
+// Defaults to one minute.
+func Window(window time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.window = window
+	})
+}
+
+// Buckets specifies the number of buckets the rolling window is divided
+// into. More buckets make the window roll over more smoothly at the cost of
+// memory. Defaults to 12.
+func Buckets(buckets int) Option {
+	return optionFunc(func(opts *options) {
+		opts.buckets = buckets
+	})
+}
+
+// Objective specifies the target availability of each procedure, as a ratio
+// between 0 and 1. Defaults to 0.999.
+func Objective(objective float64) Option {
+	return optionFunc(func(opts *options) {
+		opts.objective = objective
+	})
+}
+
+// MinRequests specifies the number of requests that must be observed within
+// the window before the error budget of a procedure may be considered
+// exhausted. Defaults to 100.
+func MinRequests(minRequests int64) Option {
+	return optionFunc(func(opts *options) {
+		opts.minRequests = minRequests
+	})
+}
+
+// WithClassifier specifies the Classifier used to classify the outcome of
+// requests. Defaults to DefaultClassifier.
+func WithClassifier(classifier Classifier) Option {
+	return optionFunc(func(opts *options) {
+		opts.classifier = classifier
+	})
+}
+
+// Metrics specifies the scope to which availability metrics are reported.
+// By default, no metrics are reported.
+func Metrics(meter *metrics.Scope) Option {
+	return optionFunc(func(opts *options) {
+		opts.meter = meter
+	})
+}
+
+// Logger specifies the logger that should be used to log.
+// Default value is noop zap logger.
+func Logger(logger *zap.Logger) Option {
+	return optionFunc(func(opts *options) {
+		opts.logger = logger
+	})
+}
+
+// withClock specifies the clock used to measure time.
+// It is only used for testing.
+func withClock(clock clock.Clock) Option {
+	return optionFunc(func(opts *options) {
+		opts.clock = clock
+	})
+}
+
+func applyOptions(opts ...Option) options {
+	options := options{
+		window:      _defaultWindow,
+		buckets:     _defaultBuckets,
+		objective:   _defaultObjective,
+		minRequests: _defaultMinRequests,
+		classifier:  DefaultClassifier,
+		logger:      zap.NewNop(),
+		clock:       clock.NewReal(),
+	}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	if options.buckets < 1 {
+		options.buckets = 1
+	}
+	if options.window < time.Duration(options.buckets) {
+		options.window = time.Duration(options.buckets)
+	}
+	return options
+}
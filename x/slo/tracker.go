@@ -0,0 +1,234 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package slo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/internal/responsewriter"
+	"go.uber.org/zap"
+)
+
+var (
+	_ middleware.UnaryInbound  = (*Tracker)(nil)
+	_ middleware.OnewayInbound = (*Tracker)(nil)
+)
+
+// Tracker is inbound middleware that tracks the availability of each
+// procedure over a rolling window.
+//
+// Availability is the ratio of requests that were not classified as
+// unexpected errors to the total number of requests.
+type Tracker struct {
+	opts options
+
+	mu         sync.RWMutex
+	procedures map[procedureKey]*procedure
+}
+
+// NewTracker builds a new Tracker with the given options.
+func NewTracker(opts ...Option) *Tracker {
+	options := applyOptions(opts...)
+	return &Tracker{
+		opts:       options,
+		procedures: make(map[procedureKey]*procedure),
+	}
+}
+
+// Stats is a snapshot of the availability of a procedure within the rolling
+// window.
+type Stats struct {
+	Successes        int64
+	ExpectedErrors   int64
+	UnexpectedErrors int64
+}
+
+// Total returns the total number of requests.
+func (s Stats) Total() int64 {
+	return s.Successes + s.ExpectedErrors + s.UnexpectedErrors
+}
+
+// Availability returns the ratio of requests that were not unexpected
+// errors. Availability is 1 if no requests were observed.
+func (s Stats) Availability() float64 {
+	total := s.Total()
+	if total == 0 {
+		return 1
+	}
+	return float64(total-s.UnexpectedErrors) / float64(total)
+}
+
+// Handle implements middleware.UnaryInbound.
+func (t *Tracker) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	w := &writer{Wrapper: responsewriter.Wrapper{ResponseWriter: resw}}
+	err := h.Handle(ctx, req, w)
+	t.record(req, err, w.isApplicationError)
+	return err
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (t *Tracker) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	err := h.HandleOneway(ctx, req)
+	t.record(req, err, false)
+	return err
+}
+
+// Stats returns the statistics for the given procedure within the current
+// window.
+func (t *Tracker) Stats(service, procedure string) Stats {
+	p := t.getProcedure(procedureKey{service: service, procedure: procedure})
+	if p == nil {
+		return Stats{}
+	}
+	return p.stats(t.opts.clock)
+}
+
+// Availability returns the availability of the given procedure within the
+// current window. Availability is 1 if no requests were observed.
+func (t *Tracker) Availability(service, procedure string) float64 {
+	return t.Stats(service, procedure).Availability()
+}
+
+// BudgetExhausted reports whether the availability of the given procedure
+// within the current window is below the objective of the Tracker.
+//
+// Procedures for which fewer than the minimum number of requests were
+// observed never exhaust their budget. Callers may use this to shed load or
+// reject optional work while a procedure is unhealthy.
+func (t *Tracker) BudgetExhausted(service, procedure string) bool {
+	stats := t.Stats(service, procedure)
+	if stats.Total() < t.opts.minRequests {
+		return false
+	}
+	return stats.Availability() < t.opts.objective
+}
+
+func (t *Tracker) record(req *transport.Request, err error, applicationError bool) {
+	class := t.opts.classifier(req, err, applicationError)
+	p := t.getOrCreateProcedure(procedureKey{service: req.Service, procedure: req.Procedure})
+	p.record(t.opts.clock, class)
+}
+
+func (t *Tracker) getProcedure(key procedureKey) *procedure {
+	t.mu.RLock()
+	p := t.procedures[key]
+	t.mu.RUnlock()
+	return p
+}
+
+func (t *Tracker) getOrCreateProcedure(key procedureKey) *procedure {
+	if p := t.getProcedure(key); p != nil {
+		return p
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if p, ok := t.procedures[key]; ok {
+		// Someone beat us to the punch.
+		return p
+	}
+	p := newProcedure(key, t.opts)
+	t.procedures[key] = p
+	return p
+}
+
+type procedureKey struct {
+	service   string
+	procedure string
+}
+
+// procedure holds the rolling window and metrics of a single procedure.
+type procedure struct {
+	window       *window
+	calls        *metrics.CounterVector
+	availability *metrics.Gauge
+}
+
+func newProcedure(key procedureKey, opts options) *procedure {
+	logger, meter := opts.logger, opts.meter
+	tags := metrics.Tags{
+		"service":   key.service,
+		"procedure": key.procedure,
+	}
+	calls, err := meter.CounterVector(metrics.Spec{
+		Name:      "slo_calls",
+		Help:      "Number of RPCs by availability classification.",
+		ConstTags: tags,
+		VarTags:   []string{"class"},
+	})
+	if err != nil {
+		logger.Error("Failed to create SLO calls vector.", zap.Error(err))
+	}
+	availability, err := meter.Gauge(metrics.Spec{
+		Name:      "slo_availability_bps",
+		Help:      "Availability within the rolling window, in basis points.",
+		ConstTags: tags,
+	})
+	if err != nil {
+		logger.Error("Failed to create SLO availability gauge.", zap.Error(err))
+	}
+	return &procedure{
+		window:       newWindow(opts.window, opts.buckets),
+		calls:        calls,
+		availability: availability,
+	}
+}
+
+func (p *procedure) record(clock clock.Clock, class Class) {
+	now := clock.Now()
+	p.window.record(now, class)
+	if counter, err := p.calls.Get("class", class.String()); err == nil {
+		counter.Inc()
+	}
+	p.availability.Store(int64(p.statsAt(now).Availability() * 10000))
+}
+
+func (p *procedure) stats(clock clock.Clock) Stats {
+	return p.statsAt(clock.Now())
+}
+
+func (p *procedure) statsAt(now time.Time) Stats {
+	counts := p.window.counts(now)
+	return Stats{
+		Successes:        counts[Success],
+		ExpectedErrors:   counts[ExpectedError],
+		UnexpectedErrors: counts[UnexpectedError],
+	}
+}
+
+// writer wraps a transport.ResponseWriter to detect application errors.
+type writer struct {
+	responsewriter.Wrapper
+
+	isApplicationError bool
+}
+
+func (w *writer) SetApplicationError() {
+	w.isApplicationError = true
+	w.ResponseWriter.SetApplicationError()
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package slo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+func TestDefaultClassifier(t *testing.T) {
+	tests := []struct {
+		desc             string
+		err              error
+		applicationError bool
+		want             Class
+	}{
+		{desc: "success", want: Success},
+		{desc: "application error", applicationError: true, want: ExpectedError},
+		{desc: "caller fault", err: yarpcerrors.InvalidArgumentErrorf("bad"), want: ExpectedError},
+		{desc: "server fault", err: yarpcerrors.InternalErrorf("oops"), want: UnexpectedError},
+		{desc: "unknown error", err: errors.New("great sadness"), want: UnexpectedError},
+		{
+			desc:             "application error with error",
+			err:              errors.New("great sadness"),
+			applicationError: true,
+			want:             ExpectedError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.Equal(t, tt.want, DefaultClassifier(&transport.Request{}, tt.err, tt.applicationError))
+		})
+	}
+}
+
+func TestTrackerUnary(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	clock := clock.NewFake()
+	tracker := NewTracker(withClock(clock), MinRequests(4), Objective(0.9))
+	req := &transport.Request{Service: "svc", Procedure: "proc"}
+
+	h := transporttest.NewMockUnaryHandler(mockCtrl)
+	gomock.InOrder(
+		h.EXPECT().Handle(gomock.Any(), req, gomock.Any()).Return(nil),
+		h.EXPECT().Handle(gomock.Any(), req, gomock.Any()).Return(yarpcerrors.NotFoundErrorf("missing")),
+		h.EXPECT().Handle(gomock.Any(), req, gomock.Any()).Return(nil),
+	)
+	for i := 0; i < 3; i++ {
+		_ = tracker.Handle(context.Background(), req, &transporttest.FakeResponseWriter{}, h)
+	}
+	assert.Equal(t, Stats{Successes: 2, ExpectedErrors: 1}, tracker.Stats("svc", "proc"))
+	assert.Equal(t, float64(1), tracker.Availability("svc", "proc"))
+	assert.False(t, tracker.BudgetExhausted("svc", "proc"))
+
+	h.EXPECT().Handle(gomock.Any(), req, gomock.Any()).Return(yarpcerrors.InternalErrorf("oops"))
+	err := tracker.Handle(context.Background(), req, &transporttest.FakeResponseWriter{}, h)
+	assert.Equal(t, yarpcerrors.InternalErrorf("oops"), err, "errors must be passed through")
+	assert.Equal(t, 0.75, tracker.Availability("svc", "proc"))
+	assert.True(t, tracker.BudgetExhausted("svc", "proc"))
+	assert.False(t, tracker.BudgetExhausted("svc", "other"))
+}
+
+func TestTrackerApplicationError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	tracker := NewTracker(withClock(clock.NewFake()))
+	req := &transport.Request{Service: "svc", Procedure: "proc"}
+
+	h := transporttest.NewMockUnaryHandler(mockCtrl)
+	h.EXPECT().Handle(gomock.Any(), req, gomock.Any()).Do(
+		func(_ context.Context, _ *transport.Request, resw transport.ResponseWriter) {
+			resw.SetApplicationError()
+		}).Return(nil)
+
+	resw := &transporttest.FakeResponseWriter{}
+	assert.NoError(t, tracker.Handle(context.Background(), req, resw, h))
+	assert.True(t, resw.IsApplicationError, "application error must be forwarded")
+	assert.Equal(t, Stats{ExpectedErrors: 1}, tracker.Stats("svc", "proc"))
+}
+
+func TestTrackerOnewayCustomClassifier(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	tracker := NewTracker(
+		withClock(clock.NewFake()),
+		WithClassifier(func(*transport.Request, error, bool) Class { return UnexpectedError }),
+	)
+	req := &transport.Request{Service: "svc", Procedure: "proc"}
+
+	h := transporttest.NewMockOnewayHandler(mockCtrl)
+	h.EXPECT().HandleOneway(gomock.Any(), req).Return(nil)
+	assert.NoError(t, tracker.HandleOneway(context.Background(), req, h))
+	assert.Equal(t, Stats{UnexpectedErrors: 1}, tracker.Stats("svc", "proc"))
+}
+
+func TestTrackerWindowRollsOver(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	clock := clock.NewFake()
+	tracker := NewTracker(withClock(clock), Window(10*time.Second), Buckets(10))
+	req := &transport.Request{Service: "svc", Procedure: "proc"}
+
+	h := transporttest.NewMockOnewayHandler(mockCtrl)
+	h.EXPECT().HandleOneway(gomock.Any(), req).Return(errors.New("great sadness")).Times(2)
+
+	_ = tracker.HandleOneway(context.Background(), req, h)
+	clock.Add(5 * time.Second)
+	_ = tracker.HandleOneway(context.Background(), req, h)
+	assert.Equal(t, Stats{UnexpectedErrors: 2}, tracker.Stats("svc", "proc"))
+
+	clock.Add(6 * time.Second)
+	assert.Equal(t, Stats{UnexpectedErrors: 1}, tracker.Stats("svc", "proc"))
+
+	clock.Add(10 * time.Second)
+	assert.Equal(t, Stats{}, tracker.Stats("svc", "proc"))
+	assert.Equal(t, float64(1), tracker.Availability("svc", "proc"))
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package slo
+
+import (
+	"sync"
+	"time"
+)
+
+// bucket holds the number of requests of each Class observed during a slice
+// of the rolling window.
+type bucket struct {
+	epoch  int64
+	counts [3]int64
+}
+
+// window is a rolling window of request counts, divided into buckets of
+// equal width.
+type window struct {
+	mu      sync.Mutex
+	width   time.Duration
+	buckets []bucket
+}
+
+func newWindow(size time.Duration, buckets int) *window {
+	return &window{
+		width:   size / time.Duration(buckets),
+		buckets: make([]bucket, buckets),
+	}
+}
+
+// record counts a request of the given Class at the given time.
+func (w *window) record(now time.Time, class Class) {
+	epoch := now.UnixNano() / int64(w.width)
+
+	w.mu.Lock()
+	b := &w.buckets[epoch%int64(len(w.buckets))]
+	if b.epoch != epoch {
+		*b = bucket{epoch: epoch}
+	}
+	b.counts[class]++
+	w.mu.Unlock()
+}
+
+// counts returns the number of requests of each Class observed within the
+// window ending at the given time.
+func (w *window) counts(now time.Time) [3]int64 {
+	epoch := now.UnixNano() / int64(w.width)
+	oldest := epoch - int64(len(w.buckets)) + 1
+
+	var counts [3]int64
+	w.mu.Lock()
+	for _, b := range w.buckets {
+		if b.epoch < oldest || b.epoch > epoch {
+			continue
+		}
+		for i, c := range b.counts {
+			counts[i] += c
+		}
+	}
+	w.mu.Unlock()
+	return counts
+}
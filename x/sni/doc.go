@@ -0,0 +1,45 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package sni serves several TLS certificates on one inbound, choosing the
+// certificate for each connection by the server name that the client
+// requests with SNI (Server Name Indication). This lets a gateway terminate
+// TLS for several logical services on one port.
+//
+// Each certificate may have its own pool of client certificate authorities,
+// so that every service only accepts the clients of its own tenants.
+//
+// 	config, err := sni.ServerTLSConfig(&tls.Config{
+// 		ClientAuth: tls.RequireAndVerifyClientCert,
+// 	}, sni.Certificate{
+// 		ServerNames: []string{"users.example.org"},
+// 		Certificate: usersCert,
+// 		ClientCAs:   usersClients,
+// 	}, sni.Certificate{
+// 		ServerNames: []string{"*.billing.example.org"},
+// 		Certificate: billingCert,
+// 		ClientCAs:   billingClients,
+// 	})
+// 	grpcTransport := grpc.NewTransport(grpc.ServerTLSConfig(config))
+// 	httpInbound := httpTransport.NewInbound(":8443", http.ServerTLSConfig(config))
+//
+// Handlers and middleware find the server name of the connection of a
+// request in the ServerName field of the TLS state of its remote peer.
+package sni
@@ -0,0 +1,114 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sni
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"strings"
+)
+
+// Certificate is a certificate served to the clients that request one of its
+// server names.
+type Certificate struct {
+	// ServerNames are the names of the certificate. Names may start with a
+	// "*." wildcard that matches a single label, as in "*.example.org".
+	ServerNames []string
+
+	// Certificate is served to the clients that request one of the server
+	// names.
+	Certificate tls.Certificate
+
+	// ClientCAs verify the client certificates of the connections that
+	// request one of the server names, if non-nil. Otherwise, the ClientCAs
+	// of the base configuration apply. Whether clients must present
+	// certificates is decided by the ClientAuth of the base configuration.
+	ClientCAs *x509.CertPool
+}
+
+// ServerTLSConfig builds a TLS configuration for inbounds that serves the
+// given certificates depending on the server names requested by clients,
+// and applies the settings of the base configuration otherwise.
+//
+// Connections that request no server name, or an unknown one, are served
+// with the certificates of the base configuration if it has any, and with
+// the first of the given certificates otherwise.
+func ServerTLSConfig(base *tls.Config, certs ...Certificate) (*tls.Config, error) {
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("sni: no certificates")
+	}
+	if base == nil {
+		base = &tls.Config{}
+	}
+
+	byName := make(map[string]*tls.Config)
+	for i, c := range certs {
+		if len(c.ServerNames) == 0 {
+			return nil, fmt.Errorf("sni: certificate %d has no server names", i)
+		}
+		config := base.Clone()
+		config.Certificates = []tls.Certificate{c.Certificate}
+		config.GetCertificate = nil
+		config.GetConfigForClient = nil
+		if c.ClientCAs != nil {
+			config.ClientCAs = c.ClientCAs
+		}
+		for _, name := range c.ServerNames {
+			name = strings.ToLower(name)
+			if _, ok := byName[name]; ok {
+				return nil, fmt.Errorf("sni: server name %q has more than one certificate", name)
+			}
+			byName[name] = config
+		}
+	}
+
+	fallback := base.Clone()
+	fallback.GetConfigForClient = nil
+	if len(base.Certificates) == 0 && base.GetCertificate == nil {
+		fallback = byName[strings.ToLower(certs[0].ServerNames[0])]
+	}
+
+	config := base.Clone()
+	config.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		if c := lookup(byName, hello.ServerName); c != nil {
+			return c, nil
+		}
+		return fallback, nil
+	}
+	return config, nil
+}
+
+// lookup returns the configuration for the given server name, matching
+// exact names before wildcards.
+func lookup(byName map[string]*tls.Config, serverName string) *tls.Config {
+	if serverName == "" {
+		return nil
+	}
+	name := strings.ToLower(strings.TrimSuffix(serverName, "."))
+	if c, ok := byName[name]; ok {
+		return c
+	}
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		return byName["*"+name[i:]]
+	}
+	return nil
+}
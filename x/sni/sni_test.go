@@ -0,0 +1,199 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sni
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// authority issues certificates.
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newAuthority(t *testing.T, name string) *authority {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &authority{cert: cert, key: key}
+}
+
+func (a *authority) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(a.cert)
+	return pool
+}
+
+func (a *authority) issue(t *testing.T, name string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{name},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, &key.PublicKey, a.key)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// handshake connects a client to a server with the given configuration and
+// returns the name of the certificate served to the client.
+func handshake(t *testing.T, server *tls.Config, client *tls.Config) (string, error) {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", server)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		defer conn.Close()
+		serverErr <- conn.(*tls.Conn).Handshake()
+	}()
+
+	conn, err := tls.Dial("tcp", listener.Addr().String(), client)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	// TLS 1.3 clients finish their handshake before servers verify their
+	// certificates.
+	if err := <-serverErr; err != nil {
+		return "", err
+	}
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName, nil
+}
+
+func TestServerTLSConfig(t *testing.T) {
+	servers := newAuthority(t, "servers")
+	usersClients := newAuthority(t, "users clients")
+	billingClients := newAuthority(t, "billing clients")
+
+	config, err := ServerTLSConfig(&tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+	}, Certificate{
+		ServerNames: []string{"users.example.org"},
+		Certificate: servers.issue(t, "users.example.org"),
+		ClientCAs:   usersClients.pool(),
+	}, Certificate{
+		ServerNames: []string{"*.billing.example.org"},
+		Certificate: servers.issue(t, "*.billing.example.org"),
+		ClientCAs:   billingClients.pool(),
+	})
+	require.NoError(t, err)
+
+	usersClient := usersClients.issue(t, "users-client")
+	billingClient := billingClients.issue(t, "billing-client")
+	tests := []struct {
+		desc       string
+		serverName string
+		clientCert tls.Certificate
+		wantName   string
+		wantErr    bool
+	}{
+		{
+			desc:       "exact name",
+			serverName: "users.example.org",
+			clientCert: usersClient,
+			wantName:   "users.example.org",
+		},
+		{
+			desc:       "wildcard name",
+			serverName: "eu.billing.example.org",
+			clientCert: billingClient,
+			wantName:   "*.billing.example.org",
+		},
+		{
+			desc:       "client of another tenant",
+			serverName: "users.example.org",
+			clientCert: billingClient,
+			wantErr:    true,
+		},
+		{
+			desc:       "unknown name",
+			serverName: "unknown.example.org",
+			clientCert: usersClient,
+			wantName:   "users.example.org",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			name, err := handshake(t, config, &tls.Config{
+				ServerName:         tt.serverName,
+				Certificates:       []tls.Certificate{tt.clientCert},
+				RootCAs:            servers.pool(),
+				InsecureSkipVerify: tt.serverName == "unknown.example.org",
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantName, name)
+		})
+	}
+}
+
+func TestServerTLSConfigErrors(t *testing.T) {
+	_, err := ServerTLSConfig(nil)
+	assert.EqualError(t, err, "sni: no certificates")
+
+	_, err = ServerTLSConfig(nil, Certificate{})
+	assert.EqualError(t, err, "sni: certificate 0 has no server names")
+
+	_, err = ServerTLSConfig(nil,
+		Certificate{ServerNames: []string{"a.example.org"}},
+		Certificate{ServerNames: []string{"A.example.org"}},
+	)
+	assert.EqualError(t, err, `sni: server name "a.example.org" has more than one certificate`)
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package spiffe provides TLS credentials backed by SPIFFE workload
+// identities.
+//
+// A Source fetches the X.509 SVIDs (SPIFFE Verifiable Identity Documents) of
+// the workload from the SPIFFE Workload API, usually served by a local SPIRE
+// agent, and keeps them up to date as the agent rotates them. TLS
+// configurations built from a Source always present the current SVID and
+// verify peers against the current trust bundles, so certificates rotate
+// without restarting the service.
+//
+// 	source := spiffe.NewSource(spiffe.Address("unix:///run/spire/agent.sock"))
+// 	if err := source.Start(); err != nil {
+// 		log.Fatal(err)
+// 	}
+// 	defer source.Stop()
+//
+// Peers are verified by their SPIFFE ID instead of their host name. An
+// Authorizer decides which SPIFFE IDs are acceptable, so each outbound can
+// expect the identity of the service it calls.
+//
+// 	httpTransport := http.NewTransport()
+// 	outbound := httpTransport.NewOutbound(chooser, http.ClientTLSConfig(
+// 		source.ClientTLSConfig(spiffe.AuthorizeID("spiffe://example.org/keyvalue")),
+// 	))
+// 	inbound := httpTransport.NewInbound(":8080", http.ServerTLSConfig(
+// 		source.ServerTLSConfig(spiffe.AuthorizeMemberOf("example.org")),
+// 	))
+//
+// gRPC connections are shared by all outbounds of a transport, so the gRPC
+// transport takes its TLS configurations as transport options. Use a
+// separate gRPC transport for each peer identity that must be enforced.
+//
+// 	grpcTransport := grpc.NewTransport(
+// 		grpc.ClientTLSConfig(source.ClientTLSConfig(spiffe.AuthorizeID("spiffe://example.org/keyvalue"))),
+// 		grpc.ServerTLSConfig(source.ServerTLSConfig(spiffe.AuthorizeMemberOf("example.org"))),
+// 	)
+//
+// TChannel does not support TLS.
+package spiffe
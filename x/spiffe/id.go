@@ -0,0 +1,83 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package spiffe
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/url"
+)
+
+// IDFromCertificate returns the SPIFFE ID of an X.509 SVID. SVIDs carry
+// exactly one URI SAN, the SPIFFE ID of the workload.
+func IDFromCertificate(cert *x509.Certificate) (string, error) {
+	if len(cert.URIs) != 1 {
+		return "", fmt.Errorf("spiffe: certificate has %d URI SANs, expected 1", len(cert.URIs))
+	}
+	id := cert.URIs[0]
+	if id.Scheme != "spiffe" || id.Host == "" {
+		return "", fmt.Errorf("spiffe: certificate URI SAN %q is not a SPIFFE ID", id)
+	}
+	return id.String(), nil
+}
+
+// trustDomain returns the trust domain of a SPIFFE ID.
+func trustDomain(id string) string {
+	u, err := url.Parse(id)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// Authorizer decides whether a peer with the given SPIFFE ID may be
+// connected to. It returns an error for unauthorized peers.
+type Authorizer func(id string) error
+
+// AuthorizeAny accepts peers with any SPIFFE ID from a trusted trust domain.
+func AuthorizeAny() Authorizer {
+	return func(string) error { return nil }
+}
+
+// AuthorizeID accepts peers with one of the given SPIFFE IDs.
+func AuthorizeID(ids ...string) Authorizer {
+	allowed := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		allowed[id] = struct{}{}
+	}
+	return func(id string) error {
+		if _, ok := allowed[id]; !ok {
+			return fmt.Errorf("spiffe: unexpected peer ID %q", id)
+		}
+		return nil
+	}
+}
+
+// AuthorizeMemberOf accepts peers with any SPIFFE ID in the given trust
+// domain, for example "example.org".
+func AuthorizeMemberOf(domain string) Authorizer {
+	return func(id string) error {
+		if trustDomain(id) != domain {
+			return fmt.Errorf("spiffe: peer ID %q is not a member of trust domain %q", id, domain)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package spiffe
+
+import (
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// _addressEnv is the environment variable that holds the address of the
+	// Workload API by convention.
+	_addressEnv = "SPIFFE_ENDPOINT_SOCKET"
+
+	_defaultStartTimeout = 30 * time.Second
+)
+
+// Option customizes the behavior of a Source.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	address      string
+	startTimeout time.Duration
+	logger       *zap.Logger
+}
+
+func newOptions(opts []Option) options {
+	options := options{
+		address:      os.Getenv(_addressEnv),
+		startTimeout: _defaultStartTimeout,
+		logger:       zap.NewNop(),
+	}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return options
+}
+
+// Address specifies the address of the Workload API, for example
+// "unix:///run/spire/agent.sock". Defaults to the value of the
+// SPIFFE_ENDPOINT_SOCKET environment variable.
+func Address(addr string) Option {
+	return optionFunc(func(opts *options) {
+		opts.address = addr
+	})
+}
+
+// StartTimeout specifies how long Start waits for the first SVID from the
+// Workload API before failing. Defaults to 30 seconds.
+func StartTimeout(timeout time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.startTimeout = timeout
+	})
+}
+
+// Logger specifies a logger for failures to reach the Workload API and for
+// SVID rotations.
+func Logger(logger *zap.Logger) Option {
+	return optionFunc(func(opts *options) {
+		opts.logger = logger
+	})
+}
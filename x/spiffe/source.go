@@ -0,0 +1,300 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package spiffe
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/internal/backoff"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// Source is a TLS credential provider that fetches X.509 SVIDs from the
+// SPIFFE Workload API and rotates them as the Workload API sends updates.
+type Source struct {
+	once *lifecycle.Once
+	opts options
+
+	conn   *grpc.ClientConn
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu      sync.RWMutex
+	id      string
+	cert    *tls.Certificate
+	bundles map[string]*x509.CertPool // trust domain -> roots
+	ready   chan struct{}
+}
+
+// NewSource builds a Source. The Source must be started before the TLS
+// configurations it builds are used.
+func NewSource(opts ...Option) *Source {
+	return &Source{
+		once:  lifecycle.NewOnce(),
+		opts:  newOptions(opts),
+		ready: make(chan struct{}),
+	}
+}
+
+// Start connects to the Workload API and waits for the first SVID.
+func (s *Source) Start() error {
+	return s.once.Start(s.start)
+}
+
+func (s *Source) start() error {
+	if s.opts.address == "" {
+		return fmt.Errorf("spiffe: no Workload API address specified with the Address option or %v", _addressEnv)
+	}
+	conn, err := dialWorkloadAPI(s.opts.address)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	go s.watch(ctx)
+
+	select {
+	case <-s.ready:
+		return nil
+	case <-time.After(s.opts.startTimeout):
+		_ = s.stop()
+		return fmt.Errorf("spiffe: timed out after %v waiting for an SVID from %v", s.opts.startTimeout, s.opts.address)
+	}
+}
+
+// watch streams SVIDs from the Workload API until stopped, reconnecting
+// after failures.
+func (s *Source) watch(ctx context.Context) {
+	defer close(s.done)
+
+	bo := backoff.DefaultExponential.Backoff()
+	for attempt := uint(0); ; attempt++ {
+		err := fetchX509SVIDs(ctx, s.conn, func(res *x509SVIDResponse) {
+			attempt = 0
+			if err := s.update(res); err != nil {
+				s.opts.logger.Warn("ignoring invalid SVID from Workload API", zap.Error(err))
+			}
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		s.opts.logger.Warn("lost connection to Workload API", zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(bo.Duration(attempt)):
+		}
+	}
+}
+
+// update replaces the current SVID and trust bundles with the first SVID of
+// a Workload API response, which is the default identity of the workload.
+func (s *Source) update(res *x509SVIDResponse) error {
+	if len(res.svids) == 0 {
+		return errors.New("spiffe: Workload API response has no SVIDs")
+	}
+	svid := res.svids[0]
+
+	certs, err := x509.ParseCertificates(svid.certs)
+	if err != nil {
+		return err
+	}
+	if len(certs) == 0 {
+		return errors.New("spiffe: SVID has no certificates")
+	}
+	id, err := IDFromCertificate(certs[0])
+	if err != nil {
+		return err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(svid.key)
+	if err != nil {
+		return err
+	}
+	cert := &tls.Certificate{PrivateKey: key, Leaf: certs[0]}
+	for _, c := range certs {
+		cert.Certificate = append(cert.Certificate, c.Raw)
+	}
+
+	bundles := make(map[string]*x509.CertPool)
+	if bundles[trustDomain(id)], err = newCertPool(svid.bundle); err != nil {
+		return err
+	}
+	for domain, bundle := range res.federatedBundles {
+		// Federated bundles are keyed by trust domain, with or without the
+		// spiffe:// scheme.
+		if td := trustDomain(domain); td != "" {
+			domain = td
+		}
+		if bundles[domain], err = newCertPool(bundle); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cert == nil {
+		close(s.ready)
+	}
+	s.id = id
+	s.cert = cert
+	s.bundles = bundles
+	s.opts.logger.Info("received SVID from Workload API",
+		zap.String("id", id), zap.Time("expires", certs[0].NotAfter))
+	return nil
+}
+
+func newCertPool(der []byte) (*x509.CertPool, error) {
+	certs, err := x509.ParseCertificates(der)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	for _, c := range certs {
+		pool.AddCert(c)
+	}
+	return pool, nil
+}
+
+// Stop stops rotating SVIDs and disconnects from the Workload API.
+func (s *Source) Stop() error {
+	return s.once.Stop(s.stop)
+}
+
+func (s *Source) stop() error {
+	s.cancel()
+	<-s.done
+	return s.conn.Close()
+}
+
+// IsRunning returns whether the Source is receiving SVIDs.
+func (s *Source) IsRunning() bool {
+	return s.once.IsRunning()
+}
+
+// ID returns the SPIFFE ID of the workload.
+func (s *Source) ID() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.id
+}
+
+func (s *Source) certificate() (*tls.Certificate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.cert == nil {
+		return nil, errors.New("spiffe: no SVID received from the Workload API")
+	}
+	return s.cert, nil
+}
+
+// GetCertificate returns the current SVID. It is suitable for
+// tls.Config.GetCertificate.
+func (s *Source) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return s.certificate()
+}
+
+// GetClientCertificate returns the current SVID. It is suitable for
+// tls.Config.GetClientCertificate.
+func (s *Source) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return s.certificate()
+}
+
+// ClientTLSConfig builds a TLS configuration for clients that presents the
+// current SVID and only connects to servers with an SVID from a trusted
+// trust domain that the authorizer accepts.
+func (s *Source) ClientTLSConfig(authorize Authorizer) *tls.Config {
+	return &tls.Config{
+		MinVersion:           tls.VersionTLS12,
+		GetClientCertificate: s.GetClientCertificate,
+		// Servers are verified by SPIFFE ID rather than by host name.
+		InsecureSkipVerify:    true,
+		VerifyPeerCertificate: s.verifyPeer(authorize),
+	}
+}
+
+// ServerTLSConfig builds a TLS configuration for servers that presents the
+// current SVID and only accepts clients with an SVID from a trusted trust
+// domain that the authorizer accepts.
+func (s *Source) ServerTLSConfig(authorize Authorizer) *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: s.GetCertificate,
+		// Clients are verified by SPIFFE ID rather than against the system
+		// roots.
+		ClientAuth:            tls.RequireAnyClientCert,
+		VerifyPeerCertificate: s.verifyPeer(authorize),
+	}
+}
+
+// verifyPeer verifies the certificate chain of a peer against the trust
+// bundle of the trust domain of its SPIFFE ID and authorizes that ID.
+func (s *Source) verifyPeer(authorize Authorizer) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("spiffe: peer presented no certificate")
+		}
+		intermediates := x509.NewCertPool()
+		var leaf *x509.Certificate
+		for i, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+			if i == 0 {
+				leaf = cert
+			} else {
+				intermediates.AddCert(cert)
+			}
+		}
+
+		id, err := IDFromCertificate(leaf)
+		if err != nil {
+			return err
+		}
+		s.mu.RLock()
+		roots := s.bundles[trustDomain(id)]
+		s.mu.RUnlock()
+		if roots == nil {
+			return fmt.Errorf("spiffe: no trust bundle for peer ID %q", id)
+		}
+		_, err = leaf.Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		if err != nil {
+			return err
+		}
+		return authorize(id)
+	}
+}
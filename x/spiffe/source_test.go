@@ -0,0 +1,261 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package spiffe
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// authority issues SVIDs for a trust domain.
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newAuthority(t *testing.T, domain string) *authority {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: domain},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		URIs:                  []*url.URL{{Scheme: "spiffe", Host: domain}},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &authority{cert: cert, key: key}
+}
+
+// issue issues a certificate for the given SPIFFE ID, returning it and its
+// private key in DER form.
+func (a *authority) issue(t *testing.T, id string) (certDER, keyDER []byte) {
+	u, err := url.Parse(id)
+	require.NoError(t, err)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		URIs:         []*url.URL{u},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, &key.PublicKey, a.key)
+	require.NoError(t, err)
+	keyDER, err = x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return der, keyDER
+}
+
+// svid encodes an X509SVID message for the given SPIFFE ID.
+func (a *authority) svid(t *testing.T, id string) []byte {
+	der, keyDER := a.issue(t, id)
+	var msg []byte
+	msg = appendField(msg, 1, []byte(id))
+	msg = appendField(msg, 2, der)
+	msg = appendField(msg, 3, keyDER)
+	msg = appendField(msg, 4, a.cert.Raw)
+	return msg
+}
+
+func appendField(b []byte, field uint64, value []byte) []byte {
+	b = appendVarint(b, field<<3|2)
+	b = appendVarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+func appendVarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+// serveWorkloadAPI serves a fake Workload API on a unix socket that streams
+// the given X509SVIDResponse messages.
+func serveWorkloadAPI(t *testing.T, responses <-chan []byte) (addr string, stop func()) {
+	dir, err := ioutil.TempDir("", "spiffe")
+	require.NoError(t, err)
+	path := filepath.Join(dir, "agent.sock")
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+
+	server := grpc.NewServer(
+		grpc.CustomCodec(rawCodec{}),
+		grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+			method, _ := grpc.MethodFromServerStream(stream)
+			assert.Equal(t, _fetchX509SVID, method)
+			md, _ := metadata.FromIncomingContext(stream.Context())
+			assert.Equal(t, []string{"true"}, md["workload.spiffe.io"])
+
+			var req []byte
+			if err := stream.RecvMsg(&req); err != nil {
+				return err
+			}
+			for {
+				select {
+				case res := <-responses:
+					if err := stream.SendMsg(res); err != nil {
+						return err
+					}
+				case <-stream.Context().Done():
+					return nil
+				}
+			}
+		}),
+	)
+	go server.Serve(listener)
+	return "unix://" + path, func() {
+		server.Stop()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestSource(t *testing.T) {
+	ca := newAuthority(t, "example.org")
+	responses := make(chan []byte, 1)
+	addr, stop := serveWorkloadAPI(t, responses)
+	defer stop()
+
+	responses <- appendField(nil, 1, ca.svid(t, "spiffe://example.org/keyvalue"))
+	source := NewSource(Address(addr))
+	require.NoError(t, source.Start())
+	defer source.Stop()
+	assert.Equal(t, "spiffe://example.org/keyvalue", source.ID())
+
+	handshake := func(server, client Authorizer) error {
+		listener, err := tls.Listen("tcp", "127.0.0.1:0", source.ServerTLSConfig(server))
+		require.NoError(t, err)
+		defer listener.Close()
+
+		errs := make(chan error, 1)
+		go func() {
+			conn, err := listener.Accept()
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer conn.Close()
+			errs <- conn.(*tls.Conn).Handshake()
+		}()
+
+		conn, err := tls.Dial("tcp", listener.Addr().String(), source.ClientTLSConfig(client))
+		if err != nil {
+			<-errs
+			return err
+		}
+		defer conn.Close()
+		return <-errs
+	}
+
+	assert.NoError(t, handshake(AuthorizeMemberOf("example.org"), AuthorizeID("spiffe://example.org/keyvalue")))
+	assert.Error(t, handshake(AuthorizeAny(), AuthorizeID("spiffe://example.org/other")),
+		"client should reject unexpected server ID")
+	assert.Error(t, handshake(AuthorizeMemberOf("other.org"), AuthorizeAny()),
+		"server should reject client from another trust domain")
+
+	// Rotate to a new SVID.
+	responses <- appendField(nil, 1, ca.svid(t, "spiffe://example.org/keyvalue-v2"))
+	for i := 0; source.ID() != "spiffe://example.org/keyvalue-v2"; i++ {
+		require.True(t, i < 100, "timed out waiting for SVID rotation")
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.NoError(t, handshake(AuthorizeAny(), AuthorizeID("spiffe://example.org/keyvalue-v2")))
+}
+
+func TestSourceUntrustedDomain(t *testing.T) {
+	responses := make(chan []byte, 1)
+	addr, stop := serveWorkloadAPI(t, responses)
+	defer stop()
+
+	responses <- appendField(nil, 1, newAuthority(t, "example.org").svid(t, "spiffe://example.org/keyvalue"))
+	source := NewSource(Address(addr))
+	require.NoError(t, source.Start())
+	defer source.Stop()
+
+	verify := source.verifyPeer(AuthorizeAny())
+	other, _ := newAuthority(t, "other.org").issue(t, "spiffe://other.org/keyvalue")
+	assert.Error(t, verify([][]byte{other}, nil), "peer from unknown trust domain")
+
+	// A certificate that claims a trusted domain but is signed by another
+	// authority.
+	forged, _ := newAuthority(t, "example.org").issue(t, "spiffe://example.org/keyvalue")
+	assert.Error(t, verify([][]byte{forged}, nil), "peer with forged certificate")
+}
+
+func TestSourceStartTimeout(t *testing.T) {
+	addr, stop := serveWorkloadAPI(t, make(chan []byte))
+	defer stop()
+
+	source := NewSource(Address(addr), StartTimeout(50*time.Millisecond))
+	assert.Error(t, source.Start())
+}
+
+func TestSourceNoAddress(t *testing.T) {
+	os.Unsetenv(_addressEnv)
+	assert.Error(t, NewSource().Start())
+}
+
+func TestIDFromCertificate(t *testing.T) {
+	tests := []struct {
+		uris    []*url.URL
+		want    string
+		wantErr bool
+	}{
+		{uris: []*url.URL{{Scheme: "spiffe", Host: "example.org", Path: "/foo"}}, want: "spiffe://example.org/foo"},
+		{uris: nil, wantErr: true},
+		{uris: []*url.URL{{Scheme: "https", Host: "example.org"}}, wantErr: true},
+		{uris: []*url.URL{{Scheme: "spiffe", Host: "a.org"}, {Scheme: "spiffe", Host: "b.org"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		id, err := IDFromCertificate(&x509.Certificate{URIs: tt.uris})
+		if tt.wantErr {
+			assert.Error(t, err, "%v", tt.uris)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, id)
+	}
+}
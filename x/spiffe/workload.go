@@ -0,0 +1,215 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package spiffe
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// _fetchX509SVID is the server-streaming method of the Workload API that
+// sends the X.509 SVIDs of the workload whenever they change.
+const _fetchX509SVID = "/SpiffeWorkloadAPI/FetchX509SVID"
+
+var errTruncated = errors.New("spiffe: truncated Workload API response")
+
+// x509SVIDResponse is an X509SVIDResponse message of the Workload API.
+type x509SVIDResponse struct {
+	svids            []x509SVID
+	federatedBundles map[string][]byte
+}
+
+// x509SVID is an X509SVID message of the Workload API.
+type x509SVID struct {
+	id     string
+	certs  []byte // ASN.1 DER certificates, leaf first
+	key    []byte // ASN.1 DER PKCS#8 private key
+	bundle []byte // ASN.1 DER certificates of the trust domain
+}
+
+// dialWorkloadAPI connects to the Workload API at a "unix://" address.
+func dialWorkloadAPI(addr string) (*grpc.ClientConn, error) {
+	if !strings.HasPrefix(addr, "unix:") {
+		return nil, fmt.Errorf("spiffe: Workload API address %q must be a unix:// address", addr)
+	}
+	path := strings.TrimPrefix(strings.TrimPrefix(addr, "unix:"), "//")
+	return grpc.Dial(path,
+		grpc.WithInsecure(),
+		grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+			return net.DialTimeout("unix", addr, timeout)
+		}),
+	)
+}
+
+// fetchX509SVIDs opens a FetchX509SVID stream and calls f with every
+// response until the stream fails or the context is cancelled.
+func fetchX509SVIDs(ctx context.Context, conn *grpc.ClientConn, f func(*x509SVIDResponse)) error {
+	// The Workload API rejects requests without this header to protect
+	// against server-side request forgery.
+	ctx = metadata.NewOutgoingContext(ctx, metadata.Pairs("workload.spiffe.io", "true"))
+	stream, err := conn.NewStream(ctx,
+		&grpc.StreamDesc{ServerStreams: true},
+		_fetchX509SVID,
+		grpc.CallCustomCodec(rawCodec{}),
+	)
+	if err != nil {
+		return err
+	}
+	// X509SVIDRequest has no fields.
+	if err := stream.SendMsg([]byte{}); err != nil {
+		return err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+	for {
+		var msg []byte
+		if err := stream.RecvMsg(&msg); err != nil {
+			return err
+		}
+		res, err := decodeX509SVIDResponse(msg)
+		if err != nil {
+			return err
+		}
+		f(res)
+	}
+}
+
+// rawCodec passes protobuf messages to and from the wire as bytes.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(obj interface{}) ([]byte, error) {
+	if b, ok := obj.([]byte); ok {
+		return b, nil
+	}
+	return nil, fmt.Errorf("expected object to be of type []byte but got %T", obj)
+}
+
+func (rawCodec) Unmarshal(data []byte, obj interface{}) error {
+	if b, ok := obj.(*[]byte); ok {
+		*b = data
+		return nil
+	}
+	return fmt.Errorf("expected object to be of type *[]byte but got %T", obj)
+}
+
+func (rawCodec) String() string { return "proto" }
+
+func decodeX509SVIDResponse(b []byte) (*x509SVIDResponse, error) {
+	res := &x509SVIDResponse{federatedBundles: make(map[string][]byte)}
+	err := decodeFields(b, func(field uint64, value []byte) error {
+		switch field {
+		case 1: // svids
+			svid, err := decodeX509SVID(value)
+			if err != nil {
+				return err
+			}
+			res.svids = append(res.svids, svid)
+		case 3: // federated_bundles
+			var domain string
+			var bundle []byte
+			err := decodeFields(value, func(field uint64, value []byte) error {
+				switch field {
+				case 1:
+					domain = string(value)
+				case 2:
+					bundle = value
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			res.federatedBundles[domain] = bundle
+		}
+		return nil
+	})
+	return res, err
+}
+
+func decodeX509SVID(b []byte) (x509SVID, error) {
+	var svid x509SVID
+	err := decodeFields(b, func(field uint64, value []byte) error {
+		switch field {
+		case 1:
+			svid.id = string(value)
+		case 2:
+			svid.certs = value
+		case 3:
+			svid.key = value
+		case 4:
+			svid.bundle = value
+		}
+		return nil
+	})
+	return svid, err
+}
+
+// decodeFields calls f with the number and contents of each length-delimited
+// field of a protobuf message, skipping fields of other wire types.
+func decodeFields(b []byte, f func(field uint64, value []byte) error) error {
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errTruncated
+		}
+		b = b[n:]
+
+		switch wireType := tag & 7; wireType {
+		case 0: // varint
+			if _, n = binary.Uvarint(b); n <= 0 {
+				return errTruncated
+			}
+			b = b[n:]
+		case 1: // 64-bit
+			if len(b) < 8 {
+				return errTruncated
+			}
+			b = b[8:]
+		case 2: // length-delimited
+			length, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < length {
+				return errTruncated
+			}
+			value := b[n : n+int(length)]
+			b = b[n+int(length):]
+			if err := f(tag>>3, value); err != nil {
+				return err
+			}
+		case 5: // 32-bit
+			if len(b) < 4 {
+				return errTruncated
+			}
+			b = b[4:]
+		default:
+			return fmt.Errorf("spiffe: unsupported wire type %d in Workload API response", wireType)
+		}
+	}
+	return nil
+}
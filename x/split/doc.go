@@ -0,0 +1,41 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package split provides an outbound that splits calls across several
+// outbounds by weight, for canary rollouts and migrations between clusters
+// or transports.
+//
+// 	outbound, err := split.NewOutbound([]split.Target{
+// 		{Name: "stable", Outbound: stable, Weight: 95},
+// 		{Name: "canary", Outbound: canary, Weight: 5},
+// 	})
+//
+// Each call is sent to one target, picked at random in proportion to the
+// weights of the targets that support the type of the call. Weights may be
+// changed while the outbound is running with SetWeights, for example when
+// configuration is reloaded, or over HTTP by mounting the handler returned by
+// Handler on an admin server:
+//
+// 	mux.Handle("/split/myservice", outbound.Handler())
+//
+// 	$ curl -X PUT -d '{"stable": 50, "canary": 50}' localhost:8080/split/myservice
+//
+// The split outbound starts and stops the outbounds of its targets.
+package split
@@ -0,0 +1,255 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package split
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/multierr"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+var (
+	_ transport.UnaryOutbound  = (*Outbound)(nil)
+	_ transport.OnewayOutbound = (*Outbound)(nil)
+)
+
+// Target is an outbound that receives a share of the calls of a split
+// Outbound.
+type Target struct {
+	// Name identifies the target when changing weights.
+	Name string
+
+	// Outbound receives the calls sent to this target. Unary calls are only
+	// sent to targets whose outbound is a transport.UnaryOutbound, and oneway
+	// calls to targets whose outbound is a transport.OnewayOutbound.
+	Outbound transport.Outbound
+
+	// Weight is the share of calls sent to this target, relative to the
+	// weights of the other targets. Targets with a weight of zero receive no
+	// calls.
+	Weight int
+}
+
+// Outbound is an outbound that splits calls across several outbounds by
+// weight.
+type Outbound struct {
+	once    *lifecycle.Once
+	opts    options
+	targets []Target // sorted by name; weights are guarded by mu
+
+	mu sync.RWMutex
+}
+
+// NewOutbound builds an Outbound that splits calls across the given targets.
+// Targets must have unique, non-empty names and non-negative weights.
+func NewOutbound(targets []Target, opts ...Option) (*Outbound, error) {
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("split outbound requires at least one target")
+	}
+
+	sorted := make([]Target, len(targets))
+	copy(sorted, targets)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	weights := make(map[string]int, len(sorted))
+	for i, t := range sorted {
+		if t.Name == "" {
+			return nil, fmt.Errorf("split outbound target %d has no name", i)
+		}
+		if t.Outbound == nil {
+			return nil, fmt.Errorf("split outbound target %q has no outbound", t.Name)
+		}
+		if i > 0 && sorted[i-1].Name == t.Name {
+			return nil, fmt.Errorf("split outbound has more than one target named %q", t.Name)
+		}
+		weights[t.Name] = t.Weight
+	}
+	if err := validateWeights(sorted, weights); err != nil {
+		return nil, err
+	}
+
+	return &Outbound{
+		once:    lifecycle.NewOnce(),
+		opts:    applyOptions(opts...),
+		targets: sorted,
+	}, nil
+}
+
+// Transports returns the transports used by the outbounds of all targets.
+func (o *Outbound) Transports() []transport.Transport {
+	var transports []transport.Transport
+	for _, t := range o.targets {
+		transports = append(transports, t.Outbound.Transports()...)
+	}
+	return transports
+}
+
+// Start starts the outbounds of all targets.
+func (o *Outbound) Start() error {
+	return o.once.Start(o.start)
+}
+
+func (o *Outbound) start() error {
+	var errs error
+	for _, t := range o.targets {
+		errs = multierr.Append(errs, t.Outbound.Start())
+	}
+	return errs
+}
+
+// Stop stops the outbounds of all targets.
+func (o *Outbound) Stop() error {
+	return o.once.Stop(o.stop)
+}
+
+func (o *Outbound) stop() error {
+	var errs error
+	for _, t := range o.targets {
+		errs = multierr.Append(errs, t.Outbound.Stop())
+	}
+	return errs
+}
+
+// IsRunning returns whether the outbound is running.
+func (o *Outbound) IsRunning() bool {
+	return o.once.IsRunning()
+}
+
+// Call sends a unary request to one of the targets that support unary calls.
+func (o *Outbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	t, ok := o.choose(func(out transport.Outbound) bool {
+		_, ok := out.(transport.UnaryOutbound)
+		return ok
+	})
+	if !ok {
+		return nil, yarpcerrors.UnavailableErrorf("split outbound has no unary target with a positive weight")
+	}
+	return t.Outbound.(transport.UnaryOutbound).Call(ctx, req)
+}
+
+// CallOneway sends a oneway request to one of the targets that support
+// oneway calls.
+func (o *Outbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	t, ok := o.choose(func(out transport.Outbound) bool {
+		_, ok := out.(transport.OnewayOutbound)
+		return ok
+	})
+	if !ok {
+		return nil, yarpcerrors.UnavailableErrorf("split outbound has no oneway target with a positive weight")
+	}
+	return t.Outbound.(transport.OnewayOutbound).CallOneway(ctx, req)
+}
+
+// choose picks a target among those whose outbound supports the call, in
+// proportion to their weights.
+func (o *Outbound) choose(supports func(transport.Outbound) bool) (Target, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	total := 0
+	for _, t := range o.targets {
+		if t.Weight > 0 && supports(t.Outbound) {
+			total += t.Weight
+		}
+	}
+	if total == 0 {
+		return Target{}, false
+	}
+
+	n := o.opts.random(total)
+	for _, t := range o.targets {
+		if t.Weight <= 0 || !supports(t.Outbound) {
+			continue
+		}
+		if n < t.Weight {
+			return t, true
+		}
+		n -= t.Weight
+	}
+	// unreachable as long as random returns a number in [0, total)
+	return Target{}, false
+}
+
+// Weights returns the current weight of each target, by name.
+func (o *Outbound) Weights() map[string]int {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	weights := make(map[string]int, len(o.targets))
+	for _, t := range o.targets {
+		weights[t.Name] = t.Weight
+	}
+	return weights
+}
+
+// SetWeights changes the weights of the targets. Targets missing from the
+// given map receive no calls. SetWeights fails without changing any weight
+// if the map names an unknown target, has negative weights, or if no target
+// would have a positive weight.
+func (o *Outbound) SetWeights(weights map[string]int) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err := validateWeights(o.targets, weights); err != nil {
+		return err
+	}
+	for i := range o.targets {
+		o.targets[i].Weight = weights[o.targets[i].Name]
+	}
+	o.opts.logger.Info("Updated split outbound weights.", zap.Any("weights", weights))
+	return nil
+}
+
+func validateWeights(targets []Target, weights map[string]int) error {
+	known := make(map[string]struct{}, len(targets))
+	for _, t := range targets {
+		known[t.Name] = struct{}{}
+	}
+
+	var unknown []string
+	total := 0
+	for name, w := range weights {
+		if _, ok := known[name]; !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		if w < 0 {
+			return fmt.Errorf("split outbound target %q has negative weight %d", name, w)
+		}
+		total += w
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("split outbound has no targets named %s", strings.Join(unknown, ", "))
+	}
+	if total == 0 {
+		return fmt.Errorf("split outbound requires a target with a positive weight")
+	}
+	return nil
+}
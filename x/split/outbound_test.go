@@ -0,0 +1,230 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package split
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// unaryOutbound is a unary outbound that identifies itself in the headers of
+// its responses.
+type unaryOutbound struct {
+	name    string
+	running bool
+}
+
+func (o *unaryOutbound) Transports() []transport.Transport { return nil }
+func (o *unaryOutbound) Start() error                      { o.running = true; return nil }
+func (o *unaryOutbound) Stop() error                       { o.running = false; return nil }
+func (o *unaryOutbound) IsRunning() bool                   { return o.running }
+
+func (o *unaryOutbound) Call(context.Context, *transport.Request) (*transport.Response, error) {
+	return &transport.Response{Headers: transport.NewHeaders().With("target", o.name)}, nil
+}
+
+// onewayOutbound also supports oneway calls.
+type onewayOutbound struct{ unaryOutbound }
+
+type ack string
+
+func (a ack) String() string { return string(a) }
+
+func (o *onewayOutbound) CallOneway(context.Context, *transport.Request) (transport.Ack, error) {
+	return ack(o.name), nil
+}
+
+func target(t *testing.T, o *Outbound) string {
+	res, err := o.Call(context.Background(), &transport.Request{})
+	require.NoError(t, err)
+	name, _ := res.Headers.Get("target")
+	return name
+}
+
+func TestNewOutboundErrors(t *testing.T) {
+	out := &unaryOutbound{}
+	tests := []struct {
+		desc    string
+		targets []Target
+		wantErr string
+	}{
+		{
+			desc:    "no targets",
+			wantErr: "requires at least one target",
+		},
+		{
+			desc:    "no name",
+			targets: []Target{{Outbound: out, Weight: 1}},
+			wantErr: "target 0 has no name",
+		},
+		{
+			desc:    "no outbound",
+			targets: []Target{{Name: "a", Weight: 1}},
+			wantErr: `target "a" has no outbound`,
+		},
+		{
+			desc:    "duplicate name",
+			targets: []Target{{Name: "a", Outbound: out, Weight: 1}, {Name: "a", Outbound: out}},
+			wantErr: `more than one target named "a"`,
+		},
+		{
+			desc:    "negative weight",
+			targets: []Target{{Name: "a", Outbound: out, Weight: -1}},
+			wantErr: `target "a" has negative weight -1`,
+		},
+		{
+			desc:    "no positive weight",
+			targets: []Target{{Name: "a", Outbound: out}},
+			wantErr: "requires a target with a positive weight",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := NewOutbound(tt.targets)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	var n int
+	o, err := NewOutbound([]Target{
+		{Name: "stable", Outbound: &unaryOutbound{name: "stable"}, Weight: 3},
+		{Name: "canary", Outbound: &unaryOutbound{name: "canary"}, Weight: 1},
+	}, withRandom(func(total int) int {
+		defer func() { n = (n + 1) % total }()
+		return n
+	}))
+	require.NoError(t, err)
+	require.NoError(t, o.Start())
+	defer o.Stop()
+
+	// Targets are ordered by name, so "canary" receives the first quarter of
+	// the range.
+	var got []string
+	for i := 0; i < 4; i++ {
+		got = append(got, target(t, o))
+	}
+	assert.Equal(t, []string{"canary", "stable", "stable", "stable"}, got)
+
+	require.NoError(t, o.SetWeights(map[string]int{"stable": 1}))
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, "stable", target(t, o))
+	}
+	assert.Equal(t, map[string]int{"stable": 1, "canary": 0}, o.Weights())
+}
+
+func TestSetWeightsErrors(t *testing.T) {
+	o, err := NewOutbound([]Target{
+		{Name: "a", Outbound: &unaryOutbound{}, Weight: 1},
+	})
+	require.NoError(t, err)
+
+	err = o.SetWeights(map[string]int{"a": 1, "c": 1, "b": 1})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no targets named b, c")
+
+	require.Error(t, o.SetWeights(map[string]int{"a": -1}))
+	require.Error(t, o.SetWeights(map[string]int{"a": 0}))
+	assert.Equal(t, map[string]int{"a": 1}, o.Weights(), "weights must not change")
+}
+
+func TestSplitByCallType(t *testing.T) {
+	o, err := NewOutbound([]Target{
+		{Name: "unary", Outbound: &unaryOutbound{name: "unary"}, Weight: 1},
+		{Name: "oneway", Outbound: &onewayOutbound{unaryOutbound{name: "oneway"}}},
+	})
+	require.NoError(t, err)
+
+	_, err = o.CallOneway(context.Background(), &transport.Request{})
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeUnavailable, yarpcerrors.FromError(err).Code())
+
+	require.NoError(t, o.SetWeights(map[string]int{"unary": 1, "oneway": 1}))
+	for i := 0; i < 10; i++ {
+		a, err := o.CallOneway(context.Background(), &transport.Request{})
+		require.NoError(t, err)
+		assert.Equal(t, "oneway", a.String())
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	a, b := &unaryOutbound{}, &unaryOutbound{}
+	o, err := NewOutbound([]Target{
+		{Name: "a", Outbound: a, Weight: 1},
+		{Name: "b", Outbound: b},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, o.Start())
+	assert.True(t, o.IsRunning())
+	assert.True(t, a.IsRunning())
+	assert.True(t, b.IsRunning())
+
+	require.NoError(t, o.Stop())
+	assert.False(t, a.IsRunning())
+	assert.False(t, b.IsRunning())
+}
+
+func TestHandler(t *testing.T) {
+	o, err := NewOutbound([]Target{
+		{Name: "stable", Outbound: &unaryOutbound{}, Weight: 100},
+		{Name: "canary", Outbound: &unaryOutbound{}},
+	})
+	require.NoError(t, err)
+	h := o.Handler()
+
+	serve := func(method, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, "/", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := serve(http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"stable": 100, "canary": 0}`, rec.Body.String())
+
+	rec = serve(http.MethodPut, `{"stable": 95, "canary": 5}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"stable": 95, "canary": 5}`, rec.Body.String())
+	assert.Equal(t, map[string]int{"stable": 95, "canary": 5}, o.Weights())
+
+	rec = serve(http.MethodPut, `{"other": 1}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "no targets named other")
+
+	rec = serve(http.MethodPut, `not json`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serve(http.MethodDelete, "")
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "GET, PUT", rec.Header().Get("Allow"))
+}
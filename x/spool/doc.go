@@ -0,0 +1,44 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package spool provides a oneway outbound that persists requests to a local
+// store before acknowledging them and delivers them in the background,
+// retrying with backoff until the downstream service accepts them.
+//
+// Spooling lets oneway calls survive transient downstream outages and process
+// restarts: requests that have not been delivered when the process stops are
+// delivered after the outbound is started again with the same store.
+//
+// 	store, err := spool.NewFileStore("/var/spool/myservice")
+// 	if err != nil {
+// 		return err
+// 	}
+// 	outbound := spool.NewOutbound(http.NewTransport().NewSingleOutbound(url), store)
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		Outbounds: yarpc.Outbounds{
+// 			"telemetry": {Oneway: outbound},
+// 		},
+// 	})
+//
+// The ack returned by a spooled call only indicates that the request was
+// persisted. Requests are delivered at least once and may be delivered out of
+// order.
+package spool
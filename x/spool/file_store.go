@@ -0,0 +1,156 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package spool
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const (
+	_recordSuffix  = ".json"
+	_corruptSuffix = ".corrupt"
+)
+
+// FileStoreOption customizes the behavior of a file Store.
+type FileStoreOption interface {
+	applyFileStore(*fileStore)
+}
+
+type fileStoreOptionFunc func(*fileStore)
+
+func (f fileStoreOptionFunc) applyFileStore(s *fileStore) { f(s) }
+
+// FileStoreLogger specifies the logger the file Store uses to log records it
+// can't read. Default value is noop zap logger.
+func FileStoreLogger(logger *zap.Logger) FileStoreOption {
+	return fileStoreOptionFunc(func(s *fileStore) {
+		s.logger = logger
+	})
+}
+
+// NewFileStore builds a Store that persists every record as a JSON file in
+// the given directory, creating the directory if necessary.
+//
+// Records are written to a temporary file and renamed into place so that a
+// crash never leaves a partially written record behind. Record files that
+// can't be read or decoded are logged and renamed with a ".corrupt" suffix,
+// so that they don't hold up the delivery of the other records.
+func NewFileStore(dir string, opts ...FileStoreOption) (Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	s := &fileStore{dir: dir, logger: zap.NewNop()}
+	for _, opt := range opts {
+		opt.applyFileStore(s)
+	}
+	return s, nil
+}
+
+type fileStore struct {
+	dir    string
+	logger *zap.Logger
+}
+
+func (s *fileStore) path(id string) string {
+	return filepath.Join(s.dir, id+_recordSuffix)
+}
+
+func (s *fileStore) Put(r Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(s.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), s.path(r.ID))
+}
+
+func (s *fileStore) Delete(id string) error {
+	if err := os.Remove(s.path(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *fileStore) List() ([]Record, error) {
+	infos, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]Record, 0, len(infos))
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || !strings.HasSuffix(name, _recordSuffix) {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(s.dir, name))
+		if os.IsNotExist(err) {
+			// Deleted concurrently.
+			continue
+		}
+		var r Record
+		if err == nil {
+			err = json.Unmarshal(data, &r)
+		}
+		if err != nil {
+			s.moveAside(name, err)
+			continue
+		}
+		records = append(records, r)
+	}
+	sortRecords(records)
+	return records, nil
+}
+
+// moveAside renames a record file that can't be read so that it is no longer
+// listed.
+func (s *fileStore) moveAside(name string, err error) {
+	path := filepath.Join(s.dir, name)
+	if renameErr := os.Rename(path, path+_corruptSuffix); renameErr != nil {
+		s.logger.Error("failed to move aside corrupt spooled request",
+			zap.String("file", path), zap.Error(err), zap.NamedError("renameError", renameErr))
+		return
+	}
+	s.logger.Error("moved aside corrupt spooled request",
+		zap.String("file", path+_corruptSuffix), zap.Error(err))
+}
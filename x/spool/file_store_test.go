@@ -0,0 +1,112 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package spool
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func bytesReader(s string) io.Reader {
+	return bytes.NewReader([]byte(s))
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := NewFileStore(filepath.Join(dir, "nested"))
+	require.NoError(t, err)
+
+	now := time.Unix(1500000000, 0).UTC()
+	first := Record{
+		ID:        "b",
+		Procedure: "first",
+		Headers:   map[string]string{"foo": "bar"},
+		Body:      []byte("hello"),
+		CreatedAt: now,
+	}
+	second := Record{
+		ID:        "a",
+		Procedure: "second",
+		Body:      []byte("world"),
+		CreatedAt: now.Add(time.Second),
+	}
+	require.NoError(t, store.Put(second))
+	require.NoError(t, store.Put(first))
+
+	records, err := store.List()
+	require.NoError(t, err)
+	assert.Equal(t, []Record{first, second}, records, "records must be listed oldest first")
+
+	first.Attempts = 3
+	require.NoError(t, store.Put(first))
+	require.NoError(t, store.Delete("a"))
+	require.NoError(t, store.Delete("does-not-exist"))
+
+	// A new store on the same directory sees the same records.
+	reopened, err := NewFileStore(filepath.Join(dir, "nested"))
+	require.NoError(t, err)
+	records, err = reopened.List()
+	require.NoError(t, err)
+	assert.Equal(t, []Record{first}, records)
+}
+
+func TestFileStoreSkipsCorruptRecords(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	core, logs := observer.New(zap.ErrorLevel)
+	store, err := NewFileStore(dir, FileStoreLogger(zap.New(core)))
+	require.NoError(t, err)
+
+	now := time.Unix(1500000000, 0).UTC()
+	first := Record{ID: "a", Procedure: "first", CreatedAt: now}
+	last := Record{ID: "c", Procedure: "last", CreatedAt: now.Add(time.Second)}
+	require.NoError(t, store.Put(first))
+	require.NoError(t, store.Put(last))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "b.json"), []byte("{not json"), 0644))
+
+	records, err := store.List()
+	require.NoError(t, err)
+	assert.Equal(t, []Record{first, last}, records)
+
+	_, err = os.Stat(filepath.Join(dir, "b.json.corrupt"))
+	assert.NoError(t, err, "corrupt record must be moved aside")
+	assert.Equal(t, 1, logs.FilterMessage("moved aside corrupt spooled request").Len())
+
+	records, err = store.List()
+	require.NoError(t, err)
+	assert.Equal(t, []Record{first, last}, records)
+	assert.Equal(t, 1, logs.Len(), "corrupt record must only be logged once")
+}
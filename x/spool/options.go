@@ -0,0 +1,107 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package spool
+
+import (
+	"time"
+
+	"go.uber.org/yarpc/api/backoff"
+	intbackoff "go.uber.org/yarpc/internal/backoff"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/zap"
+)
+
+const _defaultAttemptTimeout = 5 * time.Second
+
+// Option customizes the behavior of a spooling Outbound.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	backoff        backoff.Strategy
+	maxAttempts    uint
+	attemptTimeout time.Duration
+	logger         *zap.Logger
+	clock          clock.Clock
+}
+
+// Backoff specifies the backoff strategy for delays between delivery attempts
+// of a request.
+//
+// The default is exponential backoff starting with 10ms fully jittered,
+// doubling each attempt, with a maximum interval of 30s.
+func Backoff(strategy backoff.Strategy) Option {
+	return optionFunc(func(opts *options) {
+		opts.backoff = strategy
+	})
+}
+
+// MaxAttempts specifies the number of times delivery of a request is
+// attempted before it is dropped from the store. Dropped requests are logged.
+//
+// Defaults to 0, which retries requests until they are delivered.
+func MaxAttempts(maxAttempts uint) Option {
+	return optionFunc(func(opts *options) {
+		opts.maxAttempts = maxAttempts
+	})
+}
+
+// AttemptTimeout specifies the timeout for each delivery attempt.
+// Defaults to five seconds.
+func AttemptTimeout(timeout time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.attemptTimeout = timeout
+	})
+}
+
+// Logger specifies the logger that should be used to log.
+// Default value is noop zap logger.
+func Logger(logger *zap.Logger) Option {
+	return optionFunc(func(opts *options) {
+		opts.logger = logger
+	})
+}
+
+// withClock specifies the clock used to schedule retries.
+// It is only used for testing.
+func withClock(clock clock.Clock) Option {
+	return optionFunc(func(opts *options) {
+		opts.clock = clock
+	})
+}
+
+func applyOptions(opts ...Option) options {
+	options := options{
+		backoff:        intbackoff.DefaultExponential,
+		attemptTimeout: _defaultAttemptTimeout,
+		logger:         zap.NewNop(),
+		clock:          clock.NewReal(),
+	}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return options
+}
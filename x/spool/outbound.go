@@ -0,0 +1,274 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package spool
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"go.uber.org/atomic"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+var _ transport.OnewayOutbound = (*Outbound)(nil)
+
+// Outbound is a oneway outbound that spools requests to a Store and delivers
+// them through another oneway outbound in the background.
+type Outbound struct {
+	once  *lifecycle.Once
+	out   transport.OnewayOutbound
+	store Store
+	opts  options
+
+	seq  atomic.Uint64
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+
+	// Only accessed by the delivery goroutine.
+	retryAt map[string]time.Time
+}
+
+// NewOutbound builds a new spooling Outbound which persists requests to the
+// given store and delivers them through the given outbound.
+//
+// The returned Outbound manages the lifecycle of the wrapped outbound.
+func NewOutbound(out transport.OnewayOutbound, store Store, opts ...Option) *Outbound {
+	return &Outbound{
+		once:    lifecycle.NewOnce(),
+		out:     out,
+		store:   store,
+		opts:    applyOptions(opts...),
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		retryAt: make(map[string]time.Time),
+	}
+}
+
+// Transports returns the transports used by the wrapped outbound.
+func (o *Outbound) Transports() []transport.Transport {
+	return o.out.Transports()
+}
+
+// Start starts the wrapped outbound and begins delivering spooled requests,
+// including requests left in the store by a previous process.
+func (o *Outbound) Start() error {
+	return o.once.Start(o.start)
+}
+
+func (o *Outbound) start() error {
+	if err := o.out.Start(); err != nil {
+		return err
+	}
+	go o.run()
+	return nil
+}
+
+// Stop stops delivering spooled requests and stops the wrapped outbound.
+// Requests that have not been delivered remain in the store.
+func (o *Outbound) Stop() error {
+	return o.once.Stop(o.doStop)
+}
+
+func (o *Outbound) doStop() error {
+	close(o.stop)
+	<-o.done
+	return o.out.Stop()
+}
+
+// IsRunning returns whether the outbound is running.
+func (o *Outbound) IsRunning() bool {
+	return o.once.IsRunning()
+}
+
+// CallOneway persists the request to the store and returns an ack as soon as
+// it has been persisted. The request is delivered in the background.
+func (o *Outbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	if err := o.once.WaitUntilRunning(ctx); err != nil {
+		return nil, err
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	now := o.opts.clock.Now()
+	record := Record{
+		ID:              fmt.Sprintf("%020d-%010d", now.UnixNano(), o.seq.Inc()),
+		Caller:          req.Caller,
+		Service:         req.Service,
+		Procedure:       req.Procedure,
+		Encoding:        string(req.Encoding),
+		ShardKey:        req.ShardKey,
+		RoutingKey:      req.RoutingKey,
+		RoutingDelegate: req.RoutingDelegate,
+		Body:            body,
+		CreatedAt:       now,
+	}
+	if req.Headers.Len() > 0 {
+		record.Headers = make(map[string]string, req.Headers.Len())
+		for k, v := range req.Headers.Items() {
+			record.Headers[k] = v
+		}
+	}
+	if err := o.store.Put(record); err != nil {
+		return nil, yarpcerrors.InternalErrorf("failed to spool request to %q: %v", req.Procedure, err)
+	}
+
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+	return ack(record.ID), nil
+}
+
+// run delivers spooled requests until the outbound is stopped.
+func (o *Outbound) run() {
+	defer close(o.done)
+	for {
+		var after <-chan time.Time
+		if wait, ok := o.deliver(); ok {
+			after = o.opts.clock.After(wait)
+		}
+		select {
+		case <-o.stop:
+			return
+		case <-o.wake:
+		case <-after:
+		}
+	}
+}
+
+// deliver attempts delivery of every spooled request that is due. It returns
+// how long to wait until the next request is due, if any is pending.
+func (o *Outbound) deliver() (wait time.Duration, pending bool) {
+	records, err := o.store.List()
+	if err != nil {
+		o.opts.logger.Error("failed to list spooled requests", zap.Error(err))
+		return o.opts.backoff.Backoff().Duration(0), true
+	}
+
+	var next time.Time
+	for _, r := range records {
+		select {
+		case <-o.stop:
+			return 0, false
+		default:
+		}
+
+		if o.opts.clock.Now().Before(r.DeliverAt) {
+			if next.IsZero() || r.DeliverAt.Before(next) {
+				next = r.DeliverAt
+			}
+			continue
+		}
+		if at, ok := o.retryAt[r.ID]; ok && o.opts.clock.Now().Before(at) {
+			if next.IsZero() || at.Before(next) {
+				next = at
+			}
+			continue
+		}
+		if at, ok := o.attempt(r); ok {
+			if next.IsZero() || at.Before(next) {
+				next = at
+			}
+		}
+	}
+	if next.IsZero() {
+		return 0, false
+	}
+	return next.Sub(o.opts.clock.Now()), true
+}
+
+// attempt delivers a single request. If the request must be retried, it
+// returns the time of the next attempt.
+func (o *Outbound) attempt(r Record) (time.Time, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), o.opts.attemptTimeout)
+	_, err := o.out.CallOneway(ctx, r.request())
+	cancel()
+
+	if err == nil {
+		delete(o.retryAt, r.ID)
+		if err := o.store.Delete(r.ID); err != nil {
+			o.opts.logger.Error("failed to delete delivered request from spool",
+				zap.String("procedure", r.Procedure), zap.Error(err))
+		}
+		return time.Time{}, false
+	}
+
+	r.Attempts++
+	if o.opts.maxAttempts > 0 && r.Attempts >= o.opts.maxAttempts {
+		o.opts.logger.Error("dropping spooled request after too many failed attempts",
+			zap.String("procedure", r.Procedure), zap.Uint("attempts", r.Attempts), zap.Error(err))
+		delete(o.retryAt, r.ID)
+		if err := o.store.Delete(r.ID); err != nil {
+			o.opts.logger.Error("failed to delete dropped request from spool",
+				zap.String("procedure", r.Procedure), zap.Error(err))
+		}
+		return time.Time{}, false
+	}
+
+	o.opts.logger.Warn("failed to deliver spooled request, will retry",
+		zap.String("procedure", r.Procedure), zap.Uint("attempts", r.Attempts), zap.Error(err))
+	if err := o.store.Put(r); err != nil {
+		o.opts.logger.Error("failed to update spooled request",
+			zap.String("procedure", r.Procedure), zap.Error(err))
+	}
+	at := o.opts.clock.Now().Add(o.opts.backoff.Backoff().Duration(r.Attempts))
+	o.retryAt[r.ID] = at
+	return at, true
+}
+
+func (r Record) request() *transport.Request {
+	return &transport.Request{
+		Caller:          r.Caller,
+		Service:         r.Service,
+		Procedure:       r.Procedure,
+		Encoding:        transport.Encoding(r.Encoding),
+		Headers:         transport.HeadersFromMap(r.Headers),
+		ShardKey:        r.ShardKey,
+		RoutingKey:      r.RoutingKey,
+		RoutingDelegate: r.RoutingDelegate,
+		Body:            bytes.NewReader(r.Body),
+	}
+}
+
+// ack is returned for requests that have been spooled.
+type ack string
+
+func (a ack) String() string {
+	return "spooled:" + string(a)
+}
+
+func (a ack) Status() transport.AckStatus {
+	return transport.AckStatusPersisted
+}
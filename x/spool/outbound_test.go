@@ -0,0 +1,239 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package spool
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/backoff"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/internal/testtime"
+)
+
+type call struct {
+	procedure string
+	headers   map[string]string
+	body      string
+}
+
+// fakeOutbound records delivered calls and fails the first failures calls.
+type fakeOutbound struct {
+	transport.Outbound
+
+	mu       sync.Mutex
+	failures int
+	calls    chan call
+}
+
+func newFakeOutbound(failures int) *fakeOutbound {
+	return &fakeOutbound{failures: failures, calls: make(chan call, 10)}
+}
+
+func (o *fakeOutbound) Start() error                      { return nil }
+func (o *fakeOutbound) Stop() error                       { return nil }
+func (o *fakeOutbound) Transports() []transport.Transport { return nil }
+
+func (o *fakeOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.failures > 0 {
+		o.failures--
+		return nil, errors.New("great sadness")
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	o.calls <- call{procedure: req.Procedure, headers: req.Headers.Items(), body: string(body)}
+	return nil, nil
+}
+
+type fixedBackoff time.Duration
+
+func (b fixedBackoff) Backoff() backoff.Backoff    { return b }
+func (b fixedBackoff) Duration(uint) time.Duration { return time.Duration(b) }
+
+func waitForCall(t *testing.T, out *fakeOutbound) call {
+	select {
+	case c := <-out.calls:
+		return c
+	case <-time.After(testtime.Second):
+		t.Fatal("timed out waiting for delivery")
+		return call{}
+	}
+}
+
+func waitForEmptyStore(t *testing.T, store Store) {
+	deadline := time.Now().Add(testtime.Second)
+	for time.Now().Before(deadline) {
+		records, err := store.List()
+		require.NoError(t, err)
+		if len(records) == 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("timed out waiting for the spool to drain")
+}
+
+func callOneway(t *testing.T, o *Outbound, body string) {
+	ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+	defer cancel()
+	ack, err := o.CallOneway(ctx, &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Procedure: "procedure",
+		Encoding:  "raw",
+		Headers:   transport.NewHeaders().With("foo", "bar"),
+		Body:      bytesReader(body),
+	})
+	require.NoError(t, err)
+	assert.Contains(t, ack.String(), "spooled:")
+	assert.Equal(t, transport.AckStatusPersisted, transport.AckStatusOf(ack))
+}
+
+func TestOutboundDelivers(t *testing.T) {
+	out := newFakeOutbound(0)
+	store := NewMemoryStore()
+	o := NewOutbound(out, store)
+	require.NoError(t, o.Start())
+	defer o.Stop()
+
+	callOneway(t, o, "hello")
+
+	assert.Equal(t, call{
+		procedure: "procedure",
+		headers:   map[string]string{"foo": "bar"},
+		body:      "hello",
+	}, waitForCall(t, out))
+	waitForEmptyStore(t, store)
+}
+
+func TestOutboundRetriesWithBackoff(t *testing.T) {
+	out := newFakeOutbound(1)
+	store := NewMemoryStore()
+	clk := clock.NewFake()
+	o := NewOutbound(out, store, Backoff(fixedBackoff(time.Minute)), withClock(clk))
+	require.NoError(t, o.Start())
+	defer o.Stop()
+
+	callOneway(t, o, "hello")
+
+	// Wait for the failed attempt to be recorded.
+	deadline := time.Now().Add(testtime.Second)
+	for {
+		records, err := store.List()
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		if records[0].Attempts == 1 {
+			break
+		}
+		require.True(t, time.Now().Before(deadline), "timed out waiting for failed attempt")
+		time.Sleep(time.Millisecond)
+	}
+
+	clk.Add(time.Minute)
+	assert.Equal(t, "hello", waitForCall(t, out).body)
+	waitForEmptyStore(t, store)
+}
+
+func TestOutboundDeliversPreviouslySpooledRequests(t *testing.T) {
+	store := NewMemoryStore()
+	require.NoError(t, store.Put(Record{
+		ID:        "leftover",
+		Procedure: "procedure",
+		Body:      []byte("from a previous run"),
+	}))
+
+	out := newFakeOutbound(0)
+	o := NewOutbound(out, store)
+	require.NoError(t, o.Start())
+	defer o.Stop()
+
+	assert.Equal(t, "from a previous run", waitForCall(t, out).body)
+	waitForEmptyStore(t, store)
+}
+
+func TestOutboundHonorsDeliverAt(t *testing.T) {
+	clk := clock.NewFake()
+	store := NewMemoryStore()
+	require.NoError(t, store.Put(Record{
+		ID:        "later",
+		Procedure: "procedure",
+		Body:      []byte("not yet"),
+		DeliverAt: clk.Now().Add(time.Minute),
+	}))
+
+	out := newFakeOutbound(0)
+	o := NewOutbound(out, store, withClock(clk))
+	require.NoError(t, o.Start())
+	defer o.Stop()
+
+	select {
+	case <-out.calls:
+		t.Fatal("request must not be delivered before DeliverAt")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	clk.Add(time.Minute)
+	assert.Equal(t, "not yet", waitForCall(t, out).body)
+	waitForEmptyStore(t, store)
+}
+
+func TestOutboundMaxAttempts(t *testing.T) {
+	out := newFakeOutbound(1)
+	store := NewMemoryStore()
+	o := NewOutbound(out, store, MaxAttempts(1))
+	require.NoError(t, o.Start())
+	defer o.Stop()
+
+	callOneway(t, o, "hello")
+	waitForEmptyStore(t, store)
+	select {
+	case <-out.calls:
+		t.Fatal("dropped request must not be delivered")
+	default:
+	}
+}
+
+func TestOutboundStopKeepsPendingRequests(t *testing.T) {
+	out := newFakeOutbound(100)
+	store := NewMemoryStore()
+	o := NewOutbound(out, store, Backoff(fixedBackoff(time.Hour)))
+	require.NoError(t, o.Start())
+
+	callOneway(t, o, "hello")
+	require.NoError(t, o.Stop())
+	assert.False(t, o.IsRunning())
+
+	records, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "hello", string(records[0].Body))
+}
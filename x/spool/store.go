@@ -0,0 +1,115 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package spool
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Record is a oneway request persisted in a Store.
+type Record struct {
+	// ID uniquely identifies the record within the store.
+	ID string `json:"id"`
+
+	Caller          string            `json:"caller"`
+	Service         string            `json:"service"`
+	Procedure       string            `json:"procedure"`
+	Encoding        string            `json:"encoding"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	ShardKey        string            `json:"shardKey,omitempty"`
+	RoutingKey      string            `json:"routingKey,omitempty"`
+	RoutingDelegate string            `json:"routingDelegate,omitempty"`
+	Body            []byte            `json:"body"`
+
+	// CreatedAt is the time at which the request was spooled.
+	CreatedAt time.Time `json:"createdAt"`
+
+	// DeliverAt is the earliest time at which the request may be delivered.
+	// The zero value means the request may be delivered right away.
+	DeliverAt time.Time `json:"deliverAt,omitempty"`
+
+	// Attempts is the number of failed attempts to deliver the request.
+	Attempts uint `json:"attempts"`
+}
+
+// Store persists spooled requests.
+//
+// Implementations MUST be safe for concurrent use.
+type Store interface {
+	// Put adds the given record to the store, replacing any record with the
+	// same ID.
+	Put(Record) error
+
+	// Delete removes the record with the given ID from the store. Deleting a
+	// record that does not exist is not an error.
+	Delete(id string) error
+
+	// List returns all records in the store, oldest first.
+	List() ([]Record, error)
+}
+
+// NewMemoryStore builds a Store that keeps records in memory. Records held in
+// a memory store do not survive process restarts; it is meant for tests and
+// for services that only need to ride out transient outages.
+func NewMemoryStore() Store {
+	return &memoryStore{records: make(map[string]Record)}
+}
+
+type memoryStore struct {
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+func (s *memoryStore) Put(r Record) error {
+	s.mu.Lock()
+	s.records[r.ID] = r
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *memoryStore) Delete(id string) error {
+	s.mu.Lock()
+	delete(s.records, id)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *memoryStore) List() ([]Record, error) {
+	s.mu.Lock()
+	records := make([]Record, 0, len(s.records))
+	for _, r := range s.records {
+		records = append(records, r)
+	}
+	s.mu.Unlock()
+	sortRecords(records)
+	return records, nil
+}
+
+func sortRecords(records []Record) {
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].CreatedAt.Equal(records[j].CreatedAt) {
+			return records[i].ID < records[j].ID
+		}
+		return records[i].CreatedAt.Before(records[j].CreatedAt)
+	})
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package standard builds Dispatchers with a standard set of middleware, so
+// that new services start from sane defaults instead of empty middleware
+// chains.
+//
+// 	dispatcher := standard.NewDispatcher(yarpc.Config{
+// 		Name:     "myservice",
+// 		Inbounds: yarpc.Inbounds{inbound},
+// 		Logging:  yarpc.LoggingConfig{Zap: logger},
+// 		Metrics:  yarpc.MetricsConfig{Metrics: scope},
+// 	}, standard.Options{})
+//
+// Inbound requests pass through, outermost first,
+//
+//  - the observability middleware of the Dispatcher, which logs requests
+//    and records their metrics;
+//  - the end-to-end deadline budget of the Dispatcher;
+//  - panic recovery, which turns panics in handlers into Internal errors;
+//  - error reporting, if Options specify a Reporter (see the errorreport
+//    package);
+//  - handler timeouts, which fail requests whose handlers outlive their
+//    deadline (see the handlertimeout package);
+//  - request body limits (see the bodylimit package);
+//
+// followed by the inbound middleware of the given yarpc.Config. Unary
+// outbound calls to idempotent procedures are retried within a retry budget
+// (see the retry package) before they reach the outbound middleware of the
+// given yarpc.Config.
+//
+// Each of these may be tuned or turned off with Options.
+package standard
@@ -0,0 +1,94 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+var (
+	_ middleware.UnaryInbound  = (*recovery)(nil)
+	_ middleware.OnewayInbound = (*recovery)(nil)
+	_ middleware.StreamInbound = (*recovery)(nil)
+	_ middleware.Named         = (*recovery)(nil)
+)
+
+// recovery is inbound middleware that turns panics in handlers into
+// Internal errors, logging them and counting them by procedure.
+type recovery struct {
+	logger *zap.Logger
+	panics *metrics.CounterVector
+}
+
+func newRecovery(logger *zap.Logger, meter *metrics.Scope) *recovery {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	r := &recovery{logger: logger}
+	r.panics, _ = meter.CounterVector(metrics.Spec{
+		Name:    "handler_panics",
+		Help:    "Number of requests whose handler panicked.",
+		VarTags: []string{"procedure"},
+	})
+	return r
+}
+
+func (r *recovery) MiddlewareName() string { return "recovery" }
+
+func (r *recovery) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) (err error) {
+	defer r.recover(req.Procedure, &err)
+	return h.Handle(ctx, req, resw)
+}
+
+func (r *recovery) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) (err error) {
+	defer r.recover(req.Procedure, &err)
+	return h.HandleOneway(ctx, req)
+}
+
+func (r *recovery) HandleStream(s *transport.ServerStream, h transport.StreamHandler) (err error) {
+	defer r.recover(s.Request().Meta.Procedure, &err)
+	return h.HandleStream(s)
+}
+
+// recover must be deferred directly by the handling methods for the builtin
+// recover to stop the panic.
+func (r *recovery) recover(procedure string, err *error) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	r.logger.Error("Handler panicked.",
+		zap.String("procedure", procedure),
+		zap.String("panic", fmt.Sprint(p)),
+		zap.Stack("stack"),
+	)
+	if c, e := r.panics.Get("procedure", procedure); e == nil {
+		c.Inc()
+	}
+	*err = yarpcerrors.InternalErrorf("handler for procedure %q panicked", procedure)
+}
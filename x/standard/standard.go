@@ -0,0 +1,160 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package standard
+
+import (
+	"time"
+
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/internal/inboundmiddleware"
+	"go.uber.org/yarpc/internal/outboundmiddleware"
+	"go.uber.org/yarpc/x/bodylimit"
+	"go.uber.org/yarpc/x/errorreport"
+	"go.uber.org/yarpc/x/handlertimeout"
+	"go.uber.org/yarpc/x/retry"
+)
+
+// Defaults used for the zero values of Options.
+const (
+	DefaultMaxRequestBodyBytes   int64   = 4 * 1024 * 1024
+	DefaultRetryAttempts                 = 3
+	DefaultRetryBudgetRatio      float64 = 0.2
+	DefaultRetryBudgetMinRetries         = 10
+)
+
+// Options tunes the standard middleware. The zero value uses the defaults
+// for everything.
+type Options struct {
+	// MaxRequestBodyBytes limits the size of the bodies of unary and oneway
+	// requests. Defaults to DefaultMaxRequestBodyBytes. Negative values
+	// leave request bodies unlimited.
+	MaxRequestBodyBytes int64
+
+	// HandlerTimeoutGrace is how long unary handlers may keep running past
+	// the deadline of their request before the request fails. Zero uses the
+	// default of the handlertimeout package. Negative values turn handler
+	// timeouts off.
+	HandlerTimeoutGrace time.Duration
+
+	// RetryAttempts is the number of times calls to idempotent procedures
+	// are attempted in total. Defaults to DefaultRetryAttempts. Set to 1 to
+	// turn retries off.
+	RetryAttempts int
+
+	// RetryBudgetRatio and RetryBudgetMinRetries bound retries to a
+	// fraction of calls plus a minimum; see retry.Budget. Default to
+	// DefaultRetryBudgetRatio and DefaultRetryBudgetMinRetries.
+	RetryBudgetRatio      float64
+	RetryBudgetMinRetries int
+
+	// DisableRecovery leaves panics in handlers to the transports, which
+	// recover them without logging them to the Dispatcher's logger.
+	DisableRecovery bool
+
+	// Reporter, if set, receives reports of panics in handlers and of
+	// server errors they return, like Internal errors; see the errorreport
+	// package. By default, nothing is reported.
+	Reporter errorreport.Reporter
+
+	// ReporterOptions tune which errors are reported to Reporter and how
+	// often.
+	ReporterOptions []errorreport.Option
+}
+
+// NewDispatcher builds a Dispatcher from the given configuration with the
+// standard middleware added to it. See Config.
+func NewDispatcher(cfg yarpc.Config, opts Options) *yarpc.Dispatcher {
+	return yarpc.NewDispatcher(Config(cfg, opts))
+}
+
+// Config returns a copy of the given configuration with the standard
+// middleware placed outside of its middleware. The observability middleware
+// and deadline budget of the Dispatcher are turned on even if the given
+// configuration disables them.
+func Config(cfg yarpc.Config, opts Options) yarpc.Config {
+	cfg.DisableAutoObservabilityMiddleware = false
+	cfg.DisableDeadlineBudget = false
+
+	var (
+		unary  []middleware.UnaryInbound
+		oneway []middleware.OnewayInbound
+		stream []middleware.StreamInbound
+	)
+	if !opts.DisableRecovery {
+		r := newRecovery(cfg.Logging.Zap, cfg.Metrics.Metrics)
+		unary = append(unary, r)
+		oneway = append(oneway, r)
+		stream = append(stream, r)
+	}
+	if opts.Reporter != nil {
+		reportOpts := append([]errorreport.Option{errorreport.Metrics(cfg.Metrics.Metrics)}, opts.ReporterOptions...)
+		reports := errorreport.New(opts.Reporter, reportOpts...)
+		unary = append(unary, reports)
+		oneway = append(oneway, reports)
+		stream = append(stream, reports)
+	}
+	if opts.HandlerTimeoutGrace >= 0 {
+		var htOpts []handlertimeout.Option
+		if opts.HandlerTimeoutGrace > 0 {
+			htOpts = append(htOpts, handlertimeout.Grace(opts.HandlerTimeoutGrace))
+		}
+		htOpts = append(htOpts, handlertimeout.Metrics(cfg.Metrics.Metrics))
+		unary = append(unary, handlertimeout.New(htOpts...))
+	}
+	if maxBody := withDefault64(opts.MaxRequestBodyBytes, DefaultMaxRequestBodyBytes); maxBody > 0 {
+		limit := bodylimit.New(bodylimit.Default(maxBody))
+		unary = append(unary, limit)
+		oneway = append(oneway, limit)
+	}
+	cfg.InboundMiddleware = yarpc.InboundMiddleware{
+		Unary:  inboundmiddleware.UnaryChain(append(unary, cfg.InboundMiddleware.Unary)...),
+		Oneway: inboundmiddleware.OnewayChain(append(oneway, cfg.InboundMiddleware.Oneway)...),
+		Stream: inboundmiddleware.StreamChain(append(stream, cfg.InboundMiddleware.Stream)...),
+	}
+
+	if attempts := withDefault(opts.RetryAttempts, DefaultRetryAttempts); attempts > 1 {
+		ratio := opts.RetryBudgetRatio
+		if ratio <= 0 {
+			ratio = DefaultRetryBudgetRatio
+		}
+		retrier := retry.New(
+			retry.MaxAttempts(attempts),
+			retry.Budget(ratio, withDefault(opts.RetryBudgetMinRetries, DefaultRetryBudgetMinRetries)),
+		)
+		cfg.OutboundMiddleware.Unary = outboundmiddleware.UnaryChain(retrier, cfg.OutboundMiddleware.Unary)
+	}
+	return cfg
+}
+
+func withDefault(v, def int) int {
+	if v == 0 {
+		return def
+	}
+	return v
+}
+
+func withDefault64(v, def int64) int64 {
+	if v == 0 {
+		return def
+	}
+	return v
+}
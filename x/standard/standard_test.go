@@ -0,0 +1,163 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/inboundmiddleware"
+	"go.uber.org/yarpc/internal/outboundmiddleware"
+	"go.uber.org/yarpc/x/errorreport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type unaryHandlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f unaryHandlerFunc) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	return f(ctx, req, resw)
+}
+
+type onewayHandlerFunc func(context.Context, *transport.Request) error
+
+func (f onewayHandlerFunc) HandleOneway(ctx context.Context, req *transport.Request) error {
+	return f(ctx, req)
+}
+
+func names(mws interface{}) []string {
+	var out []string
+	switch mws := mws.(type) {
+	case []middleware.UnaryInbound:
+		for _, mw := range mws {
+			out = append(out, middleware.Name(mw))
+		}
+	case []middleware.UnaryOutbound:
+		for _, mw := range mws {
+			out = append(out, middleware.Name(mw))
+		}
+	}
+	return out
+}
+
+func TestConfigMiddleware(t *testing.T) {
+	user := yarpc.InboundMiddleware{Unary: middleware.NopUnaryInbound}
+
+	t.Run("defaults", func(t *testing.T) {
+		cfg := Config(yarpc.Config{
+			Name:                               "myservice",
+			InboundMiddleware:                  user,
+			DisableAutoObservabilityMiddleware: true,
+			DisableDeadlineBudget:              true,
+		}, Options{})
+
+		assert.False(t, cfg.DisableAutoObservabilityMiddleware)
+		assert.False(t, cfg.DisableDeadlineBudget)
+		inbound := names(inboundmiddleware.UnchainUnary(cfg.InboundMiddleware.Unary))
+		require.Len(t, inbound, 4)
+		assert.Equal(t, []string{"recovery", "handlertimeout"}, inbound[:2])
+		assert.Equal(t, []string{"retry"}, names(outboundmiddleware.UnchainUnary(cfg.OutboundMiddleware.Unary)))
+	})
+
+	t.Run("reporter", func(t *testing.T) {
+		var reports []*errorreport.Report
+		reporter := errorreport.ReporterFunc(func(_ context.Context, r *errorreport.Report) {
+			reports = append(reports, r)
+		})
+		cfg := Config(yarpc.Config{Name: "myservice"}, Options{Reporter: reporter})
+
+		inbound := names(inboundmiddleware.UnchainUnary(cfg.InboundMiddleware.Unary))
+		assert.Equal(t, []string{"recovery", "errorreport", "handlertimeout"}, inbound[:3])
+
+		err := cfg.InboundMiddleware.Unary.Handle(context.Background(),
+			&transport.Request{Procedure: "KeyValue::getValue"},
+			new(transporttest.FakeResponseWriter),
+			unaryHandlerFunc(func(context.Context, *transport.Request, transport.ResponseWriter) error {
+				panic("great sadness")
+			}))
+		assert.Equal(t, yarpcerrors.CodeInternal, yarpcerrors.FromError(err).Code(), "panics must still be recovered")
+		require.Len(t, reports, 1)
+		assert.Equal(t, "great sadness", reports[0].Panic)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		cfg := Config(yarpc.Config{Name: "myservice", InboundMiddleware: user}, Options{
+			MaxRequestBodyBytes: -1,
+			HandlerTimeoutGrace: -1,
+			RetryAttempts:       1,
+			DisableRecovery:     true,
+		})
+
+		assert.Equal(t, middleware.NopUnaryInbound, cfg.InboundMiddleware.Unary)
+		assert.Nil(t, cfg.OutboundMiddleware.Unary)
+	})
+}
+
+func TestRecovery(t *testing.T) {
+	root := metrics.New()
+	r := newRecovery(nil, root.Scope())
+	req := &transport.Request{Procedure: "KeyValue::getValue"}
+
+	err := r.Handle(context.Background(), req, new(transporttest.FakeResponseWriter), unaryHandlerFunc(
+		func(context.Context, *transport.Request, transport.ResponseWriter) error {
+			panic("great sadness")
+		}))
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeInternal, yarpcerrors.FromError(err).Code())
+	assert.Contains(t, err.Error(), `handler for procedure "KeyValue::getValue" panicked`)
+
+	err = r.HandleOneway(context.Background(), req, onewayHandlerFunc(
+		func(context.Context, *transport.Request) error {
+			return nil
+		}))
+	assert.NoError(t, err)
+
+	counters := root.Snapshot().Counters
+	require.Len(t, counters, 1)
+	assert.Equal(t, "handler_panics", counters[0].Name)
+	assert.Equal(t, int64(1), counters[0].Value)
+}
+
+func TestBodyLimit(t *testing.T) {
+	cfg := Config(yarpc.Config{Name: "myservice"}, Options{MaxRequestBodyBytes: 4})
+
+	handle := func(body string) error {
+		return cfg.InboundMiddleware.Unary.Handle(
+			context.Background(),
+			&transport.Request{Procedure: "proc", Body: bytes.NewReader([]byte(body))},
+			new(transporttest.FakeResponseWriter),
+			unaryHandlerFunc(func(_ context.Context, req *transport.Request, _ transport.ResponseWriter) error {
+				_, err := ioutil.ReadAll(req.Body)
+				return err
+			}),
+		)
+	}
+	assert.NoError(t, handle("abcd"))
+	assert.Error(t, handle("abcdefgh"))
+}
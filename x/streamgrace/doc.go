@@ -0,0 +1,53 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package streamgrace lets stream handlers finish gracefully before the
+// deadline of their stream expires.
+//
+// When the deadline of a stream expires, the transport resets the stream and
+// the client sees a DeadlineExceeded error, losing any partial results the
+// handler was about to send. The Middleware of this package signals handlers
+// a grace period before the deadline, giving them time to send a final
+// message and return, so that the stream ends successfully.
+//
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Stream: streamgrace.New(streamgrace.Grace(2 * time.Second)),
+// 		},
+// 	})
+//
+// Handlers select on Draining to learn when to wrap up.
+//
+// 	func (s *server) Watch(req *WatchRequest, stream WatchServiceWatchYARPCServer) error {
+// 		for {
+// 			select {
+// 			case event := <-s.events:
+// 				if err := stream.Send(event); err != nil {
+// 					return err
+// 				}
+// 			case <-streamgrace.Draining(stream.Context()):
+// 				return stream.Send(&WatchResponse{Resume: s.cursor()})
+// 			}
+// 		}
+// 	}
+//
+// Streams without a deadline are never drained.
+package streamgrace
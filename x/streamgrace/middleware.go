@@ -0,0 +1,120 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package streamgrace
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+)
+
+const _defaultGrace = time.Second
+
+var _ middleware.StreamInbound = (*Middleware)(nil)
+
+type drainKey struct{}
+
+// Draining returns a channel that is closed when the handler of the stream
+// with the given context should send its final messages and return.
+//
+// The channel is nil, and thus never ready, if the stream has no deadline or
+// did not go through a Middleware.
+func Draining(ctx context.Context) <-chan struct{} {
+	drain, _ := ctx.Value(drainKey{}).(<-chan struct{})
+	return drain
+}
+
+// Option customizes the behavior of a Middleware.
+type Option interface {
+	apply(*Middleware)
+}
+
+type optionFunc func(*Middleware)
+
+func (f optionFunc) apply(m *Middleware) { f(m) }
+
+// Grace specifies how long before the deadline of a stream its handler is
+// signaled to drain. Defaults to one second.
+func Grace(grace time.Duration) Option {
+	return optionFunc(func(m *Middleware) {
+		m.grace = grace
+	})
+}
+
+func withClock(c clock.Clock) Option {
+	return optionFunc(func(m *Middleware) {
+		m.clock = c
+	})
+}
+
+// Middleware is inbound stream middleware that signals handlers to drain
+// their streams shortly before the stream deadlines expire.
+type Middleware struct {
+	grace time.Duration
+	clock clock.Clock
+}
+
+// New builds a new Middleware.
+func New(opts ...Option) *Middleware {
+	m := &Middleware{
+		grace: _defaultGrace,
+		clock: clock.NewReal(),
+	}
+	for _, opt := range opts {
+		opt.apply(m)
+	}
+	return m
+}
+
+// HandleStream implements middleware.StreamInbound.
+func (m *Middleware) HandleStream(s *transport.ServerStream, h transport.StreamHandler) error {
+	ctx := s.Context()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return h.HandleStream(s)
+	}
+
+	drain := make(chan struct{})
+	if wait := deadline.Sub(m.clock.Now()) - m.grace; wait > 0 {
+		timer := m.clock.AfterFunc(wait, func() { close(drain) })
+		defer timer.Stop()
+	} else {
+		close(drain)
+	}
+
+	ctx = context.WithValue(ctx, drainKey{}, (<-chan struct{})(drain))
+	s, _ = transport.NewServerStream(contextStream{ServerStream: s, ctx: ctx})
+	return h.HandleStream(s)
+}
+
+// contextStream replaces the context of a stream.
+type contextStream struct {
+	*transport.ServerStream
+
+	ctx context.Context
+}
+
+func (s contextStream) Context() context.Context {
+	return s.ctx
+}
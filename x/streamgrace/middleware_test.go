@@ -0,0 +1,133 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package streamgrace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+)
+
+type streamHandlerFunc func(*transport.ServerStream) error
+
+func (f streamHandlerFunc) HandleStream(s *transport.ServerStream) error { return f(s) }
+
+// deadlineContext is a context with a deadline that never expires on its
+// own, to pair with a fake clock.
+type deadlineContext struct {
+	context.Context
+
+	deadline time.Time
+}
+
+func (c deadlineContext) Deadline() (time.Time, bool) { return c.deadline, true }
+
+type fakeStream struct {
+	ctx context.Context
+}
+
+func (s *fakeStream) Context() context.Context { return s.ctx }
+
+func (s *fakeStream) Request() *transport.StreamRequest {
+	return &transport.StreamRequest{Meta: &transport.RequestMeta{Procedure: "watch"}}
+}
+
+func (s *fakeStream) SendMessage(context.Context, *transport.StreamMessage) error { return nil }
+
+func (s *fakeStream) ReceiveMessage(context.Context) (*transport.StreamMessage, error) {
+	return nil, nil
+}
+
+func newServerStream(t *testing.T, ctx context.Context) *transport.ServerStream {
+	s, err := transport.NewServerStream(&fakeStream{ctx: ctx})
+	require.NoError(t, err)
+	return s
+}
+
+func TestDrainingWithoutMiddleware(t *testing.T) {
+	assert.Nil(t, Draining(context.Background()))
+}
+
+func TestNoDeadline(t *testing.T) {
+	m := New()
+	err := m.HandleStream(newServerStream(t, context.Background()), streamHandlerFunc(func(s *transport.ServerStream) error {
+		assert.Nil(t, Draining(s.Context()), "streams without deadline must not drain")
+		return nil
+	}))
+	assert.NoError(t, err)
+}
+
+func TestDrainBeforeDeadline(t *testing.T) {
+	fakeClock := clock.NewFake()
+	m := New(Grace(2*time.Second), withClock(fakeClock))
+	ctx := deadlineContext{Context: context.Background(), deadline: fakeClock.Now().Add(10 * time.Second)}
+
+	started := make(chan struct{})
+	drained := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- m.HandleStream(newServerStream(t, ctx), streamHandlerFunc(func(s *transport.ServerStream) error {
+			assert.Equal(t, "watch", s.Request().Meta.Procedure)
+			drain := Draining(s.Context())
+			close(started)
+			<-drain
+			close(drained)
+			return nil
+		}))
+	}()
+
+	<-started
+	fakeClock.Add(7 * time.Second)
+	select {
+	case <-drained:
+		t.Fatal("stream drained before the grace period")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	fakeClock.Add(time.Second)
+	select {
+	case <-drained:
+	case <-time.After(time.Second):
+		t.Fatal("stream did not drain at the start of the grace period")
+	}
+	assert.NoError(t, <-done)
+}
+
+func TestDeadlineWithinGrace(t *testing.T) {
+	fakeClock := clock.NewFake()
+	m := New(Grace(2*time.Second), withClock(fakeClock))
+	ctx := deadlineContext{Context: context.Background(), deadline: fakeClock.Now().Add(time.Second)}
+
+	err := m.HandleStream(newServerStream(t, ctx), streamHandlerFunc(func(s *transport.ServerStream) error {
+		select {
+		case <-Draining(s.Context()):
+			return nil
+		default:
+			return assert.AnError
+		}
+	}))
+	assert.NoError(t, err, "stream must drain right away")
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package tap captures the payloads of sampled requests and responses for
+// debugging in production.
+//
+// A Tap is inbound and outbound middleware that does nothing until an
+// operator enables a capture. A capture records the full requests and
+// responses of the next N calls that match a filter on procedure, caller, and
+// error code into a ring buffer, after which the Tap goes idle again.
+//
+// 	tap := tap.New()
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary: tap,
+// 		},
+// 		OutboundMiddleware: yarpc.OutboundMiddleware{
+// 			Unary: tap,
+// 		},
+// 	})
+// 	http.Handle("/debug/yarpc/tap", tap.Handler())
+//
+// The handler is the admin API of the Tap. POST a capture request to enable a
+// capture,
+//
+// 	{"filter": {"procedure": "KeyValue::getValue", "code": "internal"}, "count": 10}
+//
+// GET to retrieve the captured calls, and DELETE to disable the capture and
+// discard the captured calls.
+//
+// Captured payloads may contain sensitive data. Only expose the handler to
+// trusted operators.
+package tap
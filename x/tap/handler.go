@@ -0,0 +1,79 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tap
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// captureRequest is the body of a POST to the admin API.
+type captureRequest struct {
+	Filter Filter `json:"filter"`
+	Count  int    `json:"count"`
+}
+
+// captureResponse is the body of a GET to the admin API.
+type captureResponse struct {
+	Status
+	Captures []Capture `json:"captures"`
+}
+
+// Handler returns an HTTP handler that serves the admin API of the Tap.
+//
+// GET returns the status of the running capture and the captured calls as
+// JSON, POST enables a capture, and DELETE disables the capture and discards
+// the captured calls.
+func (t *Tap) Handler() http.Handler {
+	return http.HandlerFunc(t.serveHTTP)
+}
+
+func (t *Tap) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		writeJSON(w, captureResponse{Status: t.Status(), Captures: t.Captures()})
+	case http.MethodPost:
+		var cr captureRequest
+		if err := json.NewDecoder(req.Body).Decode(&cr); err != nil {
+			http.Error(w, fmt.Sprintf("invalid capture request: %v", err), http.StatusBadRequest)
+			return
+		}
+		if err := t.Enable(cr.Filter, cr.Count); err != nil {
+			http.Error(w, fmt.Sprintf("invalid capture request: %v", err), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, t.Status())
+	case http.MethodDelete:
+		t.Disable()
+		writeJSON(w, t.Status())
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, fmt.Sprintf("method %s not allowed", req.Method), http.StatusMethodNotAllowed)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	// The client hanging up is the only way this can fail, so there is
+	// nobody left to report the error to.
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tap
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/responsewriter"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var (
+	_ middleware.UnaryInbound  = (*Tap)(nil)
+	_ middleware.UnaryOutbound = (*Tap)(nil)
+)
+
+// Handle implements middleware.UnaryInbound.
+func (t *Tap) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	if !t.matches(req.ToRequestMeta()) {
+		return h.Handle(ctx, req, resw)
+	}
+
+	body, err := readBody(req.Body)
+	if err != nil {
+		return err
+	}
+	r := *req
+	r.Body = bytes.NewReader(body)
+
+	w := &responseWriter{Wrapper: responsewriter.Wrapper{ResponseWriter: resw}, maxBodySize: t.opts.maxBodySize}
+	start := time.Now()
+	err = h.Handle(ctx, &r, w)
+
+	c := t.newCapture(Inbound, start, req, body, err)
+	c.ResponseHeaders = w.headers
+	c.ResponseBody = w.body.Bytes()
+	c.ApplicationError = w.applicationError
+	t.record(c)
+	return err
+}
+
+// Call implements middleware.UnaryOutbound.
+func (t *Tap) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	if !t.matches(req.ToRequestMeta()) {
+		return out.Call(ctx, req)
+	}
+
+	body, err := readBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	r := *req
+	r.Body = bytes.NewReader(body)
+
+	start := time.Now()
+	res, err := out.Call(ctx, &r)
+	var resBody []byte
+	if err == nil && res != nil && res.Body != nil {
+		resBody, err = ioutil.ReadAll(res.Body)
+		if cerr := res.Body.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			res = nil
+		} else {
+			res.Body = ioutil.NopCloser(bytes.NewReader(resBody))
+		}
+	}
+
+	c := t.newCapture(Outbound, start, req, body, err)
+	if res != nil {
+		c.ResponseHeaders = copyHeaders(res.Headers)
+		c.ResponseBody = t.truncate(resBody)
+		c.ApplicationError = res.ApplicationError
+	}
+	t.record(c)
+	return res, err
+}
+
+func (t *Tap) newCapture(dir Direction, start time.Time, req *transport.Request, body []byte, err error) Capture {
+	c := Capture{
+		Time:           start,
+		Duration:       time.Since(start),
+		Direction:      dir,
+		Caller:         req.Caller,
+		Service:        req.Service,
+		Procedure:      req.Procedure,
+		Encoding:       string(req.Encoding),
+		RequestHeaders: copyHeaders(req.Headers),
+		RequestBody:    t.truncate(body),
+	}
+	if err != nil {
+		c.Code = yarpcerrors.FromError(err).Code()
+		c.Error = err.Error()
+	}
+	return c
+}
+
+// truncate returns a copy of at most the maximum body size bytes of b.
+func (t *Tap) truncate(b []byte) []byte {
+	if len(b) > t.opts.maxBodySize {
+		b = b[:t.opts.maxBodySize]
+	}
+	return append([]byte(nil), b...)
+}
+
+func readBody(r io.Reader) ([]byte, error) {
+	if r == nil {
+		return nil, nil
+	}
+	return ioutil.ReadAll(r)
+}
+
+func copyHeaders(headers transport.Headers) map[string]string {
+	if headers.Len() == 0 {
+		return nil
+	}
+	items := make(map[string]string, headers.Len())
+	for k, v := range headers.Items() {
+		items[k] = v
+	}
+	return items
+}
+
+// responseWriter records the response written by a handler, keeping at most
+// maxBodySize bytes of the body.
+type responseWriter struct {
+	responsewriter.Wrapper
+
+	maxBodySize      int
+	headers          map[string]string
+	body             bytes.Buffer
+	applicationError bool
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if remaining := w.maxBodySize - w.body.Len(); remaining > 0 {
+		if len(p) < remaining {
+			remaining = len(p)
+		}
+		w.body.Write(p[:remaining])
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *responseWriter) AddHeaders(headers transport.Headers) {
+	if headers.Len() > 0 && w.headers == nil {
+		w.headers = make(map[string]string, headers.Len())
+	}
+	for k, v := range headers.Items() {
+		w.headers[k] = v
+	}
+	w.ResponseWriter.AddHeaders(headers)
+}
+
+func (w *responseWriter) SetApplicationError() {
+	w.applicationError = true
+	w.ResponseWriter.SetApplicationError()
+}
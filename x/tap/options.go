@@ -0,0 +1,56 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tap
+
+const (
+	_defaultCapacity    = 100
+	_defaultMaxBodySize = 64 * 1024
+)
+
+// Option customizes the behavior of a Tap.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	capacity    int
+	maxBodySize int
+}
+
+// Capacity specifies the number of captured calls the Tap keeps. Once full,
+// new captures replace the oldest. Defaults to 100.
+func Capacity(capacity int) Option {
+	return optionFunc(func(opts *options) {
+		opts.capacity = capacity
+	})
+}
+
+// MaxBodySize specifies the number of bytes of each request and response
+// body the Tap keeps. Longer bodies are truncated. Defaults to 64 KiB.
+func MaxBodySize(size int) Option {
+	return optionFunc(func(opts *options) {
+		opts.maxBodySize = size
+	})
+}
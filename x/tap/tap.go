@@ -0,0 +1,200 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tap
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// Direction is the direction of a captured call relative to the process.
+type Direction string
+
+const (
+	// Inbound calls are handled by the process.
+	Inbound Direction = "inbound"
+	// Outbound calls are made by the process.
+	Outbound Direction = "outbound"
+)
+
+// Filter selects the calls a capture records. Empty fields match all calls.
+type Filter struct {
+	Procedure string `json:"procedure,omitempty"`
+	Caller    string `json:"caller,omitempty"`
+
+	// Code matches calls that failed with the given code, or that succeeded
+	// if it is CodeOK.
+	Code *yarpcerrors.Code `json:"code,omitempty"`
+}
+
+// Capture is a call recorded by a Tap. Bodies longer than the maximum body
+// size of the Tap are truncated.
+type Capture struct {
+	Time      time.Time     `json:"time"`
+	Duration  time.Duration `json:"duration"`
+	Direction Direction     `json:"direction"`
+
+	Caller    string `json:"caller"`
+	Service   string `json:"service"`
+	Procedure string `json:"procedure"`
+	Encoding  string `json:"encoding"`
+
+	RequestHeaders map[string]string `json:"requestHeaders,omitempty"`
+	RequestBody    []byte            `json:"requestBody"`
+
+	ResponseHeaders  map[string]string `json:"responseHeaders,omitempty"`
+	ResponseBody     []byte            `json:"responseBody"`
+	ApplicationError bool              `json:"applicationError"`
+
+	Code  yarpcerrors.Code `json:"code"`
+	Error string           `json:"error,omitempty"`
+}
+
+// Status describes the capture a Tap is running.
+type Status struct {
+	// Enabled reports whether the Tap is capturing calls.
+	Enabled bool `json:"enabled"`
+	// Filter selects the calls the capture records.
+	Filter Filter `json:"filter"`
+	// Remaining is the number of calls the capture will still record.
+	Remaining int `json:"remaining"`
+}
+
+// Tap is middleware that captures the payloads of calls on demand.
+type Tap struct {
+	opts options
+
+	// enabled is checked before taking the lock so that the Tap costs
+	// little while no capture is running.
+	enabled atomic.Bool
+
+	lock      sync.Mutex
+	filter    Filter
+	remaining int
+	captures  []Capture
+	next      int
+}
+
+// New builds a new Tap. It captures nothing until a capture is enabled.
+func New(opts ...Option) *Tap {
+	options := options{
+		capacity:    _defaultCapacity,
+		maxBodySize: _defaultMaxBodySize,
+	}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return &Tap{opts: options}
+}
+
+// Enable starts capturing the next count calls that match the filter,
+// replacing the capture that is running, if any. Previously captured calls
+// are kept.
+func (t *Tap) Enable(filter Filter, count int) error {
+	if count <= 0 {
+		return errors.New("count must be greater than 0")
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.filter = filter
+	t.remaining = count
+	t.enabled.Store(true)
+	return nil
+}
+
+// Disable stops the running capture, if any, and discards captured calls.
+func (t *Tap) Disable() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.enabled.Store(false)
+	t.filter = Filter{}
+	t.remaining = 0
+	t.captures = t.captures[:0]
+	t.next = 0
+}
+
+// Status returns the status of the running capture.
+func (t *Tap) Status() Status {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return Status{
+		Enabled:   t.enabled.Load(),
+		Filter:    t.filter,
+		Remaining: t.remaining,
+	}
+}
+
+// Captures returns the captured calls, oldest first.
+func (t *Tap) Captures() []Capture {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	captures := make([]Capture, 0, len(t.captures))
+	captures = append(captures, t.captures[t.next:]...)
+	return append(captures, t.captures[:t.next]...)
+}
+
+// matches reports whether a capture is running whose filter may match the
+// request. The code of the filter is checked once the call has finished.
+func (t *Tap) matches(req *transport.RequestMeta) bool {
+	if !t.enabled.Load() {
+		return false
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.remaining > 0 &&
+		(t.filter.Procedure == "" || t.filter.Procedure == req.Procedure) &&
+		(t.filter.Caller == "" || t.filter.Caller == req.Caller)
+}
+
+// record adds the capture if the running capture still wants it.
+func (t *Tap) record(c Capture) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.remaining <= 0 || (t.filter.Code != nil && *t.filter.Code != c.Code) {
+		return
+	}
+	t.remaining--
+	if t.remaining == 0 {
+		t.enabled.Store(false)
+	}
+
+	if t.opts.capacity <= 0 {
+		return
+	}
+	if len(t.captures) < t.opts.capacity {
+		t.captures = append(t.captures, c)
+		return
+	}
+	t.captures[t.next] = c
+	t.next = (t.next + 1) % t.opts.capacity
+}
@@ -0,0 +1,263 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tap
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type unaryHandlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f unaryHandlerFunc) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	return f(ctx, req, resw)
+}
+
+// echo is a handler that responds with the request body.
+var echo = unaryHandlerFunc(func(_ context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	resw.AddHeaders(transport.NewHeaders().With("echoed", "true"))
+	_, err := io.Copy(resw, req.Body)
+	return err
+})
+
+func newRequest(caller, procedure, body string) *transport.Request {
+	return &transport.Request{
+		Caller:    caller,
+		Service:   "myservice",
+		Procedure: procedure,
+		Encoding:  "raw",
+		Headers:   transport.NewHeaders().With("key", "value"),
+		Body:      strings.NewReader(body),
+	}
+}
+
+func handle(t *testing.T, tap *Tap, req *transport.Request, h transport.UnaryHandler) (string, error) {
+	resw := &transporttest.FakeResponseWriter{}
+	err := tap.Handle(context.Background(), req, resw, h)
+	return resw.Body.String(), err
+}
+
+func TestTapIdle(t *testing.T) {
+	tap := New()
+	body, err := handle(t, tap, newRequest("client", "hello", "world"), echo)
+	require.NoError(t, err)
+	assert.Equal(t, "world", body)
+	assert.Empty(t, tap.Captures())
+	assert.False(t, tap.Status().Enabled)
+}
+
+func TestTapInbound(t *testing.T) {
+	tap := New()
+	require.NoError(t, tap.Enable(Filter{Procedure: "hello", Caller: "client"}, 2))
+
+	for _, req := range []*transport.Request{
+		newRequest("client", "goodbye", "ignored"),
+		newRequest("other", "hello", "ignored"),
+		newRequest("client", "hello", "first"),
+		newRequest("client", "hello", "second"),
+		newRequest("client", "hello", "third"),
+	} {
+		body, err := handle(t, tap, req, echo)
+		require.NoError(t, err)
+		assert.NotEqual(t, "", body, "handler must see the request body")
+	}
+
+	captures := tap.Captures()
+	require.Len(t, captures, 2)
+	for i, want := range []string{"first", "second"} {
+		c := captures[i]
+		assert.Equal(t, Inbound, c.Direction)
+		assert.Equal(t, "client", c.Caller)
+		assert.Equal(t, "myservice", c.Service)
+		assert.Equal(t, "hello", c.Procedure)
+		assert.Equal(t, "raw", c.Encoding)
+		assert.Equal(t, map[string]string{"key": "value"}, c.RequestHeaders)
+		assert.Equal(t, want, string(c.RequestBody))
+		assert.Equal(t, map[string]string{"echoed": "true"}, c.ResponseHeaders)
+		assert.Equal(t, want, string(c.ResponseBody))
+		assert.Equal(t, yarpcerrors.CodeOK, c.Code)
+	}
+
+	status := tap.Status()
+	assert.False(t, status.Enabled, "capture must stop once count calls are captured")
+	assert.Equal(t, 0, status.Remaining)
+}
+
+func TestTapCodeFilter(t *testing.T) {
+	tap := New()
+	code := yarpcerrors.CodeInternal
+	require.NoError(t, tap.Enable(Filter{Code: &code}, 1))
+
+	_, err := handle(t, tap, newRequest("client", "hello", "ok"), echo)
+	require.NoError(t, err)
+	_, err = handle(t, tap, newRequest("client", "hello", "fail"), unaryHandlerFunc(
+		func(_ context.Context, _ *transport.Request, resw transport.ResponseWriter) error {
+			resw.SetApplicationError()
+			return yarpcerrors.InternalErrorf("great sadness")
+		}))
+	require.Error(t, err)
+
+	captures := tap.Captures()
+	require.Len(t, captures, 1)
+	assert.Equal(t, "fail", string(captures[0].RequestBody))
+	assert.Equal(t, yarpcerrors.CodeInternal, captures[0].Code)
+	assert.True(t, captures[0].ApplicationError)
+	assert.Contains(t, captures[0].Error, "great sadness")
+}
+
+func TestTapRingBuffer(t *testing.T) {
+	tap := New(Capacity(2), MaxBodySize(3))
+	require.NoError(t, tap.Enable(Filter{}, 3))
+
+	for _, body := range []string{"aaaa", "bbbb", "cccc"} {
+		out, err := handle(t, tap, newRequest("client", "hello", body), echo)
+		require.NoError(t, err)
+		assert.Equal(t, body, out, "truncation must not affect the response")
+	}
+
+	captures := tap.Captures()
+	require.Len(t, captures, 2)
+	assert.Equal(t, "bbb", string(captures[0].RequestBody))
+	assert.Equal(t, "bbb", string(captures[0].ResponseBody))
+	assert.Equal(t, "ccc", string(captures[1].RequestBody))
+
+	tap.Disable()
+	assert.Empty(t, tap.Captures())
+}
+
+func TestTapOutbound(t *testing.T) {
+	tap := New()
+	require.NoError(t, tap.Enable(Filter{Procedure: "hello"}, 1))
+
+	out := transporttest.NewFakeOutbound()
+	out.ExpectCall("hello").Do(func(_ context.Context, req *transport.Request) (*transport.Response, error) {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		return &transport.Response{
+			Headers: transport.NewHeaders().With("echoed", "true"),
+			Body:    ioutil.NopCloser(bytes.NewReader(body)),
+		}, nil
+	})
+
+	res, err := tap.Call(context.Background(), newRequest("client", "hello", "world"), out)
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "world", string(body))
+
+	captures := tap.Captures()
+	require.Len(t, captures, 1)
+	assert.Equal(t, Outbound, captures[0].Direction)
+	assert.Equal(t, "world", string(captures[0].RequestBody))
+	assert.Equal(t, "world", string(captures[0].ResponseBody))
+	assert.Equal(t, map[string]string{"echoed": "true"}, captures[0].ResponseHeaders)
+}
+
+func TestTapEnableInvalidCount(t *testing.T) {
+	assert.Error(t, New().Enable(Filter{}, 0))
+}
+
+func TestHandler(t *testing.T) {
+	tap := New()
+	server := httptest.NewServer(tap.Handler())
+	defer server.Close()
+
+	res, err := http.Post(server.URL, "application/json",
+		strings.NewReader(`{"filter": {"procedure": "hello", "code": "internal"}, "count": 3}`))
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	status := tap.Status()
+	assert.True(t, status.Enabled)
+	assert.Equal(t, "hello", status.Filter.Procedure)
+	require.NotNil(t, status.Filter.Code)
+	assert.Equal(t, yarpcerrors.CodeInternal, *status.Filter.Code)
+	assert.Equal(t, 3, status.Remaining)
+
+	_, err = handle(t, tap, newRequest("client", "hello", "fail"), unaryHandlerFunc(
+		func(context.Context, *transport.Request, transport.ResponseWriter) error {
+			return yarpcerrors.InternalErrorf("great sadness")
+		}))
+	require.Error(t, err)
+
+	res, err = http.Get(server.URL)
+	require.NoError(t, err)
+	var got struct {
+		Enabled   bool
+		Remaining int
+		Captures  []struct {
+			Procedure   string
+			Code        string
+			RequestBody []byte
+		}
+	}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&got))
+	res.Body.Close()
+	assert.True(t, got.Enabled)
+	assert.Equal(t, 2, got.Remaining)
+	require.Len(t, got.Captures, 1)
+	assert.Equal(t, "hello", got.Captures[0].Procedure)
+	assert.Equal(t, "internal", got.Captures[0].Code)
+	assert.Equal(t, "fail", string(got.Captures[0].RequestBody))
+
+	req, err := http.NewRequest(http.MethodDelete, server.URL, nil)
+	require.NoError(t, err)
+	res, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.False(t, tap.Status().Enabled)
+	assert.Empty(t, tap.Captures())
+
+	for _, tt := range []struct {
+		method string
+		body   string
+		want   int
+	}{
+		{http.MethodPost, `{"count": 0}`, http.StatusBadRequest},
+		{http.MethodPost, `not json`, http.StatusBadRequest},
+		{http.MethodPost, `{"filter": {"code": "sad"}, "count": 1}`, http.StatusBadRequest},
+		{http.MethodPut, ``, http.StatusMethodNotAllowed},
+	} {
+		req, err := http.NewRequest(tt.method, server.URL, strings.NewReader(tt.body))
+		require.NoError(t, err)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, tt.want, res.StatusCode, "%s %s", tt.method, tt.body)
+	}
+}
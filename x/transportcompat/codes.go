@@ -0,0 +1,59 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package transportcompat
+
+import "go.uber.org/yarpc/yarpcerrors"
+
+// _portableCodes maps codes to the codes that TChannel callers see when
+// handlers return errors with them. This follows the system error codes of
+// the TChannel transport.
+var _portableCodes = map[yarpcerrors.Code]yarpcerrors.Code{
+	yarpcerrors.CodeCancelled:         yarpcerrors.CodeCancelled,
+	yarpcerrors.CodeInvalidArgument:   yarpcerrors.CodeInvalidArgument,
+	yarpcerrors.CodeUnimplemented:     yarpcerrors.CodeInvalidArgument,
+	yarpcerrors.CodeDeadlineExceeded:  yarpcerrors.CodeDeadlineExceeded,
+	yarpcerrors.CodeUnavailable:       yarpcerrors.CodeUnavailable,
+	yarpcerrors.CodeResourceExhausted: yarpcerrors.CodeResourceExhausted,
+}
+
+// PortableCode returns the code that callers see over every transport when
+// a handler fails with an error with the given code that is not an
+// application error.
+func PortableCode(code yarpcerrors.Code) yarpcerrors.Code {
+	if code == yarpcerrors.CodeOK {
+		return code
+	}
+	if portable, ok := _portableCodes[code]; ok {
+		return portable
+	}
+	return yarpcerrors.CodeInternal
+}
+
+// portableError returns the error that callers see over every transport when
+// a handler fails with err, which is not an application error.
+func portableError(err error) error {
+	status := yarpcerrors.FromError(err)
+	code := PortableCode(status.Code())
+	if code == status.Code() && status.Name() == "" {
+		return err
+	}
+	return yarpcerrors.Newf(code, "%s", status.Message())
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package transportcompat provides middleware that gives services and
+// clients the same semantics over HTTP, TChannel, and gRPC, by restricting
+// requests and errors to what all three transports carry faithfully.
+//
+// Services that accept requests over several transports install the
+// middleware on their inbounds; clients that may be moved between transports
+// install it on their outbounds.
+//
+// 	compat := transportcompat.New()
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  compat,
+// 			Oneway: compat,
+// 		},
+// 		OutboundMiddleware: yarpc.OutboundMiddleware{
+// 			Unary:  compat,
+// 			Oneway: compat,
+// 		},
+// 		...
+// 	})
+//
+// Headers
+//
+// gRPC metadata keys are limited to lowercase letters, digits, '-', '_', and
+// '.', and values to printable ASCII; TChannel and HTTP accept more. Keys
+// starting with "rpc-", "$rpc$-", "grpc-", or ":" are reserved by one of the
+// transports, and keys ending in "-bin" are binary in gRPC. The middleware
+// rejects requests with other application headers with an InvalidArgument
+// error, both when sending and receiving them. Keys are case-insensitive on
+// all transports. See ValidateHeaders.
+//
+// Error codes
+//
+// gRPC and HTTP carry every error code, but TChannel only carries a few as
+// system errors, so handler errors that are not application errors reach
+// TChannel callers with a different code, name, and details. The inbound
+// middleware maps such errors to the codes TChannel callers see, keeping
+// only their messages, so that callers see the same errors over all
+// transports:
+//
+// 	Handler code          Code seen by callers
+// 	Cancelled             Cancelled
+// 	InvalidArgument       InvalidArgument
+// 	Unimplemented         InvalidArgument
+// 	DeadlineExceeded      DeadlineExceeded
+// 	Unavailable           Unavailable
+// 	ResourceExhausted     ResourceExhausted
+// 	Unknown, Internal,
+// 	DataLoss, and others  Internal
+//
+// ResourceExhausted errors are left alone: TChannel inbounds drop such
+// requests so that their callers time out and back off. Application errors,
+// which TChannel carries in full, are left alone too. See PortableCode.
+//
+// Deadlines
+//
+// HTTP and TChannel require deadlines and send them with millisecond
+// precision; gRPC does not require them. The middleware rejects calls
+// without deadlines with an InvalidArgument error, both when sending and
+// receiving them, and fails calls with less than a millisecond left with a
+// DeadlineExceeded error before sending them.
+package transportcompat
@@ -0,0 +1,79 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package transportcompat
+
+import (
+	"strings"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// _reservedPrefixes are the header key prefixes reserved by one of the
+// transports.
+var _reservedPrefixes = []string{
+	"rpc-",   // YARPC over HTTP and gRPC
+	"$rpc$-", // YARPC over TChannel
+	"grpc-",  // gRPC
+	":",      // HTTP/2 pseudo-headers
+}
+
+// ValidateHeaders returns an InvalidArgument error if any of the given
+// application headers cannot be carried as is by all transports.
+func ValidateHeaders(headers transport.Headers) error {
+	for key, value := range headers.Items() {
+		if err := validateHeader(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateHeader(key, value string) error {
+	if key == "" {
+		return yarpcerrors.InvalidArgumentErrorf("header keys must not be empty")
+	}
+	for _, prefix := range _reservedPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return yarpcerrors.InvalidArgumentErrorf("header %q uses the reserved prefix %q", key, prefix)
+		}
+	}
+	if strings.HasSuffix(key, "-bin") {
+		return yarpcerrors.InvalidArgumentErrorf("header %q uses the suffix %q, reserved for binary gRPC metadata", key, "-bin")
+	}
+	for i := 0; i < len(key); i++ {
+		if !isKeyChar(key[i]) {
+			return yarpcerrors.InvalidArgumentErrorf("header %q contains %q; keys may only contain lowercase letters, digits, '-', '_', and '.'", key, key[i])
+		}
+	}
+	for i := 0; i < len(value); i++ {
+		if value[i] < 0x20 || value[i] > 0x7e {
+			return yarpcerrors.InvalidArgumentErrorf("header %q has a value with %q; values may only contain printable ASCII", key, value[i])
+		}
+	}
+	return nil
+}
+
+// isKeyChar reports whether c may be used in a header key. Keys are already
+// lowercase.
+func isKeyChar(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.'
+}
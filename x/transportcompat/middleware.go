@@ -0,0 +1,116 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package transportcompat
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/responsewriter"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var (
+	_ middleware.UnaryInbound   = (*Middleware)(nil)
+	_ middleware.OnewayInbound  = (*Middleware)(nil)
+	_ middleware.UnaryOutbound  = (*Middleware)(nil)
+	_ middleware.OnewayOutbound = (*Middleware)(nil)
+)
+
+// Middleware is inbound and outbound middleware that restricts requests and
+// errors to what HTTP, TChannel, and gRPC all carry faithfully.
+type Middleware struct{}
+
+// New builds a new Middleware.
+func New() *Middleware {
+	return &Middleware{}
+}
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	if err := checkInbound(ctx, req); err != nil {
+		return err
+	}
+	w := &responseWriter{Wrapper: responsewriter.Wrapper{ResponseWriter: resw}}
+	err := h.Handle(ctx, req, w)
+	if err != nil && !w.isApplicationError {
+		return portableError(err)
+	}
+	return err
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	if err := checkInbound(ctx, req); err != nil {
+		return err
+	}
+	// Oneway callers never see handler errors.
+	return h.HandleOneway(ctx, req)
+}
+
+// Call implements middleware.UnaryOutbound.
+func (m *Middleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	if err := checkOutbound(ctx, req); err != nil {
+		return nil, err
+	}
+	return out.Call(ctx, req)
+}
+
+// CallOneway implements middleware.OnewayOutbound.
+func (m *Middleware) CallOneway(ctx context.Context, req *transport.Request, out transport.OnewayOutbound) (transport.Ack, error) {
+	if err := checkOutbound(ctx, req); err != nil {
+		return nil, err
+	}
+	return out.CallOneway(ctx, req)
+}
+
+func checkInbound(ctx context.Context, req *transport.Request) error {
+	if _, ok := ctx.Deadline(); !ok {
+		return yarpcerrors.InvalidArgumentErrorf("missing deadline for call to procedure %q of service %q", req.Procedure, req.Service)
+	}
+	return ValidateHeaders(req.Headers)
+}
+
+func checkOutbound(ctx context.Context, req *transport.Request) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return yarpcerrors.InvalidArgumentErrorf("missing deadline for call to procedure %q of service %q", req.Procedure, req.Service)
+	}
+	// HTTP and TChannel send deadlines in milliseconds.
+	if deadline.Sub(time.Now()) < time.Millisecond {
+		return yarpcerrors.DeadlineExceededErrorf("less than a millisecond left to call procedure %q of service %q", req.Procedure, req.Service)
+	}
+	return ValidateHeaders(req.Headers)
+}
+
+// responseWriter records whether the handler reported an application error.
+type responseWriter struct {
+	responsewriter.Wrapper
+
+	isApplicationError bool
+}
+
+func (w *responseWriter) SetApplicationError() {
+	w.isApplicationError = true
+	w.ResponseWriter.SetApplicationError()
+}
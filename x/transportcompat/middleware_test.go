@@ -0,0 +1,242 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package transportcompat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	intyarpcerrors "go.uber.org/yarpc/internal/yarpcerrors"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+func TestValidateHeaders(t *testing.T) {
+	tests := []struct {
+		key, value string
+		wantErr    string
+	}{
+		{key: "x-token", value: "abc 123"},
+		{key: "X-Request_ID.v2", value: "ok"},
+		{key: "rpc-caller", wantErr: `reserved prefix "rpc-"`},
+		{key: "$rpc$-service", wantErr: `reserved prefix "$rpc$-"`},
+		{key: "grpc-timeout", wantErr: `reserved prefix "grpc-"`},
+		{key: ":authority", wantErr: `reserved prefix ":"`},
+		{key: "trace-bin", wantErr: `suffix "-bin"`},
+		{key: "has space", wantErr: `contains ' '`},
+		{key: "x-token", value: "line\nbreak", wantErr: `value with '\n'`},
+		{key: "x-token", value: "caf\xc3\xa9", wantErr: "printable ASCII"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			err := ValidateHeaders(transport.NewHeaders().With(tt.key, tt.value))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, yarpcerrors.IsInvalidArgument(err))
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestPortableCode(t *testing.T) {
+	tests := map[yarpcerrors.Code]yarpcerrors.Code{
+		yarpcerrors.CodeOK:                 yarpcerrors.CodeOK,
+		yarpcerrors.CodeCancelled:          yarpcerrors.CodeCancelled,
+		yarpcerrors.CodeUnknown:            yarpcerrors.CodeInternal,
+		yarpcerrors.CodeInvalidArgument:    yarpcerrors.CodeInvalidArgument,
+		yarpcerrors.CodeDeadlineExceeded:   yarpcerrors.CodeDeadlineExceeded,
+		yarpcerrors.CodeNotFound:           yarpcerrors.CodeInternal,
+		yarpcerrors.CodeAlreadyExists:      yarpcerrors.CodeInternal,
+		yarpcerrors.CodePermissionDenied:   yarpcerrors.CodeInternal,
+		yarpcerrors.CodeResourceExhausted:  yarpcerrors.CodeResourceExhausted,
+		yarpcerrors.CodeFailedPrecondition: yarpcerrors.CodeInternal,
+		yarpcerrors.CodeAborted:            yarpcerrors.CodeInternal,
+		yarpcerrors.CodeOutOfRange:         yarpcerrors.CodeInternal,
+		yarpcerrors.CodeUnimplemented:      yarpcerrors.CodeInvalidArgument,
+		yarpcerrors.CodeInternal:           yarpcerrors.CodeInternal,
+		yarpcerrors.CodeUnavailable:        yarpcerrors.CodeUnavailable,
+		yarpcerrors.CodeDataLoss:           yarpcerrors.CodeInternal,
+		yarpcerrors.CodeUnauthenticated:    yarpcerrors.CodeInternal,
+	}
+	for code, want := range tests {
+		assert.Equal(t, want, PortableCode(code), "PortableCode(%v)", code)
+	}
+}
+
+type unaryHandlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f unaryHandlerFunc) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	return f(ctx, req, resw)
+}
+
+type onewayHandlerFunc func(context.Context, *transport.Request) error
+
+func (f onewayHandlerFunc) HandleOneway(ctx context.Context, req *transport.Request) error {
+	return f(ctx, req)
+}
+
+func newContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), time.Second)
+}
+
+func TestHandle(t *testing.T) {
+	tests := []struct {
+		desc       string
+		headers    transport.Headers
+		noDeadline bool
+		handle     func(transport.ResponseWriter) error
+		wantErr    error
+	}{
+		{
+			desc:   "success",
+			handle: func(transport.ResponseWriter) error { return nil },
+		},
+		{
+			desc:    "invalid header",
+			headers: transport.NewHeaders().With("grpc-foo", "bar"),
+			wantErr: yarpcerrors.InvalidArgumentErrorf(`header "grpc-foo" uses the reserved prefix "grpc-"`),
+		},
+		{
+			desc:       "missing deadline",
+			noDeadline: true,
+			wantErr:    yarpcerrors.InvalidArgumentErrorf(`missing deadline for call to procedure "proc" of service "svc"`),
+		},
+		{
+			desc: "mapped error",
+			handle: func(transport.ResponseWriter) error {
+				return intyarpcerrors.NewWithNamef(yarpcerrors.CodeNotFound, "no-such-user", "user not found")
+			},
+			wantErr: yarpcerrors.InternalErrorf("user not found"),
+		},
+		{
+			desc: "plain error",
+			handle: func(transport.ResponseWriter) error {
+				return errors.New("great sadness")
+			},
+			wantErr: yarpcerrors.InternalErrorf("great sadness"),
+		},
+		{
+			desc: "portable error",
+			handle: func(transport.ResponseWriter) error {
+				return yarpcerrors.UnavailableErrorf("try again")
+			},
+			wantErr: yarpcerrors.UnavailableErrorf("try again"),
+		},
+		{
+			desc: "application error",
+			handle: func(resw transport.ResponseWriter) error {
+				resw.SetApplicationError()
+				return yarpcerrors.NotFoundErrorf("user not found")
+			},
+			wantErr: yarpcerrors.NotFoundErrorf("user not found"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ctx, cancel := newContext()
+			defer cancel()
+			if tt.noDeadline {
+				ctx = context.Background()
+			}
+
+			req := &transport.Request{Service: "svc", Procedure: "proc", Headers: tt.headers}
+			h := unaryHandlerFunc(func(_ context.Context, _ *transport.Request, resw transport.ResponseWriter) error {
+				require.NotNil(t, tt.handle, "handler must not be called")
+				return tt.handle(resw)
+			})
+			err := New().Handle(ctx, req, &transporttest.FakeResponseWriter{}, h)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestHandleOneway(t *testing.T) {
+	ctx, cancel := newContext()
+	defer cancel()
+
+	called := false
+	h := onewayHandlerFunc(func(context.Context, *transport.Request) error {
+		called = true
+		return nil
+	})
+	req := &transport.Request{Headers: transport.NewHeaders().With("x-token", "abc")}
+	require.NoError(t, New().HandleOneway(ctx, req, h))
+	assert.True(t, called)
+
+	req = &transport.Request{Headers: transport.NewHeaders().With("trace-bin", "abc")}
+	assert.True(t, yarpcerrors.IsInvalidArgument(New().HandleOneway(ctx, req, h)))
+}
+
+func TestCall(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	out := transporttest.NewMockUnaryOutbound(mockCtrl)
+	req := &transport.Request{Service: "svc", Procedure: "proc", Headers: transport.NewHeaders().With("x-token", "abc")}
+	res := &transport.Response{}
+
+	ctx, cancel := newContext()
+	defer cancel()
+	out.EXPECT().Call(ctx, req).Return(res, nil)
+	got, err := New().Call(ctx, req, out)
+	require.NoError(t, err)
+	assert.Equal(t, res, got)
+
+	// No calls are expected on the outbound for invalid requests.
+	_, err = New().Call(context.Background(), req, out)
+	assert.True(t, yarpcerrors.IsInvalidArgument(err), "calls without deadlines must fail")
+
+	shortCtx, cancel := context.WithTimeout(context.Background(), 100*time.Microsecond)
+	defer cancel()
+	_, err = New().Call(shortCtx, req, out)
+	assert.True(t, yarpcerrors.IsDeadlineExceeded(err), "calls with less than a millisecond left must fail")
+
+	badReq := &transport.Request{Headers: transport.NewHeaders().With("$rpc$-foo", "bar")}
+	_, err = New().Call(ctx, badReq, out)
+	assert.True(t, yarpcerrors.IsInvalidArgument(err), "calls with invalid headers must fail")
+}
+
+func TestCallOneway(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	out := transporttest.NewMockOnewayOutbound(mockCtrl)
+	req := &transport.Request{Headers: transport.NewHeaders().With("x-token", "abc")}
+
+	ctx, cancel := newContext()
+	defer cancel()
+	out.EXPECT().CallOneway(ctx, req).Return(nil, nil)
+	_, err := New().CallOneway(ctx, req, out)
+	require.NoError(t, err)
+
+	_, err = New().CallOneway(context.Background(), req, out)
+	assert.True(t, yarpcerrors.IsInvalidArgument(err))
+}
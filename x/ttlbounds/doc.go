@@ -0,0 +1,38 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package ttlbounds keeps the TTLs of outgoing requests within a range.
+//
+// Deadlines propagate through the call graph: a caller that sets a
+// multi-hour deadline, or none at all, ties up resources in every
+// downstream service, while a deadline that has all but expired makes
+// downstreams do work whose result nobody will wait for. The Middleware is
+// unary and oneway outbound middleware that lowers TTLs above a maximum and
+// raises TTLs below a minimum, or rejects such requests with an
+// InvalidArgument error.
+//
+// Apply the Middleware to the outbounds of the downstreams it protects.
+//
+// 	bounds := ttlbounds.New(
+// 		ttlbounds.Min(10*time.Millisecond),
+// 		ttlbounds.Max(5*time.Second),
+// 	)
+// 	outbound := middleware.ApplyUnaryOutbound(httpOutbound, bounds)
+package ttlbounds
@@ -0,0 +1,145 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ttlbounds
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var (
+	_ middleware.UnaryOutbound  = (*Middleware)(nil)
+	_ middleware.OnewayOutbound = (*Middleware)(nil)
+	_ middleware.Named          = (*Middleware)(nil)
+)
+
+// Middleware is unary and oneway outbound middleware that keeps the TTLs of
+// requests within bounds.
+type Middleware struct {
+	opts options
+
+	outOfBounds *metrics.CounterVector
+}
+
+// New builds a new TTL bounds Middleware.
+func New(opts ...Option) *Middleware {
+	m := &Middleware{opts: applyOptions(opts...)}
+	m.outOfBounds, _ = m.opts.meter.CounterVector(metrics.Spec{
+		Name:    "ttl_out_of_bounds",
+		Help:    "Number of requests whose TTL was out of bounds.",
+		VarTags: []string{"procedure", "bound", "action"},
+	})
+	return m
+}
+
+// MiddlewareName implements middleware.Named.
+func (m *Middleware) MiddlewareName() string { return "ttlbounds" }
+
+// Call implements middleware.UnaryOutbound.
+func (m *Middleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	ctx, cancel, err := m.bound(ctx, req.Procedure)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	return out.Call(ctx, req)
+}
+
+// CallOneway implements middleware.OnewayOutbound.
+func (m *Middleware) CallOneway(ctx context.Context, req *transport.Request, out transport.OnewayOutbound) (transport.Ack, error) {
+	ctx, cancel, err := m.bound(ctx, req.Procedure)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	return out.CallOneway(ctx, req)
+}
+
+// bound returns a context whose TTL is within bounds, or an error if the TTL
+// of ctx is out of bounds and such requests are rejected.
+func (m *Middleware) bound(ctx context.Context, procedure string) (context.Context, context.CancelFunc, error) {
+	deadline, hasDeadline := ctx.Deadline()
+	ttl := deadline.Sub(time.Now())
+
+	switch {
+	case m.opts.max > 0 && (!hasDeadline || ttl > m.opts.max):
+		if m.opts.reject {
+			m.observe(procedure, "max", "rejected")
+			if !hasDeadline {
+				return nil, nil, yarpcerrors.InvalidArgumentErrorf(
+					"request to procedure %q has no TTL, must be at most %v", procedure, m.opts.max)
+			}
+			return nil, nil, yarpcerrors.InvalidArgumentErrorf(
+				"TTL %v of request to procedure %q is longer than %v", ttl, procedure, m.opts.max)
+		}
+		m.observe(procedure, "max", "adjusted")
+		ctx, cancel := context.WithTimeout(ctx, m.opts.max)
+		return ctx, cancel, nil
+
+	case m.opts.min > 0 && hasDeadline && ttl < m.opts.min:
+		if m.opts.reject {
+			m.observe(procedure, "min", "rejected")
+			return nil, nil, yarpcerrors.InvalidArgumentErrorf(
+				"TTL %v of request to procedure %q is shorter than %v", ttl, procedure, m.opts.min)
+		}
+		m.observe(procedure, "min", "adjusted")
+		ctx, cancel := extend(ctx, m.opts.min)
+		return ctx, cancel, nil
+	}
+	return ctx, func() {}, nil
+}
+
+func (m *Middleware) observe(procedure, bound, action string) {
+	if counter, err := m.outOfBounds.Get("procedure", procedure, "bound", bound, "action", action); err == nil {
+		counter.Inc()
+	}
+}
+
+// extend returns a copy of ctx with the given timeout that is canceled when
+// ctx is canceled, but not when the deadline of ctx passes.
+func extend(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	extended, cancel := context.WithTimeout(detachedContext{ctx}, timeout)
+	go func() {
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.Canceled {
+				cancel()
+			}
+		case <-extended.Done():
+		}
+	}()
+	return extended, cancel
+}
+
+// detachedContext keeps the values of a context but not its deadline or
+// cancellation.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
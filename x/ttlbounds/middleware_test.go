@@ -0,0 +1,167 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ttlbounds
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type ctxKey struct{}
+
+// recorder is an outbound that records the context of the last call.
+type recorder struct {
+	transport.UnaryOutbound
+
+	ctx context.Context
+}
+
+func (r *recorder) Call(ctx context.Context, _ *transport.Request) (*transport.Response, error) {
+	r.ctx = ctx
+	return &transport.Response{}, nil
+}
+
+func ttlOf(t *testing.T, ctx context.Context) time.Duration {
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok, "context must have a deadline")
+	return deadline.Sub(time.Now())
+}
+
+func counter(root *metrics.Root, bound, action string) int64 {
+	for _, s := range root.Snapshot().Counters {
+		if s.Name == "ttl_out_of_bounds" && s.Tags["bound"] == bound && s.Tags["action"] == action {
+			return s.Value
+		}
+	}
+	return 0
+}
+
+func TestWithinBounds(t *testing.T) {
+	m := New(Min(time.Second), Max(time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	var out recorder
+	_, err := m.Call(ctx, &transport.Request{Procedure: "echo"}, &out)
+	require.NoError(t, err)
+	assert.Equal(t, ctx, out.ctx)
+}
+
+func TestMax(t *testing.T) {
+	root := metrics.New()
+	m := New(Max(time.Minute), Metrics(root.Scope()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	var out recorder
+	_, err := m.Call(ctx, &transport.Request{Procedure: "echo"}, &out)
+	require.NoError(t, err)
+	assert.True(t, ttlOf(t, out.ctx) <= time.Minute)
+
+	_, err = m.Call(context.Background(), &transport.Request{Procedure: "echo"}, &out)
+	require.NoError(t, err)
+	assert.True(t, ttlOf(t, out.ctx) <= time.Minute, "requests without a deadline get the maximum TTL")
+	assert.Equal(t, int64(2), counter(root, "max", "adjusted"))
+}
+
+func TestMin(t *testing.T) {
+	root := metrics.New()
+	m := New(Min(time.Minute), Metrics(root.Scope()))
+
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), ctxKey{}, "value"), time.Millisecond)
+	defer cancel()
+	var out recorder
+	_, err := m.Call(ctx, &transport.Request{Procedure: "echo"}, &out)
+	require.NoError(t, err)
+	assert.True(t, ttlOf(t, out.ctx) > time.Second)
+	assert.Equal(t, "value", out.ctx.Value(ctxKey{}))
+	assert.Equal(t, int64(1), counter(root, "min", "adjusted"))
+}
+
+func TestExtend(t *testing.T) {
+	t.Run("outlives deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		extended, cancelExtended := extend(ctx, time.Minute)
+		defer cancelExtended()
+
+		<-ctx.Done()
+		select {
+		case <-extended.Done():
+			t.Fatal("extended context must outlive the deadline of its parent")
+		case <-time.After(10 * time.Millisecond):
+		}
+	})
+
+	t.Run("canceled with parent", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		extended, cancelExtended := extend(ctx, time.Minute)
+		defer cancelExtended()
+
+		cancel()
+		select {
+		case <-extended.Done():
+			assert.Equal(t, context.Canceled, extended.Err())
+		case <-time.After(time.Second):
+			t.Fatal("extended context must be canceled with its parent")
+		}
+	})
+}
+
+func TestReject(t *testing.T) {
+	root := metrics.New()
+	m := New(Min(time.Second), Max(time.Minute), Reject(), Metrics(root.Scope()))
+
+	tests := []struct {
+		desc    string
+		timeout time.Duration
+		bound   string
+	}{
+		{desc: "too short", timeout: time.Millisecond, bound: "min"},
+		{desc: "too long", timeout: time.Hour, bound: "max"},
+		{desc: "no deadline", bound: "max"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			var out recorder
+			_, err := m.Call(ctx, &transport.Request{Procedure: "echo"}, &out)
+			require.Error(t, err)
+			assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.FromError(err).Code())
+			assert.Nil(t, out.ctx, "outbound must not be called")
+		})
+	}
+	assert.Equal(t, int64(1), counter(root, "min", "rejected"))
+	assert.Equal(t, int64(2), counter(root, "max", "rejected"))
+}
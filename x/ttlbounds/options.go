@@ -0,0 +1,86 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ttlbounds
+
+import (
+	"time"
+
+	"go.uber.org/net/metrics"
+)
+
+// Option customizes the behavior of a Middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	min    time.Duration
+	max    time.Duration
+	reject bool
+	meter  *metrics.Scope
+}
+
+// Min specifies the shortest TTL sent to the outbound. Requests with shorter
+// TTLs get this TTL instead, and the call may outlive the deadline of the
+// caller's context; it is still canceled if the caller cancels the context.
+// By default, TTLs have no minimum.
+func Min(ttl time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.min = ttl
+	})
+}
+
+// Max specifies the longest TTL sent to the outbound. Requests with longer
+// TTLs, or without a deadline, get this TTL instead. By default, TTLs have
+// no maximum.
+func Max(ttl time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.max = ttl
+	})
+}
+
+// Reject fails requests whose TTLs are out of bounds with an
+// InvalidArgument error instead of adjusting their TTLs.
+func Reject() Option {
+	return optionFunc(func(opts *options) {
+		opts.reject = true
+	})
+}
+
+// Metrics specifies the scope to which metrics about out of bounds TTLs are
+// reported. By default, no metrics are reported.
+func Metrics(meter *metrics.Scope) Option {
+	return optionFunc(func(opts *options) {
+		opts.meter = meter
+	})
+}
+
+func applyOptions(opts ...Option) options {
+	var options options
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return options
+}
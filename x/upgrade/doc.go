@@ -0,0 +1,45 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package upgrade provides an outbound that sends calls over a preferred
+// protocol, typically gRPC, and falls back to another one, typically HTTP or
+// TChannel, for peers that do not support the preferred protocol yet.
+//
+// 	outbound := upgrade.NewOutbound(grpcOutbound, httpOutbound)
+//
+// Calls go to the preferred outbound first. When a call fails before reaching
+// a handler because the peer does not serve the preferred protocol, for
+// example because it refuses the connection or does not know the gRPC
+// service, the peer is remembered as not supporting the preferred protocol
+// and the call is sent to the fallback outbound right away. Later calls ask the
+// preferred outbound's chooser to avoid such peers, and skip the preferred
+// outbound entirely when every available peer is known not to support it.
+//
+// Peers are probed again once the probe interval has passed, so that calls
+// move over to the preferred protocol as peers are upgraded, whatever the
+// order in which callers and servers are rolled out.
+//
+// Calls that fail with one of the codes given to FallbackCodes fall back too.
+// Such calls may have reached the handler, in which case they are handled
+// twice. Only use fallback codes that handlers do not return, or procedures
+// that are safe to retry.
+//
+// The upgrade outbound starts and stops both outbounds.
+package upgrade
@@ -0,0 +1,99 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package upgrade
+
+import (
+	"time"
+
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+const _defaultProbeInterval = 5 * time.Minute
+
+// Option customizes the behavior of an Outbound.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	codes         map[yarpcerrors.Code]struct{}
+	probeInterval time.Duration
+	logger        *zap.Logger
+	clock         clock.Clock
+}
+
+// FallbackCodes specifies additional error codes of the preferred outbound
+// after which calls fall back to the fallback outbound.
+//
+// By default, calls only fall back after errors that show the peer does not
+// serve the preferred protocol: refused connections, unknown gRPC services or
+// YARPC procedures, and HTTP 404 or 415 responses to gRPC calls. Calls that
+// fail with these codes may already have reached a handler, in which case
+// they are handled twice.
+func FallbackCodes(codes ...yarpcerrors.Code) Option {
+	return optionFunc(func(opts *options) {
+		opts.codes = make(map[yarpcerrors.Code]struct{}, len(codes))
+		for _, c := range codes {
+			opts.codes[c] = struct{}{}
+		}
+	})
+}
+
+// ProbeInterval specifies how long a peer that does not support the
+// preferred protocol is avoided before the next call probes it. Defaults to
+// 5 minutes.
+func ProbeInterval(interval time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.probeInterval = interval
+	})
+}
+
+// Logger specifies the logger that should be used to log.
+// Default value is noop zap logger.
+func Logger(logger *zap.Logger) Option {
+	return optionFunc(func(opts *options) {
+		opts.logger = logger
+	})
+}
+
+func withClock(clock clock.Clock) Option {
+	return optionFunc(func(opts *options) {
+		opts.clock = clock
+	})
+}
+
+func applyOptions(opts ...Option) options {
+	options := options{
+		probeInterval: _defaultProbeInterval,
+		logger:        zap.NewNop(),
+		clock:         clock.NewReal(),
+	}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return options
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package upgrade
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+var _ transport.UnaryOutbound = (*Outbound)(nil)
+
+// Outbound is an outbound that sends unary calls over a preferred protocol
+// and falls back to another one for peers that do not support it.
+type Outbound struct {
+	once      *lifecycle.Once
+	opts      options
+	preferred transport.UnaryOutbound
+	fallback  transport.UnaryOutbound
+
+	mu sync.Mutex
+	// Time at which each peer of the preferred outbound was found not to
+	// support it, by peer identifier, for peers still within their probe
+	// interval.
+	unsupported map[string]time.Time
+}
+
+// NewOutbound builds an Outbound that sends calls to the preferred outbound,
+// falling back to the fallback outbound for peers that do not support the
+// preferred protocol.
+func NewOutbound(preferred, fallback transport.UnaryOutbound, opts ...Option) *Outbound {
+	return &Outbound{
+		once:        lifecycle.NewOnce(),
+		opts:        applyOptions(opts...),
+		preferred:   preferred,
+		fallback:    fallback,
+		unsupported: make(map[string]time.Time),
+	}
+}
+
+// Transports returns the transports used by both outbounds.
+func (o *Outbound) Transports() []transport.Transport {
+	return append(o.preferred.Transports(), o.fallback.Transports()...)
+}
+
+// Start starts both outbounds.
+func (o *Outbound) Start() error {
+	return o.once.Start(o.start)
+}
+
+func (o *Outbound) start() error {
+	return multierr.Append(o.preferred.Start(), o.fallback.Start())
+}
+
+// Stop stops both outbounds.
+func (o *Outbound) Stop() error {
+	return o.once.Stop(o.stop)
+}
+
+func (o *Outbound) stop() error {
+	return multierr.Append(o.preferred.Stop(), o.fallback.Stop())
+}
+
+// IsRunning returns whether the outbound is running.
+func (o *Outbound) IsRunning() bool {
+	return o.once.IsRunning()
+}
+
+// Call sends a unary request over the preferred protocol, falling back to the
+// fallback outbound if the peer that receives it does not support it.
+func (o *Outbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	// The body is buffered so that it can be sent to both outbounds.
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	if avoid, ok := o.avoidPeers(); ok {
+		var info transport.OutboundCallInfo
+		pctx := transport.WithOutboundCallInfo(ctx, &info)
+		if len(avoid) > 0 {
+			pctx = peer.WithAvoidPeers(pctx, avoid...)
+		}
+
+		r := *req
+		r.Body = bytes.NewReader(body)
+		res, err := o.preferred.Call(pctx, &r)
+		if err == nil || !o.shouldFallback(err) {
+			o.markSupported(info.Peer)
+			return res, err
+		}
+		o.markUnsupported(info.Peer, err)
+	}
+
+	r := *req
+	r.Body = bytes.NewReader(body)
+	return o.fallback.Call(ctx, &r)
+}
+
+// avoidPeers returns the peers the preferred outbound should avoid, that is
+// the peers known not to support the preferred protocol and not yet due for a
+// probe, and reports whether calls should go to the preferred outbound at
+// all. They should not when its peer list reports having no more available
+// peers than peers to avoid.
+func (o *Outbound) avoidPeers() ([]string, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := o.opts.clock.Now()
+	var avoid []string
+	for id, failedAt := range o.unsupported {
+		if now.Before(failedAt.Add(o.opts.probeInterval)) {
+			avoid = append(avoid, id)
+		} else {
+			// Forget peers due for a probe so that peers that left do not
+			// accumulate.
+			delete(o.unsupported, id)
+		}
+	}
+	return avoid, hasMoreAvailablePeers(o.preferred, len(avoid))
+}
+
+func (o *Outbound) markSupported(id string) {
+	if id == "" {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if _, ok := o.unsupported[id]; ok {
+		delete(o.unsupported, id)
+		o.opts.logger.Info("peer supports preferred protocol", zap.String("peer", id))
+	}
+}
+
+func (o *Outbound) markUnsupported(id string, err error) {
+	if id == "" {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	// A failed probe restarts the probe interval.
+	if _, ok := o.unsupported[id]; !ok {
+		o.opts.logger.Info("falling back for peer without preferred protocol",
+			zap.String("peer", id), zap.Error(err))
+	}
+	o.unsupported[id] = o.opts.clock.Now()
+}
+
+// shouldFallback reports whether a call that failed with the given error
+// should be sent to the fallback outbound: either the error shows that the
+// peer does not serve the preferred protocol, so the call did not reach a
+// handler, or its code is one of the configured fallback codes.
+func (o *Outbound) shouldFallback(err error) bool {
+	status := yarpcerrors.FromError(err)
+	if _, ok := o.opts.codes[status.Code()]; ok {
+		return true
+	}
+	message := status.Message()
+	// gRPC clients report HTTP servers that do not speak gRPC by their
+	// status code.
+	if strings.Contains(message, "unexpected HTTP status code received from server: 404") ||
+		strings.Contains(message, "unexpected HTTP status code received from server: 415") {
+		return true
+	}
+	switch status.Code() {
+	case yarpcerrors.CodeUnavailable:
+		// The peer does not listen for the preferred protocol.
+		return strings.Contains(message, "connection refused")
+	case yarpcerrors.CodeUnimplemented:
+		// gRPC servers without the service, and YARPC servers without the
+		// procedure, reject calls before any handler sees them.
+		return strings.Contains(message, "unknown service") ||
+			strings.Contains(message, "unrecognized procedure")
+	}
+	return false
+}
+
+// hasMoreAvailablePeers reports whether the outbound may have more than n
+// available peers. Outbounds are assumed to have enough peers unless they
+// choose peers from a list that reports how many are available. Peers that
+// left the list are still counted in n until their probe interval passes.
+func hasMoreAvailablePeers(out transport.Outbound, n int) bool {
+	co, ok := out.(interface{ Chooser() peer.Chooser })
+	if !ok {
+		return true
+	}
+	list, ok := co.Chooser().(interface{ NumAvailable() int })
+	return !ok || list.NumAvailable() > n
+}
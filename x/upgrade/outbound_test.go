@@ -0,0 +1,236 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package upgrade
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type fakeOutbound struct {
+	running bool
+	calls   []string
+	avoided [][]string
+	// Peers by the order in which the outbound sends calls to them, and the
+	// peers that fail calls.
+	peers  []string
+	failed map[string]error
+}
+
+func (o *fakeOutbound) Transports() []transport.Transport { return nil }
+func (o *fakeOutbound) Start() error                      { o.running = true; return nil }
+func (o *fakeOutbound) Stop() error                       { o.running = false; return nil }
+func (o *fakeOutbound) IsRunning() bool                   { return o.running }
+
+func (o *fakeOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	o.calls = append(o.calls, string(body))
+	avoid := peer.AvoidPeersFromContext(ctx)
+	o.avoided = append(o.avoided, avoid)
+
+	for _, p := range o.peers {
+		if !contains(avoid, p) {
+			transport.RecordOutboundAttempt(ctx, p, false)
+			if err := o.failed[p]; err != nil {
+				return nil, err
+			}
+			return &transport.Response{}, nil
+		}
+	}
+	return &transport.Response{}, nil
+}
+
+func contains(ids []string, id string) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+type chooserOutbound struct {
+	fakeOutbound
+	available int
+}
+
+func (o *chooserOutbound) Chooser() peer.Chooser { return list{o.available} }
+
+type list struct{ available int }
+
+func (list) Choose(context.Context, *transport.Request) (peer.Peer, func(error), error) {
+	return nil, nil, errors.New("not implemented")
+}
+func (list) Start() error        { return nil }
+func (list) Stop() error         { return nil }
+func (list) IsRunning() bool     { return true }
+func (l list) NumAvailable() int { return l.available }
+
+func call(o *Outbound, body string) error {
+	_, err := o.Call(context.Background(), &transport.Request{Body: strings.NewReader(body)})
+	return err
+}
+
+func TestLifecycle(t *testing.T) {
+	preferred, fallback := &fakeOutbound{}, &fakeOutbound{}
+	o := NewOutbound(preferred, fallback)
+
+	require.NoError(t, o.Start())
+	assert.True(t, o.IsRunning())
+	assert.True(t, preferred.running)
+	assert.True(t, fallback.running)
+
+	require.NoError(t, o.Stop())
+	assert.False(t, o.IsRunning())
+	assert.False(t, preferred.running)
+	assert.False(t, fallback.running)
+}
+
+func TestFallback(t *testing.T) {
+	preferred := &fakeOutbound{
+		peers:  []string{"old:5436", "new:5436"},
+		failed: map[string]error{"old:5436": yarpcerrors.UnavailableErrorf("connection refused")},
+	}
+	fallback := &fakeOutbound{}
+	o := NewOutbound(preferred, fallback)
+
+	// The first call finds out that the old peer does not support the
+	// preferred protocol, and falls back.
+	require.NoError(t, call(o, "a"))
+	assert.Equal(t, []string{"a"}, preferred.calls)
+	assert.Equal(t, []string{"a"}, fallback.calls)
+
+	// Later calls avoid the old peer.
+	require.NoError(t, call(o, "b"))
+	assert.Equal(t, []string{"a", "b"}, preferred.calls)
+	assert.Equal(t, []string{"a"}, fallback.calls)
+	assert.Equal(t, [][]string{nil, {"old:5436"}}, preferred.avoided)
+}
+
+func TestFallbackCodes(t *testing.T) {
+	preferred := &fakeOutbound{
+		peers:  []string{"peer"},
+		failed: map[string]error{"peer": yarpcerrors.InternalErrorf("great sadness")},
+	}
+	fallback := &fakeOutbound{}
+
+	o := NewOutbound(preferred, fallback)
+	assert.Error(t, call(o, "a"))
+	assert.Empty(t, fallback.calls)
+
+	o = NewOutbound(preferred, fallback, FallbackCodes(yarpcerrors.CodeInternal))
+	assert.NoError(t, call(o, "b"))
+	assert.Equal(t, []string{"b"}, fallback.calls)
+}
+
+func TestSkipsPreferredWithoutSupportingPeers(t *testing.T) {
+	preferred := &chooserOutbound{
+		fakeOutbound: fakeOutbound{
+			peers:  []string{"old:5436"},
+			failed: map[string]error{"old:5436": yarpcerrors.UnimplementedErrorf("unknown service keyvalue.KeyValue")},
+		},
+		available: 1,
+	}
+	fallback := &fakeOutbound{}
+	clk := clock.NewFake()
+	o := NewOutbound(preferred, fallback, ProbeInterval(time.Minute), withClock(clk))
+
+	require.NoError(t, call(o, "a"))
+	require.NoError(t, call(o, "b"))
+	assert.Equal(t, []string{"a"}, preferred.calls, "expected the only peer to be skipped once known")
+	assert.Equal(t, []string{"a", "b"}, fallback.calls)
+
+	// Once the probe interval passes, the peer is probed again and calls
+	// move over to the preferred protocol once it is upgraded.
+	delete(preferred.failed, "old:5436")
+	clk.Add(time.Minute)
+	require.NoError(t, call(o, "c"))
+	require.NoError(t, call(o, "d"))
+	assert.Equal(t, []string{"a", "c", "d"}, preferred.calls)
+	assert.Equal(t, []string{"a", "b"}, fallback.calls)
+}
+
+func TestNoAvailablePeers(t *testing.T) {
+	preferred := &chooserOutbound{}
+	fallback := &fakeOutbound{}
+	o := NewOutbound(preferred, fallback)
+
+	require.NoError(t, call(o, "a"))
+	assert.Empty(t, preferred.calls)
+	assert.Equal(t, []string{"a"}, fallback.calls)
+}
+
+func TestFallbackOnlyBeforeHandlers(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{yarpcerrors.UnavailableErrorf("dial tcp 10.0.0.1:5436: connect: connection refused"), true},
+		{yarpcerrors.UnimplementedErrorf("unknown service keyvalue.KeyValue"), true},
+		{yarpcerrors.UnimplementedErrorf(`unrecognized procedure "KeyValue::GetValue" for service "keyvalue"`), true},
+		{yarpcerrors.UnimplementedErrorf("unexpected HTTP status code received from server: 404 (Not Found)"), true},
+		{yarpcerrors.UnknownErrorf("unexpected HTTP status code received from server: 415 (Unsupported Media Type)"), true},
+		{yarpcerrors.UnavailableErrorf("server is overloaded"), false},
+		{yarpcerrors.UnimplementedErrorf("feature is not implemented"), false},
+	}
+	for _, tt := range tests {
+		preferred := &fakeOutbound{
+			peers:  []string{"peer"},
+			failed: map[string]error{"peer": tt.err},
+		}
+		fallback := &fakeOutbound{}
+		o := NewOutbound(preferred, fallback)
+		_ = call(o, "a")
+		assert.Equal(t, tt.want, len(fallback.calls) > 0, "fallback after %v", tt.err)
+	}
+}
+
+func TestForgetsPeersDueForProbe(t *testing.T) {
+	preferred := &fakeOutbound{
+		peers:  []string{"old:5436"},
+		failed: map[string]error{"old:5436": yarpcerrors.UnavailableErrorf("connection refused")},
+	}
+	clk := clock.NewFake()
+	o := NewOutbound(preferred, &fakeOutbound{}, ProbeInterval(time.Minute), withClock(clk))
+
+	require.NoError(t, call(o, "a"))
+	assert.Len(t, o.unsupported, 1)
+
+	// The peer left the preferred outbound, so it is never probed again.
+	preferred.peers = []string{"new:5436"}
+	clk.Add(time.Minute)
+	require.NoError(t, call(o, "b"))
+	assert.Empty(t, o.unsupported)
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xds
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/zap"
+)
+
+const (
+	_clustersPath  = "/v2/discovery:clusters"
+	_endpointsPath = "/v2/discovery:endpoints"
+	_routesPath    = "/v2/discovery:routes"
+
+	_clusterType   = "type.googleapis.com/envoy.api.v2.Cluster"
+	_endpointsType = "type.googleapis.com/envoy.api.v2.ClusterLoadAssignment"
+	_routesType    = "type.googleapis.com/envoy.api.v2.RouteConfiguration"
+)
+
+// Client polls an xDS control plane for peers and routing policy.
+type Client struct {
+	server string
+	opts   options
+}
+
+// New builds a Client for the control plane at the given base URL, for
+// example "http://pilot:15010".
+func New(server string, opts ...Option) *Client {
+	return &Client{
+		server: strings.TrimSuffix(server, "/"),
+		opts:   newOptions(opts),
+	}
+}
+
+type node struct {
+	ID      string `json:"id,omitempty"`
+	Cluster string `json:"cluster,omitempty"`
+}
+
+type discoveryRequest struct {
+	VersionInfo   string   `json:"versionInfo,omitempty"`
+	Node          node     `json:"node"`
+	ResourceNames []string `json:"resourceNames,omitempty"`
+	TypeURL       string   `json:"typeUrl"`
+	ResponseNonce string   `json:"responseNonce,omitempty"`
+}
+
+type discoveryResponse struct {
+	VersionInfo string            `json:"versionInfo"`
+	Resources   []json.RawMessage `json:"resources"`
+	TypeURL     string            `json:"typeUrl"`
+	Nonce       string            `json:"nonce"`
+}
+
+// fetcher polls a single discovery service for a set of resources, tracking
+// the version of the resources it last received.
+type fetcher struct {
+	client  *Client
+	path    string
+	typeURL string
+	names   []string
+	version string
+	nonce   string
+}
+
+func (c *Client) newFetcher(path, typeURL string, names ...string) *fetcher {
+	return &fetcher{client: c, path: path, typeURL: typeURL, names: names}
+}
+
+// reset forgets the version of the last received resources so that the next
+// fetch receives them again.
+func (f *fetcher) reset(names ...string) {
+	f.names = names
+	f.version = ""
+	f.nonce = ""
+}
+
+// fetch requests the resources from the control plane. It reports false if
+// the resources have not changed since the last fetch.
+func (f *fetcher) fetch(ctx context.Context) ([]json.RawMessage, bool, error) {
+	body, err := json.Marshal(discoveryRequest{
+		VersionInfo:   f.version,
+		Node:          node{ID: f.client.opts.nodeID, Cluster: f.client.opts.nodeCluster},
+		ResourceNames: f.names,
+		TypeURL:       f.typeURL,
+		ResponseNonce: f.nonce,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	req, err := http.NewRequest("POST", f.client.server+f.path, bytes.NewReader(body))
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := f.client.opts.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, false, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil, false, nil
+	default:
+		return nil, false, fmt.Errorf("xds: %v returned %v", f.path, res.Status)
+	}
+
+	var dres discoveryResponse
+	if err := json.NewDecoder(res.Body).Decode(&dres); err != nil {
+		return nil, false, fmt.Errorf("xds: failed to decode %v response: %v", f.path, err)
+	}
+	if dres.VersionInfo != "" && dres.VersionInfo == f.version {
+		return nil, false, nil
+	}
+	f.version = dres.VersionInfo
+	f.nonce = dres.Nonce
+	return dres.Resources, true, nil
+}
+
+// poller calls a poll function immediately after it starts and every poll
+// interval after that until it stops.
+type poller struct {
+	once   *lifecycle.Once
+	opts   *options
+	poll   func(context.Context) error
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newPoller(opts *options, poll func(context.Context) error) poller {
+	return poller{once: lifecycle.NewOnce(), opts: opts, poll: poll}
+}
+
+func (p *poller) start() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done = make(chan struct{})
+	go p.loop(ctx)
+	return nil
+}
+
+func (p *poller) loop(ctx context.Context) {
+	defer close(p.done)
+	for {
+		if err := p.poll(ctx); err != nil && ctx.Err() == nil {
+			p.opts.logger.Warn("failed to poll xDS control plane", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.opts.clock.After(p.opts.pollInterval):
+		}
+	}
+}
+
+func (p *poller) stop() error {
+	p.cancel()
+	<-p.done
+	return nil
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package xds discovers peers and routing policy from an xDS control plane.
+//
+// xDS is the family of discovery APIs served by Envoy control planes such as
+// Istio Pilot. This package speaks the v2 REST-JSON flavor of those APIs,
+// polling the control plane for changes, so that YARPC clients can join a
+// service mesh without running a sidecar proxy.
+//
+// A Client provides two integrations. Endpoints returns a peer list binder
+// that keeps a peer list up to date with the healthy endpoints of a cluster,
+// resolved through the cluster discovery service (CDS) and endpoint discovery
+// service (EDS).
+//
+// 	client := xds.New("http://pilot:15010", xds.Node("myservice-1", "myservice"))
+// 	chooser := peer.Bind(
+// 		roundrobin.New(httpTransport),
+// 		client.Endpoints("outbound|80||keyvalue.default.svc.cluster.local"),
+// 	)
+// 	outbound := httpTransport.NewOutbound(chooser)
+//
+// Routes returns unary outbound middleware that applies the timeout and retry
+// policy of the matching route from the route discovery service (RDS). Routes
+// are matched by virtual host domain against the service name of a request,
+// and by path against the procedure name. Procedures in the form
+// "Service::Method" match the gRPC path "/Service/Method"; other procedures
+// match "/" followed by the procedure name.
+//
+// 	routes := client.Routes("keyvalue")
+// 	routes.Start()
+// 	defer routes.Stop()
+//
+// Route retry conditions are mapped to YARPC error codes: "5xx" retries
+// internal, unknown, unimplemented, unavailable, data loss and deadline
+// exceeded errors; "gateway-error", "connect-failure", "reset" and
+// "refused-stream" retry unavailable errors (and deadline exceeded for
+// "gateway-error"); the gRPC conditions "cancelled", "deadline-exceeded",
+// "internal", "resource-exhausted" and "unavailable" retry the matching
+// codes.
+package xds
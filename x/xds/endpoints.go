@@ -0,0 +1,221 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xds
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"strconv"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/peer/hostport"
+)
+
+type cluster struct {
+	Name             string `json:"name"`
+	EDSClusterConfig *struct {
+		ServiceName string `json:"serviceName"`
+	} `json:"edsClusterConfig"`
+	LoadAssignment *clusterLoadAssignment `json:"loadAssignment"`
+}
+
+type clusterLoadAssignment struct {
+	ClusterName string `json:"clusterName"`
+	Endpoints   []struct {
+		LBEndpoints []lbEndpoint `json:"lbEndpoints"`
+	} `json:"endpoints"`
+}
+
+type lbEndpoint struct {
+	Endpoint struct {
+		Address struct {
+			SocketAddress struct {
+				Address   string `json:"address"`
+				PortValue uint32 `json:"portValue"`
+			} `json:"socketAddress"`
+		} `json:"address"`
+	} `json:"endpoint"`
+	HealthStatus string `json:"healthStatus"`
+}
+
+// hostPorts returns the addresses of the endpoints of the assignment that
+// may receive requests.
+func (a *clusterLoadAssignment) hostPorts() map[string]struct{} {
+	hostPorts := make(map[string]struct{})
+	for _, locality := range a.Endpoints {
+		for _, e := range locality.LBEndpoints {
+			switch e.HealthStatus {
+			case "", "UNKNOWN", "HEALTHY":
+			default:
+				continue
+			}
+			addr := e.Endpoint.Address.SocketAddress
+			if addr.Address == "" {
+				continue
+			}
+			hostPort := net.JoinHostPort(addr.Address, strconv.FormatUint(uint64(addr.PortValue), 10))
+			hostPorts[hostPort] = struct{}{}
+		}
+	}
+	return hostPorts
+}
+
+// Endpoints returns a peer list binder, suitable for peer.Bind, that keeps
+// the bound peer list up to date with the healthy endpoints of the named
+// cluster.
+//
+// The cluster is resolved through CDS. Clusters that embed their endpoints
+// use them directly and EDS clusters fetch their endpoints from EDS under
+// their EDS service name. If the control plane does not know the cluster,
+// its endpoints are fetched from EDS under the cluster name.
+func (c *Client) Endpoints(clusterName string) peer.Binder {
+	return func(pl peer.List) transport.Lifecycle {
+		u := &endpointsUpdater{
+			pl:        pl,
+			cluster:   clusterName,
+			edsName:   clusterName,
+			clusters:  c.newFetcher(_clustersPath, _clusterType, clusterName),
+			endpoints: c.newFetcher(_endpointsPath, _endpointsType, clusterName),
+			peers:     make(map[string]struct{}),
+		}
+		u.poller = newPoller(&c.opts, u.poll)
+		return u
+	}
+}
+
+type endpointsUpdater struct {
+	poller
+
+	pl        peer.List
+	cluster   string
+	edsName   string
+	static    bool
+	clusters  *fetcher
+	endpoints *fetcher
+	peers     map[string]struct{}
+}
+
+// Start starts polling the control plane for endpoints.
+func (u *endpointsUpdater) Start() error {
+	return u.once.Start(u.start)
+}
+
+// Stop stops polling the control plane and removes the endpoints from the
+// peer list.
+func (u *endpointsUpdater) Stop() error {
+	return u.once.Stop(func() error {
+		if err := u.stop(); err != nil {
+			return err
+		}
+		return u.update(nil)
+	})
+}
+
+// IsRunning returns whether the updater is polling the control plane.
+func (u *endpointsUpdater) IsRunning() bool {
+	return u.once.IsRunning()
+}
+
+func (u *endpointsUpdater) poll(ctx context.Context) error {
+	resources, changed, err := u.clusters.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	if changed {
+		if err := u.updateCluster(resources); err != nil {
+			return err
+		}
+	}
+	if u.static {
+		return nil
+	}
+
+	resources, changed, err = u.endpoints.fetch(ctx)
+	if err != nil || !changed {
+		return err
+	}
+	for _, raw := range resources {
+		var assignment clusterLoadAssignment
+		if err := json.Unmarshal(raw, &assignment); err != nil {
+			return err
+		}
+		if assignment.ClusterName == u.edsName {
+			return u.update(assignment.hostPorts())
+		}
+	}
+	return u.update(nil)
+}
+
+func (u *endpointsUpdater) updateCluster(resources []json.RawMessage) error {
+	edsName := u.cluster
+	wasStatic := u.static
+	u.static = false
+	for _, raw := range resources {
+		var c cluster
+		if err := json.Unmarshal(raw, &c); err != nil {
+			return err
+		}
+		if c.Name != u.cluster {
+			continue
+		}
+		if c.LoadAssignment != nil {
+			u.static = true
+			return u.update(c.LoadAssignment.hostPorts())
+		}
+		if c.EDSClusterConfig != nil && c.EDSClusterConfig.ServiceName != "" {
+			edsName = c.EDSClusterConfig.ServiceName
+		}
+	}
+	if edsName != u.edsName || wasStatic {
+		u.edsName = edsName
+		u.endpoints.reset(edsName)
+	}
+	return nil
+}
+
+// update applies the difference between the current peers and the given
+// addresses to the peer list.
+func (u *endpointsUpdater) update(hostPorts map[string]struct{}) error {
+	var updates peer.ListUpdates
+	for hostPort := range hostPorts {
+		if _, ok := u.peers[hostPort]; !ok {
+			updates.Additions = append(updates.Additions, hostport.PeerIdentifier(hostPort))
+		}
+	}
+	for hostPort := range u.peers {
+		if _, ok := hostPorts[hostPort]; !ok {
+			updates.Removals = append(updates.Removals, hostport.PeerIdentifier(hostPort))
+		}
+	}
+	if len(updates.Additions) == 0 && len(updates.Removals) == 0 {
+		return nil
+	}
+	if err := u.pl.Update(updates); err != nil {
+		return err
+	}
+	u.peers = hostPorts
+	if u.peers == nil {
+		u.peers = make(map[string]struct{})
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xds
+
+import (
+	"net/http"
+	"time"
+
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/zap"
+)
+
+const _defaultPollInterval = 10 * time.Second
+
+// Option customizes the behavior of an xDS Client.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(options *options) { f(options) }
+
+type options struct {
+	nodeID       string
+	nodeCluster  string
+	pollInterval time.Duration
+	httpClient   *http.Client
+	logger       *zap.Logger
+	clock        clock.Clock
+}
+
+func newOptions(opts []Option) options {
+	options := options{
+		pollInterval: _defaultPollInterval,
+		httpClient:   http.DefaultClient,
+		logger:       zap.NewNop(),
+		clock:        clock.NewReal(),
+	}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return options
+}
+
+// Node specifies the node identifier and cluster that the Client reports to
+// the control plane. Control planes use these to decide which configuration
+// the client receives.
+func Node(id, cluster string) Option {
+	return optionFunc(func(opts *options) {
+		opts.nodeID = id
+		opts.nodeCluster = cluster
+	})
+}
+
+// PollInterval specifies how often the Client polls the control plane for
+// changes. Defaults to 10 seconds.
+func PollInterval(interval time.Duration) Option {
+	return optionFunc(func(opts *options) {
+		opts.pollInterval = interval
+	})
+}
+
+// HTTPClient specifies the HTTP client used to reach the control plane.
+// Defaults to http.DefaultClient.
+func HTTPClient(client *http.Client) Option {
+	return optionFunc(func(opts *options) {
+		opts.httpClient = client
+	})
+}
+
+// Logger specifies a logger for failures to reach the control plane.
+func Logger(logger *zap.Logger) Option {
+	return optionFunc(func(opts *options) {
+		opts.logger = logger
+	})
+}
+
+// withClock specifies the clock used to schedule polls. This is used for
+// testing.
+func withClock(c clock.Clock) Option {
+	return optionFunc(func(opts *options) {
+		opts.clock = c
+	})
+}
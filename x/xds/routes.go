@@ -0,0 +1,339 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xds
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var _retryOnCodes = map[string][]yarpcerrors.Code{
+	"5xx": {
+		yarpcerrors.CodeInternal,
+		yarpcerrors.CodeUnknown,
+		yarpcerrors.CodeUnimplemented,
+		yarpcerrors.CodeUnavailable,
+		yarpcerrors.CodeDataLoss,
+		yarpcerrors.CodeDeadlineExceeded,
+	},
+	"gateway-error":      {yarpcerrors.CodeUnavailable, yarpcerrors.CodeDeadlineExceeded},
+	"connect-failure":    {yarpcerrors.CodeUnavailable},
+	"reset":              {yarpcerrors.CodeUnavailable},
+	"refused-stream":     {yarpcerrors.CodeUnavailable},
+	"cancelled":          {yarpcerrors.CodeCancelled},
+	"deadline-exceeded":  {yarpcerrors.CodeDeadlineExceeded},
+	"internal":           {yarpcerrors.CodeInternal},
+	"resource-exhausted": {yarpcerrors.CodeResourceExhausted},
+	"unavailable":        {yarpcerrors.CodeUnavailable},
+}
+
+type routeConfiguration struct {
+	Name         string        `json:"name"`
+	VirtualHosts []virtualHost `json:"virtualHosts"`
+}
+
+type virtualHost struct {
+	Domains []string `json:"domains"`
+	Routes  []struct {
+		Match struct {
+			Prefix *string `json:"prefix"`
+			Path   *string `json:"path"`
+		} `json:"match"`
+		Route *struct {
+			Timeout     string `json:"timeout"`
+			RetryPolicy *struct {
+				RetryOn       string `json:"retryOn"`
+				NumRetries    *int   `json:"numRetries"`
+				PerTryTimeout string `json:"perTryTimeout"`
+			} `json:"retryPolicy"`
+		} `json:"route"`
+	} `json:"routes"`
+}
+
+// route is the policy of a route of a virtual host.
+type route struct {
+	prefix        *string
+	path          *string
+	timeout       time.Duration
+	retries       int
+	perTryTimeout time.Duration
+	retryOn       map[yarpcerrors.Code]struct{}
+}
+
+func (r *route) matches(path string) bool {
+	if r.path != nil {
+		return *r.path == path
+	}
+	return r.prefix != nil && strings.HasPrefix(path, *r.prefix)
+}
+
+type host struct {
+	domains []string
+	routes  []route
+}
+
+func newHost(vh virtualHost) (host, error) {
+	h := host{domains: vh.Domains}
+	for _, r := range vh.Routes {
+		rt := route{prefix: r.Match.Prefix, path: r.Match.Path}
+		if r.Route != nil {
+			var err error
+			if rt.timeout, err = parseDuration(r.Route.Timeout); err != nil {
+				return host{}, err
+			}
+			if policy := r.Route.RetryPolicy; policy != nil {
+				// Envoy retries once if the number of retries is unspecified.
+				rt.retries = 1
+				if policy.NumRetries != nil {
+					rt.retries = *policy.NumRetries
+				}
+				if rt.perTryTimeout, err = parseDuration(policy.PerTryTimeout); err != nil {
+					return host{}, err
+				}
+				rt.retryOn = make(map[yarpcerrors.Code]struct{})
+				for _, cond := range strings.Split(policy.RetryOn, ",") {
+					for _, code := range _retryOnCodes[strings.TrimSpace(cond)] {
+						rt.retryOn[code] = struct{}{}
+					}
+				}
+			}
+		}
+		h.routes = append(h.routes, rt)
+	}
+	return h, nil
+}
+
+// matchDomain reports whether the virtual host serves the service, and
+// whether it does so through a wildcard.
+func (h *host) matchDomain(service string) (ok, wildcard bool) {
+	for _, domain := range h.domains {
+		switch {
+		case domain == service:
+			return true, false
+		case domain == "*",
+			strings.HasPrefix(domain, "*") && strings.HasSuffix(service, domain[1:]),
+			strings.HasSuffix(domain, "*") && strings.HasPrefix(service, domain[:len(domain)-1]):
+			wildcard = true
+		}
+	}
+	return wildcard, wildcard
+}
+
+// parseDuration parses durations in the JSON form of protobuf durations, for
+// example "1.5s".
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
+
+var _ middleware.UnaryOutbound = (*Routes)(nil)
+
+// Routes is unary outbound middleware that applies the timeout and retry
+// policy of routes from the control plane. Calls that match no route are
+// sent unchanged.
+//
+// Routes must be started to receive routes from the control plane.
+type Routes struct {
+	poller
+
+	fetcher *fetcher
+	name    string
+
+	mu    sync.RWMutex
+	hosts []host
+}
+
+// Routes builds middleware that applies the policy of the routes in the
+// named route configuration.
+func (c *Client) Routes(routeConfig string) *Routes {
+	r := &Routes{
+		fetcher: c.newFetcher(_routesPath, _routesType, routeConfig),
+		name:    routeConfig,
+	}
+	r.poller = newPoller(&c.opts, r.poll)
+	return r
+}
+
+// Start starts polling the control plane for routes.
+func (r *Routes) Start() error {
+	return r.once.Start(r.start)
+}
+
+// Stop stops polling the control plane. The last received routes remain in
+// effect.
+func (r *Routes) Stop() error {
+	return r.once.Stop(r.stop)
+}
+
+// IsRunning returns whether the middleware is polling the control plane.
+func (r *Routes) IsRunning() bool {
+	return r.once.IsRunning()
+}
+
+func (r *Routes) poll(ctx context.Context) error {
+	resources, changed, err := r.fetcher.fetch(ctx)
+	if err != nil || !changed {
+		return err
+	}
+
+	var hosts []host
+	for _, raw := range resources {
+		var config routeConfiguration
+		if err := json.Unmarshal(raw, &config); err != nil {
+			return err
+		}
+		if config.Name != r.name {
+			continue
+		}
+		for _, vh := range config.VirtualHosts {
+			h, err := newHost(vh)
+			if err != nil {
+				return err
+			}
+			hosts = append(hosts, h)
+		}
+	}
+
+	r.mu.Lock()
+	r.hosts = hosts
+	r.mu.Unlock()
+	return nil
+}
+
+// match finds the route for a request, preferring virtual hosts that name the
+// service exactly over wildcard virtual hosts.
+func (r *Routes) match(req *transport.Request) (route, bool) {
+	path := "/" + strings.Replace(req.Procedure, "::", "/", 1)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched *host
+	for i := range r.hosts {
+		ok, wildcard := r.hosts[i].matchDomain(req.Service)
+		if !ok {
+			continue
+		}
+		if !wildcard {
+			matched = &r.hosts[i]
+			break
+		}
+		if matched == nil {
+			matched = &r.hosts[i]
+		}
+	}
+	if matched == nil {
+		return route{}, false
+	}
+	for _, rt := range matched.routes {
+		if rt.matches(path) {
+			return rt, true
+		}
+	}
+	return route{}, false
+}
+
+// Call sends the request with the timeout and retry policy of the matching
+// route.
+func (r *Routes) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	rt, ok := r.match(req)
+	if !ok {
+		return out.Call(ctx, req)
+	}
+	if rt.retries == 0 {
+		return callWithTimeout(ctx, rt.timeout, req, out)
+	}
+
+	ctx, cancel := withTimeout(ctx, rt.timeout)
+	res, err := r.callWithRetries(ctx, rt, req, out)
+	return releaseOnClose(res, err, cancel)
+}
+
+func (r *Routes) callWithRetries(ctx context.Context, rt route, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	// The body is buffered so that it can be sent again on every attempt.
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		attemptReq := *req
+		attemptReq.Body = bytes.NewReader(body)
+		res, err := callWithTimeout(ctx, rt.perTryTimeout, &attemptReq, out)
+		if err == nil || attempt >= rt.retries || ctx.Err() != nil {
+			return res, err
+		}
+		if _, ok := rt.retryOn[yarpcerrors.FromError(err).Code()]; !ok {
+			return res, err
+		}
+	}
+}
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// callWithTimeout sends the request with the given timeout, if any.
+func callWithTimeout(ctx context.Context, timeout time.Duration, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	ctx, cancel := withTimeout(ctx, timeout)
+	res, err := out.Call(ctx, req)
+	return releaseOnClose(res, err, cancel)
+}
+
+// releaseOnClose defers cancelling the context of a call until the response
+// body is closed, since transports may stream the body from the connection.
+func releaseOnClose(res *transport.Response, err error, cancel context.CancelFunc) (*transport.Response, error) {
+	if err != nil || res == nil || res.Body == nil {
+		cancel()
+		return res, err
+	}
+	res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
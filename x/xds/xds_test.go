@@ -0,0 +1,272 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xds
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// controlPlane serves fixed xDS resources over REST-JSON.
+type controlPlane struct {
+	mu        sync.Mutex
+	version   string
+	resources map[string][]string // path -> JSON resources
+	requests  []discoveryRequest
+}
+
+func newControlPlane(t *testing.T) (*controlPlane, *httptest.Server) {
+	cp := &controlPlane{resources: make(map[string][]string)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req discoveryRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		cp.mu.Lock()
+		defer cp.mu.Unlock()
+		cp.requests = append(cp.requests, req)
+		if req.VersionInfo == cp.version {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		res := discoveryResponse{VersionInfo: cp.version, TypeURL: req.TypeURL, Nonce: "nonce-" + cp.version}
+		for _, resource := range cp.resources[r.URL.Path] {
+			res.Resources = append(res.Resources, json.RawMessage(resource))
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(res))
+	}))
+	return cp, server
+}
+
+func (cp *controlPlane) set(version string, resources map[string][]string) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.version = version
+	cp.resources = resources
+}
+
+type fakeList struct{ updates chan peer.ListUpdates }
+
+func (l *fakeList) Update(updates peer.ListUpdates) error {
+	l.updates <- updates
+	return nil
+}
+
+func (l *fakeList) next(t *testing.T) (additions, removals []string) {
+	select {
+	case updates := <-l.updates:
+		return identifiers(updates.Additions), identifiers(updates.Removals)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for peer list update")
+		return nil, nil
+	}
+}
+
+func identifiers(ids []peer.Identifier) []string {
+	var out []string
+	for _, id := range ids {
+		out = append(out, id.Identifier())
+	}
+	sort.Strings(out)
+	return out
+}
+
+const _assignment = `{
+	"@type": "type.googleapis.com/envoy.api.v2.ClusterLoadAssignment",
+	"clusterName": "keyvalue-eds",
+	"endpoints": [{"lbEndpoints": [
+		{"endpoint": {"address": {"socketAddress": {"address": "10.0.0.1", "portValue": 8080}}}, "healthStatus": "HEALTHY"},
+		{"endpoint": {"address": {"socketAddress": {"address": "10.0.0.2", "portValue": 8080}}}},
+		{"endpoint": {"address": {"socketAddress": {"address": "10.0.0.3", "portValue": 8080}}}, "healthStatus": "UNHEALTHY"}
+	]}]
+}`
+
+func TestEndpoints(t *testing.T) {
+	cp, server := newControlPlane(t)
+	defer server.Close()
+	cp.set("1", map[string][]string{
+		_clustersPath:  {`{"name": "keyvalue", "type": "EDS", "edsClusterConfig": {"serviceName": "keyvalue-eds"}}`},
+		_endpointsPath: {_assignment},
+	})
+
+	fakeClock := clock.NewFake()
+	client := New(server.URL, Node("node-1", "myservice"), PollInterval(time.Minute), withClock(fakeClock))
+	list := &fakeList{updates: make(chan peer.ListUpdates, 1)}
+	updater := client.Endpoints("keyvalue")(list)
+	require.NoError(t, updater.Start())
+
+	additions, removals := list.next(t)
+	assert.Equal(t, []string{"10.0.0.1:8080", "10.0.0.2:8080"}, additions)
+	assert.Empty(t, removals)
+
+	cp.set("2", map[string][]string{
+		_clustersPath: {`{"name": "keyvalue", "type": "EDS", "edsClusterConfig": {"serviceName": "keyvalue-eds"}}`},
+		_endpointsPath: {`{
+			"clusterName": "keyvalue-eds",
+			"endpoints": [{"lbEndpoints": [
+				{"endpoint": {"address": {"socketAddress": {"address": "10.0.0.2", "portValue": 8080}}}},
+				{"endpoint": {"address": {"socketAddress": {"address": "10.0.0.4", "portValue": 8080}}}}
+			]}]
+		}`},
+	})
+	fakeClock.Add(time.Minute)
+
+	additions, removals = list.next(t)
+	assert.Equal(t, []string{"10.0.0.4:8080"}, additions)
+	assert.Equal(t, []string{"10.0.0.1:8080"}, removals)
+
+	require.NoError(t, updater.Stop())
+	additions, removals = list.next(t)
+	assert.Empty(t, additions)
+	assert.Equal(t, []string{"10.0.0.2:8080", "10.0.0.4:8080"}, removals)
+
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	require.NotEmpty(t, cp.requests)
+	assert.Equal(t, node{ID: "node-1", Cluster: "myservice"}, cp.requests[0].Node)
+	assert.Equal(t, []string{"keyvalue"}, cp.requests[0].ResourceNames)
+	assert.Equal(t, _clusterType, cp.requests[0].TypeURL)
+}
+
+func TestEndpointsStaticCluster(t *testing.T) {
+	cp, server := newControlPlane(t)
+	defer server.Close()
+	cp.set("1", map[string][]string{
+		_clustersPath: {`{"name": "keyvalue", "loadAssignment": ` + _assignment + `}`},
+	})
+
+	list := &fakeList{updates: make(chan peer.ListUpdates, 1)}
+	updater := New(server.URL).Endpoints("keyvalue")(list)
+	require.NoError(t, updater.Start())
+	defer updater.Stop()
+
+	additions, _ := list.next(t)
+	assert.Equal(t, []string{"10.0.0.1:8080", "10.0.0.2:8080"}, additions)
+}
+
+func TestRoutes(t *testing.T) {
+	cp, server := newControlPlane(t)
+	defer server.Close()
+	cp.set("1", map[string][]string{
+		_routesPath: {`{
+			"name": "keyvalue",
+			"virtualHosts": [
+				{"domains": ["*"], "routes": [{"match": {"prefix": "/"}, "route": {"timeout": "5s"}}]},
+				{"domains": ["keyvalue"], "routes": [
+					{"match": {"path": "/KeyValue/Get"}, "route": {
+						"timeout": "2s",
+						"retryPolicy": {"retryOn": "unavailable,internal", "numRetries": 2, "perTryTimeout": "0.5s"}
+					}},
+					{"match": {"prefix": "/KeyValue/"}, "route": {"timeout": "1s"}}
+				]}
+			]
+		}`},
+	})
+
+	routes := New(server.URL).Routes("keyvalue")
+	require.NoError(t, routes.poll(context.Background()))
+
+	assertDeadline := func(ctx context.Context, timeout time.Duration) {
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok, "expected a deadline")
+		assert.WithinDuration(t, time.Now().Add(timeout), deadline, 100*time.Millisecond)
+	}
+
+	t.Run("retries", func(t *testing.T) {
+		out := transporttest.NewFakeOutbound()
+		out.ExpectCall("KeyValue::Get").Times(2).Do(func(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+			assertDeadline(ctx, 500*time.Millisecond)
+			return nil, yarpcerrors.UnavailableErrorf("try again")
+		})
+		out.ExpectCall("KeyValue::Get").Return(&transport.Response{}, nil)
+
+		_, err := routes.Call(context.Background(), &transport.Request{
+			Service:   "keyvalue",
+			Procedure: "KeyValue::Get",
+			Body:      bytes.NewReader([]byte("body")),
+		}, out)
+		require.NoError(t, err)
+		out.Verify(t)
+		for _, req := range out.Calls() {
+			body, err := ioutil.ReadAll(req.Body)
+			require.NoError(t, err)
+			assert.Equal(t, "body", string(body))
+		}
+	})
+
+	t.Run("not retryable", func(t *testing.T) {
+		out := transporttest.NewFakeOutbound()
+		out.ExpectCall("KeyValue::Get").Return(nil, yarpcerrors.InvalidArgumentErrorf("bad"))
+
+		_, err := routes.Call(context.Background(), &transport.Request{
+			Service:   "keyvalue",
+			Procedure: "KeyValue::Get",
+		}, out)
+		assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.FromError(err).Code())
+		assert.Len(t, out.Calls(), 1)
+	})
+
+	tests := []struct {
+		service   string
+		procedure string
+		timeout   time.Duration
+	}{
+		{service: "keyvalue", procedure: "KeyValue::Set", timeout: time.Second},
+		{service: "keyvalue", procedure: "other", timeout: 0},
+		{service: "other", procedure: "KeyValue::Set", timeout: 5 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.service+"/"+tt.procedure, func(t *testing.T) {
+			out := transporttest.NewFakeOutbound()
+			out.ExpectCall(tt.procedure).Do(func(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+				if tt.timeout == 0 {
+					_, ok := ctx.Deadline()
+					assert.False(t, ok, "expected no deadline")
+				} else {
+					assertDeadline(ctx, tt.timeout)
+				}
+				return &transport.Response{}, nil
+			})
+
+			_, err := routes.Call(context.Background(), &transport.Request{
+				Service:   tt.service,
+				Procedure: tt.procedure,
+			}, out)
+			require.NoError(t, err)
+			out.Verify(t)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpcbench
+
+import (
+	"fmt"
+	"time"
+)
+
+// Transport identifies a transport to benchmark.
+type Transport string
+
+// Transports that may be benchmarked.
+const (
+	HTTP     Transport = "http"
+	GRPC     Transport = "grpc"
+	TChannel Transport = "tchannel"
+)
+
+// AllTransports is the list of all transports that may be benchmarked.
+var AllTransports = []Transport{HTTP, GRPC, TChannel}
+
+// Encoding identifies an encoding to benchmark.
+type Encoding string
+
+// Encodings that may be benchmarked.
+const (
+	Raw      Encoding = "raw"
+	JSON     Encoding = "json"
+	Protobuf Encoding = "proto"
+)
+
+// AllEncodings is the list of all encodings that may be benchmarked.
+var AllEncodings = []Encoding{Raw, JSON, Protobuf}
+
+// Config configures a benchmark.
+type Config struct {
+	// Transport used between the client and the server.
+	Transport Transport
+
+	// Encoding of the requests and responses.
+	Encoding Encoding
+
+	// Number of concurrent callers. Defaults to 1.
+	Concurrency int
+
+	// Size of the payload carried by every request and response, in bytes.
+	// Defaults to 1024.
+	PayloadSize int
+
+	// Number of requests to make. If zero, requests are made until Duration
+	// has elapsed.
+	Requests int
+
+	// Duration of the benchmark, if Requests is zero. Defaults to one
+	// second.
+	Duration time.Duration
+
+	// Timeout of every request. Defaults to one second.
+	Timeout time.Duration
+}
+
+// String returns a name for the configuration suitable for use as the name
+// of a sub-benchmark.
+func (c Config) String() string {
+	c = c.withDefaults()
+	return fmt.Sprintf("%v/%v/concurrency=%d/payload=%d", c.Transport, c.Encoding, c.Concurrency, c.PayloadSize)
+}
+
+func (c Config) withDefaults() Config {
+	if c.Concurrency <= 0 {
+		c.Concurrency = 1
+	}
+	if c.PayloadSize <= 0 {
+		c.PayloadSize = 1024
+	}
+	if c.Requests <= 0 && c.Duration <= 0 {
+		c.Duration = time.Second
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = time.Second
+	}
+	return c
+}
+
+// Matrix returns a configuration for every combination of the given
+// transports, encodings, concurrencies, and payload sizes.
+func Matrix(transports []Transport, encodings []Encoding, concurrencies []int, payloadSizes []int) []Config {
+	configs := make([]Config, 0, len(transports)*len(encodings)*len(concurrencies)*len(payloadSizes))
+	for _, t := range transports {
+		for _, e := range encodings {
+			for _, c := range concurrencies {
+				for _, p := range payloadSizes {
+					configs = append(configs, Config{
+						Transport:   t,
+						Encoding:    e,
+						Concurrency: c,
+						PayloadSize: p,
+					})
+				}
+			}
+		}
+	}
+	return configs
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package yarpcbench measures the performance of YARPC transports and
+// encodings so that regressions can be caught between releases.
+//
+// Every benchmark starts a server dispatcher that echoes requests and a
+// client dispatcher connected to it over a single transport, in the same
+// process, and then drives unary requests of a given payload size through
+// them with a given number of concurrent callers.
+//
+// Use Run to measure a configuration and report its throughput, latency
+// distribution, and allocations:
+//
+// 	result, err := yarpcbench.Run(yarpcbench.Config{
+// 		Transport:   yarpcbench.GRPC,
+// 		Encoding:    yarpcbench.Protobuf,
+// 		Concurrency: 8,
+// 		PayloadSize: 4096,
+// 		Requests:    10000,
+// 	})
+// 	fmt.Println(result)
+//
+// Use Benchmark to run a configuration from a Go benchmark, and Matrix to
+// build every combination of transports, encodings, concurrencies, and
+// payload sizes:
+//
+// 	func BenchmarkYARPC(b *testing.B) {
+// 		for _, cfg := range yarpcbench.Matrix(yarpcbench.AllTransports, yarpcbench.AllEncodings, []int{1, 16}, []int{256, 65536}) {
+// 			b.Run(cfg.String(), func(b *testing.B) {
+// 				yarpcbench.Benchmark(b, cfg)
+// 			})
+// 		}
+// 	}
+//
+// Because the client and the server share a process, allocations include
+// both sides of every request.
+package yarpcbench
@@ -0,0 +1,190 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpcbench
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"go.uber.org/multierr"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/encoding/json"
+	"go.uber.org/yarpc/encoding/protobuf"
+	"go.uber.org/yarpc/encoding/raw"
+)
+
+const (
+	_serverName = "yarpcbench"
+	_clientName = "yarpcbench-client"
+
+	_rawProcedure  = "echo-raw"
+	_jsonProcedure = "echo-json"
+	_protoService  = "uber.yarpc.yarpcbench.Echo"
+	_protoMethod   = "Echo"
+)
+
+// jsonBody is the request and response body of the JSON echo procedure.
+type jsonBody struct {
+	Payload string `json:"payload"`
+}
+
+// pair is a server dispatcher and a client dispatcher connected to it.
+type pair struct {
+	server *yarpc.Dispatcher
+	client *yarpc.Dispatcher
+
+	// call makes one request to the server and verifies the response.
+	call func(context.Context) error
+}
+
+// startPair starts a server and a client for the given configuration.
+func startPair(cfg Config) (_ *pair, err error) {
+	inbound, addr, err := newInbound(cfg.Transport)
+	if err != nil {
+		return nil, err
+	}
+	server := yarpc.NewDispatcher(yarpc.Config{
+		Name:     _serverName,
+		Inbounds: yarpc.Inbounds{inbound},
+	})
+	server.Register(procedures())
+	if err := server.Start(); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			err = multierr.Append(err, server.Stop())
+		}
+	}()
+
+	outbound, err := newOutbound(cfg.Transport, addr())
+	if err != nil {
+		return nil, err
+	}
+	client := yarpc.NewDispatcher(yarpc.Config{
+		Name: _clientName,
+		Outbounds: yarpc.Outbounds{
+			_serverName: {Unary: outbound},
+		},
+	})
+	if err := client.Start(); err != nil {
+		return nil, err
+	}
+
+	call, err := newCall(cfg, client.ClientConfig(_serverName))
+	if err != nil {
+		return nil, multierr.Append(err, client.Stop())
+	}
+	return &pair{server: server, client: client, call: call}, nil
+}
+
+// Stop stops the client and the server.
+func (p *pair) Stop() error {
+	return multierr.Append(p.client.Stop(), p.server.Stop())
+}
+
+// procedures returns an echo procedure for every encoding.
+func procedures() []transport.Procedure {
+	var procedures []transport.Procedure
+	procedures = append(procedures, raw.Procedure(_rawProcedure, func(_ context.Context, body []byte) ([]byte, error) {
+		return body, nil
+	})...)
+	procedures = append(procedures, json.Procedure(_jsonProcedure, func(_ context.Context, body *jsonBody) (*jsonBody, error) {
+		return body, nil
+	})...)
+	procedures = append(procedures, protobuf.BuildProcedures(protobuf.BuildProceduresParams{
+		ServiceName: _protoService,
+		UnaryHandlerParams: []protobuf.BuildProceduresUnaryHandlerParams{
+			{
+				MethodName: _protoMethod,
+				Handler: protobuf.NewUnaryHandler(protobuf.UnaryHandlerParams{
+					Handle: func(_ context.Context, request proto.Message) (proto.Message, error) {
+						return request, nil
+					},
+					NewRequest: newBytesValue,
+				}),
+			},
+		},
+	})...)
+	return procedures
+}
+
+func newBytesValue() proto.Message {
+	return &types.BytesValue{}
+}
+
+// newCall returns a function that calls the echo procedure of the given
+// encoding with a payload of the configured size.
+func newCall(cfg Config, cc transport.ClientConfig) (func(context.Context) error, error) {
+	payload := bytes.Repeat([]byte("a"), cfg.PayloadSize)
+
+	switch cfg.Encoding {
+	case Raw:
+		client := raw.New(cc)
+		return func(ctx context.Context) error {
+			res, err := client.Call(ctx, _rawProcedure, payload)
+			if err != nil {
+				return err
+			}
+			return checkSize(len(res), len(payload))
+		}, nil
+	case JSON:
+		client := json.New(cc)
+		req := &jsonBody{Payload: string(payload)}
+		return func(ctx context.Context) error {
+			var res jsonBody
+			if err := client.Call(ctx, _jsonProcedure, req, &res); err != nil {
+				return err
+			}
+			return checkSize(len(res.Payload), len(payload))
+		}, nil
+	case Protobuf:
+		client := protobuf.NewClient(protobuf.ClientParams{
+			ServiceName:  _protoService,
+			ClientConfig: cc,
+		})
+		req := &types.BytesValue{Value: payload}
+		return func(ctx context.Context) error {
+			res, err := client.Call(ctx, _protoMethod, req, newBytesValue)
+			if err != nil {
+				return err
+			}
+			value, ok := res.(*types.BytesValue)
+			if !ok {
+				return fmt.Errorf("unexpected response type %T", res)
+			}
+			return checkSize(len(value.Value), len(payload))
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", cfg.Encoding)
+	}
+}
+
+func checkSize(got, want int) error {
+	if got != want {
+		return fmt.Errorf("response payload has %d bytes, expected %d", got, want)
+	}
+	return nil
+}
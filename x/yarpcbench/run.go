@@ -0,0 +1,231 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpcbench
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/atomic"
+	"go.uber.org/multierr"
+)
+
+// Result is the outcome of a benchmark.
+type Result struct {
+	Config Config
+
+	// Number of requests made, and how many of them failed.
+	Requests int
+	Errors   int
+
+	// Wall time taken by all requests.
+	Elapsed time.Duration
+
+	// Latency distribution of successful and failed requests.
+	Latency Latency
+
+	// Heap allocations per request, on the client and the server combined.
+	AllocsPerRequest float64
+	BytesPerRequest  float64
+}
+
+// Latency summarizes a latency distribution.
+type Latency struct {
+	Min  time.Duration
+	Mean time.Duration
+	P50  time.Duration
+	P90  time.Duration
+	P99  time.Duration
+	Max  time.Duration
+}
+
+// Throughput returns the number of requests per second.
+func (r Result) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Requests) / r.Elapsed.Seconds()
+}
+
+// String returns a one-line summary of the result.
+func (r Result) String() string {
+	return fmt.Sprintf(
+		"%v: %d requests (%d errors) in %v, %.0f req/s, latency min=%v mean=%v p50=%v p90=%v p99=%v max=%v, %.1f allocs/req, %.0f B/req",
+		r.Config, r.Requests, r.Errors, r.Elapsed, r.Throughput(),
+		r.Latency.Min, r.Latency.Mean, r.Latency.P50, r.Latency.P90, r.Latency.P99, r.Latency.Max,
+		r.AllocsPerRequest, r.BytesPerRequest,
+	)
+}
+
+// Run runs a benchmark with the given configuration. It returns an error if
+// the client and server could not be started or stopped. Failed requests
+// are counted in the result.
+func Run(cfg Config) (_ Result, err error) {
+	cfg = cfg.withDefaults()
+	p, err := startPair(cfg)
+	if err != nil {
+		return Result{}, err
+	}
+	defer func() { err = multierr.Append(err, p.Stop()) }()
+
+	// Warm up connections so that they are not part of the measurements.
+	if err := callOnce(p, cfg.Timeout); err != nil {
+		return Result{}, fmt.Errorf("warm-up request failed: %v", err)
+	}
+
+	var (
+		latencies = make([][]time.Duration, cfg.Concurrency)
+		failures  = make([]int, cfg.Concurrency)
+		count     atomic.Int64
+		before    runtime.MemStats
+		after     runtime.MemStats
+	)
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	deadline := start.Add(cfg.Duration)
+
+	drive(cfg.Concurrency, func(i int) bool {
+		if cfg.Requests > 0 {
+			if count.Inc() > int64(cfg.Requests) {
+				return false
+			}
+		} else if !time.Now().Before(deadline) {
+			return false
+		}
+
+		callStart := time.Now()
+		if err := callOnce(p, cfg.Timeout); err != nil {
+			failures[i]++
+		}
+		latencies[i] = append(latencies[i], time.Since(callStart))
+		return true
+	})
+
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	result := Result{Config: cfg, Elapsed: elapsed}
+	var all []time.Duration
+	for i := range latencies {
+		all = append(all, latencies[i]...)
+		result.Errors += failures[i]
+	}
+	result.Requests = len(all)
+	result.Latency = summarize(all)
+	if result.Requests > 0 {
+		result.AllocsPerRequest = float64(after.Mallocs-before.Mallocs) / float64(result.Requests)
+		result.BytesPerRequest = float64(after.TotalAlloc-before.TotalAlloc) / float64(result.Requests)
+	}
+	return result, nil
+}
+
+// Benchmark runs the given configuration as a Go benchmark, making b.N
+// requests with the configured concurrency. Requests and Duration are
+// ignored.
+func Benchmark(b *testing.B, cfg Config) {
+	cfg = cfg.withDefaults()
+	p, err := startPair(cfg)
+	if err != nil {
+		b.Fatalf("failed to start %v: %v", cfg, err)
+	}
+	defer func() {
+		if err := p.Stop(); err != nil {
+			b.Errorf("failed to stop %v: %v", cfg, err)
+		}
+	}()
+
+	if err := callOnce(p, cfg.Timeout); err != nil {
+		b.Fatalf("warm-up request failed: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.SetBytes(int64(cfg.PayloadSize))
+	b.ResetTimer()
+
+	var count atomic.Int64
+	drive(cfg.Concurrency, func(int) bool {
+		if count.Inc() > int64(b.N) {
+			return false
+		}
+		if err := callOnce(p, cfg.Timeout); err != nil {
+			b.Errorf("request failed: %v", err)
+		}
+		return true
+	})
+}
+
+// drive calls f from the given number of goroutines until it returns false
+// in all of them. f is given the index of the calling goroutine.
+func drive(concurrency int, f func(i int) bool) {
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for f(i) {
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func callOnce(p *pair, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return p.call(ctx)
+}
+
+// summarize computes the latency distribution of the given latencies.
+func summarize(latencies []time.Duration) Latency {
+	if len(latencies) == 0 {
+		return Latency{}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	var total time.Duration
+	for _, l := range latencies {
+		total += l
+	}
+	return Latency{
+		Min:  latencies[0],
+		Mean: total / time.Duration(len(latencies)),
+		P50:  percentile(latencies, 50),
+		P90:  percentile(latencies, 90),
+		P99:  percentile(latencies, 99),
+		Max:  latencies[len(latencies)-1],
+	}
+}
+
+// percentile returns the pth percentile of the given sorted latencies using
+// the nearest-rank method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpcbench
+
+import (
+	"fmt"
+	"net"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/transport/grpc"
+	"go.uber.org/yarpc/transport/http"
+	"go.uber.org/yarpc/transport/tchannel"
+)
+
+// newInbound builds an inbound of the given transport on a local port. The
+// returned function reports the address of the inbound after it has been
+// started.
+func newInbound(t Transport) (transport.Inbound, func() string, error) {
+	switch t {
+	case HTTP:
+		inbound := http.NewTransport().NewInbound("127.0.0.1:0")
+		return inbound, func() string { return "http://" + inbound.Addr().String() }, nil
+	case GRPC:
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return nil, nil, err
+		}
+		inbound := grpc.NewTransport().NewInbound(listener)
+		return inbound, func() string { return listener.Addr().String() }, nil
+	case TChannel:
+		trans, err := tchannel.NewTransport(
+			tchannel.ServiceName(_serverName),
+			tchannel.ListenAddr("127.0.0.1:0"),
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		return trans.NewInbound(), trans.ListenAddr, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown transport %q", t)
+	}
+}
+
+// newOutbound builds an outbound of the given transport to the given
+// address.
+func newOutbound(t Transport, addr string) (transport.UnaryOutbound, error) {
+	switch t {
+	case HTTP:
+		return http.NewTransport().NewSingleOutbound(addr), nil
+	case GRPC:
+		return grpc.NewTransport().NewSingleOutbound(addr), nil
+	case TChannel:
+		trans, err := tchannel.NewTransport(tchannel.ServiceName(_clientName))
+		if err != nil {
+			return nil, err
+		}
+		return trans.NewSingleOutbound(addr), nil
+	default:
+		return nil, fmt.Errorf("unknown transport %q", t)
+	}
+}
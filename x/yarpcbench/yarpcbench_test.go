@@ -0,0 +1,106 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpcbench
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	for _, cfg := range Matrix(AllTransports, AllEncodings, []int{1, 4}, []int{16}) {
+		cfg.Requests = 20
+		t.Run(cfg.String(), func(t *testing.T) {
+			result, err := Run(cfg)
+			require.NoError(t, err)
+			assert.Equal(t, 20, result.Requests)
+			assert.Equal(t, 0, result.Errors)
+			assert.True(t, result.Elapsed > 0, "elapsed time must be positive")
+			assert.True(t, result.Throughput() > 0, "throughput must be positive")
+			assert.True(t, result.Latency.Min <= result.Latency.P50, "min must not exceed p50")
+			assert.True(t, result.Latency.P50 <= result.Latency.P99, "p50 must not exceed p99")
+			assert.True(t, result.Latency.P99 <= result.Latency.Max, "p99 must not exceed max")
+			assert.True(t, result.AllocsPerRequest > 0, "requests must allocate")
+			assert.Contains(t, result.String(), cfg.String())
+		})
+	}
+}
+
+func TestRunDuration(t *testing.T) {
+	result, err := Run(Config{
+		Transport:   HTTP,
+		Encoding:    Raw,
+		Concurrency: 2,
+		Duration:    50 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	assert.True(t, result.Requests > 0, "requests must be made")
+	assert.True(t, result.Elapsed >= 50*time.Millisecond, "benchmark must last for its duration")
+	assert.Equal(t, 1024, result.Config.PayloadSize)
+}
+
+func TestRunInvalidConfig(t *testing.T) {
+	_, err := Run(Config{Transport: "carrier-pigeon", Encoding: Raw})
+	assert.EqualError(t, err, `unknown transport "carrier-pigeon"`)
+
+	_, err = Run(Config{Transport: HTTP, Encoding: "morse", Requests: 1})
+	assert.EqualError(t, err, `unknown encoding "morse"`)
+}
+
+func TestMatrix(t *testing.T) {
+	configs := Matrix([]Transport{HTTP, GRPC}, []Encoding{Raw}, []int{1, 8}, []int{64})
+	assert.Equal(t, []Config{
+		{Transport: HTTP, Encoding: Raw, Concurrency: 1, PayloadSize: 64},
+		{Transport: HTTP, Encoding: Raw, Concurrency: 8, PayloadSize: 64},
+		{Transport: GRPC, Encoding: Raw, Concurrency: 1, PayloadSize: 64},
+		{Transport: GRPC, Encoding: Raw, Concurrency: 8, PayloadSize: 64},
+	}, configs)
+	assert.Equal(t, "http/raw/concurrency=1/payload=64", configs[0].String())
+	assert.Equal(t, "grpc/proto/concurrency=1/payload=1024", Config{Transport: GRPC, Encoding: Protobuf}.String())
+}
+
+func TestSummarize(t *testing.T) {
+	assert.Equal(t, Latency{}, summarize(nil))
+
+	var latencies []time.Duration
+	for i := 100; i > 0; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, Latency{
+		Min:  time.Millisecond,
+		Mean: 50500 * time.Microsecond,
+		P50:  50 * time.Millisecond,
+		P90:  90 * time.Millisecond,
+		P99:  99 * time.Millisecond,
+		Max:  100 * time.Millisecond,
+	}, summarize(latencies))
+}
+
+func BenchmarkYARPC(b *testing.B) {
+	for _, cfg := range Matrix(AllTransports, AllEncodings, []int{1, 16}, []int{256, 65536}) {
+		b.Run(cfg.String(), func(b *testing.B) {
+			Benchmark(b, cfg)
+		})
+	}
+}
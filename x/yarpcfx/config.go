@@ -0,0 +1,116 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpcfx
+
+import (
+	"go.uber.org/fx"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/yarpcconfig"
+)
+
+// ConfigParams defines the dependencies of the constructors returned by
+// Config. Specs in the "yarpcfx" value groups are registered with the
+// Configurator, in addition to the specs of all yarpcconfig plugins.
+type ConfigParams struct {
+	fx.In
+
+	Transports       []yarpcconfig.TransportSpec       `group:"yarpcfx"`
+	PeerChoosers     []yarpcconfig.PeerChooserSpec     `group:"yarpcfx"`
+	PeerLists        []yarpcconfig.PeerListSpec        `group:"yarpcfx"`
+	PeerListUpdaters []yarpcconfig.PeerListUpdaterSpec `group:"yarpcfx"`
+}
+
+// Config provides the yarpc.Config of the service with the given name,
+// loaded by yarpcconfig from data, to an Fx application. data is the YARPC
+// configuration parsed into a map[string]interface{} or
+// map[interface{}]interface{}, as accepted by Configurator.LoadConfig.
+//
+// Transports, peer choosers, peer lists and peer list updaters are provided
+// to the "yarpcfx" value groups or registered as yarpcconfig plugins.
+//
+// 	fx.Provide(
+// 		yarpcfx.Config("myservice", data),
+// 		yarpcfx.TransportSpec(http.TransportSpec()),
+// 	)
+func Config(serviceName string, data interface{}, opts ...yarpcconfig.Option) interface{} {
+	return func(p ConfigParams) (yarpc.Config, error) {
+		opts := append([]yarpcconfig.Option{yarpcconfig.RegisteredPlugins()}, opts...)
+		cfg := yarpcconfig.New(opts...)
+		for _, s := range p.Transports {
+			if err := cfg.RegisterTransport(s); err != nil {
+				return yarpc.Config{}, err
+			}
+		}
+		for _, s := range p.PeerChoosers {
+			if err := cfg.RegisterPeerChooser(s); err != nil {
+				return yarpc.Config{}, err
+			}
+		}
+		for _, s := range p.PeerLists {
+			if err := cfg.RegisterPeerList(s); err != nil {
+				return yarpc.Config{}, err
+			}
+		}
+		for _, s := range p.PeerListUpdaters {
+			if err := cfg.RegisterPeerListUpdater(s); err != nil {
+				return yarpc.Config{}, err
+			}
+		}
+		return cfg.LoadConfig(serviceName, data)
+	}
+}
+
+// TransportSpec provides the given TransportSpec to the "yarpcfx" value
+// group, to be registered with the Configurator used by Config.
+func TransportSpec(s yarpcconfig.TransportSpec) fx.Annotated {
+	return fx.Annotated{
+		Group:  "yarpcfx",
+		Target: func() yarpcconfig.TransportSpec { return s },
+	}
+}
+
+// PeerChooserSpec provides the given PeerChooserSpec to the "yarpcfx" value
+// group, to be registered with the Configurator used by Config.
+func PeerChooserSpec(s yarpcconfig.PeerChooserSpec) fx.Annotated {
+	return fx.Annotated{
+		Group:  "yarpcfx",
+		Target: func() yarpcconfig.PeerChooserSpec { return s },
+	}
+}
+
+// PeerListSpec provides the given PeerListSpec to the "yarpcfx" value group,
+// to be registered with the Configurator used by Config.
+func PeerListSpec(s yarpcconfig.PeerListSpec) fx.Annotated {
+	return fx.Annotated{
+		Group:  "yarpcfx",
+		Target: func() yarpcconfig.PeerListSpec { return s },
+	}
+}
+
+// PeerListUpdaterSpec provides the given PeerListUpdaterSpec to the
+// "yarpcfx" value group, to be registered with the Configurator used by
+// Config.
+func PeerListUpdaterSpec(s yarpcconfig.PeerListUpdaterSpec) fx.Annotated {
+	return fx.Annotated{
+		Group:  "yarpcfx",
+		Target: func() yarpcconfig.PeerListUpdaterSpec { return s },
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package yarpcfx wires YARPC into Fx applications.
+//
+// Module provides a *yarpc.Dispatcher that is started and stopped with the
+// application, together with the yarpc.ClientConfig that the Fx modules
+// generated for Thrift and Protobuf services depend on. The Dispatcher is
+// built from a yarpc.Config, which may be provided directly or loaded with
+// yarpcconfig by Config.
+//
+// 	fx.New(
+// 		yarpcfx.Module,
+// 		fx.Provide(
+// 			yarpcfx.Config("myservice", cfg),
+// 			yarpcfx.ClientConfig("keyvalue"),
+// 			yarpcfx.Procedures(procedures...),
+// 			kvfx.Server(),
+// 		),
+// 	)
+//
+// Procedures are registered with the Dispatcher from the "yarpcfx" value
+// group before it starts. The server modules generated for Thrift and
+// Protobuf services provide their procedures to this group, and Procedures
+// provides any others.
+package yarpcfx
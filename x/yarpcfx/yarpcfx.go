@@ -0,0 +1,109 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpcfx
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+)
+
+// Module provides a *yarpc.Dispatcher and a yarpc.ClientConfig to an Fx
+// application. The Dispatcher starts and stops with the application, even
+// if nothing depends on it.
+var Module = fx.Options(
+	fx.Provide(NewDispatcher),
+	fx.Invoke(func(*yarpc.Dispatcher) {}),
+)
+
+// DispatcherParams defines the dependencies of NewDispatcher.
+type DispatcherParams struct {
+	fx.In
+
+	Lifecycle fx.Lifecycle
+	Config    yarpc.Config
+
+	Procedures [][]transport.Procedure `group:"yarpcfx"`
+}
+
+// DispatcherResult defines the values provided by NewDispatcher.
+type DispatcherResult struct {
+	fx.Out
+
+	Dispatcher *yarpc.Dispatcher
+
+	// Provider lets the Fx modules generated for Thrift and Protobuf
+	// services build clients for outbounds of the Dispatcher.
+	Provider yarpc.ClientConfig
+}
+
+// NewDispatcher builds a Dispatcher from the yarpc.Config of the
+// application, registers the procedures of the "yarpcfx" value group with
+// it, and ties it to the lifecycle of the application.
+func NewDispatcher(p DispatcherParams) DispatcherResult {
+	d := yarpc.NewDispatcher(p.Config)
+	for _, procedures := range p.Procedures {
+		d.Register(procedures)
+	}
+	p.Lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error { return d.Start() },
+		OnStop:  func(context.Context) error { return d.Stop() },
+	})
+	return DispatcherResult{Dispatcher: d, Provider: d}
+}
+
+// ClientConfig provides the transport.ClientConfig for the outbound with
+// the given name, named after it, to an Fx application.
+//
+// 	fx.Provide(yarpcfx.ClientConfig("keyvalue"))
+//
+// 	type params struct {
+// 		fx.In
+//
+// 		KeyValue transport.ClientConfig `name:"keyvalue"`
+// 	}
+func ClientConfig(name string) fx.Annotated {
+	return fx.Annotated{
+		Name: name,
+		Target: func(provider yarpc.ClientConfig) transport.ClientConfig {
+			return provider.ClientConfig(name)
+		},
+	}
+}
+
+// ProceduresResult defines the output of Procedures.
+type ProceduresResult struct {
+	fx.Out
+
+	Procedures []transport.Procedure `group:"yarpcfx"`
+}
+
+// Procedures provides the given procedures to the "yarpcfx" value group, to
+// be registered with the Dispatcher.
+//
+// 	fx.Provide(yarpcfx.Procedures(raw.Procedure("echo", echo)...))
+func Procedures(procedures ...transport.Procedure) interface{} {
+	return func() ProceduresResult {
+		return ProceduresResult{Procedures: procedures}
+	}
+}
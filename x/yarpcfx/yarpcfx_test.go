@@ -0,0 +1,121 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpcfx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/encoding/raw"
+	"go.uber.org/yarpc/yarpcconfig"
+	"go.uber.org/yarpc/yarpctest"
+)
+
+func TestModule(t *testing.T) {
+	echo := func(_ context.Context, body []byte) ([]byte, error) { return body, nil }
+
+	var (
+		d        *yarpc.Dispatcher
+		provider yarpc.ClientConfig
+	)
+	app := fxtest.New(t,
+		Module,
+		fx.Provide(
+			func() yarpc.Config { return yarpc.Config{Name: "myservice"} },
+			Procedures(raw.Procedure("echo", echo)...),
+			Procedures(raw.Procedure("ping", echo)...),
+		),
+		fx.Populate(&d, &provider),
+	)
+	require.NotNil(t, d)
+	assert.Equal(t, d, provider)
+
+	var names []string
+	for _, p := range d.Router().Procedures() {
+		names = append(names, p.Name)
+	}
+	assert.ElementsMatch(t, []string{"echo", "ping"}, names)
+
+	app.RequireStart()
+	app.RequireStop()
+}
+
+func TestClientConfig(t *testing.T) {
+	type params struct {
+		fx.In
+
+		KeyValue transport.ClientConfig `name:"keyvalue"`
+	}
+
+	var p params
+	fxtest.New(t,
+		Module,
+		fx.Provide(
+			func() yarpc.Config {
+				out := yarpctest.NewFakeTransport().NewOutbound(nil)
+				return yarpc.Config{
+					Name: "myservice",
+					Outbounds: yarpc.Outbounds{
+						"keyvalue": {Unary: out},
+					},
+				}
+			},
+			ClientConfig("keyvalue"),
+		),
+		fx.Populate(&p),
+	)
+	require.NotNil(t, p.KeyValue)
+	assert.Equal(t, "myservice", p.KeyValue.Caller())
+	assert.Equal(t, "keyvalue", p.KeyValue.Service())
+}
+
+func TestConfig(t *testing.T) {
+	var cfg yarpc.Config
+	fxtest.New(t,
+		fx.Provide(
+			Config("myservice", map[string]interface{}{}),
+			TransportSpec(yarpctest.FakeTransportSpec()),
+			PeerChooserSpec(yarpctest.FakePeerChooserSpec()),
+			PeerListSpec(yarpctest.FakePeerListSpec()),
+			PeerListUpdaterSpec(yarpctest.FakePeerListUpdaterSpec()),
+		),
+		fx.Populate(&cfg),
+	)
+	assert.Equal(t, "myservice", cfg.Name)
+
+	t.Run("invalid spec", func(t *testing.T) {
+		app := fx.New(
+			fx.Provide(
+				Config("myservice", map[string]interface{}{}),
+				TransportSpec(yarpcconfig.TransportSpec{}),
+			),
+			fx.Populate(&cfg),
+		)
+		require.Error(t, app.Err())
+		assert.Contains(t, app.Err().Error(), "name is required")
+	})
+}
@@ -24,7 +24,10 @@ import (
 	"net"
 	"testing"
 
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/middleware"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/zap"
 )
 
 // ServiceOpts are the configuration options for a yarpc service.
@@ -33,6 +36,13 @@ type ServiceOpts struct {
 	Listener   net.Listener
 	Port       uint16
 	Procedures []transport.Procedure
+
+	UnaryInboundMiddleware  []middleware.UnaryInbound
+	OnewayInboundMiddleware []middleware.OnewayInbound
+	StreamInboundMiddleware []middleware.StreamInbound
+
+	Logger  *zap.Logger
+	Metrics *metrics.Scope
 }
 
 // ServiceOption is an option when creating a Service.
@@ -0,0 +1,135 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpctest
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/atomic"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/x/yarpctest/api"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// FailFirstN is handler middleware that fails the first n requests with an
+// error of the given code, and lets the following requests through. Use it
+// to script failures for retry tests.
+//
+// 	Proc(Name("echo"), EchoHandler(FailFirstN(2, yarpcerrors.CodeUnavailable)))
+//
+// The count is reset when the handler is started.
+func FailFirstN(n int, code yarpcerrors.Code) api.UnaryInboundMiddleware {
+	return &failFirstN{n: int64(n), code: code}
+}
+
+type failFirstN struct {
+	n     int64
+	code  yarpcerrors.Code
+	count atomic.Int64
+}
+
+func (f *failFirstN) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	if i := f.count.Inc(); i <= f.n {
+		return yarpcerrors.Newf(f.code, "yarpctest: injected failure %d of %d", i, f.n)
+	}
+	return h.Handle(ctx, req, resw)
+}
+
+func (f *failFirstN) Start(testing.TB) error {
+	f.count.Store(0)
+	return nil
+}
+
+func (f *failFirstN) Stop(testing.TB) error { return nil }
+
+// DelayEveryKth is handler middleware that delays every kth request by d
+// before handling it, and lets the other requests through right away. Use it
+// to script slow responses for hedging and timeout tests.
+//
+// 	Proc(Name("echo"), EchoHandler(DelayEveryKth(3, time.Second)))
+//
+// Delayed requests fail with a DeadlineExceeded error if their context ends
+// first. The count is reset when the handler is started.
+func DelayEveryKth(k int, d time.Duration) api.UnaryInboundMiddleware {
+	return &delayEveryKth{k: int64(k), delay: d}
+}
+
+type delayEveryKth struct {
+	k     int64
+	delay time.Duration
+	count atomic.Int64
+}
+
+func (d *delayEveryKth) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	if d.k > 0 && d.count.Inc()%d.k == 0 {
+		timer := time.NewTimer(d.delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return yarpcerrors.DeadlineExceededErrorf("yarpctest: request ended while delayed: %v", ctx.Err())
+		case <-timer.C:
+		}
+	}
+	return h.Handle(ctx, req, resw)
+}
+
+func (d *delayEveryKth) Start(testing.TB) error {
+	d.count.Store(0)
+	return nil
+}
+
+func (d *delayEveryKth) Stop(testing.TB) error { return nil }
+
+// FlakyPercent is handler middleware that fails about p percent of requests
+// at random with an Unavailable error. Use it for circuit breaker and load
+// tests that only care about failure rates.
+//
+// 	Proc(Name("echo"), EchoHandler(FlakyPercent(10)))
+func FlakyPercent(p float64) api.UnaryInboundMiddleware {
+	return &flakyPercent{
+		percent: p,
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+type flakyPercent struct {
+	api.NoopLifecycle
+
+	percent float64
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func (f *flakyPercent) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	f.mu.Lock()
+	roll := f.rand.Float64() * 100
+	f.mu.Unlock()
+
+	if roll < f.percent {
+		return yarpcerrors.UnavailableErrorf("yarpctest: injected flaky failure")
+	}
+	return h.Handle(ctx, req, resw)
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpctest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/x/yarpctest/api"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+func handleN(ctx context.Context, t *testing.T, mw api.UnaryInboundMiddleware, n int) []error {
+	require.NoError(t, mw.Start(t))
+	defer func() { require.NoError(t, mw.Stop(t)) }()
+
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = mw.Handle(ctx, &transport.Request{}, new(transporttest.FakeResponseWriter), newStaticHandler("ok"))
+	}
+	return errs
+}
+
+func TestFailFirstN(t *testing.T) {
+	mw := FailFirstN(2, yarpcerrors.CodeUnavailable)
+	for i := 0; i < 2; i++ {
+		errs := handleN(context.Background(), t, mw, 3)
+		assert.True(t, yarpcerrors.IsUnavailable(errs[0]))
+		assert.True(t, yarpcerrors.IsUnavailable(errs[1]))
+		assert.NoError(t, errs[2])
+	}
+}
+
+func TestDelayEveryKth(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	errs := handleN(ctx, t, DelayEveryKth(2, time.Minute), 4)
+	assert.NoError(t, errs[0])
+	assert.True(t, yarpcerrors.IsDeadlineExceeded(errs[1]))
+	assert.NoError(t, errs[2])
+	assert.True(t, yarpcerrors.IsDeadlineExceeded(errs[3]))
+}
+
+func TestFlakyPercent(t *testing.T) {
+	for _, err := range handleN(context.Background(), t, FlakyPercent(0), 10) {
+		assert.NoError(t, err)
+	}
+	for _, err := range handleN(context.Background(), t, FlakyPercent(100), 10) {
+		assert.True(t, yarpcerrors.IsUnavailable(err))
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpctest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/x/yarpctest/api"
+	"go.uber.org/yarpc/x/yarpctest/types"
+)
+
+// MatrixTransport is a transport over which Matrix runs scenarios.
+type MatrixTransport struct {
+	Name    string
+	Service func(...api.ServiceOption) api.Lifecycle
+	Request func(...api.RequestOption) api.Action
+}
+
+var (
+	// HTTPTransport runs Matrix scenarios over HTTP.
+	HTTPTransport = MatrixTransport{Name: "http", Service: HTTPService, Request: HTTPRequest}
+
+	// TChannelTransport runs Matrix scenarios over TChannel.
+	TChannelTransport = MatrixTransport{Name: "tchannel", Service: TChannelService, Request: TChannelRequest}
+
+	// GRPCTransport runs Matrix scenarios over gRPC.
+	GRPCTransport = MatrixTransport{Name: "grpc", Service: GRPCService, Request: GRPCRequest}
+)
+
+var (
+	_matrixTransports = []MatrixTransport{HTTPTransport, TChannelTransport, GRPCTransport}
+	_matrixEncodings  = []transport.Encoding{"raw", "json", "proto", "thrift"}
+)
+
+// MatrixCase is a combination of transport and encoding for which Matrix
+// runs a scenario.
+type MatrixCase struct {
+	Transport MatrixTransport
+	Encoding  transport.Encoding
+
+	port *types.Port
+}
+
+// Service creates a service over the transport of the case, listening on a
+// port reserved for the case.
+func (c MatrixCase) Service(options ...api.ServiceOption) api.Lifecycle {
+	return c.Transport.Service(append([]api.ServiceOption{c.port}, options...)...)
+}
+
+// Request creates a request over the transport of the case, sent to the
+// service of the case with the encoding of the case.
+func (c MatrixCase) Request(options ...api.RequestOption) api.Action {
+	encoding := api.RequestOptionFunc(func(opts *api.RequestOpts) {
+		opts.GiveRequest.Encoding = c.Encoding
+	})
+	return c.Transport.Request(append([]api.RequestOption{c.port, encoding}, options...)...)
+}
+
+// MatrixOption customizes the combinations run by Matrix.
+type MatrixOption func(*matrixOpts)
+
+type matrixOpts struct {
+	transports []MatrixTransport
+	encodings  []transport.Encoding
+}
+
+// MatrixTransports limits Matrix to the given transports. Defaults to HTTP,
+// TChannel, and gRPC.
+func MatrixTransports(transports ...MatrixTransport) MatrixOption {
+	return func(opts *matrixOpts) {
+		opts.transports = transports
+	}
+}
+
+// MatrixEncodings limits Matrix to the given encodings. Defaults to raw,
+// json, proto, and thrift.
+func MatrixEncodings(encodings ...transport.Encoding) MatrixOption {
+	return func(opts *matrixOpts) {
+		opts.encodings = encodings
+	}
+}
+
+// Matrix runs the same scenario for every combination of transport and
+// encoding, in subtests named after them, such as "http/json". The scenario
+// builds the services and the requests of the test for a combination; the
+// services are started before the requests run and stopped afterwards.
+//
+// 	Matrix(t, func(c MatrixCase) (Lifecycle, Action) {
+// 		services := c.Service(Name("myservice"), Proc(Name("echo"), EchoHandler()))
+// 		requests := c.Request(
+// 			Service("myservice"),
+// 			Procedure("echo"),
+// 			Body("hello"),
+// 			WantRespBody("hello"),
+// 		)
+// 		return services, requests
+// 	})
+//
+// Requests only set the encoding of the request; the scenario is responsible
+// for giving bodies that make sense for c.Encoding.
+func Matrix(t *testing.T, scenario func(MatrixCase) (Lifecycle, Action), options ...MatrixOption) {
+	opts := matrixOpts{
+		transports: _matrixTransports,
+		encodings:  _matrixEncodings,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	for _, trans := range opts.transports {
+		for _, encoding := range opts.encodings {
+			trans, encoding := trans, encoding
+			t.Run(trans.Name+"/"+string(encoding), func(t *testing.T) {
+				services, requests := scenario(MatrixCase{
+					Transport: trans,
+					Encoding:  encoding,
+					port:      NewPortProvider(t).NamedPort("matrix"),
+				})
+				require.NoError(t, services.Start(t))
+				defer func() { require.NoError(t, services.Stop(t)) }()
+				requests.Run(t)
+			})
+		}
+	}
+}
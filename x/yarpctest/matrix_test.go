@@ -0,0 +1,74 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpctest
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/x/yarpctest/api"
+	"go.uber.org/yarpc/x/yarpctest/types"
+)
+
+func TestMatrix(t *testing.T) {
+	// encodingHandler responds with the encoding of the request.
+	encodingHandler := &types.UnaryHandler{Handler: api.UnaryHandlerFunc(
+		func(_ context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+			_, err := io.WriteString(resw, string(req.Encoding))
+			return err
+		},
+	)}
+
+	var cases []string
+	Matrix(t, func(c MatrixCase) (Lifecycle, Action) {
+		cases = append(cases, c.Transport.Name+"/"+string(c.Encoding))
+		services := c.Service(
+			Name("myservice"),
+			Proc(Name("echo"), EchoHandler()),
+			Proc(Name("encoding"), encodingHandler),
+		)
+		requests := Actions(
+			c.Request(
+				Service("myservice"),
+				Procedure("echo"),
+				Body("hello"),
+				WantRespBody("hello"),
+			),
+			c.Request(
+				Service("myservice"),
+				Procedure("encoding"),
+				WantRespBody(string(c.Encoding)),
+			),
+		)
+		return services, requests
+	})
+	assert.Len(t, cases, 12)
+
+	cases = nil
+	Matrix(t, func(c MatrixCase) (Lifecycle, Action) {
+		cases = append(cases, c.Transport.Name+"/"+string(c.Encoding))
+		return Lifecycles(), Actions()
+	}, MatrixTransports(HTTPTransport, GRPCTransport), MatrixEncodings("json"))
+	assert.Equal(t, []string{"http/json", "grpc/json"}, cases)
+}
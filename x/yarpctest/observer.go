@@ -0,0 +1,154 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpctest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/x/yarpctest/api"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// Observer captures the metrics and logs emitted by the services it is passed
+// to, so that tests can make assertions about them.
+//
+// 	obs := NewObserver()
+// 	services := Lifecycles(
+// 		HTTPService(Name("myservice"), p.NamedPort("1"), obs, Proc(Name("echo"), EchoHandler())),
+// 	)
+// 	requests := Actions(
+// 		HTTPRequest(p.NamedPort("1"), Service("myservice"), Procedure("echo")),
+// 		obs.WantCounter("calls", map[string]string{"procedure": "echo"}, 1),
+// 		obs.WantLog("Handled inbound request.", map[string]interface{}{"procedure": "echo"}),
+// 	)
+type Observer struct {
+	api.NoopLifecycle
+
+	root   *metrics.Root
+	logger *zap.Logger
+	logs   *observer.ObservedLogs
+}
+
+var _ api.ServiceOption = (*Observer)(nil)
+
+// NewObserver builds a new Observer. Pass it to a service as an option to
+// capture the metrics and logs of the service.
+func NewObserver() *Observer {
+	core, logs := observer.New(zapcore.DebugLevel)
+	return &Observer{
+		root:   metrics.New(),
+		logger: zap.New(core),
+		logs:   logs,
+	}
+}
+
+// ApplyService implements ServiceOption.
+func (o *Observer) ApplyService(opts *api.ServiceOpts) {
+	opts.Logger = o.logger
+	opts.Metrics = o.root.Scope()
+}
+
+// Metrics returns a snapshot of the metrics captured so far.
+func (o *Observer) Metrics() *metrics.RootSnapshot {
+	return o.root.Snapshot()
+}
+
+// Logs returns the logs captured so far.
+func (o *Observer) Logs() *observer.ObservedLogs {
+	return o.logs
+}
+
+// WantCounter asserts that the counters with the given name and tags add up
+// to the given value. Counters may have tags besides the given ones.
+func (o *Observer) WantCounter(name string, tags map[string]string, want int64) api.Action {
+	return api.ActionFunc(func(t testing.TB) {
+		var got int64
+		for _, c := range o.Metrics().Counters {
+			if c.Name == name && hasTags(c.Tags, tags) {
+				got += c.Value
+			}
+		}
+		assert.Equal(t, want, got, "unexpected value for counter %q with tags %v", name, tags)
+	})
+}
+
+// WantLog asserts that a log with the given message and fields was captured.
+// The log may have fields besides the given ones, and fields are looked up
+// in nested namespaces too.
+func (o *Observer) WantLog(message string, fields map[string]interface{}) api.Action {
+	return api.ActionFunc(func(t testing.TB) {
+		entries := o.logs.FilterMessage(message).All()
+		for _, entry := range entries {
+			if hasFields(entry.ContextMap(), fields) {
+				return
+			}
+		}
+		assert.Fail(t, "missing log", "no log %q with fields %v among %v", message, fields, describeLogs(entries))
+	})
+}
+
+func hasTags(tags metrics.Tags, want map[string]string) bool {
+	for k, v := range want {
+		if got, ok := tags[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+func hasFields(fields map[string]interface{}, want map[string]interface{}) bool {
+	for k, v := range want {
+		got, ok := findField(fields, k)
+		if !ok || !assert.ObjectsAreEqualValues(v, got) {
+			return false
+		}
+	}
+	return true
+}
+
+// findField looks up a field by key, descending into the namespaces of the
+// log.
+func findField(fields map[string]interface{}, key string) (interface{}, bool) {
+	if v, ok := fields[key]; ok {
+		return v, true
+	}
+	for _, v := range fields {
+		if namespace, ok := v.(map[string]interface{}); ok {
+			if v, ok := findField(namespace, key); ok {
+				return v, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func describeLogs(entries []observer.LoggedEntry) []string {
+	logs := make([]string, len(entries))
+	for i, entry := range entries {
+		logs[i] = fmt.Sprintf("%v", entry.ContextMap())
+	}
+	return logs
+}
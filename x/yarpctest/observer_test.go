@@ -0,0 +1,74 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpctest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserver(t *testing.T) {
+	p := NewPortProvider(t)
+	obs := NewObserver()
+	services := Lifecycles(
+		HTTPService(
+			Name("myservice"),
+			p.NamedPort("1"),
+			obs,
+			Proc(Name("echo"), EchoHandler()),
+			Proc(Name("error"), ErrorHandler(errors.New("error from myservice"))),
+		),
+	)
+	require.NoError(t, services.Start(t))
+	defer func() { require.NoError(t, services.Stop(t)) }()
+
+	Actions(
+		RepeatAction(
+			HTTPRequest(
+				p.NamedPort("1"),
+				Body("test body"),
+				Service("myservice"),
+				Procedure("echo"),
+				WantRespBody("test body"),
+			),
+			2,
+		),
+		HTTPRequest(
+			p.NamedPort("1"),
+			Service("myservice"),
+			Procedure("error"),
+			WantError("error from myservice"),
+		),
+		obs.WantCounter("calls", map[string]string{"procedure": "echo", "direction": "inbound"}, 2),
+		obs.WantCounter("successes", map[string]string{"procedure": "echo"}, 2),
+		obs.WantCounter("calls", map[string]string{"procedure": "error"}, 1),
+		obs.WantLog("Handled inbound request.", map[string]interface{}{
+			"procedure":  "echo",
+			"successful": true,
+		}),
+		obs.WantLog("Error handling inbound request.", map[string]interface{}{
+			"procedure": "error",
+			"error":     "error from myservice",
+		}),
+	).Run(t)
+}
@@ -21,10 +21,13 @@
 package yarpctest
 
 import (
+	"context"
 	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/testtime"
 	"go.uber.org/yarpc/yarpcerrors"
 )
@@ -290,6 +293,41 @@ func TestServiceRouting(t *testing.T) {
 				3,
 			),
 		},
+		{
+			name: "inbound middleware",
+			services: Lifecycles(
+				HTTPService(
+					Name("myservice"),
+					p.NamedPort("7"),
+					UnaryInboundMiddleware(middleware.UnaryInboundFunc(
+						func(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+							if req.Procedure == "forbidden" {
+								return errors.New("rejected by middleware")
+							}
+							return h.Handle(ctx, req, resw)
+						},
+					)),
+					Proc(Name("echo"), EchoHandler()),
+					Proc(Name("forbidden"), EchoHandler()),
+				),
+			),
+			requests: Actions(
+				HTTPRequest(
+					p.NamedPort("7"),
+					Body("test body"),
+					Service("myservice"),
+					Procedure("echo"),
+					WantRespBody("test body"),
+				),
+				HTTPRequest(
+					p.NamedPort("7"),
+					Body("test body"),
+					Service("myservice"),
+					Procedure("forbidden"),
+					WantError("rejected by middleware"),
+				),
+			),
+		},
 	}
 
 	for _, tt := range tests {
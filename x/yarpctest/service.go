@@ -32,6 +32,7 @@ import (
 	"go.uber.org/multierr"
 	"go.uber.org/yarpc"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/inboundmiddleware"
 	"go.uber.org/yarpc/transport/grpc"
 	"go.uber.org/yarpc/transport/http"
 	"go.uber.org/yarpc/transport/tchannel"
@@ -49,7 +50,7 @@ func HTTPService(options ...api.ServiceOption) api.Lifecycle {
 			require.NoError(t, opts.Listener.Close())
 		}
 		inbound := http.NewTransport().NewInbound(fmt.Sprintf("127.0.0.1:%d", opts.Port))
-		s := createService(opts.Name, inbound, opts.Procedures, options)
+		s := createService(opts, inbound, options)
 		return s.Stop, s.Start(t)
 	})
 }
@@ -73,7 +74,7 @@ func TChannelService(options ...api.ServiceOption) api.Lifecycle {
 		)
 		require.NoError(t, err)
 		inbound := trans.NewInbound()
-		s := createService(opts.Name, inbound, opts.Procedures, options)
+		s := createService(opts, inbound, options)
 		return s.Stop, s.Start(t)
 	})
 }
@@ -93,7 +94,7 @@ func GRPCService(options ...api.ServiceOption) api.Lifecycle {
 			require.NoError(t, err)
 		}
 		inbound := trans.NewInbound(listener)
-		service := createService(opts.Name, inbound, opts.Procedures, options)
+		service := createService(opts, inbound, options)
 		return service.Stop, service.Start(t)
 	})
 }
@@ -123,25 +124,34 @@ func (s *startToStopper) Stop(t testing.TB) error {
 }
 
 func createService(
-	name string,
+	opts api.ServiceOpts,
 	inbound transport.Inbound,
-	procedures []transport.Procedure,
 	options []api.ServiceOption,
 ) *wrappedDispatcher {
+	metricsConfig := yarpc.MetricsConfig{Tally: tally.NoopScope}
+	if opts.Metrics != nil {
+		metricsConfig = yarpc.MetricsConfig{Metrics: opts.Metrics}
+	}
 	d := yarpc.NewDispatcher(
 		yarpc.Config{
-			Name:     name,
+			Name:     opts.Name,
 			Inbounds: yarpc.Inbounds{inbound},
-			Metrics: yarpc.MetricsConfig{
-				Tally: tally.NoopScope,
+			InboundMiddleware: yarpc.InboundMiddleware{
+				Unary:  inboundmiddleware.UnaryChain(opts.UnaryInboundMiddleware...),
+				Oneway: inboundmiddleware.OnewayChain(opts.OnewayInboundMiddleware...),
+				Stream: inboundmiddleware.StreamChain(opts.StreamInboundMiddleware...),
+			},
+			Logging: yarpc.LoggingConfig{
+				Zap: opts.Logger,
 			},
+			Metrics: metricsConfig,
 		},
 	)
-	d.Register(procedures)
+	d.Register(opts.Procedures)
 	return &wrappedDispatcher{
 		Dispatcher: d,
 		options:    options,
-		procedures: procedures,
+		procedures: opts.Procedures,
 	}
 }
 
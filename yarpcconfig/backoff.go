@@ -51,12 +51,15 @@ func (c Backoff) Strategy() (backoffapi.Strategy, error) {
 // Each subsequent attempt has twice the range of possible jittered delay
 // duration.
 // The range of possible values will not exceed "max", inclusive.
+// "seed", if specified, makes the jittered durations reproducible.
 //
 //   first: 100ms
 //   max: 30s
+//   seed: 42
 type ExponentialBackoff struct {
 	First time.Duration `config:"first"`
 	Max   time.Duration `config:"max"`
+	Seed  *int64        `config:"seed"`
 }
 
 // Strategy returns an exponential backoff strategy (in terms of the number of
@@ -70,6 +73,9 @@ func (c ExponentialBackoff) Strategy() (backoffapi.Strategy, error) {
 	if c.Max > 0 {
 		opts = append(opts, backoff.MaxBackoff(c.Max))
 	}
+	if c.Seed != nil {
+		opts = append(opts, backoff.Seed(*c.Seed))
+	}
 
 	return backoff.NewExponential(opts...)
 }
@@ -40,6 +40,7 @@ func TestBackoffConfig(t *testing.T) {
 		err  bool
 	}
 
+	seed := int64(42)
 	tests := []testCase{
 		{
 			name: "empty",
@@ -58,6 +59,18 @@ func TestBackoffConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "seeded",
+			give: `
+				exponential:
+					seed: 42
+			`,
+			want: Backoff{
+				Exponential: ExponentialBackoff{
+					Seed: &seed,
+				},
+			},
+		},
 		{
 			name: "bogus",
 			give: `
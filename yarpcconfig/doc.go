@@ -41,6 +41,15 @@
 // TransportSpecs, PeerChooserSpecs, PeerListSpecs, and PeerListUpdaterSpecs
 // with it.
 //
+// Packages that extend YARPC may register their specs with the process as a
+// Plugin, usually from an init function. Configurators built with the
+// RegisteredPlugins option know about the specs of all such plugins, under
+// names qualified with the namespace of the plugin.
+//
+// 	import _ "example.com/acme/kafka"
+//
+// 	cfg := yarpcconfig.New(yarpcconfig.RegisteredPlugins())
+//
 // Use LoadConfigFromYAML to load a yarpc.Config from YAML and pass that to
 // yarpc.NewDispatcher.
 //
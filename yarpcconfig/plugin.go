@@ -0,0 +1,244 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpcconfig
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// PluginNamespaceSeparator separates the namespace of a Plugin from the
+// names of its specs. A transport "kafka" registered by the Plugin with the
+// namespace "acme" is configured under the name "acme/kafka".
+const PluginNamespaceSeparator = "/"
+
+// Plugin bundles the configuration specs of a package that extends YARPC
+// with its own transports, peer choosers, peer lists, or peer list updaters.
+//
+// Such packages register themselves from an init function with
+// MustRegisterPlugin, so that applications only need to import them for
+// their side effects.
+//
+// 	package kafka
+//
+// 	func init() {
+// 		yarpcconfig.MustRegisterPlugin(yarpcconfig.Plugin{
+// 			Namespace:  "acme",
+// 			Transports: []yarpcconfig.TransportSpec{TransportSpec()},
+// 		})
+// 	}
+//
+// Applications then opt into all registered plugins with the
+// RegisteredPlugins option.
+//
+// 	import _ "example.com/acme/kafka"
+//
+// 	cfg := yarpcconfig.New(yarpcconfig.RegisteredPlugins())
+//
+// The specs of a plugin are known by their names qualified with the
+// namespace of the plugin, so that extensions from different sources do not
+// replace each other or the specs that are registered by hand.
+type Plugin struct {
+	// Namespace of the plugin, usually the name of the organization or
+	// project that provides it. This is required and may not contain
+	// PluginNamespaceSeparator.
+	Namespace string
+
+	Transports       []TransportSpec
+	PeerChoosers     []PeerChooserSpec
+	PeerLists        []PeerListSpec
+	PeerListUpdaters []PeerListUpdaterSpec
+}
+
+var _plugins = struct {
+	sync.Mutex
+
+	byNamespace map[string]Plugin
+}{byNamespace: make(map[string]Plugin)}
+
+// RegisterPlugin registers a Plugin with the process, to be picked up by
+// Configurators built with the RegisteredPlugins option.
+//
+// An error is returned if the Plugin or any of its specs is invalid, or if a
+// Plugin with the same namespace was already registered. Use
+// MustRegisterPlugin to panic in case of registration failure.
+func RegisterPlugin(p Plugin) error {
+	p = p.qualified()
+	if err := p.validate(); err != nil {
+		return fmt.Errorf("invalid Plugin %q: %v", p.Namespace, err)
+	}
+
+	_plugins.Lock()
+	defer _plugins.Unlock()
+
+	if _, ok := _plugins.byNamespace[p.Namespace]; ok {
+		return fmt.Errorf("a Plugin with the namespace %q is already registered", p.Namespace)
+	}
+	_plugins.byNamespace[p.Namespace] = p
+	return nil
+}
+
+// MustRegisterPlugin registers the given Plugin with the process. This
+// function panics if the Plugin is invalid or its namespace is taken.
+func MustRegisterPlugin(p Plugin) {
+	if err := RegisterPlugin(p); err != nil {
+		panic(err)
+	}
+}
+
+// RegisteredPlugins teaches the Configurator about the specs of all Plugins
+// registered with RegisterPlugin, under their qualified names.
+//
+// Plugins are usually registered by init functions, so this picks up all
+// plugins imported by the application by the time New is called.
+func RegisteredPlugins() Option {
+	return func(c *Configurator) {
+		for _, p := range registeredPlugins() {
+			// Specs were validated when the plugin was registered so
+			// these cannot fail.
+			for _, s := range p.Transports {
+				c.MustRegisterTransport(s)
+			}
+			for _, s := range p.PeerChoosers {
+				c.MustRegisterPeerChooser(s)
+			}
+			for _, s := range p.PeerLists {
+				c.MustRegisterPeerList(s)
+			}
+			for _, s := range p.PeerListUpdaters {
+				c.MustRegisterPeerListUpdater(s)
+			}
+		}
+	}
+}
+
+// registeredPlugins returns the registered plugins sorted by namespace.
+func registeredPlugins() []Plugin {
+	_plugins.Lock()
+	defer _plugins.Unlock()
+
+	plugins := make([]Plugin, 0, len(_plugins.byNamespace))
+	for _, p := range _plugins.byNamespace {
+		plugins = append(plugins, p)
+	}
+	sort.Slice(plugins, func(i, j int) bool {
+		return plugins[i].Namespace < plugins[j].Namespace
+	})
+	return plugins
+}
+
+// qualified returns a copy of the plugin with the names of its specs
+// qualified with its namespace.
+func (p Plugin) qualified() Plugin {
+	out := Plugin{Namespace: p.Namespace}
+	for _, s := range p.Transports {
+		s.Name = p.qualify(s.Name)
+		out.Transports = append(out.Transports, s)
+	}
+	for _, s := range p.PeerChoosers {
+		s.Name = p.qualify(s.Name)
+		out.PeerChoosers = append(out.PeerChoosers, s)
+	}
+	for _, s := range p.PeerLists {
+		s.Name = p.qualify(s.Name)
+		out.PeerLists = append(out.PeerLists, s)
+	}
+	for _, s := range p.PeerListUpdaters {
+		s.Name = p.qualify(s.Name)
+		out.PeerListUpdaters = append(out.PeerListUpdaters, s)
+	}
+	return out
+}
+
+func (p Plugin) qualify(name string) string {
+	if name == "" {
+		// Leave empty names empty so that they fail validation.
+		return ""
+	}
+	return p.Namespace + PluginNamespaceSeparator + name
+}
+
+// validate validates a qualified plugin by compiling its specs.
+func (p Plugin) validate() error {
+	if p.Namespace == "" {
+		return errors.New("namespace is required")
+	}
+	if strings.Contains(p.Namespace, PluginNamespaceSeparator) {
+		return fmt.Errorf("namespace may not contain %q", PluginNamespaceSeparator)
+	}
+
+	names := make(map[string]struct{})
+	checkName := func(kind, name string) error {
+		if name == "" {
+			return fmt.Errorf("%v name is required", kind)
+		}
+		local := strings.TrimPrefix(name, p.Namespace+PluginNamespaceSeparator)
+		if strings.Contains(local, PluginNamespaceSeparator) {
+			return fmt.Errorf("%v name %q may not contain %q", kind, local, PluginNamespaceSeparator)
+		}
+		if _, ok := names[kind+" "+name]; ok {
+			return fmt.Errorf("%v %q is defined more than once", kind, local)
+		}
+		names[kind+" "+name] = struct{}{}
+		return nil
+	}
+
+	for i := range p.Transports {
+		s := &p.Transports[i]
+		if err := checkName("transport", s.Name); err != nil {
+			return err
+		}
+		if _, err := compileTransportSpec(s); err != nil {
+			return fmt.Errorf("invalid TransportSpec for %q: %v", s.Name, err)
+		}
+	}
+	for i := range p.PeerChoosers {
+		s := &p.PeerChoosers[i]
+		if err := checkName("peer chooser", s.Name); err != nil {
+			return err
+		}
+		if _, err := compilePeerChooserSpec(s); err != nil {
+			return fmt.Errorf("invalid PeerChooserSpec for %q: %v", s.Name, err)
+		}
+	}
+	for i := range p.PeerLists {
+		s := &p.PeerLists[i]
+		if err := checkName("peer list", s.Name); err != nil {
+			return err
+		}
+		if _, err := compilePeerListSpec(s); err != nil {
+			return fmt.Errorf("invalid PeerListSpec for %q: %v", s.Name, err)
+		}
+	}
+	for i := range p.PeerListUpdaters {
+		s := &p.PeerListUpdaters[i]
+		if err := checkName("peer list updater", s.Name); err != nil {
+			return err
+		}
+		if _, err := compilePeerListUpdaterSpec(s); err != nil {
+			return fmt.Errorf("invalid PeerListUpdaterSpec for %q: %v", s.Name, err)
+		}
+	}
+	return nil
+}
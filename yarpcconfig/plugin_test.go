@@ -0,0 +1,136 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpcconfig_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/internal/whitespace"
+	"go.uber.org/yarpc/yarpcconfig"
+	"go.uber.org/yarpc/yarpctest"
+)
+
+func TestRegisterPluginErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		give    yarpcconfig.Plugin
+		wantErr string
+	}{
+		{
+			desc:    "no namespace",
+			give:    yarpcconfig.Plugin{},
+			wantErr: "namespace is required",
+		},
+		{
+			desc:    "namespace with separator",
+			give:    yarpcconfig.Plugin{Namespace: "a/b"},
+			wantErr: `namespace may not contain "/"`,
+		},
+		{
+			desc: "unnamed spec",
+			give: yarpcconfig.Plugin{
+				Namespace: "errors",
+				PeerLists: []yarpcconfig.PeerListSpec{{}},
+			},
+			wantErr: "peer list name is required",
+		},
+		{
+			desc: "name with separator",
+			give: yarpcconfig.Plugin{
+				Namespace:  "errors",
+				Transports: []yarpcconfig.TransportSpec{{Name: "foo/bar"}},
+			},
+			wantErr: `transport name "foo/bar" may not contain "/"`,
+		},
+		{
+			desc: "duplicate spec",
+			give: yarpcconfig.Plugin{
+				Namespace: "errors",
+				PeerListUpdaters: []yarpcconfig.PeerListUpdaterSpec{
+					yarpctest.FakePeerListUpdaterSpec(),
+					yarpctest.FakePeerListUpdaterSpec(),
+				},
+			},
+			wantErr: `peer list updater "fake-updater" is defined more than once`,
+		},
+		{
+			desc: "invalid spec",
+			give: yarpcconfig.Plugin{
+				Namespace:    "errors",
+				PeerChoosers: []yarpcconfig.PeerChooserSpec{{Name: "chooser"}},
+			},
+			wantErr: `invalid PeerChooserSpec for "errors/chooser": BuildPeerChooser is required`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := yarpcconfig.RegisterPlugin(tt.give)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			assert.Panics(t, func() { yarpcconfig.MustRegisterPlugin(tt.give) })
+		})
+	}
+}
+
+func TestRegisteredPlugins(t *testing.T) {
+	yarpcconfig.MustRegisterPlugin(yarpcconfig.Plugin{
+		Namespace:        "plugintest",
+		Transports:       []yarpcconfig.TransportSpec{yarpctest.FakeTransportSpec()},
+		PeerLists:        []yarpcconfig.PeerListSpec{yarpctest.FakePeerListSpec()},
+		PeerListUpdaters: []yarpcconfig.PeerListUpdaterSpec{yarpctest.FakePeerListUpdaterSpec()},
+	})
+
+	err := yarpcconfig.RegisterPlugin(yarpcconfig.Plugin{Namespace: "plugintest"})
+	require.Error(t, err, "namespaces must be unique")
+	assert.Contains(t, err.Error(), `a Plugin with the namespace "plugintest" is already registered`)
+
+	yaml := whitespace.Expand(`
+		outbounds:
+			their-service:
+				unary:
+					plugintest/fake-transport:
+						nop: "*.*"
+						plugintest/fake-list:
+							plugintest/fake-updater:
+								nop: ":1234"
+	`)
+
+	t.Run("not loaded by default", func(t *testing.T) {
+		_, err := yarpcconfig.New().LoadConfigFromYAML("myservice", strings.NewReader(yaml))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unknown transport "plugintest/fake-transport"`)
+	})
+
+	t.Run("loaded with option", func(t *testing.T) {
+		cfg, err := yarpcconfig.New(yarpcconfig.RegisteredPlugins()).
+			LoadConfigFromYAML("myservice", strings.NewReader(yaml))
+		require.NoError(t, err)
+
+		unary, ok := cfg.Outbounds["their-service"].Unary.(*yarpctest.FakeOutbound)
+		require.True(t, ok, "unary outbound must be fake outbound")
+		assert.Equal(t, "*.*", unary.NopOption())
+		assert.NotNil(t, unary.Chooser())
+	})
+}
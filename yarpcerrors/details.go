@@ -0,0 +1,212 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpcerrors
+
+import "time"
+
+// RetryAfterDetailKey is the key of the integer detail, in milliseconds, set
+// by WithRetryAfter.
+const RetryAfterDetailKey = "retry-after-ms"
+
+// Detail is a typed key/value pair attached to a Status.
+//
+// Details are propagated by all YARPC transports, so servers may use them to
+// send structured metadata about an error back to clients. Use the
+// StringDetail, IntDetail, FloatDetail, and BoolDetail constructors to build
+// a Detail.
+type Detail struct {
+	key   string
+	value interface{}
+}
+
+// StringDetail returns a Detail with a string value.
+func StringDetail(key string, value string) Detail {
+	return Detail{key: key, value: value}
+}
+
+// IntDetail returns a Detail with an integer value.
+func IntDetail(key string, value int64) Detail {
+	return Detail{key: key, value: value}
+}
+
+// FloatDetail returns a Detail with a floating point value.
+func FloatDetail(key string, value float64) Detail {
+	return Detail{key: key, value: value}
+}
+
+// BoolDetail returns a Detail with a boolean value.
+func BoolDetail(key string, value bool) Detail {
+	return Detail{key: key, value: value}
+}
+
+// Key returns the key of the Detail.
+func (d Detail) Key() string {
+	return d.key
+}
+
+// Value returns the value of the Detail. This is one of string, int64,
+// float64 or bool.
+func (d Detail) Value() interface{} {
+	return d.value
+}
+
+// WithDetails returns a new Status with the given details added to it.
+//
+// If a detail with the same key is already present on the Status, it is
+// replaced.
+func (s *Status) WithDetails(details ...Detail) *Status {
+	if s == nil || len(details) == 0 {
+		return s
+	}
+	status := s.clone()
+	status.details = make([]Detail, 0, len(s.details)+len(details))
+	status.details = append(status.details, s.details...)
+	for _, d := range details {
+		if i := status.detailIndex(d.key); i >= 0 {
+			status.details[i] = d
+			continue
+		}
+		status.details = append(status.details, d)
+	}
+	return status
+}
+
+// Details returns the details attached to this Status, in the order in which
+// they were added.
+func (s *Status) Details() []Detail {
+	if s == nil || len(s.details) == 0 {
+		return nil
+	}
+	details := make([]Detail, len(s.details))
+	copy(details, s.details)
+	return details
+}
+
+// WithCause returns a new Status with the given error recorded as its cause.
+//
+// The cause is converted to a Status with FromError so that it may be
+// propagated across transports along with the Status.
+func (s *Status) WithCause(cause error) *Status {
+	if s == nil {
+		return nil
+	}
+	status := s.clone()
+	status.cause = FromError(cause)
+	return status
+}
+
+// Cause returns the Status that caused this Status, or nil if no cause was
+// recorded with WithCause.
+func (s *Status) Cause() *Status {
+	if s == nil {
+		return nil
+	}
+	return s.cause
+}
+
+// WithRetryAfter returns a new Status that asks clients to wait for the given
+// duration before sending more requests, typically on ResourceExhausted or
+// Unavailable errors returned by overloaded servers.
+//
+// The duration is rounded down to the millisecond and propagated as the
+// RetryAfterDetailKey detail.
+func (s *Status) WithRetryAfter(d time.Duration) *Status {
+	return s.WithDetails(IntDetail(RetryAfterDetailKey, int64(d/time.Millisecond)))
+}
+
+// RetryAfter returns how long the server asked clients to wait before sending
+// more requests with WithRetryAfter.
+//
+// The second return value is false if the server did not ask clients to
+// wait.
+func (s *Status) RetryAfter() (time.Duration, bool) {
+	ms, ok := s.IntDetail(RetryAfterDetailKey)
+	if !ok || ms < 0 {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+// StringDetail returns the string value of the detail with the given key.
+//
+// The second return value is false if the detail is absent or is not a
+// string.
+func (s *Status) StringDetail(key string) (string, bool) {
+	v, ok := s.detail(key).(string)
+	return v, ok
+}
+
+// IntDetail returns the integer value of the detail with the given key.
+//
+// The second return value is false if the detail is absent or is not an
+// integer.
+func (s *Status) IntDetail(key string) (int64, bool) {
+	v, ok := s.detail(key).(int64)
+	return v, ok
+}
+
+// FloatDetail returns the floating point value of the detail with the given
+// key.
+//
+// The second return value is false if the detail is absent or is not a
+// floating point number.
+func (s *Status) FloatDetail(key string) (float64, bool) {
+	v, ok := s.detail(key).(float64)
+	return v, ok
+}
+
+// BoolDetail returns the boolean value of the detail with the given key.
+//
+// The second return value is false if the detail is absent or is not a
+// boolean.
+func (s *Status) BoolDetail(key string) (bool, bool) {
+	v, ok := s.detail(key).(bool)
+	return v, ok
+}
+
+func (s *Status) detail(key string) interface{} {
+	if s == nil {
+		return nil
+	}
+	if i := s.detailIndex(key); i >= 0 {
+		return s.details[i].value
+	}
+	return nil
+}
+
+func (s *Status) detailIndex(key string) int {
+	for i, d := range s.details {
+		if d.key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Status) clone() *Status {
+	return &Status{
+		code:    s.code,
+		name:    s.name,
+		message: s.message,
+		details: s.details,
+		cause:   s.cause,
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpcerrors
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetails(t *testing.T) {
+	status := Newf(CodeNotFound, "hello").WithDetails(
+		StringDetail("string", "foo"),
+		IntDetail("int", 42),
+		FloatDetail("float", 1.5),
+		BoolDetail("bool", true),
+	)
+
+	s, ok := status.StringDetail("string")
+	assert.True(t, ok)
+	assert.Equal(t, "foo", s)
+
+	i, ok := status.IntDetail("int")
+	assert.True(t, ok)
+	assert.Equal(t, int64(42), i)
+
+	f, ok := status.FloatDetail("float")
+	assert.True(t, ok)
+	assert.Equal(t, 1.5, f)
+
+	b, ok := status.BoolDetail("bool")
+	assert.True(t, ok)
+	assert.True(t, b)
+
+	_, ok = status.StringDetail("int")
+	assert.False(t, ok, "detail of a different type must not be returned")
+	_, ok = status.IntDetail("missing")
+	assert.False(t, ok, "missing detail must not be returned")
+
+	assert.Equal(t, []Detail{
+		StringDetail("string", "foo"),
+		IntDetail("int", 42),
+		FloatDetail("float", 1.5),
+		BoolDetail("bool", true),
+	}, status.Details())
+	assert.Equal(t, CodeNotFound, status.Code())
+	assert.Equal(t, "hello", status.Message())
+}
+
+func TestDetailsReplace(t *testing.T) {
+	original := Newf(CodeInternal, "hello").WithDetails(StringDetail("foo", "bar"))
+	replaced := original.WithDetails(StringDetail("foo", "baz"), IntDetail("qux", 1))
+
+	s, _ := original.StringDetail("foo")
+	assert.Equal(t, "bar", s, "original Status must not be modified")
+	s, _ = replaced.StringDetail("foo")
+	assert.Equal(t, "baz", s)
+	assert.Len(t, replaced.Details(), 2)
+}
+
+func TestDetailsPreservedByWithName(t *testing.T) {
+	status := Newf(CodeInternal, "hello").
+		WithDetails(StringDetail("foo", "bar")).
+		WithCause(errors.New("great sadness")).
+		WithName("foo-bar")
+	assert.Equal(t, "foo-bar", status.Name())
+	assert.Equal(t, []Detail{StringDetail("foo", "bar")}, status.Details())
+	assert.Equal(t, Newf(CodeUnknown, "great sadness"), status.Cause())
+}
+
+func TestCause(t *testing.T) {
+	cause := Newf(CodeUnavailable, "downstream unavailable")
+	status := Newf(CodeInternal, "hello").WithCause(cause)
+	assert.Equal(t, cause, status.Cause())
+	assert.Nil(t, Newf(CodeInternal, "hello").Cause())
+}
+
+func TestRetryAfter(t *testing.T) {
+	_, ok := Newf(CodeResourceExhausted, "slow down").RetryAfter()
+	assert.False(t, ok)
+
+	status := Newf(CodeResourceExhausted, "slow down").WithRetryAfter(1500*time.Millisecond + time.Microsecond)
+	d, ok := status.RetryAfter()
+	assert.True(t, ok)
+	assert.Equal(t, 1500*time.Millisecond, d)
+
+	ms, ok := status.IntDetail(RetryAfterDetailKey)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1500), ms)
+
+	_, ok = Newf(CodeResourceExhausted, "slow down").WithDetails(IntDetail(RetryAfterDetailKey, -1)).RetryAfter()
+	assert.False(t, ok)
+}
+
+func TestDetailsNil(t *testing.T) {
+	var status *Status
+	assert.Nil(t, status.WithDetails(StringDetail("foo", "bar")))
+	assert.Nil(t, status.WithCause(errors.New("foo")))
+	assert.Nil(t, status.Details())
+	assert.Nil(t, status.Cause())
+	_, ok := status.StringDetail("foo")
+	assert.False(t, ok)
+}
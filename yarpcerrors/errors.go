@@ -68,6 +68,8 @@ type Status struct {
 	code    Code
 	name    string
 	message string
+	details []Detail
+	cause   *Status
 }
 
 // WithName returns a new Status with the given name.
@@ -80,19 +82,15 @@ type Status struct {
 //
 // Deprecated: Use only error codes to represent the type of the error.
 func (s *Status) WithName(name string) *Status {
-	// TODO: We plan to add a WithDetails method to add semantic metadata to
-	// Statuses soon.
 	if s == nil {
 		return nil
 	}
 	if err := validateName(name); err != nil {
 		return err.(*Status)
 	}
-	return &Status{
-		code:    s.code,
-		name:    name,
-		message: s.message,
-	}
+	status := s.clone()
+	status.name = name
+	return status
 }
 
 // Code returns the error code for this Status.